@@ -0,0 +1,26 @@
+/*
+Copyright 2021.
+*/
+
+// Package v1alpha1 contains the configuration API of the kube-green controller,
+// loaded from the file set with the --config flag.
+// +kubebuilder:object:generate=true
+// +kubebuilder:skip
+// +groupName=config.kube-green.com
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+var (
+	// GroupVersion is group version used to register these objects
+	GroupVersion = schema.GroupVersion{Group: "config.kube-green.com", Version: "v1alpha1"}
+
+	// SchemeBuilder is used to add go types to the GroupVersionKind scheme
+	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+
+	// AddToScheme adds the types in this group-version to the given scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+)
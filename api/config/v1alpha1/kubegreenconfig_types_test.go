@@ -0,0 +1,459 @@
+/*
+Copyright 2021.
+*/
+
+package v1alpha1
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+)
+
+func TestKubeGreenConfig(t *testing.T) {
+	t.Run("decode config file", func(t *testing.T) {
+		scheme := runtime.NewScheme()
+		require.NoError(t, AddToScheme(scheme))
+
+		content, err := os.ReadFile("testdata/config.yaml")
+		require.NoError(t, err)
+
+		config := &KubeGreenConfig{}
+		err = runtime.DecodeInto(serializer.NewCodecFactory(scheme).UniversalDecoder(), content, config)
+		require.NoError(t, err)
+
+		spec, err := config.Complete()
+		require.NoError(t, err)
+		require.Equal(t, "127.0.0.1:8080", spec.Metrics.BindAddress)
+		require.Equal(t, "2bd226ed.kube-green.com", spec.LeaderElection.ResourceName)
+
+		require.Equal(t, 50, config.GetSleepInfoConcurrency())
+		require.Equal(t, int64(120), *config.SleepDelta)
+		require.Equal(t, "Europe/Rome", config.DefaultTimeZone)
+		require.Equal(t, &RateLimiter{
+			BaseDelay:      &metav1.Duration{Duration: 10 * time.Millisecond},
+			MaxDelay:       &metav1.Duration{Duration: 5 * time.Minute},
+			QPS:            getPtr(float64(20)),
+			Burst:          getPtr(200),
+			NamespaceQPS:   getPtr(float64(2)),
+			NamespaceBurst: getPtr(5),
+		}, config.RateLimiter)
+		require.Equal(t, &Namespaces{
+			Allow: []string{"team-*"},
+			Deny:  []string{"kube-*"},
+			DenySelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"kube-green.dev/protected": "true"},
+			},
+		}, config.Namespaces)
+		require.Equal(t, []Plugin{
+			{
+				APIVersion: "example.com/v1",
+				Kind:       "MyDatabase",
+				URL:        "http://my-plugin.kube-green:8080/sleep",
+				Timeout:    &metav1.Duration{Duration: 5 * time.Second},
+			},
+		}, config.Plugins)
+		require.Equal(t, []string{"cloudnative-pg", "flux"}, config.Presets)
+		require.Equal(t, []SlackUser{
+			{ID: "U012AB3CD", Namespaces: []string{"team-a-*"}},
+		}, config.SlackUsers)
+		require.Equal(t, &Prices{Currency: "USD", CPUHour: 0.032, MemoryGiBHour: 0.004}, config.Prices)
+		require.Equal(t, &StateSecret{
+			NamePattern: "kube-green-{name}",
+			Labels:      map[string]string{"team": "platform"},
+			Annotations: map[string]string{"example.com/backup": "skip"},
+		}, config.StateSecret)
+		require.Equal(t, []string{"platform-*"}, config.ProtectedNamespaces)
+		require.Equal(t, &ClientTransport{
+			ProxyURL:  "http://proxy.example.com:3128",
+			CAFile:    "/etc/kube-green/proxy-ca.crt",
+			UserAgent: "kube-green-controller",
+		}, config.ClientTransport)
+		require.Equal(t, []RemoteCluster{
+			{Name: "dev-1", KubeconfigSecret: KubeconfigSecretRef{Namespace: "kube-green", Name: "dev-1-kubeconfig"}},
+		}, config.RemoteClusters)
+		require.Equal(t, DefaultKubeconfigSecretKey, config.RemoteClusters[0].KubeconfigSecret.GetKey())
+		require.Equal(t, &Impersonation{ServiceAccountName: "kube-green", Required: true}, config.Impersonation)
+		require.Equal(t, &OperationGate{
+			URL:           "https://policy.example.com/kube-green",
+			Timeout:       &metav1.Duration{Duration: 5 * time.Second},
+			FailurePolicy: OperationGateFailurePolicyIgnore,
+		}, config.OperationGate)
+		require.Equal(t, 5*time.Second, config.OperationGate.GetTimeout())
+		require.True(t, config.OperationGate.IsFailureIgnored())
+		require.Len(t, config.FreezeWindows, 1)
+		require.Equal(t, "release-week", config.FreezeWindows[0].Name)
+		require.True(t, config.FreezeWindows[0].Start.Equal(&metav1.Time{Time: time.Date(2024, 12, 16, 0, 0, 0, 0, time.UTC)}))
+		require.True(t, config.FreezeWindows[0].End.Equal(&metav1.Time{Time: time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC)}))
+		require.True(t, config.FreezeWindows[0].AllowWakeUp)
+	})
+
+	t.Run("freeze window", func(t *testing.T) {
+		freezeWindow := FreezeWindow{
+			Name:  "release-week",
+			Start: metav1.NewTime(time.Date(2024, 12, 16, 0, 0, 0, 0, time.UTC)),
+			End:   metav1.NewTime(time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC)),
+		}
+		require.False(t, freezeWindow.IsActive(time.Date(2024, 12, 15, 23, 59, 0, 0, time.UTC)))
+		require.True(t, freezeWindow.IsActive(time.Date(2024, 12, 16, 0, 0, 0, 0, time.UTC)))
+		require.True(t, freezeWindow.IsActive(time.Date(2024, 12, 20, 23, 59, 0, 0, time.UTC)))
+		require.False(t, freezeWindow.IsActive(time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("operation gate", func(t *testing.T) {
+		gate := OperationGate{URL: "http://policy.kube-green:8080/review"}
+		require.Equal(t, 10*time.Second, gate.GetTimeout())
+		require.False(t, gate.IsFailureIgnored())
+	})
+
+	t.Run("plugin", func(t *testing.T) {
+		plugin := Plugin{APIVersion: "example.com/v1", Kind: "MyDatabase"}
+		require.Equal(t, schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "MyDatabase"}, plugin.GetGroupVersionKind())
+		require.Equal(t, 10*time.Second, plugin.GetTimeout())
+
+		plugin.Timeout = &metav1.Duration{Duration: time.Second}
+		require.Equal(t, time.Second, plugin.GetTimeout())
+	})
+
+	t.Run("prices", func(t *testing.T) {
+		prices := Prices{Currency: "USD", CPUHour: 0.032, MemoryGiBHour: 0.004}
+		require.InDelta(t, 10*0.032+20*0.004, prices.EstimateCost(10, 20*(1<<30)), 1e-9)
+	})
+
+	t.Run("state secret name", func(t *testing.T) {
+		var stateSecret *StateSecret
+		require.Equal(t, "sleepinfo-working-hours", stateSecret.GetName("working-hours"))
+		require.Equal(t, "sleepinfo-working-hours", (&StateSecret{}).GetName("working-hours"))
+		require.Equal(t, "kube-green-working-hours-state", (&StateSecret{NamePattern: "kube-green-{name}-state"}).GetName("working-hours"))
+	})
+
+	t.Run("sleep info concurrency not set", func(t *testing.T) {
+		require.Equal(t, 0, KubeGreenConfig{}.GetSleepInfoConcurrency())
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		tests := []struct {
+			name          string
+			config        KubeGreenConfig
+			expectedError string
+		}{
+			{
+				name:   "empty config",
+				config: KubeGreenConfig{},
+			},
+			{
+				name: "valid default time zone",
+				config: KubeGreenConfig{
+					DefaultTimeZone: "America/New_York",
+				},
+			},
+			{
+				name: "invalid default time zone",
+				config: KubeGreenConfig{
+					DefaultTimeZone: "Not/Existent",
+				},
+				expectedError: "invalid defaultTimeZone: unknown time zone Not/Existent",
+			},
+			{
+				name: "negative sleep delta",
+				config: KubeGreenConfig{
+					SleepDelta: getPtr(int64(-1)),
+				},
+				expectedError: "invalid sleepDelta: must be a positive number of seconds",
+			},
+			{
+				name: "watch namespaces with namespace names filter",
+				config: KubeGreenConfig{
+					WatchNamespaces: []string{"team-a"},
+					Namespaces: &Namespaces{
+						Deny: []string{"kube-*"},
+					},
+				},
+			},
+			{
+				name: "watch namespaces with namespace label selector",
+				config: KubeGreenConfig{
+					WatchNamespaces: []string{"team-a"},
+					Namespaces: &Namespaces{
+						DenySelector: &metav1.LabelSelector{},
+					},
+				},
+				expectedError: "invalid namespaces: label selectors are not supported with watchNamespaces",
+			},
+			{
+				name: "valid plugin",
+				config: KubeGreenConfig{
+					Plugins: []Plugin{
+						{APIVersion: "example.com/v1", Kind: "MyDatabase", URL: "https://my-plugin:8443/sleep"},
+					},
+				},
+			},
+			{
+				name: "plugin without api version",
+				config: KubeGreenConfig{
+					Plugins: []Plugin{
+						{Kind: "MyDatabase", URL: "http://my-plugin/sleep"},
+					},
+				},
+				expectedError: "invalid plugins[0]: apiVersion is invalid",
+			},
+			{
+				name: "plugin without kind",
+				config: KubeGreenConfig{
+					Plugins: []Plugin{
+						{APIVersion: "example.com/v1", URL: "http://my-plugin/sleep"},
+					},
+				},
+				expectedError: "invalid plugins[0]: kind is required",
+			},
+			{
+				name: "plugin with invalid url",
+				config: KubeGreenConfig{
+					Plugins: []Plugin{
+						{APIVersion: "example.com/v1", Kind: "MyDatabase", URL: "my-plugin/sleep"},
+					},
+				},
+				expectedError: "invalid plugins[0]: url must be an http or https url",
+			},
+			{
+				name: "valid slack user",
+				config: KubeGreenConfig{
+					SlackUsers: []SlackUser{
+						{ID: "U012AB3CD", Namespaces: []string{"team-a-*"}},
+					},
+				},
+			},
+			{
+				name: "slack user without id",
+				config: KubeGreenConfig{
+					SlackUsers: []SlackUser{
+						{Namespaces: []string{"team-a-*"}},
+					},
+				},
+				expectedError: "invalid slackUsers[0]: id is required",
+			},
+			{
+				name: "slack user without namespaces",
+				config: KubeGreenConfig{
+					SlackUsers: []SlackUser{
+						{ID: "U012AB3CD"},
+					},
+				},
+				expectedError: "invalid slackUsers[0]: namespaces is required",
+			},
+			{
+				name: "slack user with invalid namespace pattern",
+				config: KubeGreenConfig{
+					SlackUsers: []SlackUser{
+						{ID: "U012AB3CD", Namespaces: []string{"team-["}},
+					},
+				},
+				expectedError: "invalid slackUsers[0]: invalid namespace pattern \"team-[\": syntax error in pattern",
+			},
+			{
+				name: "valid prices",
+				config: KubeGreenConfig{
+					Prices: &Prices{Currency: "EUR", CPUHour: 0.03},
+				},
+			},
+			{
+				name: "prices without currency",
+				config: KubeGreenConfig{
+					Prices: &Prices{CPUHour: 0.03},
+				},
+				expectedError: "invalid prices: currency is required",
+			},
+			{
+				name: "negative prices",
+				config: KubeGreenConfig{
+					Prices: &Prices{Currency: "EUR", MemoryGiBHour: -1},
+				},
+				expectedError: "invalid prices: cpuHour and memoryGiBHour must not be negative",
+			},
+			{
+				name: "valid state secret",
+				config: KubeGreenConfig{
+					StateSecret: &StateSecret{
+						NamePattern: "kube-green-state-{name}",
+						Labels:      map[string]string{"team": "platform"},
+					},
+				},
+			},
+			{
+				name: "state secret name pattern without name",
+				config: KubeGreenConfig{
+					StateSecret: &StateSecret{NamePattern: "kube-green-state"},
+				},
+				expectedError: "invalid stateSecret: namePattern must contain {name}",
+			},
+			{
+				name: "state secret with invalid name pattern",
+				config: KubeGreenConfig{
+					StateSecret: &StateSecret{NamePattern: "Kube_Green-{name}"},
+				},
+				expectedError: "invalid stateSecret: namePattern \"Kube_Green-{name}\" does not produce a valid name",
+			},
+			{
+				name: "state secret with invalid labels",
+				config: KubeGreenConfig{
+					StateSecret: &StateSecret{Labels: map[string]string{"team": "-"}},
+				},
+				expectedError: "invalid stateSecret: invalid label team=-",
+			},
+			{
+				name: "valid protected namespaces",
+				config: KubeGreenConfig{
+					ProtectedNamespaces: []string{"platform-*", "monitoring"},
+				},
+			},
+			{
+				name: "protected namespaces with invalid pattern",
+				config: KubeGreenConfig{
+					ProtectedNamespaces: []string{"platform-["},
+				},
+				expectedError: "invalid protectedNamespaces: invalid namespace pattern \"platform-[\": syntax error in pattern",
+			},
+			{
+				name: "valid client transport",
+				config: KubeGreenConfig{
+					ClientTransport: &ClientTransport{ProxyURL: "socks5://[fd00::1]:1080", UserAgent: "kube-green"},
+				},
+			},
+			{
+				name: "client transport with invalid proxy url",
+				config: KubeGreenConfig{
+					ClientTransport: &ClientTransport{ProxyURL: "ftp://proxy.example.com"},
+				},
+				expectedError: "invalid clientTransport: proxyURL must be an http, https or socks5 url",
+			},
+			{
+				name: "valid remote clusters",
+				config: KubeGreenConfig{
+					RemoteClusters: []RemoteCluster{
+						{Name: "dev-1", KubeconfigSecret: KubeconfigSecretRef{Namespace: "kube-green", Name: "dev-1", Key: "kubeconfig"}},
+						{Name: "dev-2", KubeconfigSecret: KubeconfigSecretRef{Namespace: "kube-green", Name: "dev-2"}},
+					},
+				},
+			},
+			{
+				name: "remote cluster with invalid name",
+				config: KubeGreenConfig{
+					RemoteClusters: []RemoteCluster{
+						{Name: "Dev_1", KubeconfigSecret: KubeconfigSecretRef{Namespace: "kube-green", Name: "dev-1"}},
+					},
+				},
+				expectedError: "invalid remoteClusters[0]: name \"Dev_1\" is not a valid DNS label",
+			},
+			{
+				name: "remote cluster without kubeconfig secret",
+				config: KubeGreenConfig{
+					RemoteClusters: []RemoteCluster{
+						{Name: "dev-1", KubeconfigSecret: KubeconfigSecretRef{Name: "dev-1"}},
+					},
+				},
+				expectedError: "invalid remoteClusters[0]: kubeconfigSecret namespace and name are required",
+			},
+			{
+				name: "remote clusters with duplicate name",
+				config: KubeGreenConfig{
+					RemoteClusters: []RemoteCluster{
+						{Name: "dev-1", KubeconfigSecret: KubeconfigSecretRef{Namespace: "kube-green", Name: "dev-1"}},
+						{Name: "dev-1", KubeconfigSecret: KubeconfigSecretRef{Namespace: "kube-green", Name: "dev-2"}},
+					},
+				},
+				expectedError: "invalid remoteClusters[1]: duplicate name \"dev-1\"",
+			},
+			{
+				name: "valid impersonation",
+				config: KubeGreenConfig{
+					Impersonation: &Impersonation{ServiceAccountName: "kube-green", Required: true},
+				},
+			},
+			{
+				name: "impersonation with invalid service account name",
+				config: KubeGreenConfig{
+					Impersonation: &Impersonation{ServiceAccountName: "Kube_Green"},
+				},
+				expectedError: "invalid impersonation: serviceAccountName \"Kube_Green\" is not a valid name",
+			},
+			{
+				name: "valid operation gate",
+				config: KubeGreenConfig{
+					OperationGate: &OperationGate{URL: "http://policy.kube-green:8080/review"},
+				},
+			},
+			{
+				name: "operation gate with invalid url",
+				config: KubeGreenConfig{
+					OperationGate: &OperationGate{URL: "policy.kube-green"},
+				},
+				expectedError: "invalid operationGate: url must be an http or https url",
+			},
+			{
+				name: "operation gate with invalid failure policy",
+				config: KubeGreenConfig{
+					OperationGate: &OperationGate{URL: "http://policy.kube-green:8080/review", FailurePolicy: "Skip"},
+				},
+				expectedError: "invalid operationGate: failurePolicy must be Fail or Ignore",
+			},
+			{
+				name: "valid freeze windows",
+				config: KubeGreenConfig{
+					FreezeWindows: []FreezeWindow{
+						{Name: "release-week", Start: metav1.NewTime(time.Date(2024, 12, 16, 0, 0, 0, 0, time.UTC)), End: metav1.NewTime(time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC))},
+						{Name: "audit", Start: metav1.NewTime(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)), End: metav1.NewTime(time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC))},
+					},
+				},
+			},
+			{
+				name: "freeze window without name",
+				config: KubeGreenConfig{
+					FreezeWindows: []FreezeWindow{
+						{Start: metav1.NewTime(time.Date(2024, 12, 16, 0, 0, 0, 0, time.UTC)), End: metav1.NewTime(time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC))},
+					},
+				},
+				expectedError: "invalid freezeWindows[0]: name is required",
+			},
+			{
+				name: "freeze window ending before its start",
+				config: KubeGreenConfig{
+					FreezeWindows: []FreezeWindow{
+						{Name: "release-week", Start: metav1.NewTime(time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC)), End: metav1.NewTime(time.Date(2024, 12, 16, 0, 0, 0, 0, time.UTC))},
+					},
+				},
+				expectedError: "invalid freezeWindows[0]: end must be after start",
+			},
+			{
+				name: "freeze windows with duplicate name",
+				config: KubeGreenConfig{
+					FreezeWindows: []FreezeWindow{
+						{Name: "release-week", Start: metav1.NewTime(time.Date(2024, 12, 16, 0, 0, 0, 0, time.UTC)), End: metav1.NewTime(time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC))},
+						{Name: "release-week", Start: metav1.NewTime(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)), End: metav1.NewTime(time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC))},
+					},
+				},
+				expectedError: "invalid freezeWindows[1]: duplicate name \"release-week\"",
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				_, err := test.config.Complete()
+				if test.expectedError != "" {
+					require.EqualError(t, err, test.expectedError)
+					return
+				}
+				require.NoError(t, err)
+			})
+		}
+	})
+}
+
+func getPtr[T any](item T) *T {
+	return &item
+}
@@ -0,0 +1,131 @@
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cfg "sigs.k8s.io/controller-runtime/pkg/config/v1alpha1"
+)
+
+func TestDeepCopy(t *testing.T) {
+	t.Run("kube-green config", func(t *testing.T) {
+		config := &KubeGreenConfig{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "KubeGreenConfig",
+				APIVersion: "config.kube-green.com/v1alpha1",
+			},
+			ControllerManagerConfigurationSpec: cfg.ControllerManagerConfigurationSpec{
+				Controller: &cfg.ControllerConfigurationSpec{
+					GroupKindConcurrency: map[string]int{
+						SleepInfoGroupKind: 10,
+					},
+				},
+			},
+			SleepDelta:      getPtr(int64(60)),
+			DefaultTimeZone: "Europe/Rome",
+			RateLimiter: &RateLimiter{
+				BaseDelay: &metav1.Duration{Duration: time.Millisecond},
+				MaxDelay:  &metav1.Duration{Duration: time.Second},
+				QPS:       getPtr(float64(10)),
+				Burst:     getPtr(100),
+			},
+			Namespaces: &Namespaces{
+				Allow: []string{"team-*"},
+				Deny:  []string{"kube-*"},
+				DenySelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"kube-green.dev/protected": "true"},
+				},
+			},
+			WatchNamespaces: []string{"team-a", "team-b"},
+			Plugins: []Plugin{
+				{
+					APIVersion: "example.com/v1",
+					Kind:       "MyDatabase",
+					URL:        "http://my-plugin.kube-green:8080/sleep",
+					Timeout:    &metav1.Duration{Duration: 5 * time.Second},
+				},
+			},
+			Presets: []string{"cloudnative-pg", "flux"},
+			SlackUsers: []SlackUser{
+				{ID: "U012AB3CD", Namespaces: []string{"team-a-*"}},
+			},
+			Prices: &Prices{Currency: "USD", CPUHour: 0.03, MemoryGiBHour: 0.004},
+			StateSecret: &StateSecret{
+				NamePattern: "kube-green-{name}",
+				Labels:      map[string]string{"team": "platform"},
+				Annotations: map[string]string{"example.com/backup": "skip"},
+			},
+			ProtectedNamespaces: []string{"platform-*"},
+			RemoteClusters: []RemoteCluster{
+				{Name: "dev-1", KubeconfigSecret: KubeconfigSecretRef{Namespace: "kube-green", Name: "dev-1-kubeconfig"}},
+			},
+			Impersonation: &Impersonation{ServiceAccountName: "kube-green", Required: true},
+			OperationGate: &OperationGate{
+				URL:     "https://policy.example.com/kube-green",
+				Timeout: &metav1.Duration{Duration: 5 * time.Second},
+			},
+			FreezeWindows: []FreezeWindow{
+				{
+					Name:  "release-week",
+					Start: metav1.NewTime(time.Date(2024, 12, 16, 0, 0, 0, 0, time.UTC)),
+					End:   metav1.NewTime(time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC)),
+				},
+			},
+		}
+
+		require.Equal(t, config, config.DeepCopy())
+		require.Equal(t, config, config.DeepCopyObject())
+		require.Equal(t, config.RateLimiter, config.RateLimiter.DeepCopy())
+		require.Equal(t, config.Namespaces, config.Namespaces.DeepCopy())
+		require.Equal(t, &config.Plugins[0], config.Plugins[0].DeepCopy())
+		require.Equal(t, &config.SlackUsers[0], config.SlackUsers[0].DeepCopy())
+		require.Equal(t, config.Prices, config.Prices.DeepCopy())
+		require.Equal(t, config.StateSecret, config.StateSecret.DeepCopy())
+		require.Equal(t, &config.RemoteClusters[0], config.RemoteClusters[0].DeepCopy())
+		require.Equal(t, &config.RemoteClusters[0].KubeconfigSecret, config.RemoteClusters[0].KubeconfigSecret.DeepCopy())
+		require.Equal(t, config.Impersonation, config.Impersonation.DeepCopy())
+		require.Equal(t, config.OperationGate, config.OperationGate.DeepCopy())
+		require.Equal(t, &config.FreezeWindows[0], config.FreezeWindows[0].DeepCopy())
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var config *KubeGreenConfig = nil
+		require.Nil(t, config.DeepCopy())
+		require.Nil(t, config.DeepCopyObject())
+
+		var rateLimiter *RateLimiter = nil
+		require.Nil(t, rateLimiter.DeepCopy())
+
+		var namespaces *Namespaces = nil
+		require.Nil(t, namespaces.DeepCopy())
+
+		var plugin *Plugin = nil
+		require.Nil(t, plugin.DeepCopy())
+
+		var slackUser *SlackUser = nil
+		require.Nil(t, slackUser.DeepCopy())
+
+		var prices *Prices = nil
+		require.Nil(t, prices.DeepCopy())
+
+		var stateSecret *StateSecret = nil
+		require.Nil(t, stateSecret.DeepCopy())
+
+		var remoteCluster *RemoteCluster = nil
+		require.Nil(t, remoteCluster.DeepCopy())
+
+		var kubeconfigSecret *KubeconfigSecretRef = nil
+		require.Nil(t, kubeconfigSecret.DeepCopy())
+
+		var impersonation *Impersonation = nil
+		require.Nil(t, impersonation.DeepCopy())
+
+		var operationGate *OperationGate = nil
+		require.Nil(t, operationGate.DeepCopy())
+
+		var freezeWindow *FreezeWindow = nil
+		require.Nil(t, freezeWindow.DeepCopy())
+	})
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2021.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// HourlyKubeGreenReport is the name of the KubeGreenReport over the last
+	// hour.
+	HourlyKubeGreenReport = "hourly"
+	// DailyKubeGreenReport is the name of the KubeGreenReport over the last
+	// day.
+	DailyKubeGreenReport = "daily"
+)
+
+// KubeGreenReportSpec defines the desired state of KubeGreenReport
+type KubeGreenReportSpec struct {
+	// Window is the period, ending when the report is computed, over which
+	// the operations are counted, e.g. 1h.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	Window metav1.Duration `json:"window"`
+}
+
+// SleepInfoFailure is the last operation of a SleepInfo failed in the window
+// of the report.
+type SleepInfoFailure struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Operation is the type of the failed operation, SLEEP or WAKE_UP.
+	Operation string      `json:"operation"`
+	Time      metav1.Time `json:"time"`
+	Error     string      `json:"error"`
+}
+
+// KubeGreenReportStatus defines the observed state of KubeGreenReport
+type KubeGreenReportStatus struct {
+	// LastUpdateTime is when the report was computed, the end of its window.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Last Update Time"
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+	// SleepInfos is the number of SleepInfo in the cluster.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="SleepInfos"
+	SleepInfos int32 `json:"sleepInfos,omitempty"`
+	// Namespaces is the number of namespaces with a SleepInfo.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Namespaces"
+	Namespaces int32 `json:"namespaces,omitempty"`
+	// NamespacesAsleep is the number of namespaces asleep when the report is
+	// computed, i.e. with a SleepInfo asleep.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Namespaces Asleep"
+	NamespacesAsleep int32 `json:"namespacesAsleep,omitempty"`
+	// AsleepNamespaces are the names of the namespaces asleep, sorted.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Asleep Namespaces"
+	AsleepNamespaces []string `json:"asleepNamespaces,omitempty"`
+	// Sleeps is the number of sleep operations run in the window.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Sleeps"
+	Sleeps int32 `json:"sleeps,omitempty"`
+	// WakeUps is the number of wake up operations run in the window.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Wake Ups"
+	WakeUps int32 `json:"wakeUps,omitempty"`
+	// Failures is the number of operations failed in the window.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Failures"
+	Failures int32 `json:"failures,omitempty"`
+	// FailedSleepInfos are the SleepInfo with an operation failed in the
+	// window, with their last failure.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Failed SleepInfos"
+	FailedSleepInfos []SleepInfoFailure `json:"failedSleepInfos,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=kubegreenreports,scope=Cluster
+//+kubebuilder:printcolumn:name="Window",type=string,JSONPath=`.spec.window`
+//+kubebuilder:printcolumn:name="Namespaces",type=integer,JSONPath=`.status.namespaces`
+//+kubebuilder:printcolumn:name="Asleep",type=integer,JSONPath=`.status.namespacesAsleep`
+//+kubebuilder:printcolumn:name="Sleeps",type=integer,JSONPath=`.status.sleeps`
+//+kubebuilder:printcolumn:name="Wake Ups",type=integer,JSONPath=`.status.wakeUps`
+//+kubebuilder:printcolumn:name="Failures",type=integer,JSONPath=`.status.failures`
+//+kubebuilder:printcolumn:name="Last Update",type=date,JSONPath=`.status.lastUpdateTime`
+//+operator-sdk:csv:customresourcedefinitions:displayName="KubeGreenReport"
+
+// KubeGreenReport is the Schema for the kubegreenreports API. It rolls up the
+// status of the SleepInfo of the cluster: the namespaces asleep, and the
+// operations run and failed over a window. It is updated periodically by the
+// controller.
+type KubeGreenReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KubeGreenReportSpec   `json:"spec,omitempty"`
+	Status KubeGreenReportStatus `json:"status,omitempty"`
+}
+
+// Validate returns an error if the KubeGreenReport is not valid.
+func (r KubeGreenReport) Validate() error {
+	if r.Spec.Window.Duration <= 0 {
+		return fmt.Errorf("window is invalid: must be positive")
+	}
+	return nil
+}
+
+//+kubebuilder:object:root=true
+
+// KubeGreenReportList contains a list of KubeGreenReport
+type KubeGreenReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KubeGreenReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KubeGreenReport{}, &KubeGreenReportList{})
+}
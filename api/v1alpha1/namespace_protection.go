@@ -0,0 +1,38 @@
+package v1alpha1
+
+import (
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ProtectedNamespaceLabel protects a namespace from kube-green: its SleepInfo
+// are rejected by the webhook and ignored by the controller. A namespace is
+// protected if the label is set to any value but "false".
+const ProtectedNamespaceLabel = "kube-green.dev/protected"
+
+// DefaultProtectedNamespaces are the namespaces always protected, besides
+// the ones configured.
+var DefaultProtectedNamespaces = []string{"kube-system", "kube-public"}
+
+// IsNamespaceNameProtected returns true if the name of the namespace is one of
+// the DefaultProtectedNamespaces or matches one of the glob patterns of the
+// protected namespaces.
+func IsNamespaceNameProtected(name string, protectedNamespaces []string) bool {
+	for _, pattern := range append(append([]string{}, DefaultProtectedNamespaces...), protectedNamespaces...) {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNamespaceProtected returns true if the SleepInfo are not allowed in the
+// namespace, because of its name or of the ProtectedNamespaceLabel.
+func IsNamespaceProtected(namespace *corev1.Namespace, protectedNamespaces []string) bool {
+	if IsNamespaceNameProtected(namespace.Name, protectedNamespaces) {
+		return true
+	}
+	value, ok := namespace.Labels[ProtectedNamespaceLabel]
+	return ok && value != "false"
+}
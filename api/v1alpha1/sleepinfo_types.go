@@ -7,8 +7,12 @@ package v1alpha1
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/jsonpath"
 )
 
 type ExcludeRef struct {
@@ -16,27 +20,422 @@ type ExcludeRef struct {
 	// Supported api version is "apps/v1".
 	APIVersion string `json:"apiVersion,omitempty"`
 	// Kind of the kubernetes resources of the specific version.
-	// Supported kind are "Deployment" and "CronJob".
+	// Supported kind are "Deployment", "CronJob", "Job", "ReplicaSet", "ReplicationController", "DaemonSet", "PodDisruptionBudget" and "Pod".
 	Kind string `json:"kind,omitempty"`
 	// Name which identify the kubernetes resource.
 	// +optional
 	Name string `json:"name,omitempty"`
+	// NamePattern is a regular expression which identifies the kubernetes
+	// resources of the Kind by name, e.g. preview-.* for the names generated
+	// by the preview environments. It must match the whole name.
+	// +optional
+	NamePattern string `json:"namePattern,omitempty"`
+	// NameGlob is a glob pattern which identifies the kubernetes resources of
+	// the Kind by name, e.g. preview-*.
+	// +optional
+	NameGlob string `json:"nameGlob,omitempty"`
 	// MatchLabels which identify the kubernetes resource by labels
 	// +optional
 	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+	// OwnerKind identifies the kubernetes resources by the kind of their
+	// owner, e.g. Rollout, so that the resources managed by a controller which
+	// would undo the changes are not put to sleep. If Kind is set, only the
+	// resources of that kind are excluded.
+	// +optional
+	OwnerKind string `json:"ownerKind,omitempty"`
+	// MatchField identifies the kubernetes resources by the value of a field,
+	// e.g. the Deployments with a critical priority class. If Kind is set, only
+	// the resources of that kind are excluded.
+	// +optional
+	MatchField *FieldMatcher `json:"matchField,omitempty"`
+}
+
+// FieldMatcher matches the kubernetes resources by the value of a field.
+type FieldMatcher struct {
+	// JSONPath of the field, e.g. {.spec.template.spec.priorityClassName}.
+	JSONPath string `json:"jsonPath"`
+	// Values of the field of the matched resources. If empty, the resources
+	// where the field is set to a non empty value are matched.
+	// +optional
+	Values []string `json:"values,omitempty"`
+}
+
+// CronJobsSelector selects by labels the CronJobs to suspend.
+type CronJobsSelector struct {
+	// Include selects the CronJobs to suspend. If not set, all the CronJobs
+	// of the namespace are suspended.
+	// +optional
+	Include *metav1.LabelSelector `json:"include,omitempty"`
+	// Exclude selects the CronJobs to not suspend. It takes precedence over Include.
+	// +optional
+	Exclude *metav1.LabelSelector `json:"exclude,omitempty"`
+}
+
+const (
+	// SleepConditionFailurePolicyOpen puts the namespace to sleep if the condition cannot be evaluated.
+	SleepConditionFailurePolicyOpen = "Open"
+	// SleepConditionFailurePolicyClosed skips the sleep if the condition cannot be evaluated.
+	SleepConditionFailurePolicyClosed = "Closed"
+)
+
+// SleepCondition is a condition which must be true to put the namespace to sleep.
+type SleepCondition struct {
+	// PrometheusQuery is the PromQL expression evaluated at sleep time, e.g.
+	// sum(rate(http_requests_total{namespace="my-namespace"}[30m])) == 0.
+	// The condition is true if the query returns at least one sample, or a
+	// scalar different from 0.
+	PrometheusQuery string `json:"prometheusQuery"`
+	// FailurePolicy defines what happens if the query fails: with Open the
+	// namespace is put to sleep, with Closed the sleep is skipped. Default to Closed.
+	// +kubebuilder:validation:Enum=Open;Closed
+	// +optional
+	FailurePolicy string `json:"failurePolicy,omitempty"`
+}
+
+// IdleSleep configures the sleep of the idle Deployments, detected with the
+// Prometheus metrics of their pods.
+type IdleSleep struct {
+	// Period is how long a Deployment must be idle before being put to sleep, e.g. 2h.
+	Period metav1.Duration `json:"period"`
+	// CPUThreshold is the CPU usage, in cores, under which a Deployment is idle.
+	// Default to 10m.
+	// +optional
+	CPUThreshold *resource.Quantity `json:"cpuThreshold,omitempty"`
+	// NetworkThreshold is the received network traffic, in bytes per second,
+	// under which a Deployment is idle. If not set, the network traffic is not
+	// taken into account.
+	// +optional
+	NetworkThreshold *resource.Quantity `json:"networkThreshold,omitempty"`
+}
+
+// SleepInfoReference references a SleepInfo, or all the SleepInfo of a namespace.
+type SleepInfoReference struct {
+	// Namespace of the referenced SleepInfo. Default to the namespace of the
+	// referencing SleepInfo.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Name of the referenced SleepInfo. If not set, all the SleepInfo of the
+	// namespace are referenced.
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+// SleepPolicy configures which resources are skipped by the sleep operation,
+// and how the sleep is kept.
+type SleepPolicy struct {
+	// Enforce suspends again the CronJobs put to sleep, if they are resumed
+	// while the namespace is sleeping.
+	// +optional
+	Enforce bool `json:"enforce,omitempty"`
+	// MinAgeBeforeSleep is the minimum time since the last update of a Deployment
+	// before it is put to sleep, e.g. 30m. The Deployments updated more recently
+	// (e.g. a fresh deploy under test) are skipped by the sleep.
+	// +optional
+	MinAgeBeforeSleep *metav1.Duration `json:"minAgeBeforeSleep,omitempty"`
+	// Strategy is how the Deployments are put to sleep: scaleToZero scales
+	// them to zero replicas, pauseRollout pauses their rollout without
+	// deleting their pods, patchOnly only annotates them as sleeping, e.g.
+	// for an external controller, and keepOne keeps one replica alive, so
+	// that health checks keep working. It can be overridden for a single
+	// Deployment with the kube-green.dev/sleep-strategy annotation. Default
+	// to scaleToZero.
+	// +kubebuilder:validation:Enum=scaleToZero;pauseRollout;patchOnly;keepOne
+	// +optional
+	Strategy string `json:"strategy,omitempty"`
+	// TerminationCheck checks that the pods terminate after the sleep: the
+	// pods stuck terminating, e.g. because of their finalizers or of the
+	// unmount of their volumes, keep their nodes alive.
+	// +optional
+	TerminationCheck *PodTerminationCheck `json:"terminationCheck,omitempty"`
+}
+
+// PodTerminationCheck configures the check of the pods still terminating
+// after the sleep.
+type PodTerminationCheck struct {
+	// Timeout is the time after the sleep within which the pods must
+	// terminate. The pods still terminating after the timeout and after their
+	// grace period are stuck: an event is recorded on the SleepInfo. Default
+	// to 5m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// ForceDelete deletes the pods stuck terminating, removing their
+	// finalizers and without grace period.
+	// +optional
+	ForceDelete bool `json:"forceDelete,omitempty"`
+}
+
+// SleepStrategyAnnotation sets the sleep strategy of a Deployment, overriding
+// the strategy of the SleepPolicy.
+const SleepStrategyAnnotation = "kube-green.dev/sleep-strategy"
+
+const (
+	// SleepStrategyScaleToZero puts the Deployments to sleep scaling them to
+	// zero replicas, or to the replicas of their sleep class.
+	SleepStrategyScaleToZero = "scaleToZero"
+	// SleepStrategyPauseRollout puts the Deployments to sleep pausing their
+	// rollout, without deleting their pods.
+	SleepStrategyPauseRollout = "pauseRollout"
+	// SleepStrategyPatchOnly puts the Deployments to sleep only annotating
+	// them as sleeping.
+	SleepStrategyPatchOnly = "patchOnly"
+	// SleepStrategyKeepOne puts the Deployments to sleep scaling them to one
+	// replica, kept alive as canary until the wake up.
+	SleepStrategyKeepOne = "keepOne"
+)
+
+const (
+	// ReplicasSourceSnapshot restores the replicas saved when the namespace is
+	// put to sleep.
+	ReplicasSourceSnapshot = "Snapshot"
+	// ReplicasSourceDeclared restores the replicas declared on the cluster at
+	// wake up time.
+	ReplicasSourceDeclared = "Declared"
+	// ReplicasSourceSnapshotAtLeastMin restores the replicas saved when the
+	// namespace is put to sleep, raised to the minReplicas of the
+	// HorizontalPodAutoscaler at wake up time.
+	ReplicasSourceSnapshotAtLeastMin = "SnapshotAtLeastMin"
+	// ReplicasSourceAutoscaler hands the replicas back to the
+	// HorizontalPodAutoscaler, restoring its minReplicas at wake up time.
+	ReplicasSourceAutoscaler = "Autoscaler"
+)
+
+const (
+	// AlreadyZeroKeep keeps at zero replicas, on wake up, the Deployments
+	// which were already at zero replicas before the sleep.
+	AlreadyZeroKeep = "Keep"
+	// AlreadyZeroRestore restores the Deployments which were already at zero
+	// replicas before the sleep as the other Deployments.
+	AlreadyZeroRestore = "Restore"
+)
+
+// WakeUpPolicy configures how the resources are restored by the wake up operation.
+type WakeUpPolicy struct {
+	// ReplicasSource is where the replicas of the Deployments are restored from.
+	// With Snapshot, they are restored to the replicas saved at sleep time.
+	// With Declared, they are restored to the replicas set in the
+	// kube-green.dev/desired-replicas annotation of the Deployment (e.g. by
+	// the CI) or, if not set, to the minReplicas of the HorizontalPodAutoscaler
+	// targeting it, falling back to the saved replicas. The Deployments
+	// targeted by a HorizontalPodAutoscaler are restored, with
+	// SnapshotAtLeastMin, to the saved replicas but at least to its
+	// minReplicas and, with Autoscaler, to its minReplicas, so that the
+	// autoscaler scales them from there. Default to Snapshot.
+	// +kubebuilder:validation:Enum=Snapshot;Declared;SnapshotAtLeastMin;Autoscaler
+	// +optional
+	ReplicasSource string `json:"replicasSource,omitempty"`
+	// AlreadyZero is what the wake up does with the Deployments which were
+	// already at zero replicas before the sleep, e.g. turned off on purpose.
+	// With Keep, they stay at zero replicas. With Restore, they are restored
+	// as the other Deployments, i.e. to their declared replicas if the
+	// replicas source is Declared. Default to Keep.
+	// +kubebuilder:validation:Enum=Keep;Restore
+	// +optional
+	AlreadyZero string `json:"alreadyZero,omitempty"`
+	// Verification is a smoke test run after the wake up: if it does not
+	// pass, the wake up is marked as failed.
+	// +optional
+	Verification *WakeUpVerification `json:"verification,omitempty"`
+}
+
+const (
+	// WakeUpVerificationPending is the result of the verification of a wake
+	// up not yet completed.
+	WakeUpVerificationPending = "Pending"
+	// WakeUpVerificationPassed is the result of a passed verification.
+	WakeUpVerificationPassed = "Passed"
+	// WakeUpVerificationFailed is the result of a failed verification.
+	WakeUpVerificationFailed = "Failed"
+)
+
+const (
+	// SleepIncompleteCondition is the condition of the SleepInfo which is true
+	// if some pods of the resources put to sleep are still running after the
+	// sleep, e.g. because they have been scaled up again by a controller not
+	// handled by kube-green.
+	SleepIncompleteCondition = "SleepIncomplete"
+	// SleepIncompleteReasonResidualPods is the reason of the incomplete sleep.
+	SleepIncompleteReasonResidualPods = "ResidualPods"
+	// SleepIncompleteReasonNoResidualPods is the reason of the complete sleep.
+	SleepIncompleteReasonNoResidualPods = "NoResidualPods"
+)
+
+const (
+	// OperationFailedCondition is the condition of the SleepInfo which is true
+	// if its last reconcile failed, with the reason of the failure, so that
+	// the alerts can distinguish an invalid schedule from a permission denied
+	// or a transient error of the API server.
+	OperationFailedCondition = "OperationFailed"
+	// OperationFailedReasonInvalidSchedule is the reason of the failure of a
+	// schedule which cannot be computed.
+	OperationFailedReasonInvalidSchedule = "InvalidSchedule"
+	// OperationFailedReasonForbidden is the reason of the failure of a request
+	// denied by the RBAC of the API server.
+	OperationFailedReasonForbidden = "Forbidden"
+	// OperationFailedReasonThrottled is the reason of the failure of a request
+	// throttled by the API server.
+	OperationFailedReasonThrottled = "Throttled"
+	// OperationFailedReasonTransientAPIError is the reason of the failure of a
+	// request because of a transient error of the API server, e.g. a timeout
+	// or a conflict, which is solved by the retry.
+	OperationFailedReasonTransientAPIError = "TransientAPIError"
+	// OperationFailedReasonStateStoreFailed is the reason of the failure in
+	// reading or writing the Secret where the state is stored.
+	OperationFailedReasonStateStoreFailed = "StateStoreFailed"
+	// OperationFailedReasonPatchFailed is the reason of the failure of the
+	// patch of a resource put to sleep or woken up.
+	OperationFailedReasonPatchFailed = "PatchFailed"
+	// OperationFailedReasonUnknown is the reason of the other failures.
+	OperationFailedReasonUnknown = "Unknown"
+	// OperationFailedReasonSucceeded is the reason of the condition false.
+	OperationFailedReasonSucceeded = "Succeeded"
+)
+
+const (
+	// OperationQueuedCondition is the condition of the SleepInfo which is true
+	// if its operation is waiting for the operation of another SleepInfo of
+	// the same namespace, e.g. a wake up requested by hand colliding with a
+	// scheduled sleep, with the operation it is queued behind in the message.
+	OperationQueuedCondition = "OperationQueued"
+	// OperationQueuedReasonNamespaceLocked is the reason of the queued
+	// operation.
+	OperationQueuedReasonNamespaceLocked = "NamespaceLocked"
+	// OperationQueuedReasonLockAcquired is the reason of the condition false,
+	// once the queued operation acquired the lock of the namespace.
+	OperationQueuedReasonLockAcquired = "LockAcquired"
+)
+
+// WakeUpVerification is a smoke test run after the wake up, to detect the
+// broken wake ups before the users arrive. Exactly one of url and
+// jobFromCronJob must be set.
+type WakeUpVerification struct {
+	// URL is checked with a GET request: the verification passes when it
+	// responds with a 2xx status code.
+	// +optional
+	URL string `json:"url,omitempty"`
+	// JobFromCronJob is the name of a CronJob of the namespace, e.g. a
+	// suspended one, whose job template is run as a Job: the verification
+	// passes when the Job completes.
+	// +optional
+	JobFromCronJob string `json:"jobFromCronJob,omitempty"`
+	// Delay is the time after the wake up before the verification starts,
+	// to let the pods start. Default to 1m.
+	// +optional
+	Delay *metav1.Duration `json:"delay,omitempty"`
+	// Timeout is the time after the start of the verification within which
+	// it must pass, otherwise the wake up is marked as failed. Default to 10m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// Expiry configures the cleanup of the namespaces left asleep for too long,
+// e.g. the stale preview environments.
+type Expiry struct {
+	// After is how long the namespace must be continuously asleep before it
+	// expires, e.g. 168h for a week.
+	After metav1.Duration `json:"after"`
+	// WebhookURL is notified with a POST request when the namespace expires,
+	// e.g. to delete the preview environment.
+	// +optional
+	WebhookURL string `json:"webhookURL,omitempty"`
+	// DeleteSelector selects by labels the Deployments, StatefulSets,
+	// CronJobs, Jobs and Services of the namespace deleted when it expires.
+	// If not set, no resource is deleted.
+	// +optional
+	DeleteSelector *metav1.LabelSelector `json:"deleteSelector,omitempty"`
+}
+
+// SleepClassLabel sets the sleep class of a workload, e.g. batch, web or
+// critical, which selects how it is put to sleep by the SleepInfo with classes.
+const SleepClassLabel = "kube-green.dev/class"
+
+// SleepClass configures how the workloads of a class are put to sleep.
+type SleepClass struct {
+	// Name of the class, i.e. the value of the kube-green.dev/class label of
+	// its workloads.
+	Name string `json:"name"`
+	// Replicas the Deployments of the class are scaled to on sleep, e.g. 1
+	// to keep a single replica of a web frontend. Default to 0.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+	// If Skip is set to true, the workloads of the class are not put to sleep.
+	// +optional
+	Skip bool `json:"skip,omitempty"`
+}
+
+const (
+	// RejectionPolicyFail fails the operation if the patch of a resource is
+	// rejected by an admission webhook.
+	RejectionPolicyFail = "Fail"
+	// RejectionPolicySkip skips the resources whose patch is rejected by an
+	// admission webhook.
+	RejectionPolicySkip = "Skip"
+)
+
+const (
+	// SchedulePresetOfficeHours keeps the namespace awake from 08:00 to 20:00,
+	// from monday to friday.
+	SchedulePresetOfficeHours = "officeHours"
+	// SchedulePresetExtendedOfficeHours keeps the namespace awake from 07:00
+	// to 22:00, from monday to friday.
+	SchedulePresetExtendedOfficeHours = "extendedOfficeHours"
+	// SchedulePresetNights puts the namespace to sleep from 22:00 to 06:00,
+	// every day.
+	SchedulePresetNights = "nights"
+)
+
+// schedulePreset is the schedule a preset expands into.
+type schedulePreset struct {
+	weekdays   string
+	sleepTime  string
+	wakeUpTime string
+}
+
+var schedulePresets = map[string]schedulePreset{
+	SchedulePresetOfficeHours:         {weekdays: "1-5", sleepTime: "20:00", wakeUpTime: "08:00"},
+	SchedulePresetExtendedOfficeHours: {weekdays: "1-5", sleepTime: "22:00", wakeUpTime: "07:00"},
+	SchedulePresetNights:              {weekdays: "*", sleepTime: "22:00", wakeUpTime: "06:00"},
 }
 
 // SleepInfoSpec defines the desired state of SleepInfo
 type SleepInfoSpec struct {
+	// Preset is a named schedule, which sets the weekdays, sleepAt and wakeUpAt
+	// not set in the SleepInfo. The presets are: officeHours (awake from 08:00
+	// to 20:00, from monday to friday), extendedOfficeHours (awake from 07:00
+	// to 22:00, from monday to friday) and nights (asleep from 22:00 to 06:00,
+	// every day).
+	// +kubebuilder:validation:Enum=officeHours;extendedOfficeHours;nights
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	Preset string `json:"preset,omitempty"`
 	// Weekdays are in cron notation.
 	//
-	// For example, to configure a schedule from monday to friday, set it to "1-5"
+	// For example, to configure a schedule from monday to friday, set it to "1-5".
+	// The last and the nth weekday of the month are set with L and #, e.g.
+	// "5L" is the last friday of the month and "1#2" is its second monday.
+	// It is required if the preset is not set.
+	// +optional
 	//+operator-sdk:csv:customresourcedefinitions:type=spec
 	Weekdays string `json:"weekdays"`
+	// SleepWeekdays are the weekdays of the sleep, in cron notation as the
+	// weekdays, if different from the ones of the wake up. For example, to
+	// sleep on friday night and wake up on monday morning, set it to "5" and
+	// the wakeUpWeekdays to "1". If not set, the weekdays are used.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	SleepWeekdays string `json:"sleepWeekdays,omitempty"`
+	// WakeUpWeekdays are the weekdays of the wake up, in cron notation as the
+	// weekdays, if different from the ones of the sleep. The sleeps and the
+	// wake ups must alternate. If not set, the weekdays are used.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	WakeUpWeekdays string `json:"wakeUpWeekdays,omitempty"`
 	// Hours:Minutes
 	//
 	// Accept cron schedule for both hour and minute.
 	// For example, *:*/2 is set to configure a run every even minute.
+	// It is required if the preset is not set.
+	// +optional
 	//+operator-sdk:csv:customresourcedefinitions:type=spec
 	SleepTime string `json:"sleepAt"`
 	// Hours:Minutes
@@ -61,10 +460,201 @@ type SleepInfoSpec struct {
 	// +optional
 	//+operator-sdk:csv:customresourcedefinitions:type=spec
 	SuspendCronjobs bool `json:"suspendCronJobs,omitempty"`
+	// CronJobsSelector selects by labels the CronJobs to suspend, independently
+	// of the Deployments. If set, on sleep the selected cronjobs of the namespace
+	// will be suspended, even if SuspendCronjobs is not set.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	CronJobsSelector *CronJobsSelector `json:"cronJobsSelector,omitempty"`
 	// If SuspendDeployments is set to false, on sleep the deployment of the namespace will not be suspended. By default Deployment will be suspended.
 	// +optional
 	//+operator-sdk:csv:customresourcedefinitions:type=spec
 	SuspendDeployments *bool `json:"suspendDeployments,omitempty"`
+	// If SuspendJobs is set to true, on sleep the jobs of the namespace not owned by
+	// other resources (e.g. CronJobs) will be suspended.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	SuspendJobs bool `json:"suspendJobs,omitempty"`
+	// If SuspendReplicaSets is set to true, on sleep the ReplicaSets and the
+	// ReplicationControllers of the namespace not owned by other resources
+	// (e.g. Deployments) will be scaled to 0.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	SuspendReplicaSets bool `json:"suspendReplicaSets,omitempty"`
+	// If SuspendDaemonSets is set to true, on sleep the DaemonSets of the namespace
+	// not owned by other resources will be stopped, patching their node selector
+	// so that no node matches it.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	SuspendDaemonSets bool `json:"suspendDaemonSets,omitempty"`
+	// If SuspendPodDisruptionBudgets is set to true, on sleep the
+	// PodDisruptionBudgets of the namespace will be relaxed, setting their
+	// minAvailable to 0, so that the workloads scaled to 0 do not raise
+	// alerts or block the node drains. They are restored on wake up.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	SuspendPodDisruptionBudgets bool `json:"suspendPodDisruptionBudgets,omitempty"`
+	// If SuspendCustomResources is set to true, on sleep the custom resources of
+	// the supported operators (e.g. Strimzi KafkaConnect, ECK Kibana, Percona
+	// database clusters) in the namespace will be suspended.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	SuspendCustomResources bool `json:"suspendCustomResources,omitempty"`
+	// If DeleteStandalonePods is set to true, on sleep the pods of the
+	// namespace not owned by other resources (e.g. the debug pods and the pods
+	// created with kubectl run) will be deleted. They are recorded in the
+	// operations history, but they are not restored on wake up.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	DeleteStandalonePods bool `json:"deleteStandalonePods,omitempty"`
+	// SleepCondition is an optional condition evaluated at sleep time: if it is
+	// false, the sleep is skipped. The Prometheus url is set in the controller.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	SleepCondition *SleepCondition `json:"sleepCondition,omitempty"`
+	// IdleSleep enables the sleep of the Deployments which are idle, while the
+	// namespace is awake. The idle Deployments wake up with the wake up schedule,
+	// or when they are scaled up manually.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	IdleSleep *IdleSleep `json:"idleSleep,omitempty"`
+	// SleepPolicy configures which resources are skipped by the sleep operation,
+	// and how the sleep is kept.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	SleepPolicy *SleepPolicy `json:"sleepPolicy,omitempty"`
+	// WakeUpPolicy configures how the resources are restored by the wake up operation.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	WakeUpPolicy *WakeUpPolicy `json:"wakeUpPolicy,omitempty"`
+	// RejectionPolicy defines what happens if an admission webhook rejects the
+	// patch of a resource: with Fail the operation fails, with Skip the
+	// resource is skipped and reported in the operations history, and the
+	// operation goes on with the other resources. Default to Fail.
+	// +kubebuilder:validation:Enum=Fail;Skip
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	RejectionPolicy string `json:"rejectionPolicy,omitempty"`
+	// DependsOn lists the SleepInfo which must be awake when this SleepInfo
+	// wakes up, e.g. the SleepInfo of a shared backend namespace: if they are
+	// sleeping, their wake up is requested together with the wake up of this one.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	DependsOn []SleepInfoReference `json:"dependsOn,omitempty"`
+	// SleepGroup is the name of the sleep group of the SleepInfo. The SleepInfo
+	// of the same group, also in different namespaces, sleep and wake up as a
+	// unit: when a member wakes up the others are woken up, and if the sleep of
+	// a member fails the whole group is woken up again.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	SleepGroup string `json:"sleepGroup,omitempty"`
+	// Classes are the sleep classes managed by the SleepInfo. If set, only the
+	// workloads labeled with kube-green.dev/class set to one of the classes are
+	// managed, each one put to sleep as configured by its class.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	Classes []SleepClass `json:"classes,omitempty"`
+	// Expiry makes the namespace expire when it is continuously asleep for
+	// too long: an Expired event is recorded, the webhook is notified and
+	// the selected resources are deleted.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	Expiry *Expiry `json:"expiry,omitempty"`
+	// ServiceAccountName is the ServiceAccount of the namespace impersonated
+	// by the controller to handle the resources, so that it acts only with the
+	// permissions delegated to it. If empty, the ServiceAccount of the
+	// controller configuration is used, if any.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+}
+
+// OperationHistory is the summary of an operation performed on the namespace
+type OperationHistory struct {
+	// The operation type. SLEEP or WAKE_UP are the possibilities
+	Type string `json:"type"`
+	// Information when the operation was performed.
+	Time metav1.Time `json:"time"`
+	// The number of resources handled by the operation, grouped by kind.
+	// +optional
+	ResourceCounts map[string]int `json:"resourceCounts,omitempty"`
+	// The error of the operation, if it fails.
+	// +optional
+	Error string `json:"error,omitempty"`
+	// The pods running in the namespace before the operation, recorded when
+	// the sleep reports or the warm up of the nodes are enabled.
+	// +optional
+	RunningPods *RunningPods `json:"runningPods,omitempty"`
+	// The resources skipped by the operation because their patch timed out or
+	// was rejected by an admission webhook.
+	// +optional
+	FailedResources []FailedResource `json:"failedResources,omitempty"`
+	// The resources changed during the sleep, found by the wake up comparing
+	// their state stored by the sleep with the live one.
+	// +optional
+	Diffs []ResourceDiff `json:"diffs,omitempty"`
+	// The result of the verification of the wake up, if configured: Pending,
+	// Passed or Failed. If it fails, its reason is the error of the operation.
+	// +optional
+	Verification string `json:"verification,omitempty"`
+	// The result of the Job of the verification of the wake up, if run from
+	// the job template of a CronJob.
+	// +optional
+	VerificationJob *JobResult `json:"verificationJob,omitempty"`
+}
+
+// JobResult is the result of a Job run by kube-green.
+type JobResult struct {
+	// The name of the Job.
+	Name string `json:"name"`
+	// The number of pods of the Job which succeeded.
+	// +optional
+	Succeeded int32 `json:"succeeded,omitempty"`
+	// The number of pods of the Job which failed.
+	// +optional
+	Failed int32 `json:"failed,omitempty"`
+	// The message of the condition which finished the Job.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// FailedResource is a resource skipped by an operation because it fails to be
+// handled, so that it does not block the other resources.
+type FailedResource struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Why the resource failed.
+	Reason string `json:"reason"`
+}
+
+// ResourceDiff is the difference between the state of a resource stored by the
+// sleep and its live state at the wake up.
+type ResourceDiff struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// What changed during the sleep, e.g. the pod template of a Deployment.
+	Diff string `json:"diff"`
+}
+
+// RunningPods is the summary of the pods running in a namespace.
+type RunningPods struct {
+	// The number of running pods.
+	Count int `json:"count"`
+	// The sum of the resources requested by the running pods.
+	// +optional
+	Requests v1.ResourceList `json:"requests,omitempty"`
+}
+
+// SleepRecord is the record of an operation from which the sleep reports are
+// computed.
+type SleepRecord struct {
+	// The operation type. SLEEP or WAKE_UP are the possibilities
+	Type string `json:"type"`
+	// Information when the operation was performed.
+	Time metav1.Time `json:"time"`
+	// The pods running in the namespace before the operation.
+	// +optional
+	RunningPods *RunningPods `json:"runningPods,omitempty"`
 }
 
 // SleepInfoStatus defines the observed state of SleepInfo
@@ -78,6 +668,36 @@ type SleepInfoStatus struct {
 	// +optional
 	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Operation Type"
 	OperationType string `json:"operation,omitempty"`
+	// ObservedGeneration is the generation of the spec last handled by the
+	// controller. When the spec changes, e.g. the schedule is edited while
+	// the namespace is sleeping, the namespace is woken up if the new schedule
+	// says that it should be awake.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Observed Generation"
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// FrozenBy is the name of the change freeze window which is skipping the
+	// scheduled operations of the SleepInfo, empty if none.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Frozen By"
+	FrozenBy string `json:"frozenBy,omitempty"`
+	// The last operations performed, from the oldest to the most recent.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Operations History"
+	OperationsHistory []OperationHistory `json:"operationsHistory,omitempty"`
+	// The records of the last operations, from the oldest to the most recent,
+	// from which the sleep reports are computed. They cover more operations
+	// than the operations history, and are recorded when the sleep reports or
+	// the warm up of the nodes are enabled.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Sleep Records"
+	SleepRecords []SleepRecord `json:"sleepRecords,omitempty"`
+	// Conditions are the conditions of the SleepInfo, e.g. SleepIncomplete
+	// when some pods are still running after the sleep.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Conditions"
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -96,22 +716,109 @@ type SleepInfo struct {
 }
 
 func (s SleepInfo) GetSleepSchedule() (string, error) {
-	return s.getScheduleFromWeekdayAndTime(s.Spec.SleepTime)
+	return s.getScheduleFromWeekdayAndTime(s.getSleepWeekdays(), s.getSleepTime())
 }
 
 func (s SleepInfo) GetWakeUpSchedule() (string, error) {
-	if s.Spec.WakeUpTime == "" {
+	wakeUpTime := s.getWakeUpTime()
+	if wakeUpTime == "" {
 		return "", nil
 	}
-	return s.getScheduleFromWeekdayAndTime(s.Spec.WakeUpTime)
+	return s.getScheduleFromWeekdayAndTime(s.getWakeUpWeekdays(), wakeUpTime)
+}
+
+// getPreset returns the schedule of the preset of the SleepInfo. It returns
+// an error if the preset is not supported.
+func (s SleepInfo) getPreset() (schedulePreset, error) {
+	if s.Spec.Preset == "" {
+		return schedulePreset{}, nil
+	}
+	preset, ok := schedulePresets[s.Spec.Preset]
+	if !ok {
+		return schedulePreset{}, fmt.Errorf("preset %s not supported: must be one of %s, %s or %s", s.Spec.Preset, SchedulePresetOfficeHours, SchedulePresetExtendedOfficeHours, SchedulePresetNights)
+	}
+	return preset, nil
+}
+
+func (s SleepInfo) getWeekdays() string {
+	if s.Spec.Weekdays != "" {
+		return s.Spec.Weekdays
+	}
+	preset, _ := s.getPreset()
+	return preset.weekdays
+}
+
+// getSleepWeekdays returns the weekdays of the sleep: the sleepWeekdays if
+// set, otherwise the weekdays.
+func (s SleepInfo) getSleepWeekdays() string {
+	if s.Spec.SleepWeekdays != "" {
+		return s.Spec.SleepWeekdays
+	}
+	return s.getWeekdays()
+}
+
+// getWakeUpWeekdays returns the weekdays of the wake up: the wakeUpWeekdays if
+// set, otherwise the weekdays.
+func (s SleepInfo) getWakeUpWeekdays() string {
+	if s.Spec.WakeUpWeekdays != "" {
+		return s.Spec.WakeUpWeekdays
+	}
+	return s.getWeekdays()
+}
+
+// HasWeekdaysPerOperation returns true if the sleep and the wake up are on
+// different weekdays, e.g. sleep on friday and wake up on monday.
+func (s SleepInfo) HasWeekdaysPerOperation() bool {
+	return s.getSleepWeekdays() != s.getWakeUpWeekdays()
+}
+
+func (s SleepInfo) getSleepTime() string {
+	if s.Spec.SleepTime != "" {
+		return s.Spec.SleepTime
+	}
+	preset, _ := s.getPreset()
+	return preset.sleepTime
+}
+
+func (s SleepInfo) getWakeUpTime() string {
+	if s.Spec.WakeUpTime != "" {
+		return s.Spec.WakeUpTime
+	}
+	preset, _ := s.getPreset()
+	return preset.wakeUpTime
+}
+
+// IsCrossMidnight returns true if the sleep window crosses the midnight, i.e.
+// the wake up time is earlier in the day than the sleep time (e.g. sleep at
+// 22:00 and wake up at 06:00). In this case the wake up happens the day after
+// the sleep. Only times with fixed hour and minute are considered.
+func (s SleepInfo) IsCrossMidnight() bool {
+	sleepAt, ok := getMinutesOfDay(s.getSleepTime())
+	if !ok {
+		return false
+	}
+	wakeUpAt, ok := getMinutesOfDay(s.getWakeUpTime())
+	if !ok {
+		return false
+	}
+	return wakeUpAt < sleepAt
+}
+
+// getMinutesOfDay returns the minutes from the midnight of a time in the HH:mm
+// format. It returns false if the time is not fixed, e.g. it is "*:30".
+func getMinutesOfDay(hourAndMinute string) (int, bool) {
+	t, err := time.Parse("15:04", hourAndMinute)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
 }
 
 func (s SleepInfo) GetExcludeRef() []ExcludeRef {
 	return s.Spec.ExcludeRef
 }
 
-func (s SleepInfo) getScheduleFromWeekdayAndTime(hourAndMinute string) (string, error) {
-	weekday := s.Spec.Weekdays
+func (s SleepInfo) getScheduleFromWeekdayAndTime(weekday, hourAndMinute string) (string, error) {
 	if weekday == "" {
 		return "", fmt.Errorf("empty weekdays from SleepInfo configuration")
 	}
@@ -129,7 +836,15 @@ func (s SleepInfo) getScheduleFromWeekdayAndTime(hourAndMinute string) (string,
 }
 
 func (s SleepInfo) IsCronjobsToSuspend() bool {
-	return s.Spec.SuspendCronjobs
+	return s.Spec.SuspendCronjobs || s.Spec.CronJobsSelector != nil
+}
+
+// GetCronJobsSelector returns the selectors of the CronJobs to suspend.
+func (s SleepInfo) GetCronJobsSelector() CronJobsSelector {
+	if s.Spec.CronJobsSelector == nil {
+		return CronJobsSelector{}
+	}
+	return *s.Spec.CronJobsSelector
 }
 
 func (s SleepInfo) IsDeploymentsToSuspend() bool {
@@ -139,6 +854,247 @@ func (s SleepInfo) IsDeploymentsToSuspend() bool {
 	return *s.Spec.SuspendDeployments
 }
 
+func (s SleepInfo) IsJobsToSuspend() bool {
+	return s.Spec.SuspendJobs
+}
+
+func (s SleepInfo) IsReplicaSetsToSuspend() bool {
+	return s.Spec.SuspendReplicaSets
+}
+
+func (s SleepInfo) IsDaemonSetsToSuspend() bool {
+	return s.Spec.SuspendDaemonSets
+}
+
+func (s SleepInfo) IsPodDisruptionBudgetsToSuspend() bool {
+	return s.Spec.SuspendPodDisruptionBudgets
+}
+
+func (s SleepInfo) IsStandalonePodsToDelete() bool {
+	return s.Spec.DeleteStandalonePods
+}
+
+func (s SleepInfo) IsCustomResourcesToSuspend() bool {
+	return s.Spec.SuspendCustomResources
+}
+
+// GetSleepCondition returns the condition to put the namespace to sleep, or
+// nil if not set.
+func (s SleepInfo) GetSleepCondition() *SleepCondition {
+	return s.Spec.SleepCondition
+}
+
+// GetIdleSleep returns the configuration of the sleep of the idle Deployments,
+// or nil if not set.
+func (s SleepInfo) GetIdleSleep() *IdleSleep {
+	return s.Spec.IdleSleep
+}
+
+// IsSleepEnforced returns true if the resources resumed while the namespace is
+// sleeping are put to sleep again.
+func (s SleepInfo) IsSleepEnforced() bool {
+	return s.Spec.SleepPolicy != nil && s.Spec.SleepPolicy.Enforce
+}
+
+// GetMinAgeBeforeSleep returns the minimum time since the last update of a
+// Deployment before it is put to sleep. It is 0 if not set.
+func (s SleepInfo) GetMinAgeBeforeSleep() time.Duration {
+	if s.Spec.SleepPolicy == nil || s.Spec.SleepPolicy.MinAgeBeforeSleep == nil {
+		return 0
+	}
+	return s.Spec.SleepPolicy.MinAgeBeforeSleep.Duration
+}
+
+// GetSleepStrategy returns how the Deployment with the given annotations is
+// put to sleep: the strategy of its SleepStrategyAnnotation, if valid,
+// otherwise the strategy of the SleepPolicy. It is scaleToZero if not set.
+func (s SleepInfo) GetSleepStrategy(annotations map[string]string) string {
+	if strategy := annotations[SleepStrategyAnnotation]; isSleepStrategy(strategy) {
+		return strategy
+	}
+	if s.Spec.SleepPolicy == nil || s.Spec.SleepPolicy.Strategy == "" {
+		return SleepStrategyScaleToZero
+	}
+	return s.Spec.SleepPolicy.Strategy
+}
+
+func isSleepStrategy(strategy string) bool {
+	switch strategy {
+	case SleepStrategyScaleToZero, SleepStrategyPauseRollout, SleepStrategyPatchOnly, SleepStrategyKeepOne:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetPodTerminationCheck returns the check of the pods terminating after the
+// sleep, or nil if not set.
+func (s SleepInfo) GetPodTerminationCheck() *PodTerminationCheck {
+	if s.Spec.SleepPolicy == nil {
+		return nil
+	}
+	return s.Spec.SleepPolicy.TerminationCheck
+}
+
+// GetTimeout returns the time after the sleep within which the pods must
+// terminate. It is 5m if not set.
+func (c PodTerminationCheck) GetTimeout() time.Duration {
+	if c.Timeout == nil {
+		return 5 * time.Minute
+	}
+	return c.Timeout.Duration
+}
+
+// HasDependency returns true if other is referenced in the dependsOn of the
+// SleepInfo. A reference to the whole namespace of the SleepInfo does not
+// include the SleepInfo itself.
+func (s SleepInfo) HasDependency(other SleepInfo) bool {
+	isSelf := other.Namespace == s.Namespace && other.Name == s.Name
+	for _, ref := range s.Spec.DependsOn {
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = s.Namespace
+		}
+		if other.Namespace != namespace {
+			continue
+		}
+		if ref.Name == other.Name || (ref.Name == "" && !isSelf) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInSleepGroupOf returns true if the SleepInfo and other are different
+// members of the same sleep group.
+func (s SleepInfo) IsInSleepGroupOf(other SleepInfo) bool {
+	if s.Spec.SleepGroup == "" || s.Spec.SleepGroup != other.Spec.SleepGroup {
+		return false
+	}
+	return other.Namespace != s.Namespace || other.Name != s.Name
+}
+
+// GetReplicasSource returns where the replicas of the Deployments are
+// restored from on wake up. It is Snapshot if not set.
+func (s SleepInfo) GetReplicasSource() string {
+	if s.Spec.WakeUpPolicy == nil || s.Spec.WakeUpPolicy.ReplicasSource == "" {
+		return ReplicasSourceSnapshot
+	}
+	return s.Spec.WakeUpPolicy.ReplicasSource
+}
+
+// GetAlreadyZeroPolicy returns what the wake up does with the Deployments
+// already at zero replicas before the sleep. It is Keep if not set.
+func (s SleepInfo) GetAlreadyZeroPolicy() string {
+	if s.Spec.WakeUpPolicy == nil || s.Spec.WakeUpPolicy.AlreadyZero == "" {
+		return AlreadyZeroKeep
+	}
+	return s.Spec.WakeUpPolicy.AlreadyZero
+}
+
+// GetWakeUpVerification returns the verification run after the wake up, or
+// nil if not set.
+func (s SleepInfo) GetWakeUpVerification() *WakeUpVerification {
+	if s.Spec.WakeUpPolicy == nil {
+		return nil
+	}
+	return s.Spec.WakeUpPolicy.Verification
+}
+
+// GetExpiry returns the configuration of the expiry of the namespace, or nil
+// if not set.
+func (s SleepInfo) GetExpiry() *Expiry {
+	return s.Spec.Expiry
+}
+
+// GetDelay returns the time after the wake up before the verification
+// starts. It is 1m if not set.
+func (v WakeUpVerification) GetDelay() time.Duration {
+	if v.Delay == nil {
+		return time.Minute
+	}
+	return v.Delay.Duration
+}
+
+// GetTimeout returns the time within which the verification must pass. It is
+// 10m if not set.
+func (v WakeUpVerification) GetTimeout() time.Duration {
+	if v.Timeout == nil {
+		return 10 * time.Minute
+	}
+	return v.Timeout.Duration
+}
+
+// IsRejectedResourceSkipped returns true if the resources whose patch is
+// rejected by an admission webhook are skipped by the operation.
+func (s SleepInfo) IsRejectedResourceSkipped() bool {
+	return s.Spec.RejectionPolicy == RejectionPolicySkip
+}
+
+// getSleepClass returns the class of the workload with the given labels. It
+// returns false if the SleepInfo has no class of the workload.
+func (s SleepInfo) getSleepClass(labels map[string]string) (SleepClass, bool) {
+	name, ok := labels[SleepClassLabel]
+	if !ok {
+		return SleepClass{}, false
+	}
+	for _, class := range s.Spec.Classes {
+		if class.Name == name {
+			return class, true
+		}
+	}
+	return SleepClass{}, false
+}
+
+// IsManagedByClass returns true if the workload with the given labels is put
+// to sleep by the SleepInfo: all the workloads if it has no classes,
+// otherwise only the workloads of its classes which are not skipped.
+func (s SleepInfo) IsManagedByClass(labels map[string]string) bool {
+	if len(s.Spec.Classes) == 0 {
+		return true
+	}
+	class, ok := s.getSleepClass(labels)
+	return ok && !class.Skip
+}
+
+// GetSleepReplicas returns the replicas the Deployment with the given labels
+// is scaled to on sleep. It is 0 if not set by the class of the Deployment.
+func (s SleepInfo) GetSleepReplicas(labels map[string]string) int32 {
+	class, ok := s.getSleepClass(labels)
+	if !ok || class.Replicas == nil {
+		return 0
+	}
+	return *class.Replicas
+}
+
+// ParseJSONPath parses the JSONPath of the field, which can be set with or
+// without the surrounding braces. A missing field is printed as empty.
+func (m FieldMatcher) ParseJSONPath() (*jsonpath.JSONPath, error) {
+	template := m.JSONPath
+	if !strings.HasPrefix(template, "{") {
+		template = fmt.Sprintf("{%s}", template)
+	}
+	parser := jsonpath.New("matchField").AllowMissingKeys(true)
+	if err := parser.Parse(template); err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
+
+// GetCPUThreshold returns the CPU usage, in cores, under which a Deployment is idle.
+func (i IdleSleep) GetCPUThreshold() float64 {
+	if i.CPUThreshold == nil {
+		return 0.01
+	}
+	return i.CPUThreshold.AsApproximateFloat64()
+}
+
+// IsFailOpen returns true if the namespace is put to sleep when the
+// condition cannot be evaluated.
+func (c SleepCondition) IsFailOpen() bool {
+	return c.FailurePolicy == SleepConditionFailurePolicyOpen
+}
+
 //+kubebuilder:object:root=true
 
 // SleepInfoList contains a list of SleepInfo
@@ -2,8 +2,10 @@ package v1alpha1
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -61,6 +63,65 @@ func TestSleepInfo(t *testing.T) {
 		})
 	})
 
+	t.Run("schedule presets", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			spec           SleepInfoSpec
+			expectedSleep  string
+			expectedWakeUp string
+		}{
+			{
+				name:           "office hours",
+				spec:           SleepInfoSpec{Preset: SchedulePresetOfficeHours},
+				expectedSleep:  "00 20 * * 1-5",
+				expectedWakeUp: "00 08 * * 1-5",
+			},
+			{
+				name:           "extended office hours",
+				spec:           SleepInfoSpec{Preset: SchedulePresetExtendedOfficeHours},
+				expectedSleep:  "00 22 * * 1-5",
+				expectedWakeUp: "00 07 * * 1-5",
+			},
+			{
+				name:           "nights with time zone",
+				spec:           SleepInfoSpec{Preset: SchedulePresetNights, TimeZone: "Europe/Rome"},
+				expectedSleep:  "CRON_TZ=Europe/Rome 00 22 * * *",
+				expectedWakeUp: "CRON_TZ=Europe/Rome 00 06 * * *",
+			},
+			{
+				name:           "fields set override the preset",
+				spec:           SleepInfoSpec{Preset: SchedulePresetOfficeHours, Weekdays: "1-4", SleepTime: "18:00"},
+				expectedSleep:  "00 18 * * 1-4",
+				expectedWakeUp: "00 08 * * 1-4",
+			},
+			{
+				name:           "weekdays per operation",
+				spec:           SleepInfoSpec{Weekdays: "1-5", SleepWeekdays: "5", WakeUpWeekdays: "1", SleepTime: "20:00", WakeUpTime: "08:00"},
+				expectedSleep:  "00 20 * * 5",
+				expectedWakeUp: "00 08 * * 1",
+			},
+			{
+				name:           "weekdays of one operation",
+				spec:           SleepInfoSpec{Preset: SchedulePresetOfficeHours, WakeUpWeekdays: "1"},
+				expectedSleep:  "00 20 * * 1-5",
+				expectedWakeUp: "00 08 * * 1",
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				sleepInfo := SleepInfo{Spec: test.spec}
+				schedule, err := sleepInfo.GetSleepSchedule()
+				require.NoError(t, err)
+				require.Equal(t, test.expectedSleep, schedule)
+
+				schedule, err = sleepInfo.GetWakeUpSchedule()
+				require.NoError(t, err)
+				require.Equal(t, test.expectedWakeUp, schedule)
+			})
+		}
+	})
+
 	t.Run("sleep + wake up without timezone", func(t *testing.T) {
 		sleepInfo := SleepInfo{
 			TypeMeta: metav1.TypeMeta{
@@ -272,6 +333,221 @@ func TestSleepInfo(t *testing.T) {
 		})
 	})
 
+	t.Run("cronjobs selector", func(t *testing.T) {
+		t.Run("not set", func(t *testing.T) {
+			sleepInfo := SleepInfo{
+				Spec: SleepInfoSpec{},
+			}
+
+			require.False(t, sleepInfo.IsCronjobsToSuspend())
+			require.Equal(t, CronJobsSelector{}, sleepInfo.GetCronJobsSelector())
+		})
+
+		t.Run("set without suspendCronJobs", func(t *testing.T) {
+			selector := CronJobsSelector{
+				Include: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app": "batch"},
+				},
+			}
+			sleepInfo := SleepInfo{
+				Spec: SleepInfoSpec{
+					CronJobsSelector: &selector,
+				},
+			}
+
+			require.True(t, sleepInfo.IsCronjobsToSuspend())
+			require.Equal(t, selector, sleepInfo.GetCronJobsSelector())
+		})
+	})
+
+	t.Run("suspend jobs, replicasets, daemonsets, poddisruptionbudgets and custom resources options", func(t *testing.T) {
+		sleepInfo := SleepInfo{}
+		require.False(t, sleepInfo.IsJobsToSuspend())
+		require.False(t, sleepInfo.IsReplicaSetsToSuspend())
+		require.False(t, sleepInfo.IsDaemonSetsToSuspend())
+		require.False(t, sleepInfo.IsPodDisruptionBudgetsToSuspend())
+		require.False(t, sleepInfo.IsCustomResourcesToSuspend())
+
+		sleepInfo.Spec.SuspendJobs = true
+		sleepInfo.Spec.SuspendReplicaSets = true
+		sleepInfo.Spec.SuspendDaemonSets = true
+		sleepInfo.Spec.SuspendPodDisruptionBudgets = true
+		sleepInfo.Spec.SuspendCustomResources = true
+		require.True(t, sleepInfo.IsJobsToSuspend())
+		require.True(t, sleepInfo.IsReplicaSetsToSuspend())
+		require.True(t, sleepInfo.IsDaemonSetsToSuspend())
+		require.True(t, sleepInfo.IsPodDisruptionBudgetsToSuspend())
+		require.True(t, sleepInfo.IsCustomResourcesToSuspend())
+	})
+
+	t.Run("delete standalone pods option", func(t *testing.T) {
+		sleepInfo := SleepInfo{}
+		require.False(t, sleepInfo.IsStandalonePodsToDelete())
+
+		sleepInfo.Spec.DeleteStandalonePods = true
+		require.True(t, sleepInfo.IsStandalonePodsToDelete())
+	})
+
+	t.Run("sleep condition", func(t *testing.T) {
+		sleepInfo := SleepInfo{}
+		require.Nil(t, sleepInfo.GetSleepCondition())
+
+		sleepInfo.Spec.SleepCondition = &SleepCondition{PrometheusQuery: "up == 0"}
+		require.Equal(t, &SleepCondition{PrometheusQuery: "up == 0"}, sleepInfo.GetSleepCondition())
+		require.False(t, sleepInfo.GetSleepCondition().IsFailOpen())
+
+		sleepInfo.Spec.SleepCondition.FailurePolicy = SleepConditionFailurePolicyOpen
+		require.True(t, sleepInfo.GetSleepCondition().IsFailOpen())
+	})
+
+	t.Run("min age before sleep", func(t *testing.T) {
+		sleepInfo := SleepInfo{}
+		require.Zero(t, sleepInfo.GetMinAgeBeforeSleep())
+
+		sleepInfo.Spec.SleepPolicy = &SleepPolicy{}
+		require.Zero(t, sleepInfo.GetMinAgeBeforeSleep())
+
+		sleepInfo.Spec.SleepPolicy.MinAgeBeforeSleep = &metav1.Duration{Duration: 30 * time.Minute}
+		require.Equal(t, 30*time.Minute, sleepInfo.GetMinAgeBeforeSleep())
+	})
+
+	t.Run("pod termination check", func(t *testing.T) {
+		sleepInfo := SleepInfo{}
+		require.Nil(t, sleepInfo.GetPodTerminationCheck())
+
+		sleepInfo.Spec.SleepPolicy = &SleepPolicy{TerminationCheck: &PodTerminationCheck{}}
+		require.Equal(t, 5*time.Minute, sleepInfo.GetPodTerminationCheck().GetTimeout())
+
+		sleepInfo.Spec.SleepPolicy.TerminationCheck.Timeout = &metav1.Duration{Duration: 15 * time.Minute}
+		require.Equal(t, 15*time.Minute, sleepInfo.GetPodTerminationCheck().GetTimeout())
+	})
+
+	t.Run("sleep enforced", func(t *testing.T) {
+		sleepInfo := SleepInfo{}
+		require.False(t, sleepInfo.IsSleepEnforced())
+
+		sleepInfo.Spec.SleepPolicy = &SleepPolicy{Enforce: true}
+		require.True(t, sleepInfo.IsSleepEnforced())
+	})
+
+	t.Run("sleep group", func(t *testing.T) {
+		getSleepInfo := func(namespace, name, sleepGroup string) SleepInfo {
+			return SleepInfo{
+				ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+				Spec:       SleepInfoSpec{SleepGroup: sleepGroup},
+			}
+		}
+		sleepInfo := getSleepInfo("frontend", "sleepinfo", "shop")
+		require.True(t, sleepInfo.IsInSleepGroupOf(getSleepInfo("backend", "sleepinfo", "shop")))
+		require.False(t, sleepInfo.IsInSleepGroupOf(getSleepInfo("backend", "sleepinfo", "other")))
+		require.False(t, sleepInfo.IsInSleepGroupOf(sleepInfo))
+		require.False(t, getSleepInfo("frontend", "sleepinfo", "").IsInSleepGroupOf(getSleepInfo("backend", "sleepinfo", "")))
+	})
+
+	t.Run("replicas source", func(t *testing.T) {
+		sleepInfo := SleepInfo{}
+		require.Equal(t, ReplicasSourceSnapshot, sleepInfo.GetReplicasSource())
+
+		sleepInfo.Spec.WakeUpPolicy = &WakeUpPolicy{}
+		require.Equal(t, ReplicasSourceSnapshot, sleepInfo.GetReplicasSource())
+
+		sleepInfo.Spec.WakeUpPolicy.ReplicasSource = ReplicasSourceDeclared
+		require.Equal(t, ReplicasSourceDeclared, sleepInfo.GetReplicasSource())
+	})
+
+	t.Run("already zero policy", func(t *testing.T) {
+		sleepInfo := SleepInfo{}
+		require.Equal(t, AlreadyZeroKeep, sleepInfo.GetAlreadyZeroPolicy())
+
+		sleepInfo.Spec.WakeUpPolicy = &WakeUpPolicy{ReplicasSource: ReplicasSourceDeclared}
+		require.Equal(t, AlreadyZeroKeep, sleepInfo.GetAlreadyZeroPolicy())
+
+		sleepInfo.Spec.WakeUpPolicy.AlreadyZero = AlreadyZeroRestore
+		require.Equal(t, AlreadyZeroRestore, sleepInfo.GetAlreadyZeroPolicy())
+	})
+
+	t.Run("wake up verification", func(t *testing.T) {
+		sleepInfo := SleepInfo{}
+		require.Nil(t, sleepInfo.GetWakeUpVerification())
+
+		sleepInfo.Spec.WakeUpPolicy = &WakeUpPolicy{Verification: &WakeUpVerification{URL: "http://frontend"}}
+		require.Equal(t, time.Minute, sleepInfo.GetWakeUpVerification().GetDelay())
+		require.Equal(t, 10*time.Minute, sleepInfo.GetWakeUpVerification().GetTimeout())
+
+		sleepInfo.Spec.WakeUpPolicy.Verification.Delay = &metav1.Duration{Duration: 0}
+		sleepInfo.Spec.WakeUpPolicy.Verification.Timeout = &metav1.Duration{Duration: 5 * time.Minute}
+		require.Equal(t, time.Duration(0), sleepInfo.GetWakeUpVerification().GetDelay())
+		require.Equal(t, 5*time.Minute, sleepInfo.GetWakeUpVerification().GetTimeout())
+	})
+
+	t.Run("sleep strategy", func(t *testing.T) {
+		sleepInfo := SleepInfo{}
+		require.Equal(t, SleepStrategyScaleToZero, sleepInfo.GetSleepStrategy(nil))
+
+		sleepInfo.Spec.SleepPolicy = &SleepPolicy{Strategy: SleepStrategyPauseRollout}
+		require.Equal(t, SleepStrategyPauseRollout, sleepInfo.GetSleepStrategy(nil))
+		require.Equal(t, SleepStrategyPatchOnly, sleepInfo.GetSleepStrategy(map[string]string{SleepStrategyAnnotation: SleepStrategyPatchOnly}))
+		require.Equal(t, SleepStrategyPauseRollout, sleepInfo.GetSleepStrategy(map[string]string{SleepStrategyAnnotation: "invalid"}))
+		require.Equal(t, SleepStrategyKeepOne, sleepInfo.GetSleepStrategy(map[string]string{SleepStrategyAnnotation: SleepStrategyKeepOne}))
+	})
+
+	t.Run("sleep classes", func(t *testing.T) {
+		batch := map[string]string{SleepClassLabel: "batch"}
+		web := map[string]string{SleepClassLabel: "web"}
+		critical := map[string]string{SleepClassLabel: "critical"}
+		other := map[string]string{SleepClassLabel: "other"}
+
+		sleepInfo := SleepInfo{}
+		for _, labels := range []map[string]string{nil, batch, other} {
+			require.True(t, sleepInfo.IsManagedByClass(labels))
+			require.Equal(t, int32(0), sleepInfo.GetSleepReplicas(labels))
+		}
+
+		sleepInfo.Spec.Classes = []SleepClass{
+			{Name: "batch"},
+			{Name: "web", Replicas: getPtr[int32](1)},
+			{Name: "critical", Skip: true},
+		}
+		require.True(t, sleepInfo.IsManagedByClass(batch))
+		require.True(t, sleepInfo.IsManagedByClass(web))
+		require.False(t, sleepInfo.IsManagedByClass(critical))
+		require.False(t, sleepInfo.IsManagedByClass(other))
+		require.False(t, sleepInfo.IsManagedByClass(nil))
+		require.Equal(t, int32(0), sleepInfo.GetSleepReplicas(batch))
+		require.Equal(t, int32(1), sleepInfo.GetSleepReplicas(web))
+		require.Equal(t, int32(0), sleepInfo.GetSleepReplicas(nil))
+	})
+
+	t.Run("cross midnight", func(t *testing.T) {
+		tests := []struct {
+			spec     SleepInfoSpec
+			expected bool
+		}{
+			{spec: SleepInfoSpec{SleepTime: "22:00", WakeUpTime: "6:00"}, expected: true},
+			{spec: SleepInfoSpec{SleepTime: "20:00", WakeUpTime: "08:00"}, expected: true},
+			{spec: SleepInfoSpec{SleepTime: "08:00", WakeUpTime: "20:00"}, expected: false},
+			{spec: SleepInfoSpec{SleepTime: "20:00"}, expected: false},
+			{spec: SleepInfoSpec{SleepTime: "*:30", WakeUpTime: "*:10"}, expected: false},
+			{spec: SleepInfoSpec{Preset: SchedulePresetNights}, expected: true},
+		}
+		for _, test := range tests {
+			sleepInfo := SleepInfo{Spec: test.spec}
+			require.Equal(t, test.expected, sleepInfo.IsCrossMidnight(), "sleep at %s, wake up at %s", test.spec.SleepTime, test.spec.WakeUpTime)
+		}
+	})
+
+	t.Run("idle sleep", func(t *testing.T) {
+		sleepInfo := SleepInfo{}
+		require.Nil(t, sleepInfo.GetIdleSleep())
+
+		sleepInfo.Spec.IdleSleep = &IdleSleep{Period: metav1.Duration{Duration: 2 * time.Hour}}
+		require.Equal(t, 0.01, sleepInfo.GetIdleSleep().GetCPUThreshold())
+
+		cpuThreshold := resource.MustParse("100m")
+		sleepInfo.Spec.IdleSleep.CPUThreshold = &cpuThreshold
+		require.Equal(t, 0.1, sleepInfo.GetIdleSleep().GetCPUThreshold())
+	})
+
 	t.Run("fails if weekday is empty", func(t *testing.T) {
 		sleepInfo := SleepInfo{
 			TypeMeta: metav1.TypeMeta{
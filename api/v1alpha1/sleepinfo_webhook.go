@@ -5,24 +5,162 @@ Copyright 2021.
 package v1alpha1
 
 import (
+	"context"
 	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/robfig/cron/v3"
+	"github.com/kube-green/kube-green/pkg/schedule"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
+// alternationCheckWindow is how far the schedules with different weekdays for
+// the sleep and the wake up are simulated. The schedules repeat every week, or
+// every month with the L and # weekdays, so five weeks are enough.
+const alternationCheckWindow = 35 * 24 * time.Hour
+
+// alternationCheckStart is when the simulation of the schedules starts, fixed
+// so that the validation of a SleepInfo does not depend on when it is run.
+var alternationCheckStart = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
 // log is for logging in this package.
 var sleepinfolog = logf.Log.WithName("sleepinfo-resource")
 
-func (s *SleepInfo) SetupWebhookWithManager(mgr ctrl.Manager) error {
+// SetupWebhookWithManager registers the validating and the mutating webhooks
+// of the SleepInfo. The mutating webhook sets defaultTimeZone as the time zone
+// of the SleepInfo which do not set it; if empty, UTC is set. The validating
+// webhook rejects the SleepInfo in the protectedNamespaces, besides the
+// DefaultProtectedNamespaces; if namespaceScoped, the controller cannot read
+// the Namespaces and their labels are not checked.
+func (s *SleepInfo) SetupWebhookWithManager(mgr ctrl.Manager, defaultTimeZone string, protectedNamespaces []string, namespaceScoped bool) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(s).
+		WithDefaulter(&SleepInfoDefaulter{DefaultTimeZone: defaultTimeZone}).
+		WithValidator(&SleepInfoValidator{
+			Client:                mgr.GetClient(),
+			ProtectedNamespaces:   protectedNamespaces,
+			IgnoreNamespaceLabels: namespaceScoped,
+		}).
 		Complete()
 }
 
+//+kubebuilder:webhook:path=/mutate-kube-green-com-v1alpha1-sleepinfo,mutating=true,failurePolicy=fail,sideEffects=None,groups=kube-green.com,resources=sleepinfos,verbs=create;update,versions=v1alpha1,name=msleepinfo.kb.io,admissionReviewVersions=v1
+
+// SleepInfoDefaulter fills the defaults of the SleepInfo fields and normalizes
+// them, so that the stored SleepInfo is always explicit.
+type SleepInfoDefaulter struct {
+	// DefaultTimeZone is the time zone set if the SleepInfo does not set it.
+	// If empty, UTC is set.
+	DefaultTimeZone string
+}
+
+var _ webhook.CustomDefaulter = &SleepInfoDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type
+func (d *SleepInfoDefaulter) Default(_ context.Context, obj runtime.Object) error {
+	s, ok := obj.(*SleepInfo)
+	if !ok {
+		return fmt.Errorf("expected a SleepInfo but got a %T", obj)
+	}
+	sleepinfolog.Info("default", "name", s.Name, "namespace", s.Namespace)
+
+	if s.Spec.TimeZone == "" {
+		s.Spec.TimeZone = d.DefaultTimeZone
+		if s.Spec.TimeZone == "" {
+			s.Spec.TimeZone = "UTC"
+		}
+	}
+	// the preset is expanded into the schedule fields not set.
+	s.Spec.Weekdays = s.getWeekdays()
+	s.Spec.SleepTime = s.getSleepTime()
+	s.Spec.WakeUpTime = s.getWakeUpTime()
+	if s.Spec.Weekdays == "" {
+		s.Spec.Weekdays = "*"
+	}
+	s.Spec.Weekdays = normalizeWeekdays(s.Spec.Weekdays)
+	s.Spec.SleepWeekdays = normalizeWeekdays(s.Spec.SleepWeekdays)
+	s.Spec.WakeUpWeekdays = normalizeWeekdays(s.Spec.WakeUpWeekdays)
+	if s.Spec.SuspendDeployments == nil {
+		suspendDeployments := true
+		s.Spec.SuspendDeployments = &suspendDeployments
+	}
+	s.Spec.SleepTime = normalizeTime(s.Spec.SleepTime)
+	s.Spec.WakeUpTime = normalizeTime(s.Spec.WakeUpTime)
+	return nil
+}
+
+var weekdayNumbers = map[string]string{
+	"sun": "0", "sunday": "0",
+	"mon": "1", "monday": "1",
+	"tue": "2", "tuesday": "2",
+	"wed": "3", "wednesday": "3",
+	"thu": "4", "thursday": "4",
+	"fri": "5", "friday": "5",
+	"sat": "6", "saturday": "6",
+}
+
+// normalizeWeekdays replaces the weekday names with their cron number, e.g.
+// mon-fri becomes 1-5 and friL becomes 5L, and removes the spaces. Unknown
+// values are kept as is, so they are reported by the validation.
+func normalizeWeekdays(weekdays string) string {
+	weekdays = strings.ReplaceAll(weekdays, " ", "")
+	lists := strings.Split(weekdays, ",")
+	for i, list := range lists {
+		bounds := strings.Split(list, "-")
+		for j, bound := range bounds {
+			name, suffix := splitWeekdaySuffix(bound)
+			if number, ok := weekdayNumbers[strings.ToLower(name)]; ok {
+				bounds[j] = number + suffix
+			}
+		}
+		lists[i] = strings.Join(bounds, "-")
+	}
+	return strings.Join(lists, ",")
+}
+
+// splitWeekdaySuffix splits the weekday from the L or #n suffix which selects
+// the last or the nth weekday of the month, e.g. fri#3 is split in fri and #3.
+func splitWeekdaySuffix(weekday string) (string, string) {
+	if i := strings.Index(weekday, "#"); i >= 0 {
+		return weekday[:i], weekday[i:]
+	}
+	if strings.HasSuffix(weekday, "L") {
+		return strings.TrimSuffix(weekday, "L"), "L"
+	}
+	return weekday, ""
+}
+
+// normalizeTime formats the hours and the minutes of the time with two digits,
+// e.g. 8:0 becomes 08:00. The cron expressions (e.g. *:*/2) are kept as is.
+func normalizeTime(hourAndMinute string) string {
+	parts := strings.Split(strings.TrimSpace(hourAndMinute), ":")
+	//nolint:gomnd
+	if len(parts) != 2 {
+		return hourAndMinute
+	}
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return hourAndMinute
+		}
+		parts[i] = fmt.Sprintf("%02d", number)
+	}
+	return strings.Join(parts, ":")
+}
+
 //+kubebuilder:webhook:path=/validate-kube-green-com-v1alpha1-sleepinfo,mutating=false,failurePolicy=fail,sideEffects=None,groups=kube-green.com,resources=sleepinfos,verbs=create;update,versions=v1alpha1,name=vsleepinfo.kb.io,admissionReviewVersions=v1
 
 var _ webhook.Validator = &SleepInfo{}
@@ -47,37 +185,426 @@ func (s *SleepInfo) ValidateDelete() error {
 	return nil
 }
 
+// SleepInfoValidator validates the SleepInfo as its webhook.Validator and, in
+// addition, rejects the SleepInfo in the protected namespaces and the
+// dependsOn which make a dependency cycle with the other SleepInfo of the
+// cluster.
+type SleepInfoValidator struct {
+	// Client lists the SleepInfo of the cluster and gets the namespaces. If
+	// nil, the dependency cycles and the labels of the namespaces are not
+	// checked.
+	Client client.Reader
+	// ProtectedNamespaces are the glob patterns of the namespaces where the
+	// SleepInfo are rejected, besides the DefaultProtectedNamespaces.
+	ProtectedNamespaces []string
+	// IgnoreNamespaceLabels checks the protected namespaces only by name,
+	// without getting the namespaces.
+	IgnoreNamespaceLabels bool
+}
+
+var _ webhook.CustomValidator = &SleepInfoValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *SleepInfoValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	s, ok := obj.(*SleepInfo)
+	if !ok {
+		return fmt.Errorf("expected a SleepInfo but got a %T", obj)
+	}
+	if err := s.ValidateCreate(); err != nil {
+		return err
+	}
+	if err := v.validateNamespace(ctx, s); err != nil {
+		return err
+	}
+	return v.validateDependsOn(ctx, s)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *SleepInfoValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	s, ok := newObj.(*SleepInfo)
+	if !ok {
+		return fmt.Errorf("expected a SleepInfo but got a %T", newObj)
+	}
+	if err := s.ValidateUpdate(oldObj); err != nil {
+		return err
+	}
+	if err := v.validateNamespace(ctx, s); err != nil {
+		return err
+	}
+	return v.validateDependsOn(ctx, s)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *SleepInfoValidator) ValidateDelete(_ context.Context, obj runtime.Object) error {
+	s, ok := obj.(*SleepInfo)
+	if !ok {
+		return fmt.Errorf("expected a SleepInfo but got a %T", obj)
+	}
+	return s.ValidateDelete()
+}
+
+// validateNamespace rejects the SleepInfo in a protected namespace, to prevent
+// a catastrophic misconfiguration, e.g. the sleep of kube-system.
+func (v *SleepInfoValidator) validateNamespace(ctx context.Context, s *SleepInfo) error {
+	protectedErr := fmt.Errorf("namespace %s is protected, SleepInfo are not allowed", s.Namespace)
+	if IsNamespaceNameProtected(s.Namespace, v.ProtectedNamespaces) {
+		return protectedErr
+	}
+	if v.Client == nil || v.IgnoreNamespaceLabels {
+		return nil
+	}
+	namespace := &corev1.Namespace{}
+	if err := v.Client.Get(ctx, client.ObjectKey{Name: s.Namespace}, namespace); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("fails to get the namespace to check if it is protected: %s", err)
+	}
+	if IsNamespaceProtected(namespace, v.ProtectedNamespaces) {
+		return protectedErr
+	}
+	return nil
+}
+
+func (v *SleepInfoValidator) validateDependsOn(ctx context.Context, s *SleepInfo) error {
+	if len(s.Spec.DependsOn) == 0 || v.Client == nil {
+		return nil
+	}
+	sleepInfos := SleepInfoList{}
+	if err := v.Client.List(ctx, &sleepInfos); err != nil {
+		return fmt.Errorf("fails to list sleepinfos to check dependsOn: %s", err)
+	}
+	if cycle := findDependencyCycle(*s, sleepInfos.Items); cycle != nil {
+		return fmt.Errorf("dependsOn is invalid: dependency cycle %s", strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+// findDependencyCycle returns the dependency cycle of the SleepInfo through
+// the other SleepInfo, e.g. [ns1/a ns2/b ns1/a], or nil if there is none. The
+// cycles not including the SleepInfo are not searched, since they have been
+// rejected at their admission.
+func findDependencyCycle(sleepInfo SleepInfo, sleepInfos []SleepInfo) []string {
+	getKey := func(s SleepInfo) string {
+		return fmt.Sprintf("%s/%s", s.Namespace, s.Name)
+	}
+	// the SleepInfo under validation replaces its stored version.
+	nodes := []SleepInfo{sleepInfo}
+	for _, other := range sleepInfos {
+		if getKey(other) != getKey(sleepInfo) {
+			nodes = append(nodes, other)
+		}
+	}
+
+	visited := map[string]bool{}
+	var visit func(current SleepInfo, path []string) []string
+	visit = func(current SleepInfo, path []string) []string {
+		for _, next := range nodes {
+			if !current.HasDependency(next) {
+				continue
+			}
+			if getKey(next) == getKey(sleepInfo) {
+				return append(path, getKey(next))
+			}
+			if visited[getKey(next)] {
+				continue
+			}
+			visited[getKey(next)] = true
+			if cycle := visit(next, append(path, getKey(next))); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+	return visit(sleepInfo, []string{getKey(sleepInfo)})
+}
+
+// Validate returns an error if the SleepInfo is rejected by the validating
+// webhook, without the checks which need the cluster, i.e. the protected
+// namespaces and the dependency cycles. The SleepInfo should be defaulted
+// first, as done by the mutating webhook.
+func (s SleepInfo) Validate() error {
+	return s.validateSleepInfo()
+}
+
 func (s SleepInfo) validateSleepInfo() error {
-	schedule, err := s.GetSleepSchedule()
+	if _, err := s.getPreset(); err != nil {
+		return err
+	}
+	sleepSchedule, err := s.GetSleepSchedule()
 	if err != nil {
 		return err
 	}
-	if _, err = cron.ParseStandard(schedule); err != nil {
+	if _, err = schedule.Parse(sleepSchedule); err != nil {
 		return err
 	}
 
-	schedule, err = s.GetWakeUpSchedule()
+	wakeUpSchedule, err := s.GetWakeUpSchedule()
 	if err != nil {
 		return err
 	}
-	if schedule != "" {
-		if _, err = cron.ParseStandard(schedule); err != nil {
+	if wakeUpSchedule != "" {
+		if _, err = schedule.Parse(wakeUpSchedule); err != nil {
+			return err
+		}
+		if err := s.isSleepWindowValid(sleepSchedule, wakeUpSchedule); err != nil {
+			return err
+		}
+	} else if s.Spec.WakeUpWeekdays != "" {
+		return fmt.Errorf("wakeUpWeekdays is set without wakeUpAt: the namespace never wakes up")
+	}
+
+	if err := isCronJobsSelectorValid(s.GetCronJobsSelector()); err != nil {
+		return err
+	}
+
+	if condition := s.GetSleepCondition(); condition != nil {
+		if err := isSleepConditionValid(*condition); err != nil {
 			return err
 		}
 	}
 
+	if idleSleep := s.GetIdleSleep(); idleSleep != nil && idleSleep.Period.Duration <= 0 {
+		return fmt.Errorf("idleSleep.period must be greater than 0")
+	}
+
+	if s.GetMinAgeBeforeSleep() < 0 {
+		return fmt.Errorf("sleepPolicy.minAgeBeforeSleep must not be negative")
+	}
+
+	if s.Spec.SleepPolicy != nil && s.Spec.SleepPolicy.Strategy != "" && !isSleepStrategy(s.Spec.SleepPolicy.Strategy) {
+		return fmt.Errorf("sleepPolicy.strategy is invalid: must be %s, %s, %s or %s", SleepStrategyScaleToZero, SleepStrategyPauseRollout, SleepStrategyPatchOnly, SleepStrategyKeepOne)
+	}
+
+	if check := s.GetPodTerminationCheck(); check != nil && check.GetTimeout() <= 0 {
+		return fmt.Errorf("sleepPolicy.terminationCheck.timeout must be greater than 0")
+	}
+
+	if err := isReplicasSourceValid(s.GetReplicasSource()); err != nil {
+		return err
+	}
+
+	switch s.GetAlreadyZeroPolicy() {
+	case AlreadyZeroKeep, AlreadyZeroRestore:
+	default:
+		return fmt.Errorf("wakeUpPolicy.alreadyZero is invalid: must be %s or %s", AlreadyZeroKeep, AlreadyZeroRestore)
+	}
+
+	if verification := s.GetWakeUpVerification(); verification != nil {
+		if err := isWakeUpVerificationValid(*verification); err != nil {
+			return err
+		}
+	}
+
+	if expiry := s.GetExpiry(); expiry != nil {
+		if err := isExpiryValid(*expiry); err != nil {
+			return err
+		}
+	}
+
+	if s.Spec.ServiceAccountName != "" && len(validation.IsDNS1123Subdomain(s.Spec.ServiceAccountName)) > 0 {
+		return fmt.Errorf("serviceAccountName %q is not a valid name", s.Spec.ServiceAccountName)
+	}
+
+	if err := isRejectionPolicyValid(s.Spec.RejectionPolicy); err != nil {
+		return err
+	}
+
+	if err := areSleepClassesValid(s.Spec.Classes); err != nil {
+		return err
+	}
+
+	for _, ref := range s.Spec.DependsOn {
+		if ref.Namespace == "" && ref.Name == "" {
+			return fmt.Errorf("dependsOn is invalid: namespace or name must be set")
+		}
+	}
+
 	for _, excludeRef := range s.GetExcludeRef() {
-		return isExcludeRefValid(excludeRef)
+		if err := isExcludeRefValid(excludeRef); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSleepWindowValid returns an error if the sleeps and the wake ups do not
+// alternate. With the same weekdays, it is the case unless the sleep and wake
+// up times are the same: it is not possible to know if the namespace should
+// sleep the whole day or not at all. With different weekdays, e.g. sleep on
+// friday and wake up on monday, the schedules are simulated.
+func (s SleepInfo) isSleepWindowValid(sleepSchedule, wakeUpSchedule string) error {
+	if !s.HasWeekdaysPerOperation() {
+		return isSleepTimeDifferent(s.getSleepTime(), s.getWakeUpTime())
+	}
+	if err := schedule.CheckAlternation(sleepSchedule, wakeUpSchedule, alternationCheckStart, alternationCheckStart.Add(alternationCheckWindow)); err != nil {
+		return fmt.Errorf("sleepWeekdays and wakeUpWeekdays are invalid: the sleeps and the wake ups must alternate: %s", err)
+	}
+	return nil
+}
+
+// isSleepTimeDifferent returns an error if the sleep and wake up times are the
+// same.
+func isSleepTimeDifferent(sleepTime, wakeUpTime string) error {
+	sleepAt, ok := getMinutesOfDay(sleepTime)
+	if !ok {
+		return nil
+	}
+	if wakeUpAt, ok := getMinutesOfDay(wakeUpTime); ok && wakeUpAt == sleepAt {
+		return fmt.Errorf("sleepAt and wakeUpAt must be different: the sleep window is ambiguous, actual: %s", sleepTime)
 	}
 	return nil
 }
 
 func isExcludeRefValid(excludeRef ExcludeRef) error {
+	if excludeRef.NamePattern != "" || excludeRef.NameGlob != "" {
+		return isExcludeRefNamePatternValid(excludeRef)
+	}
+	if excludeRef.MatchField != nil {
+		return isExcludeRefMatchFieldValid(excludeRef)
+	}
 	if excludeRef.Name == "" && excludeRef.APIVersion == "" && excludeRef.Kind == "" && len(excludeRef.MatchLabels) > 0 {
 		return nil
 	}
-	if len(excludeRef.MatchLabels) == 0 && excludeRef.Name != "" && excludeRef.APIVersion != "" && excludeRef.Kind != "" {
+	if len(excludeRef.MatchLabels) == 0 && excludeRef.Name != "" && excludeRef.APIVersion != "" && excludeRef.Kind != "" && excludeRef.OwnerKind == "" {
+		return nil
+	}
+	if excludeRef.OwnerKind != "" && excludeRef.Name == "" && len(excludeRef.MatchLabels) == 0 {
+		return nil
+	}
+	return fmt.Errorf(`excludeRef is invalid. Must have set: matchLabels, name,apiVersion and kind fields or ownerKind`)
+}
+
+func isExcludeRefNamePatternValid(excludeRef ExcludeRef) error {
+	if excludeRef.Kind == "" || excludeRef.Name != "" || len(excludeRef.MatchLabels) > 0 || excludeRef.OwnerKind != "" || excludeRef.MatchField != nil || (excludeRef.NamePattern != "" && excludeRef.NameGlob != "") {
+		return fmt.Errorf("excludeRef is invalid. namePattern or nameGlob must be set only with kind and apiVersion fields")
+	}
+	if excludeRef.NamePattern != "" {
+		if _, err := regexp.Compile(excludeRef.NamePattern); err != nil {
+			return fmt.Errorf("excludeRef.namePattern is invalid: %s", err)
+		}
+	}
+	if excludeRef.NameGlob != "" {
+		if _, err := path.Match(excludeRef.NameGlob, ""); err != nil {
+			return fmt.Errorf("excludeRef.nameGlob is invalid: %s", err)
+		}
+	}
+	return nil
+}
+
+func isExcludeRefMatchFieldValid(excludeRef ExcludeRef) error {
+	if excludeRef.Name != "" || len(excludeRef.MatchLabels) > 0 || excludeRef.OwnerKind != "" {
+		return fmt.Errorf("excludeRef is invalid. matchField must be set only with kind and apiVersion fields")
+	}
+	if strings.TrimSpace(excludeRef.MatchField.JSONPath) == "" {
+		return fmt.Errorf("excludeRef.matchField.jsonPath is required")
+	}
+	if _, err := excludeRef.MatchField.ParseJSONPath(); err != nil {
+		return fmt.Errorf("excludeRef.matchField.jsonPath is invalid: %s", err)
+	}
+	return nil
+}
+
+func isCronJobsSelectorValid(selector CronJobsSelector) error {
+	if _, err := metav1.LabelSelectorAsSelector(selector.Include); err != nil {
+		return fmt.Errorf("cronJobsSelector.include is invalid: %s", err)
+	}
+	if _, err := metav1.LabelSelectorAsSelector(selector.Exclude); err != nil {
+		return fmt.Errorf("cronJobsSelector.exclude is invalid: %s", err)
+	}
+	return nil
+}
+
+func isSleepConditionValid(condition SleepCondition) error {
+	if strings.TrimSpace(condition.PrometheusQuery) == "" {
+		return fmt.Errorf("sleepCondition.prometheusQuery is required")
+	}
+	switch condition.FailurePolicy {
+	case "", SleepConditionFailurePolicyOpen, SleepConditionFailurePolicyClosed:
+		return nil
+	default:
+		return fmt.Errorf("sleepCondition.failurePolicy is invalid: must be %s or %s", SleepConditionFailurePolicyOpen, SleepConditionFailurePolicyClosed)
+	}
+}
+
+func isReplicasSourceValid(replicasSource string) error {
+	switch replicasSource {
+	case ReplicasSourceSnapshot, ReplicasSourceDeclared, ReplicasSourceSnapshotAtLeastMin, ReplicasSourceAutoscaler:
+		return nil
+	default:
+		return fmt.Errorf("wakeUpPolicy.replicasSource is invalid: must be one of %s, %s, %s or %s", ReplicasSourceSnapshot, ReplicasSourceDeclared, ReplicasSourceSnapshotAtLeastMin, ReplicasSourceAutoscaler)
+	}
+}
+
+func isWakeUpVerificationValid(verification WakeUpVerification) error {
+	if (verification.URL == "") == (verification.JobFromCronJob == "") {
+		return fmt.Errorf("wakeUpPolicy.verification is invalid: exactly one of url and jobFromCronJob must be set")
+	}
+	if verification.URL != "" {
+		verificationURL, err := url.Parse(verification.URL)
+		if err != nil {
+			return fmt.Errorf("wakeUpPolicy.verification.url is invalid: %s", err)
+		}
+		if verificationURL.Scheme != "http" && verificationURL.Scheme != "https" {
+			return fmt.Errorf("wakeUpPolicy.verification.url is invalid: scheme must be http or https")
+		}
+	}
+	if verification.GetDelay() < 0 {
+		return fmt.Errorf("wakeUpPolicy.verification.delay must not be negative")
+	}
+	if verification.GetTimeout() <= 0 {
+		return fmt.Errorf("wakeUpPolicy.verification.timeout must be greater than 0")
+	}
+	return nil
+}
+
+func isExpiryValid(expiry Expiry) error {
+	if expiry.After.Duration <= 0 {
+		return fmt.Errorf("expiry.after must be greater than 0")
+	}
+	if expiry.WebhookURL != "" {
+		webhookURL, err := url.Parse(expiry.WebhookURL)
+		if err != nil {
+			return fmt.Errorf("expiry.webhookURL is invalid: %s", err)
+		}
+		if webhookURL.Scheme != "http" && webhookURL.Scheme != "https" {
+			return fmt.Errorf("expiry.webhookURL is invalid: scheme must be http or https")
+		}
+	}
+	if expiry.DeleteSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(expiry.DeleteSelector)
+		if err != nil {
+			return fmt.Errorf("expiry.deleteSelector is invalid: %s", err)
+		}
+		if selector.Empty() {
+			return fmt.Errorf("expiry.deleteSelector is invalid: must not be empty")
+		}
+	}
+	return nil
+}
+
+func areSleepClassesValid(classes []SleepClass) error {
+	names := map[string]bool{}
+	for _, class := range classes {
+		if class.Name == "" {
+			return fmt.Errorf("classes is invalid: name is required")
+		}
+		if names[class.Name] {
+			return fmt.Errorf("classes is invalid: duplicate class %s", class.Name)
+		}
+		names[class.Name] = true
+		if class.Replicas != nil && *class.Replicas < 0 {
+			return fmt.Errorf("classes is invalid: replicas of class %s must not be negative", class.Name)
+		}
+	}
+	return nil
+}
+
+func isRejectionPolicyValid(rejectionPolicy string) error {
+	switch rejectionPolicy {
+	case "", RejectionPolicyFail, RejectionPolicySkip:
 		return nil
+	default:
+		return fmt.Errorf("rejectionPolicy is invalid: must be %s or %s", RejectionPolicyFail, RejectionPolicySkip)
 	}
-	return fmt.Errorf(`excludeRef is invalid. Must have set: matchLabels or name,apiVersion and kind fields`)
 }
@@ -5,10 +5,16 @@ Copyright 2021.
 package v1alpha1
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestValidateSleepInfo(t *testing.T) {
@@ -87,6 +93,22 @@ func TestValidateSleepInfo(t *testing.T) {
 				SleepTime: "1:00",
 			},
 		},
+		{
+			name: "ok - last and nth weekday of the month",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1#1,5L",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+			},
+		},
+		{
+			name:          "fails - nth weekday out of the month",
+			expectedError: "invalid day of week 1#6: the occurrence in the month must be from 1 to 5",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:  "1#6",
+				SleepTime: "20:00",
+			},
+		},
 		{
 			name:          "fails - wake up time without minutes",
 			expectedError: "expected exactly 5 fields, found 4: [15 * * 1-5]",
@@ -134,7 +156,165 @@ func TestValidateSleepInfo(t *testing.T) {
 		},
 		{
 			name:          "fails - missing Name in ExcludeRef item",
-			expectedError: `excludeRef is invalid. Must have set: matchLabels or name,apiVersion and kind fields`,
+			expectedError: `excludeRef is invalid. Must have set: matchLabels, name,apiVersion and kind fields or ownerKind`,
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "13:15",
+				WakeUpTime: "13:20",
+				ExcludeRef: []ExcludeRef{
+					{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+					},
+				},
+			},
+		},
+		{
+			name: "ok - OwnerKind in ExcludeRef item",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "13:15",
+				WakeUpTime: "13:20",
+				ExcludeRef: []ExcludeRef{
+					{
+						MatchLabels: map[string]string{
+							"app": "backend",
+						},
+					},
+					{
+						Kind:      "ReplicaSet",
+						OwnerKind: "Rollout",
+					},
+				},
+			},
+		},
+		{
+			name: "ok - NamePattern and NameGlob in ExcludeRef items",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "13:15",
+				WakeUpTime: "13:20",
+				ExcludeRef: []ExcludeRef{
+					{
+						APIVersion:  "apps/v1",
+						Kind:        "Deployment",
+						NamePattern: "preview-.*",
+					},
+					{
+						Kind:     "CronJob",
+						NameGlob: "preview-*",
+					},
+				},
+			},
+		},
+		{
+			name:          "fails - NamePattern without Kind in ExcludeRef item",
+			expectedError: `excludeRef is invalid. namePattern or nameGlob must be set only with kind and apiVersion fields`,
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "13:15",
+				WakeUpTime: "13:20",
+				ExcludeRef: []ExcludeRef{
+					{
+						NamePattern: "preview-.*",
+					},
+				},
+			},
+		},
+		{
+			name:          "fails - invalid NamePattern in ExcludeRef item",
+			expectedError: "excludeRef.namePattern is invalid: error parsing regexp: missing closing ): `preview-(.*`",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "13:15",
+				WakeUpTime: "13:20",
+				ExcludeRef: []ExcludeRef{
+					{
+						Kind:        "Deployment",
+						NamePattern: "preview-(.*",
+					},
+				},
+			},
+		},
+		{
+			name:          "fails - invalid NameGlob in ExcludeRef item",
+			expectedError: "excludeRef.nameGlob is invalid: syntax error in pattern",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "13:15",
+				WakeUpTime: "13:20",
+				ExcludeRef: []ExcludeRef{
+					{
+						Kind:     "Deployment",
+						NameGlob: "preview-[",
+					},
+				},
+			},
+		},
+		{
+			name: "ok - MatchField in ExcludeRef item",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "13:15",
+				WakeUpTime: "13:20",
+				ExcludeRef: []ExcludeRef{
+					{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						MatchField: &FieldMatcher{
+							JSONPath: "{.spec.template.spec.priorityClassName}",
+							Values:   []string{"critical"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:          "fails - MatchField without JSONPath in ExcludeRef item",
+			expectedError: "excludeRef.matchField.jsonPath is required",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "13:15",
+				WakeUpTime: "13:20",
+				ExcludeRef: []ExcludeRef{
+					{
+						MatchField: &FieldMatcher{Values: []string{"critical"}},
+					},
+				},
+			},
+		},
+		{
+			name:          "fails - invalid JSONPath in ExcludeRef item",
+			expectedError: "excludeRef.matchField.jsonPath is invalid: unterminated array",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "13:15",
+				WakeUpTime: "13:20",
+				ExcludeRef: []ExcludeRef{
+					{
+						MatchField: &FieldMatcher{JSONPath: "{.spec.containers[0}"},
+					},
+				},
+			},
+		},
+		{
+			name:          "fails - Name and MatchField both sets in ExcludeRef item",
+			expectedError: "excludeRef is invalid. matchField must be set only with kind and apiVersion fields",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "13:15",
+				WakeUpTime: "13:20",
+				ExcludeRef: []ExcludeRef{
+					{
+						Name:       "backend",
+						MatchField: &FieldMatcher{JSONPath: "{.spec.replicas}"},
+					},
+				},
+			},
+		},
+		{
+			name:          "fails - Name and OwnerKind both sets in ExcludeRef item",
+			expectedError: `excludeRef is invalid. Must have set: matchLabels, name,apiVersion and kind fields or ownerKind`,
 			sleepInfoSpec: SleepInfoSpec{
 				Weekdays:   "1-5",
 				SleepTime:  "13:15",
@@ -143,13 +323,15 @@ func TestValidateSleepInfo(t *testing.T) {
 					{
 						APIVersion: "apps/v1",
 						Kind:       "Deployment",
+						Name:       "backend",
+						OwnerKind:  "Rollout",
 					},
 				},
 			},
 		},
 		{
 			name:          "fails - Name and MatchLabels both sets in ExcludeRef item",
-			expectedError: `excludeRef is invalid. Must have set: matchLabels or name,apiVersion and kind fields`,
+			expectedError: `excludeRef is invalid. Must have set: matchLabels, name,apiVersion and kind fields or ownerKind`,
 			sleepInfoSpec: SleepInfoSpec{
 				Weekdays:  "1-5",
 				SleepTime: "13:15",
@@ -191,6 +373,491 @@ func TestValidateSleepInfo(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:          "fails - invalid cronJobsSelector include",
+			expectedError: `cronJobsSelector.include is invalid: "Foo" is not a valid label selector operator`,
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:  "1-5",
+				SleepTime: "13:15",
+				CronJobsSelector: &CronJobsSelector{
+					Include: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{Key: "app", Operator: "Foo"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:          "fails - invalid cronJobsSelector exclude",
+			expectedError: `cronJobsSelector.exclude is invalid: "Foo" is not a valid label selector operator`,
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:  "1-5",
+				SleepTime: "13:15",
+				CronJobsSelector: &CronJobsSelector{
+					Exclude: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{Key: "app", Operator: "Foo"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "ok - sleepCondition",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:  "1-5",
+				SleepTime: "13:15",
+				SleepCondition: &SleepCondition{
+					PrometheusQuery: "sum(rate(http_requests_total[30m])) == 0",
+					FailurePolicy:   SleepConditionFailurePolicyOpen,
+				},
+			},
+		},
+		{
+			name:          "fails - sleepCondition without query",
+			expectedError: "sleepCondition.prometheusQuery is required",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:       "1-5",
+				SleepTime:      "13:15",
+				SleepCondition: &SleepCondition{},
+			},
+		},
+		{
+			name:          "fails - sleepCondition with invalid failure policy",
+			expectedError: "sleepCondition.failurePolicy is invalid: must be Open or Closed",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:  "1-5",
+				SleepTime: "13:15",
+				SleepCondition: &SleepCondition{
+					PrometheusQuery: "up == 0",
+					FailurePolicy:   "Ignore",
+				},
+			},
+		},
+		{
+			name: "ok - idleSleep",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				IdleSleep: &IdleSleep{
+					Period: metav1.Duration{Duration: 2 * time.Hour},
+				},
+			},
+		},
+		{
+			name:          "fails - idleSleep without period",
+			expectedError: "idleSleep.period must be greater than 0",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:  "1-5",
+				SleepTime: "13:15",
+				IdleSleep: &IdleSleep{},
+			},
+		},
+		{
+			name:          "fails - negative minAgeBeforeSleep",
+			expectedError: "sleepPolicy.minAgeBeforeSleep must not be negative",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:  "1-5",
+				SleepTime: "13:15",
+				SleepPolicy: &SleepPolicy{
+					MinAgeBeforeSleep: &metav1.Duration{Duration: -time.Minute},
+				},
+			},
+		},
+		{
+			name:          "fails - zero timeout of the termination check",
+			expectedError: "sleepPolicy.terminationCheck.timeout must be greater than 0",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:  "1-5",
+				SleepTime: "13:15",
+				SleepPolicy: &SleepPolicy{
+					TerminationCheck: &PodTerminationCheck{Timeout: &metav1.Duration{}},
+				},
+			},
+		},
+		{
+			name: "ok - declared replicas source",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				WakeUpPolicy: &WakeUpPolicy{
+					ReplicasSource: ReplicasSourceDeclared,
+				},
+			},
+		},
+		{
+			name: "ok - autoscaler replicas source",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				WakeUpPolicy: &WakeUpPolicy{
+					ReplicasSource: ReplicasSourceAutoscaler,
+				},
+			},
+		},
+		{
+			name:          "fails - invalid replicas source",
+			expectedError: "wakeUpPolicy.replicasSource is invalid: must be one of Snapshot, Declared, SnapshotAtLeastMin or Autoscaler",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				WakeUpPolicy: &WakeUpPolicy{
+					ReplicasSource: "Unknown",
+				},
+			},
+		},
+		{
+			name: "ok - restore the deployments already at zero replicas",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				WakeUpPolicy: &WakeUpPolicy{
+					ReplicasSource: ReplicasSourceDeclared,
+					AlreadyZero:    AlreadyZeroRestore,
+				},
+			},
+		},
+		{
+			name:          "fails - invalid already zero policy",
+			expectedError: "wakeUpPolicy.alreadyZero is invalid: must be Keep or Restore",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				WakeUpPolicy: &WakeUpPolicy{
+					AlreadyZero: "Unknown",
+				},
+			},
+		},
+		{
+			name: "ok - skip rejected resources",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:        "1-5",
+				SleepTime:       "20:00",
+				WakeUpTime:      "08:00",
+				RejectionPolicy: RejectionPolicySkip,
+			},
+		},
+		{
+			name:          "fails - invalid rejection policy",
+			expectedError: "rejectionPolicy is invalid: must be Fail or Skip",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:        "1-5",
+				SleepTime:       "20:00",
+				WakeUpTime:      "08:00",
+				RejectionPolicy: "Ignore",
+			},
+		},
+		{
+			name: "ok - expiry",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				Expiry: &Expiry{
+					After:      metav1.Duration{Duration: 72 * time.Hour},
+					WebhookURL: "https://ci.example.com/previews/expired",
+					DeleteSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"app.kubernetes.io/part-of": "preview"},
+					},
+				},
+			},
+		},
+		{
+			name: "ok - service account",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:           "1-5",
+				SleepTime:          "20:00",
+				WakeUpTime:         "08:00",
+				ServiceAccountName: "kube-green",
+			},
+		},
+		{
+			name:          "fails - invalid service account name",
+			expectedError: "serviceAccountName \"Kube_Green\" is not a valid name",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:           "1-5",
+				SleepTime:          "20:00",
+				WakeUpTime:         "08:00",
+				ServiceAccountName: "Kube_Green",
+			},
+		},
+		{
+			name:          "fails - expiry without after",
+			expectedError: "expiry.after must be greater than 0",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				Expiry:     &Expiry{},
+			},
+		},
+		{
+			name:          "fails - expiry with invalid webhook url",
+			expectedError: "expiry.webhookURL is invalid: scheme must be http or https",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				Expiry: &Expiry{
+					After:      metav1.Duration{Duration: 72 * time.Hour},
+					WebhookURL: "ftp://ci.example.com",
+				},
+			},
+		},
+		{
+			name:          "fails - expiry with empty delete selector",
+			expectedError: "expiry.deleteSelector is invalid: must not be empty",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				Expiry: &Expiry{
+					After:          metav1.Duration{Duration: 72 * time.Hour},
+					DeleteSelector: &metav1.LabelSelector{},
+				},
+			},
+		},
+		{
+			name: "ok - wake up verification",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				WakeUpPolicy: &WakeUpPolicy{
+					Verification: &WakeUpVerification{
+						URL:     "http://frontend.my-namespace/healthz",
+						Delay:   &metav1.Duration{Duration: 2 * time.Minute},
+						Timeout: &metav1.Duration{Duration: 5 * time.Minute},
+					},
+				},
+			},
+		},
+		{
+			name:          "fails - wake up verification with both url and job",
+			expectedError: "wakeUpPolicy.verification is invalid: exactly one of url and jobFromCronJob must be set",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				WakeUpPolicy: &WakeUpPolicy{
+					Verification: &WakeUpVerification{URL: "http://frontend", JobFromCronJob: "smoke-test"},
+				},
+			},
+		},
+		{
+			name:          "fails - wake up verification without url and job",
+			expectedError: "wakeUpPolicy.verification is invalid: exactly one of url and jobFromCronJob must be set",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				WakeUpPolicy: &WakeUpPolicy{
+					Verification: &WakeUpVerification{},
+				},
+			},
+		},
+		{
+			name:          "fails - wake up verification with invalid url scheme",
+			expectedError: "wakeUpPolicy.verification.url is invalid: scheme must be http or https",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				WakeUpPolicy: &WakeUpPolicy{
+					Verification: &WakeUpVerification{URL: "frontend:8080"},
+				},
+			},
+		},
+		{
+			name:          "fails - wake up verification with zero timeout",
+			expectedError: "wakeUpPolicy.verification.timeout must be greater than 0",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				WakeUpPolicy: &WakeUpPolicy{
+					Verification: &WakeUpVerification{JobFromCronJob: "smoke-test", Timeout: &metav1.Duration{}},
+				},
+			},
+		},
+		{
+			name: "ok - sleep strategy",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:    "1-5",
+				SleepTime:   "20:00",
+				WakeUpTime:  "08:00",
+				SleepPolicy: &SleepPolicy{Strategy: SleepStrategyPauseRollout},
+			},
+		},
+		{
+			name:          "fails - invalid sleep strategy",
+			expectedError: "sleepPolicy.strategy is invalid: must be scaleToZero, pauseRollout, patchOnly or keepOne",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:    "1-5",
+				SleepTime:   "20:00",
+				WakeUpTime:  "08:00",
+				SleepPolicy: &SleepPolicy{Strategy: "delete"},
+			},
+		},
+		{
+			name: "ok - classes",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				Classes: []SleepClass{
+					{Name: "batch"},
+					{Name: "web", Replicas: getPtr[int32](1)},
+					{Name: "critical", Skip: true},
+				},
+			},
+		},
+		{
+			name:          "fails - class without name",
+			expectedError: "classes is invalid: name is required",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				Classes:    []SleepClass{{Replicas: getPtr[int32](1)}},
+			},
+		},
+		{
+			name:          "fails - duplicate class",
+			expectedError: "classes is invalid: duplicate class web",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				Classes:    []SleepClass{{Name: "web"}, {Name: "web", Skip: true}},
+			},
+		},
+		{
+			name:          "fails - negative class replicas",
+			expectedError: "classes is invalid: replicas of class web must not be negative",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				Classes:    []SleepClass{{Name: "web", Replicas: getPtr[int32](-1)}},
+			},
+		},
+		{
+			name: "ok - preset",
+			sleepInfoSpec: SleepInfoSpec{
+				Preset: SchedulePresetOfficeHours,
+			},
+		},
+		{
+			name:          "fails - same sleep and wake up time",
+			expectedError: "sleepAt and wakeUpAt must be different: the sleep window is ambiguous, actual: 20:00",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "20:00",
+			},
+		},
+		{
+			name: "ok - sleep window across the midnight",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "22:00",
+				WakeUpTime: "6:00",
+			},
+		},
+		{
+			name: "ok - sleep on friday and wake up on monday",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:       "*",
+				SleepWeekdays:  "5",
+				WakeUpWeekdays: "1",
+				SleepTime:      "20:00",
+				WakeUpTime:     "08:00",
+			},
+		},
+		{
+			name: "ok - same time on different weekdays",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:       "*",
+				SleepWeekdays:  "5",
+				WakeUpWeekdays: "1",
+				SleepTime:      "20:00",
+				WakeUpTime:     "20:00",
+			},
+		},
+		{
+			name: "ok - sleep on the nights before the weekdays",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:      "1-5",
+				SleepWeekdays: "0-4",
+				SleepTime:     "20:00",
+				WakeUpTime:    "08:00",
+			},
+		},
+		{
+			name:          "fails - two wake ups without a sleep",
+			expectedError: "sleepWeekdays and wakeUpWeekdays are invalid: the sleeps and the wake ups must alternate: the wake up at 2024-01-01T08:00:00Z is followed by another wake up at 2024-01-03T08:00:00Z",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:       "*",
+				SleepWeekdays:  "5",
+				WakeUpWeekdays: "1,3",
+				SleepTime:      "20:00",
+				WakeUpTime:     "08:00",
+			},
+		},
+		{
+			name:          "fails - two sleeps without a wake up",
+			expectedError: "sleepWeekdays and wakeUpWeekdays are invalid: the sleeps and the wake ups must alternate: the sleep at 2024-01-01T20:00:00Z is followed by another sleep at 2024-01-02T20:00:00Z",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:       "*",
+				SleepWeekdays:  "1-5",
+				WakeUpWeekdays: "1",
+				SleepTime:      "20:00",
+				WakeUpTime:     "08:00",
+			},
+		},
+		{
+			name:          "fails - wakeUpWeekdays without wakeUpAt",
+			expectedError: "wakeUpWeekdays is set without wakeUpAt: the namespace never wakes up",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:       "*",
+				WakeUpWeekdays: "1",
+				SleepTime:      "20:00",
+			},
+		},
+		{
+			name:          "fails - preset not supported",
+			expectedError: "preset lunchBreak not supported: must be one of officeHours, extendedOfficeHours or nights",
+			sleepInfoSpec: SleepInfoSpec{
+				Preset:    "lunchBreak",
+				Weekdays:  "1-5",
+				SleepTime: "13:15",
+			},
+		},
+		{
+			name: "ok - cronJobsSelector",
+			sleepInfoSpec: SleepInfoSpec{
+				Weekdays:  "1-5",
+				SleepTime: "13:15",
+				CronJobsSelector: &CronJobsSelector{
+					Include: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"app": "batch"},
+					},
+					Exclude: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{Key: "nightly", Operator: metav1.LabelSelectorOpExists},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -284,3 +951,294 @@ func TestSleepInfoValidation(t *testing.T) {
 		require.NoError(t, (&SleepInfo{}).ValidateDelete())
 	})
 }
+
+func TestSleepInfoValidator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, AddToScheme(scheme))
+
+	getSleepInfo := func(namespace, name string, dependsOn ...SleepInfoReference) *SleepInfo {
+		return &SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				DependsOn:  dependsOn,
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		existing      []*SleepInfo
+		sleepInfo     *SleepInfo
+		expectedError string
+	}{
+		{
+			name:      "ok - without dependencies",
+			existing:  []*SleepInfo{getSleepInfo("backend", "sleepinfo", SleepInfoReference{Namespace: "frontend"})},
+			sleepInfo: getSleepInfo("frontend", "sleepinfo"),
+		},
+		{
+			name:      "ok - dependencies without cycle",
+			existing:  []*SleepInfo{getSleepInfo("backend", "sleepinfo", SleepInfoReference{Namespace: "database"})},
+			sleepInfo: getSleepInfo("frontend", "sleepinfo", SleepInfoReference{Namespace: "backend"}),
+		},
+		{
+			name:      "ok - dependency on the other SleepInfo of the namespace",
+			existing:  []*SleepInfo{getSleepInfo("frontend", "other")},
+			sleepInfo: getSleepInfo("frontend", "sleepinfo", SleepInfoReference{Namespace: "frontend"}),
+		},
+		{
+			name:          "fails - dependency on itself",
+			sleepInfo:     getSleepInfo("frontend", "sleepinfo", SleepInfoReference{Name: "sleepinfo"}),
+			expectedError: "dependsOn is invalid: dependency cycle frontend/sleepinfo -> frontend/sleepinfo",
+		},
+		{
+			name: "fails - dependency cycle",
+			existing: []*SleepInfo{
+				getSleepInfo("backend", "sleepinfo", SleepInfoReference{Namespace: "database", Name: "sleepinfo"}),
+				getSleepInfo("database", "sleepinfo", SleepInfoReference{Namespace: "frontend"}),
+			},
+			sleepInfo:     getSleepInfo("frontend", "sleepinfo", SleepInfoReference{Namespace: "backend"}),
+			expectedError: "dependsOn is invalid: dependency cycle frontend/sleepinfo -> backend/sleepinfo -> database/sleepinfo -> frontend/sleepinfo",
+		},
+		{
+			name:          "fails - reference without namespace and name",
+			sleepInfo:     getSleepInfo("frontend", "sleepinfo", SleepInfoReference{}),
+			expectedError: "dependsOn is invalid: namespace or name must be set",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(scheme)
+			for _, sleepInfo := range test.existing {
+				builder = builder.WithObjects(sleepInfo)
+			}
+			validator := &SleepInfoValidator{Client: builder.Build()}
+
+			createErr := validator.ValidateCreate(context.Background(), test.sleepInfo)
+			updateErr := validator.ValidateUpdate(context.Background(), getSleepInfo(test.sleepInfo.Namespace, test.sleepInfo.Name), test.sleepInfo)
+			if test.expectedError != "" {
+				require.EqualError(t, createErr, test.expectedError)
+				require.EqualError(t, updateErr, test.expectedError)
+				return
+			}
+			require.NoError(t, createErr)
+			require.NoError(t, updateErr)
+		})
+	}
+}
+
+func TestSleepInfoValidatorProtectedNamespaces(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, AddToScheme(scheme))
+
+	getNamespace := func(name string, labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		getNamespace("labeled", map[string]string{ProtectedNamespaceLabel: "true"}),
+		getNamespace("not-protected", map[string]string{ProtectedNamespaceLabel: "false"}),
+	).Build()
+	validator := &SleepInfoValidator{Client: c, ProtectedNamespaces: []string{"platform-*"}}
+
+	tests := []struct {
+		namespace     string
+		expectedError string
+	}{
+		{namespace: "kube-system", expectedError: "namespace kube-system is protected, SleepInfo are not allowed"},
+		{namespace: "kube-public", expectedError: "namespace kube-public is protected, SleepInfo are not allowed"},
+		{namespace: "platform-ingress", expectedError: "namespace platform-ingress is protected, SleepInfo are not allowed"},
+		{namespace: "labeled", expectedError: "namespace labeled is protected, SleepInfo are not allowed"},
+		{namespace: "not-protected"},
+		{namespace: "not-existent"},
+	}
+	for _, test := range tests {
+		t.Run(test.namespace, func(t *testing.T) {
+			sleepInfo := &SleepInfo{
+				ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: test.namespace},
+				Spec: SleepInfoSpec{
+					Weekdays:  "1-5",
+					SleepTime: "20:00",
+				},
+			}
+			createErr := validator.ValidateCreate(context.Background(), sleepInfo)
+			updateErr := validator.ValidateUpdate(context.Background(), sleepInfo, sleepInfo)
+			if test.expectedError != "" {
+				require.EqualError(t, createErr, test.expectedError)
+				require.EqualError(t, updateErr, test.expectedError)
+				return
+			}
+			require.NoError(t, createErr)
+			require.NoError(t, updateErr)
+		})
+	}
+
+	t.Run("namespace labels ignored with namespace scoped permissions", func(t *testing.T) {
+		validator := &SleepInfoValidator{Client: c, ProtectedNamespaces: []string{"platform-*"}, IgnoreNamespaceLabels: true}
+		getSleepInfo := func(namespace string) *SleepInfo {
+			return &SleepInfo{
+				ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: namespace},
+				Spec:       SleepInfoSpec{Weekdays: "1-5", SleepTime: "20:00"},
+			}
+		}
+		require.NoError(t, validator.ValidateCreate(context.Background(), getSleepInfo("labeled")))
+		require.EqualError(t, validator.ValidateCreate(context.Background(), getSleepInfo("platform-ingress")), "namespace platform-ingress is protected, SleepInfo are not allowed")
+	})
+}
+
+func TestSleepInfoDefaulter(t *testing.T) {
+	suspendDeployments := true
+	dontSuspendDeployments := false
+
+	tests := []struct {
+		name            string
+		defaultTimeZone string
+		spec            SleepInfoSpec
+		expected        SleepInfoSpec
+	}{
+		{
+			name: "fill defaults",
+			spec: SleepInfoSpec{
+				SleepTime: "20:00",
+			},
+			expected: SleepInfoSpec{
+				Weekdays:           "*",
+				SleepTime:          "20:00",
+				TimeZone:           "UTC",
+				SuspendDeployments: &suspendDeployments,
+			},
+		},
+		{
+			name:            "default time zone from controller configuration",
+			defaultTimeZone: "Europe/Rome",
+			spec: SleepInfoSpec{
+				Weekdays:  "1-5",
+				SleepTime: "20:00",
+			},
+			expected: SleepInfoSpec{
+				Weekdays:           "1-5",
+				SleepTime:          "20:00",
+				TimeZone:           "Europe/Rome",
+				SuspendDeployments: &suspendDeployments,
+			},
+		},
+		{
+			name:            "keep set values",
+			defaultTimeZone: "Europe/Rome",
+			spec: SleepInfoSpec{
+				Weekdays:           "1-5",
+				SleepTime:          "20:00",
+				WakeUpTime:         "08:00",
+				TimeZone:           "America/New_York",
+				SuspendDeployments: &dontSuspendDeployments,
+			},
+			expected: SleepInfoSpec{
+				Weekdays:           "1-5",
+				SleepTime:          "20:00",
+				WakeUpTime:         "08:00",
+				TimeZone:           "America/New_York",
+				SuspendDeployments: &dontSuspendDeployments,
+			},
+		},
+		{
+			name: "normalize weekdays and times",
+			spec: SleepInfoSpec{
+				Weekdays:   "Mon-fri, sunday",
+				SleepTime:  "8:0",
+				WakeUpTime: " 19:30 ",
+				TimeZone:   "UTC",
+			},
+			expected: SleepInfoSpec{
+				Weekdays:           "1-5,0",
+				SleepTime:          "08:00",
+				WakeUpTime:         "19:30",
+				TimeZone:           "UTC",
+				SuspendDeployments: &suspendDeployments,
+			},
+		},
+		{
+			name: "normalize last and nth weekdays",
+			spec: SleepInfoSpec{
+				Weekdays:   "FriL, mon#1",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				TimeZone:   "UTC",
+			},
+			expected: SleepInfoSpec{
+				Weekdays:           "5L,1#1",
+				SleepTime:          "20:00",
+				WakeUpTime:         "08:00",
+				TimeZone:           "UTC",
+				SuspendDeployments: &suspendDeployments,
+			},
+		},
+		{
+			name: "normalize weekdays per operation",
+			spec: SleepInfoSpec{
+				SleepWeekdays:  "Fri",
+				WakeUpWeekdays: "mon",
+				SleepTime:      "20:00",
+				WakeUpTime:     "08:00",
+				TimeZone:       "UTC",
+			},
+			expected: SleepInfoSpec{
+				Weekdays:           "*",
+				SleepWeekdays:      "5",
+				WakeUpWeekdays:     "1",
+				SleepTime:          "20:00",
+				WakeUpTime:         "08:00",
+				TimeZone:           "UTC",
+				SuspendDeployments: &suspendDeployments,
+			},
+		},
+		{
+			name: "expand preset",
+			spec: SleepInfoSpec{
+				Preset:    SchedulePresetOfficeHours,
+				SleepTime: "19:00",
+			},
+			expected: SleepInfoSpec{
+				Preset:             SchedulePresetOfficeHours,
+				Weekdays:           "1-5",
+				SleepTime:          "19:00",
+				WakeUpTime:         "08:00",
+				TimeZone:           "UTC",
+				SuspendDeployments: &suspendDeployments,
+			},
+		},
+		{
+			name: "keep cron expressions",
+			spec: SleepInfoSpec{
+				Weekdays:  "*/2",
+				SleepTime: "*:*/2",
+				TimeZone:  "UTC",
+			},
+			expected: SleepInfoSpec{
+				Weekdays:           "*/2",
+				SleepTime:          "*:*/2",
+				TimeZone:           "UTC",
+				SuspendDeployments: &suspendDeployments,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sleepInfo := &SleepInfo{Spec: test.spec}
+			defaulter := &SleepInfoDefaulter{DefaultTimeZone: test.defaultTimeZone}
+			require.NoError(t, defaulter.Default(context.Background(), sleepInfo))
+			require.Equal(t, test.expected, sleepInfo.Spec)
+			require.NoError(t, sleepInfo.validateSleepInfo())
+		})
+	}
+
+	t.Run("fails with other objects", func(t *testing.T) {
+		defaulter := &SleepInfoDefaulter{}
+		require.EqualError(t, defaulter.Default(context.Background(), &SleepInfoList{}), "expected a SleepInfo but got a *v1alpha1.SleepInfoList")
+	})
+}
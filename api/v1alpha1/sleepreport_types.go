@@ -0,0 +1,115 @@
+/*
+Copyright 2021.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DailySleepReport is the name of the SleepReport over the last day.
+	DailySleepReport = "daily"
+	// WeeklySleepReport is the name of the SleepReport over the last week.
+	WeeklySleepReport = "weekly"
+)
+
+// SleepReportSpec defines the desired state of SleepReport
+type SleepReportSpec struct {
+	// Window is the period, ending when the report is computed, over which
+	// the savings are computed, e.g. 24h.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	Window metav1.Duration `json:"window"`
+}
+
+// Savings is the capacity saved by the sleep of the namespaces.
+type Savings struct {
+	// SleptHours is the number of hours slept. The cluster-wide total is the
+	// sum of the hours slept by each namespace.
+	SleptHours resource.Quantity `json:"sleptHours"`
+	// PodHours is the number of pod-hours avoided.
+	PodHours resource.Quantity `json:"podHours"`
+	// CPURequestHours is the CPU requested by the avoided pods, in cpu-hours.
+	CPURequestHours resource.Quantity `json:"cpuRequestHours"`
+	// MemoryRequestHours is the memory requested by the avoided pods, in
+	// byte-hours.
+	MemoryRequestHours resource.Quantity `json:"memoryRequestHours"`
+	// EstimatedCost is the estimated cost of the CPU and the memory
+	// requested by the avoided pods, in the currency of the report. It is set
+	// only if the prices are configured in the controller.
+	// +optional
+	EstimatedCost string `json:"estimatedCost,omitempty"`
+}
+
+// NamespaceSavings is the capacity saved by the sleep of a namespace.
+type NamespaceSavings struct {
+	Namespace string `json:"namespace"`
+	Savings   `json:",inline"`
+}
+
+// SleepReportStatus defines the observed state of SleepReport
+type SleepReportStatus struct {
+	// LastUpdateTime is when the report was computed, the end of its window.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Last Update Time"
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+	// Currency is the currency of the estimated costs.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Currency"
+	Currency string `json:"currency,omitempty"`
+	// Total is the capacity saved in the whole cluster.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Total"
+	Total Savings `json:"total,omitempty"`
+	// Namespaces is the capacity saved by each namespace.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Namespaces"
+	Namespaces []NamespaceSavings `json:"namespaces,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=sleepreports,scope=Cluster
+//+kubebuilder:printcolumn:name="Window",type=string,JSONPath=`.spec.window`
+//+kubebuilder:printcolumn:name="Slept Hours",type=string,JSONPath=`.status.total.sleptHours`
+//+kubebuilder:printcolumn:name="Pod Hours",type=string,JSONPath=`.status.total.podHours`
+//+kubebuilder:printcolumn:name="Estimated Cost",type=string,JSONPath=`.status.total.estimatedCost`
+//+kubebuilder:printcolumn:name="Currency",type=string,JSONPath=`.status.currency`
+//+kubebuilder:printcolumn:name="Last Update",type=date,JSONPath=`.status.lastUpdateTime`
+//+operator-sdk:csv:customresourcedefinitions:displayName="SleepReport"
+
+// SleepReport is the Schema for the sleepreports API. It reports the
+// capacity saved by the sleep of the namespaces over a window, and it is
+// updated periodically by the controller.
+type SleepReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SleepReportSpec   `json:"spec,omitempty"`
+	Status SleepReportStatus `json:"status,omitempty"`
+}
+
+// Validate returns an error if the SleepReport is not valid.
+func (r SleepReport) Validate() error {
+	if r.Spec.Window.Duration <= 0 {
+		return fmt.Errorf("window is invalid: must be positive")
+	}
+	return nil
+}
+
+//+kubebuilder:object:root=true
+
+// SleepReportList contains a list of SleepReport
+type SleepReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SleepReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SleepReport{}, &SleepReportList{})
+}
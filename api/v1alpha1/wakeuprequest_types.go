@@ -0,0 +1,110 @@
+/*
+Copyright 2021.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// WakeUpRequestPhaseWakingUp is the phase of a WakeUpRequest waiting for
+	// its SleepInfo to wake up.
+	WakeUpRequestPhaseWakingUp = "WakingUp"
+	// WakeUpRequestPhaseFailed is the phase of a WakeUpRequest which could
+	// not wake up its SleepInfo. The failed requests are not deleted.
+	WakeUpRequestPhaseFailed = "Failed"
+)
+
+// WakeUpRequestSpec defines the desired state of WakeUpRequest
+type WakeUpRequestSpec struct {
+	// SleepInfos are the names of the SleepInfo of the namespace to wake up.
+	// If empty, all the SleepInfo of the namespace are woken up.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	SleepInfos []string `json:"sleepInfos,omitempty"`
+	// Reason is why the wake up is requested. It is reported in the events.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	Reason string `json:"reason,omitempty"`
+	// Duration is how long the SleepInfo stay awake before going back to
+	// sleep. If empty, they stay awake until their next scheduled sleep.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// WakeUpRequestStatus defines the observed state of WakeUpRequest
+type WakeUpRequestStatus struct {
+	// Phase is WakingUp while the SleepInfo wake up, and Failed if they do not
+	// wake up in time. The request is deleted when the SleepInfo are awake.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Phase"
+	Phase string `json:"phase,omitempty"`
+	// RequestedAt is when the wake up of the SleepInfo has been requested.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Requested At"
+	RequestedAt metav1.Time `json:"requestedAt,omitempty"`
+	// SleepInfos are the names of the sleeping SleepInfo whose wake up has
+	// been requested.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="SleepInfos"
+	SleepInfos []string `json:"sleepInfos,omitempty"`
+	// Message is the reason of the failure.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Message"
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.spec.reason`
+//+kubebuilder:printcolumn:name="Requested At",type=date,JSONPath=`.status.requestedAt`
+//+operator-sdk:csv:customresourcedefinitions:displayName="WakeUpRequest"
+
+// WakeUpRequest is the Schema for the wakeuprequests API. When created, it
+// wakes up the sleeping SleepInfo of its namespace, restoring the resources
+// from their stored state regardless of the schedule, and it is deleted by
+// the controller once they are awake.
+type WakeUpRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WakeUpRequestSpec   `json:"spec,omitempty"`
+	Status WakeUpRequestStatus `json:"status,omitempty"`
+}
+
+// Validate returns an error if the WakeUpRequest is not valid.
+func (r WakeUpRequest) Validate() error {
+	if r.Spec.Duration != nil && r.Spec.Duration.Duration <= 0 {
+		return fmt.Errorf("duration is invalid: must be positive")
+	}
+	return nil
+}
+
+// GetDuration returns how long the SleepInfo stay awake, or 0 if they stay
+// awake until their next scheduled sleep.
+func (r WakeUpRequest) GetDuration() time.Duration {
+	if r.Spec.Duration == nil {
+		return 0
+	}
+	return r.Spec.Duration.Duration
+}
+
+//+kubebuilder:object:root=true
+
+// WakeUpRequestList contains a list of WakeUpRequest
+type WakeUpRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WakeUpRequest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WakeUpRequest{}, &WakeUpRequestList{})
+}
@@ -2,8 +2,11 @@ package v1alpha1
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -23,6 +26,24 @@ func TestDeepCopy(t *testing.T) {
 				WakeUpTime:         "*:20", // at minute 20
 				SuspendCronjobs:    true,
 				SuspendDeployments: getPtr(false),
+				SleepCondition: &SleepCondition{
+					PrometheusQuery: "sum(rate(http_requests_total[30m])) == 0",
+					FailurePolicy:   SleepConditionFailurePolicyOpen,
+				},
+				CronJobsSelector: &CronJobsSelector{
+					Include: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"app": "batch"},
+					},
+					Exclude: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{Key: "nightly", Operator: metav1.LabelSelectorOpExists},
+						},
+					},
+				},
+				Classes: []SleepClass{
+					{Name: "batch"},
+					{Name: "web", Replicas: getPtr[int32](1)},
+				},
 				ExcludeRef: []ExcludeRef{
 					{
 						Name: "",
@@ -37,6 +58,35 @@ func TestDeepCopy(t *testing.T) {
 			Status: SleepInfoStatus{
 				OperationType:    "sleep",
 				LastScheduleTime: metav1.Now(),
+				OperationsHistory: []OperationHistory{
+					{
+						Type: "SLEEP",
+						Time: metav1.Now(),
+						ResourceCounts: map[string]int{
+							"Deployment": 2,
+						},
+						Error: "some error",
+						RunningPods: &RunningPods{
+							Count: 2,
+							Requests: v1.ResourceList{
+								v1.ResourceCPU:    resource.MustParse("500m"),
+								v1.ResourceMemory: resource.MustParse("1Gi"),
+							},
+						},
+					},
+				},
+				SleepRecords: []SleepRecord{
+					{
+						Type: "SLEEP",
+						Time: metav1.Now(),
+						RunningPods: &RunningPods{
+							Count: 2,
+							Requests: v1.ResourceList{
+								v1.ResourceCPU: resource.MustParse("500m"),
+							},
+						},
+					},
+				},
 			},
 		}
 
@@ -47,8 +97,53 @@ func TestDeepCopy(t *testing.T) {
 
 		require.Equal(t, &sleepInfo.Spec, sleepInfo.Spec.DeepCopy())
 
+		require.Equal(t, sleepInfo.Spec.CronJobsSelector, sleepInfo.Spec.CronJobsSelector.DeepCopy())
+		require.Equal(t, sleepInfo.Spec.SleepCondition, sleepInfo.Spec.SleepCondition.DeepCopy())
+
 		require.Equal(t, &sleepInfo.Spec.ExcludeRef[0], sleepInfo.Spec.ExcludeRef[0].DeepCopy())
 		require.Equal(t, &sleepInfo.Spec.ExcludeRef[1], sleepInfo.Spec.ExcludeRef[1].DeepCopy())
+
+		require.Equal(t, &sleepInfo.Status.OperationsHistory[0], sleepInfo.Status.OperationsHistory[0].DeepCopy())
+		require.Equal(t, sleepInfo.Status.OperationsHistory[0].RunningPods, sleepInfo.Status.OperationsHistory[0].RunningPods.DeepCopy())
+		require.Equal(t, &sleepInfo.Status.SleepRecords[0], sleepInfo.Status.SleepRecords[0].DeepCopy())
+	})
+
+	t.Run("sleep report", func(t *testing.T) {
+		savings := Savings{
+			SleptHours:         resource.MustParse("12"),
+			PodHours:           resource.MustParse("24"),
+			CPURequestHours:    resource.MustParse("6"),
+			MemoryRequestHours: resource.MustParse("12Gi"),
+		}
+		sleepReport := &SleepReport{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "SleepReport",
+				APIVersion: "kube-green.com/v1alpha1",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: DailySleepReport,
+			},
+			Spec: SleepReportSpec{
+				Window: metav1.Duration{Duration: 24 * time.Hour},
+			},
+			Status: SleepReportStatus{
+				LastUpdateTime: metav1.Now(),
+				Total:          savings,
+				Namespaces: []NamespaceSavings{
+					{Namespace: "team-a", Savings: savings},
+				},
+			},
+		}
+		sleepReportList := &SleepReportList{Items: []SleepReport{*sleepReport}}
+
+		require.Equal(t, sleepReport, sleepReport.DeepCopy())
+		require.Equal(t, sleepReport, sleepReport.DeepCopyObject())
+		require.Equal(t, &sleepReport.Spec, sleepReport.Spec.DeepCopy())
+		require.Equal(t, &sleepReport.Status, sleepReport.Status.DeepCopy())
+		require.Equal(t, &sleepReport.Status.Total, sleepReport.Status.Total.DeepCopy())
+		require.Equal(t, &sleepReport.Status.Namespaces[0], sleepReport.Status.Namespaces[0].DeepCopy())
+		require.Equal(t, sleepReportList, sleepReportList.DeepCopy())
+		require.Equal(t, sleepReportList, sleepReportList.DeepCopyObject())
 	})
 
 	t.Run("sleep info list", func(t *testing.T) {
@@ -109,10 +204,52 @@ func TestDeepCopy(t *testing.T) {
 			require.Nil(t, sleepInfoSpec.DeepCopy())
 		})
 
+		t.Run("sleep condition", func(t *testing.T) {
+			var sleepCondition *SleepCondition = nil
+
+			require.Nil(t, sleepCondition.DeepCopy())
+		})
+
+		t.Run("operation history", func(t *testing.T) {
+			var operationHistory *OperationHistory = nil
+
+			require.Nil(t, operationHistory.DeepCopy())
+		})
+
 		t.Run("status", func(t *testing.T) {
 			var sleepInfoStatus *SleepInfoStatus = nil
 
 			require.Nil(t, sleepInfoStatus.DeepCopy())
 		})
+
+		t.Run("running pods", func(t *testing.T) {
+			var runningPods *RunningPods = nil
+
+			require.Nil(t, runningPods.DeepCopy())
+		})
+
+		t.Run("sleep record", func(t *testing.T) {
+			var sleepRecord *SleepRecord = nil
+
+			require.Nil(t, sleepRecord.DeepCopy())
+		})
+
+		t.Run("sleep report", func(t *testing.T) {
+			var sleepReport *SleepReport = nil
+			var sleepReportList *SleepReportList = nil
+			var sleepReportSpec *SleepReportSpec = nil
+			var sleepReportStatus *SleepReportStatus = nil
+			var savings *Savings = nil
+			var namespaceSavings *NamespaceSavings = nil
+
+			require.Nil(t, sleepReport.DeepCopy())
+			require.Nil(t, sleepReport.DeepCopyObject())
+			require.Nil(t, sleepReportList.DeepCopy())
+			require.Nil(t, sleepReportList.DeepCopyObject())
+			require.Nil(t, sleepReportSpec.DeepCopy())
+			require.Nil(t, sleepReportStatus.DeepCopy())
+			require.Nil(t, savings.DeepCopy())
+			require.Nil(t, namespaceSavings.DeepCopy())
+		})
 	})
 }
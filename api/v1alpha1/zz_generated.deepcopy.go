@@ -10,9 +10,36 @@ Copyright 2021.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CronJobsSelector) DeepCopyInto(out *CronJobsSelector) {
+	*out = *in
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronJobsSelector.
+func (in *CronJobsSelector) DeepCopy() *CronJobsSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(CronJobsSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExcludeRef) DeepCopyInto(out *ExcludeRef) {
 	*out = *in
@@ -23,6 +50,11 @@ func (in *ExcludeRef) DeepCopyInto(out *ExcludeRef) {
 			(*out)[key] = val
 		}
 	}
+	if in.MatchField != nil {
+		in, out := &in.MatchField, &out.MatchField
+		*out = new(FieldMatcher)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExcludeRef.
@@ -35,6 +67,376 @@ func (in *ExcludeRef) DeepCopy() *ExcludeRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Expiry) DeepCopyInto(out *Expiry) {
+	*out = *in
+	out.After = in.After
+	if in.DeleteSelector != nil {
+		in, out := &in.DeleteSelector, &out.DeleteSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Expiry.
+func (in *Expiry) DeepCopy() *Expiry {
+	if in == nil {
+		return nil
+	}
+	out := new(Expiry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailedResource) DeepCopyInto(out *FailedResource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailedResource.
+func (in *FailedResource) DeepCopy() *FailedResource {
+	if in == nil {
+		return nil
+	}
+	out := new(FailedResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FieldMatcher) DeepCopyInto(out *FieldMatcher) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FieldMatcher.
+func (in *FieldMatcher) DeepCopy() *FieldMatcher {
+	if in == nil {
+		return nil
+	}
+	out := new(FieldMatcher)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdleSleep) DeepCopyInto(out *IdleSleep) {
+	*out = *in
+	out.Period = in.Period
+	if in.CPUThreshold != nil {
+		in, out := &in.CPUThreshold, &out.CPUThreshold
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.NetworkThreshold != nil {
+		in, out := &in.NetworkThreshold, &out.NetworkThreshold
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdleSleep.
+func (in *IdleSleep) DeepCopy() *IdleSleep {
+	if in == nil {
+		return nil
+	}
+	out := new(IdleSleep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobResult) DeepCopyInto(out *JobResult) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobResult.
+func (in *JobResult) DeepCopy() *JobResult {
+	if in == nil {
+		return nil
+	}
+	out := new(JobResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeGreenReport) DeepCopyInto(out *KubeGreenReport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeGreenReport.
+func (in *KubeGreenReport) DeepCopy() *KubeGreenReport {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeGreenReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KubeGreenReport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeGreenReportList) DeepCopyInto(out *KubeGreenReportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KubeGreenReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeGreenReportList.
+func (in *KubeGreenReportList) DeepCopy() *KubeGreenReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeGreenReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KubeGreenReportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeGreenReportSpec) DeepCopyInto(out *KubeGreenReportSpec) {
+	*out = *in
+	out.Window = in.Window
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeGreenReportSpec.
+func (in *KubeGreenReportSpec) DeepCopy() *KubeGreenReportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeGreenReportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeGreenReportStatus) DeepCopyInto(out *KubeGreenReportStatus) {
+	*out = *in
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	if in.AsleepNamespaces != nil {
+		in, out := &in.AsleepNamespaces, &out.AsleepNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailedSleepInfos != nil {
+		in, out := &in.FailedSleepInfos, &out.FailedSleepInfos
+		*out = make([]SleepInfoFailure, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeGreenReportStatus.
+func (in *KubeGreenReportStatus) DeepCopy() *KubeGreenReportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeGreenReportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceSavings) DeepCopyInto(out *NamespaceSavings) {
+	*out = *in
+	in.Savings.DeepCopyInto(&out.Savings)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceSavings.
+func (in *NamespaceSavings) DeepCopy() *NamespaceSavings {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceSavings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperationHistory) DeepCopyInto(out *OperationHistory) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.ResourceCounts != nil {
+		in, out := &in.ResourceCounts, &out.ResourceCounts
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.RunningPods != nil {
+		in, out := &in.RunningPods, &out.RunningPods
+		*out = new(RunningPods)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FailedResources != nil {
+		in, out := &in.FailedResources, &out.FailedResources
+		*out = make([]FailedResource, len(*in))
+		copy(*out, *in)
+	}
+	if in.Diffs != nil {
+		in, out := &in.Diffs, &out.Diffs
+		*out = make([]ResourceDiff, len(*in))
+		copy(*out, *in)
+	}
+	if in.VerificationJob != nil {
+		in, out := &in.VerificationJob, &out.VerificationJob
+		*out = new(JobResult)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationHistory.
+func (in *OperationHistory) DeepCopy() *OperationHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(OperationHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodTerminationCheck) DeepCopyInto(out *PodTerminationCheck) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTerminationCheck.
+func (in *PodTerminationCheck) DeepCopy() *PodTerminationCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(PodTerminationCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceDiff) DeepCopyInto(out *ResourceDiff) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceDiff.
+func (in *ResourceDiff) DeepCopy() *ResourceDiff {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceDiff)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunningPods) DeepCopyInto(out *RunningPods) {
+	*out = *in
+	if in.Requests != nil {
+		in, out := &in.Requests, &out.Requests
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunningPods.
+func (in *RunningPods) DeepCopy() *RunningPods {
+	if in == nil {
+		return nil
+	}
+	out := new(RunningPods)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Savings) DeepCopyInto(out *Savings) {
+	*out = *in
+	out.SleptHours = in.SleptHours.DeepCopy()
+	out.PodHours = in.PodHours.DeepCopy()
+	out.CPURequestHours = in.CPURequestHours.DeepCopy()
+	out.MemoryRequestHours = in.MemoryRequestHours.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Savings.
+func (in *Savings) DeepCopy() *Savings {
+	if in == nil {
+		return nil
+	}
+	out := new(Savings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SleepClass) DeepCopyInto(out *SleepClass) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SleepClass.
+func (in *SleepClass) DeepCopy() *SleepClass {
+	if in == nil {
+		return nil
+	}
+	out := new(SleepClass)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SleepCondition) DeepCopyInto(out *SleepCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SleepCondition.
+func (in *SleepCondition) DeepCopy() *SleepCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(SleepCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SleepInfo) DeepCopyInto(out *SleepInfo) {
 	*out = *in
@@ -62,6 +464,22 @@ func (in *SleepInfo) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SleepInfoFailure) DeepCopyInto(out *SleepInfoFailure) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SleepInfoFailure.
+func (in *SleepInfoFailure) DeepCopy() *SleepInfoFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(SleepInfoFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SleepInfoList) DeepCopyInto(out *SleepInfoList) {
 	*out = *in
@@ -94,6 +512,21 @@ func (in *SleepInfoList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SleepInfoReference) DeepCopyInto(out *SleepInfoReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SleepInfoReference.
+func (in *SleepInfoReference) DeepCopy() *SleepInfoReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SleepInfoReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SleepInfoSpec) DeepCopyInto(out *SleepInfoSpec) {
 	*out = *in
@@ -104,11 +537,53 @@ func (in *SleepInfoSpec) DeepCopyInto(out *SleepInfoSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.CronJobsSelector != nil {
+		in, out := &in.CronJobsSelector, &out.CronJobsSelector
+		*out = new(CronJobsSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.SuspendDeployments != nil {
 		in, out := &in.SuspendDeployments, &out.SuspendDeployments
 		*out = new(bool)
 		**out = **in
 	}
+	if in.SleepCondition != nil {
+		in, out := &in.SleepCondition, &out.SleepCondition
+		*out = new(SleepCondition)
+		**out = **in
+	}
+	if in.IdleSleep != nil {
+		in, out := &in.IdleSleep, &out.IdleSleep
+		*out = new(IdleSleep)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SleepPolicy != nil {
+		in, out := &in.SleepPolicy, &out.SleepPolicy
+		*out = new(SleepPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WakeUpPolicy != nil {
+		in, out := &in.WakeUpPolicy, &out.WakeUpPolicy
+		*out = new(WakeUpPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]SleepInfoReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Classes != nil {
+		in, out := &in.Classes, &out.Classes
+		*out = make([]SleepClass, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Expiry != nil {
+		in, out := &in.Expiry, &out.Expiry
+		*out = new(Expiry)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SleepInfoSpec.
@@ -125,6 +600,27 @@ func (in *SleepInfoSpec) DeepCopy() *SleepInfoSpec {
 func (in *SleepInfoStatus) DeepCopyInto(out *SleepInfoStatus) {
 	*out = *in
 	in.LastScheduleTime.DeepCopyInto(&out.LastScheduleTime)
+	if in.OperationsHistory != nil {
+		in, out := &in.OperationsHistory, &out.OperationsHistory
+		*out = make([]OperationHistory, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SleepRecords != nil {
+		in, out := &in.SleepRecords, &out.SleepRecords
+		*out = make([]SleepRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SleepInfoStatus.
@@ -136,3 +632,298 @@ func (in *SleepInfoStatus) DeepCopy() *SleepInfoStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SleepPolicy) DeepCopyInto(out *SleepPolicy) {
+	*out = *in
+	if in.MinAgeBeforeSleep != nil {
+		in, out := &in.MinAgeBeforeSleep, &out.MinAgeBeforeSleep
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.TerminationCheck != nil {
+		in, out := &in.TerminationCheck, &out.TerminationCheck
+		*out = new(PodTerminationCheck)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SleepPolicy.
+func (in *SleepPolicy) DeepCopy() *SleepPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(SleepPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SleepRecord) DeepCopyInto(out *SleepRecord) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.RunningPods != nil {
+		in, out := &in.RunningPods, &out.RunningPods
+		*out = new(RunningPods)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SleepRecord.
+func (in *SleepRecord) DeepCopy() *SleepRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(SleepRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SleepReport) DeepCopyInto(out *SleepReport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SleepReport.
+func (in *SleepReport) DeepCopy() *SleepReport {
+	if in == nil {
+		return nil
+	}
+	out := new(SleepReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SleepReport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SleepReportList) DeepCopyInto(out *SleepReportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SleepReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SleepReportList.
+func (in *SleepReportList) DeepCopy() *SleepReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(SleepReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SleepReportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SleepReportSpec) DeepCopyInto(out *SleepReportSpec) {
+	*out = *in
+	out.Window = in.Window
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SleepReportSpec.
+func (in *SleepReportSpec) DeepCopy() *SleepReportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SleepReportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SleepReportStatus) DeepCopyInto(out *SleepReportStatus) {
+	*out = *in
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	in.Total.DeepCopyInto(&out.Total)
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]NamespaceSavings, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SleepReportStatus.
+func (in *SleepReportStatus) DeepCopy() *SleepReportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SleepReportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WakeUpPolicy) DeepCopyInto(out *WakeUpPolicy) {
+	*out = *in
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(WakeUpVerification)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WakeUpPolicy.
+func (in *WakeUpPolicy) DeepCopy() *WakeUpPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(WakeUpPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WakeUpRequest) DeepCopyInto(out *WakeUpRequest) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WakeUpRequest.
+func (in *WakeUpRequest) DeepCopy() *WakeUpRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(WakeUpRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WakeUpRequest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WakeUpRequestList) DeepCopyInto(out *WakeUpRequestList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WakeUpRequest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WakeUpRequestList.
+func (in *WakeUpRequestList) DeepCopy() *WakeUpRequestList {
+	if in == nil {
+		return nil
+	}
+	out := new(WakeUpRequestList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WakeUpRequestList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WakeUpRequestSpec) DeepCopyInto(out *WakeUpRequestSpec) {
+	*out = *in
+	if in.SleepInfos != nil {
+		in, out := &in.SleepInfos, &out.SleepInfos
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WakeUpRequestSpec.
+func (in *WakeUpRequestSpec) DeepCopy() *WakeUpRequestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WakeUpRequestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WakeUpRequestStatus) DeepCopyInto(out *WakeUpRequestStatus) {
+	*out = *in
+	in.RequestedAt.DeepCopyInto(&out.RequestedAt)
+	if in.SleepInfos != nil {
+		in, out := &in.SleepInfos, &out.SleepInfos
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WakeUpRequestStatus.
+func (in *WakeUpRequestStatus) DeepCopy() *WakeUpRequestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WakeUpRequestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WakeUpVerification) DeepCopyInto(out *WakeUpVerification) {
+	*out = *in
+	if in.Delay != nil {
+		in, out := &in.Delay, &out.Delay
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WakeUpVerification.
+func (in *WakeUpVerification) DeepCopy() *WakeUpVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(WakeUpVerification)
+	in.DeepCopyInto(out)
+	return out
+}
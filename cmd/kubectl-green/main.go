@@ -0,0 +1,121 @@
+// kubectl-green is the kubectl plugin of kube-green. Installed in the PATH,
+// its commands are run as kubectl green <command>.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/pkg/lint"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const usage = `Usage: kubectl green <command> [flags]
+
+Commands:
+  lint    Check the SleepInfo in the files, without a cluster.
+`
+
+// errLintFailed is returned when the lint finds errors, which are already
+// printed.
+var errLintFailed = errors.New("lint failed")
+
+func main() {
+	// the webhook functions used by the lint log with the controller logger.
+	ctrl.SetLogger(logr.Discard())
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(1)
+	}
+	var err error
+	switch os.Args[1] {
+	case "lint":
+		err = runLint(os.Args[2:], os.Stdin, os.Stdout)
+	case "help", "-h", "--help":
+		fmt.Fprint(os.Stdout, usage)
+		return
+	default:
+		err = fmt.Errorf("unknown command %s\n\n%s", os.Args[1], usage)
+	}
+	if err != nil {
+		if !errors.Is(err, errLintFailed) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+}
+
+// files is a flag which can be repeated, as the -f flag of kubectl.
+type files []string
+
+func (f *files) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *files) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// runLint prints the findings of the SleepInfo in the files, and fails if
+// one of them is an error, so that it can be used in the pre-merge checks.
+func runLint(args []string, stdin io.Reader, out io.Writer) error {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	filenames := files{}
+	fs.Var(&filenames, "f", "The file with the SleepInfo to lint, - for the standard input. It can be repeated.")
+	fs.Var(&filenames, "filename", "The file with the SleepInfo to lint, - for the standard input. It can be repeated.")
+	defaultTimeZone := fs.String("default-time-zone", "", "The time zone used if the SleepInfo does not set it, as configured in the controller. If empty, the SleepInfo without time zone are reported.")
+	strict := fs.Bool("strict", false, "Fail also if there are warnings.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(filenames) == 0 {
+		return fmt.Errorf("-f is required")
+	}
+
+	sleepInfos := []v1alpha1.SleepInfo{}
+	for _, filename := range filenames {
+		fileSleepInfos, err := decodeFile(filename, stdin)
+		if err != nil {
+			return err
+		}
+		sleepInfos = append(sleepInfos, fileSleepInfos...)
+	}
+	if len(sleepInfos) == 0 {
+		return fmt.Errorf("no SleepInfo found")
+	}
+
+	findings := lint.Lint(sleepInfos, lint.Options{DefaultTimeZone: *defaultTimeZone})
+	for _, finding := range findings {
+		fmt.Fprintln(out, finding)
+	}
+	fmt.Fprintf(out, "%d SleepInfo linted, %d findings\n", len(sleepInfos), len(findings))
+	if lint.HasErrors(findings) || (*strict && len(findings) > 0) {
+		return errLintFailed
+	}
+	return nil
+}
+
+func decodeFile(filename string, stdin io.Reader) ([]v1alpha1.SleepInfo, error) {
+	if filename == "-" {
+		return lint.Decode(stdin)
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("fails to read file: %s", err)
+	}
+	defer file.Close()
+	sleepInfos, err := lint.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	return sleepInfos, nil
+}
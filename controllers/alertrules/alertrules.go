@@ -0,0 +1,353 @@
+package alertrules
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	sleepinfocontroller "github.com/kube-green/kube-green/controllers/sleepinfo"
+	"github.com/kube-green/kube-green/pkg/clock"
+	"github.com/kube-green/kube-green/pkg/schedule"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// RuleName is the name of the PrometheusRule generated in each namespace
+	// with a SleepInfo.
+	RuleName = "kube-green-alerts"
+
+	// NamespaceAsleepPastWakeUpAlert fires when the namespace is still asleep
+	// more than the Threshold after its scheduled wake up.
+	NamespaceAsleepPastWakeUpAlert = "KubeGreenNamespaceAsleepPastWakeUp"
+	// WakeUpFailedAlert fires when the reconciles of the namespace fail while
+	// it is asleep after its scheduled wake up.
+	WakeUpFailedAlert = "KubeGreenWakeUpFailed"
+
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedByValue = "kube-green"
+
+	// ruleLookAhead is how far the wake up windows are written in the rules.
+	// The schedules repeat every week, so the rules stay valid for a week
+	// even if they are not generated again.
+	ruleLookAhead = 7 * 24 * time.Hour
+	// failuresRange is the range of the failed reconciles of the WakeUpFailed
+	// alert.
+	failuresRange = "5m"
+)
+
+var prometheusRuleGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "PrometheusRule"}
+
+// Generator periodically generates, in each namespace with a SleepInfo, a
+// PrometheusRule with the alerts of the namespaces which fail to wake up on
+// schedule, derived from the schedules of its SleepInfo. The Prometheus
+// Operator must be installed in the cluster.
+//
+// PromQL does not know the cron schedules, so the rules contain the windows
+// in which the namespace must be awake, as unix times, for the next week.
+// A namespace with many SleepInfo must be awake when all of them are awake.
+// The rules of the namespaces without SleepInfo are deleted.
+type Generator struct {
+	// Client reads the SleepInfo and writes the PrometheusRule.
+	Client client.Client
+	// Interval is how often the rules are generated.
+	Interval time.Duration
+	// Threshold is how long after the scheduled wake up a namespace still
+	// asleep is alerted.
+	Threshold time.Duration
+	// RuleLabels are set on the PrometheusRule, so that they are selected by
+	// the ruleSelector of the Prometheus.
+	RuleLabels map[string]string
+	Log        logr.Logger
+	// Clock gives the current time, from which the windows of the next week
+	// are computed. If nil, the real clock is used.
+	Clock clock.Clock
+}
+
+// Start generates the rules every Interval, until the context is done.
+func (g *Generator) Start(ctx context.Context) error {
+	ticker := time.NewTicker(g.Interval)
+	defer ticker.Stop()
+	for {
+		if err := g.Generate(ctx); err != nil {
+			g.Log.Error(err, "fails to generate the alerting rules")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection returns true, so that only the leader writes the rules.
+func (g *Generator) NeedLeaderElection() bool {
+	return true
+}
+
+// Generate writes the PrometheusRule of the namespaces with a SleepInfo, and
+// deletes the ones not needed anymore.
+func (g *Generator) Generate(ctx context.Context) error {
+	sleepInfos := kubegreenv1alpha1.SleepInfoList{}
+	if err := g.Client.List(ctx, &sleepInfos); err != nil {
+		return fmt.Errorf("fails to list sleepinfos: %s", err)
+	}
+	now := clock.Now(g.Clock)
+
+	sleepInfosByNamespace := map[string][]kubegreenv1alpha1.SleepInfo{}
+	for _, sleepInfo := range sleepInfos.Items {
+		if sleepInfo.Annotations[sleepinfocontroller.InertAnnotation] == "true" {
+			continue
+		}
+		sleepInfosByNamespace[sleepInfo.Namespace] = append(sleepInfosByNamespace[sleepInfo.Namespace], sleepInfo)
+	}
+
+	generated := map[string]bool{}
+	for namespace, namespaceSleepInfos := range sleepInfosByNamespace {
+		log := g.Log.WithValues("namespace", namespace)
+		rules, err := GetRules(namespace, namespaceSleepInfos, now, g.Threshold)
+		if err != nil {
+			// the rule of the namespace is kept, it is still valid for the
+			// previous schedule.
+			log.Error(err, "fails to get the alerting rules")
+			generated[namespace] = true
+			continue
+		}
+		if len(rules) == 0 {
+			continue
+		}
+		if err := g.upsert(ctx, namespace, rules); err != nil {
+			log.Error(err, "fails to write the alerting rules")
+		}
+		generated[namespace] = true
+	}
+
+	prometheusRules := &unstructured.UnstructuredList{}
+	prometheusRules.SetGroupVersionKind(prometheusRuleGVK.GroupVersion().WithKind(prometheusRuleGVK.Kind + "List"))
+	if err := g.Client.List(ctx, prometheusRules, client.MatchingLabels{managedByLabel: managedByValue}); err != nil {
+		return fmt.Errorf("fails to list prometheusrules: %s", err)
+	}
+	for _, prometheusRule := range prometheusRules.Items {
+		prometheusRule := prometheusRule
+		if prometheusRule.GetName() != RuleName || generated[prometheusRule.GetNamespace()] {
+			continue
+		}
+		if err := g.Client.Delete(ctx, &prometheusRule); client.IgnoreNotFound(err) != nil {
+			g.Log.Error(err, "fails to delete the alerting rules", "namespace", prometheusRule.GetNamespace())
+			continue
+		}
+		g.Log.Info("alerting rules deleted", "namespace", prometheusRule.GetNamespace())
+	}
+	return nil
+}
+
+func (g *Generator) upsert(ctx context.Context, namespace string, rules []Rule) error {
+	spec := getSpec(namespace, rules)
+	prometheusRule := &unstructured.Unstructured{}
+	prometheusRule.SetGroupVersionKind(prometheusRuleGVK)
+	err := g.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: RuleName}, prometheusRule)
+	if apierrors.IsNotFound(err) {
+		prometheusRule.SetName(RuleName)
+		prometheusRule.SetNamespace(namespace)
+		prometheusRule.SetLabels(g.getLabels(nil))
+		prometheusRule.Object["spec"] = spec
+		return g.Client.Create(ctx, prometheusRule)
+	}
+	if err != nil {
+		return err
+	}
+	labels := g.getLabels(prometheusRule.GetLabels())
+	if reflect.DeepEqual(prometheusRule.Object["spec"], spec) && reflect.DeepEqual(prometheusRule.GetLabels(), labels) {
+		return nil
+	}
+	prometheusRule.SetLabels(labels)
+	prometheusRule.Object["spec"] = spec
+	return g.Client.Update(ctx, prometheusRule)
+}
+
+func (g *Generator) getLabels(labels map[string]string) map[string]string {
+	result := map[string]string{}
+	for key, value := range labels {
+		result[key] = value
+	}
+	for key, value := range g.RuleLabels {
+		result[key] = value
+	}
+	result[managedByLabel] = managedByValue
+	return result
+}
+
+// Rule is an alerting rule of a namespace.
+type Rule struct {
+	Alert       string
+	Expr        string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// GetRules returns the alerting rules of the namespace with the SleepInfo,
+// with the wake up windows from now to the next week. The namespace whose
+// SleepInfo never wake up, or are never awake for the threshold, has no
+// rules.
+func GetRules(namespace string, sleepInfos []kubegreenv1alpha1.SleepInfo, now time.Time, threshold time.Duration) ([]Rule, error) {
+	rules := []Rule{}
+	asleepWindows, err := getNamespaceWindows(sleepInfos, now, threshold)
+	if err != nil {
+		return nil, err
+	}
+	if len(asleepWindows) > 0 {
+		rules = append(rules, Rule{
+			Alert: NamespaceAsleepPastWakeUpAlert,
+			Expr:  fmt.Sprintf(`kube_green_namespace_asleep{namespace="%s"} == 1 and on() (%s)`, namespace, getWindowsExpr(asleepWindows)),
+			Labels: map[string]string{
+				"severity": "warning",
+			},
+			Annotations: map[string]string{
+				"summary":     "The namespace is still asleep after its scheduled wake up.",
+				"description": fmt.Sprintf("The namespace {{ $labels.namespace }} is still asleep more than %s after its scheduled wake up.", threshold),
+			},
+		})
+	}
+
+	failedWindows, err := getNamespaceWindows(sleepInfos, now, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(failedWindows) > 0 {
+		rules = append(rules, Rule{
+			Alert: WakeUpFailedAlert,
+			Expr: fmt.Sprintf(`increase(kube_green_operation_failures_total{namespace="%s"}[%s]) > 0 and on(namespace) kube_green_namespace_asleep{namespace="%s"} == 1 and on() (%s)`,
+				namespace, failuresRange, namespace, getWindowsExpr(failedWindows)),
+			Labels: map[string]string{
+				"severity": "warning",
+			},
+			Annotations: map[string]string{
+				"summary":     "The namespace fails to wake up on schedule.",
+				"description": "The wake up of the namespace {{ $labels.namespace }} fails with reason {{ $labels.reason }}.",
+			},
+		})
+	}
+	return rules, nil
+}
+
+// window is a time range in which a namespace must be awake.
+type window struct {
+	start, end time.Time
+}
+
+// getNamespaceWindows returns the windows, from now to the next week, in
+// which all the SleepInfo are awake since at least delay.
+func getNamespaceWindows(sleepInfos []kubegreenv1alpha1.SleepInfo, now time.Time, delay time.Duration) ([]window, error) {
+	var windows []window
+	for i, sleepInfo := range sleepInfos {
+		sleepInfo := sleepInfo
+		sleepInfoWindows, err := getAwakeWindows(&sleepInfo, now, delay)
+		if err != nil {
+			return nil, fmt.Errorf("sleepinfo %s: %s", sleepInfo.Name, err)
+		}
+		if i == 0 {
+			windows = sleepInfoWindows
+			continue
+		}
+		windows = intersect(windows, sleepInfoWindows)
+	}
+	return windows, nil
+}
+
+// getAwakeWindows returns the windows, from now to the next week, between
+// delay after each wake up of the SleepInfo and its next sleep. The last
+// wake up without a sleep in the next week is awake until the end of the
+// week.
+func getAwakeWindows(sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time, delay time.Duration) ([]window, error) {
+	until := now.Add(ruleLookAhead)
+	// the current window may have started in the previous week.
+	operations, err := sleepinfocontroller.PreviewOperations(sleepInfo, now.Add(-ruleLookAhead), until)
+	if err != nil {
+		return nil, err
+	}
+	windows := []window{}
+	for i, operation := range operations {
+		if operation.Type != schedule.WakeUp {
+			continue
+		}
+		current := window{start: operation.Time.Add(delay), end: until}
+		if i+1 < len(operations) {
+			current.end = operations[i+1].Time
+		}
+		if current.start.Before(current.end) && current.end.After(now) {
+			windows = append(windows, current)
+		}
+	}
+	return windows, nil
+}
+
+// intersect returns the ranges in both the sorted windows.
+func intersect(a, b []window) []window {
+	result := []window{}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		start, end := a[i].start, a[i].end
+		if b[j].start.After(start) {
+			start = b[j].start
+		}
+		if b[j].end.Before(end) {
+			end = b[j].end
+		}
+		if start.Before(end) {
+			result = append(result, window{start: start, end: end})
+		}
+		if a[i].end.Before(b[j].end) {
+			i++
+		} else {
+			j++
+		}
+	}
+	return result
+}
+
+// getWindowsExpr returns the PromQL expression which is not empty only in the
+// windows.
+func getWindowsExpr(windows []window) string {
+	exprs := []string{}
+	for _, window := range windows {
+		exprs = append(exprs, fmt.Sprintf("vector(time()) > %d < %d", window.start.Unix(), window.end.Unix()))
+	}
+	return strings.Join(exprs, " or ")
+}
+
+// getSpec returns the spec of the PrometheusRule with the rules, as
+// unstructured content.
+func getSpec(namespace string, rules []Rule) map[string]interface{} {
+	unstructuredRules := []interface{}{}
+	for _, rule := range rules {
+		unstructuredRules = append(unstructuredRules, map[string]interface{}{
+			"alert":       rule.Alert,
+			"expr":        rule.Expr,
+			"labels":      toUnstructured(rule.Labels),
+			"annotations": toUnstructured(rule.Annotations),
+		})
+	}
+	return map[string]interface{}{
+		"groups": []interface{}{
+			map[string]interface{}{
+				"name":  fmt.Sprintf("kube-green.%s", namespace),
+				"rules": unstructuredRules,
+			},
+		},
+	}
+}
+
+func toUnstructured(values map[string]string) map[string]interface{} {
+	result := map[string]interface{}{}
+	for key, value := range values {
+		result[key] = value
+	}
+	return result
+}
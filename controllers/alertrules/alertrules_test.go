@@ -0,0 +1,223 @@
+package alertrules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	sleepinfocontroller "github.com/kube-green/kube-green/controllers/sleepinfo"
+	"github.com/kube-green/kube-green/pkg/testutil"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// now is a Wednesday.
+var now = time.Date(2021, 3, 24, 12, 0, 0, 0, time.UTC)
+
+func getSleepInfo(namespace, name, sleepAt, wakeUpAt string) kubegreenv1alpha1.SleepInfo {
+	return kubegreenv1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: kubegreenv1alpha1.SleepInfoSpec{
+			Weekdays:   "1-5",
+			SleepTime:  sleepAt,
+			WakeUpTime: wakeUpAt,
+		},
+	}
+}
+
+func getWindow(day, startHour, startMinute, endHour, endMinute int) window {
+	return window{
+		start: time.Date(2021, 3, day, startHour, startMinute, 0, 0, time.UTC),
+		end:   time.Date(2021, 3, day, endHour, endMinute, 0, 0, time.UTC),
+	}
+}
+
+func TestGetNamespaceWindows(t *testing.T) {
+	tests := []struct {
+		name        string
+		sleepInfos  []kubegreenv1alpha1.SleepInfo
+		delay       time.Duration
+		expected    []window
+		expectedErr string
+	}{
+		{
+			name:       "wake up windows of the next week",
+			sleepInfos: []kubegreenv1alpha1.SleepInfo{getSleepInfo("ns", "working-hours", "20:00", "08:00")},
+			delay:      15 * time.Minute,
+			expected: []window{
+				getWindow(24, 8, 15, 20, 0),
+				getWindow(25, 8, 15, 20, 0),
+				getWindow(26, 8, 15, 20, 0),
+				getWindow(29, 8, 15, 20, 0),
+				getWindow(30, 8, 15, 20, 0),
+				// the last wake up is awake until the end of the week.
+				getWindow(31, 8, 15, 12, 0),
+			},
+		},
+		{
+			name: "the namespace is awake when all the sleepinfo are awake",
+			sleepInfos: []kubegreenv1alpha1.SleepInfo{
+				getSleepInfo("ns", "working-hours", "20:00", "08:00"),
+				getSleepInfo("ns", "databases", "19:00", "09:00"),
+			},
+			expected: []window{
+				getWindow(24, 9, 0, 19, 0),
+				getWindow(25, 9, 0, 19, 0),
+				getWindow(26, 9, 0, 19, 0),
+				getWindow(29, 9, 0, 19, 0),
+				getWindow(30, 9, 0, 19, 0),
+				getWindow(31, 9, 0, 12, 0),
+			},
+		},
+		{
+			name: "the namespace with a sleepinfo without wake up is never awake",
+			sleepInfos: []kubegreenv1alpha1.SleepInfo{
+				getSleepInfo("ns", "working-hours", "20:00", "08:00"),
+				getSleepInfo("ns", "sleep-only", "20:00", ""),
+			},
+			expected: []window{},
+		},
+		{
+			name:       "delay longer than the awake window",
+			sleepInfos: []kubegreenv1alpha1.SleepInfo{getSleepInfo("ns", "lunch-break", "14:00", "13:00")},
+			delay:      2 * time.Hour,
+			expected:   []window{},
+		},
+		{
+			name:        "invalid schedule",
+			sleepInfos:  []kubegreenv1alpha1.SleepInfo{getSleepInfo("ns", "invalid", "25:00", "08:00")},
+			expectedErr: "sleepinfo invalid:",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			windows, err := getNamespaceWindows(test.sleepInfos, now, test.delay)
+			if test.expectedErr != "" {
+				require.ErrorContains(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, windows, len(test.expected))
+			for i, expected := range test.expected {
+				require.True(t, expected.start.Equal(windows[i].start), "start of window %d: %s", i, windows[i].start)
+				require.True(t, expected.end.Equal(windows[i].end), "end of window %d: %s", i, windows[i].end)
+			}
+		})
+	}
+}
+
+func TestGetRules(t *testing.T) {
+	t.Run("rules of the namespace", func(t *testing.T) {
+		sleepInfo := getSleepInfo("my-namespace", "sleepinfo", "20:00", "08:00")
+		sleepInfo.Spec.Weekdays = "3"
+		rules, err := GetRules("my-namespace", []kubegreenv1alpha1.SleepInfo{sleepInfo}, now, 15*time.Minute)
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+
+		// Wednesday 24 08:15 - 20:00 and Wednesday 31 08:15 - 12:00.
+		require.Equal(t, NamespaceAsleepPastWakeUpAlert, rules[0].Alert)
+		require.Equal(t, `kube_green_namespace_asleep{namespace="my-namespace"} == 1 and on() (vector(time()) > 1616573700 < 1616616000 or vector(time()) > 1617178500 < 1617192000)`, rules[0].Expr)
+		require.Contains(t, rules[0].Annotations["description"], "15m0s after its scheduled wake up")
+
+		require.Equal(t, WakeUpFailedAlert, rules[1].Alert)
+		require.Equal(t, `increase(kube_green_operation_failures_total{namespace="my-namespace"}[5m]) > 0 and on(namespace) kube_green_namespace_asleep{namespace="my-namespace"} == 1 and on() (vector(time()) > 1616572800 < 1616616000 or vector(time()) > 1617177600 < 1617192000)`, rules[1].Expr)
+	})
+
+	t.Run("no rules without wake up", func(t *testing.T) {
+		rules, err := GetRules("my-namespace", []kubegreenv1alpha1.SleepInfo{getSleepInfo("my-namespace", "sleepinfo", "20:00", "")}, now, 15*time.Minute)
+		require.NoError(t, err)
+		require.Empty(t, rules)
+	})
+}
+
+func getPrometheusRule(namespace, name string, labels map[string]string) *unstructured.Unstructured {
+	prometheusRule := &unstructured.Unstructured{}
+	prometheusRule.SetGroupVersionKind(prometheusRuleGVK)
+	prometheusRule.SetNamespace(namespace)
+	prometheusRule.SetName(name)
+	prometheusRule.SetLabels(labels)
+	prometheusRule.Object["spec"] = map[string]interface{}{"groups": []interface{}{}}
+	return prometheusRule
+}
+
+func TestGenerator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	workingHours := getSleepInfo("working-hours", "sleepinfo", "20:00", "08:00")
+	sleepOnly := getSleepInfo("sleep-only", "sleepinfo", "20:00", "")
+	inert := getSleepInfo("inert", "sleepinfo", "20:00", "08:00")
+	inert.Annotations = map[string]string{sleepinfocontroller.InertAnnotation: "true"}
+	managed := map[string]string{managedByLabel: managedByValue}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			&workingHours,
+			&sleepOnly,
+			&inert,
+			getPrometheusRule("deleted", RuleName, managed),
+			getPrometheusRule("inert", RuleName, managed),
+			getPrometheusRule("sleep-only", RuleName, managed),
+			getPrometheusRule("unmanaged", RuleName, nil),
+		).
+		Build()
+	generator := &Generator{
+		Client:     c,
+		Threshold:  15 * time.Minute,
+		RuleLabels: map[string]string{"release": "prometheus"},
+		Log:        logr.Discard(),
+		Clock:      testutil.NewClock(now),
+	}
+	getRule := func(t *testing.T, namespace string) (*unstructured.Unstructured, error) {
+		t.Helper()
+		prometheusRule := &unstructured.Unstructured{}
+		prometheusRule.SetGroupVersionKind(prometheusRuleGVK)
+		err := c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: RuleName}, prometheusRule)
+		return prometheusRule, err
+	}
+
+	t.Run("generates the rules of the namespaces with a sleepinfo", func(t *testing.T) {
+		require.NoError(t, generator.Generate(context.Background()))
+
+		prometheusRule, err := getRule(t, "working-hours")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{managedByLabel: managedByValue, "release": "prometheus"}, prometheusRule.GetLabels())
+		groups, found, err := unstructured.NestedSlice(prometheusRule.Object, "spec", "groups")
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Len(t, groups, 1)
+		group := groups[0].(map[string]interface{})
+		require.Equal(t, "kube-green.working-hours", group["name"])
+		require.Len(t, group["rules"], 2)
+	})
+
+	t.Run("deletes the managed rules of the namespaces without alerts", func(t *testing.T) {
+		for _, namespace := range []string{"deleted", "inert", "sleep-only"} {
+			_, err := getRule(t, namespace)
+			require.True(t, apierrors.IsNotFound(err), namespace)
+		}
+		_, err := getRule(t, "unmanaged")
+		require.NoError(t, err)
+	})
+
+	t.Run("updates the rules when the windows change", func(t *testing.T) {
+		generator.Threshold = 30 * time.Minute
+		require.NoError(t, generator.Generate(context.Background()))
+
+		prometheusRule, err := getRule(t, "working-hours")
+		require.NoError(t, err)
+		groups, _, err := unstructured.NestedSlice(prometheusRule.Object, "spec", "groups")
+		require.NoError(t, err)
+		alert := groups[0].(map[string]interface{})["rules"].([]interface{})[0].(map[string]interface{})
+		require.Contains(t, alert["expr"], "vector(time()) > 1616574600 <")
+	})
+}
@@ -0,0 +1,173 @@
+package kubegreenreport
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/pkg/clock"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	sleepOperation  = "SLEEP"
+	wakeUpOperation = "WAKE_UP"
+)
+
+// defaultReports are the KubeGreenReport created by the Aggregator if
+// missing.
+var defaultReports = map[string]time.Duration{
+	kubegreenv1alpha1.HourlyKubeGreenReport: time.Hour,
+	kubegreenv1alpha1.DailyKubeGreenReport:  24 * time.Hour,
+}
+
+//+kubebuilder:rbac:groups=kube-green.com,resources=kubegreenreports,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups=kube-green.com,resources=kubegreenreports/status,verbs=get;update;patch
+
+// Aggregator periodically rolls up the status of the SleepInfo of the cluster
+// into the KubeGreenReport, so that the state of the fleet is read from a
+// single object. The hourly and the daily reports are created if missing,
+// and other reports can be created with a custom window.
+//
+// A namespace is asleep if one of its SleepInfo is asleep. The operations are
+// counted from the operations history of the SleepInfo, so the operations
+// dropped from the history are not counted.
+type Aggregator struct {
+	// Client reads the SleepInfo and writes the KubeGreenReport.
+	Client client.Client
+	// Interval is how often the reports are computed.
+	Interval time.Duration
+	Log      logr.Logger
+	// Clock gives the current time, at which the reports are aggregated. If
+	// nil, the real clock is used.
+	Clock clock.Clock
+}
+
+// Start computes the reports every Interval, until the context is done.
+func (a *Aggregator) Start(ctx context.Context) error {
+	ticker := time.NewTicker(a.Interval)
+	defer ticker.Stop()
+	for {
+		if err := a.Aggregate(ctx); err != nil {
+			a.Log.Error(err, "fails to compute the kube-green reports")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection returns true, so that only the leader writes the reports.
+func (a *Aggregator) NeedLeaderElection() bool {
+	return true
+}
+
+// Aggregate computes and stores all the KubeGreenReport.
+func (a *Aggregator) Aggregate(ctx context.Context) error {
+	if err := a.createDefaultReports(ctx); err != nil {
+		return err
+	}
+	reports := kubegreenv1alpha1.KubeGreenReportList{}
+	if err := a.Client.List(ctx, &reports); err != nil {
+		return fmt.Errorf("fails to list kubegreenreports: %s", err)
+	}
+	sleepInfos := kubegreenv1alpha1.SleepInfoList{}
+	if err := a.Client.List(ctx, &sleepInfos); err != nil {
+		return fmt.Errorf("fails to list sleepinfos: %s", err)
+	}
+	now := clock.Now(a.Clock)
+
+	for _, report := range reports.Items {
+		report := report
+		log := a.Log.WithValues("kubegreenreport", report.Name)
+		if err := report.Validate(); err != nil {
+			log.Error(err, "invalid kubegreenreport, skip")
+			continue
+		}
+		patch := client.MergeFrom(report.DeepCopy())
+		report.Status = getStatus(sleepInfos.Items, now.Add(-report.Spec.Window.Duration), now)
+		if err := a.Client.Status().Patch(ctx, &report, patch); err != nil {
+			log.Error(err, "fails to update kubegreenreport")
+		}
+	}
+	return nil
+}
+
+func (a *Aggregator) createDefaultReports(ctx context.Context) error {
+	for name, window := range defaultReports {
+		report := &kubegreenv1alpha1.KubeGreenReport{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       kubegreenv1alpha1.KubeGreenReportSpec{Window: metav1.Duration{Duration: window}},
+		}
+		if err := a.Client.Create(ctx, report); err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("fails to create kubegreenreport %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// getStatus rolls up the SleepInfo, counting the operations run between from
+// and now.
+func getStatus(sleepInfos []kubegreenv1alpha1.SleepInfo, from, now time.Time) kubegreenv1alpha1.KubeGreenReportStatus {
+	status := kubegreenv1alpha1.KubeGreenReportStatus{
+		LastUpdateTime:   metav1.NewTime(now),
+		SleepInfos:       int32(len(sleepInfos)),
+		AsleepNamespaces: []string{},
+		FailedSleepInfos: []kubegreenv1alpha1.SleepInfoFailure{},
+	}
+	namespaces := map[string]bool{}
+	for _, sleepInfo := range sleepInfos {
+		namespaces[sleepInfo.Namespace] = namespaces[sleepInfo.Namespace] || sleepInfo.Status.OperationType == sleepOperation
+
+		var lastFailure *kubegreenv1alpha1.SleepInfoFailure
+		for _, operation := range sleepInfo.Status.OperationsHistory {
+			if operation.Time.Time.Before(from) || operation.Time.Time.After(now) {
+				continue
+			}
+			switch operation.Type {
+			case sleepOperation:
+				status.Sleeps++
+			case wakeUpOperation:
+				status.WakeUps++
+			}
+			if operation.Error == "" {
+				continue
+			}
+			status.Failures++
+			lastFailure = &kubegreenv1alpha1.SleepInfoFailure{
+				Namespace: sleepInfo.Namespace,
+				Name:      sleepInfo.Name,
+				Operation: operation.Type,
+				Time:      operation.Time,
+				Error:     operation.Error,
+			}
+		}
+		if lastFailure != nil {
+			status.FailedSleepInfos = append(status.FailedSleepInfos, *lastFailure)
+		}
+	}
+
+	status.Namespaces = int32(len(namespaces))
+	for namespace, isAsleep := range namespaces {
+		if isAsleep {
+			status.AsleepNamespaces = append(status.AsleepNamespaces, namespace)
+		}
+	}
+	sort.Strings(status.AsleepNamespaces)
+	status.NamespacesAsleep = int32(len(status.AsleepNamespaces))
+	sort.Slice(status.FailedSleepInfos, func(i, j int) bool {
+		if status.FailedSleepInfos[i].Namespace != status.FailedSleepInfos[j].Namespace {
+			return status.FailedSleepInfos[i].Namespace < status.FailedSleepInfos[j].Namespace
+		}
+		return status.FailedSleepInfos[i].Name < status.FailedSleepInfos[j].Name
+	})
+	return status
+}
@@ -0,0 +1,194 @@
+package kubegreenreport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/pkg/testutil"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var now = time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)
+
+func getOperation(operationType string, ago time.Duration, err string) kubegreenv1alpha1.OperationHistory {
+	return kubegreenv1alpha1.OperationHistory{
+		Type:  operationType,
+		Time:  metav1.NewTime(now.Add(-ago)),
+		Error: err,
+	}
+}
+
+func getSleepInfo(namespace, name, operationType string, history ...kubegreenv1alpha1.OperationHistory) kubegreenv1alpha1.SleepInfo {
+	return kubegreenv1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Status: kubegreenv1alpha1.SleepInfoStatus{
+			OperationType:     operationType,
+			OperationsHistory: history,
+		},
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		sleepInfos []kubegreenv1alpha1.SleepInfo
+		expected   kubegreenv1alpha1.KubeGreenReportStatus
+	}{
+		{
+			name: "no sleepinfo",
+			expected: kubegreenv1alpha1.KubeGreenReportStatus{
+				LastUpdateTime:   metav1.NewTime(now),
+				AsleepNamespaces: []string{},
+				FailedSleepInfos: []kubegreenv1alpha1.SleepInfoFailure{},
+			},
+		},
+		{
+			name: "namespaces asleep and operations in the window",
+			sleepInfos: []kubegreenv1alpha1.SleepInfo{
+				getSleepInfo("team-b", "sleepinfo", "SLEEP",
+					getOperation("SLEEP", 30*time.Hour, ""),
+					getOperation("WAKE_UP", 20*time.Hour, ""),
+					getOperation("SLEEP", 2*time.Hour, ""),
+				),
+				getSleepInfo("team-a", "sleepinfo", "WAKE_UP",
+					getOperation("SLEEP", 12*time.Hour, ""),
+					getOperation("WAKE_UP", time.Hour, ""),
+				),
+				// a namespace is asleep if one of its SleepInfo is asleep.
+				getSleepInfo("team-a", "cronjobs", "SLEEP",
+					getOperation("SLEEP", 3*time.Hour, ""),
+				),
+				getSleepInfo("team-c", "sleepinfo", ""),
+			},
+			expected: kubegreenv1alpha1.KubeGreenReportStatus{
+				LastUpdateTime:   metav1.NewTime(now),
+				SleepInfos:       4,
+				Namespaces:       3,
+				NamespacesAsleep: 2,
+				AsleepNamespaces: []string{"team-a", "team-b"},
+				Sleeps:           3,
+				WakeUps:          2,
+				FailedSleepInfos: []kubegreenv1alpha1.SleepInfoFailure{},
+			},
+		},
+		{
+			name: "failures",
+			sleepInfos: []kubegreenv1alpha1.SleepInfo{
+				getSleepInfo("team-b", "sleepinfo", "WAKE_UP",
+					getOperation("SLEEP", 30*time.Hour, "failure before the window"),
+					getOperation("SLEEP", 10*time.Hour, "first failure"),
+					getOperation("WAKE_UP", 2*time.Hour, "last failure"),
+				),
+				getSleepInfo("team-a", "sleepinfo", "SLEEP",
+					getOperation("SLEEP", time.Hour, "failure"),
+				),
+				getSleepInfo("team-a", "other", "WAKE_UP",
+					getOperation("WAKE_UP", time.Hour, ""),
+				),
+			},
+			expected: kubegreenv1alpha1.KubeGreenReportStatus{
+				LastUpdateTime:   metav1.NewTime(now),
+				SleepInfos:       3,
+				Namespaces:       2,
+				NamespacesAsleep: 1,
+				AsleepNamespaces: []string{"team-a"},
+				Sleeps:           2,
+				WakeUps:          2,
+				Failures:         3,
+				FailedSleepInfos: []kubegreenv1alpha1.SleepInfoFailure{
+					{Namespace: "team-a", Name: "sleepinfo", Operation: "SLEEP", Time: metav1.NewTime(now.Add(-time.Hour)), Error: "failure"},
+					{Namespace: "team-b", Name: "sleepinfo", Operation: "WAKE_UP", Time: metav1.NewTime(now.Add(-2 * time.Hour)), Error: "last failure"},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := getStatus(test.sleepInfos, now.Add(-24*time.Hour), now)
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestAggregator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	teamA := getSleepInfo("team-a", "sleepinfo", "SLEEP",
+		getOperation("SLEEP", 20*time.Hour, ""),
+		getOperation("WAKE_UP", 8*time.Hour, "failure"),
+		getOperation("SLEEP", 30*time.Minute, ""),
+	)
+	teamB := getSleepInfo("team-b", "sleepinfo", "WAKE_UP")
+	objects := []client.Object{
+		&teamA,
+		&teamB,
+		&kubegreenv1alpha1.KubeGreenReport{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid"},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+	aggregator := &Aggregator{
+		Client:   c,
+		Interval: time.Hour,
+		Log:      logr.Discard(),
+		Clock:    testutil.NewClock(now),
+	}
+	require.NoError(t, aggregator.Aggregate(context.Background()))
+
+	getReport := func(name string) *kubegreenv1alpha1.KubeGreenReport {
+		report := &kubegreenv1alpha1.KubeGreenReport{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: name}, report))
+		return report
+	}
+
+	t.Run("hourly report", func(t *testing.T) {
+		report := getReport(kubegreenv1alpha1.HourlyKubeGreenReport)
+		require.Equal(t, time.Hour, report.Spec.Window.Duration)
+		require.True(t, now.Equal(report.Status.LastUpdateTime.Time))
+		require.Equal(t, int32(2), report.Status.SleepInfos)
+		require.Equal(t, int32(2), report.Status.Namespaces)
+		require.Equal(t, []string{"team-a"}, report.Status.AsleepNamespaces)
+		require.Equal(t, int32(1), report.Status.Sleeps)
+		require.Zero(t, report.Status.WakeUps)
+		require.Zero(t, report.Status.Failures)
+		require.Empty(t, report.Status.FailedSleepInfos)
+	})
+
+	t.Run("daily report", func(t *testing.T) {
+		report := getReport(kubegreenv1alpha1.DailyKubeGreenReport)
+		require.Equal(t, 24*time.Hour, report.Spec.Window.Duration)
+		require.Equal(t, int32(1), report.Status.NamespacesAsleep)
+		require.Equal(t, int32(2), report.Status.Sleeps)
+		require.Equal(t, int32(1), report.Status.WakeUps)
+		require.Equal(t, int32(1), report.Status.Failures)
+		require.Len(t, report.Status.FailedSleepInfos, 1)
+		require.Equal(t, "failure", report.Status.FailedSleepInfos[0].Error)
+	})
+
+	t.Run("invalid report is skipped", func(t *testing.T) {
+		report := getReport("invalid")
+		require.True(t, report.Status.LastUpdateTime.IsZero())
+	})
+
+	t.Run("default reports are not overwritten", func(t *testing.T) {
+		report := getReport(kubegreenv1alpha1.DailyKubeGreenReport)
+		report.Spec.Window = metav1.Duration{Duration: 12 * time.Hour}
+		require.NoError(t, c.Update(context.Background(), report))
+		require.NoError(t, aggregator.Aggregate(context.Background()))
+
+		report = getReport(kubegreenv1alpha1.DailyKubeGreenReport)
+		require.Equal(t, 12*time.Hour, report.Spec.Window.Duration)
+		require.Equal(t, int32(1), report.Status.Sleeps)
+		require.Equal(t, int32(1), report.Status.WakeUps)
+	})
+}
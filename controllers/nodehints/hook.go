@@ -0,0 +1,72 @@
+package nodehints
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// EmptyEvent is sent when nodes become empty while the namespaces sleep.
+	EmptyEvent = "empty"
+	// RestoreEvent is sent when the hints are removed from the nodes, before
+	// the wake up or when they are not empty anymore.
+	RestoreEvent = "restore"
+)
+
+// Event is sent to the Hook when the hints of some nodes change.
+type Event struct {
+	// Type is empty or restore.
+	Type string `json:"type"`
+	// Nodes are the names of the nodes.
+	Nodes []string  `json:"nodes"`
+	Time  time.Time `json:"time"`
+}
+
+// Hook is notified when the hints of some nodes change, e.g. to scale down a
+// node pool without waiting for the cluster-autoscaler.
+type Hook interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+type httpHook struct {
+	client *http.Client
+	url    string
+}
+
+// NewHTTPHook returns a Hook which sends each event as JSON with a POST
+// request to the url. If httpClient is nil, http.DefaultClient is used.
+func NewHTTPHook(httpClient *http.Client, url string) Hook {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &httpHook{
+		client: httpClient,
+		url:    url,
+	}
+}
+
+func (h *httpHook) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("node hints hook responded with status %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package nodehints
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	sleepinfocontroller "github.com/kube-green/kube-green/controllers/sleepinfo"
+	"github.com/kube-green/kube-green/pkg/clock"
+	"github.com/kube-green/kube-green/pkg/schedule"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// EmptySinceAnnotation is set on the nodes which became empty while the
+	// namespaces sleep, with the time when they were found empty.
+	EmptySinceAnnotation = "kube-green.dev/empty-since"
+	// CordonedAnnotation is set on the nodes cordoned by kube-green, which are
+	// uncordoned when the hints are removed.
+	CordonedAnnotation = "kube-green.dev/cordoned"
+
+	mirrorPodAnnotation = "kubernetes.io/config.mirror"
+)
+
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch;patch
+
+// Hinter marks the nodes which became empty while the namespaces sleep, to
+// help the cluster-autoscaler to remove them faster. A node is empty if it
+// runs only DaemonSet, static and completed pods.
+//
+// The empty nodes are annotated and, if Cordon is set, cordoned, so that no
+// pod is scheduled on them before the cluster-autoscaler removes them. The
+// hints are removed WakeUpLeadTime before the next scheduled wake up, when no
+// namespace is sleeping anymore, or when the node is not empty anymore.
+type Hinter struct {
+	// Client reads the SleepInfo and the nodes, and patches the nodes.
+	Client client.Client
+	// PodReader reads the pods of the nodes. It should not be cached, to not
+	// watch all the pods of the cluster.
+	PodReader client.Reader
+	// Interval is how often the nodes are checked.
+	Interval time.Duration
+	// NodeSelector selects the nodes which can be marked, e.g. the nodes of
+	// the autoscaled node pools. If nil, all the nodes can be marked.
+	NodeSelector labels.Selector
+	// Cordon cordons the empty nodes, besides annotating them.
+	Cordon bool
+	// WakeUpLeadTime is how long before the next wake up the hints are
+	// removed, so that the nodes are available again when the namespaces
+	// wake up.
+	WakeUpLeadTime time.Duration
+	// Hook is notified when the hints change. If nil, it is disabled.
+	Hook Hook
+	Log  logr.Logger
+	// Clock gives the current time, to find the upcoming sleeps and wake
+	// ups. If nil, the real clock is used.
+	Clock clock.Clock
+}
+
+// Start checks the nodes every Interval, until the context is done.
+func (h *Hinter) Start(ctx context.Context) error {
+	ticker := time.NewTicker(h.Interval)
+	defer ticker.Stop()
+	for {
+		if err := h.Run(ctx); err != nil {
+			h.Log.Error(err, "fails to update the node hints")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection returns true, so that only the leader marks the nodes.
+func (h *Hinter) NeedLeaderElection() bool {
+	return true
+}
+
+// Run marks the empty nodes, if the namespaces are sleeping, and removes the
+// hints which are not needed anymore.
+func (h *Hinter) Run(ctx context.Context) error {
+	sleepInfos := kubegreenv1alpha1.SleepInfoList{}
+	if err := h.Client.List(ctx, &sleepInfos); err != nil {
+		return fmt.Errorf("fails to list sleepinfos: %s", err)
+	}
+	nodes := v1.NodeList{}
+	if err := h.Client.List(ctx, &nodes); err != nil {
+		return fmt.Errorf("fails to list nodes: %s", err)
+	}
+	now := clock.Now(h.Clock)
+
+	emptyNodes := map[string]bool{}
+	if h.isSleeping(sleepInfos.Items, now) {
+		pods := v1.PodList{}
+		if err := h.PodReader.List(ctx, &pods); err != nil {
+			return fmt.Errorf("fails to list pods: %s", err)
+		}
+		emptyNodes = getEmptyNodes(nodes.Items, pods.Items)
+	}
+
+	marked, restored := []string{}, []string{}
+	for _, node := range nodes.Items {
+		node := node
+		_, hinted := node.Annotations[EmptySinceAnnotation]
+		switch {
+		case emptyNodes[node.Name] && !hinted && h.canMark(node):
+			if err := h.mark(ctx, &node, now); err != nil {
+				h.Log.Error(err, "fails to mark the empty node", "node", node.Name)
+				continue
+			}
+			marked = append(marked, node.Name)
+		case !emptyNodes[node.Name] && hinted:
+			if err := restore(ctx, h.Client, &node); err != nil {
+				h.Log.Error(err, "fails to remove the hints from the node", "node", node.Name)
+				continue
+			}
+			restored = append(restored, node.Name)
+		}
+	}
+
+	if len(marked) > 0 {
+		h.Log.Info("empty nodes marked", "nodes", marked)
+		h.notify(ctx, Event{Type: EmptyEvent, Nodes: marked, Time: now})
+	}
+	if len(restored) > 0 {
+		h.Log.Info("node hints removed", "nodes", restored)
+		h.notify(ctx, Event{Type: RestoreEvent, Nodes: restored, Time: now})
+	}
+	return nil
+}
+
+// isSleeping returns true if at least a namespace is sleeping, and no
+// namespace wakes up in the next WakeUpLeadTime.
+func (h *Hinter) isSleeping(sleepInfos []kubegreenv1alpha1.SleepInfo, now time.Time) bool {
+	sleeping := false
+	for _, sleepInfo := range sleepInfos {
+		sleepInfo := sleepInfo
+		if sleepInfo.Status.OperationType != schedule.Sleep {
+			continue
+		}
+		sleeping = true
+		nextWakeUp, err := sleepinfocontroller.GetNextWakeUp(&sleepInfo, now)
+		if err != nil {
+			h.Log.Error(err, "fails to get the next wake up", "sleepinfo", client.ObjectKeyFromObject(&sleepInfo))
+			return false
+		}
+		if !nextWakeUp.IsZero() && !now.Before(nextWakeUp.Add(-h.WakeUpLeadTime)) {
+			return false
+		}
+	}
+	return sleeping
+}
+
+func (h *Hinter) canMark(node v1.Node) bool {
+	if h.NodeSelector != nil && !h.NodeSelector.Matches(labels.Set(node.Labels)) {
+		return false
+	}
+	// the nodes cordoned by others are left untouched.
+	return !node.Spec.Unschedulable
+}
+
+func (h *Hinter) mark(ctx context.Context, node *v1.Node, now time.Time) error {
+	patch := client.MergeFrom(node.DeepCopy())
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[EmptySinceAnnotation] = now.Format(time.RFC3339)
+	if h.Cordon {
+		node.Annotations[CordonedAnnotation] = "true"
+		node.Spec.Unschedulable = true
+	}
+	return h.Client.Patch(ctx, node, patch)
+}
+
+func (h *Hinter) notify(ctx context.Context, event Event) {
+	if h.Hook == nil {
+		return
+	}
+	if err := h.Hook.Notify(ctx, event); err != nil {
+		h.Log.Error(err, "fails to notify the node hints hook", "type", event.Type)
+	}
+}
+
+func restore(ctx context.Context, c client.Writer, node *v1.Node) error {
+	patch := client.MergeFrom(node.DeepCopy())
+	if _, ok := node.Annotations[CordonedAnnotation]; ok {
+		node.Spec.Unschedulable = false
+	}
+	delete(node.Annotations, EmptySinceAnnotation)
+	delete(node.Annotations, CordonedAnnotation)
+	return c.Patch(ctx, node, patch)
+}
+
+// getEmptyNodes returns the nodes which run only DaemonSet, static and
+// completed pods.
+func getEmptyNodes(nodes []v1.Node, pods []v1.Pod) map[string]bool {
+	emptyNodes := map[string]bool{}
+	for _, node := range nodes {
+		emptyNodes[node.Name] = true
+	}
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || !isWorkload(pod) {
+			continue
+		}
+		delete(emptyNodes, pod.Spec.NodeName)
+	}
+	return emptyNodes
+}
+
+func isWorkload(pod v1.Pod) bool {
+	if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		return false
+	}
+	if _, ok := pod.Annotations[mirrorPodAnnotation]; ok {
+		return false
+	}
+	owner := metav1.GetControllerOf(&pod)
+	return owner == nil || owner.Kind != "DaemonSet"
+}
@@ -0,0 +1,196 @@
+package nodehints
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/pkg/testutil"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeHook struct {
+	events []Event
+}
+
+func (f *fakeHook) Notify(_ context.Context, event Event) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+func TestHinter(t *testing.T) {
+	// Tuesday, the namespace sleeps from 20:00 to 08:00.
+	now := time.Date(2021, 3, 23, 21, 0, 0, 0, time.UTC)
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	getNode := func(name string, pool string, annotations map[string]string, unschedulable bool) *v1.Node {
+		return &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Labels:      map[string]string{"pool": pool},
+				Annotations: annotations,
+			},
+			Spec: v1.NodeSpec{Unschedulable: unschedulable},
+		}
+	}
+	getPod := func(name, nodeName, ownerKind string, phase v1.PodPhase, annotations map[string]string) *v1.Pod {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team-a", Annotations: annotations},
+			Spec:       v1.PodSpec{NodeName: nodeName},
+			Status:     v1.PodStatus{Phase: phase},
+		}
+		if ownerKind != "" {
+			controller := true
+			pod.OwnerReferences = []metav1.OwnerReference{{Kind: ownerKind, Name: "owner", Controller: &controller}}
+		}
+		return pod
+	}
+	newHinter := func(operationType string) (*Hinter, client.Client, *fakeHook) {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&kubegreenv1alpha1.SleepInfo{
+				ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "team-a"},
+				Spec:       kubegreenv1alpha1.SleepInfoSpec{Weekdays: "1-5", SleepTime: "20:00", WakeUpTime: "08:00"},
+				Status:     kubegreenv1alpha1.SleepInfoStatus{OperationType: operationType},
+			},
+			getNode("empty", "autoscaled", nil, false),
+			getNode("busy", "autoscaled", nil, false),
+			getNode("cordoned", "autoscaled", nil, true),
+			getNode("static", "static", nil, false),
+			getNode("hinted", "autoscaled", map[string]string{
+				EmptySinceAnnotation: "2021-03-23T20:30:00Z",
+				CordonedAnnotation:   "true",
+			}, true),
+		).Build()
+		podReader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			getPod("daemon", "empty", "DaemonSet", v1.PodRunning, nil),
+			getPod("static", "empty", "Node", v1.PodRunning, map[string]string{mirrorPodAnnotation: "hash"}),
+			getPod("job", "empty", "Job", v1.PodSucceeded, nil),
+			getPod("api", "busy", "ReplicaSet", v1.PodRunning, nil),
+			getPod("db", "hinted", "StatefulSet", v1.PodRunning, nil),
+			getPod("pending", "", "ReplicaSet", v1.PodPending, nil),
+		).Build()
+		hook := &fakeHook{}
+		return &Hinter{
+			Client:         c,
+			PodReader:      podReader,
+			Interval:       time.Minute,
+			NodeSelector:   labels.SelectorFromSet(labels.Set{"pool": "autoscaled"}),
+			Cordon:         true,
+			WakeUpLeadTime: time.Hour,
+			Hook:           hook,
+			Log:            logr.Discard(),
+			Clock:          testutil.NewClock(now),
+		}, c, hook
+	}
+	getNodeState := func(t *testing.T, c client.Client, name string) (map[string]string, bool) {
+		t.Helper()
+		node := &v1.Node{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: name}, node))
+		return node.Annotations, node.Spec.Unschedulable
+	}
+
+	t.Run("mark the empty nodes while sleeping", func(t *testing.T) {
+		hinter, c, hook := newHinter("SLEEP")
+		require.NoError(t, hinter.Run(context.Background()))
+
+		annotations, unschedulable := getNodeState(t, c, "empty")
+		require.Equal(t, map[string]string{
+			EmptySinceAnnotation: "2021-03-23T21:00:00Z",
+			CordonedAnnotation:   "true",
+		}, annotations)
+		require.True(t, unschedulable)
+
+		for _, name := range []string{"busy", "static"} {
+			annotations, unschedulable := getNodeState(t, c, name)
+			require.Empty(t, annotations, name)
+			require.False(t, unschedulable, name)
+		}
+		annotations, unschedulable = getNodeState(t, c, "cordoned")
+		require.Empty(t, annotations)
+		require.True(t, unschedulable)
+
+		// the hinted node is not empty anymore.
+		annotations, unschedulable = getNodeState(t, c, "hinted")
+		require.Empty(t, annotations)
+		require.False(t, unschedulable)
+
+		require.Equal(t, []Event{
+			{Type: EmptyEvent, Nodes: []string{"empty"}, Time: now},
+			{Type: RestoreEvent, Nodes: []string{"hinted"}, Time: now},
+		}, hook.events)
+	})
+
+	t.Run("annotate without cordon", func(t *testing.T) {
+		hinter, c, _ := newHinter("SLEEP")
+		hinter.Cordon = false
+		require.NoError(t, hinter.Run(context.Background()))
+
+		annotations, unschedulable := getNodeState(t, c, "empty")
+		require.Equal(t, map[string]string{EmptySinceAnnotation: "2021-03-23T21:00:00Z"}, annotations)
+		require.False(t, unschedulable)
+	})
+
+	t.Run("remove the hints before the wake up", func(t *testing.T) {
+		hinter, c, hook := newHinter("SLEEP")
+		require.NoError(t, hinter.Run(context.Background()))
+
+		beforeWakeUp := time.Date(2021, 3, 24, 7, 30, 0, 0, time.UTC)
+		hinter.Clock = testutil.NewClock(beforeWakeUp)
+		require.NoError(t, hinter.Run(context.Background()))
+
+		annotations, unschedulable := getNodeState(t, c, "empty")
+		require.Empty(t, annotations)
+		require.False(t, unschedulable)
+		require.Equal(t, Event{Type: RestoreEvent, Nodes: []string{"empty"}, Time: beforeWakeUp}, hook.events[len(hook.events)-1])
+	})
+
+	t.Run("no namespace is sleeping", func(t *testing.T) {
+		hinter, c, hook := newHinter("WAKE_UP")
+		require.NoError(t, hinter.Run(context.Background()))
+
+		for _, name := range []string{"empty", "hinted"} {
+			annotations, unschedulable := getNodeState(t, c, name)
+			require.Empty(t, annotations, name)
+			require.False(t, unschedulable, name)
+		}
+		require.Equal(t, []Event{
+			{Type: RestoreEvent, Nodes: []string{"hinted"}, Time: now},
+		}, hook.events)
+	})
+}
+
+func TestHTTPHook(t *testing.T) {
+	var received Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, http.MethodPost, req.Method)
+		require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&received))
+		if len(received.Nodes) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	hook := NewHTTPHook(nil, server.URL)
+	event := Event{Type: EmptyEvent, Nodes: []string{"node-1"}, Time: time.Date(2021, 3, 23, 21, 0, 0, 0, time.UTC)}
+	require.NoError(t, hook.Notify(context.Background(), event))
+	require.Equal(t, event, received)
+
+	err := hook.Notify(context.Background(), Event{Type: RestoreEvent})
+	require.EqualError(t, err, "node hints hook responded with status 400")
+}
@@ -0,0 +1,69 @@
+package prewarm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Event is sent to the Hook when a namespace must be warmed up.
+type Event struct {
+	Namespace string `json:"namespace"`
+	// WakeUpTime is the time of the next wake up of the namespace.
+	WakeUpTime time.Time `json:"wakeUpTime"`
+	// Pods is the number of pods running before the sleep.
+	Pods int `json:"pods"`
+	// Requests are the resources requested by the pods running before the
+	// sleep.
+	Requests v1.ResourceList `json:"requests,omitempty"`
+}
+
+// Hook is notified once when a namespace must be warmed up, before its wake
+// up.
+type Hook interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+type httpHook struct {
+	client *http.Client
+	url    string
+}
+
+// NewHTTPHook returns a Hook which sends each event as JSON with a POST
+// request to the url. If httpClient is nil, http.DefaultClient is used.
+func NewHTTPHook(httpClient *http.Client, url string) Hook {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &httpHook{
+		client: httpClient,
+		url:    url,
+	}
+}
+
+func (h *httpHook) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("warm up hook responded with status %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package prewarm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	sleepinfocontroller "github.com/kube-green/kube-green/controllers/sleepinfo"
+	"github.com/kube-green/kube-green/pkg/clock"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultImage is the image of the placeholder pods, which do nothing.
+	DefaultImage = "registry.k8s.io/pause:3.9"
+
+	sleepOperation = "SLEEP"
+)
+
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=list;create;delete
+
+// Warmer warms up the nodes before the wake up of the namespaces, so that the
+// pods woken up do not wait for the cluster-autoscaler to add the nodes.
+//
+// LeadTime before the next scheduled wake up of a sleeping namespace, it
+// creates in the namespace placeholder pods which request the resources of
+// the pods running before the sleep, as recorded in the operations history.
+// The placeholder pods must have a negative priority, so that they are
+// preempted by the pods woken up, and they are deleted after the wake up.
+type Warmer struct {
+	// Client reads the SleepInfo and creates and deletes the placeholder pods.
+	Client client.Client
+	// PodReader reads the placeholder pods. It should not be cached, to not
+	// watch all the pods of the cluster.
+	PodReader client.Reader
+	// Interval is how often the namespaces are checked.
+	Interval time.Duration
+	// LeadTime is how long before the wake up the nodes are warmed up.
+	LeadTime time.Duration
+	// PriorityClassName is the priority class of the placeholder pods, which
+	// must have a negative priority. If empty, the placeholder pods are not
+	// created.
+	PriorityClassName string
+	// Image is the image of the placeholder pods. If empty, DefaultImage is
+	// used.
+	Image string
+	// MaxPods is the max number of placeholder pods in a namespace. If the
+	// namespace ran more pods, each placeholder requests the resources of more
+	// pods. If 0, the placeholder pods are as many as the pods.
+	MaxPods int
+	// Hook is notified when a namespace must be warmed up, e.g. to scale up a
+	// node pool directly. If nil, it is disabled.
+	Hook Hook
+	Log  logr.Logger
+	// Clock gives the current time, to find the upcoming wake ups. If nil,
+	// the real clock is used.
+	Clock clock.Clock
+
+	// notified are the namespaces notified to the Hook, with their wake up.
+	notified map[string]time.Time
+}
+
+// warmUp is the warm up of a namespace.
+type warmUp struct {
+	wakeUpTime time.Time
+	pods       *kubegreenv1alpha1.RunningPods
+}
+
+// Start checks the namespaces every Interval, until the context is done.
+func (w *Warmer) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		if err := w.Run(ctx); err != nil {
+			w.Log.Error(err, "fails to warm up the nodes")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection returns true, so that only the leader creates the
+// placeholder pods.
+func (w *Warmer) NeedLeaderElection() bool {
+	return true
+}
+
+// Run warms up the namespaces which wake up in the next LeadTime, and deletes
+// the placeholder pods which are not needed anymore.
+func (w *Warmer) Run(ctx context.Context) error {
+	sleepInfos := kubegreenv1alpha1.SleepInfoList{}
+	if err := w.Client.List(ctx, &sleepInfos); err != nil {
+		return fmt.Errorf("fails to list sleepinfos: %s", err)
+	}
+	placeholders := v1.PodList{}
+	if err := w.PodReader.List(ctx, &placeholders, client.HasLabels{sleepinfocontroller.PlaceholderLabel}); err != nil {
+		return fmt.Errorf("fails to list placeholder pods: %s", err)
+	}
+	now := clock.Now(w.Clock)
+	warmUps := w.getWarmUps(sleepInfos.Items, now)
+
+	placeholdersByNamespace := map[string][]v1.Pod{}
+	for _, pod := range placeholders.Items {
+		placeholdersByNamespace[pod.Namespace] = append(placeholdersByNamespace[pod.Namespace], pod)
+	}
+	for namespace, pods := range placeholdersByNamespace {
+		if _, ok := warmUps[namespace]; ok {
+			continue
+		}
+		for _, pod := range pods {
+			pod := pod
+			if err := w.Client.Delete(ctx, &pod); client.IgnoreNotFound(err) != nil {
+				w.Log.Error(err, "fails to delete the placeholder pod", "pod", client.ObjectKeyFromObject(&pod))
+			}
+		}
+		w.Log.Info("placeholder pods deleted", "namespace", namespace)
+	}
+	for namespace := range w.notified {
+		if _, ok := warmUps[namespace]; !ok {
+			delete(w.notified, namespace)
+		}
+	}
+
+	for namespace, warmUp := range warmUps {
+		log := w.Log.WithValues("namespace", namespace)
+		if w.PriorityClassName != "" && len(placeholdersByNamespace[namespace]) == 0 {
+			if err := w.createPlaceholders(ctx, namespace, warmUp.pods); err != nil {
+				log.Error(err, "fails to create the placeholder pods")
+			} else {
+				log.Info("placeholder pods created", "wakeUpTime", warmUp.wakeUpTime)
+			}
+		}
+		w.notify(ctx, namespace, warmUp)
+	}
+	return nil
+}
+
+// getWarmUps returns the sleeping namespaces which wake up in the next
+// LeadTime, with the pods they ran before the sleep. If there are more
+// SleepInfo in a namespace, the one which ran the most pods is used.
+func (w *Warmer) getWarmUps(sleepInfos []kubegreenv1alpha1.SleepInfo, now time.Time) map[string]warmUp {
+	warmUps := map[string]warmUp{}
+	for _, sleepInfo := range sleepInfos {
+		sleepInfo := sleepInfo
+		if sleepInfo.Status.OperationType != sleepOperation {
+			continue
+		}
+		pods := getPodsBeforeSleep(sleepInfo.Status.OperationsHistory)
+		if pods == nil || pods.Count == 0 {
+			continue
+		}
+		wakeUpTime, err := sleepinfocontroller.GetNextWakeUp(&sleepInfo, now)
+		if err != nil {
+			w.Log.Error(err, "fails to get the next wake up", "sleepinfo", client.ObjectKeyFromObject(&sleepInfo))
+			continue
+		}
+		if wakeUpTime.IsZero() || now.Before(wakeUpTime.Add(-w.LeadTime)) {
+			continue
+		}
+		if current, ok := warmUps[sleepInfo.Namespace]; ok && current.pods.Count >= pods.Count {
+			continue
+		}
+		warmUps[sleepInfo.Namespace] = warmUp{wakeUpTime: wakeUpTime, pods: pods}
+	}
+	return warmUps
+}
+
+func (w *Warmer) createPlaceholders(ctx context.Context, namespace string, pods *kubegreenv1alpha1.RunningPods) error {
+	count := pods.Count
+	if w.MaxPods > 0 && count > w.MaxPods {
+		count = w.MaxPods
+	}
+	requests := getPlaceholderRequests(pods, count)
+	image := w.Image
+	if image == "" {
+		image = DefaultImage
+	}
+	for i := 0; i < count; i++ {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("kube-green-placeholder-%d", i),
+				Namespace: namespace,
+				Labels:    map[string]string{sleepinfocontroller.PlaceholderLabel: "true"},
+			},
+			Spec: v1.PodSpec{
+				PriorityClassName:             w.PriorityClassName,
+				TerminationGracePeriodSeconds: getPtr(int64(0)),
+				AutomountServiceAccountToken:  getPtr(false),
+				Containers: []v1.Container{{
+					Name:      "placeholder",
+					Image:     image,
+					Resources: v1.ResourceRequirements{Requests: requests},
+				}},
+			},
+		}
+		if err := w.Client.Create(ctx, pod); err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("fails to create pod %s: %s", pod.Name, err)
+		}
+	}
+	return nil
+}
+
+func (w *Warmer) notify(ctx context.Context, namespace string, warmUp warmUp) {
+	if w.Hook == nil || w.notified[namespace].Equal(warmUp.wakeUpTime) {
+		return
+	}
+	event := Event{
+		Namespace:  namespace,
+		WakeUpTime: warmUp.wakeUpTime,
+		Pods:       warmUp.pods.Count,
+		Requests:   warmUp.pods.Requests,
+	}
+	if err := w.Hook.Notify(ctx, event); err != nil {
+		w.Log.Error(err, "fails to notify the warm up hook", "namespace", namespace)
+		return
+	}
+	if w.notified == nil {
+		w.notified = map[string]time.Time{}
+	}
+	w.notified[namespace] = warmUp.wakeUpTime
+}
+
+// getPodsBeforeSleep returns the pods running before the last sleep, or nil
+// if they are not recorded.
+func getPodsBeforeSleep(history []kubegreenv1alpha1.OperationHistory) *kubegreenv1alpha1.RunningPods {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Type == sleepOperation {
+			return history[i].RunningPods
+		}
+	}
+	return nil
+}
+
+// getPlaceholderRequests splits the CPU and the memory requested by the pods
+// among count placeholder pods.
+func getPlaceholderRequests(pods *kubegreenv1alpha1.RunningPods, count int) v1.ResourceList {
+	requests := v1.ResourceList{}
+	if cpu := pods.Requests.Cpu(); !cpu.IsZero() {
+		requests[v1.ResourceCPU] = *resource.NewMilliQuantity(cpu.MilliValue()/int64(count), resource.DecimalSI)
+	}
+	if memory := pods.Requests.Memory(); !memory.IsZero() {
+		requests[v1.ResourceMemory] = *resource.NewQuantity(memory.Value()/int64(count), resource.BinarySI)
+	}
+	return requests
+}
+
+func getPtr[T any](item T) *T {
+	return &item
+}
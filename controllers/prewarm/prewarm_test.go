@@ -0,0 +1,194 @@
+package prewarm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	sleepinfocontroller "github.com/kube-green/kube-green/controllers/sleepinfo"
+	"github.com/kube-green/kube-green/pkg/testutil"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeHook struct {
+	events []Event
+}
+
+func (f *fakeHook) Notify(_ context.Context, event Event) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+func TestWarmer(t *testing.T) {
+	// Wednesday, the namespaces wake up at 08:00.
+	beforeWakeUp := time.Date(2021, 3, 24, 7, 45, 0, 0, time.UTC)
+	wakeUpTime := time.Date(2021, 3, 24, 8, 0, 0, 0, time.UTC)
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	podsBeforeSleep := &kubegreenv1alpha1.RunningPods{
+		Count: 4,
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("2"),
+			v1.ResourceMemory: resource.MustParse("4Gi"),
+		},
+	}
+	getSleepInfo := func(namespace, operationType string, history ...kubegreenv1alpha1.OperationHistory) *kubegreenv1alpha1.SleepInfo {
+		return &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: namespace},
+			Spec:       kubegreenv1alpha1.SleepInfoSpec{Weekdays: "1-5", SleepTime: "20:00", WakeUpTime: "08:00"},
+			Status: kubegreenv1alpha1.SleepInfoStatus{
+				OperationType:     operationType,
+				OperationsHistory: history,
+			},
+		}
+	}
+	newWarmer := func(now time.Time) (*Warmer, client.Client, *fakeHook) {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			getSleepInfo("team-a", "SLEEP",
+				kubegreenv1alpha1.OperationHistory{Type: "SLEEP", RunningPods: podsBeforeSleep},
+			),
+			// the running pods are not recorded.
+			getSleepInfo("team-b", "SLEEP",
+				kubegreenv1alpha1.OperationHistory{Type: "SLEEP"},
+			),
+			getSleepInfo("team-c", "WAKE_UP",
+				kubegreenv1alpha1.OperationHistory{Type: "SLEEP", RunningPods: podsBeforeSleep},
+				kubegreenv1alpha1.OperationHistory{Type: "WAKE_UP"},
+			),
+			// left over after the wake up.
+			&v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "kube-green-placeholder-0",
+					Namespace: "team-c",
+					Labels:    map[string]string{sleepinfocontroller.PlaceholderLabel: "true"},
+				},
+			},
+			&v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "team-c"},
+			},
+		).Build()
+		hook := &fakeHook{}
+		return &Warmer{
+			Client:            c,
+			PodReader:         c,
+			Interval:          time.Minute,
+			LeadTime:          30 * time.Minute,
+			PriorityClassName: "kube-green-placeholder",
+			MaxPods:           2,
+			Hook:              hook,
+			Log:               logr.Discard(),
+			Clock:             testutil.NewClock(now),
+		}, c, hook
+	}
+	listPods := func(t *testing.T, c client.Client, namespace string) []v1.Pod {
+		t.Helper()
+		pods := v1.PodList{}
+		require.NoError(t, c.List(context.Background(), &pods, client.InNamespace(namespace)))
+		return pods.Items
+	}
+
+	t.Run("warm up before the wake up", func(t *testing.T) {
+		warmer, c, hook := newWarmer(beforeWakeUp)
+		require.NoError(t, warmer.Run(context.Background()))
+
+		pods := listPods(t, c, "team-a")
+		require.Len(t, pods, 2)
+		for i, pod := range pods {
+			require.Equal(t, []string{"kube-green-placeholder-0", "kube-green-placeholder-1"}[i], pod.Name)
+			require.Equal(t, "true", pod.Labels[sleepinfocontroller.PlaceholderLabel])
+			require.Equal(t, "kube-green-placeholder", pod.Spec.PriorityClassName)
+			require.Equal(t, DefaultImage, pod.Spec.Containers[0].Image)
+			require.Equal(t, "1", pod.Spec.Containers[0].Resources.Requests.Cpu().String())
+			require.Equal(t, "2Gi", pod.Spec.Containers[0].Resources.Requests.Memory().String())
+		}
+		require.Empty(t, listPods(t, c, "team-b"))
+		// only the placeholder pod is deleted.
+		pods = listPods(t, c, "team-c")
+		require.Len(t, pods, 1)
+		require.Equal(t, "api", pods[0].Name)
+
+		require.Len(t, hook.events, 1)
+		require.Equal(t, "team-a", hook.events[0].Namespace)
+		require.True(t, wakeUpTime.Equal(hook.events[0].WakeUpTime))
+		require.Equal(t, 4, hook.events[0].Pods)
+
+		t.Run("placeholder pods and notification are not repeated", func(t *testing.T) {
+			require.NoError(t, warmer.Run(context.Background()))
+			require.Len(t, listPods(t, c, "team-a"), 2)
+			require.Len(t, hook.events, 1)
+		})
+	})
+
+	t.Run("only the hook without priority class", func(t *testing.T) {
+		warmer, c, hook := newWarmer(beforeWakeUp)
+		warmer.PriorityClassName = ""
+		require.NoError(t, warmer.Run(context.Background()))
+
+		require.Empty(t, listPods(t, c, "team-a"))
+		require.Len(t, hook.events, 1)
+	})
+
+	t.Run("no warm up before the lead time", func(t *testing.T) {
+		warmer, c, hook := newWarmer(wakeUpTime.Add(-time.Hour))
+		require.NoError(t, warmer.Run(context.Background()))
+
+		require.Empty(t, listPods(t, c, "team-a"))
+		require.Empty(t, hook.events)
+	})
+}
+
+func TestGetPlaceholderRequests(t *testing.T) {
+	pods := &kubegreenv1alpha1.RunningPods{
+		Count: 3,
+		Requests: v1.ResourceList{
+			v1.ResourceCPU: resource.MustParse("1"),
+		},
+	}
+	requests := getPlaceholderRequests(pods, 3)
+	require.Equal(t, "333m", requests.Cpu().String())
+	_, ok := requests[v1.ResourceMemory]
+	require.False(t, ok)
+}
+
+func TestHTTPHook(t *testing.T) {
+	var received Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, http.MethodPost, req.Method)
+		require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&received))
+		if received.Namespace == "" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	hook := NewHTTPHook(nil, server.URL)
+	event := Event{
+		Namespace:  "team-a",
+		WakeUpTime: time.Date(2021, 3, 24, 8, 0, 0, 0, time.UTC),
+		Pods:       4,
+		Requests:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+	}
+	require.NoError(t, hook.Notify(context.Background(), event))
+	require.Equal(t, "team-a", received.Namespace)
+	require.Equal(t, 4, received.Pods)
+	require.Equal(t, "2", received.Requests.Cpu().String())
+
+	err := hook.Notify(context.Background(), Event{})
+	require.EqualError(t, err, "warm up hook responded with status 400")
+}
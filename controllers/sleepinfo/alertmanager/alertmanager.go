@@ -0,0 +1,164 @@
+package alertmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// CreatedBy is the author of the silences created by kube-green.
+	CreatedBy = "kube-green"
+
+	stateExpired = "expired"
+)
+
+// Silencer creates and expires the Alertmanager silences of the sleeping namespaces.
+type Silencer interface {
+	// Silence silences the alerts of the namespace until endsAt. If the
+	// silence of the SleepInfo already exists, it is extended.
+	Silence(ctx context.Context, namespace, sleepInfo string, endsAt time.Time) error
+	// Expire expires the silences of the namespace created for the SleepInfo.
+	Expire(ctx context.Context, namespace, sleepInfo string) error
+}
+
+// Matcher is the matcher of the alert labels of a silence.
+type Matcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+// Silence is a silence of the Alertmanager v2 API.
+type Silence struct {
+	ID        string         `json:"id,omitempty"`
+	Matchers  []Matcher      `json:"matchers"`
+	StartsAt  time.Time      `json:"startsAt"`
+	EndsAt    time.Time      `json:"endsAt"`
+	CreatedBy string         `json:"createdBy"`
+	Comment   string         `json:"comment"`
+	Status    *SilenceStatus `json:"status,omitempty"`
+}
+
+type SilenceStatus struct {
+	State string `json:"state"`
+}
+
+type silencer struct {
+	client         *http.Client
+	url            string
+	namespaceLabel string
+}
+
+// NewSilencer returns a Silencer which uses the Alertmanager v2 API at the url.
+// The silences match the alerts with the namespaceLabel label set to the
+// namespace. If httpClient is nil, http.DefaultClient is used.
+func NewSilencer(httpClient *http.Client, alertmanagerURL, namespaceLabel string) Silencer {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &silencer{
+		client:         httpClient,
+		url:            strings.TrimSuffix(alertmanagerURL, "/"),
+		namespaceLabel: namespaceLabel,
+	}
+}
+
+func (s *silencer) Silence(ctx context.Context, namespace, sleepInfo string, endsAt time.Time) error {
+	silences, err := s.getSilences(ctx, namespace, sleepInfo)
+	if err != nil {
+		return err
+	}
+
+	silence := Silence{
+		Matchers: []Matcher{
+			{Name: s.namespaceLabel, Value: namespace, IsEqual: true},
+		},
+		StartsAt:  time.Now().UTC(),
+		EndsAt:    endsAt.UTC(),
+		CreatedBy: CreatedBy,
+		Comment:   getComment(namespace, sleepInfo),
+	}
+	if len(silences) > 0 {
+		silence.ID = silences[0].ID
+		silence.StartsAt = silences[0].StartsAt
+	}
+
+	body, err := json.Marshal(silence)
+	if err != nil {
+		return err
+	}
+	return s.do(ctx, http.MethodPost, "/api/v2/silences", body, nil)
+}
+
+func (s *silencer) Expire(ctx context.Context, namespace, sleepInfo string) error {
+	silences, err := s.getSilences(ctx, namespace, sleepInfo)
+	if err != nil {
+		return err
+	}
+	for _, silence := range silences {
+		if err := s.do(ctx, http.MethodDelete, "/api/v2/silence/"+url.PathEscape(silence.ID), nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getSilences returns the silences not expired created by kube-green for the SleepInfo.
+func (s *silencer) getSilences(ctx context.Context, namespace, sleepInfo string) ([]Silence, error) {
+	query := url.Values{}
+	query.Set("filter", fmt.Sprintf("%s=%q", s.namespaceLabel, namespace))
+
+	allSilences := []Silence{}
+	if err := s.do(ctx, http.MethodGet, "/api/v2/silences?"+query.Encode(), nil, &allSilences); err != nil {
+		return nil, err
+	}
+
+	silences := []Silence{}
+	for _, silence := range allSilences {
+		if silence.CreatedBy != CreatedBy || silence.Comment != getComment(namespace, sleepInfo) {
+			continue
+		}
+		if silence.Status != nil && silence.Status.State == stateExpired {
+			continue
+		}
+		silences = append(silences, silence)
+	}
+	return silences, nil
+}
+
+func (s *silencer) do(ctx context.Context, method, path string, body []byte, response interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, s.url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("alertmanager responded with status %d", res.StatusCode)
+	}
+	if response == nil {
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+		return fmt.Errorf("invalid alertmanager response: %s", err)
+	}
+	return nil
+}
+
+func getComment(namespace, sleepInfo string) string {
+	return fmt.Sprintf("namespace %s is sleeping by SleepInfo %s", namespace, sleepInfo)
+}
@@ -0,0 +1,122 @@
+package alertmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAlertmanager is an in memory implementation of the silences of the
+// Alertmanager v2 API.
+type fakeAlertmanager struct {
+	silences []Silence
+	filters  []string
+}
+
+func (f *fakeAlertmanager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/silences":
+		f.filters = append(f.filters, r.URL.Query().Get("filter"))
+		_ = json.NewEncoder(w).Encode(f.silences)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v2/silences":
+		silence := Silence{}
+		if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		silence.Status = &SilenceStatus{State: "active"}
+		if silence.ID == "" {
+			silence.ID = fmt.Sprintf("silence-%d", len(f.silences)+1)
+			f.silences = append(f.silences, silence)
+		} else {
+			for i := range f.silences {
+				if f.silences[i].ID == silence.ID {
+					f.silences[i] = silence
+				}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"silenceID": silence.ID})
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/api/v2/silence/"):
+		id := strings.TrimPrefix(r.URL.Path, "/api/v2/silence/")
+		for i := range f.silences {
+			if f.silences[i].ID == id {
+				f.silences[i].Status = &SilenceStatus{State: stateExpired}
+			}
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestSilencer(t *testing.T) {
+	endsAt := time.Date(2021, 3, 24, 8, 0, 0, 0, time.UTC)
+
+	t.Run("creates, extends and expires the silence", func(t *testing.T) {
+		alertmanager := &fakeAlertmanager{
+			silences: []Silence{
+				{ID: "other", CreatedBy: "someone", Comment: "maintenance", Status: &SilenceStatus{State: "active"}},
+			},
+		}
+		server := httptest.NewServer(alertmanager)
+		defer server.Close()
+		s := NewSilencer(server.Client(), server.URL+"/", "namespace")
+
+		require.NoError(t, s.Silence(context.Background(), "my-namespace", "sleepinfo", endsAt))
+		require.Len(t, alertmanager.silences, 2)
+		silence := alertmanager.silences[1]
+		require.Equal(t, []Matcher{{Name: "namespace", Value: "my-namespace", IsEqual: true}}, silence.Matchers)
+		require.Equal(t, endsAt, silence.EndsAt)
+		require.Equal(t, CreatedBy, silence.CreatedBy)
+		require.Equal(t, "namespace my-namespace is sleeping by SleepInfo sleepinfo", silence.Comment)
+		require.Equal(t, []string{`namespace="my-namespace"`}, alertmanager.filters)
+
+		require.NoError(t, s.Silence(context.Background(), "my-namespace", "sleepinfo", endsAt.Add(time.Hour)))
+		require.Len(t, alertmanager.silences, 2, "the silence is extended")
+		require.Equal(t, endsAt.Add(time.Hour), alertmanager.silences[1].EndsAt)
+
+		require.NoError(t, s.Expire(context.Background(), "my-namespace", "sleepinfo"))
+		require.Equal(t, stateExpired, alertmanager.silences[1].Status.State)
+		require.Equal(t, "active", alertmanager.silences[0].Status.State, "other silences are not expired")
+
+		require.NoError(t, s.Silence(context.Background(), "my-namespace", "sleepinfo", endsAt))
+		require.Len(t, alertmanager.silences, 3, "expired silences are not extended")
+	})
+
+	t.Run("expire without silences", func(t *testing.T) {
+		alertmanager := &fakeAlertmanager{}
+		server := httptest.NewServer(alertmanager)
+		defer server.Close()
+		s := NewSilencer(nil, server.URL, "kubernetes_namespace")
+
+		require.NoError(t, s.Expire(context.Background(), "my-namespace", "sleepinfo"))
+		require.Equal(t, []string{`kubernetes_namespace="my-namespace"`}, alertmanager.filters)
+	})
+
+	t.Run("fails if alertmanager responds with error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+		s := NewSilencer(nil, server.URL, "namespace")
+
+		require.EqualError(t, s.Silence(context.Background(), "my-namespace", "sleepinfo", endsAt), "alertmanager responded with status 500")
+		require.EqualError(t, s.Expire(context.Background(), "my-namespace", "sleepinfo"), "alertmanager responded with status 500")
+	})
+
+	t.Run("fails if alertmanager response is invalid", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("not a json"))
+		}))
+		defer server.Close()
+		s := NewSilencer(nil, server.URL, "namespace")
+
+		require.EqualError(t, s.Expire(context.Background(), "my-namespace", "sleepinfo"), "invalid alertmanager response: invalid character 'o' in literal null (expecting 'u')")
+	})
+}
@@ -0,0 +1,34 @@
+package audit
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// ActorSchedule is the actor of the operations triggered by the SleepInfo schedule.
+	ActorSchedule = "schedule"
+	// ActorManual is the actor of the operations triggered on demand by a user.
+	ActorManual = "manual"
+
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Event is the audit record of a sleep or wake up operation.
+type Event struct {
+	Timestamp time.Time `json:"timestamp"`
+	Namespace string    `json:"namespace"`
+	SleepInfo string    `json:"sleepInfo"`
+	Operation string    `json:"operation"`
+	Actor     string    `json:"actor"`
+	// Resources contains the names of the touched resources, grouped by kind.
+	Resources map[string][]string `json:"resources,omitempty"`
+	Outcome   string              `json:"outcome"`
+	Error     string              `json:"error,omitempty"`
+}
+
+// Sink is where the audit events are shipped.
+type Sink interface {
+	Write(ctx context.Context, event Event) error
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func getEvent(operation string) Event {
+	return Event{
+		Timestamp: time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC),
+		Namespace: "my-namespace",
+		SleepInfo: "my-sleepinfo",
+		Operation: operation,
+		Actor:     ActorSchedule,
+		Resources: map[string][]string{
+			"Deployment": {"deployment1", "deployment2"},
+		},
+		Outcome: OutcomeSuccess,
+	}
+}
+
+func TestJSONSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sink := NewJSONSink(buf)
+
+	require.NoError(t, sink.Write(context.Background(), getEvent("SLEEP")))
+	require.NoError(t, sink.Write(context.Background(), getEvent("WAKE_UP")))
+
+	require.Equal(t, `{"timestamp":"2021-03-23T20:00:00Z","namespace":"my-namespace","sleepInfo":"my-sleepinfo","operation":"SLEEP","actor":"schedule","resources":{"Deployment":["deployment1","deployment2"]},"outcome":"success"}
+{"timestamp":"2021-03-23T20:00:00Z","namespace":"my-namespace","sleepInfo":"my-sleepinfo","operation":"WAKE_UP","actor":"schedule","resources":{"Deployment":["deployment1","deployment2"]},"outcome":"success"}
+`, buf.String())
+}
+
+func TestConfigMapSink(t *testing.T) {
+	ctx := context.Background()
+	namespace := "kube-green"
+	name := "audit"
+
+	getEvents := func(t *testing.T, c client.Client) []Event {
+		t.Helper()
+		configMap := &v1.ConfigMap{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, configMap))
+		events := []Event{}
+		require.NoError(t, json.Unmarshal([]byte(configMap.Data[configMapEventsKey]), &events))
+		return events
+	}
+
+	t.Run("create configmap if not exists", func(t *testing.T) {
+		c := fake.NewClientBuilder().Build()
+		sink := NewConfigMapSink(c, namespace, name, 2)
+
+		require.NoError(t, sink.Write(ctx, getEvent("SLEEP")))
+
+		require.Equal(t, []Event{getEvent("SLEEP")}, getEvents(t, c))
+	})
+
+	t.Run("keep only the last events", func(t *testing.T) {
+		c := fake.NewClientBuilder().Build()
+		sink := NewConfigMapSink(c, namespace, name, 2)
+
+		require.NoError(t, sink.Write(ctx, getEvent("SLEEP")))
+		require.NoError(t, sink.Write(ctx, getEvent("WAKE_UP")))
+		require.NoError(t, sink.Write(ctx, getEvent("SLEEP")))
+
+		require.Equal(t, []Event{getEvent("WAKE_UP"), getEvent("SLEEP")}, getEvents(t, c))
+	})
+
+	t.Run("retry if configmap is created concurrently", func(t *testing.T) {
+		fakeClient := fake.NewClientBuilder().Build()
+		c := &racingCreateClient{
+			Client: fakeClient,
+			racing: NewConfigMapSink(fakeClient, namespace, name, 2),
+			event:  getEvent("WAKE_UP"),
+		}
+		sink := NewConfigMapSink(c, namespace, name, 2)
+
+		require.NoError(t, sink.Write(ctx, getEvent("SLEEP")))
+
+		require.Equal(t, []Event{getEvent("WAKE_UP"), getEvent("SLEEP")}, getEvents(t, c))
+	})
+
+	t.Run("fails if configmap data is not valid", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithObjects(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      name,
+			},
+			Data: map[string]string{
+				configMapEventsKey: "not-a-json",
+			},
+		}).Build()
+		sink := NewConfigMapSink(c, namespace, name, 2)
+
+		err := sink.Write(ctx, getEvent("SLEEP"))
+		require.ErrorContains(t, err, "fails to parse audit events in configmap audit")
+	})
+}
+
+// racingCreateClient writes, before the first Create, the event of a
+// concurrent sink, which creates the ConfigMap.
+type racingCreateClient struct {
+	client.Client
+	racing Sink
+	event  Event
+	raced  bool
+}
+
+func (c *racingCreateClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if !c.raced {
+		c.raced = true
+		if err := c.racing.Write(ctx, c.event); err != nil {
+			return err
+		}
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func TestHTTPSink(t *testing.T) {
+	t.Run("send event", func(t *testing.T) {
+		var receivedEvent Event
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodPost, r.Method)
+			require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(body, &receivedEvent))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		sink := NewHTTPSink(server.Client(), server.URL)
+		require.NoError(t, sink.Write(context.Background(), getEvent("SLEEP")))
+		require.Equal(t, getEvent("SLEEP"), receivedEvent)
+	})
+
+	t.Run("fails if endpoint responds with error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		sink := NewHTTPSink(nil, server.URL)
+		err := sink.Write(context.Background(), getEvent("SLEEP"))
+		require.EqualError(t, err, "audit endpoint responded with status 500")
+	})
+}
@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const configMapEventsKey = "events"
+
+type configMapSink struct {
+	client    client.Client
+	namespace string
+	name      string
+	size      int
+}
+
+// NewConfigMapSink returns a Sink which keeps the last size events in the
+// ConfigMap, as a ring buffer. The ConfigMap is created if it does not exist.
+func NewConfigMapSink(c client.Client, namespace, name string, size int) Sink {
+	return &configMapSink{
+		client:    c,
+		namespace: namespace,
+		name:      name,
+		size:      size,
+	}
+}
+
+func (s *configMapSink) Write(ctx context.Context, event Event) error {
+	// the ConfigMap can be created by a concurrent write after it is read: the
+	// write is retried as on a conflict, appending the event to its events.
+	return retry.OnError(retry.DefaultRetry, isConflictOrAlreadyExists, func() error {
+		configMap := &v1.ConfigMap{}
+		err := s.client.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: s.name}, configMap)
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		isNew := apierrors.IsNotFound(err)
+
+		events := []Event{}
+		if data := configMap.Data[configMapEventsKey]; data != "" {
+			if err := json.Unmarshal([]byte(data), &events); err != nil {
+				return fmt.Errorf("fails to parse audit events in configmap %s: %s", s.name, err)
+			}
+		}
+		events = append(events, event)
+		if s.size > 0 && len(events) > s.size {
+			events = events[len(events)-s.size:]
+		}
+		data, err := json.Marshal(events)
+		if err != nil {
+			return err
+		}
+
+		if isNew {
+			return s.client.Create(ctx, &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      s.name,
+					Namespace: s.namespace,
+				},
+				Data: map[string]string{
+					configMapEventsKey: string(data),
+				},
+			})
+		}
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[configMapEventsKey] = string(data)
+		return s.client.Update(ctx, configMap)
+	})
+}
+
+func isConflictOrAlreadyExists(err error) bool {
+	return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+}
@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type httpSink struct {
+	client *http.Client
+	url    string
+}
+
+// NewHTTPSink returns a Sink which sends each event as JSON with a POST request
+// to the url. If httpClient is nil, http.DefaultClient is used.
+func NewHTTPSink(httpClient *http.Client, url string) Sink {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &httpSink{
+		client: httpClient,
+		url:    url,
+	}
+}
+
+func (s *httpSink) Write(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("audit endpoint responded with status %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+type jsonSink struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewJSONSink returns a Sink which writes the events to w, one JSON object per line.
+func NewJSONSink(w io.Writer) Sink {
+	return &jsonSink{
+		encoder: json.NewEncoder(w),
+	}
+}
+
+func (s *jsonSink) Write(_ context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.encoder.Encode(event)
+}
@@ -0,0 +1,84 @@
+package sleepinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// checkpointSaveInterval is how many resources are patched between two saves
+// of the checkpoint of the operation.
+const checkpointSaveInterval = 10
+
+// checkpointGroupKinds are the group kinds of the resources handled by the
+// SleepInfo, by the kind returned by getResourceNames. The names of the
+// custom resources already have their group kind.
+var checkpointGroupKinds = map[string]schema.GroupKind{
+	"Deployment":            {Group: "apps", Kind: "Deployment"},
+	"CronJob":               {Group: "batch", Kind: "CronJob"},
+	"Job":                   {Group: "batch", Kind: "Job"},
+	"ReplicaSet":            {Group: "apps", Kind: "ReplicaSet"},
+	"ReplicationController": {Kind: "ReplicationController"},
+	"DaemonSet":             {Group: "apps", Kind: "DaemonSet"},
+	"PodDisruptionBudget":   {Group: "policy", Kind: "PodDisruptionBudget"},
+	"Pod":                   {Kind: "Pod"},
+}
+
+// getCheckpoint returns the checkpoint of the operation: the one stored in the
+// secret if the operation is resumed, otherwise an empty one.
+func getCheckpoint(secret *v1.Secret, sleepInfoData SleepInfoData) (*resource.Checkpoint, error) {
+	if sleepInfoData.PendingOperationID == "" || secret == nil {
+		return resource.NewCheckpoint(resource.CheckpointData{}), nil
+	}
+	data, err := resource.UnmarshalCheckpointData(secret.Data[operationCheckpointKey])
+	if err != nil {
+		return resource.NewCheckpoint(resource.CheckpointData{}), fmt.Errorf("fails to parse %s: %s", operationCheckpointKey, err)
+	}
+	return resource.NewCheckpoint(data), nil
+}
+
+// getCheckpointKeys returns the keys in the checkpoint of the resources
+// handled by the SleepInfo.
+func (r Resources) getCheckpointKeys() []string {
+	keys := []string{}
+	for kind, names := range r.getResourceNames() {
+		for _, name := range names {
+			groupKind, ok := checkpointGroupKinds[kind]
+			if !ok {
+				keys = append(keys, name)
+				continue
+			}
+			keys = append(keys, resource.GetCheckpointKey(groupKind, name))
+		}
+	}
+	return keys
+}
+
+// getCheckpointSave returns the function which saves the checkpoint of the
+// operation in the secret, with a patch so that the state is not rewritten.
+func (r *SleepInfoReconciler) getCheckpointSave(secretName, namespace string) func(ctx context.Context, data resource.CheckpointData) error {
+	return func(ctx context.Context, data resource.CheckpointData) error {
+		value, err := data.Marshal()
+		if err != nil {
+			return err
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"data": map[string][]byte{operationCheckpointKey: value},
+		})
+		if err != nil {
+			return err
+		}
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: namespace},
+		}
+		return r.Client.Patch(ctx, secret, client.RawPatch(types.MergePatchType, patch))
+	}
+}
@@ -0,0 +1,135 @@
+package sleepinfo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/deployments"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestGetCheckpoint(t *testing.T) {
+	secret := &v1.Secret{Data: map[string][]byte{
+		operationCheckpointKey: []byte(`{"planned":["Deployment.apps/api","Deployment.apps/frontend"],"patched":["Deployment.apps/api"]}`),
+	}}
+
+	t.Run("new operation", func(t *testing.T) {
+		checkpoint, err := getCheckpoint(secret, SleepInfoData{CurrentOperationType: sleepOperation})
+		require.NoError(t, err)
+		require.Equal(t, resource.CheckpointData{Patched: []string{}}, checkpoint.Get())
+	})
+
+	t.Run("resumed operation", func(t *testing.T) {
+		checkpoint, err := getCheckpoint(secret, SleepInfoData{CurrentOperationType: sleepOperation, PendingOperationID: "SLEEP-1709582400"})
+		require.NoError(t, err)
+		require.False(t, checkpoint.IsToPatch("Deployment.apps/api"))
+		require.True(t, checkpoint.IsToPatch("Deployment.apps/frontend"))
+		require.False(t, checkpoint.IsToPatch("Deployment.apps/new"))
+	})
+
+	t.Run("resumed operation without checkpoint", func(t *testing.T) {
+		checkpoint, err := getCheckpoint(&v1.Secret{}, SleepInfoData{CurrentOperationType: wakeUpOperation, PendingOperationID: "WAKE_UP-1709582400"})
+		require.NoError(t, err)
+		require.True(t, checkpoint.IsToPatch("Deployment.apps/api"))
+	})
+
+	t.Run("invalid checkpoint", func(t *testing.T) {
+		invalidSecret := &v1.Secret{Data: map[string][]byte{operationCheckpointKey: []byte("{")}}
+		checkpoint, err := getCheckpoint(invalidSecret, SleepInfoData{CurrentOperationType: sleepOperation, PendingOperationID: "SLEEP-1709582400"})
+		require.ErrorContains(t, err, "fails to parse operation-checkpoint")
+		require.True(t, checkpoint.IsToPatch("Deployment.apps/api"))
+	})
+}
+
+func TestGetCheckpointKeys(t *testing.T) {
+	r := newResourcesMock(t, resource.Mock{
+		MockResourceNames: []string{"api", "frontend"},
+	}, resource.Mock{
+		MockResourceNames: []string{"report"},
+	})
+	r.customresources = resource.GetResourceMock(resource.Mock{
+		MockResourceNames: []string{"Cluster.postgresql.cnpg.io/db"},
+	})
+	keys := r.getCheckpointKeys()
+	sort.Strings(keys)
+	require.Equal(t, []string{
+		"Cluster.postgresql.cnpg.io/db",
+		"CronJob.batch/report",
+		"Deployment.apps/api",
+		"Deployment.apps/frontend",
+	}, keys)
+}
+
+func TestResumeSleepFromCheckpoint(t *testing.T) {
+	testLogger := zap.New(zap.UseDevMode(true))
+	secretName := "secret-name"
+	namespace := "my-namespace"
+	now := time.Date(2023, 1, 9, 20, 0, 0, 0, time.UTC)
+	pendingOperationID := fmt.Sprintf("%s-%d", sleepOperation, now.Add(-time.Minute).Unix())
+	storedReplicas := []byte(`[{"name":"api","replicas":3},{"name":"frontend","replicas":2}]`)
+	storedSecret := getSecret(mockSecretSpec{
+		namespace: namespace,
+		name:      secretName,
+		data: withStateChecksum(map[string][]byte{
+			lastOperationKey:       []byte(sleepOperation),
+			lastScheduleKey:        []byte(now.Add(-time.Minute).Format(time.RFC3339)),
+			pendingOperationKey:    []byte(pendingOperationID),
+			operationCheckpointKey: []byte(`{"planned":["Deployment.apps/api","Deployment.apps/frontend"],"patched":["Deployment.apps/api"]}`),
+			replicasBeforeSleepKey: storedReplicas,
+		}),
+	})
+	var replicas0, replicas2, replicas1 int32 = 0, 2, 1
+	// api is already asleep, and new is created after the sleep started.
+	api := deployments.GetMock(deployments.MockSpec{Name: "api", Namespace: namespace, Replicas: &replicas0})
+	frontend := deployments.GetMock(deployments.MockSpec{Name: "frontend", Namespace: namespace, Replicas: &replicas2})
+	newDeployment := deployments.GetMock(deployments.MockSpec{Name: "new", Namespace: namespace, Replicas: &replicas1})
+
+	c := fake.NewClientBuilder().WithRuntimeObjects(storedSecret, &api, &frontend, &newDeployment).Build()
+	r := SleepInfoReconciler{Client: c, Log: testLogger}
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{}
+	sleepInfoData := SleepInfoData{
+		CurrentOperationType:        sleepOperation,
+		PendingOperationID:          pendingOperationID,
+		OriginalDeploymentsReplicas: map[string]int32{"api": 3, "frontend": 2},
+	}
+	checkpoint, err := getCheckpoint(storedSecret, sleepInfoData)
+	require.NoError(t, err)
+	resources, err := NewResources(context.Background(), resource.ResourceClient{
+		Client:     c,
+		Log:        testLogger,
+		SleepInfo:  sleepInfo,
+		Checkpoint: checkpoint,
+	}, namespace, sleepInfoData)
+	require.NoError(t, err)
+
+	require.NoError(t, r.upsertSecret(context.Background(), testLogger, now, secretName, namespace, sleepInfo, storedSecret, sleepInfoData, resources))
+	secret, err := r.getSecret(context.Background(), secretName, namespace)
+	require.NoError(t, err)
+	require.Equal(t, storedReplicas, secret.Data[replicasBeforeSleepKey])
+	require.Equal(t, []byte(pendingOperationID), secret.Data[pendingOperationKey])
+	require.JSONEq(t, `{"planned":["Deployment.apps/api","Deployment.apps/frontend"],"patched":["Deployment.apps/api"]}`, string(secret.Data[operationCheckpointKey]))
+
+	checkpoint.SetSave(1, r.getCheckpointSave(secretName, namespace))
+	require.NoError(t, resources.sleep(context.Background()))
+
+	for name, expected := range map[string]int32{"api": 0, "frontend": 0, "new": 1} {
+		deployment := appsv1.Deployment{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, &deployment))
+		require.Equal(t, expected, *deployment.Spec.Replicas, name)
+	}
+	secret, err = r.getSecret(context.Background(), secretName, namespace)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"planned":["Deployment.apps/api","Deployment.apps/frontend"],"patched":["Deployment.apps/api","Deployment.apps/frontend"]}`, string(secret.Data[operationCheckpointKey]))
+	require.Equal(t, storedReplicas, secret.Data[replicasBeforeSleepKey])
+}
@@ -9,7 +9,12 @@ import (
 
 	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
 	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/internal/tracing"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
@@ -21,6 +26,10 @@ var (
 	ErrFetchingCronJobs = errors.New("error fetching cronjobs")
 )
 
+// listPageSize is the number of cron jobs listed by each request, so that the
+// namespaces with thousands of cron jobs are listed in pages.
+const listPageSize = 500
+
 type OriginalSuspendStatus map[string]bool
 type cronjobs struct {
 	resource.ResourceClient
@@ -33,7 +42,7 @@ func NewResource(ctx context.Context, res resource.ResourceClient, namespace str
 	c := cronjobs{
 		ResourceClient:        res,
 		OriginalSuspendStatus: originalSuspendStatus,
-		areToSuspend:          res.SleepInfo.IsCronjobsToSuspend(),
+		areToSuspend:          res.SleepInfo.IsCronjobsToSuspend() || (res.IsWakeUp && len(originalSuspendStatus) > 0),
 		data:                  []unstructured.Unstructured{},
 	}
 	if !c.areToSuspend {
@@ -50,17 +59,28 @@ func (c cronjobs) HasResource() bool {
 	return len(c.data) > 0
 }
 
+func (c cronjobs) GetResourceNames() []string {
+	names := []string{}
+	for _, cronjob := range c.data {
+		names = append(names, cronjob.GetName())
+	}
+	return names
+}
+
 func getSuspendStatus(cronjob unstructured.Unstructured) (bool, bool, error) {
 	return unstructured.NestedBool(cronjob.Object, "spec", "suspend")
 }
 
-func (c cronjobs) Sleep(ctx context.Context) error {
+func (c cronjobs) Sleep(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "cronjobs.sleep", trace.WithAttributes(attribute.Int("resources.count", len(c.data))))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	for _, cronjob := range c.data {
 		cronjobSuspended, found, err := getSuspendStatus(cronjob)
 		if err != nil {
 			return err
 		}
-		if found && cronjobSuspended {
+		if (found && cronjobSuspended) || c.IsKeptAwake(&cronjob) {
 			continue
 		}
 		newCronJob := cronjob.DeepCopy()
@@ -76,7 +96,10 @@ func (c cronjobs) Sleep(ctx context.Context) error {
 	return nil
 }
 
-func (c cronjobs) WakeUp(ctx context.Context) error {
+func (c cronjobs) WakeUp(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "cronjobs.wakeUp", trace.WithAttributes(attribute.Int("resources.count", len(c.data))))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	for _, cronjob := range c.data {
 		cronjob := cronjob
 
@@ -107,6 +130,50 @@ func (c cronjobs) WakeUp(ctx context.Context) error {
 	return nil
 }
 
+// Resuspend suspends again the CronJobs put to sleep, i.e. the ones in
+// originalSuspendStatus, which have been resumed while the namespace is
+// sleeping. It returns the names of the suspended CronJobs. An event is
+// recorded on each of them, to identify the SleepInfo suspending it.
+func Resuspend(ctx context.Context, res resource.ResourceClient, namespace string, originalSuspendStatus OriginalSuspendStatus) ([]string, error) {
+	if !res.SleepInfo.IsCronjobsToSuspend() || len(originalSuspendStatus) == 0 {
+		return nil, nil
+	}
+	c := cronjobs{
+		ResourceClient:        res,
+		OriginalSuspendStatus: originalSuspendStatus,
+		areToSuspend:          true,
+	}
+	if err := c.fetch(ctx, namespace); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrFetchingCronJobs, err)
+	}
+
+	suspended := []string{}
+	for _, cronjob := range c.data {
+		cronjob := cronjob
+		if _, ok := originalSuspendStatus[cronjob.GetName()]; !ok {
+			continue
+		}
+		cronjobSuspended, found, err := getSuspendStatus(cronjob)
+		if err != nil {
+			return suspended, err
+		}
+		if (found && cronjobSuspended) || c.IsKeptAwake(&cronjob) {
+			continue
+		}
+		newCronJob := cronjob.DeepCopy()
+		unstructured.RemoveNestedField(newCronJob.Object, "metadata", "resourceVersion")
+		if err = unstructured.SetNestedField(newCronJob.Object, true, "spec", "suspend"); err != nil {
+			return suspended, err
+		}
+		if err := c.SSAPatch(ctx, newCronJob); err != nil {
+			return suspended, err
+		}
+		c.Eventf(&cronjob, v1.EventTypeNormal, "Resuspended", "CronJob resumed while sleeping, suspended again by SleepInfo %s", res.SleepInfo.GetName())
+		suspended = append(suspended, cronjob.GetName())
+	}
+	return suspended, nil
+}
+
 type OriginalCronJobStatus struct {
 	Name    string `json:"name"`
 	Suspend bool   `json:"suspend"`
@@ -122,7 +189,9 @@ func (c cronjobs) GetOriginalInfoToSave() ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		if found && cronJobSuspended {
+		// a cron job suspended by a previous sleep is still to wake up.
+		status, ok := c.OriginalSuspendStatus[cronJob.GetName()]
+		if found && cronJobSuspended && (!ok || status) {
 			continue
 		}
 		cronJobsStatus = append(cronJobsStatus, OriginalCronJobStatus{
@@ -132,20 +201,29 @@ func (c cronjobs) GetOriginalInfoToSave() ([]byte, error) {
 	return json.Marshal(cronJobsStatus)
 }
 
-func (c *cronjobs) fetch(ctx context.Context, namespace string) error {
-	var err error
+func (c *cronjobs) fetch(ctx context.Context, namespace string) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "cronjobs.list")
+	defer func() { tracing.EndSpan(span, err) }()
+
 	c.data, err = c.getListByNamespace(ctx, namespace)
 	c.Log.V(1).WithValues("number of cron jobs", len(c.data), "namespace", namespace).Info("cron jobs in namespace")
 	return err
 }
 
+// getListByNamespace lists the cron jobs of the namespace page by page,
+// keeping only the ones handled by the SleepInfo, without their managed
+// fields, so that the cron jobs excluded and the managed fields of a large
+// namespace are not kept in memory for the whole operation.
 func (c cronjobs) getListByNamespace(ctx context.Context, namespace string) ([]unstructured.Unstructured, error) {
 	listOptions := &client.ListOptions{
 		Namespace: namespace,
-		Limit:     500,
+		Limit:     listPageSize,
 	}
 
 	excludeRef := c.ResourceClient.SleepInfo.GetExcludeRef()
+	if c.IsWakeUp {
+		excludeRef = nil
+	}
 	cronJobsToExclude := getCronJobNameToExclude(excludeRef)
 	cronJobLabelsToExclude := getCronJobLabelsToExclude(excludeRef)
 	fieldsSelector := []string{}
@@ -176,13 +254,100 @@ func (c cronjobs) getListByNamespace(ctx context.Context, namespace string) ([]u
 		return nil, err
 	}
 
-	cronjobs := unstructured.UnstructuredList{}
-	cronjobs.SetGroupVersionKind(restMapping.GroupVersionKind)
+	cronjobs := []unstructured.Unstructured{}
+	for {
+		page := unstructured.UnstructuredList{}
+		page.SetGroupVersionKind(restMapping.GroupVersionKind)
+		if err := c.Client.List(ctx, &page, listOptions); err != nil {
+			return cronjobs, client.IgnoreNotFound(err)
+		}
+		items, err := c.filter(page.Items, excludeRef)
+		if err != nil {
+			return nil, err
+		}
+		for _, cronJob := range items {
+			cronJob.SetManagedFields(nil)
+			cronjobs = append(cronjobs, cronJob)
+		}
+		if page.GetContinue() == "" {
+			return cronjobs, nil
+		}
+		listOptions.Continue = page.GetContinue()
+	}
+}
+
+// filter returns the listed cron jobs handled by the SleepInfo. On wake up,
+// all of them are returned, to restore the ones in the stored state.
+func (c cronjobs) filter(cronJobs []unstructured.Unstructured, excludeRef []kubegreenv1alpha1.ExcludeRef) ([]unstructured.Unstructured, error) {
+	if c.IsWakeUp {
+		return cronJobs, nil
+	}
+	return filterBySelector(filterByClass(filterExcluded(cronJobs, excludeRef), c.ResourceClient.SleepInfo), c.ResourceClient.SleepInfo.GetCronJobsSelector())
+}
 
-	if err := c.Client.List(ctx, &cronjobs, listOptions); err != nil {
-		return cronjobs.Items, client.IgnoreNotFound(err)
+// filterByClass returns the cron jobs managed by the sleep classes of the
+// SleepInfo.
+func filterByClass(cronJobs []unstructured.Unstructured, sleepInfo *kubegreenv1alpha1.SleepInfo) []unstructured.Unstructured {
+	filtered := []unstructured.Unstructured{}
+	for _, cronJob := range cronJobs {
+		if sleepInfo.IsManagedByClass(cronJob.GetLabels()) {
+			filtered = append(filtered, cronJob)
+		}
+	}
+	return filtered
+}
+
+// filterExcluded returns the cron jobs not excluded by the kind of their
+// owner, by name pattern or by field, which cannot be set in the list options.
+func filterExcluded(cronJobs []unstructured.Unstructured, excludeRef []kubegreenv1alpha1.ExcludeRef) []unstructured.Unstructured {
+	filtered := []unstructured.Unstructured{}
+	for _, cronJob := range cronJobs {
+		cronJob := cronJob
+		excluded := false
+		for _, exclusion := range excludeRef {
+			if resource.IsExcludedByOwner("CronJob", &cronJob, exclusion) ||
+				resource.IsExcludedByNamePattern("CronJob", &cronJob, exclusion) ||
+				resource.IsExcludedByField("CronJob", &cronJob, exclusion) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			filtered = append(filtered, cronJob)
+		}
+	}
+	return filtered
+}
+
+// filterBySelector returns the cron jobs matching the include selector, if set,
+// and not matching the exclude selector, if set.
+func filterBySelector(cronJobs []unstructured.Unstructured, selector kubegreenv1alpha1.CronJobsSelector) ([]unstructured.Unstructured, error) {
+	if selector.Include == nil && selector.Exclude == nil {
+		return cronJobs, nil
+	}
+	include := labels.Everything()
+	if selector.Include != nil {
+		var err error
+		if include, err = metav1.LabelSelectorAsSelector(selector.Include); err != nil {
+			return nil, fmt.Errorf("invalid cronJobsSelector include: %s", err)
+		}
+	}
+	exclude := labels.Nothing()
+	if selector.Exclude != nil {
+		var err error
+		if exclude, err = metav1.LabelSelectorAsSelector(selector.Exclude); err != nil {
+			return nil, fmt.Errorf("invalid cronJobsSelector exclude: %s", err)
+		}
+	}
+
+	filtered := []unstructured.Unstructured{}
+	for _, cronJob := range cronJobs {
+		cronJobLabels := labels.Set(cronJob.GetLabels())
+		if include.Matches(cronJobLabels) && !exclude.Matches(cronJobLabels) {
+			filtered = append(filtered, cronJob)
+		}
 	}
-	return cronjobs.Items, nil
+	return filtered, nil
 }
 
 func getCronJobNameToExclude(excludeRef []kubegreenv1alpha1.ExcludeRef) []string {
@@ -10,11 +10,14 @@ import (
 	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
 	"github.com/kube-green/kube-green/internal/testutil"
 
+	"github.com/go-logr/logr"
 	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -170,6 +173,68 @@ func TestCronJobs(t *testing.T) {
 					},
 				},
 			},
+			{
+				name: "include cronjobs by selector",
+				client: getFakeClient().
+					WithRuntimeObjects(&cronJob1, &cronJob2, &cronJobWithLabels).
+					Build(),
+				expected: []unstructured.Unstructured{cronJobWithLabels},
+				sleepInfo: &v1alpha1.SleepInfo{
+					Spec: v1alpha1.SleepInfoSpec{
+						CronJobsSelector: &v1alpha1.CronJobsSelector{
+							Include: &metav1.LabelSelector{
+								MatchLabels: map[string]string{
+									"app": "foo",
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				name: "exclude cronjobs by selector",
+				client: getFakeClient().
+					WithRuntimeObjects(&cronJob1, &cronJob2, &cronJobWithLabels).
+					Build(),
+				expected: []unstructured.Unstructured{cronJob1, cronJob2},
+				sleepInfo: &v1alpha1.SleepInfo{
+					Spec: v1alpha1.SleepInfoSpec{
+						CronJobsSelector: &v1alpha1.CronJobsSelector{
+							Exclude: &metav1.LabelSelector{
+								MatchExpressions: []metav1.LabelSelectorRequirement{
+									{
+										Key:      "app",
+										Operator: metav1.LabelSelectorOpExists,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				name: "exclude selector takes precedence over include selector",
+				client: getFakeClient().
+					WithRuntimeObjects(&cronJob1, &cronJob2, &cronJobWithLabels).
+					Build(),
+				expected: []unstructured.Unstructured{},
+				sleepInfo: &v1alpha1.SleepInfo{
+					Spec: v1alpha1.SleepInfoSpec{
+						CronJobsSelector: &v1alpha1.CronJobsSelector{
+							Include: &metav1.LabelSelector{
+								MatchLabels: map[string]string{
+									"app": "foo",
+								},
+							},
+							Exclude: &metav1.LabelSelector{
+								MatchLabels: map[string]string{
+									"app": "foo",
+								},
+							},
+						},
+					},
+				},
+			},
 		}
 
 		for _, test := range listCronJobsTests {
@@ -191,17 +256,42 @@ func TestCronJobs(t *testing.T) {
 				require.Equal(t, test.expected, cronjobs.data)
 			})
 		}
+
+		t.Run("list the cron jobs in pages", func(t *testing.T) {
+			objects := []runtime.Object{}
+			for i := 0; i < 2*listPageSize+1; i++ {
+				cronJob := GetMock(MockSpec{Name: fmt.Sprintf("cj-%04d", i), Namespace: namespace})
+				cronJob.SetManagedFields([]metav1.ManagedFieldsEntry{{
+					Manager:    "kubectl",
+					Operation:  metav1.ManagedFieldsOperationUpdate,
+					APIVersion: "batch/v1",
+					FieldsType: "FieldsV1",
+					FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:schedule":{}}}`)},
+				}})
+				objects = append(objects, &cronJob)
+			}
+			pagedClient := &pagedClient{Client: getFakeClient().WithRuntimeObjects(objects...).Build()}
+
+			c := getNewResource(t, pagedClient, nil)
+			require.Equal(t, 3, pagedClient.pages)
+			require.Len(t, c.data, 2*listPageSize+1)
+			require.Equal(t, "cj-0000", c.data[0].GetName())
+			require.Equal(t, "cj-1000", c.data[2*listPageSize].GetName())
+			require.Nil(t, c.data[0].GetManagedFields(), "the managed fields are not kept in memory")
+		})
 	})
 
 	t.Run("HasResources", func(t *testing.T) {
 		t.Run("without resource", func(t *testing.T) {
 			c := getNewResource(t, getFakeClient().Build(), nil)
 			require.False(t, c.HasResource())
+			require.Empty(t, c.GetResourceNames())
 		})
 
 		t.Run("with resource", func(t *testing.T) {
 			c := getNewResource(t, getFakeClient().WithRuntimeObjects(&cronJob1).Build(), nil)
 			require.True(t, c.HasResource())
+			require.Equal(t, []string{cronJob1.GetName()}, c.GetResourceNames())
 		})
 	})
 
@@ -239,7 +329,7 @@ func TestCronJobs(t *testing.T) {
 				},
 			}
 			c := getNewResource(t, fakeClient, nil)
-			require.EqualError(t, c.Sleep(context.Background()), "error during patch")
+			require.EqualError(t, c.Sleep(context.Background()), "fails to patch CronJob.batch cj1: error during patch")
 		})
 	})
 
@@ -295,7 +385,7 @@ func TestCronJobs(t *testing.T) {
 				cronJob1.GetName(): false,
 				cronJob2.GetName(): false,
 			})
-			require.EqualError(t, c.WakeUp(context.Background()), "error during patch")
+			require.EqualError(t, c.WakeUp(context.Background()), "fails to patch CronJob.batch cj1: error during patch")
 		})
 	})
 
@@ -324,6 +414,18 @@ func TestCronJobs(t *testing.T) {
 			require.NoError(t, err)
 			require.JSONEq(t, `[{"name":"cj-suspend-set-false","suspend":false},{"name":"cj1","suspend":false},{"name":"cj2","suspend":false}]`, string(res))
 		})
+
+		t.Run("keeps cron jobs suspended by a previous sleep", func(t *testing.T) {
+			fakeClient := getFakeClient().
+				WithRuntimeObjects(&suspendedCronJobs).
+				Build()
+			c := getNewResource(t, fakeClient, map[string]bool{
+				suspendedCronJobs.GetName(): false,
+			})
+			res, err := c.GetOriginalInfoToSave()
+			require.NoError(t, err)
+			require.JSONEq(t, `[{"name":"cj-suspended","suspend":false}]`, string(res))
+		})
 	})
 
 	t.Run("GetOriginalInfoToRestore", func(t *testing.T) {
@@ -358,11 +460,112 @@ func TestCronJobs(t *testing.T) {
 	})
 }
 
+func TestResuspend(t *testing.T) {
+	namespace := "my-namespace"
+	suspendFalse := false
+	sleepInfo := &v1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sleepinfo",
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.SleepInfoSpec{
+			SuspendCronjobs: true,
+		},
+	}
+	resumedCronJob := GetMock(MockSpec{
+		Name:      "cj-resumed",
+		Namespace: namespace,
+		Suspend:   &suspendFalse,
+	})
+	suspendedCronJob := convertCronJobToBeSuspended(t, GetMock(MockSpec{
+		Name:      "cj-suspended",
+		Namespace: namespace,
+	}))
+	notSleptCronJob := GetMock(MockSpec{
+		Name:      "cj-not-slept",
+		Namespace: namespace,
+	})
+	originalSuspendStatus := OriginalSuspendStatus{
+		resumedCronJob.GetName():   false,
+		suspendedCronJob.GetName(): false,
+	}
+
+	t.Run("suspends again the resumed cron jobs", func(t *testing.T) {
+		c := getFakeClient().WithRuntimeObjects(&resumedCronJob, &suspendedCronJob, &notSleptCronJob).Build()
+		recorder := record.NewFakeRecorder(10)
+
+		names, err := Resuspend(context.Background(), resource.ResourceClient{
+			Client:           c,
+			Log:              zap.New(zap.UseDevMode(true)),
+			SleepInfo:        sleepInfo,
+			FieldManagerName: "kube-green",
+			Recorder:         recorder,
+		}, namespace, originalSuspendStatus)
+		require.NoError(t, err)
+		require.Equal(t, []string{resumedCronJob.GetName()}, names)
+
+		for name, expected := range map[string]bool{
+			resumedCronJob.GetName():   true,
+			suspendedCronJob.GetName(): true,
+			notSleptCronJob.GetName():  false,
+		} {
+			cronJob := unstructured.Unstructured{}
+			cronJob.SetGroupVersionKind(schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "CronJob"})
+			require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, &cronJob))
+			suspended, _, err := getSuspendStatus(cronJob)
+			require.NoError(t, err)
+			require.Equal(t, expected, suspended, name)
+		}
+		require.Len(t, recorder.Events, 1)
+		require.Equal(t, "Normal Resuspended CronJob resumed while sleeping, suspended again by SleepInfo sleepinfo", <-recorder.Events)
+	})
+
+	t.Run("does nothing if cron jobs are not to suspend", func(t *testing.T) {
+		c := getFakeClient().WithRuntimeObjects(&resumedCronJob).Build()
+		names, err := Resuspend(context.Background(), resource.ResourceClient{
+			Client:    c,
+			Log:       zap.New(zap.UseDevMode(true)),
+			SleepInfo: &v1alpha1.SleepInfo{},
+		}, namespace, originalSuspendStatus)
+		require.NoError(t, err)
+		require.Empty(t, names)
+	})
+}
+
 func suspendAndUpdateResourceVersion(t *testing.T, cronJob unstructured.Unstructured) unstructured.Unstructured {
 	t.Helper()
 	return updateResourceVersion(t, convertCronJobToBeSuspended(t, cronJob))
 }
 
+func TestFilterExcluded(t *testing.T) {
+	ownedCronJob := GetMock(MockSpec{Name: "cj-owned", Namespace: "my-namespace"})
+	ownedCronJob.SetOwnerReferences([]metav1.OwnerReference{
+		{APIVersion: "example.com/v1", Kind: "Backup", Name: "nightly"},
+	})
+	cronJob := GetMock(MockSpec{Name: "cj", Namespace: "my-namespace"})
+
+	filtered := filterExcluded([]unstructured.Unstructured{ownedCronJob, cronJob}, []v1alpha1.ExcludeRef{
+		{OwnerKind: "Backup"},
+	})
+	require.Equal(t, []unstructured.Unstructured{cronJob}, filtered)
+
+	filtered = filterExcluded([]unstructured.Unstructured{ownedCronJob, cronJob}, []v1alpha1.ExcludeRef{
+		{Kind: "Deployment", OwnerKind: "Backup"},
+	})
+	require.Equal(t, []unstructured.Unstructured{ownedCronJob, cronJob}, filtered)
+
+	previewCronJob := GetMock(MockSpec{Name: "preview-42-cleanup", Namespace: "my-namespace"})
+	filtered = filterExcluded([]unstructured.Unstructured{previewCronJob, cronJob}, []v1alpha1.ExcludeRef{
+		{Kind: "CronJob", NamePattern: "preview-[0-9]+-.*"},
+	})
+	require.Equal(t, []unstructured.Unstructured{cronJob}, filtered)
+
+	filtered = filterExcluded([]unstructured.Unstructured{previewCronJob, cronJob}, []v1alpha1.ExcludeRef{
+		{Kind: "CronJob", NameGlob: "preview-*"},
+	})
+	require.Equal(t, []unstructured.Unstructured{cronJob}, filtered)
+}
+
 func convertCronJobToBeSuspended(t *testing.T, cronJob unstructured.Unstructured) unstructured.Unstructured {
 	t.Helper()
 	suspendTrue := true
@@ -402,3 +605,89 @@ func getFakeClient() *fake.ClientBuilder {
 		NewClientBuilder().
 		WithRESTMapper(restMapper)
 }
+
+// pagedClient splits the lists of the fake client, which returns all the
+// objects at once, in pages of the limit of the list options, counting the
+// pages listed.
+type pagedClient struct {
+	client.Client
+	pages int
+}
+
+func (c *pagedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	limit, continueToken := listOpts.Limit, listOpts.Continue
+	listOpts.Limit, listOpts.Continue = 0, ""
+	if err := c.Client.List(ctx, list, &listOpts); err != nil {
+		return err
+	}
+	c.pages++
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	start := 0
+	if continueToken != "" {
+		if start, err = strconv.Atoi(continueToken); err != nil {
+			return err
+		}
+	}
+	end := len(items)
+	if limit > 0 && start+int(limit) < end {
+		end = start + int(limit)
+		list.SetContinue(strconv.Itoa(end))
+	}
+	return meta.SetList(list, items[start:end])
+}
+
+func getCronJobsClient(b *testing.B, namespace string, count int) client.Client {
+	b.Helper()
+	objects := []runtime.Object{}
+	for i := 0; i < count; i++ {
+		cronJob := GetMock(MockSpec{Name: fmt.Sprintf("cj-%05d", i), Namespace: namespace})
+		objects = append(objects, &cronJob)
+	}
+	return &pagedClient{Client: getFakeClient().WithRuntimeObjects(objects...).Build()}
+}
+
+func BenchmarkNewResource(b *testing.B) {
+	namespace := "my-namespace"
+	res := resource.ResourceClient{
+		Client:    getCronJobsClient(b, namespace, 2000),
+		Log:       logr.Discard(),
+		SleepInfo: &v1alpha1.SleepInfo{Spec: v1alpha1.SleepInfoSpec{SuspendCronjobs: true}},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewResource(context.Background(), res, namespace, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSleep(b *testing.B) {
+	namespace := "my-namespace"
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		res := resource.ResourceClient{
+			Client:           getCronJobsClient(b, namespace, 2000),
+			Log:              logr.Discard(),
+			SleepInfo:        &v1alpha1.SleepInfo{Spec: v1alpha1.SleepInfoSpec{SuspendCronjobs: true}},
+			FieldManagerName: "kube-green",
+		}
+		c, err := NewResource(context.Background(), res, namespace, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+		if err := c.Sleep(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
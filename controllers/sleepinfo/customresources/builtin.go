@@ -0,0 +1,204 @@
+package customresources
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// DefaultRegistry is the registry used by the controller. Handlers of other
+// custom resources can be added to it with DefaultRegistry.Register.
+var DefaultRegistry = NewDefaultRegistry()
+
+// NewDefaultRegistry returns a registry with the built-in handlers of the
+// custom resources of common operators:
+//   - Strimzi: KafkaConnect, KafkaMirrorMaker2 and KafkaBridge are scaled to 0
+//     replicas. Kafka clusters cannot be scaled to 0 by the operator.
+//   - Elastic ECK: Kibana, ApmServer and EnterpriseSearch are scaled to 0.
+//     Elasticsearch clusters cannot be scaled to 0 by the operator.
+//   - Percona: XtraDB Cluster, Server for MongoDB, Server for MySQL and
+//     PostgreSQL clusters are paused.
+func NewDefaultRegistry() *Registry {
+	r := NewRegistry()
+
+	replicasHandler := NewFieldHandler(int64(0), "spec", "replicas")
+	r.Register(schema.GroupVersionKind{Group: "kafka.strimzi.io", Version: "v1beta2", Kind: "KafkaConnect"}, replicasHandler)
+	r.Register(schema.GroupVersionKind{Group: "kafka.strimzi.io", Version: "v1beta2", Kind: "KafkaMirrorMaker2"}, replicasHandler)
+	r.Register(schema.GroupVersionKind{Group: "kafka.strimzi.io", Version: "v1beta2", Kind: "KafkaBridge"}, replicasHandler)
+
+	countHandler := NewFieldHandler(int64(0), "spec", "count")
+	r.Register(schema.GroupVersionKind{Group: "kibana.k8s.elastic.co", Version: "v1", Kind: "Kibana"}, countHandler)
+	r.Register(schema.GroupVersionKind{Group: "apm.k8s.elastic.co", Version: "v1", Kind: "ApmServer"}, countHandler)
+	r.Register(schema.GroupVersionKind{Group: "enterprisesearch.k8s.elastic.co", Version: "v1", Kind: "EnterpriseSearch"}, countHandler)
+
+	pauseHandler := NewFieldHandler(true, "spec", "pause")
+	r.Register(schema.GroupVersionKind{Group: "pxc.percona.com", Version: "v1", Kind: "PerconaXtraDBCluster"}, pauseHandler)
+	r.Register(schema.GroupVersionKind{Group: "psmdb.percona.com", Version: "v1", Kind: "PerconaServerMongoDB"}, pauseHandler)
+	r.Register(schema.GroupVersionKind{Group: "ps.percona.com", Version: "v1alpha1", Kind: "PerconaServerMySQL"}, pauseHandler)
+	r.Register(schema.GroupVersionKind{Group: "pgv2.percona.com", Version: "v2", Kind: "PerconaPGCluster"}, pauseHandler)
+
+	return r
+}
+
+// RegisterRefreshHandlers adds to the registry the optional handlers which
+// stop the periodic refresh of the resources of the sleeping namespaces,
+// reducing the API traffic towards the external providers during the night:
+//   - External Secrets Operator: the refresh of the ExternalSecrets is
+//     disabled, setting their refreshInterval to 0.
+//   - cert-manager: the renewal of the Certificates is postponed until just
+//     before their expiry, since cert-manager has no way to pause it.
+func RegisterRefreshHandlers(r *Registry) {
+	refreshIntervalHandler := NewFieldHandler("0", "spec", "refreshInterval")
+	r.Register(schema.GroupVersionKind{Group: "external-secrets.io", Version: "v1beta1", Kind: "ExternalSecret"}, refreshIntervalHandler)
+	r.Register(schema.GroupVersionKind{Group: "external-secrets.io", Version: "v1", Kind: "ExternalSecret"}, refreshIntervalHandler)
+
+	r.Register(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}, certificateHandler{})
+}
+
+// fieldHandler puts to sleep the custom resources setting a field to a value,
+// e.g. the replicas to 0 or the pause flag to true.
+type fieldHandler struct {
+	sleepValue interface{}
+	fields     []string
+}
+
+// NewFieldHandler returns a handler which, on sleep, sets the field at the
+// given path to sleepValue and, on wake up, restores its original value.
+func NewFieldHandler(sleepValue interface{}, fields ...string) Handler {
+	return fieldHandler{
+		sleepValue: sleepValue,
+		fields:     fields,
+	}
+}
+
+type originalField struct {
+	Found bool        `json:"found"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+func (h fieldHandler) Sleep(obj *unstructured.Unstructured) (json.RawMessage, error) {
+	value, found, err := unstructured.NestedFieldNoCopy(obj.Object, h.fields...)
+	if err != nil {
+		return nil, err
+	}
+	if found && reflect.DeepEqual(value, h.sleepValue) {
+		return nil, nil
+	}
+	original, err := json.Marshal(originalField{Found: found, Value: value})
+	if err != nil {
+		return nil, err
+	}
+	if err := unstructured.SetNestedField(obj.Object, h.sleepValue, h.fields...); err != nil {
+		return nil, err
+	}
+	return original, nil
+}
+
+func (h fieldHandler) WakeUp(obj *unstructured.Unstructured, original json.RawMessage) error {
+	value, found, err := unstructured.NestedFieldNoCopy(obj.Object, h.fields...)
+	if err != nil {
+		return err
+	}
+	if !found || !reflect.DeepEqual(value, h.sleepValue) {
+		// the field has been changed since the sleep, it is not restored.
+		return nil
+	}
+
+	originalValue := originalField{}
+	if err := json.Unmarshal(original, &originalValue); err != nil {
+		return fmt.Errorf("invalid original value of %s: %s", strings.Join(h.fields, "."), err)
+	}
+	if !originalValue.Found {
+		unstructured.RemoveNestedField(obj.Object, h.fields...)
+		return nil
+	}
+	return unstructured.SetNestedField(obj.Object, toJSONValue(originalValue.Value), h.fields...)
+}
+
+// certificateRenewBefore is the minimum renewBefore accepted by cert-manager.
+const certificateRenewBefore = "5m"
+
+// certificateHandler postpones the renewal of the cert-manager Certificates
+// setting their renewBefore to the minimum accepted by cert-manager, so that
+// a Certificate is renewed during the sleep only if it is about to expire.
+// The renewBeforePercentage, which cannot be set together with renewBefore,
+// is removed during the sleep.
+type certificateHandler struct{}
+
+type originalCertificate struct {
+	RenewBefore           originalField `json:"renewBefore"`
+	RenewBeforePercentage originalField `json:"renewBeforePercentage"`
+}
+
+func (h certificateHandler) Sleep(obj *unstructured.Unstructured) (json.RawMessage, error) {
+	renewBefore, renewBeforeFound, err := unstructured.NestedFieldNoCopy(obj.Object, "spec", "renewBefore")
+	if err != nil {
+		return nil, err
+	}
+	percentage, percentageFound, err := unstructured.NestedFieldNoCopy(obj.Object, "spec", "renewBeforePercentage")
+	if err != nil {
+		return nil, err
+	}
+	if renewBeforeFound && renewBefore == certificateRenewBefore && !percentageFound {
+		return nil, nil
+	}
+	original, err := json.Marshal(originalCertificate{
+		RenewBefore:           originalField{Found: renewBeforeFound, Value: renewBefore},
+		RenewBeforePercentage: originalField{Found: percentageFound, Value: percentage},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := unstructured.SetNestedField(obj.Object, certificateRenewBefore, "spec", "renewBefore"); err != nil {
+		return nil, err
+	}
+	unstructured.RemoveNestedField(obj.Object, "spec", "renewBeforePercentage")
+	return original, nil
+}
+
+func (h certificateHandler) WakeUp(obj *unstructured.Unstructured, original json.RawMessage) error {
+	renewBefore, found, err := unstructured.NestedFieldNoCopy(obj.Object, "spec", "renewBefore")
+	if err != nil {
+		return err
+	}
+	_, percentageFound, err := unstructured.NestedFieldNoCopy(obj.Object, "spec", "renewBeforePercentage")
+	if err != nil {
+		return err
+	}
+	if !found || renewBefore != certificateRenewBefore || percentageFound {
+		// the renewal has been changed since the sleep, it is not restored.
+		return nil
+	}
+
+	originalValue := originalCertificate{}
+	if err := json.Unmarshal(original, &originalValue); err != nil {
+		return fmt.Errorf("invalid original value of the certificate renewal: %s", err)
+	}
+	for field, value := range map[string]originalField{
+		"renewBefore":           originalValue.RenewBefore,
+		"renewBeforePercentage": originalValue.RenewBeforePercentage,
+	} {
+		if !value.Found {
+			unstructured.RemoveNestedField(obj.Object, "spec", field)
+			continue
+		}
+		if err := unstructured.SetNestedField(obj.Object, toJSONValue(value.Value), "spec", field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toJSONValue converts the integer numbers decoded as float64 to int64, as
+// they are in the objects read from the API server.
+func toJSONValue(value interface{}) interface{} {
+	if f, ok := value.(float64); ok && f == math.Trunc(f) {
+		return int64(f)
+	}
+	return value
+}
@@ -0,0 +1,158 @@
+package customresources
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestDefaultRegistry(t *testing.T) {
+	r := NewDefaultRegistry()
+
+	for _, gvk := range []schema.GroupVersionKind{
+		{Group: "kafka.strimzi.io", Version: "v1beta2", Kind: "KafkaConnect"},
+		{Group: "kafka.strimzi.io", Version: "v1beta2", Kind: "KafkaMirrorMaker2"},
+		{Group: "kafka.strimzi.io", Version: "v1beta2", Kind: "KafkaBridge"},
+		{Group: "kibana.k8s.elastic.co", Version: "v1", Kind: "Kibana"},
+		{Group: "apm.k8s.elastic.co", Version: "v1", Kind: "ApmServer"},
+		{Group: "enterprisesearch.k8s.elastic.co", Version: "v1", Kind: "EnterpriseSearch"},
+		{Group: "pxc.percona.com", Version: "v1", Kind: "PerconaXtraDBCluster"},
+		{Group: "psmdb.percona.com", Version: "v1", Kind: "PerconaServerMongoDB"},
+		{Group: "ps.percona.com", Version: "v1alpha1", Kind: "PerconaServerMySQL"},
+		{Group: "pgv2.percona.com", Version: "v2", Kind: "PerconaPGCluster"},
+	} {
+		_, ok := r.Handler(gvk)
+		require.True(t, ok, "handler of %s not found", gvk)
+	}
+	require.Len(t, r.GroupVersionKinds(), 10)
+}
+
+func TestRegisterRefreshHandlers(t *testing.T) {
+	r := NewDefaultRegistry()
+	RegisterRefreshHandlers(r)
+	require.Len(t, r.GroupVersionKinds(), 13)
+
+	for _, version := range []string{"v1beta1", "v1"} {
+		t.Run(fmt.Sprintf("ExternalSecret %s", version), func(t *testing.T) {
+			h, ok := r.Handler(schema.GroupVersionKind{Group: "external-secrets.io", Version: version, Kind: "ExternalSecret"})
+			require.True(t, ok)
+
+			obj := GetMock(MockSpec{Name: "my-secret", Spec: map[string]interface{}{"refreshInterval": "1h"}})
+			original, err := h.Sleep(&obj)
+			require.NoError(t, err)
+			require.JSONEq(t, `{"found":true,"value":"1h"}`, string(original))
+			require.Equal(t, "0", obj.Object["spec"].(map[string]interface{})["refreshInterval"])
+
+			require.NoError(t, h.WakeUp(&obj, original))
+			require.Equal(t, "1h", obj.Object["spec"].(map[string]interface{})["refreshInterval"])
+		})
+	}
+
+	t.Run("Certificate", func(t *testing.T) {
+		h, ok := r.Handler(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"})
+		require.True(t, ok)
+
+		obj := GetMock(MockSpec{Name: "my-certificate", Spec: map[string]interface{}{"secretName": "tls", "renewBefore": "720h"}})
+		original, err := h.Sleep(&obj)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"renewBefore":{"found":true,"value":"720h"},"renewBeforePercentage":{"found":false}}`, string(original))
+		require.Equal(t, map[string]interface{}{"secretName": "tls", "renewBefore": "5m"}, obj.Object["spec"])
+
+		sleepOriginal, err := h.Sleep(&obj)
+		require.NoError(t, err)
+		require.Nil(t, sleepOriginal, "already sleeping")
+
+		require.NoError(t, h.WakeUp(&obj, original))
+		require.Equal(t, map[string]interface{}{"secretName": "tls", "renewBefore": "720h"}, obj.Object["spec"])
+	})
+
+	t.Run("Certificate with renewBeforePercentage", func(t *testing.T) {
+		h, _ := r.Handler(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"})
+
+		obj := GetMock(MockSpec{Name: "my-certificate", Spec: map[string]interface{}{"renewBeforePercentage": int64(20)}})
+		original, err := h.Sleep(&obj)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"renewBefore": "5m"}, obj.Object["spec"])
+
+		require.NoError(t, h.WakeUp(&obj, original))
+		require.Equal(t, map[string]interface{}{"renewBeforePercentage": int64(20)}, obj.Object["spec"])
+	})
+
+	t.Run("Certificate changed during the sleep", func(t *testing.T) {
+		h, _ := r.Handler(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"})
+
+		obj := GetMock(MockSpec{Name: "my-certificate", Spec: map[string]interface{}{}})
+		original, err := h.Sleep(&obj)
+		require.NoError(t, err)
+		require.NoError(t, unstructured.SetNestedField(obj.Object, "48h", "spec", "renewBefore"))
+
+		require.NoError(t, h.WakeUp(&obj, original))
+		require.Equal(t, map[string]interface{}{"renewBefore": "48h"}, obj.Object["spec"])
+	})
+}
+
+func TestFieldHandler(t *testing.T) {
+	getObj := func(spec map[string]interface{}) *unstructured.Unstructured {
+		obj := GetMock(MockSpec{Name: "my-cr", Spec: spec})
+		return &obj
+	}
+
+	t.Run("replicas handler", func(t *testing.T) {
+		h := NewFieldHandler(int64(0), "spec", "replicas")
+
+		obj := getObj(map[string]interface{}{"replicas": int64(3)})
+		original, err := h.Sleep(obj)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"found":true,"value":3}`, string(original))
+		require.Equal(t, map[string]interface{}{"replicas": int64(0)}, obj.Object["spec"])
+
+		original, err = h.Sleep(obj)
+		require.NoError(t, err)
+		require.Nil(t, original, "already sleeping")
+
+		require.NoError(t, h.WakeUp(obj, json.RawMessage(`{"found":true,"value":3}`)))
+		require.Equal(t, map[string]interface{}{"replicas": int64(3)}, obj.Object["spec"])
+	})
+
+	t.Run("pause handler with field not set", func(t *testing.T) {
+		h := NewFieldHandler(true, "spec", "pause")
+
+		obj := getObj(map[string]interface{}{"size": int64(3)})
+		original, err := h.Sleep(obj)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"found":false}`, string(original))
+		require.Equal(t, map[string]interface{}{"size": int64(3), "pause": true}, obj.Object["spec"])
+
+		require.NoError(t, h.WakeUp(obj, original))
+		require.Equal(t, map[string]interface{}{"size": int64(3)}, obj.Object["spec"])
+	})
+
+	t.Run("not restores the field changed after sleep", func(t *testing.T) {
+		h := NewFieldHandler(int64(0), "spec", "count")
+
+		obj := getObj(map[string]interface{}{"count": int64(2)})
+		require.NoError(t, h.WakeUp(obj, json.RawMessage(`{"found":true,"value":1}`)))
+		require.Equal(t, map[string]interface{}{"count": int64(2)}, obj.Object["spec"])
+	})
+
+	t.Run("throws if original is not a correct json", func(t *testing.T) {
+		h := NewFieldHandler(int64(0), "spec", "count")
+
+		obj := getObj(map[string]interface{}{"count": int64(0)})
+		err := h.WakeUp(obj, json.RawMessage(`[]`))
+		require.EqualError(t, err, "invalid original value of spec.count: json: cannot unmarshal array into Go value of type customresources.originalField")
+	})
+
+	t.Run("throws if field is not a map", func(t *testing.T) {
+		h := NewFieldHandler(int64(0), "spec", "count")
+
+		obj := getObj(nil)
+		obj.Object["spec"] = "invalid"
+		_, err := h.Sleep(obj)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,232 @@
+package customresources
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	ErrFetchingCustomResources = errors.New("error fetching custom resources")
+)
+
+// OriginalInfo contains the original values of the custom resources, keyed by
+// kind and name (see getKey).
+type OriginalInfo map[string]json.RawMessage
+
+type customResources struct {
+	resource.ResourceClient
+	registry     *Registry
+	data         []unstructured.Unstructured
+	OriginalInfo OriginalInfo
+	areToSuspend bool
+}
+
+func NewResource(ctx context.Context, res resource.ResourceClient, namespace string, registry *Registry, originalInfo map[string]json.RawMessage) (resource.Resource, error) {
+	c := customResources{
+		ResourceClient: res,
+		registry:       registry,
+		OriginalInfo:   originalInfo,
+		areToSuspend:   (res.SleepInfo.IsCustomResourcesToSuspend() || (res.IsWakeUp && len(originalInfo) > 0)) && registry != nil,
+		data:           []unstructured.Unstructured{},
+	}
+	if !c.areToSuspend {
+		return c, nil
+	}
+	if err := c.fetch(ctx, namespace); err != nil {
+		return customResources{}, fmt.Errorf("%w: %s", ErrFetchingCustomResources, err)
+	}
+
+	return c, nil
+}
+
+// getKey returns the key identifying the custom resource in the namespace,
+// e.g. KafkaConnect.kafka.strimzi.io/my-connect.
+func getKey(obj unstructured.Unstructured) string {
+	return fmt.Sprintf("%s/%s", obj.GroupVersionKind().GroupKind(), obj.GetName())
+}
+
+func (c customResources) HasResource() bool {
+	return len(c.data) > 0
+}
+
+func (c customResources) GetResourceNames() []string {
+	names := []string{}
+	for _, obj := range c.data {
+		names = append(names, getKey(obj))
+	}
+	return names
+}
+
+func (c customResources) getHandler(obj unstructured.Unstructured) (Handler, error) {
+	handler, ok := c.registry.Handler(obj.GroupVersionKind())
+	if !ok {
+		return nil, fmt.Errorf("handler not found for %s", obj.GroupVersionKind())
+	}
+	return handler, nil
+}
+
+func (c customResources) Sleep(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "customresources.sleep", trace.WithAttributes(attribute.Int("resources.count", len(c.data))))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	for _, obj := range c.data {
+		obj := obj
+		if c.IsKeptAwake(&obj) {
+			continue
+		}
+
+		handler, err := c.getHandler(obj)
+		if err != nil {
+			return err
+		}
+		newObj := obj.DeepCopy()
+		original, err := handler.Sleep(newObj)
+		if err != nil {
+			return fmt.Errorf("fails to put to sleep %s: %s", getKey(obj), err)
+		}
+		if original == nil {
+			continue
+		}
+
+		if err := c.Patch(ctx, &obj, newObj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c customResources) WakeUp(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "customresources.wakeUp", trace.WithAttributes(attribute.Int("resources.count", len(c.data))))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	for _, obj := range c.data {
+		obj := obj
+
+		crLogger := c.Log.WithValues("customResource", getKey(obj), "namespace", obj.GetNamespace())
+		original, ok := c.OriginalInfo[getKey(obj)]
+		if !ok {
+			crLogger.Info("original custom resource info not correctly set")
+			continue
+		}
+
+		handler, err := c.getHandler(obj)
+		if err != nil {
+			return err
+		}
+		newObj := obj.DeepCopy()
+		if err := handler.WakeUp(newObj, original); err != nil {
+			return fmt.Errorf("fails to wake up %s: %s", getKey(obj), err)
+		}
+		if equality.Semantic.DeepEqual(obj.Object, newObj.Object) {
+			crLogger.Info("custom resource is not sleeping during wake up")
+			continue
+		}
+
+		if err := c.Patch(ctx, &obj, newObj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type OriginalCustomResourceInfo struct {
+	Key      string          `json:"key"`
+	Original json.RawMessage `json:"original"`
+}
+
+func (c customResources) GetOriginalInfoToSave() ([]byte, error) {
+	if !c.areToSuspend {
+		return nil, nil
+	}
+	originalCustomResourcesInfo := []OriginalCustomResourceInfo{}
+	for _, obj := range c.data {
+		handler, err := c.getHandler(obj)
+		if err != nil {
+			return nil, err
+		}
+		original, err := handler.Sleep(obj.DeepCopy())
+		if err != nil {
+			return nil, err
+		}
+		if original == nil {
+			// a custom resource put to sleep by a previous sleep is still to wake up.
+			savedOriginal, ok := c.OriginalInfo[getKey(obj)]
+			if !ok {
+				continue
+			}
+			original = savedOriginal
+		}
+		originalCustomResourcesInfo = append(originalCustomResourcesInfo, OriginalCustomResourceInfo{
+			Key:      getKey(obj),
+			Original: original,
+		})
+	}
+	return json.Marshal(originalCustomResourcesInfo)
+}
+
+func (c *customResources) fetch(ctx context.Context, namespace string) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "customresources.list")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	c.data = []unstructured.Unstructured{}
+	for _, gvk := range c.registry.ServedGroupVersionKinds() {
+		if _, err := c.Client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			if meta.IsNoMatchError(err) {
+				// the CRD is not installed in the cluster.
+				continue
+			}
+			return err
+		}
+
+		list := unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := c.Client.List(ctx, &list, &client.ListOptions{
+			Namespace: namespace,
+			Limit:     500,
+		}); err != nil {
+			if client.IgnoreNotFound(err) == nil {
+				continue
+			}
+			return err
+		}
+		for _, obj := range list.Items {
+			obj := obj
+			obj.SetGroupVersionKind(gvk)
+			if !c.IsWakeUp && (resource.IsExcluded(gvk.Kind, &obj, c.SleepInfo.GetExcludeRef()) || !c.SleepInfo.IsManagedByClass(obj.GetLabels())) {
+				continue
+			}
+			c.data = append(c.data, obj)
+		}
+	}
+	c.Log.V(1).WithValues("number of custom resources", len(c.data), "namespace", namespace).Info("custom resources in namespace")
+	return nil
+}
+
+func GetOriginalInfoToRestore(savedData []byte) (OriginalInfo, error) {
+	if savedData == nil {
+		return OriginalInfo{}, nil
+	}
+	originalCustomResourcesInfo := []OriginalCustomResourceInfo{}
+	if err := json.Unmarshal(savedData, &originalCustomResourcesInfo); err != nil {
+		return nil, err
+	}
+	originalInfo := OriginalInfo{}
+	for _, info := range originalCustomResourcesInfo {
+		if info.Key != "" {
+			originalInfo[info.Key] = info.Original
+		}
+	}
+	return originalInfo, nil
+}
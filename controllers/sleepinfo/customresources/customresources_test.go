@@ -0,0 +1,308 @@
+package customresources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/internal/testutil"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var (
+	kafkaConnectGVK = schema.GroupVersionKind{Group: "kafka.strimzi.io", Version: "v1beta2", Kind: "KafkaConnect"}
+	kibanaGVK       = schema.GroupVersionKind{Group: "kibana.k8s.elastic.co", Version: "v1", Kind: "Kibana"}
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	require.Empty(t, r.GroupVersionKinds())
+
+	_, ok := r.Handler(kibanaGVK)
+	require.False(t, ok)
+
+	r.Register(kibanaGVK, NewFieldHandler(int64(0), "spec", "count"))
+	r.Register(kafkaConnectGVK, NewFieldHandler(int64(0), "spec", "replicas"))
+	_, ok = r.Handler(kibanaGVK)
+	require.True(t, ok)
+	require.Equal(t, []schema.GroupVersionKind{kafkaConnectGVK, kibanaGVK}, r.GroupVersionKinds())
+	require.Equal(t, []schema.GroupVersionKind{kafkaConnectGVK, kibanaGVK}, r.ServedGroupVersionKinds())
+
+	r.SetServed(map[schema.GroupVersionKind]bool{kibanaGVK: true})
+	require.Equal(t, []schema.GroupVersionKind{kibanaGVK}, r.ServedGroupVersionKinds())
+	require.Equal(t, []schema.GroupVersionKind{kafkaConnectGVK, kibanaGVK}, r.GroupVersionKinds())
+}
+
+func TestCustomResources(t *testing.T) {
+	testLogger := zap.New(zap.UseDevMode(true))
+
+	namespace := "my-namespace"
+	kafkaConnect := GetMock(MockSpec{
+		GroupVersionKind: kafkaConnectGVK,
+		Name:             "my-connect",
+		Namespace:        namespace,
+		Spec:             map[string]interface{}{"replicas": int64(3)},
+	})
+	kibana := GetMock(MockSpec{
+		GroupVersionKind: kibanaGVK,
+		Name:             "my-kibana",
+		Namespace:        namespace,
+		Spec:             map[string]interface{}{"count": int64(1)},
+	})
+	kibanaWithLabels := GetMock(MockSpec{
+		GroupVersionKind: kibanaGVK,
+		Name:             "kibana-with-labels",
+		Namespace:        namespace,
+		Labels:           map[string]string{"app": "foo"},
+		Spec:             map[string]interface{}{"count": int64(1)},
+	})
+	kibanaOtherNamespace := GetMock(MockSpec{
+		GroupVersionKind: kibanaGVK,
+		Name:             "my-kibana",
+		Namespace:        "other-namespace",
+		Spec:             map[string]interface{}{"count": int64(1)},
+	})
+	sleepingKafkaConnect := GetMock(MockSpec{
+		GroupVersionKind: kafkaConnectGVK,
+		Name:             "my-connect",
+		Namespace:        namespace,
+		Spec:             map[string]interface{}{"replicas": int64(0)},
+	})
+	sleepingKibana := GetMock(MockSpec{
+		GroupVersionKind: kibanaGVK,
+		Name:             "my-kibana",
+		Namespace:        namespace,
+		Spec:             map[string]interface{}{"count": int64(0)},
+	})
+	sleepInfo := &v1alpha1.SleepInfo{
+		Spec: v1alpha1.SleepInfoSpec{
+			SuspendCustomResources: true,
+		},
+	}
+
+	getNewResource := func(t *testing.T, client client.Client, originalInfo OriginalInfo) customResources {
+		t.Helper()
+		res, err := NewResource(context.Background(), resource.ResourceClient{
+			Client:    client,
+			Log:       testLogger,
+			SleepInfo: sleepInfo,
+		}, namespace, NewDefaultRegistry(), originalInfo)
+		require.NoError(t, err)
+
+		c, ok := res.(customResources)
+		require.True(t, ok)
+		return c
+	}
+
+	t.Run("NewResource", func(t *testing.T) {
+		tests := []struct {
+			name          string
+			client        client.Client
+			sleepInfo     *v1alpha1.SleepInfo
+			expectedNames []string
+			throws        bool
+		}{
+			{
+				name:          "without custom resources",
+				client:        getFakeClient().Build(),
+				sleepInfo:     sleepInfo,
+				expectedNames: []string{},
+			},
+			{
+				name:          "with custom resources",
+				client:        getFakeClient().WithRuntimeObjects(&kafkaConnect, &kibana, &kibanaOtherNamespace).Build(),
+				sleepInfo:     sleepInfo,
+				expectedNames: []string{"KafkaConnect.kafka.strimzi.io/my-connect", "Kibana.kibana.k8s.elastic.co/my-kibana"},
+			},
+			{
+				name:          "not fetched if not to suspend",
+				client:        getFakeClient().WithRuntimeObjects(&kafkaConnect, &kibana).Build(),
+				sleepInfo:     &v1alpha1.SleepInfo{},
+				expectedNames: []string{},
+			},
+			{
+				name:   "with excluded custom resources",
+				client: getFakeClient().WithRuntimeObjects(&kafkaConnect, &kibana, &kibanaWithLabels).Build(),
+				sleepInfo: &v1alpha1.SleepInfo{
+					Spec: v1alpha1.SleepInfoSpec{
+						SuspendCustomResources: true,
+						ExcludeRef: []v1alpha1.ExcludeRef{
+							{Kind: "KafkaConnect", Name: "my-connect"},
+							{MatchLabels: map[string]string{"app": "foo"}},
+						},
+					},
+				},
+				expectedNames: []string{"Kibana.kibana.k8s.elastic.co/my-kibana"},
+			},
+			{
+				name: "throws if list fails",
+				client: testutil.PossiblyErroringFakeCtrlRuntimeClient{
+					Client: getFakeClient().WithRuntimeObjects(&kibana).Build(),
+					ShouldError: func(method testutil.Method, obj runtime.Object) bool {
+						return method == testutil.List
+					},
+				},
+				sleepInfo:     sleepInfo,
+				expectedNames: []string{},
+				throws:        true,
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				r := resource.ResourceClient{
+					Client:    test.client,
+					Log:       testLogger,
+					SleepInfo: test.sleepInfo,
+				}
+
+				res, err := NewResource(context.Background(), r, namespace, NewDefaultRegistry(), OriginalInfo{})
+				if test.throws {
+					require.EqualError(t, err, fmt.Sprintf("%s: error during list", ErrFetchingCustomResources))
+					return
+				}
+				require.NoError(t, err)
+				require.Equal(t, test.expectedNames, res.GetResourceNames())
+				require.Equal(t, len(test.expectedNames) > 0, res.HasResource())
+			})
+		}
+	})
+
+	t.Run("Sleep", func(t *testing.T) {
+		t.Run("not throws if no data", func(t *testing.T) {
+			c := getNewResource(t, getFakeClient().Build(), nil)
+			require.NoError(t, c.Sleep(context.Background()))
+		})
+
+		t.Run("puts the custom resources to sleep", func(t *testing.T) {
+			fakeClient := getFakeClient().WithRuntimeObjects(&kafkaConnect, &kibana).Build()
+			c := getNewResource(t, fakeClient, nil)
+			require.NoError(t, c.Sleep(context.Background()))
+
+			require.Equal(t, map[string]interface{}{"replicas": int64(0)}, getSpec(t, fakeClient, kafkaConnect))
+			require.Equal(t, map[string]interface{}{"count": int64(0)}, getSpec(t, fakeClient, kibana))
+		})
+
+		t.Run("fails to patch custom resources", func(t *testing.T) {
+			fakeClient := testutil.PossiblyErroringFakeCtrlRuntimeClient{
+				Client: getFakeClient().WithRuntimeObjects(&kibana).Build(),
+				ShouldError: func(method testutil.Method, obj runtime.Object) bool {
+					return method == testutil.Patch
+				},
+			}
+			c := getNewResource(t, fakeClient, nil)
+			require.EqualError(t, c.Sleep(context.Background()), "fails to patch Kibana.kibana.k8s.elastic.co my-kibana: error during patch")
+		})
+	})
+
+	t.Run("WakeUp", func(t *testing.T) {
+		t.Run("not throws if no data", func(t *testing.T) {
+			c := getNewResource(t, getFakeClient().Build(), nil)
+			require.NoError(t, c.WakeUp(context.Background()))
+		})
+
+		t.Run("restores the original values", func(t *testing.T) {
+			fakeClient := getFakeClient().WithRuntimeObjects(&sleepingKafkaConnect, &sleepingKibana).Build()
+			c := getNewResource(t, fakeClient, OriginalInfo{
+				"KafkaConnect.kafka.strimzi.io/my-connect": json.RawMessage(`{"found":true,"value":3}`),
+			})
+			require.NoError(t, c.WakeUp(context.Background()))
+
+			require.Equal(t, map[string]interface{}{"replicas": int64(3)}, getSpec(t, fakeClient, kafkaConnect))
+			require.Equal(t, map[string]interface{}{"count": int64(0)}, getSpec(t, fakeClient, kibana), "not saved, not restored")
+		})
+
+		t.Run("fails to wake up", func(t *testing.T) {
+			fakeClient := testutil.PossiblyErroringFakeCtrlRuntimeClient{
+				Client: getFakeClient().WithRuntimeObjects(&sleepingKibana).Build(),
+				ShouldError: func(method testutil.Method, obj runtime.Object) bool {
+					return method == testutil.Patch
+				},
+			}
+			c := getNewResource(t, fakeClient, OriginalInfo{
+				"Kibana.kibana.k8s.elastic.co/my-kibana": json.RawMessage(`{"found":true,"value":1}`),
+			})
+			require.EqualError(t, c.WakeUp(context.Background()), "fails to patch Kibana.kibana.k8s.elastic.co my-kibana: error during patch")
+		})
+	})
+
+	t.Run("GetOriginalInfoToSave", func(t *testing.T) {
+		t.Run("returns nil if not to suspend", func(t *testing.T) {
+			c := getNewResource(t, getFakeClient().Build(), nil)
+			c.areToSuspend = false
+			res, err := c.GetOriginalInfoToSave()
+			require.NoError(t, err)
+			require.Nil(t, res)
+		})
+
+		t.Run("with custom resources", func(t *testing.T) {
+			c := getNewResource(t, getFakeClient().WithRuntimeObjects(&kafkaConnect, &kibana).Build(), nil)
+			res, err := c.GetOriginalInfoToSave()
+			require.NoError(t, err)
+			require.JSONEq(t, `[{"key":"KafkaConnect.kafka.strimzi.io/my-connect","original":{"found":true,"value":3}},{"key":"Kibana.kibana.k8s.elastic.co/my-kibana","original":{"found":true,"value":1}}]`, string(res))
+		})
+
+		t.Run("keeps custom resources put to sleep by a previous sleep", func(t *testing.T) {
+			c := getNewResource(t, getFakeClient().WithRuntimeObjects(&sleepingKafkaConnect, &sleepingKibana).Build(), OriginalInfo{
+				"Kibana.kibana.k8s.elastic.co/my-kibana": json.RawMessage(`{"found":true,"value":1}`),
+			})
+			res, err := c.GetOriginalInfoToSave()
+			require.NoError(t, err)
+			require.JSONEq(t, `[{"key":"Kibana.kibana.k8s.elastic.co/my-kibana","original":{"found":true,"value":1}}]`, string(res))
+		})
+	})
+
+	t.Run("GetOriginalInfoToRestore", func(t *testing.T) {
+		t.Run("if empty saved data, returns empty info", func(t *testing.T) {
+			info, err := GetOriginalInfoToRestore(nil)
+			require.NoError(t, err)
+			require.Equal(t, OriginalInfo{}, info)
+		})
+
+		t.Run("throws if data is not a correct json", func(t *testing.T) {
+			_, err := GetOriginalInfoToRestore([]byte("{}"))
+			require.EqualError(t, err, "json: cannot unmarshal object into Go value of type []customresources.OriginalCustomResourceInfo")
+		})
+
+		t.Run("correctly returns data", func(t *testing.T) {
+			info, err := GetOriginalInfoToRestore([]byte(`[{"key":"Kibana.kibana.k8s.elastic.co/my-kibana","original":{"found":true,"value":1}},{"key":""}]`))
+			require.NoError(t, err)
+			require.Equal(t, OriginalInfo{
+				"Kibana.kibana.k8s.elastic.co/my-kibana": json.RawMessage(`{"found":true,"value":1}`),
+			}, info)
+		})
+	})
+}
+
+func getSpec(t *testing.T, c client.Client, obj unstructured.Unstructured) interface{} {
+	t.Helper()
+	current := unstructured.Unstructured{}
+	current.SetGroupVersionKind(obj.GroupVersionKind())
+	err := c.Get(context.Background(), client.ObjectKeyFromObject(&obj), &current)
+	require.NoError(t, err)
+	return current.Object["spec"]
+}
+
+// getFakeClient returns a fake client which knows only the KafkaConnect and
+// Kibana kinds, as if only their CRDs were installed.
+func getFakeClient() *fake.ClientBuilder {
+	restMapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{
+		kafkaConnectGVK.GroupVersion(),
+		kibanaGVK.GroupVersion(),
+	})
+	restMapper.Add(kafkaConnectGVK, meta.RESTScopeNamespace)
+	restMapper.Add(kibanaGVK, meta.RESTScopeNamespace)
+
+	return fake.NewClientBuilder().WithRESTMapper(restMapper)
+}
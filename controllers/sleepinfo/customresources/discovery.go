@@ -0,0 +1,151 @@
+package customresources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// discoveryVerbs are the verbs the controller needs on a custom resource to
+// put it to sleep and wake it up.
+var discoveryVerbs = []string{"list", "patch"}
+
+// ResourcesDiscovery returns the resources served by the API server for a
+// group version. It is implemented by the discovery client.
+type ResourcesDiscovery interface {
+	ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error)
+}
+
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=selfsubjectaccessreviews,verbs=create
+
+// Discoverer periodically checks which custom resources of the Registry are
+// served by the API server and allowed to the controller, so that the
+// handlers of the optional APIs not installed in the cluster, or whose RBAC
+// is not granted, are skipped instead of failing every reconcile. The
+// handlers are enabled again when their CRD is installed or their RBAC is
+// granted later.
+type Discoverer struct {
+	Registry  *Registry
+	Discovery ResourcesDiscovery
+	// Client checks with a SelfSubjectAccessReview if the controller can
+	// list and patch the served custom resources. If nil, the permissions are
+	// not checked.
+	Client client.Client
+	// Interval is how often the custom resources are discovered.
+	Interval time.Duration
+	Log      logr.Logger
+}
+
+// Start discovers the custom resources every Interval, until the context is
+// done.
+func (d *Discoverer) Start(ctx context.Context) error {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		if err := d.Discover(ctx); err != nil {
+			d.Log.Error(err, "fails to discover the custom resources")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection returns false, so that the replica which becomes the
+// leader already knows the custom resources served.
+func (d *Discoverer) NeedLeaderElection() bool {
+	return false
+}
+
+// Discover sets in the Registry the custom resources served and allowed. The
+// kinds whose discovery fails keep their previous state.
+func (d *Discoverer) Discover(ctx context.Context) error {
+	kindsByGroupVersion := map[schema.GroupVersion][]schema.GroupVersionKind{}
+	for _, gvk := range d.Registry.GroupVersionKinds() {
+		kindsByGroupVersion[gvk.GroupVersion()] = append(kindsByGroupVersion[gvk.GroupVersion()], gvk)
+	}
+
+	served := map[schema.GroupVersionKind]bool{}
+	errs := []string{}
+	for groupVersion, gvks := range kindsByGroupVersion {
+		resources, err := d.Discovery.ServerResourcesForGroupVersion(groupVersion.String())
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Sprintf("%s: %s", groupVersion, err))
+			for _, gvk := range gvks {
+				served[gvk] = d.Registry.IsServed(gvk)
+			}
+			continue
+		}
+		if err != nil {
+			// the group version is not served, its CRD is not installed.
+			continue
+		}
+		for _, gvk := range gvks {
+			isServed, err := d.isServedAndAllowed(ctx, gvk, resources.APIResources)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", gvk.GroupKind(), err))
+				isServed = d.Registry.IsServed(gvk)
+			}
+			served[gvk] = isServed
+		}
+	}
+
+	for _, gvk := range d.Registry.GroupVersionKinds() {
+		switch wasServed := d.Registry.IsServed(gvk); {
+		case served[gvk] && !wasServed:
+			d.Log.Info("custom resource served, handler enabled", "kind", gvk.String())
+		case !served[gvk] && wasServed:
+			d.Log.Info("custom resource not served or not allowed, handler skipped", "kind", gvk.String())
+		}
+	}
+	d.Registry.SetServed(served)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("fails to discover %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// isServedAndAllowed returns true if the kind is in the served resources, and
+// the controller can list and patch it.
+func (d *Discoverer) isServedAndAllowed(ctx context.Context, gvk schema.GroupVersionKind, resources []metav1.APIResource) (bool, error) {
+	for _, resource := range resources {
+		// the subresources, e.g. rollouts/status, have the kind of their resource.
+		if resource.Kind != gvk.Kind || strings.Contains(resource.Name, "/") {
+			continue
+		}
+		if d.Client == nil {
+			return true, nil
+		}
+		for _, verb := range discoveryVerbs {
+			review := &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Group:    gvk.Group,
+						Version:  gvk.Version,
+						Resource: resource.Name,
+						Verb:     verb,
+					},
+				},
+			}
+			if err := d.Client.Create(ctx, review); err != nil {
+				return false, err
+			}
+			if !review.Status.Allowed {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	return false, nil
+}
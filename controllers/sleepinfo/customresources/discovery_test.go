@@ -0,0 +1,113 @@
+package customresources
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeDiscovery struct {
+	resources map[string][]metav1.APIResource
+	err       error
+}
+
+func (d *fakeDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	resources, ok := d.resources[groupVersion]
+	if !ok {
+		return nil, apierrors.NewNotFound(schema.GroupResource{}, groupVersion)
+	}
+	return &metav1.APIResourceList{GroupVersion: groupVersion, APIResources: resources}, nil
+}
+
+// accessReviewClient is a client which allows the access to all the
+// resources, except the denied ones.
+type accessReviewClient struct {
+	client.Client
+	denied map[string]bool
+}
+
+func (c accessReviewClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	review, ok := obj.(*authorizationv1.SelfSubjectAccessReview)
+	if !ok {
+		return c.Client.Create(ctx, obj, opts...)
+	}
+	attributes := review.Spec.ResourceAttributes
+	review.Status.Allowed = !c.denied[attributes.Resource+"/"+attributes.Verb]
+	return nil
+}
+
+func TestDiscoverer(t *testing.T) {
+	rolloutGVK := schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"}
+	registry := NewRegistry()
+	registry.Register(kafkaConnectGVK, NewFieldHandler(int64(0), "spec", "replicas"))
+	registry.Register(kibanaGVK, NewFieldHandler(int64(0), "spec", "count"))
+	registry.Register(rolloutGVK, NewFieldHandler(int64(0), "spec", "replicas"))
+	require.True(t, registry.IsServed(kafkaConnectGVK))
+
+	discovery := &fakeDiscovery{resources: map[string][]metav1.APIResource{
+		"kafka.strimzi.io/v1beta2": {
+			{Name: "kafkaconnects", Kind: "KafkaConnect"},
+			{Name: "kafkaconnects/status", Kind: "KafkaConnect"},
+		},
+		"argoproj.io/v1alpha1": {
+			{Name: "rollouts/status", Kind: "Rollout"},
+			{Name: "rollouts", Kind: "Rollout"},
+		},
+	}}
+	c := accessReviewClient{
+		Client: fake.NewClientBuilder().Build(),
+		denied: map[string]bool{"rollouts/patch": true},
+	}
+	discoverer := &Discoverer{
+		Registry:  registry,
+		Discovery: discovery,
+		Client:    c,
+		Log:       logr.Discard(),
+	}
+
+	t.Run("skips the kinds not served or not allowed", func(t *testing.T) {
+		require.NoError(t, discoverer.Discover(context.Background()))
+		require.Equal(t, []schema.GroupVersionKind{kafkaConnectGVK}, registry.ServedGroupVersionKinds())
+		require.False(t, registry.IsServed(kibanaGVK))
+		require.False(t, registry.IsServed(rolloutGVK))
+	})
+
+	t.Run("enables the kinds installed later", func(t *testing.T) {
+		discovery.resources["kibana.k8s.elastic.co/v1"] = []metav1.APIResource{{Name: "kibanas", Kind: "Kibana"}}
+		require.NoError(t, discoverer.Discover(context.Background()))
+		require.Equal(t, []schema.GroupVersionKind{kafkaConnectGVK, kibanaGVK}, registry.ServedGroupVersionKinds())
+	})
+
+	t.Run("enables the kinds allowed later", func(t *testing.T) {
+		delete(c.denied, "rollouts/patch")
+		require.NoError(t, discoverer.Discover(context.Background()))
+		require.True(t, registry.IsServed(rolloutGVK))
+	})
+
+	t.Run("keeps the previous state if the discovery fails", func(t *testing.T) {
+		discovery.err = fmt.Errorf("connection refused")
+		defer func() { discovery.err = nil }()
+		err := discoverer.Discover(context.Background())
+		require.ErrorContains(t, err, "connection refused")
+		require.Equal(t, []schema.GroupVersionKind{rolloutGVK, kafkaConnectGVK, kibanaGVK}, registry.ServedGroupVersionKinds())
+	})
+
+	t.Run("without client the permissions are not checked", func(t *testing.T) {
+		c.denied["kafkaconnects/list"] = true
+		discoverer := &Discoverer{Registry: registry, Discovery: discovery, Log: logr.Discard()}
+		require.NoError(t, discoverer.Discover(context.Background()))
+		require.True(t, registry.IsServed(kafkaConnectGVK))
+	})
+}
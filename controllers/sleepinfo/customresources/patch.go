@@ -0,0 +1,106 @@
+package customresources
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// patchHandler puts to sleep the custom resources applying a JSON merge patch,
+// e.g. setting the replicas to 0 and an annotation which pauses the operator.
+// Each field changed by the patch is restored on its own, as with
+// NewFieldHandler.
+type patchHandler struct {
+	fields []fieldHandler
+}
+
+// patchedField is the original value of a field changed by the sleep patch.
+type patchedField struct {
+	Fields   []string        `json:"fields"`
+	Original json.RawMessage `json:"original"`
+}
+
+// NewPatchHandler returns a handler which, on sleep, applies the JSON merge
+// patch to the object and, on wake up, restores the original values of the
+// fields set by the patch. The patch cannot remove fields.
+func NewPatchHandler(sleepPatch map[string]interface{}) (Handler, error) {
+	fields, err := getPatchFields(sleepPatch, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("the sleep patch is empty")
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return strings.Join(fields[i].fields, ".") < strings.Join(fields[j].fields, ".")
+	})
+	return patchHandler{fields: fields}, nil
+}
+
+// getPatchFields returns a handler for each field set by the patch.
+func getPatchFields(patch map[string]interface{}, path []string) ([]fieldHandler, error) {
+	fields := []fieldHandler{}
+	for key, value := range patch {
+		fieldPath := append(append([]string{}, path...), key)
+		switch value := value.(type) {
+		case nil:
+			return nil, fmt.Errorf("the sleep patch cannot remove %s", strings.Join(fieldPath, "."))
+		case map[string]interface{}:
+			nested, err := getPatchFields(value, fieldPath)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, nested...)
+		default:
+			fields = append(fields, fieldHandler{sleepValue: value, fields: fieldPath})
+		}
+	}
+	return fields, nil
+}
+
+func (h patchHandler) Sleep(obj *unstructured.Unstructured) (json.RawMessage, error) {
+	originals := []patchedField{}
+	for _, field := range h.fields {
+		original, err := field.Sleep(obj)
+		if err != nil {
+			return nil, fmt.Errorf("fails to set %s: %s", strings.Join(field.fields, "."), err)
+		}
+		if original != nil {
+			originals = append(originals, patchedField{Fields: field.fields, Original: original})
+		}
+	}
+	if len(originals) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(originals)
+}
+
+func (h patchHandler) WakeUp(obj *unstructured.Unstructured, original json.RawMessage) error {
+	originals := []patchedField{}
+	if err := json.Unmarshal(original, &originals); err != nil {
+		return fmt.Errorf("invalid original values: %s", err)
+	}
+	for _, patched := range originals {
+		field, ok := h.getField(patched.Fields)
+		if !ok {
+			// the field is no longer set by the patch, it is left as is.
+			continue
+		}
+		if err := field.WakeUp(obj, patched.Original); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h patchHandler) getField(fields []string) (fieldHandler, bool) {
+	for _, field := range h.fields {
+		if strings.Join(field.fields, "\x00") == strings.Join(fields, "\x00") {
+			return field, true
+		}
+	}
+	return fieldHandler{}, false
+}
@@ -0,0 +1,126 @@
+package customresources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestPatchHandler(t *testing.T) {
+	sleepPatch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{"example.com/paused": "true"},
+		},
+		"spec": map[string]interface{}{"replicas": int64(0)},
+	}
+
+	t.Run("sleep and wake up", func(t *testing.T) {
+		h, err := NewPatchHandler(sleepPatch)
+		require.NoError(t, err)
+
+		obj := GetMock(MockSpec{Name: "my-cr", Spec: map[string]interface{}{"replicas": int64(3)}})
+		obj.SetAnnotations(map[string]string{"other": "value"})
+		original, err := h.Sleep(&obj)
+		require.NoError(t, err)
+		require.JSONEq(t, `[
+			{"fields": ["metadata", "annotations", "example.com/paused"], "original": {"found": false}},
+			{"fields": ["spec", "replicas"], "original": {"found": true, "value": 3}}
+		]`, string(original))
+		require.Equal(t, map[string]string{"other": "value", "example.com/paused": "true"}, obj.GetAnnotations())
+		require.Equal(t, int64(0), obj.Object["spec"].(map[string]interface{})["replicas"])
+
+		require.NoError(t, h.WakeUp(&obj, original))
+		require.Equal(t, map[string]string{"other": "value"}, obj.GetAnnotations())
+		require.Equal(t, int64(3), obj.Object["spec"].(map[string]interface{})["replicas"])
+	})
+
+	t.Run("already sleeping", func(t *testing.T) {
+		h, err := NewPatchHandler(sleepPatch)
+		require.NoError(t, err)
+
+		obj := GetMock(MockSpec{Name: "my-cr", Spec: map[string]interface{}{"replicas": int64(0)}})
+		obj.SetAnnotations(map[string]string{"example.com/paused": "true"})
+		original, err := h.Sleep(&obj)
+		require.NoError(t, err)
+		require.Nil(t, original)
+	})
+
+	t.Run("only the changed fields are restored", func(t *testing.T) {
+		h, err := NewPatchHandler(sleepPatch)
+		require.NoError(t, err)
+
+		obj := GetMock(MockSpec{Name: "my-cr", Spec: map[string]interface{}{"replicas": int64(0)}})
+		original, err := h.Sleep(&obj)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"fields": ["metadata", "annotations", "example.com/paused"], "original": {"found": false}}]`, string(original))
+
+		// the replicas are changed during the sleep, and they are not restored.
+		obj.Object["spec"].(map[string]interface{})["replicas"] = int64(2)
+		require.NoError(t, h.WakeUp(&obj, original))
+		require.Empty(t, obj.GetAnnotations())
+		require.Equal(t, int64(2), obj.Object["spec"].(map[string]interface{})["replicas"])
+	})
+
+	t.Run("invalid original values", func(t *testing.T) {
+		h, err := NewPatchHandler(sleepPatch)
+		require.NoError(t, err)
+
+		obj := GetMock(MockSpec{Name: "my-cr"})
+		require.EqualError(t, h.WakeUp(&obj, []byte(`{"found":true}`)), "invalid original values: json: cannot unmarshal object into Go value of type []customresources.patchedField")
+	})
+
+	t.Run("invalid patch", func(t *testing.T) {
+		_, err := NewPatchHandler(map[string]interface{}{"spec": map[string]interface{}{"replicas": nil}})
+		require.EqualError(t, err, "the sleep patch cannot remove spec.replicas")
+
+		_, err = NewPatchHandler(map[string]interface{}{"spec": map[string]interface{}{}})
+		require.EqualError(t, err, "the sleep patch is empty")
+	})
+}
+
+func TestRegisterPresets(t *testing.T) {
+	t.Run("register the kinds of the presets", func(t *testing.T) {
+		r := NewDefaultRegistry()
+		require.NoError(t, RegisterPresets(r, []string{"cloudnative-pg", "flux"}))
+
+		for _, gvk := range []schema.GroupVersionKind{
+			{Group: "postgresql.cnpg.io", Version: "v1", Kind: "Cluster"},
+			{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Kind: "Kustomization"},
+			{Group: "helm.toolkit.fluxcd.io", Version: "v2", Kind: "HelmRelease"},
+			{Group: "helm.toolkit.fluxcd.io", Version: "v2beta2", Kind: "HelmRelease"},
+		} {
+			_, ok := r.Handler(gvk)
+			require.True(t, ok, "handler of %s not found", gvk)
+		}
+		require.Len(t, r.GroupVersionKinds(), 14)
+	})
+
+	t.Run("hibernate the CloudNativePG clusters", func(t *testing.T) {
+		r := NewRegistry()
+		require.NoError(t, RegisterPresets(r, []string{"cloudnative-pg"}))
+		h, ok := r.Handler(schema.GroupVersionKind{Group: "postgresql.cnpg.io", Version: "v1", Kind: "Cluster"})
+		require.True(t, ok)
+
+		obj := GetMock(MockSpec{Name: "my-db", Spec: map[string]interface{}{"instances": int64(3)}})
+		original, err := h.Sleep(&obj)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"cnpg.io/hibernation": "on"}, obj.GetAnnotations())
+
+		require.NoError(t, h.WakeUp(&obj, original))
+		require.Empty(t, obj.GetAnnotations())
+	})
+
+	t.Run("all the presets are valid", func(t *testing.T) {
+		r := NewRegistry()
+		require.NoError(t, RegisterPresets(r, PresetNames()))
+		require.Len(t, r.GroupVersionKinds(), 10)
+	})
+
+	t.Run("preset not supported", func(t *testing.T) {
+		r := NewRegistry()
+		err := RegisterPresets(r, []string{"flux", "redis"})
+		require.EqualError(t, err, "preset redis not supported: must be one of argo-rollouts, cloudnative-pg, crunchy-postgres, flux, keda, rabbitmq-operator, zalando-postgres")
+		require.Empty(t, r.GroupVersionKinds())
+	})
+}
@@ -0,0 +1,154 @@
+package customresources
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+)
+
+// PluginRequest is the body of the request sent to the plugins.
+type PluginRequest struct {
+	// Object is the custom resource to put to sleep.
+	Object map[string]interface{} `json:"object"`
+}
+
+// PluginResponse is the body of the response of the plugins.
+type PluginResponse struct {
+	// SleepPatch is the JSON merge patch which puts the object to sleep.
+	// If empty, the object is already sleeping.
+	SleepPatch json.RawMessage `json:"sleepPatch,omitempty"`
+	// RestorePatch is the JSON merge patch which wakes up the object.
+	RestorePatch json.RawMessage `json:"restorePatch,omitempty"`
+}
+
+// pluginHandler delegates to an external HTTP endpoint how to put to sleep a
+// kind of custom resource which kube-green does not know.
+type pluginHandler struct {
+	client  *http.Client
+	url     string
+	timeout time.Duration
+}
+
+// NewPluginHandler returns a Handler which, on sleep, sends the object as JSON
+// with a POST request to the url of the plugin, and applies the sleep patch of
+// the response. The restore patch is saved and applied on wake up, without
+// calling the plugin. If httpClient is nil, http.DefaultClient is used.
+func NewPluginHandler(httpClient *http.Client, url string, timeout time.Duration) Handler {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return pluginHandler{
+		client:  httpClient,
+		url:     url,
+		timeout: timeout,
+	}
+}
+
+func (h pluginHandler) Sleep(obj *unstructured.Unstructured) (json.RawMessage, error) {
+	res, err := h.call(obj)
+	if err != nil {
+		return nil, err
+	}
+	if isEmptyPatch(res.SleepPatch) {
+		return nil, nil
+	}
+
+	sleepingObj, err := applyMergePatch(obj.Object, res.SleepPatch)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sleep patch: %s", err)
+	}
+	if equality.Semantic.DeepEqual(obj.Object, sleepingObj) {
+		return nil, nil
+	}
+	if isEmptyPatch(res.RestorePatch) {
+		return nil, fmt.Errorf("restore patch is required")
+	}
+	obj.Object = sleepingObj
+	return res.RestorePatch, nil
+}
+
+func (h pluginHandler) WakeUp(obj *unstructured.Unstructured, original json.RawMessage) error {
+	restoredObj, err := applyMergePatch(obj.Object, original)
+	if err != nil {
+		return fmt.Errorf("invalid restore patch: %s", err)
+	}
+	obj.Object = restoredObj
+	return nil
+}
+
+func (h pluginHandler) call(obj *unstructured.Unstructured) (PluginResponse, error) {
+	ctx := context.Background()
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	body, err := json.Marshal(PluginRequest{Object: obj.Object})
+	if err != nil {
+		return PluginResponse{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return PluginResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		return PluginResponse{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return PluginResponse{}, fmt.Errorf("plugin responded with status %d", res.StatusCode)
+	}
+
+	pluginResponse := PluginResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&pluginResponse); err != nil {
+		return PluginResponse{}, fmt.Errorf("invalid plugin response: %s", err)
+	}
+	return pluginResponse, nil
+}
+
+func isEmptyPatch(patch json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(patch)
+	return len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) || bytes.Equal(trimmed, []byte("{}"))
+}
+
+// applyMergePatch returns a copy of the object with the JSON merge patch
+// (RFC 7386) applied.
+func applyMergePatch(obj map[string]interface{}, patch json.RawMessage) (map[string]interface{}, error) {
+	patchObj := map[string]interface{}{}
+	// the integer numbers are decoded as int64, as in the objects read from the API server.
+	if err := utiljson.Unmarshal(patch, &patchObj); err != nil {
+		return nil, err
+	}
+	return mergePatch(runtime.DeepCopyJSON(obj), patchObj), nil
+}
+
+func mergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = map[string]interface{}{}
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		if patchValue, ok := value.(map[string]interface{}); ok {
+			targetValue, _ := target[key].(map[string]interface{})
+			target[key] = mergePatch(targetValue, patchValue)
+			continue
+		}
+		target[key] = value
+	}
+	return target
+}
@@ -0,0 +1,108 @@
+package customresources
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestPluginHandler(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "MyDatabase"}
+	getPlugin := func(t *testing.T, response string) (*httptest.Server, *PluginRequest) {
+		t.Helper()
+		receivedRequest := &PluginRequest{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodPost, r.Method)
+			require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(receivedRequest))
+			w.Header().Set("Content-Type", "application/json")
+			_, err := w.Write([]byte(response))
+			require.NoError(t, err)
+		}))
+		t.Cleanup(server.Close)
+		return server, receivedRequest
+	}
+
+	t.Run("sleep and wake up with the patches of the plugin", func(t *testing.T) {
+		server, receivedRequest := getPlugin(t, `{"sleepPatch":{"spec":{"instances":0,"suspended":true}},"restorePatch":{"spec":{"instances":2,"suspended":null}}}`)
+		h := NewPluginHandler(server.Client(), server.URL, time.Second)
+
+		obj := GetMock(MockSpec{
+			GroupVersionKind: gvk,
+			Name:             "my-db",
+			Namespace:        "my-namespace",
+			Spec:             map[string]interface{}{"instances": int64(2), "version": "15"},
+		})
+		original, err := h.Sleep(&obj)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"spec":{"instances":2,"suspended":null}}`, string(original))
+		require.Equal(t, map[string]interface{}{"instances": int64(0), "suspended": true, "version": "15"}, obj.Object["spec"])
+		require.Equal(t, "my-db", receivedRequest.Object["metadata"].(map[string]interface{})["name"])
+
+		require.NoError(t, h.WakeUp(&obj, original))
+		require.Equal(t, map[string]interface{}{"instances": int64(2), "version": "15"}, obj.Object["spec"])
+	})
+
+	t.Run("returns nil if already sleeping", func(t *testing.T) {
+		for _, response := range []string{
+			`{}`,
+			`{"sleepPatch":null}`,
+			`{"sleepPatch":{"spec":{"instances":0}},"restorePatch":{"spec":{"instances":2}}}`,
+		} {
+			server, _ := getPlugin(t, response)
+			h := NewPluginHandler(server.Client(), server.URL, 0)
+
+			obj := GetMock(MockSpec{
+				GroupVersionKind: gvk,
+				Name:             "my-db",
+				Spec:             map[string]interface{}{"instances": int64(0)},
+			})
+			original, err := h.Sleep(&obj)
+			require.NoError(t, err)
+			require.Nil(t, original, response)
+		}
+	})
+
+	t.Run("throws if restore patch is missing", func(t *testing.T) {
+		server, _ := getPlugin(t, `{"sleepPatch":{"spec":{"instances":0}}}`)
+		h := NewPluginHandler(server.Client(), server.URL, 0)
+
+		obj := GetMock(MockSpec{GroupVersionKind: gvk, Name: "my-db"})
+		_, err := h.Sleep(&obj)
+		require.EqualError(t, err, "restore patch is required")
+	})
+
+	t.Run("throws if plugin responds with error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+		h := NewPluginHandler(nil, server.URL, 0)
+
+		obj := GetMock(MockSpec{GroupVersionKind: gvk, Name: "my-db"})
+		_, err := h.Sleep(&obj)
+		require.EqualError(t, err, "plugin responded with status 500")
+	})
+
+	t.Run("throws if plugin response is invalid", func(t *testing.T) {
+		server, _ := getPlugin(t, `not a json`)
+		h := NewPluginHandler(server.Client(), server.URL, 0)
+
+		obj := GetMock(MockSpec{GroupVersionKind: gvk, Name: "my-db"})
+		_, err := h.Sleep(&obj)
+		require.EqualError(t, err, "invalid plugin response: invalid character 'o' in literal null (expecting 'u')")
+	})
+
+	t.Run("throws if restore patch is invalid", func(t *testing.T) {
+		h := NewPluginHandler(nil, "http://not-called", 0)
+
+		obj := GetMock(MockSpec{GroupVersionKind: gvk, Name: "my-db"})
+		err := h.WakeUp(&obj, json.RawMessage(`[]`))
+		require.EqualError(t, err, "invalid restore patch: json: cannot unmarshal array into Go value of type map[string]interface {}")
+	})
+}
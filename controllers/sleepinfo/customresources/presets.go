@@ -0,0 +1,118 @@
+package customresources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// presetKind is a kind of custom resource handled by a preset, with the patch
+// which puts it to sleep.
+type presetKind struct {
+	gvk        schema.GroupVersionKind
+	sleepPatch map[string]interface{}
+}
+
+// presets are the bundles of the handlers of the custom resources of
+// well-known operators, enabled by name. The integer values are int64, as in
+// the objects read from the API server.
+var presets = map[string][]presetKind{
+	// CloudNativePG clusters are hibernated: the pods are deleted and the
+	// volumes are kept.
+	"cloudnative-pg": {
+		{
+			gvk:        schema.GroupVersionKind{Group: "postgresql.cnpg.io", Version: "v1", Kind: "Cluster"},
+			sleepPatch: map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{"cnpg.io/hibernation": "on"}}},
+		},
+	},
+	// Crunchy Postgres for Kubernetes clusters are shut down.
+	"crunchy-postgres": {
+		{
+			gvk:        schema.GroupVersionKind{Group: "postgres-operator.crunchydata.com", Version: "v1beta1", Kind: "PostgresCluster"},
+			sleepPatch: map[string]interface{}{"spec": map[string]interface{}{"shutdown": true}},
+		},
+	},
+	// Zalando Postgres Operator clusters are scaled to 0 instances.
+	"zalando-postgres": {
+		{
+			gvk:        schema.GroupVersionKind{Group: "acid.zalan.do", Version: "v1", Kind: "postgresql"},
+			sleepPatch: map[string]interface{}{"spec": map[string]interface{}{"numberOfInstances": int64(0)}},
+		},
+	},
+	// RabbitMQ Cluster Operator clusters are scaled to 0 replicas.
+	"rabbitmq-operator": {
+		{
+			gvk:        schema.GroupVersionKind{Group: "rabbitmq.com", Version: "v1beta1", Kind: "RabbitmqCluster"},
+			sleepPatch: map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(0)}},
+		},
+	},
+	// KEDA ScaledObjects are paused at 0 replicas, so that they do not scale
+	// up again the workloads put to sleep, and ScaledJobs are paused.
+	"keda": {
+		{
+			gvk:        schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "ScaledObject"},
+			sleepPatch: map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{"autoscaling.keda.sh/paused-replicas": "0"}}},
+		},
+		{
+			gvk:        schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "ScaledJob"},
+			sleepPatch: map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{"autoscaling.keda.sh/paused": "true"}}},
+		},
+	},
+	// Argo Rollouts are scaled to 0 replicas.
+	"argo-rollouts": {
+		{
+			gvk:        schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"},
+			sleepPatch: map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(0)}},
+		},
+	},
+	// Flux Kustomizations and HelmReleases are suspended, so that they do not
+	// revert the sleep of the resources they apply.
+	"flux": {
+		{
+			gvk:        schema.GroupVersionKind{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Kind: "Kustomization"},
+			sleepPatch: map[string]interface{}{"spec": map[string]interface{}{"suspend": true}},
+		},
+		{
+			gvk:        schema.GroupVersionKind{Group: "helm.toolkit.fluxcd.io", Version: "v2", Kind: "HelmRelease"},
+			sleepPatch: map[string]interface{}{"spec": map[string]interface{}{"suspend": true}},
+		},
+		{
+			gvk:        schema.GroupVersionKind{Group: "helm.toolkit.fluxcd.io", Version: "v2beta2", Kind: "HelmRelease"},
+			sleepPatch: map[string]interface{}{"spec": map[string]interface{}{"suspend": true}},
+		},
+	},
+}
+
+// PresetNames returns the names of the presets, sorted.
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegisterPresets adds to the registry the handlers of the custom resources
+// of the presets, so that the SleepInfo with suspendCustomResources put them
+// to sleep without a plugin. It returns an error if a preset is not
+// supported, before any handler is registered.
+func RegisterPresets(r *Registry, names []string) error {
+	for _, name := range names {
+		if _, ok := presets[name]; !ok {
+			return fmt.Errorf("preset %s not supported: must be one of %s", name, strings.Join(PresetNames(), ", "))
+		}
+	}
+	for _, name := range names {
+		for _, kind := range presets[name] {
+			handler, err := NewPatchHandler(kind.sleepPatch)
+			if err != nil {
+				return fmt.Errorf("invalid preset %s: %s", name, err)
+			}
+			r.Register(kind.gvk, handler)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package customresources
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Handler puts to sleep and wakes up a kind of custom resource.
+type Handler interface {
+	// Sleep changes the object to put it to sleep, and returns the original
+	// values to restore at wake up. It returns nil if the object is already sleeping.
+	Sleep(obj *unstructured.Unstructured) (json.RawMessage, error)
+	// WakeUp changes the object restoring the original values returned by Sleep.
+	WakeUp(obj *unstructured.Unstructured, original json.RawMessage) error
+}
+
+// Registry contains the handlers of the custom resources, keyed by GroupVersionKind.
+type Registry struct {
+	mu       sync.RWMutex
+	handlers map[schema.GroupVersionKind]Handler
+	// served are the kinds served by the API server and allowed to the
+	// controller, as found by the Discoverer. If nil, all the kinds are
+	// handled.
+	served map[schema.GroupVersionKind]bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		handlers: map[schema.GroupVersionKind]Handler{},
+	}
+}
+
+// Register adds the handler of the custom resource of the given kind,
+// replacing the handler already registered for the same kind.
+func (r *Registry) Register(gvk schema.GroupVersionKind, handler Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[gvk] = handler
+}
+
+// Handler returns the handler registered for the given kind.
+func (r *Registry) Handler(gvk schema.GroupVersionKind) (Handler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, ok := r.handlers[gvk]
+	return handler, ok
+}
+
+// GroupVersionKinds returns the registered kinds, sorted.
+func (r *Registry) GroupVersionKinds() []schema.GroupVersionKind {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	gvks := make([]schema.GroupVersionKind, 0, len(r.handlers))
+	for gvk := range r.handlers {
+		gvks = append(gvks, gvk)
+	}
+	sort.Slice(gvks, func(i, j int) bool {
+		return gvks[i].String() < gvks[j].String()
+	})
+	return gvks
+}
+
+// SetServed sets the kinds served by the API server and allowed to the
+// controller. The other kinds are not handled.
+func (r *Registry) SetServed(served map[schema.GroupVersionKind]bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.served = served
+}
+
+// IsServed returns true if the kind is served and allowed, or if the kinds
+// served have not been discovered.
+func (r *Registry) IsServed(gvk schema.GroupVersionKind) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.served == nil || r.served[gvk]
+}
+
+// ServedGroupVersionKinds returns the registered kinds which are served,
+// sorted.
+func (r *Registry) ServedGroupVersionKinds() []schema.GroupVersionKind {
+	gvks := []schema.GroupVersionKind{}
+	for _, gvk := range r.GroupVersionKinds() {
+		if r.IsServed(gvk) {
+			gvks = append(gvks, gvk)
+		}
+	}
+	return gvks
+}
@@ -0,0 +1,30 @@
+package customresources
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type MockSpec struct {
+	GroupVersionKind schema.GroupVersionKind
+	Namespace        string
+	Name             string
+	Labels           map[string]string
+	Spec             map[string]interface{}
+}
+
+func GetMock(opts MockSpec) unstructured.Unstructured {
+	obj := unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": opts.Spec,
+		},
+	}
+	if opts.Spec == nil {
+		obj.Object["spec"] = map[string]interface{}{}
+	}
+	obj.SetGroupVersionKind(opts.GroupVersionKind)
+	obj.SetName(opts.Name)
+	obj.SetNamespace(opts.Namespace)
+	obj.SetLabels(opts.Labels)
+	return obj
+}
@@ -0,0 +1,205 @@
+package daemonsets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SleepNodeSelectorKey is the node selector key added to the DaemonSets during
+// the sleep. No node has this label, so all the pods of the DaemonSet are removed.
+const SleepNodeSelectorKey = "kube-green.com/sleeping"
+
+var (
+	ErrFetchingDaemonSets = errors.New("error fetching daemonsets")
+)
+
+type OriginalNodeSelectors map[string]map[string]string
+type daemonsets struct {
+	resource.ResourceClient
+	data                  []appsv1.DaemonSet
+	OriginalNodeSelectors OriginalNodeSelectors
+	areToSuspend          bool
+}
+
+func NewResource(ctx context.Context, res resource.ResourceClient, namespace string, originalNodeSelectors map[string]map[string]string) (resource.Resource, error) {
+	d := daemonsets{
+		ResourceClient:        res,
+		OriginalNodeSelectors: originalNodeSelectors,
+		areToSuspend:          res.SleepInfo.IsDaemonSetsToSuspend() || (res.IsWakeUp && len(originalNodeSelectors) > 0),
+		data:                  []appsv1.DaemonSet{},
+	}
+	if !d.areToSuspend {
+		return d, nil
+	}
+	if err := d.fetch(ctx, namespace); err != nil {
+		return daemonsets{}, fmt.Errorf("%w: %s", ErrFetchingDaemonSets, err)
+	}
+
+	return d, nil
+}
+
+func (d daemonsets) HasResource() bool {
+	return len(d.data) > 0
+}
+
+func (d daemonsets) GetResourceNames() []string {
+	names := []string{}
+	for _, daemonSet := range d.data {
+		names = append(names, daemonSet.Name)
+	}
+	return names
+}
+
+func isSleeping(daemonSet appsv1.DaemonSet) bool {
+	_, ok := daemonSet.Spec.Template.Spec.NodeSelector[SleepNodeSelectorKey]
+	return ok
+}
+
+func (d daemonsets) Sleep(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "daemonsets.sleep", trace.WithAttributes(attribute.Int("resources.count", len(d.data))))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	for _, daemonSet := range d.data {
+		daemonSet := daemonSet
+		if isSleeping(daemonSet) || d.IsKeptAwake(&daemonSet) {
+			continue
+		}
+		newDaemonSet := daemonSet.DeepCopy()
+		if newDaemonSet.Spec.Template.Spec.NodeSelector == nil {
+			newDaemonSet.Spec.Template.Spec.NodeSelector = map[string]string{}
+		}
+		newDaemonSet.Spec.Template.Spec.NodeSelector[SleepNodeSelectorKey] = "true"
+
+		if err := d.Patch(ctx, &daemonSet, newDaemonSet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d daemonsets) WakeUp(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "daemonsets.wakeUp", trace.WithAttributes(attribute.Int("resources.count", len(d.data))))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	for _, daemonSet := range d.data {
+		daemonSet := daemonSet
+
+		dsLogger := d.Log.WithValues("daemonset", daemonSet.Name, "namespace", daemonSet.Namespace)
+		if !isSleeping(daemonSet) {
+			dsLogger.Info("daemonset is not sleeping during wake up")
+			continue
+		}
+
+		nodeSelector, ok := d.OriginalNodeSelectors[daemonSet.Name]
+		if !ok {
+			dsLogger.Info("original daemonset info not correctly set")
+			continue
+		}
+
+		newDaemonSet := daemonSet.DeepCopy()
+		newDaemonSet.Spec.Template.Spec.NodeSelector = nodeSelector
+
+		if err := d.Patch(ctx, &daemonSet, newDaemonSet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type OriginalDaemonSetInfo struct {
+	Name         string            `json:"name"`
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+}
+
+func (d daemonsets) GetOriginalInfoToSave() ([]byte, error) {
+	if !d.areToSuspend {
+		return nil, nil
+	}
+	originalDaemonSetsInfo := []OriginalDaemonSetInfo{}
+	for _, daemonSet := range d.data {
+		nodeSelector := daemonSet.Spec.Template.Spec.NodeSelector
+		if isSleeping(daemonSet) {
+			// a daemonset put to sleep by a previous sleep is still to wake up.
+			originalNodeSelector, ok := d.OriginalNodeSelectors[daemonSet.Name]
+			if !ok {
+				continue
+			}
+			nodeSelector = originalNodeSelector
+		}
+		originalDaemonSetsInfo = append(originalDaemonSetsInfo, OriginalDaemonSetInfo{
+			Name:         daemonSet.Name,
+			NodeSelector: nodeSelector,
+		})
+	}
+	return json.Marshal(originalDaemonSetsInfo)
+}
+
+func (d *daemonsets) fetch(ctx context.Context, namespace string) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "daemonsets.list")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	daemonSetList, err := d.getListByNamespace(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	d.Log.V(1).WithValues("number of daemonsets", len(daemonSetList), "namespace", namespace).Info("daemonsets in namespace")
+	d.data = d.filterDaemonSets(daemonSetList)
+	return nil
+}
+
+func (d daemonsets) getListByNamespace(ctx context.Context, namespace string) ([]appsv1.DaemonSet, error) {
+	listOptions := &client.ListOptions{
+		Namespace: namespace,
+		Limit:     500,
+	}
+	daemonSetList := appsv1.DaemonSetList{}
+	if err := d.Client.List(ctx, &daemonSetList, listOptions); err != nil {
+		return daemonSetList.Items, client.IgnoreNotFound(err)
+	}
+	return daemonSetList.Items, nil
+}
+
+// filterDaemonSets returns the daemonsets not managed by other resources (e.g.
+// by an operator, which would revert the patch) and not excluded by the SleepInfo.
+func (d daemonsets) filterDaemonSets(daemonSetList []appsv1.DaemonSet) []appsv1.DaemonSet {
+	filteredList := []appsv1.DaemonSet{}
+	for _, daemonSet := range daemonSetList {
+		daemonSet := daemonSet
+		if metav1.GetControllerOf(&daemonSet) != nil {
+			continue
+		}
+		if !d.IsWakeUp && (resource.IsExcluded("DaemonSet", &daemonSet, d.SleepInfo.GetExcludeRef()) || !d.SleepInfo.IsManagedByClass(daemonSet.Labels)) {
+			continue
+		}
+		filteredList = append(filteredList, daemonSet)
+	}
+	return filteredList
+}
+
+func GetOriginalInfoToRestore(savedData []byte) (OriginalNodeSelectors, error) {
+	if savedData == nil {
+		return OriginalNodeSelectors{}, nil
+	}
+	originalDaemonSetsInfo := []OriginalDaemonSetInfo{}
+	if err := json.Unmarshal(savedData, &originalDaemonSetsInfo); err != nil {
+		return nil, err
+	}
+	originalNodeSelectors := OriginalNodeSelectors{}
+	for _, daemonSet := range originalDaemonSetsInfo {
+		if daemonSet.Name != "" {
+			originalNodeSelectors[daemonSet.Name] = daemonSet.NodeSelector
+		}
+	}
+	return originalNodeSelectors, nil
+}
@@ -0,0 +1,355 @@
+package daemonsets
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/internal/testutil"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestDaemonSets(t *testing.T) {
+	testLogger := zap.New(zap.UseDevMode(true))
+
+	namespace := "my-namespace"
+	isController := true
+	daemonSet1 := GetMock(MockSpec{
+		Name:      "ds1",
+		Namespace: namespace,
+	})
+	daemonSetWithNodeSelector := GetMock(MockSpec{
+		Name:      "ds-with-node-selector",
+		Namespace: namespace,
+		NodeSelector: map[string]string{
+			"kubernetes.io/os": "linux",
+		},
+	})
+	daemonSetWithLabels := GetMock(MockSpec{
+		Name:      "ds-with-labels",
+		Namespace: namespace,
+		Labels: map[string]string{
+			"app": "foo",
+		},
+	})
+	daemonSetOtherNamespace := GetMock(MockSpec{
+		Name:      "ds-other-namespace",
+		Namespace: "other-namespace",
+	})
+	daemonSetOwnedByOperator := GetMock(MockSpec{
+		Name:      "ds-owned-by-operator",
+		Namespace: namespace,
+		OwnerReferences: []metav1.OwnerReference{
+			{
+				APIVersion: "example.com/v1",
+				Kind:       "Agent",
+				Name:       "agent",
+				UID:        "agent-uid",
+				Controller: &isController,
+			},
+		},
+	})
+	sleepingDaemonSet1 := GetMock(MockSpec{
+		Name:      "ds1",
+		Namespace: namespace,
+		NodeSelector: map[string]string{
+			SleepNodeSelectorKey: "true",
+		},
+	})
+	sleepingDaemonSetWithNodeSelector := GetMock(MockSpec{
+		Name:      "ds-with-node-selector",
+		Namespace: namespace,
+		NodeSelector: map[string]string{
+			"kubernetes.io/os":   "linux",
+			SleepNodeSelectorKey: "true",
+		},
+	})
+	sleepInfo := &v1alpha1.SleepInfo{
+		Spec: v1alpha1.SleepInfoSpec{
+			SuspendDaemonSets: true,
+		},
+	}
+
+	getNewResource := func(t *testing.T, client client.Client, originalNodeSelectors map[string]map[string]string) daemonsets {
+		t.Helper()
+
+		resource, err := NewResource(context.Background(), resource.ResourceClient{
+			Client:    client,
+			Log:       testLogger,
+			SleepInfo: sleepInfo,
+		}, namespace, originalNodeSelectors)
+		require.NoError(t, err)
+
+		daemonsets, ok := resource.(daemonsets)
+		require.True(t, ok)
+		return daemonsets
+	}
+
+	t.Run("NewResource", func(t *testing.T) {
+		listDaemonSetsTests := []struct {
+			name          string
+			client        client.Client
+			expectedNames []string
+			sleepInfo     *v1alpha1.SleepInfo
+			throws        bool
+		}{
+			{
+				name: "get list of daemonsets",
+				client: fake.NewClientBuilder().
+					WithRuntimeObjects(&daemonSet1, &daemonSetWithNodeSelector, &daemonSetOtherNamespace).
+					Build(),
+				expectedNames: []string{"ds-with-node-selector", "ds1"},
+				sleepInfo:     sleepInfo,
+			},
+			{
+				name:      "fails to list daemonsets",
+				sleepInfo: sleepInfo,
+				client: &testutil.PossiblyErroringFakeCtrlRuntimeClient{
+					Client: fake.NewClientBuilder().Build(),
+					ShouldError: func(method testutil.Method, obj runtime.Object) bool {
+						return method == testutil.List
+					},
+				},
+				throws:        true,
+				expectedNames: []string{},
+			},
+			{
+				name: "skip daemonsets owned by other resources",
+				client: fake.NewClientBuilder().
+					WithRuntimeObjects(&daemonSet1, &daemonSetOwnedByOperator).
+					Build(),
+				expectedNames: []string{"ds1"},
+				sleepInfo:     sleepInfo,
+			},
+			{
+				name: "exclude daemonsets by name and labels",
+				client: fake.NewClientBuilder().
+					WithRuntimeObjects(&daemonSet1, &daemonSetWithNodeSelector, &daemonSetWithLabels).
+					Build(),
+				expectedNames: []string{"ds1"},
+				sleepInfo: &v1alpha1.SleepInfo{
+					Spec: v1alpha1.SleepInfoSpec{
+						SuspendDaemonSets: true,
+						ExcludeRef: []v1alpha1.ExcludeRef{
+							{
+								APIVersion: "apps/v1",
+								Kind:       "DaemonSet",
+								Name:       "ds-with-node-selector",
+							},
+							{
+								MatchLabels: map[string]string{
+									"app": "foo",
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				name: "disabled daemonsets suspend",
+				client: fake.NewClientBuilder().
+					WithRuntimeObjects(&daemonSet1).
+					Build(),
+				sleepInfo:     &v1alpha1.SleepInfo{},
+				expectedNames: []string{},
+			},
+		}
+
+		for _, test := range listDaemonSetsTests {
+			t.Run(test.name, func(t *testing.T) {
+				r := resource.ResourceClient{
+					Client:    test.client,
+					Log:       testLogger,
+					SleepInfo: test.sleepInfo,
+				}
+
+				res, err := NewResource(context.Background(), r, namespace, map[string]map[string]string{})
+				if test.throws {
+					require.EqualError(t, err, fmt.Sprintf("%s: error during list", ErrFetchingDaemonSets))
+				} else {
+					require.NoError(t, err)
+				}
+				require.Equal(t, test.expectedNames, res.GetResourceNames())
+			})
+		}
+	})
+
+	t.Run("HasResources", func(t *testing.T) {
+		t.Run("without resource", func(t *testing.T) {
+			d := getNewResource(t, fake.NewClientBuilder().Build(), nil)
+			require.False(t, d.HasResource())
+			require.Empty(t, d.GetResourceNames())
+		})
+
+		t.Run("with resource", func(t *testing.T) {
+			d := getNewResource(t, fake.NewClientBuilder().WithRuntimeObjects(&daemonSet1).Build(), nil)
+			require.True(t, d.HasResource())
+			require.Equal(t, []string{"ds1"}, d.GetResourceNames())
+		})
+	})
+
+	t.Run("Sleep", func(t *testing.T) {
+		t.Run("not throws if no data", func(t *testing.T) {
+			d := getNewResource(t, fake.NewClientBuilder().Build(), nil)
+			require.NoError(t, d.Sleep(context.Background()))
+		})
+
+		t.Run("add the sleep node selector", func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithRuntimeObjects(&daemonSet1, &daemonSetWithNodeSelector).
+				Build()
+			d := getNewResource(t, fakeClient, nil)
+			require.NoError(t, d.Sleep(context.Background()))
+
+			list, err := d.getListByNamespace(context.Background(), namespace)
+			require.NoError(t, err)
+			require.Equal(t, map[string]map[string]string{
+				"ds1": {
+					SleepNodeSelectorKey: "true",
+				},
+				"ds-with-node-selector": {
+					"kubernetes.io/os":   "linux",
+					SleepNodeSelectorKey: "true",
+				},
+			}, getNodeSelectors(list))
+		})
+
+		t.Run("fails to patch daemonsets", func(t *testing.T) {
+			fakeClient := testutil.PossiblyErroringFakeCtrlRuntimeClient{
+				Client: fake.NewClientBuilder().WithRuntimeObjects(&daemonSet1).Build(),
+				ShouldError: func(method testutil.Method, obj runtime.Object) bool {
+					return method == testutil.Patch
+				},
+			}
+			d := getNewResource(t, fakeClient, nil)
+			require.EqualError(t, d.Sleep(context.Background()), "fails to patch DaemonSet.apps ds1: error during patch")
+		})
+	})
+
+	t.Run("WakeUp", func(t *testing.T) {
+		t.Run("not throws if no data", func(t *testing.T) {
+			d := getNewResource(t, fake.NewClientBuilder().Build(), nil)
+			require.NoError(t, d.WakeUp(context.Background()))
+		})
+
+		t.Run("restore the original node selector", func(t *testing.T) {
+			sleepingNotSavedDaemonSet := GetMock(MockSpec{
+				Name:      "ds-not-saved",
+				Namespace: namespace,
+				NodeSelector: map[string]string{
+					SleepNodeSelectorKey: "true",
+				},
+			})
+			fakeClient := fake.NewClientBuilder().
+				WithRuntimeObjects(&sleepingDaemonSet1, &sleepingDaemonSetWithNodeSelector, &sleepingNotSavedDaemonSet).
+				Build()
+			d := getNewResource(t, fakeClient, map[string]map[string]string{
+				"ds1": nil,
+				"ds-with-node-selector": {
+					"kubernetes.io/os": "linux",
+				},
+			})
+			require.NoError(t, d.WakeUp(context.Background()))
+
+			list, err := d.getListByNamespace(context.Background(), namespace)
+			require.NoError(t, err)
+			require.Equal(t, map[string]map[string]string{
+				"ds1": nil,
+				"ds-with-node-selector": {
+					"kubernetes.io/os": "linux",
+				},
+				"ds-not-saved": {
+					SleepNodeSelectorKey: "true",
+				},
+			}, getNodeSelectors(list))
+		})
+
+		t.Run("fails to wake up", func(t *testing.T) {
+			fakeClient := testutil.PossiblyErroringFakeCtrlRuntimeClient{
+				Client: fake.NewClientBuilder().WithRuntimeObjects(&sleepingDaemonSet1).Build(),
+				ShouldError: func(method testutil.Method, obj runtime.Object) bool {
+					return method == testutil.Patch
+				},
+			}
+			d := getNewResource(t, fakeClient, map[string]map[string]string{
+				"ds1": nil,
+			})
+			require.EqualError(t, d.WakeUp(context.Background()), "fails to patch DaemonSet.apps ds1: error during patch")
+		})
+	})
+
+	t.Run("GetOriginalInfoToSave", func(t *testing.T) {
+		t.Run("returns nil if not to suspend", func(t *testing.T) {
+			d := getNewResource(t, fake.NewClientBuilder().Build(), nil)
+			d.areToSuspend = false
+			res, err := d.GetOriginalInfoToSave()
+			require.NoError(t, err)
+			require.Nil(t, res)
+		})
+
+		t.Run("with daemonsets", func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithRuntimeObjects(&daemonSet1, &daemonSetWithNodeSelector).
+				Build()
+			d := getNewResource(t, fakeClient, nil)
+			res, err := d.GetOriginalInfoToSave()
+			require.NoError(t, err)
+			require.JSONEq(t, `[{"name":"ds-with-node-selector","nodeSelector":{"kubernetes.io/os":"linux"}},{"name":"ds1"}]`, string(res))
+		})
+
+		t.Run("keeps daemonsets put to sleep by a previous sleep", func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithRuntimeObjects(&sleepingDaemonSet1, &sleepingDaemonSetWithNodeSelector).
+				Build()
+			d := getNewResource(t, fakeClient, map[string]map[string]string{
+				"ds-with-node-selector": {
+					"kubernetes.io/os": "linux",
+				},
+			})
+			res, err := d.GetOriginalInfoToSave()
+			require.NoError(t, err)
+			require.JSONEq(t, `[{"name":"ds-with-node-selector","nodeSelector":{"kubernetes.io/os":"linux"}}]`, string(res))
+		})
+	})
+
+	t.Run("GetOriginalInfoToRestore", func(t *testing.T) {
+		t.Run("if empty saved data, returns empty info", func(t *testing.T) {
+			info, err := GetOriginalInfoToRestore(nil)
+			require.NoError(t, err)
+			require.Equal(t, OriginalNodeSelectors{}, info)
+		})
+
+		t.Run("throws if data is not a correct json", func(t *testing.T) {
+			_, err := GetOriginalInfoToRestore([]byte("{}"))
+			require.EqualError(t, err, "json: cannot unmarshal object into Go value of type []daemonsets.OriginalDaemonSetInfo")
+		})
+
+		t.Run("correctly returns data", func(t *testing.T) {
+			info, err := GetOriginalInfoToRestore([]byte(`[{"name":"ds1"},{"name":"ds2","nodeSelector":{"kubernetes.io/os":"linux"}},{"name":""}]`))
+			require.NoError(t, err)
+			require.Equal(t, OriginalNodeSelectors{
+				"ds1": nil,
+				"ds2": {
+					"kubernetes.io/os": "linux",
+				},
+			}, info)
+		})
+	})
+}
+
+func getNodeSelectors(list []appsv1.DaemonSet) map[string]map[string]string {
+	nodeSelectors := map[string]map[string]string{}
+	for _, daemonSet := range list {
+		nodeSelectors[daemonSet.Name] = daemonSet.Spec.Template.Spec.NodeSelector
+	}
+	return nodeSelectors
+}
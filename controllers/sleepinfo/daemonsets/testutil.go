@@ -0,0 +1,55 @@
+package daemonsets
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type MockSpec struct {
+	Namespace       string
+	Name            string
+	Labels          map[string]string
+	ResourceVersion string
+	NodeSelector    map[string]string
+	OwnerReferences []metav1.OwnerReference
+}
+
+func GetMock(opts MockSpec) appsv1.DaemonSet {
+	return appsv1.DaemonSet{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "DaemonSet",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            opts.Name,
+			Namespace:       opts.Namespace,
+			ResourceVersion: opts.ResourceVersion,
+			Labels:          opts.Labels,
+			OwnerReferences: opts.OwnerReferences,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": opts.Name,
+				},
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": opts.Name,
+					},
+				},
+				Spec: v1.PodSpec{
+					NodeSelector: opts.NodeSelector,
+					Containers: []v1.Container{
+						{
+							Name:  "container",
+							Image: "my-image",
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,67 @@
+package sleepinfo
+
+import (
+	"context"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/deployments"
+
+	"github.com/go-logr/logr"
+)
+
+// handleDeferredOperations puts to sleep the Deployments whose sleep is
+// deferred by their annotation while the namespace is sleeping, and wakes up
+// the ones whose wake up is deferred while the namespace is awake, given the
+// time of the last operation of the namespace. It returns the time of the next
+// deferred operation, or the zero time if there is none. A failure is only
+// logged, so it is retried at the next reconcile.
+func (r *SleepInfoReconciler) handleDeferredOperations(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo, isSleeping bool, originalReplicas map[string]int32, lastOperation, now time.Time) time.Time {
+	if !sleepInfo.IsDeploymentsToSuspend() {
+		return time.Time{}
+	}
+	location := time.UTC
+	if timeZone := r.getSleepInfoWithDefaults(sleepInfo).Spec.TimeZone; timeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(timeZone); err != nil {
+			log.Error(err, "invalid time zone, deferred operations skipped")
+			return time.Time{}
+		}
+	}
+
+	resourceClient, err := r.getResourceClient(log, sleepInfo)
+	if err != nil {
+		log.Error(err, "fails to get the client of the resources, deferred operations skipped")
+		return time.Time{}
+	}
+	if isSleeping {
+		names, next, err := deployments.SleepDeferred(ctx, resourceClient, sleepInfo.Namespace, originalReplicas, location, lastOperation, now)
+		if err != nil {
+			log.Error(err, "fails to put to sleep deferred deployments")
+		}
+		if len(names) > 0 {
+			log.Info("deferred deployments put to sleep", "deployments", names)
+		}
+		return next
+	}
+	names, next, err := deployments.WakeUpDeferred(ctx, resourceClient, sleepInfo.Namespace, location, lastOperation, now)
+	if err != nil {
+		log.Error(err, "fails to wake up deferred deployments")
+	}
+	if len(names) > 0 {
+		log.Info("deferred deployments woken up", "deployments", names)
+	}
+	return next
+}
+
+// getRequeueAfterDeferred returns the requeue after, shortened to the next
+// deferred operation if it comes before.
+func getRequeueAfterDeferred(requeueAfter time.Duration, nextDeferred, now time.Time) time.Duration {
+	if nextDeferred.IsZero() {
+		return requeueAfter
+	}
+	if untilDeferred := nextDeferred.Sub(now); untilDeferred < requeueAfter {
+		return untilDeferred
+	}
+	return requeueAfter
+}
@@ -0,0 +1,16 @@
+package sleepinfo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRequeueAfterDeferred(t *testing.T) {
+	now := time.Date(2021, 3, 24, 20, 0, 0, 0, time.UTC)
+
+	require.Equal(t, time.Hour, getRequeueAfterDeferred(time.Hour, time.Time{}, now))
+	require.Equal(t, 30*time.Minute, getRequeueAfterDeferred(time.Hour, now.Add(30*time.Minute), now))
+	require.Equal(t, time.Hour, getRequeueAfterDeferred(time.Hour, now.Add(2*time.Hour), now))
+}
@@ -0,0 +1,175 @@
+package sleepinfo
+
+import (
+	"context"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/audit"
+	"github.com/kube-green/kube-green/pkg/schedule"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WakeUpRequestedAtAnnotation requests the wake up of a sleeping SleepInfo
+// before its wake up schedule. It is set, to the RFC3339 time of the request,
+// on the dependencies and on the sleep group members of a SleepInfo when it
+// wakes up, and on the sleep group members when the sleep of one of them fails.
+const WakeUpRequestedAtAnnotation = "kube-green.dev/wake-up-requested-at"
+
+// WakeUpRequestedByScheduleAtAnnotation is set by kube-green, together with
+// the WakeUpRequestedAtAnnotation, to the time of the wake up it requests
+// itself, e.g. of the dependencies of a SleepInfo woken up by its schedule.
+// The request is audited with the schedule actor only while the two match:
+// a request overwritten by a user is manual.
+const WakeUpRequestedByScheduleAtAnnotation = "kube-green.dev/wake-up-requested-by-schedule-at"
+
+// requestDependenciesWakeUp requests the wake up of the sleeping SleepInfo
+// listed in the dependsOn of the SleepInfo or members of its sleep group. A
+// failure is only logged, so it does not block the wake up.
+func (r *SleepInfoReconciler) requestDependenciesWakeUp(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time) {
+	if len(sleepInfo.Spec.DependsOn) == 0 && sleepInfo.Spec.SleepGroup == "" {
+		return
+	}
+	sleepInfos := kubegreenv1alpha1.SleepInfoList{}
+	if err := r.Client.List(ctx, &sleepInfos); err != nil {
+		log.Error(err, "fails to list sleepinfos to wake up the dependencies")
+		return
+	}
+	for _, dependency := range sleepInfos.Items {
+		dependency := dependency
+		if dependency.Status.OperationType != sleepOperation {
+			continue
+		}
+		reason := ""
+		switch {
+		case sleepInfo.HasDependency(dependency):
+			reason = "DependencyWakeUpRequested"
+		case sleepInfo.IsInSleepGroupOf(dependency):
+			reason = "SleepGroupWakeUpRequested"
+		default:
+			continue
+		}
+		dependencyKey := client.ObjectKeyFromObject(&dependency)
+		if err := r.requestWakeUp(ctx, &dependency, now); err != nil {
+			log.Error(err, "fails to request the wake up of the dependency", "dependency", dependencyKey)
+			continue
+		}
+		log.Info("wake up of the dependency requested", "dependency", dependencyKey)
+		if r.Recorder != nil {
+			r.Recorder.Eventf(sleepInfo, v1.EventTypeNormal, reason, "Wake up of SleepInfo %s requested", dependencyKey)
+		}
+	}
+}
+
+// rollbackSleepGroup requests the wake up of all the members of the sleep
+// group of the SleepInfo, itself included, after its sleep failed: the group
+// is woken up again as a unit, and its members still awake skip the sleep.
+// A failure is only logged.
+func (r *SleepInfoReconciler) rollbackSleepGroup(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time) {
+	if sleepInfo.Spec.SleepGroup == "" {
+		return
+	}
+	sleepInfos := kubegreenv1alpha1.SleepInfoList{}
+	if err := r.Client.List(ctx, &sleepInfos); err != nil {
+		log.Error(err, "fails to list sleepinfos to roll back the sleep group", "sleepGroup", sleepInfo.Spec.SleepGroup)
+		return
+	}
+	for _, member := range sleepInfos.Items {
+		member := member
+		isSelf := member.Namespace == sleepInfo.Namespace && member.Name == sleepInfo.Name
+		if !isSelf && !sleepInfo.IsInSleepGroupOf(member) {
+			continue
+		}
+		if err := r.requestWakeUp(ctx, &member, now); err != nil {
+			log.Error(err, "fails to roll back the sleep of the sleep group member", "member", client.ObjectKeyFromObject(&member))
+		}
+	}
+	log.Info("sleep of the sleep group rolled back", "sleepGroup", sleepInfo.Spec.SleepGroup)
+	if r.Recorder != nil {
+		r.Recorder.Eventf(sleepInfo, v1.EventTypeNormal, "SleepGroupRolledBack", "Sleep failed, sleep group %s woken up again", sleepInfo.Spec.SleepGroup)
+	}
+}
+
+// requestWakeUp sets the WakeUpRequestedAtAnnotation on the SleepInfo,
+// marking the request as made by kube-green.
+func (r *SleepInfoReconciler) requestWakeUp(ctx context.Context, sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time) error {
+	return annotate(ctx, r.Client, sleepInfo, map[string]string{
+		WakeUpRequestedAtAnnotation:           now.Format(time.RFC3339),
+		WakeUpRequestedByScheduleAtAnnotation: now.Format(time.RFC3339),
+	})
+}
+
+// RequestWakeUp requests the wake up of the SleepInfo, setting the
+// WakeUpRequestedAtAnnotation to now.
+func RequestWakeUp(ctx context.Context, c client.Writer, sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time) error {
+	return annotate(ctx, c, sleepInfo, map[string]string{
+		WakeUpRequestedAtAnnotation: now.Format(time.RFC3339),
+	})
+}
+
+func annotate(ctx context.Context, c client.Writer, sleepInfo *kubegreenv1alpha1.SleepInfo, annotations map[string]string) error {
+	patch := client.MergeFrom(sleepInfo.DeepCopy())
+	if sleepInfo.Annotations == nil {
+		sleepInfo.Annotations = map[string]string{}
+	}
+	for key, value := range annotations {
+		sleepInfo.Annotations[key] = value
+	}
+	return c.Patch(ctx, sleepInfo, patch)
+}
+
+// getWakeUpRequestedAt returns when the wake up of the SleepInfo has been
+// requested, and false if it has not.
+func getWakeUpRequestedAt(sleepInfo *kubegreenv1alpha1.SleepInfo) (time.Time, bool) {
+	value, ok := sleepInfo.Annotations[WakeUpRequestedAtAnnotation]
+	if !ok {
+		return time.Time{}, false
+	}
+	requestedAt, err := time.Parse(time.RFC3339, value)
+	return requestedAt, err == nil
+}
+
+// getWakeUpRequestedBy returns the audit actor of the requested wake up.
+func getWakeUpRequestedBy(sleepInfo *kubegreenv1alpha1.SleepInfo) string {
+	requestedAt, ok := sleepInfo.Annotations[WakeUpRequestedAtAnnotation]
+	if ok && sleepInfo.Annotations[WakeUpRequestedByScheduleAtAnnotation] == requestedAt {
+		return audit.ActorSchedule
+	}
+	return audit.ActorManual
+}
+
+// isWakeUpRequested returns true if the SleepInfo is sleeping and its wake up
+// has been requested since it went to sleep. The request can be at the same
+// time as the sleep, when the sleep is rolled back as soon as it fails.
+func isWakeUpRequested(sleepInfo *kubegreenv1alpha1.SleepInfo, data SleepInfoData) bool {
+	if !data.IsWakeUpOperation() {
+		return false
+	}
+	requestedAt, ok := getWakeUpRequestedAt(sleepInfo)
+	return ok && !requestedAt.Before(data.LastSchedule)
+}
+
+// isSleepGroupRolledBack returns true if the SleepInfo is awake and the sleep
+// of its sleep group has been rolled back since it woke up.
+func isSleepGroupRolledBack(sleepInfo *kubegreenv1alpha1.SleepInfo, data SleepInfoData) bool {
+	if !data.IsSleepOperation() || sleepInfo.Spec.SleepGroup == "" {
+		return false
+	}
+	requestedAt, ok := getWakeUpRequestedAt(sleepInfo)
+	return ok && requestedAt.After(data.LastSchedule)
+}
+
+// getNextScheduleAfterWakeUp returns the next schedule and the time to requeue
+// after, for a wake up executed at now on request, or for the sleep at the end
+// of a time-boxed wake up.
+func (r *SleepInfoReconciler) getNextScheduleAfterWakeUp(data SleepInfoData, now time.Time) (time.Time, time.Duration, error) {
+	scheduleDelta := time.Duration(r.SleepDelta) * time.Second
+	nextSchedule, err := schedule.NextAfterExecution(data.NextOperationSchedule, now, scheduleDelta)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return nextSchedule, getRequeueAfter(nextSchedule, now), nil
+}
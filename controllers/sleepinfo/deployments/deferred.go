@@ -0,0 +1,155 @@
+package deployments
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/internal/tracing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// DeferredReplicasAnnotation is set on the Deployments whose wake up is
+// deferred by the WakeUpAtAnnotation, with the replicas to restore.
+const DeferredReplicasAnnotation = "kube-green.dev/deferred-replicas"
+
+// isSleepDeferred returns true if the sleep of the Deployment is deferred by
+// the SleepAtAnnotation, recording an event which explains the skip.
+func (d deployments) isSleepDeferred(deployment appsv1.Deployment) bool {
+	value, ok := d.getTimeOfDayAnnotation(deployment, resource.SleepAtAnnotation)
+	if !ok {
+		return false
+	}
+	d.Log.Info("deployment sleep deferred", "deployment", deployment.Name, "namespace", deployment.Namespace, "sleepAt", value)
+	d.Eventf(&deployment, v1.EventTypeNormal, "SleepDeferred", "Sleep deferred to %s by the %s annotation", value, resource.SleepAtAnnotation)
+	return true
+}
+
+// isWakeUpDeferred returns true if the wake up of the Deployment is deferred
+// by the WakeUpAtAnnotation, recording an event which explains the skip.
+func (d deployments) isWakeUpDeferred(deployment appsv1.Deployment) bool {
+	value, ok := d.getTimeOfDayAnnotation(deployment, resource.WakeUpAtAnnotation)
+	if !ok {
+		return false
+	}
+	d.Log.Info("deployment wake up deferred", "deployment", deployment.Name, "namespace", deployment.Namespace, "wakeUpAt", value)
+	d.Eventf(&deployment, v1.EventTypeNormal, "WakeUpDeferred", "Wake up deferred to %s by the %s annotation", value, resource.WakeUpAtAnnotation)
+	return true
+}
+
+// getTimeOfDayAnnotation returns the value of the annotation, if it is a
+// valid HH:mm time.
+func (d deployments) getTimeOfDayAnnotation(deployment appsv1.Deployment, annotation string) (string, bool) {
+	value, ok := deployment.Annotations[annotation]
+	if !ok {
+		return "", false
+	}
+	if _, err := resource.GetNextTimeOfDay(value, d.Now(), time.UTC); err != nil {
+		d.Log.Info("invalid time annotation, ignored", "deployment", deployment.Name, "annotation", annotation, "value", value)
+		return "", false
+	}
+	return value, true
+}
+
+// getDeferredReplicas returns the replicas of the Deployment whose wake up is
+// deferred, or 0 if it is not deferred.
+func getDeferredReplicas(deployment appsv1.Deployment) int32 {
+	replicas, err := strconv.ParseInt(deployment.Annotations[DeferredReplicasAnnotation], 10, 32)
+	if err != nil {
+		return 0
+	}
+	return int32(replicas)
+}
+
+// SleepDeferred puts to sleep the Deployments of the namespace whose sleep is
+// deferred by the SleepAtAnnotation, once the first HH:mm after the sleep of
+// the namespace is passed. Only the Deployments whose replicas are saved in
+// originalReplicas are put to sleep, so that they are restored by the wake
+// up. It returns the names of the Deployments put to sleep, and the time of
+// the next deferred sleep, or the zero time if there is none.
+func SleepDeferred(ctx context.Context, res resource.ResourceClient, namespace string, originalReplicas map[string]int32, location *time.Location, sleptAt, now time.Time) (names []string, next time.Time, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "deployments.sleepDeferred")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	d := deployments{ResourceClient: res, namespace: namespace}
+	deploymentList, err := d.getListByNamespace(ctx, namespace)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	names = []string{}
+	for _, deployment := range d.filterExcludedDeployment(deploymentList) {
+		deployment := deployment
+		value, ok := deployment.Annotations[resource.SleepAtAnnotation]
+		sleepReplicas := res.SleepInfo.GetSleepReplicas(deployment.Labels)
+		if !ok || deployment.Spec.Replicas == nil || *deployment.Spec.Replicas <= sleepReplicas {
+			continue
+		}
+		if res.SleepInfo.GetSleepStrategy(deployment.Annotations) != kubegreenv1alpha1.SleepStrategyScaleToZero {
+			continue
+		}
+		sleepAt, err := resource.GetNextTimeOfDay(value, sleptAt, location)
+		if err != nil {
+			continue
+		}
+		if sleepAt.After(now) {
+			if next.IsZero() || sleepAt.Before(next) {
+				next = sleepAt
+			}
+			continue
+		}
+		if _, ok := originalReplicas[deployment.Name]; !ok {
+			continue
+		}
+		newDeploy := deployment.DeepCopy()
+		*newDeploy.Spec.Replicas = sleepReplicas
+		if err := d.Patch(ctx, &deployment, newDeploy); err != nil {
+			return names, next, err
+		}
+		names = append(names, deployment.Name)
+	}
+	return names, next, nil
+}
+
+// WakeUpDeferred restores the replicas of the Deployments of the namespace
+// whose wake up is deferred by the WakeUpAtAnnotation, once the first HH:mm
+// after the wake up of the namespace is passed. It returns the names of the
+// Deployments woken up, and the time of the next deferred wake up, or the zero
+// time if there is none.
+func WakeUpDeferred(ctx context.Context, res resource.ResourceClient, namespace string, location *time.Location, wokenUpAt, now time.Time) (names []string, next time.Time, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "deployments.wakeUpDeferred")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	d := deployments{ResourceClient: res, namespace: namespace}
+	deploymentList, err := d.getListByNamespace(ctx, namespace)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	names = []string{}
+	for _, deployment := range d.filterExcludedDeployment(deploymentList) {
+		deployment := deployment
+		if _, ok := deployment.Annotations[DeferredReplicasAnnotation]; !ok || deployment.Spec.Replicas == nil {
+			continue
+		}
+		// without a valid wake up time, the Deployment is woken up immediately.
+		if wakeUpAt, err := resource.GetNextTimeOfDay(deployment.Annotations[resource.WakeUpAtAnnotation], wokenUpAt, location); err == nil && wakeUpAt.After(now) {
+			if next.IsZero() || wakeUpAt.Before(next) {
+				next = wakeUpAt
+			}
+			continue
+		}
+		newDeploy := deployment.DeepCopy()
+		delete(newDeploy.Annotations, DeferredReplicasAnnotation)
+		if *deployment.Spec.Replicas == res.SleepInfo.GetSleepReplicas(deployment.Labels) {
+			*newDeploy.Spec.Replicas = getDeferredReplicas(deployment)
+		}
+		if err := d.Patch(ctx, &deployment, newDeploy); err != nil {
+			return names, next, err
+		}
+		names = append(names, deployment.Name)
+	}
+	return names, next, nil
+}
@@ -0,0 +1,166 @@
+package deployments
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestDeferredOperations(t *testing.T) {
+	testLogger := zap.New(zap.UseDevMode(true))
+
+	ctx := context.Background()
+	namespace := "my-namespace"
+	// the namespace sleeps at 20:00 and wakes up at 08:00.
+	sleptAt := time.Date(2021, 3, 24, 20, 0, 0, 0, time.UTC)
+	wokenUpAt := time.Date(2021, 3, 25, 8, 0, 0, 0, time.UTC)
+
+	getDeployment := func(name string, replicas int32, annotations map[string]string) appsv1.Deployment {
+		deployment := GetMock(MockSpec{
+			Namespace: namespace,
+			Name:      name,
+			Replicas:  getPtr(replicas),
+		})
+		deployment.Annotations = annotations
+		return deployment
+	}
+	getReplicas := func(t *testing.T, c client.Client, name string) (int32, map[string]string) {
+		t.Helper()
+		deployment := appsv1.Deployment{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, &deployment))
+		return *deployment.Spec.Replicas, deployment.Annotations
+	}
+	newResourceClient := func(c client.Client) resource.ResourceClient {
+		return resource.ResourceClient{
+			Client:    c,
+			Log:       testLogger,
+			SleepInfo: &v1alpha1.SleepInfo{},
+			Recorder:  record.NewFakeRecorder(10),
+		}
+	}
+
+	t.Run("sleep skips the deployments with the sleep-at annotation", func(t *testing.T) {
+		worker := getDeployment("worker", 2, map[string]string{resource.SleepAtAnnotation: "23:00"})
+		api := getDeployment("api", 3, nil)
+		c := fake.NewClientBuilder().WithRuntimeObjects(&worker, &api).Build()
+		recorder := record.NewFakeRecorder(1)
+		resourceClient := newResourceClient(c)
+		resourceClient.Recorder = recorder
+		r, err := NewResource(ctx, resourceClient, namespace, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, r.Sleep(ctx))
+
+		replicas, _ := getReplicas(t, c, worker.Name)
+		require.Equal(t, int32(2), replicas)
+		replicas, _ = getReplicas(t, c, api.Name)
+		require.Equal(t, int32(0), replicas)
+		require.Equal(t, "Normal SleepDeferred Sleep deferred to 23:00 by the kube-green.dev/sleep-at annotation", <-recorder.Events)
+	})
+
+	t.Run("sleep the deferred deployments when due", func(t *testing.T) {
+		worker := getDeployment("worker", 2, map[string]string{resource.SleepAtAnnotation: "23:00"})
+		later := getDeployment("later", 2, map[string]string{resource.SleepAtAnnotation: "23:30"})
+		notSaved := getDeployment("not-saved", 2, map[string]string{resource.SleepAtAnnotation: "21:00"})
+		invalid := getDeployment("invalid", 2, map[string]string{resource.SleepAtAnnotation: "late"})
+		c := fake.NewClientBuilder().WithRuntimeObjects(&worker, &later, &notSaved, &invalid).Build()
+		originalReplicas := map[string]int32{worker.Name: 2, later.Name: 2, invalid.Name: 2}
+
+		names, next, err := SleepDeferred(ctx, newResourceClient(c), namespace, originalReplicas, time.UTC, sleptAt, sleptAt.Add(time.Hour))
+		require.NoError(t, err)
+		require.Empty(t, names)
+		require.Equal(t, time.Date(2021, 3, 24, 23, 0, 0, 0, time.UTC), next)
+
+		names, next, err = SleepDeferred(ctx, newResourceClient(c), namespace, originalReplicas, time.UTC, sleptAt, sleptAt.Add(3*time.Hour+10*time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, []string{worker.Name}, names)
+		require.Equal(t, time.Date(2021, 3, 24, 23, 30, 0, 0, time.UTC), next)
+
+		replicas, _ := getReplicas(t, c, worker.Name)
+		require.Equal(t, int32(0), replicas)
+		// the replicas of the deployment are not saved, so it is not restored by the wake up.
+		replicas, _ = getReplicas(t, c, notSaved.Name)
+		require.Equal(t, int32(2), replicas)
+		replicas, _ = getReplicas(t, c, invalid.Name)
+		require.Equal(t, int32(2), replicas)
+	})
+
+	t.Run("the sleep-at is in the time zone", func(t *testing.T) {
+		location, err := time.LoadLocation("Europe/Rome")
+		require.NoError(t, err)
+		worker := getDeployment("worker", 2, map[string]string{resource.SleepAtAnnotation: "23:00"})
+		c := fake.NewClientBuilder().WithRuntimeObjects(&worker).Build()
+
+		names, next, err := SleepDeferred(ctx, newResourceClient(c), namespace, map[string]int32{worker.Name: 2}, location, sleptAt, sleptAt.Add(time.Hour))
+		require.NoError(t, err)
+		require.Empty(t, names)
+		require.True(t, time.Date(2021, 3, 24, 22, 0, 0, 0, time.UTC).Equal(next))
+	})
+
+	t.Run("wake up defers the deployments with the wake-up-at annotation", func(t *testing.T) {
+		worker := getDeployment("worker", 0, map[string]string{resource.WakeUpAtAnnotation: "10:00"})
+		api := getDeployment("api", 0, nil)
+		c := fake.NewClientBuilder().WithRuntimeObjects(&worker, &api).Build()
+		recorder := record.NewFakeRecorder(1)
+		resourceClient := newResourceClient(c)
+		resourceClient.Recorder = recorder
+		r, err := NewResource(ctx, resourceClient, namespace, map[string]int32{worker.Name: 2, api.Name: 3})
+		require.NoError(t, err)
+
+		require.NoError(t, r.WakeUp(ctx))
+
+		replicas, annotations := getReplicas(t, c, worker.Name)
+		require.Equal(t, int32(0), replicas)
+		require.Equal(t, "2", annotations[DeferredReplicasAnnotation])
+		replicas, _ = getReplicas(t, c, api.Name)
+		require.Equal(t, int32(3), replicas)
+		require.Equal(t, "Normal WakeUpDeferred Wake up deferred to 10:00 by the kube-green.dev/wake-up-at annotation", <-recorder.Events)
+
+		t.Run("the deferred replicas are saved by the next sleep", func(t *testing.T) {
+			r, err := NewResource(ctx, newResourceClient(c), namespace, nil)
+			require.NoError(t, err)
+			originalReplicas, err := r.GetOriginalInfoToSave()
+			require.NoError(t, err)
+			require.JSONEq(t, `[{"name":"api","replicas":3},{"name":"worker","replicas":2}]`, string(originalReplicas))
+		})
+
+		t.Run("wake up the deferred deployments when due", func(t *testing.T) {
+			names, next, err := WakeUpDeferred(ctx, newResourceClient(c), namespace, time.UTC, wokenUpAt, wokenUpAt.Add(time.Hour))
+			require.NoError(t, err)
+			require.Empty(t, names)
+			require.Equal(t, time.Date(2021, 3, 25, 10, 0, 0, 0, time.UTC), next)
+
+			names, next, err = WakeUpDeferred(ctx, newResourceClient(c), namespace, time.UTC, wokenUpAt, wokenUpAt.Add(2*time.Hour))
+			require.NoError(t, err)
+			require.Equal(t, []string{worker.Name}, names)
+			require.True(t, next.IsZero())
+
+			replicas, annotations := getReplicas(t, c, worker.Name)
+			require.Equal(t, int32(2), replicas)
+			require.NotContains(t, annotations, DeferredReplicasAnnotation)
+		})
+	})
+
+	t.Run("wake up immediately without a valid wake-up-at annotation", func(t *testing.T) {
+		worker := getDeployment("worker", 0, map[string]string{DeferredReplicasAnnotation: "2"})
+		c := fake.NewClientBuilder().WithRuntimeObjects(&worker).Build()
+
+		names, next, err := WakeUpDeferred(ctx, newResourceClient(c), namespace, time.UTC, wokenUpAt, wokenUpAt)
+		require.NoError(t, err)
+		require.Equal(t, []string{worker.Name}, names)
+		require.True(t, next.IsZero())
+
+		replicas, _ := getReplicas(t, c, worker.Name)
+		require.Equal(t, int32(2), replicas)
+	})
+}
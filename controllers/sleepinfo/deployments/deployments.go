@@ -3,16 +3,35 @@ package deployments
 import (
 	"context"
 	"encoding/json"
+	"strconv"
+	"time"
 
 	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
 	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/internal/tracing"
 
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	v1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// DesiredReplicasAnnotation declares the replicas a Deployment is restored to
+// on wake up, when the replicas source of the SleepInfo is Declared. It can be
+// set e.g. by the CI which deploys the Deployment.
+const DesiredReplicasAnnotation = "kube-green.dev/desired-replicas"
+
+// SleepingAnnotation is added to the Deployments put to sleep with the
+// pauseRollout, patchOnly or keepOne strategy, set to the strategy, so that the
+// wake up restores them even if their strategy changes during the sleep.
+const SleepingAnnotation = "kube-green.dev/sleeping"
+
 type deployments struct {
 	resource.ResourceClient
+	namespace        string
 	data             []appsv1.Deployment
 	OriginalReplicas map[string]int32
 	areToSuspend     bool
@@ -21,9 +40,10 @@ type deployments struct {
 func NewResource(ctx context.Context, res resource.ResourceClient, namespace string, originalReplicas map[string]int32) (resource.Resource, error) {
 	d := deployments{
 		ResourceClient:   res,
+		namespace:        namespace,
 		OriginalReplicas: originalReplicas,
 		data:             []appsv1.Deployment{},
-		areToSuspend:     res.SleepInfo.IsDeploymentsToSuspend(),
+		areToSuspend:     res.SleepInfo.IsDeploymentsToSuspend() || (res.IsWakeUp && len(originalReplicas) > 0),
 	}
 	if !d.areToSuspend {
 		return d, nil
@@ -39,16 +59,50 @@ func (d deployments) HasResource() bool {
 	return len(d.data) > 0
 }
 
-func (d deployments) Sleep(ctx context.Context) error {
+func (d deployments) GetResourceNames() []string {
+	names := []string{}
+	for _, deployment := range d.data {
+		names = append(names, deployment.Name)
+	}
+	return names
+}
+
+func (d deployments) Sleep(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "deployments.sleep", trace.WithAttributes(attribute.Int("resources.count", len(d.data))))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	for _, deployment := range d.data {
 		deployment := deployment
 
-		deploymentReplicas := *deployment.Spec.Replicas
-		if deploymentReplicas == 0 {
+		strategy := d.SleepInfo.GetSleepStrategy(deployment.Annotations)
+		sleepReplicas := d.SleepInfo.GetSleepReplicas(deployment.Labels)
+		if isAsleep(deployment, strategy, sleepReplicas) || d.IsKeptAwake(&deployment) {
+			continue
+		}
+		// the deferred sleep scales the Deployments to zero.
+		if strategy == kubegreenv1alpha1.SleepStrategyScaleToZero && d.isSleepDeferred(deployment) {
 			continue
 		}
+		if minAge := d.SleepInfo.GetMinAgeBeforeSleep(); minAge > 0 {
+			if age := d.Now().Sub(getLastUpdateTime(deployment)); age < minAge {
+				d.Log.Info("deployment updated recently, skip sleep", "deployment", deployment.Name, "namespace", deployment.Namespace, "age", age.String())
+				d.Eventf(&deployment, v1.EventTypeNormal, "SleepSkipped", "Sleep skipped: deployment updated %s ago, less than minAgeBeforeSleep %s", age.Round(time.Second), minAge)
+				continue
+			}
+		}
 		newDeploy := deployment.DeepCopy()
-		*newDeploy.Spec.Replicas = 0
+		switch strategy {
+		case kubegreenv1alpha1.SleepStrategyPauseRollout:
+			newDeploy.Spec.Paused = true
+			setSleepingAnnotation(newDeploy, strategy)
+		case kubegreenv1alpha1.SleepStrategyPatchOnly:
+			setSleepingAnnotation(newDeploy, strategy)
+		case kubegreenv1alpha1.SleepStrategyKeepOne:
+			*newDeploy.Spec.Replicas = 1
+			setSleepingAnnotation(newDeploy, strategy)
+		default:
+			*newDeploy.Spec.Replicas = sleepReplicas
+		}
 
 		if err := d.Patch(ctx, &deployment, newDeploy); err != nil {
 			return err
@@ -57,13 +111,73 @@ func (d deployments) Sleep(ctx context.Context) error {
 	return nil
 }
 
-func (d deployments) WakeUp(ctx context.Context) error {
+// isAsleep returns true if the Deployment is already asleep, as put to sleep
+// by the strategy. The Deployments paused by others are not handled by the
+// pauseRollout strategy.
+func isAsleep(deployment appsv1.Deployment, strategy string, sleepReplicas int32) bool {
+	_, isSleeping := deployment.Annotations[SleepingAnnotation]
+	switch strategy {
+	case kubegreenv1alpha1.SleepStrategyPauseRollout:
+		return isSleeping || deployment.Spec.Paused
+	case kubegreenv1alpha1.SleepStrategyPatchOnly:
+		return isSleeping
+	case kubegreenv1alpha1.SleepStrategyKeepOne:
+		return isSleeping || *deployment.Spec.Replicas <= 1
+	default:
+		return *deployment.Spec.Replicas <= sleepReplicas
+	}
+}
+
+func setSleepingAnnotation(deployment *appsv1.Deployment, strategy string) {
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	deployment.Annotations[SleepingAnnotation] = strategy
+}
+
+// wakeUpSleeping restores the Deployment put to sleep with the strategy of its
+// SleepingAnnotation. The canary kept by keepOne is scaled back to the saved
+// replicas, unless its replicas changed during the sleep.
+func (d deployments) wakeUpSleeping(ctx context.Context, deployment appsv1.Deployment) error {
+	newDeploy := deployment.DeepCopy()
+	switch deployment.Annotations[SleepingAnnotation] {
+	case kubegreenv1alpha1.SleepStrategyPauseRollout:
+		newDeploy.Spec.Paused = false
+	case kubegreenv1alpha1.SleepStrategyKeepOne:
+		if replica, ok := d.OriginalReplicas[deployment.Name]; ok && replica > 0 && *deployment.Spec.Replicas == 1 {
+			*newDeploy.Spec.Replicas = replica
+		}
+	}
+	delete(newDeploy.Annotations, SleepingAnnotation)
+	return d.Patch(ctx, &deployment, newDeploy)
+}
+
+func (d deployments) WakeUp(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "deployments.wakeUp", trace.WithAttributes(attribute.Int("resources.count", len(d.data))))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	replicasSource := d.SleepInfo.GetReplicasSource()
+	hpaMinReplicas := map[string]int32{}
+	if replicasSource != kubegreenv1alpha1.ReplicasSourceSnapshot && d.HasResource() {
+		if hpaMinReplicas, err = d.getHPAMinReplicas(ctx); err != nil {
+			return err
+		}
+	}
+
 	for _, deployment := range d.data {
 		deployment := deployment
 
 		deployLogger := d.Log.WithValues("deployment", deployment.Name, "namespace", deployment.Namespace)
-		if *deployment.Spec.Replicas != 0 {
-			deployLogger.Info("replicas not 0 during wake up")
+		if _, ok := deployment.Annotations[SleepingAnnotation]; ok {
+			if err := d.wakeUpSleeping(ctx, deployment); err != nil {
+				return err
+			}
+			continue
+		}
+		// the Deployments at zero replicas are always restored, also if the
+		// replicas of their sleep class changed during the sleep.
+		if replicas := *deployment.Spec.Replicas; replicas != 0 && replicas != d.SleepInfo.GetSleepReplicas(deployment.Labels) {
+			deployLogger.Info("replicas changed during the sleep, skip wake up")
 			continue
 		}
 
@@ -72,8 +186,30 @@ func (d deployments) WakeUp(ctx context.Context) error {
 			deployLogger.Info("original deploy info not correctly set")
 			continue
 		}
+		if replica == 0 && d.SleepInfo.GetAlreadyZeroPolicy() == kubegreenv1alpha1.AlreadyZeroKeep {
+			// the Deployment was already at zero replicas before the sleep.
+			continue
+		}
+		replica = getReplicasToRestore(deployLogger, replicasSource, deployment, replica, hpaMinReplicas)
+		if replica == 0 {
+			continue
+		}
 
+		// the patch is computed from the live Deployment, so the pod template
+		// changed during the sleep, e.g. by a rollout restart, is kept and its
+		// new revision is scaled up, instead of the revision put to sleep.
 		newDeploy := deployment.DeepCopy()
+		if d.isWakeUpDeferred(deployment) {
+			// the replicas are restored by WakeUpDeferred.
+			if newDeploy.Annotations == nil {
+				newDeploy.Annotations = map[string]string{}
+			}
+			newDeploy.Annotations[DeferredReplicasAnnotation] = strconv.Itoa(int(replica))
+			if err := d.Patch(ctx, &deployment, newDeploy); err != nil {
+				return err
+			}
+			continue
+		}
 		*newDeploy.Spec.Replicas = replica
 
 		if err := d.Patch(ctx, &deployment, newDeploy); err != nil {
@@ -83,7 +219,85 @@ func (d deployments) WakeUp(ctx context.Context) error {
 	return nil
 }
 
-func (d *deployments) fetch(ctx context.Context, namespace string) error {
+// getReplicasToRestore returns the replicas the Deployment is restored to from
+// the replicas source, given its saved replicas.
+func getReplicasToRestore(log logr.Logger, replicasSource string, deployment appsv1.Deployment, savedReplicas int32, hpaMinReplicas map[string]int32) int32 {
+	switch replicasSource {
+	case kubegreenv1alpha1.ReplicasSourceDeclared:
+		if declaredReplicas, ok := getDeclaredReplicas(log, deployment, hpaMinReplicas); ok {
+			return declaredReplicas
+		}
+	case kubegreenv1alpha1.ReplicasSourceSnapshotAtLeastMin:
+		if minReplicas, ok := hpaMinReplicas[deployment.Name]; ok && minReplicas > savedReplicas {
+			return minReplicas
+		}
+	case kubegreenv1alpha1.ReplicasSourceAutoscaler:
+		// the HorizontalPodAutoscaler does not scale the Deployments at zero
+		// replicas, so the wake up restores its minReplicas, and it scales
+		// them from there.
+		if minReplicas, ok := hpaMinReplicas[deployment.Name]; ok {
+			log.V(1).Info("deployment handed back to the autoscaler", "minReplicas", minReplicas)
+			return minReplicas
+		}
+	}
+	return savedReplicas
+}
+
+// getDeclaredReplicas returns the replicas declared for the Deployment: the
+// value of the DesiredReplicasAnnotation or, if not set, the minReplicas of the
+// HorizontalPodAutoscaler targeting it. It returns false if none is declared.
+func getDeclaredReplicas(log logr.Logger, deployment appsv1.Deployment, hpaMinReplicas map[string]int32) (int32, bool) {
+	if value, ok := deployment.Annotations[DesiredReplicasAnnotation]; ok {
+		replicas, err := strconv.ParseInt(value, 10, 32)
+		if err == nil && replicas >= 0 {
+			return int32(replicas), true
+		}
+		log.Info("invalid desired replicas annotation, ignored", "annotation", DesiredReplicasAnnotation, "value", value)
+	}
+	replicas, ok := hpaMinReplicas[deployment.Name]
+	return replicas, ok
+}
+
+// getHPAMinReplicas returns the minReplicas of the HorizontalPodAutoscalers in
+// the namespace, keyed by the name of the Deployment they target.
+func (d deployments) getHPAMinReplicas(ctx context.Context) (map[string]int32, error) {
+	hpaList := autoscalingv2.HorizontalPodAutoscalerList{}
+	if err := d.Client.List(ctx, &hpaList, &client.ListOptions{
+		Namespace: d.namespace,
+		Limit:     500,
+	}); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	minReplicas := map[string]int32{}
+	for _, hpa := range hpaList.Items {
+		target := hpa.Spec.ScaleTargetRef
+		if target.Kind != "Deployment" || target.Name == "" {
+			continue
+		}
+		// minReplicas defaults to 1 if not set.
+		minReplicas[target.Name] = 1
+		if hpa.Spec.MinReplicas != nil {
+			minReplicas[target.Name] = *hpa.Spec.MinReplicas
+		}
+	}
+	return minReplicas, nil
+}
+
+// getLastUpdateTime returns when the Deployment was last rolled out, i.e. the
+// last update of its Progressing condition, or its creation time.
+func getLastUpdateTime(deployment appsv1.Deployment) time.Time {
+	lastUpdateTime := deployment.CreationTimestamp.Time
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.LastUpdateTime.After(lastUpdateTime) {
+			lastUpdateTime = condition.LastUpdateTime.Time
+		}
+	}
+	return lastUpdateTime
+}
+
+func (d *deployments) fetch(ctx context.Context, namespace string) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "deployments.list")
+	defer func() { tracing.EndSpan(span, err) }()
 	log := d.Log.WithValues("namespace", namespace)
 
 	deploymentList, err := d.getListByNamespace(ctx, namespace)
@@ -91,7 +305,10 @@ func (d *deployments) fetch(ctx context.Context, namespace string) error {
 		return err
 	}
 	log.V(1).Info("deployments in namespace", "number of deployment", len(deploymentList))
-	d.data = d.filterExcludedDeployment(deploymentList)
+	d.data = deploymentList
+	if !d.IsWakeUp {
+		d.data = d.filterExcludedDeployment(deploymentList)
+	}
 	return nil
 }
 
@@ -118,6 +335,9 @@ func (d deployments) filterExcludedDeployment(deploymentList []appsv1.Deployment
 }
 
 func shouldExcludeDeployment(deployment appsv1.Deployment, sleepInfo *kubegreenv1alpha1.SleepInfo) bool {
+	if !sleepInfo.IsManagedByClass(deployment.Labels) {
+		return true
+	}
 	for _, exclusion := range sleepInfo.GetExcludeRef() {
 		if exclusion.Kind == "Deployment" && exclusion.APIVersion == "apps/v1" && exclusion.Name != "" && deployment.Name == exclusion.Name {
 			return true
@@ -125,6 +345,15 @@ func shouldExcludeDeployment(deployment appsv1.Deployment, sleepInfo *kubegreenv
 		if labelMatch(deployment.Labels, exclusion.MatchLabels) {
 			return true
 		}
+		if resource.IsExcludedByOwner("Deployment", &deployment, exclusion) {
+			return true
+		}
+		if resource.IsExcludedByNamePattern("Deployment", &deployment, exclusion) {
+			return true
+		}
+		if resource.IsExcludedByField("Deployment", &deployment, exclusion) {
+			return true
+		}
 	}
 
 	return false
@@ -147,9 +376,26 @@ func labelMatch(labels, matchLabels map[string]string) bool {
 	return matched
 }
 
+// RevisionAnnotation is the revision of the Deployment, increased by
+// Kubernetes on each change of its pod template.
+const RevisionAnnotation = "deployment.kubernetes.io/revision"
+
+// RestartedAtAnnotation is set on the pod template by kubectl rollout restart,
+// to the time of the restart.
+const RestartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
 type OriginalReplicas struct {
 	Name     string `json:"name"`
 	Replicas int32  `json:"replicas"`
+	// Revision is the revision of the Deployment when put to sleep, to find
+	// the changes of its pod template during the sleep.
+	Revision string `json:"revision,omitempty"`
+	// RestartedAt is the time of the last rollout restart of the Deployment
+	// when put to sleep, to find the restarts during the sleep.
+	RestartedAt string `json:"restartedAt,omitempty"`
+	// AlreadyZero is true if the Deployment was already at zero replicas
+	// before the sleep, so that the wake up does not scale it up.
+	AlreadyZero bool `json:"alreadyZero,omitempty"`
 }
 
 func (d deployments) GetOriginalInfoToSave() ([]byte, error) {
@@ -165,11 +411,20 @@ func (d deployments) GetOriginalInfoToSave() ([]byte, error) {
 			}
 		}
 		if originalReplicas == 0 {
+			originalReplicas = getDeferredReplicas(deployment)
+		}
+		if originalReplicas == 0 {
+			originalDeploymentsReplicas = append(originalDeploymentsReplicas, OriginalReplicas{
+				Name:        deployment.Name,
+				AlreadyZero: true,
+			})
 			continue
 		}
 		originalDeploymentsReplicas = append(originalDeploymentsReplicas, OriginalReplicas{
-			Name:     deployment.Name,
-			Replicas: originalReplicas,
+			Name:        deployment.Name,
+			Replicas:    originalReplicas,
+			Revision:    deployment.Annotations[RevisionAnnotation],
+			RestartedAt: deployment.Spec.Template.Annotations[RestartedAtAnnotation],
 		})
 	}
 	return json.Marshal(originalDeploymentsReplicas)
@@ -3,6 +3,7 @@ package deployments
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/kube-green/kube-green/api/v1alpha1"
 	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
@@ -10,8 +11,10 @@ import (
 
 	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -187,6 +190,7 @@ func TestHasResource(t *testing.T) {
 		require.NoError(t, err)
 
 		require.False(t, d.HasResource())
+		require.Empty(t, d.GetResourceNames())
 	})
 
 	t.Run("with resource", func(t *testing.T) {
@@ -198,6 +202,7 @@ func TestHasResource(t *testing.T) {
 		require.NoError(t, err)
 
 		require.True(t, d.HasResource())
+		require.Equal(t, []string{"deployment1"}, d.GetResourceNames())
 	})
 }
 
@@ -276,6 +281,259 @@ func TestSleep(t *testing.T) {
 		}, list)
 	})
 
+	t.Run("skip deployments updated recently", func(t *testing.T) {
+		now := time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)
+		recentDeploy := GetMock(MockSpec{
+			Namespace: namespace,
+			Name:      "recent",
+			Replicas:  getPtr(int32(2)),
+		})
+		recentDeploy.Status.Conditions = []appsv1.DeploymentCondition{
+			{
+				Type:           appsv1.DeploymentProgressing,
+				LastUpdateTime: metav1.NewTime(now.Add(-10 * time.Minute)),
+			},
+		}
+		oldDeploy := GetMock(MockSpec{
+			Namespace: namespace,
+			Name:      "old",
+			Replicas:  getPtr(int32(2)),
+		})
+		oldDeploy.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour))
+		c := fake.NewClientBuilder().WithRuntimeObjects(&recentDeploy, &oldDeploy).Build()
+		recorder := record.NewFakeRecorder(10)
+
+		resource, err := NewResource(ctx, resource.ResourceClient{
+			Client:   c,
+			Log:      testLogger,
+			Recorder: recorder,
+			Clock:    fixedClock{now: now},
+			SleepInfo: &v1alpha1.SleepInfo{
+				Spec: v1alpha1.SleepInfoSpec{
+					SleepPolicy: &v1alpha1.SleepPolicy{
+						MinAgeBeforeSleep: &metav1.Duration{Duration: 30 * time.Minute},
+					},
+				},
+			},
+		}, namespace, map[string]int32{})
+		require.NoError(t, err)
+
+		require.NoError(t, resource.Sleep(ctx))
+
+		deployment := appsv1.Deployment{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "recent"}, &deployment))
+		require.Equal(t, int32(2), *deployment.Spec.Replicas)
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "old"}, &deployment))
+		require.Equal(t, int32(0), *deployment.Spec.Replicas)
+
+		require.Len(t, recorder.Events, 1)
+		require.Equal(t, "Normal SleepSkipped Sleep skipped: deployment updated 10m0s ago, less than minAgeBeforeSleep 30m0s", <-recorder.Events)
+	})
+
+	t.Run("skip deployments kept awake", func(t *testing.T) {
+		awakeDeploy := GetMock(MockSpec{
+			Namespace: namespace,
+			Name:      "awake",
+			Replicas:  getPtr(int32(2)),
+			PodAnnotations: map[string]string{
+				resource.AwakeUntilAnnotation: time.Now().Add(time.Hour).Format(time.RFC3339),
+			},
+		})
+		c := fake.NewClientBuilder().WithRuntimeObjects(&awakeDeploy, &d1).Build()
+
+		resource, err := NewResource(ctx, resource.ResourceClient{
+			Client:    c,
+			Log:       testLogger,
+			SleepInfo: emptySleepInfo,
+		}, namespace, map[string]int32{})
+		require.NoError(t, err)
+
+		require.NoError(t, resource.Sleep(ctx))
+
+		deployment := appsv1.Deployment{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "awake"}, &deployment))
+		require.Equal(t, int32(2), *deployment.Spec.Replicas)
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "d1"}, &deployment))
+		require.Equal(t, int32(0), *deployment.Spec.Replicas)
+	})
+
+	t.Run("put to sleep the deployments by class", func(t *testing.T) {
+		getClassDeploy := func(name, class string) appsv1.Deployment {
+			return GetMock(MockSpec{
+				Namespace: namespace,
+				Name:      name,
+				Replicas:  getPtr(int32(3)),
+				Labels:    map[string]string{v1alpha1.SleepClassLabel: class},
+			})
+		}
+		batch := getClassDeploy("batch", "batch")
+		web := getClassDeploy("web", "web")
+		critical := getClassDeploy("critical", "critical")
+		c := fake.NewClientBuilder().WithRuntimeObjects(&batch, &web, &critical, &d2).Build()
+		sleepInfo := &v1alpha1.SleepInfo{
+			Spec: v1alpha1.SleepInfoSpec{
+				Classes: []v1alpha1.SleepClass{
+					{Name: "batch"},
+					{Name: "web", Replicas: getPtr(int32(1))},
+					{Name: "critical", Skip: true},
+				},
+			},
+		}
+
+		res, err := NewResource(ctx, resource.ResourceClient{
+			Client:    c,
+			Log:       testLogger,
+			SleepInfo: sleepInfo,
+		}, namespace, map[string]int32{})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"batch", "web"}, res.GetResourceNames())
+
+		require.NoError(t, res.Sleep(ctx))
+
+		expectedReplicas := map[string]int32{"batch": 0, "web": 1, "critical": 3, "d2": 5}
+		for name, replicas := range expectedReplicas {
+			deployment := appsv1.Deployment{}
+			require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &deployment))
+			require.Equal(t, replicas, *deployment.Spec.Replicas, name)
+		}
+
+		res, err = NewResource(ctx, resource.ResourceClient{
+			Client:    c,
+			Log:       testLogger,
+			SleepInfo: sleepInfo,
+		}, namespace, map[string]int32{"batch": 3, "web": 3})
+		require.NoError(t, err)
+
+		require.NoError(t, res.WakeUp(ctx))
+
+		for _, name := range []string{"batch", "web"} {
+			deployment := appsv1.Deployment{}
+			require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &deployment))
+			require.Equal(t, int32(3), *deployment.Spec.Replicas, name)
+		}
+	})
+
+	t.Run("put to sleep the deployments by strategy", func(t *testing.T) {
+		getStrategyDeploy := func(name, strategy string) appsv1.Deployment {
+			deployment := GetMock(MockSpec{
+				Namespace: namespace,
+				Name:      name,
+				Replicas:  getPtr(int32(3)),
+			})
+			if strategy != "" {
+				deployment.Annotations = map[string]string{v1alpha1.SleepStrategyAnnotation: strategy}
+			}
+			return deployment
+		}
+		paused := getStrategyDeploy("paused", "")
+		patched := getStrategyDeploy("patched", v1alpha1.SleepStrategyPatchOnly)
+		scaled := getStrategyDeploy("scaled", v1alpha1.SleepStrategyScaleToZero)
+		pausedByOthers := getStrategyDeploy("paused-by-others", "")
+		pausedByOthers.Spec.Paused = true
+		c := fake.NewClientBuilder().WithRuntimeObjects(&paused, &patched, &scaled, &pausedByOthers).Build()
+		sleepInfo := &v1alpha1.SleepInfo{
+			Spec: v1alpha1.SleepInfoSpec{
+				SleepPolicy: &v1alpha1.SleepPolicy{Strategy: v1alpha1.SleepStrategyPauseRollout},
+			},
+		}
+
+		res, err := NewResource(ctx, resource.ResourceClient{
+			Client:    c,
+			Log:       testLogger,
+			SleepInfo: sleepInfo,
+		}, namespace, map[string]int32{})
+		require.NoError(t, err)
+		require.NoError(t, res.Sleep(ctx))
+
+		getDeployment := func(name string) appsv1.Deployment {
+			deployment := appsv1.Deployment{}
+			require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &deployment))
+			return deployment
+		}
+		deployment := getDeployment("paused")
+		require.True(t, deployment.Spec.Paused)
+		require.Equal(t, int32(3), *deployment.Spec.Replicas)
+		require.Equal(t, v1alpha1.SleepStrategyPauseRollout, deployment.Annotations[SleepingAnnotation])
+		deployment = getDeployment("patched")
+		require.False(t, deployment.Spec.Paused)
+		require.Equal(t, int32(3), *deployment.Spec.Replicas)
+		require.Equal(t, v1alpha1.SleepStrategyPatchOnly, deployment.Annotations[SleepingAnnotation])
+		deployment = getDeployment("scaled")
+		require.Equal(t, int32(0), *deployment.Spec.Replicas)
+		require.NotContains(t, deployment.Annotations, SleepingAnnotation)
+		deployment = getDeployment("paused-by-others")
+		require.NotContains(t, deployment.Annotations, SleepingAnnotation)
+
+		// the wake up restores the deployments also if the strategy changes during the sleep.
+		res, err = NewResource(ctx, resource.ResourceClient{
+			Client:    c,
+			Log:       testLogger,
+			SleepInfo: &v1alpha1.SleepInfo{},
+		}, namespace, map[string]int32{"paused": 3, "patched": 3, "scaled": 3, "paused-by-others": 3})
+		require.NoError(t, err)
+		require.NoError(t, res.WakeUp(ctx))
+
+		for _, name := range []string{"paused", "patched", "scaled"} {
+			deployment := getDeployment(name)
+			require.False(t, deployment.Spec.Paused, name)
+			require.Equal(t, int32(3), *deployment.Spec.Replicas, name)
+			require.NotContains(t, deployment.Annotations, SleepingAnnotation, name)
+		}
+		require.True(t, getDeployment("paused-by-others").Spec.Paused)
+	})
+
+	t.Run("keep one replica of the deployments as canary", func(t *testing.T) {
+		canary := GetMock(MockSpec{
+			Namespace: namespace,
+			Name:      "canary",
+			Replicas:  getPtr(int32(3)),
+		})
+		single := GetMock(MockSpec{
+			Namespace: namespace,
+			Name:      "single",
+			Replicas:  getPtr(int32(1)),
+		})
+		c := fake.NewClientBuilder().WithRuntimeObjects(&canary, &single).Build()
+		sleepInfo := &v1alpha1.SleepInfo{
+			Spec: v1alpha1.SleepInfoSpec{
+				SleepPolicy: &v1alpha1.SleepPolicy{Strategy: v1alpha1.SleepStrategyKeepOne},
+			},
+		}
+		getDeployment := func(name string) appsv1.Deployment {
+			deployment := appsv1.Deployment{}
+			require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &deployment))
+			return deployment
+		}
+
+		res, err := NewResource(ctx, resource.ResourceClient{
+			Client:    c,
+			Log:       testLogger,
+			SleepInfo: sleepInfo,
+		}, namespace, map[string]int32{})
+		require.NoError(t, err)
+		require.NoError(t, res.Sleep(ctx))
+
+		deployment := getDeployment("canary")
+		require.Equal(t, int32(1), *deployment.Spec.Replicas)
+		require.Equal(t, v1alpha1.SleepStrategyKeepOne, deployment.Annotations[SleepingAnnotation])
+		deployment = getDeployment("single")
+		require.Equal(t, int32(1), *deployment.Spec.Replicas)
+		require.NotContains(t, deployment.Annotations, SleepingAnnotation)
+
+		res, err = NewResource(ctx, resource.ResourceClient{
+			Client:    c,
+			Log:       testLogger,
+			SleepInfo: sleepInfo,
+		}, namespace, map[string]int32{"canary": 3, "single": 1})
+		require.NoError(t, err)
+		require.NoError(t, res.WakeUp(ctx))
+
+		deployment = getDeployment("canary")
+		require.Equal(t, int32(3), *deployment.Spec.Replicas)
+		require.NotContains(t, deployment.Annotations, SleepingAnnotation)
+		require.Equal(t, int32(1), *getDeployment("single").Spec.Replicas)
+	})
+
 	t.Run("fails to patch deployment", func(t *testing.T) {
 		c := fake.NewClientBuilder().WithRuntimeObjects(&d1, &d2, &dZeroReplicas).Build()
 		fakeClient := &testutil.PossiblyErroringFakeCtrlRuntimeClient{
@@ -292,7 +550,7 @@ func TestSleep(t *testing.T) {
 		}, namespace, map[string]int32{})
 		require.NoError(t, err)
 
-		require.EqualError(t, resource.Sleep(ctx), "error during patch")
+		require.EqualError(t, resource.Sleep(ctx), "fails to patch Deployment.apps d1: error during patch")
 	})
 
 	t.Run("not fails if deployments not found", func(t *testing.T) {
@@ -397,6 +655,70 @@ func TestWakeUp(t *testing.T) {
 		}, list)
 	})
 
+	t.Run("wake up deploy excluded during the sleep", func(t *testing.T) {
+		suspendDeployments := false
+		c := fake.NewClientBuilder().WithRuntimeObjects(&d1, &d2).Build()
+		r, err := NewResource(ctx, resource.ResourceClient{
+			Client: c,
+			Log:    testLogger,
+			SleepInfo: &v1alpha1.SleepInfo{
+				Spec: v1alpha1.SleepInfoSpec{
+					SuspendDeployments: &suspendDeployments,
+					ExcludeRef: []v1alpha1.ExcludeRef{
+						{APIVersion: "apps/v1", Kind: "Deployment", Name: d1.Name},
+					},
+				},
+			},
+			IsWakeUp: true,
+		}, namespace, map[string]int32{
+			d1.Name: replica1,
+			d2.Name: replica5,
+		})
+		require.NoError(t, err)
+
+		err = r.WakeUp(ctx)
+		require.NoError(t, err)
+
+		deployment := appsv1.Deployment{}
+		require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(&d1), &deployment))
+		require.Equal(t, replica1, *deployment.Spec.Replicas)
+		require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(&d2), &deployment))
+		require.Equal(t, replica5, *deployment.Spec.Replicas)
+	})
+
+	t.Run("wake up deploy restarted during the sleep", func(t *testing.T) {
+		restarted := d1.DeepCopy()
+		restarted.Annotations = map[string]string{RevisionAnnotation: "3"}
+		restarted.Spec.Template.Annotations = map[string]string{RestartedAtAnnotation: "2023-01-09T23:10:00Z"}
+		paused := d2.DeepCopy()
+		paused.Annotations = map[string]string{SleepingAnnotation: v1alpha1.SleepStrategyPauseRollout}
+		paused.Spec.Paused = true
+		paused.Spec.Replicas = &replica5
+		paused.Spec.Template.Annotations = map[string]string{RestartedAtAnnotation: "2023-01-09T23:10:00Z"}
+		c := fake.NewClientBuilder().WithRuntimeObjects(restarted, paused).Build()
+		r, err := NewResource(ctx, resource.ResourceClient{
+			Client:    c,
+			Log:       testLogger,
+			SleepInfo: emptySleepInfo,
+		}, namespace, map[string]int32{
+			d1.Name: replica5,
+			d2.Name: replica5,
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, r.WakeUp(ctx))
+
+		deployment := appsv1.Deployment{}
+		require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(restarted), &deployment))
+		require.Equal(t, replica5, *deployment.Spec.Replicas)
+		require.Equal(t, "3", deployment.Annotations[RevisionAnnotation])
+		require.Equal(t, restarted.Spec.Template, deployment.Spec.Template, "the restarted pod template is kept")
+
+		require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(paused), &deployment))
+		require.False(t, deployment.Spec.Paused, "the rollout of the restart is resumed")
+		require.Equal(t, paused.Spec.Template, deployment.Spec.Template, "the restarted pod template is kept")
+	})
+
 	t.Run("wake up fails", func(t *testing.T) {
 		c := testutil.PossiblyErroringFakeCtrlRuntimeClient{
 			Client: fake.NewClientBuilder().WithRuntimeObjects(&d1).Build(),
@@ -415,10 +737,176 @@ func TestWakeUp(t *testing.T) {
 		require.NoError(t, err)
 
 		err = r.WakeUp(ctx)
-		require.EqualError(t, err, "error during patch")
+		require.EqualError(t, err, "fails to patch Deployment.apps d1: error during patch")
 	})
 }
 
+func TestWakeUpDeclaredReplicas(t *testing.T) {
+	testLogger := zap.New(zap.UseDevMode(true))
+
+	ctx := context.Background()
+	namespace := "my-namespace"
+	var replica0 int32 = 0
+	var replica3 int32 = 3
+
+	withAnnotation := GetMock(MockSpec{
+		Namespace: namespace,
+		Name:      "with-annotation",
+		Replicas:  &replica0,
+	})
+	withAnnotation.Annotations = map[string]string{DesiredReplicasAnnotation: "4"}
+	withInvalidAnnotation := GetMock(MockSpec{
+		Namespace: namespace,
+		Name:      "with-invalid-annotation",
+		Replicas:  &replica0,
+	})
+	withInvalidAnnotation.Annotations = map[string]string{DesiredReplicasAnnotation: "many"}
+	withHPA := GetMock(MockSpec{
+		Namespace: namespace,
+		Name:      "with-hpa",
+		Replicas:  &replica0,
+	})
+	withHPADefaultMin := GetMock(MockSpec{
+		Namespace: namespace,
+		Name:      "with-hpa-default-min",
+		Replicas:  &replica0,
+	})
+	notDeclared := GetMock(MockSpec{
+		Namespace: namespace,
+		Name:      "not-declared",
+		Replicas:  &replica0,
+	})
+	alreadyZero := GetMock(MockSpec{
+		Namespace: namespace,
+		Name:      "already-zero",
+		Replicas:  &replica0,
+	})
+	alreadyZero.Annotations = map[string]string{DesiredReplicasAnnotation: "4"}
+	hpa := autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "hpa", Namespace: namespace},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       withHPA.Name,
+			},
+			MinReplicas: &replica3,
+			MaxReplicas: 10,
+		},
+	}
+	hpaDefaultMin := autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "hpa-default-min", Namespace: namespace},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       withHPADefaultMin.Name,
+			},
+			MaxReplicas: 10,
+		},
+	}
+	originalReplicas := map[string]int32{
+		withAnnotation.Name:        2,
+		withInvalidAnnotation.Name: 2,
+		withHPA.Name:               2,
+		withHPADefaultMin.Name:     2,
+		notDeclared.Name:           2,
+		alreadyZero.Name:           0,
+	}
+
+	tests := []struct {
+		name             string
+		replicasSource   string
+		alreadyZero      string
+		expectedReplicas map[string]int32
+	}{
+		{
+			name:           "restore the snapshot by default",
+			replicasSource: "",
+			expectedReplicas: map[string]int32{
+				withAnnotation.Name:        2,
+				withInvalidAnnotation.Name: 2,
+				withHPA.Name:               2,
+				withHPADefaultMin.Name:     2,
+				notDeclared.Name:           2,
+				alreadyZero.Name:           0,
+			},
+		},
+		{
+			name:           "restore the declared replicas",
+			replicasSource: v1alpha1.ReplicasSourceDeclared,
+			expectedReplicas: map[string]int32{
+				withAnnotation.Name:        4,
+				withInvalidAnnotation.Name: 2,
+				withHPA.Name:               3,
+				withHPADefaultMin.Name:     1,
+				notDeclared.Name:           2,
+				alreadyZero.Name:           0,
+			},
+		},
+		{
+			name:           "restore the snapshot at least to the min replicas",
+			replicasSource: v1alpha1.ReplicasSourceSnapshotAtLeastMin,
+			expectedReplicas: map[string]int32{
+				withAnnotation.Name:        2,
+				withInvalidAnnotation.Name: 2,
+				withHPA.Name:               3,
+				withHPADefaultMin.Name:     2,
+				notDeclared.Name:           2,
+				alreadyZero.Name:           0,
+			},
+		},
+		{
+			name:           "hand the replicas back to the autoscaler",
+			replicasSource: v1alpha1.ReplicasSourceAutoscaler,
+			expectedReplicas: map[string]int32{
+				withAnnotation.Name:        2,
+				withInvalidAnnotation.Name: 2,
+				withHPA.Name:               3,
+				withHPADefaultMin.Name:     1,
+				notDeclared.Name:           2,
+				alreadyZero.Name:           0,
+			},
+		},
+		{
+			name:           "restore the declared replicas of the deployments already at zero",
+			replicasSource: v1alpha1.ReplicasSourceDeclared,
+			alreadyZero:    v1alpha1.AlreadyZeroRestore,
+			expectedReplicas: map[string]int32{
+				withAnnotation.Name: 4,
+				alreadyZero.Name:    4,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithRuntimeObjects(&withAnnotation, &withInvalidAnnotation, &withHPA, &withHPADefaultMin, &notDeclared, &alreadyZero, &hpa, &hpaDefaultMin).Build()
+			r, err := NewResource(ctx, resource.ResourceClient{
+				Client: c,
+				Log:    testLogger,
+				SleepInfo: &v1alpha1.SleepInfo{
+					Spec: v1alpha1.SleepInfoSpec{
+						WakeUpPolicy: &v1alpha1.WakeUpPolicy{
+							ReplicasSource: test.replicasSource,
+							AlreadyZero:    test.alreadyZero,
+						},
+					},
+				},
+			}, namespace, originalReplicas)
+			require.NoError(t, err)
+
+			require.NoError(t, r.WakeUp(ctx))
+
+			for name, expectedReplicas := range test.expectedReplicas {
+				deployment := appsv1.Deployment{}
+				require.NoError(t, c.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, &deployment))
+				require.Equal(t, expectedReplicas, *deployment.Spec.Replicas, name)
+			}
+		})
+	}
+}
+
 func TestDeploymentOriginalReplicas(t *testing.T) {
 	testLogger := zap.New(zap.UseDevMode(true))
 
@@ -463,7 +951,7 @@ func TestDeploymentOriginalReplicas(t *testing.T) {
 		res, err := r.GetOriginalInfoToSave()
 		require.NoError(t, err)
 
-		expectedInfoToSave := `[{"name":"d1","replicas":1},{"name":"d2","replicas":5}]`
+		expectedInfoToSave := `[{"name":"d1","replicas":1},{"name":"d2","replicas":5},{"name":"dZeroReplica","replicas":0,"alreadyZero":true}]`
 		require.JSONEq(t, expectedInfoToSave, string(res))
 
 		t.Run("restore saved info", func(t *testing.T) {
@@ -471,12 +959,46 @@ func TestDeploymentOriginalReplicas(t *testing.T) {
 			restoredInfo, err := GetOriginalInfoToRestore(infoToSave)
 			require.NoError(t, err)
 			require.Equal(t, map[string]int32{
-				d1.Name: replica1,
-				d2.Name: replica5,
+				d1.Name:            replica1,
+				d2.Name:            replica5,
+				dZeroReplicas.Name: replica0,
 			}, restoredInfo)
 		})
 	})
 
+	t.Run("save the revision of the deployments", func(t *testing.T) {
+		d1 := d1.DeepCopy()
+		d1.Annotations = map[string]string{RevisionAnnotation: "3"}
+		c := fake.NewClientBuilder().WithRuntimeObjects(d1).Build()
+		r, err := NewResource(ctx, resource.ResourceClient{
+			Client:    c,
+			Log:       testLogger,
+			SleepInfo: emptySleepInfo,
+		}, namespace, nil)
+		require.NoError(t, err)
+
+		res, err := r.GetOriginalInfoToSave()
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"name":"d1","replicas":1,"revision":"3"}]`, string(res))
+	})
+
+	t.Run("save the restart time of the deployments", func(t *testing.T) {
+		d1 := d1.DeepCopy()
+		d1.Annotations = map[string]string{RevisionAnnotation: "3"}
+		d1.Spec.Template.Annotations = map[string]string{RestartedAtAnnotation: "2023-01-09T08:00:00Z"}
+		c := fake.NewClientBuilder().WithRuntimeObjects(d1).Build()
+		r, err := NewResource(ctx, resource.ResourceClient{
+			Client:    c,
+			Log:       testLogger,
+			SleepInfo: emptySleepInfo,
+		}, namespace, nil)
+		require.NoError(t, err)
+
+		res, err := r.GetOriginalInfoToSave()
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"name":"d1","replicas":1,"revision":"3","restartedAt":"2023-01-09T08:00:00Z"}]`, string(res))
+	})
+
 	t.Run("restore info with data nil", func(t *testing.T) {
 		info, err := GetOriginalInfoToRestore(nil)
 		require.Equal(t, map[string]int32{}, info)
@@ -559,6 +1081,14 @@ func TestLabelMatch(t *testing.T) {
 	}
 }
 
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
 func getPtr[T any](item T) *T {
 	return &item
 }
@@ -0,0 +1,54 @@
+package sleepinfo
+
+import (
+	"context"
+	"time"
+)
+
+// drainContext is a context with the values of its parent, which is not
+// canceled with it.
+type drainContext struct {
+	parent context.Context
+}
+
+func (drainContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (drainContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (drainContext) Err() error {
+	return nil
+}
+
+func (c drainContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+// withDrainTimeout returns a context canceled drainTimeout after ctx is done,
+// instead of with it, so that the operation in flight when the controller is
+// stopped, e.g. on SIGTERM, completes instead of leaving the namespace half
+// asleep. If drainTimeout is 0, the returned context is canceled with ctx.
+func withDrainTimeout(ctx context.Context, drainTimeout time.Duration) (context.Context, context.CancelFunc) {
+	if drainTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	drainCtx, cancel := context.WithCancel(drainContext{parent: ctx})
+	go func() {
+		select {
+		case <-drainCtx.Done():
+			return
+		case <-ctx.Done():
+		}
+		timer := time.NewTimer(drainTimeout)
+		defer timer.Stop()
+		select {
+		case <-drainCtx.Done():
+		case <-timer.C:
+			cancel()
+		}
+	}()
+	return drainCtx, cancel
+}
@@ -0,0 +1,82 @@
+package sleepinfo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type drainTestKey struct{}
+
+func TestWithDrainTimeout(t *testing.T) {
+	t.Run("not canceled with the parent until the drain timeout", func(t *testing.T) {
+		parent, cancelParent := context.WithCancel(context.WithValue(context.Background(), drainTestKey{}, "value"))
+		ctx, cancel := withDrainTimeout(parent, 100*time.Millisecond)
+		defer cancel()
+		require.Equal(t, "value", ctx.Value(drainTestKey{}))
+
+		cancelParent()
+		select {
+		case <-ctx.Done():
+			t.Fatal("canceled with the parent")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		select {
+		case <-ctx.Done():
+			require.ErrorIs(t, ctx.Err(), context.Canceled)
+		case <-time.After(time.Second):
+			t.Fatal("not canceled after the drain timeout")
+		}
+	})
+
+	t.Run("canceled with the parent without drain timeout", func(t *testing.T) {
+		parent, cancelParent := context.WithCancel(context.Background())
+		ctx, cancel := withDrainTimeout(parent, 0)
+		defer cancel()
+
+		cancelParent()
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("not canceled with the parent")
+		}
+	})
+
+	t.Run("canceled when the operation completes", func(t *testing.T) {
+		ctx, cancel := withDrainTimeout(context.Background(), time.Minute)
+		cancel()
+		require.ErrorIs(t, ctx.Err(), context.Canceled)
+	})
+}
+
+func TestReconcileWhileStopping(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "ns"},
+		Spec:       kubegreenv1alpha1.SleepInfoSpec{Weekdays: "*", SleepTime: "*:*"},
+	}
+	r := &SleepInfoReconciler{
+		Client:       fake.NewClientBuilder().WithScheme(scheme).WithObjects(sleepInfo).Build(),
+		Log:          logr.Discard(),
+		DrainTimeout: time.Minute,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "sleepinfo"}})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, ctrl.Result{}, result)
+}
@@ -0,0 +1,132 @@
+package sleepinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ScheduleError is the error of a schedule of the SleepInfo which cannot be
+// computed, e.g. because its cron expression is not valid. Its message is the
+// one of the wrapped error, which already names the invalid schedule.
+type ScheduleError struct {
+	Err error
+}
+
+func (e *ScheduleError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ScheduleError) Unwrap() error {
+	return e.Err
+}
+
+// StateStoreError is the error of the Secret where the state of the
+// SleepInfo is stored.
+type StateStoreError struct {
+	// Op is what fails: read, decode or write.
+	Op     string
+	Secret string
+	Err    error
+}
+
+func (e *StateStoreError) Error() string {
+	return fmt.Sprintf("fails to %s the state in the secret %s: %s", e.Op, e.Secret, e.Err)
+}
+
+func (e *StateStoreError) Unwrap() error {
+	return e.Err
+}
+
+// PatchError is the error of the patch of a resource put to sleep or woken
+// up, with its GroupVersionKind and name.
+type PatchError = resource.PatchError
+
+// getFailureReason returns the reason of the failure of the reconcile, so
+// that the alerts can distinguish e.g. an invalid schedule from a permission
+// denied by the RBAC or a transient error of the API server. The cause of an
+// API error takes precedence over the failed step.
+func getFailureReason(err error) string {
+	var scheduleErr *ScheduleError
+	if errors.As(err, &scheduleErr) {
+		return kubegreenv1alpha1.OperationFailedReasonInvalidSchedule
+	}
+	switch {
+	case apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err):
+		return kubegreenv1alpha1.OperationFailedReasonForbidden
+	case apierrors.IsTooManyRequests(err):
+		return kubegreenv1alpha1.OperationFailedReasonThrottled
+	case apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) || apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err) || apierrors.IsConflict(err) || errors.Is(err, context.DeadlineExceeded):
+		return kubegreenv1alpha1.OperationFailedReasonTransientAPIError
+	}
+	var stateStoreErr *StateStoreError
+	if errors.As(err, &stateStoreErr) {
+		return kubegreenv1alpha1.OperationFailedReasonStateStoreFailed
+	}
+	var patchErr *PatchError
+	if errors.As(err, &patchErr) {
+		return kubegreenv1alpha1.OperationFailedReasonPatchFailed
+	}
+	return kubegreenv1alpha1.OperationFailedReasonUnknown
+}
+
+// setOperationFailed records the failure of the reconcile of the SleepInfo
+// in the OperationFailed condition and in the metrics, by reason. A failure
+// in updating the condition is only logged.
+func (r *SleepInfoReconciler) setOperationFailed(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo, err error) {
+	condition := metav1.Condition{
+		Type:    kubegreenv1alpha1.OperationFailedCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  getFailureReason(err),
+		Message: err.Error(),
+	}
+	r.Metrics.OperationFailures.WithLabelValues(sleepInfo.Namespace, condition.Reason).Inc()
+	if err := r.setLatestStatusCondition(ctx, client.ObjectKeyFromObject(sleepInfo), condition); err != nil {
+		log.Error(err, "fails to set the OperationFailed condition")
+	}
+}
+
+// resetOperationFailed sets the OperationFailed condition to false, once the
+// SleepInfo is reconciled without errors. The SleepInfo is updated only if
+// the condition is true, so that the reconciles without failures do not
+// read it again.
+func (r *SleepInfoReconciler) resetOperationFailed(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo) {
+	if !meta.IsStatusConditionTrue(sleepInfo.Status.Conditions, kubegreenv1alpha1.OperationFailedCondition) {
+		return
+	}
+	if err := r.setLatestStatusCondition(ctx, client.ObjectKeyFromObject(sleepInfo), metav1.Condition{
+		Type:    kubegreenv1alpha1.OperationFailedCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  kubegreenv1alpha1.OperationFailedReasonSucceeded,
+		Message: "the last operation succeeded",
+	}); err != nil {
+		log.Error(err, "fails to reset the OperationFailed condition")
+	}
+}
+
+// setLatestStatusCondition sets the condition on the latest version of the
+// SleepInfo, since its status can be changed by the reconcile before the
+// condition is set.
+func (r *SleepInfoReconciler) setLatestStatusCondition(ctx context.Context, key client.ObjectKey, condition metav1.Condition) error {
+	currentSleepInfo := &kubegreenv1alpha1.SleepInfo{}
+	if err := r.Get(ctx, key, currentSleepInfo); err != nil {
+		return err
+	}
+	current := meta.FindStatusCondition(currentSleepInfo.Status.Conditions, condition.Type)
+	if current != nil && current.Status == condition.Status && current.Reason == condition.Reason && current.Message == condition.Message {
+		return nil
+	}
+	sleepInfo := currentSleepInfo.DeepCopy()
+	meta.SetStatusCondition(&sleepInfo.Status.Conditions, condition)
+	return r.Status().Patch(ctx, sleepInfo, client.MergeFromWithOptions(currentSleepInfo, client.MergeFromWithOptimisticLock{}))
+}
@@ -0,0 +1,173 @@
+package sleepinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/metrics"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetFailureReason(t *testing.T) {
+	deploymentsResource := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	deploymentGVK := appsv1.SchemeGroupVersion.WithKind("Deployment")
+
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "invalid schedule",
+			err:      &ScheduleError{Err: fmt.Errorf("current schedule not valid: expected exactly 5 fields, found 4: [* * * *]")},
+			expected: kubegreenv1alpha1.OperationFailedReasonInvalidSchedule,
+		},
+		{
+			name:     "forbidden patch",
+			err:      &PatchError{GVK: deploymentGVK, Name: "api", Err: apierrors.NewForbidden(deploymentsResource, "api", errors.New("RBAC denied"))},
+			expected: kubegreenv1alpha1.OperationFailedReasonForbidden,
+		},
+		{
+			name:     "unauthorized",
+			err:      apierrors.NewUnauthorized("token expired"),
+			expected: kubegreenv1alpha1.OperationFailedReasonForbidden,
+		},
+		{
+			name:     "too many requests",
+			err:      &StateStoreError{Op: "write", Secret: "sleepinfo-secret", Err: apierrors.NewTooManyRequests("slow down", 1)},
+			expected: kubegreenv1alpha1.OperationFailedReasonThrottled,
+		},
+		{
+			name:     "server timeout",
+			err:      &PatchError{GVK: deploymentGVK, Name: "api", Err: apierrors.NewServerTimeout(deploymentsResource, "patch", 1)},
+			expected: kubegreenv1alpha1.OperationFailedReasonTransientAPIError,
+		},
+		{
+			name:     "conflict",
+			err:      apierrors.NewConflict(deploymentsResource, "api", errors.New("the object has been modified")),
+			expected: kubegreenv1alpha1.OperationFailedReasonTransientAPIError,
+		},
+		{
+			name:     "deadline exceeded",
+			err:      fmt.Errorf("fails to list: %w", context.DeadlineExceeded),
+			expected: kubegreenv1alpha1.OperationFailedReasonTransientAPIError,
+		},
+		{
+			name:     "state store",
+			err:      &StateStoreError{Op: "decode", Secret: "sleepinfo-secret", Err: errors.New("invalid JSON")},
+			expected: kubegreenv1alpha1.OperationFailedReasonStateStoreFailed,
+		},
+		{
+			name:     "patch",
+			err:      &PatchError{GVK: deploymentGVK, Name: "api", Err: apierrors.NewBadRequest("invalid patch")},
+			expected: kubegreenv1alpha1.OperationFailedReasonPatchFailed,
+		},
+		{
+			name:     "unknown",
+			err:      errors.New("something went wrong"),
+			expected: kubegreenv1alpha1.OperationFailedReasonUnknown,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, getFailureReason(test.err))
+		})
+	}
+}
+
+func TestErrorMessages(t *testing.T) {
+	scheduleErr := errors.New("current schedule not valid")
+	require.EqualError(t, &ScheduleError{Err: scheduleErr}, "current schedule not valid")
+	require.ErrorIs(t, &ScheduleError{Err: scheduleErr}, scheduleErr)
+
+	stateErr := errors.New("invalid JSON")
+	require.EqualError(t, &StateStoreError{Op: "decode", Secret: "sleepinfo-secret", Err: stateErr}, "fails to decode the state in the secret sleepinfo-secret: invalid JSON")
+	require.ErrorIs(t, &StateStoreError{Op: "decode", Secret: "sleepinfo-secret", Err: stateErr}, stateErr)
+
+	patchErr := &resource.PatchError{GVK: appsv1.SchemeGroupVersion.WithKind("Deployment"), Namespace: "my-namespace", Name: "api", Err: stateErr}
+	require.EqualError(t, patchErr, "fails to patch Deployment.apps api: invalid JSON")
+	require.ErrorIs(t, patchErr, stateErr)
+}
+
+func TestOperationFailedCondition(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	namespace := "my-namespace"
+	getReconciler := func(sleepInfo *kubegreenv1alpha1.SleepInfo) SleepInfoReconciler {
+		return SleepInfoReconciler{
+			Client:  fake.NewClientBuilder().WithScheme(scheme).WithObjects(sleepInfo).Build(),
+			Metrics: metrics.SetupMetricsOrDie("kube_green"),
+		}
+	}
+	getCondition := func(t *testing.T, r SleepInfoReconciler, sleepInfo *kubegreenv1alpha1.SleepInfo) *metav1.Condition {
+		t.Helper()
+		current := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, r.Get(context.Background(), client.ObjectKeyFromObject(sleepInfo), current))
+		return meta.FindStatusCondition(current.Status.Conditions, kubegreenv1alpha1.OperationFailedCondition)
+	}
+
+	t.Run("set the condition and count the failure by reason", func(t *testing.T) {
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: namespace}}
+		r := getReconciler(sleepInfo)
+
+		err := &ScheduleError{Err: errors.New("current schedule not valid")}
+		r.setOperationFailed(context.Background(), logr.Discard(), sleepInfo, err)
+		r.setOperationFailed(context.Background(), logr.Discard(), sleepInfo, err)
+
+		condition := getCondition(t, r, sleepInfo)
+		require.NotNil(t, condition)
+		require.Equal(t, metav1.ConditionTrue, condition.Status)
+		require.Equal(t, kubegreenv1alpha1.OperationFailedReasonInvalidSchedule, condition.Reason)
+		require.Equal(t, "current schedule not valid", condition.Message)
+		require.Equal(t, float64(2), testutil.ToFloat64(r.Metrics.OperationFailures.WithLabelValues(namespace, kubegreenv1alpha1.OperationFailedReasonInvalidSchedule)))
+	})
+
+	t.Run("reset the condition after a successful operation", func(t *testing.T) {
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: namespace},
+			Status: kubegreenv1alpha1.SleepInfoStatus{
+				Conditions: []metav1.Condition{{
+					Type:               kubegreenv1alpha1.OperationFailedCondition,
+					Status:             metav1.ConditionTrue,
+					Reason:             kubegreenv1alpha1.OperationFailedReasonPatchFailed,
+					Message:            "fails to patch Deployment.apps api: invalid patch",
+					LastTransitionTime: metav1.Now(),
+				}},
+			},
+		}
+		r := getReconciler(sleepInfo)
+
+		r.resetOperationFailed(context.Background(), logr.Discard(), sleepInfo)
+
+		condition := getCondition(t, r, sleepInfo)
+		require.NotNil(t, condition)
+		require.Equal(t, metav1.ConditionFalse, condition.Status)
+		require.Equal(t, kubegreenv1alpha1.OperationFailedReasonSucceeded, condition.Reason)
+	})
+
+	t.Run("the condition is not added if the operation never failed", func(t *testing.T) {
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: namespace}}
+		r := getReconciler(sleepInfo)
+
+		r.resetOperationFailed(context.Background(), logr.Discard(), sleepInfo)
+
+		require.Nil(t, getCondition(t, r, sleepInfo))
+	})
+}
@@ -0,0 +1,172 @@
+package sleepinfo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ExpiredAtAnnotation is set on a SleepInfo, to the RFC3339 time when its
+// namespace expired, so that each sleep expires only once.
+const ExpiredAtAnnotation = "kube-green.dev/expired-at"
+
+const (
+	// expiryRetryInterval is how frequently a failed expiry is retried.
+	expiryRetryInterval = 5 * time.Minute
+	// expiryRequestTimeout is the timeout of the request to the webhook of
+	// the expiry.
+	expiryRequestTimeout = 10 * time.Second
+)
+
+// ExpiryNotification is the body of the request sent to the webhook of the
+// expiry when a namespace expires.
+type ExpiryNotification struct {
+	Namespace     string    `json:"namespace"`
+	SleepInfo     string    `json:"sleepInfo"`
+	SleepingSince time.Time `json:"sleepingSince"`
+	ExpiredAt     time.Time `json:"expiredAt"`
+}
+
+// getSleepingSince returns since when the namespace of the SleepInfo is
+// continuously asleep, i.e. the time of the first sleep after the last wake
+// up, and false if it is awake.
+func getSleepingSince(sleepInfo *kubegreenv1alpha1.SleepInfo) (time.Time, bool) {
+	if sleepInfo.Status.OperationType != sleepOperation {
+		return time.Time{}, false
+	}
+	history := sleepInfo.Status.OperationsHistory
+	sleepingSince := sleepInfo.Status.LastScheduleTime.Time
+	for i := len(history) - 1; i >= 0 && history[i].Type == sleepOperation; i-- {
+		sleepingSince = history[i].Time.Time
+	}
+	return sleepingSince, !sleepingSince.IsZero()
+}
+
+// isExpired returns true if the namespace has already expired during the
+// sleep started at sleepingSince.
+func isExpired(sleepInfo *kubegreenv1alpha1.SleepInfo, sleepingSince time.Time) bool {
+	value, ok := sleepInfo.Annotations[ExpiredAtAnnotation]
+	if !ok {
+		return false
+	}
+	expiredAt, err := time.Parse(time.RFC3339, value)
+	return err == nil && !expiredAt.Before(sleepingSince)
+}
+
+// handleExpiry makes the namespace of the SleepInfo expire, if it is asleep
+// since more than the expiry. It returns when the expiry must be checked
+// again, and false if there is nothing to expire.
+func (r *SleepInfoReconciler) handleExpiry(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time) (time.Duration, bool) {
+	expiry := sleepInfo.GetExpiry()
+	if expiry == nil {
+		return 0, false
+	}
+	sleepingSince, isAsleep := getSleepingSince(sleepInfo)
+	if !isAsleep || isExpired(sleepInfo, sleepingSince) {
+		return 0, false
+	}
+	expiresAt := sleepingSince.Add(expiry.After.Duration)
+	if now.Before(expiresAt) {
+		return expiresAt.Sub(now), true
+	}
+
+	log = log.WithValues("sleepingSince", sleepingSince)
+	if err := r.expire(ctx, sleepInfo, *expiry, sleepingSince, now); err != nil {
+		log.Error(err, "fails to expire the namespace")
+		return expiryRetryInterval, true
+	}
+	log.Info("namespace expired")
+	return 0, false
+}
+
+// expire deletes the resources selected by the expiry, notifies its webhook
+// and marks the SleepInfo as expired.
+func (r *SleepInfoReconciler) expire(ctx context.Context, sleepInfo *kubegreenv1alpha1.SleepInfo, expiry kubegreenv1alpha1.Expiry, sleepingSince, now time.Time) error {
+	if expiry.DeleteSelector != nil {
+		namespaceClient, err := r.getNamespaceClient(sleepInfo)
+		if err != nil {
+			return err
+		}
+		if err := deleteExpiredResources(ctx, namespaceClient, sleepInfo.Namespace, expiry.DeleteSelector); err != nil {
+			return err
+		}
+	}
+	if expiry.WebhookURL != "" {
+		if err := r.notifyExpiry(ctx, expiry.WebhookURL, ExpiryNotification{
+			Namespace:     sleepInfo.Namespace,
+			SleepInfo:     sleepInfo.Name,
+			SleepingSince: sleepingSince,
+			ExpiredAt:     now,
+		}); err != nil {
+			return err
+		}
+	}
+	if err := annotate(ctx, r.Client, sleepInfo, map[string]string{
+		ExpiredAtAnnotation: now.Format(time.RFC3339),
+	}); err != nil {
+		return err
+	}
+	if r.Recorder != nil {
+		r.Recorder.Eventf(sleepInfo, v1.EventTypeNormal, "Expired", "Namespace asleep since %s, expired after %s", sleepingSince.Format(time.RFC3339), expiry.After.Duration)
+	}
+	return nil
+}
+
+// deleteExpiredResources deletes the resources of the namespace selected by
+// the selector.
+func deleteExpiredResources(ctx context.Context, c client.Client, namespace string, deleteSelector *metav1.LabelSelector) error {
+	selector, err := metav1.LabelSelectorAsSelector(deleteSelector)
+	if err != nil {
+		return fmt.Errorf("invalid delete selector: %s", err)
+	}
+	for _, obj := range []client.Object{&appsv1.Deployment{}, &appsv1.StatefulSet{}, &batchv1.CronJob{}, &batchv1.Job{}, &v1.Service{}} {
+		if err := c.DeleteAllOf(ctx, obj,
+			client.InNamespace(namespace),
+			client.MatchingLabelsSelector{Selector: selector},
+			client.PropagationPolicy(metav1.DeletePropagationBackground),
+		); err != nil {
+			return fmt.Errorf("fails to delete %T: %s", obj, err)
+		}
+	}
+	return nil
+}
+
+// notifyExpiry sends the notification of the expiry to the webhook.
+func (r *SleepInfoReconciler) notifyExpiry(ctx context.Context, url string, notification ExpiryNotification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	httpClient := r.ExpiryHTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	ctx, cancel := context.WithTimeout(ctx, expiryRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with status %d", url, res.StatusCode)
+	}
+	return nil
+}
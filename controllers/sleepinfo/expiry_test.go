@@ -0,0 +1,253 @@
+package sleepinfo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestGetSleepingSince(t *testing.T) {
+	sleptAt := time.Date(2023, 1, 6, 20, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		status        kubegreenv1alpha1.SleepInfoStatus
+		expected      time.Time
+		expectedSleep bool
+	}{
+		{
+			name:   "awake",
+			status: kubegreenv1alpha1.SleepInfoStatus{OperationType: wakeUpOperation},
+		},
+		{
+			name: "without history",
+			status: kubegreenv1alpha1.SleepInfoStatus{
+				OperationType:    sleepOperation,
+				LastScheduleTime: metav1.NewTime(sleptAt),
+			},
+			expected:      sleptAt,
+			expectedSleep: true,
+		},
+		{
+			name: "repeated sleeps",
+			status: kubegreenv1alpha1.SleepInfoStatus{
+				OperationType:    sleepOperation,
+				LastScheduleTime: metav1.NewTime(sleptAt.Add(48 * time.Hour)),
+				OperationsHistory: []kubegreenv1alpha1.OperationHistory{
+					{Type: sleepOperation, Time: metav1.NewTime(sleptAt.Add(-48 * time.Hour))},
+					{Type: wakeUpOperation, Time: metav1.NewTime(sleptAt.Add(-12 * time.Hour))},
+					{Type: sleepOperation, Time: metav1.NewTime(sleptAt)},
+					{Type: sleepOperation, Time: metav1.NewTime(sleptAt.Add(24 * time.Hour))},
+					{Type: sleepOperation, Time: metav1.NewTime(sleptAt.Add(48 * time.Hour))},
+				},
+			},
+			expected:      sleptAt,
+			expectedSleep: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sleepingSince, isAsleep := getSleepingSince(&kubegreenv1alpha1.SleepInfo{Status: test.status})
+			require.Equal(t, test.expectedSleep, isAsleep)
+			require.Equal(t, test.expected, sleepingSince)
+		})
+	}
+}
+
+func TestHandleExpiry(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+	log := zap.New(zap.UseDevMode(true))
+	sleptAt := time.Date(2023, 1, 6, 20, 0, 0, 0, time.UTC)
+
+	getSleepInfo := func(expiry *kubegreenv1alpha1.Expiry) *kubegreenv1alpha1.SleepInfo {
+		return &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sleepinfo",
+				Namespace: "preview-123",
+			},
+			Spec: kubegreenv1alpha1.SleepInfoSpec{
+				Expiry: expiry,
+			},
+			Status: kubegreenv1alpha1.SleepInfoStatus{
+				OperationType:    sleepOperation,
+				LastScheduleTime: metav1.NewTime(sleptAt),
+				OperationsHistory: []kubegreenv1alpha1.OperationHistory{
+					{Type: sleepOperation, Time: metav1.NewTime(sleptAt)},
+				},
+			},
+		}
+	}
+	getDeployment := func(name string, labels map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "preview-123",
+				Labels:    labels,
+			},
+		}
+	}
+	getReconciler := func(objects ...client.Object) (SleepInfoReconciler, *record.FakeRecorder) {
+		recorder := record.NewFakeRecorder(10)
+		return SleepInfoReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+			Recorder: recorder,
+		}, recorder
+	}
+	getAnnotations := func(t *testing.T, r SleepInfoReconciler, sleepInfo *kubegreenv1alpha1.SleepInfo) map[string]string {
+		t.Helper()
+		updatedSleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, r.Get(context.Background(), client.ObjectKeyFromObject(sleepInfo), updatedSleepInfo))
+		return updatedSleepInfo.Annotations
+	}
+	getDeploymentNames := func(t *testing.T, r SleepInfoReconciler) []string {
+		t.Helper()
+		deployments := appsv1.DeploymentList{}
+		require.NoError(t, r.List(context.Background(), &deployments, client.InNamespace("preview-123")))
+		names := []string{}
+		for _, deployment := range deployments.Items {
+			names = append(names, deployment.Name)
+		}
+		return names
+	}
+
+	t.Run("without expiry", func(t *testing.T) {
+		sleepInfo := getSleepInfo(nil)
+		r, _ := getReconciler(sleepInfo)
+		_, isPending := r.handleExpiry(context.Background(), log, sleepInfo, sleptAt.Add(30*24*time.Hour))
+		require.False(t, isPending)
+	})
+
+	t.Run("awake namespace", func(t *testing.T) {
+		sleepInfo := getSleepInfo(&kubegreenv1alpha1.Expiry{After: metav1.Duration{Duration: 72 * time.Hour}})
+		sleepInfo.Status.OperationType = wakeUpOperation
+		r, _ := getReconciler(sleepInfo)
+		_, isPending := r.handleExpiry(context.Background(), log, sleepInfo, sleptAt.Add(30*24*time.Hour))
+		require.False(t, isPending)
+	})
+
+	t.Run("not yet expired", func(t *testing.T) {
+		sleepInfo := getSleepInfo(&kubegreenv1alpha1.Expiry{After: metav1.Duration{Duration: 72 * time.Hour}})
+		r, _ := getReconciler(sleepInfo)
+		expireAfter, isPending := r.handleExpiry(context.Background(), log, sleepInfo, sleptAt.Add(24*time.Hour))
+		require.True(t, isPending)
+		require.Equal(t, 48*time.Hour, expireAfter)
+		require.Empty(t, getAnnotations(t, r, sleepInfo))
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		var notification ExpiryNotification
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			require.Equal(t, http.MethodPost, req.Method)
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&notification))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		sleepInfo := getSleepInfo(&kubegreenv1alpha1.Expiry{
+			After:      metav1.Duration{Duration: 72 * time.Hour},
+			WebhookURL: server.URL,
+			DeleteSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app.kubernetes.io/part-of": "preview"},
+			},
+		})
+		r, recorder := getReconciler(
+			sleepInfo,
+			getDeployment("api", map[string]string{"app.kubernetes.io/part-of": "preview"}),
+			getDeployment("database", nil),
+		)
+		now := sleptAt.Add(73 * time.Hour)
+		_, isPending := r.handleExpiry(context.Background(), log, sleepInfo, now)
+		require.False(t, isPending)
+
+		require.Equal(t, []string{"database"}, getDeploymentNames(t, r))
+		require.Equal(t, ExpiryNotification{
+			Namespace:     "preview-123",
+			SleepInfo:     "sleepinfo",
+			SleepingSince: sleptAt,
+			ExpiredAt:     now,
+		}, notification)
+		require.Equal(t, map[string]string{
+			ExpiredAtAnnotation: "2023-01-09T21:00:00Z",
+		}, getAnnotations(t, r, sleepInfo))
+		require.Equal(t, "Normal Expired Namespace asleep since 2023-01-06T20:00:00Z, expired after 72h0m0s", <-recorder.Events)
+	})
+
+	t.Run("already expired", func(t *testing.T) {
+		sleepInfo := getSleepInfo(&kubegreenv1alpha1.Expiry{After: metav1.Duration{Duration: 72 * time.Hour}})
+		sleepInfo.Annotations = map[string]string{ExpiredAtAnnotation: "2023-01-09T21:00:00Z"}
+		r, _ := getReconciler(sleepInfo)
+		_, isPending := r.handleExpiry(context.Background(), log, sleepInfo, sleptAt.Add(30*24*time.Hour))
+		require.False(t, isPending)
+	})
+
+	t.Run("expired during a previous sleep", func(t *testing.T) {
+		sleepInfo := getSleepInfo(&kubegreenv1alpha1.Expiry{After: metav1.Duration{Duration: 72 * time.Hour}})
+		sleepInfo.Annotations = map[string]string{ExpiredAtAnnotation: "2023-01-01T00:00:00Z"}
+		r, _ := getReconciler(sleepInfo)
+		now := sleptAt.Add(73 * time.Hour)
+		_, isPending := r.handleExpiry(context.Background(), log, sleepInfo, now)
+		require.False(t, isPending)
+		require.Equal(t, map[string]string{
+			ExpiredAtAnnotation: "2023-01-09T21:00:00Z",
+		}, getAnnotations(t, r, sleepInfo))
+	})
+
+	t.Run("webhook fails", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		sleepInfo := getSleepInfo(&kubegreenv1alpha1.Expiry{
+			After:      metav1.Duration{Duration: 72 * time.Hour},
+			WebhookURL: server.URL,
+		})
+		r, _ := getReconciler(sleepInfo)
+		expireAfter, isPending := r.handleExpiry(context.Background(), log, sleepInfo, sleptAt.Add(73*time.Hour))
+		require.True(t, isPending)
+		require.Equal(t, expiryRetryInterval, expireAfter)
+		require.Empty(t, getAnnotations(t, r, sleepInfo))
+	})
+}
+
+func TestDeleteExpiredResources(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	selected := map[string]string{"preview": "true"}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "preview-123", Labels: selected}},
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "database", Namespace: "preview-123"}},
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "preview-456", Labels: selected}},
+		&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "preview-123", Labels: selected}},
+	).Build()
+	require.NoError(t, deleteExpiredResources(context.Background(), c, "preview-123", &metav1.LabelSelector{MatchLabels: selected}))
+
+	services := v1.ServiceList{}
+	require.NoError(t, c.List(context.Background(), &services))
+	require.Len(t, services.Items, 2)
+	for _, service := range services.Items {
+		require.NotEqual(t, client.ObjectKey{Namespace: "preview-123", Name: "api"}, client.ObjectKeyFromObject(&service))
+	}
+	statefulSets := appsv1.StatefulSetList{}
+	require.NoError(t, c.List(context.Background(), &statefulSets))
+	require.Empty(t, statefulSets.Items)
+}
@@ -0,0 +1,46 @@
+package sleepinfo
+
+import (
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/audit"
+	"github.com/kube-green/kube-green/pkg/schedule"
+)
+
+// getWakeUpAfterFailedSleep returns the actor of the wake up which replaces
+// the pending sleep, failed and retried by each reconcile, and true if it is
+// due at now. The failed sleep is retried only until the scheduled wake up,
+// or until the namespace is forced awake or its wake up is requested, so that
+// it does not keep the namespace asleep and its lock held.
+func getWakeUpAfterFailedSleep(sleepInfo *kubegreenv1alpha1.SleepInfo, data SleepInfoData, isForcedAwake bool, now time.Time) (string, bool, error) {
+	if !data.IsSleepOperation() || data.PendingOperationID == "" {
+		return "", false, nil
+	}
+	if isForcedAwake {
+		return audit.ActorManual, true, nil
+	}
+	// the request can be at the same time as the failed sleep, when the
+	// sleep group is rolled back.
+	if requestedAt, ok := getWakeUpRequestedAt(sleepInfo); ok && !requestedAt.Before(data.LastSchedule) {
+		return getWakeUpRequestedBy(sleepInfo), true, nil
+	}
+	if data.NextOperationSchedule == data.CurrentOperationSchedule {
+		return "", false, nil
+	}
+	wakeUpSchedule, err := schedule.Parse(data.NextOperationSchedule)
+	if err != nil {
+		return "", false, &ScheduleError{Err: err}
+	}
+	return audit.ActorSchedule, !wakeUpSchedule.Next(data.LastSchedule).After(now), nil
+}
+
+// toWakeUpAfterFailedSleep returns the data of the wake up which replaces the
+// failed sleep. The wake up restores the state stored by the sleep for the
+// resources it has already put to sleep.
+func toWakeUpAfterFailedSleep(data SleepInfoData) SleepInfoData {
+	data.CurrentOperationType = wakeUpOperation
+	data.CurrentOperationSchedule, data.NextOperationSchedule = data.NextOperationSchedule, data.CurrentOperationSchedule
+	data.PendingOperationID = ""
+	return data
+}
@@ -0,0 +1,150 @@
+package sleepinfo
+
+import (
+	"context"
+	"testing"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/deployments"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/metrics"
+	"github.com/kube-green/kube-green/internal/testutil"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestWakeUpAfterFailedSleep(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	namespace := "my-namespace"
+	sleepInfoName := "working-hours"
+	// the sleep of the deployment keeps failing, while its wake up succeeds.
+	newReconciler := func(t *testing.T) (*SleepInfoReconciler, ctrl.Request) {
+		t.Helper()
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: sleepInfoName, Namespace: namespace},
+			Spec: kubegreenv1alpha1.SleepInfoSpec{
+				Weekdays:   "*",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				TimeZone:   "UTC",
+			},
+		}
+		var replicas3 int32 = 3
+		api := deployments.GetMock(deployments.MockSpec{Name: "api", Namespace: namespace, Replicas: &replicas3})
+		objects := []client.Object{
+			&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}},
+			sleepInfo,
+			getSecret(mockSecretSpec{
+				namespace: namespace,
+				name:      "sleepinfo-working-hours",
+				data: withStateChecksum(map[string][]byte{
+					lastOperationKey: []byte(wakeUpOperation),
+					lastScheduleKey:  []byte("2023-01-09T08:00:00Z"),
+				}),
+			}),
+			&api,
+		}
+		r := &SleepInfoReconciler{
+			Client: testutil.PossiblyErroringFakeCtrlRuntimeClient{
+				Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+				ShouldError: func(method testutil.Method, obj runtime.Object) bool {
+					deployment, isDeployment := obj.(*appsv1.Deployment)
+					return method == testutil.Patch && isDeployment && *deployment.Spec.Replicas == 0
+				},
+			},
+			Log:        zap.New(zap.UseDevMode(true)),
+			Metrics:    metrics.SetupMetricsOrDie("kube_green"),
+			Clock:      mockClock{now: "2023-01-09T20:00:30Z", t: t},
+			SleepDelta: 60,
+		}
+		req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(sleepInfo)}
+		_, err := r.Reconcile(context.Background(), req)
+		require.Error(t, err)
+		require.NotEmpty(t, getStoredSecret(t, r).Data[pendingOperationKey], "the failed sleep is pending")
+		return r, req
+	}
+	annotate := func(t *testing.T, r *SleepInfoReconciler, obj client.Object, key client.ObjectKey, annotations map[string]string) {
+		t.Helper()
+		require.NoError(t, r.Client.Get(context.Background(), key, obj))
+		obj.SetAnnotations(annotations)
+		require.NoError(t, r.Client.Update(context.Background(), obj))
+	}
+
+	tests := []struct {
+		name     string
+		now      string
+		setup    func(t *testing.T, r *SleepInfoReconciler)
+		expected string
+	}{
+		{
+			name:     "sleep retried until the wake up",
+			now:      "2023-01-09T22:00:00Z",
+			expected: sleepOperation,
+		},
+		{
+			name:     "scheduled wake up",
+			now:      "2023-01-10T08:00:30Z",
+			expected: wakeUpOperation,
+		},
+		{
+			name: "requested wake up",
+			now:  "2023-01-09T22:00:00Z",
+			setup: func(t *testing.T, r *SleepInfoReconciler) {
+				annotate(t, r, &kubegreenv1alpha1.SleepInfo{}, client.ObjectKey{Name: sleepInfoName, Namespace: namespace}, map[string]string{
+					WakeUpRequestedAtAnnotation: "2023-01-09T21:00:00Z",
+				})
+			},
+			expected: wakeUpOperation,
+		},
+		{
+			name: "forced wake up",
+			now:  "2023-01-09T22:00:00Z",
+			setup: func(t *testing.T, r *SleepInfoReconciler) {
+				annotate(t, r, &v1.Namespace{}, client.ObjectKey{Name: namespace}, map[string]string{
+					ForceAwakeAnnotation: "true",
+				})
+			},
+			expected: wakeUpOperation,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, req := newReconciler(t)
+			if test.setup != nil {
+				test.setup(t, r)
+			}
+			r.Clock = mockClock{now: test.now, t: t}
+			_, err := r.Reconcile(context.Background(), req)
+			require.Equal(t, test.expected == sleepOperation, err != nil)
+
+			secret := getStoredSecret(t, r)
+			require.Equal(t, test.expected, string(secret.Data[lastOperationKey]))
+			deployment := appsv1.Deployment{}
+			require.NoError(t, r.Client.Get(context.Background(), client.ObjectKey{Name: "api", Namespace: namespace}, &deployment))
+			require.Equal(t, int32(3), *deployment.Spec.Replicas)
+			if test.expected == sleepOperation {
+				require.NotEmpty(t, secret.Data[pendingOperationKey], "the failed sleep is still pending")
+				return
+			}
+			require.NotContains(t, secret.Data, pendingOperationKey)
+		})
+	}
+}
+
+func getStoredSecret(t *testing.T, r *SleepInfoReconciler) *v1.Secret {
+	t.Helper()
+	secret, err := r.getSecret(context.Background(), "sleepinfo-working-hours", "my-namespace")
+	require.NoError(t, err)
+	return secret
+}
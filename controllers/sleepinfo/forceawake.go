@@ -0,0 +1,75 @@
+package sleepinfo
+
+import (
+	"context"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ForceAwakeAnnotation, set to "true" on a namespace, wakes up immediately
+// all the SleepInfo of the namespace, and skips their sleeps until it is
+// removed. It is an escape hatch for the incident response, when the owner
+// of the SleepInfo is not around.
+const ForceAwakeAnnotation = "kube-green.dev/force-awake"
+
+// isForcedAwake returns true if the namespace is annotated with the
+// ForceAwakeAnnotation.
+func isForcedAwake(namespace client.Object) bool {
+	return namespace.GetAnnotations()[ForceAwakeAnnotation] == "true"
+}
+
+// isNamespaceForcedAwake returns true if the namespace is kept awake by the
+// ForceAwakeAnnotation or, as all the namespaces, by WakeAll or Teardown.
+// With WatchNamespaces the namespaces cannot be read, so the annotation is
+// ignored.
+func (r *SleepInfoReconciler) isNamespaceForcedAwake(ctx context.Context, namespaceName string) (bool, error) {
+	if r.WakeAll || r.Teardown {
+		return true, nil
+	}
+	if len(r.WatchNamespaces) > 0 {
+		return false, nil
+	}
+	namespace := &v1.Namespace{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: namespaceName}, namespace); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return isForcedAwake(namespace), nil
+}
+
+// getSleepInfosOfNamespace returns the requests of all the SleepInfo of the
+// namespace.
+func (r *SleepInfoReconciler) getSleepInfosOfNamespace(namespace client.Object) []reconcile.Request {
+	sleepInfos := kubegreenv1alpha1.SleepInfoList{}
+	if err := r.Client.List(context.Background(), &sleepInfos, client.InNamespace(namespace.GetName())); err != nil {
+		r.Log.Error(err, "fails to list sleepinfos", "namespace", namespace.GetName())
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, sleepInfo := range sleepInfos.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: sleepInfo.Namespace, Name: sleepInfo.Name},
+		})
+	}
+	return requests
+}
+
+// namespaceForcedAwakePredicate filters the updates of the namespaces
+// annotated with the ForceAwakeAnnotation, which are woken up immediately.
+var namespaceForcedAwakePredicate = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return false },
+	DeleteFunc:  func(event.DeleteEvent) bool { return false },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if e.ObjectOld == nil || e.ObjectNew == nil {
+			return false
+		}
+		return !isForcedAwake(e.ObjectOld) && isForcedAwake(e.ObjectNew)
+	},
+}
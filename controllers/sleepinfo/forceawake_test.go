@@ -0,0 +1,176 @@
+package sleepinfo
+
+import (
+	"context"
+	"testing"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/deployments"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/metrics"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestForceAwake(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	getNamespace := func(name string, annotations map[string]string) *v1.Namespace {
+		return &v1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: annotations},
+		}
+	}
+	forcedAwake := getNamespace("forced", map[string]string{ForceAwakeAnnotation: "true"})
+	notForced := getNamespace("not-forced", map[string]string{ForceAwakeAnnotation: "false"})
+
+	t.Run("isNamespaceForcedAwake", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(forcedAwake, notForced).Build()
+		r := SleepInfoReconciler{Client: c}
+
+		tests := []struct {
+			namespace string
+			expected  bool
+		}{
+			{namespace: "forced", expected: true},
+			{namespace: "not-forced", expected: false},
+			{namespace: "not-existent", expected: false},
+		}
+		for _, test := range tests {
+			isForcedAwake, err := r.isNamespaceForcedAwake(context.Background(), test.namespace)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, isForcedAwake, test.namespace)
+		}
+
+		r.WakeAll = true
+		for _, test := range tests {
+			isForcedAwake, err := r.isNamespaceForcedAwake(context.Background(), test.namespace)
+			require.NoError(t, err)
+			require.True(t, isForcedAwake, test.namespace)
+		}
+	})
+
+	t.Run("isNamespaceForcedAwake with WatchNamespaces", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(forcedAwake).Build()
+		r := SleepInfoReconciler{Client: c, WatchNamespaces: []string{"forced"}}
+
+		isForcedAwake, err := r.isNamespaceForcedAwake(context.Background(), "forced")
+		require.NoError(t, err)
+		require.False(t, isForcedAwake, "the namespaces are not read")
+
+		r.WakeAll = true
+		isForcedAwake, err = r.isNamespaceForcedAwake(context.Background(), "forced")
+		require.NoError(t, err)
+		require.True(t, isForcedAwake)
+	})
+
+	t.Run("wake all a SleepInfo without wake up", func(t *testing.T) {
+		namespace := "my-namespace"
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: "working-hours", Namespace: namespace},
+			Spec: kubegreenv1alpha1.SleepInfoSpec{
+				Weekdays:  "*",
+				SleepTime: "20:00",
+				TimeZone:  "UTC",
+			},
+		}
+		var replicas0 int32 = 0
+		api := deployments.GetMock(deployments.MockSpec{Name: "api", Namespace: namespace, Replicas: &replicas0})
+		objects := []client.Object{
+			&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}},
+			sleepInfo,
+			getSecret(mockSecretSpec{
+				namespace: namespace,
+				name:      "sleepinfo-working-hours",
+				data: withStateChecksum(map[string][]byte{
+					lastOperationKey:       []byte(sleepOperation),
+					lastScheduleKey:        []byte("2023-01-09T20:00:00Z"),
+					replicasBeforeSleepKey: []byte(`[{"name":"api","replicas":3}]`),
+				}),
+			}),
+			&api,
+		}
+		r := SleepInfoReconciler{
+			Client:  fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+			Log:     zap.New(zap.UseDevMode(true)),
+			Metrics: metrics.SetupMetricsOrDie("kube_green"),
+			Clock:   mockClock{now: "2023-01-10T09:00:00Z", t: t},
+			WakeAll: true,
+		}
+
+		_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(sleepInfo)})
+		require.NoError(t, err)
+		deployment := appsv1.Deployment{}
+		require.NoError(t, r.Client.Get(context.Background(), client.ObjectKeyFromObject(&api), &deployment))
+		require.Equal(t, int32(3), *deployment.Spec.Replicas)
+		updatedSleepInfo := kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, r.Client.Get(context.Background(), client.ObjectKeyFromObject(sleepInfo), &updatedSleepInfo))
+		require.Equal(t, wakeUpOperation, updatedSleepInfo.Status.OperationType)
+	})
+
+	t.Run("getSleepInfosOfNamespace", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&kubegreenv1alpha1.SleepInfo{ObjectMeta: metav1.ObjectMeta{Name: "working-hours", Namespace: "forced"}},
+			&kubegreenv1alpha1.SleepInfo{ObjectMeta: metav1.ObjectMeta{Name: "weekend", Namespace: "forced"}},
+			&kubegreenv1alpha1.SleepInfo{ObjectMeta: metav1.ObjectMeta{Name: "working-hours", Namespace: "other"}},
+		).Build()
+		r := SleepInfoReconciler{Client: c, Log: zap.New(zap.UseDevMode(true))}
+
+		require.ElementsMatch(t, []reconcile.Request{
+			{NamespacedName: types.NamespacedName{Namespace: "forced", Name: "working-hours"}},
+			{NamespacedName: types.NamespacedName{Namespace: "forced", Name: "weekend"}},
+		}, r.getSleepInfosOfNamespace(forcedAwake))
+	})
+
+	t.Run("namespaceForcedAwakePredicate", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			old      *v1.Namespace
+			new      *v1.Namespace
+			expected bool
+		}{
+			{
+				name:     "annotation added",
+				old:      getNamespace("ns", nil),
+				new:      getNamespace("ns", map[string]string{ForceAwakeAnnotation: "true"}),
+				expected: true,
+			},
+			{
+				name:     "annotation set to true",
+				old:      getNamespace("ns", map[string]string{ForceAwakeAnnotation: "false"}),
+				new:      getNamespace("ns", map[string]string{ForceAwakeAnnotation: "true"}),
+				expected: true,
+			},
+			{
+				name:     "annotation removed",
+				old:      getNamespace("ns", map[string]string{ForceAwakeAnnotation: "true"}),
+				new:      getNamespace("ns", nil),
+				expected: false,
+			},
+			{
+				name:     "other annotation changed",
+				old:      getNamespace("ns", nil),
+				new:      getNamespace("ns", map[string]string{"foo": "bar"}),
+				expected: false,
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				require.Equal(t, test.expected, namespaceForcedAwakePredicate.Update(event.UpdateEvent{ObjectOld: test.old, ObjectNew: test.new}))
+			})
+		}
+
+		require.False(t, namespaceForcedAwakePredicate.Create(event.CreateEvent{Object: forcedAwake}))
+	})
+}
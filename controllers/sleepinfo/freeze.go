@@ -0,0 +1,56 @@
+package sleepinfo
+
+import (
+	"context"
+	"time"
+
+	configv1alpha1 "github.com/kube-green/kube-green/api/config/v1alpha1"
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// getFreezeWindow returns the change freeze window active at now which skips
+// the scheduled operation of type operationType, nil if none. The sleeps are
+// skipped by all the windows, the wake ups only by the windows which do not
+// allow them.
+func (r *SleepInfoReconciler) getFreezeWindow(operationType string, now time.Time) *configv1alpha1.FreezeWindow {
+	for i, freezeWindow := range r.FreezeWindows {
+		if !freezeWindow.IsActive(now) {
+			continue
+		}
+		if operationType == wakeUpOperation && freezeWindow.AllowWakeUp {
+			continue
+		}
+		return &r.FreezeWindows[i]
+	}
+	return nil
+}
+
+// getRequeueAfterFreeze returns requeueAfter, shortened to the end of the
+// freeze window so that the skipped operation is retried as soon as the
+// freeze ends.
+func getRequeueAfterFreeze(requeueAfter time.Duration, freezeWindow *configv1alpha1.FreezeWindow, now time.Time) time.Duration {
+	if freezeWindow == nil {
+		return requeueAfter
+	}
+	if untilEnd := freezeWindow.End.Sub(now); untilEnd < requeueAfter {
+		return untilEnd
+	}
+	return requeueAfter
+}
+
+// updateFrozenBy records in the status of the SleepInfo the name of the
+// freeze window which is skipping its operations, if changed.
+func (r *SleepInfoReconciler) updateFrozenBy(ctx context.Context, currentSleepInfo *kubegreenv1alpha1.SleepInfo, freezeWindow *configv1alpha1.FreezeWindow) error {
+	frozenBy := ""
+	if freezeWindow != nil {
+		frozenBy = freezeWindow.Name
+	}
+	if currentSleepInfo.Status.FrozenBy == frozenBy {
+		return nil
+	}
+	sleepInfo := currentSleepInfo.DeepCopy()
+	sleepInfo.Status.FrozenBy = frozenBy
+	return r.Status().Patch(ctx, sleepInfo, client.MergeFrom(currentSleepInfo))
+}
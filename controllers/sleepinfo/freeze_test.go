@@ -0,0 +1,137 @@
+package sleepinfo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	configv1alpha1 "github.com/kube-green/kube-green/api/config/v1alpha1"
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetFreezeWindow(t *testing.T) {
+	releaseWeek := configv1alpha1.FreezeWindow{
+		Name:        "release-week",
+		Start:       metav1.NewTime(time.Date(2024, 12, 16, 0, 0, 0, 0, time.UTC)),
+		End:         metav1.NewTime(time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC)),
+		AllowWakeUp: true,
+	}
+	audit := configv1alpha1.FreezeWindow{
+		Name:  "audit",
+		Start: metav1.NewTime(time.Date(2024, 12, 20, 0, 0, 0, 0, time.UTC)),
+		End:   metav1.NewTime(time.Date(2024, 12, 23, 0, 0, 0, 0, time.UTC)),
+	}
+	r := SleepInfoReconciler{FreezeWindows: []configv1alpha1.FreezeWindow{releaseWeek, audit}}
+
+	tests := []struct {
+		name          string
+		operationType string
+		now           time.Time
+		expected      *configv1alpha1.FreezeWindow
+	}{
+		{
+			name:          "sleep before the freeze",
+			operationType: sleepOperation,
+			now:           time.Date(2024, 12, 15, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "sleep during the freeze",
+			operationType: sleepOperation,
+			now:           time.Date(2024, 12, 16, 20, 0, 0, 0, time.UTC),
+			expected:      &releaseWeek,
+		},
+		{
+			name:          "wake up allowed during the freeze",
+			operationType: wakeUpOperation,
+			now:           time.Date(2024, 12, 17, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "wake up during the freeze which does not allow it",
+			operationType: wakeUpOperation,
+			now:           time.Date(2024, 12, 20, 8, 0, 0, 0, time.UTC),
+			expected:      &audit,
+		},
+		{
+			name:          "sleep after the freeze",
+			operationType: sleepOperation,
+			now:           time.Date(2024, 12, 23, 20, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, r.getFreezeWindow(test.operationType, test.now))
+		})
+	}
+
+	t.Run("without freeze windows", func(t *testing.T) {
+		r := SleepInfoReconciler{}
+		require.Nil(t, r.getFreezeWindow(sleepOperation, time.Date(2024, 12, 16, 20, 0, 0, 0, time.UTC)))
+	})
+}
+
+func TestGetRequeueAfterFreeze(t *testing.T) {
+	now := time.Date(2024, 12, 20, 20, 0, 0, 0, time.UTC)
+	freezeWindow := &configv1alpha1.FreezeWindow{
+		Name:  "release-week",
+		Start: metav1.NewTime(time.Date(2024, 12, 16, 0, 0, 0, 0, time.UTC)),
+		End:   metav1.NewTime(time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC)),
+	}
+
+	require.Equal(t, 24*time.Hour, getRequeueAfterFreeze(24*time.Hour, nil, now))
+	require.Equal(t, 4*time.Hour, getRequeueAfterFreeze(24*time.Hour, freezeWindow, now))
+	require.Equal(t, time.Hour, getRequeueAfterFreeze(time.Hour, freezeWindow, now))
+}
+
+func TestUpdateFrozenBy(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+	freezeWindow := &configv1alpha1.FreezeWindow{Name: "release-week"}
+
+	getFrozenBy := func(t *testing.T, r SleepInfoReconciler, sleepInfo *kubegreenv1alpha1.SleepInfo) string {
+		t.Helper()
+		updatedSleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, r.Get(context.Background(), client.ObjectKeyFromObject(sleepInfo), updatedSleepInfo))
+		return updatedSleepInfo.Status.FrozenBy
+	}
+
+	t.Run("frozen", func(t *testing.T) {
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "my-namespace"},
+			Status:     kubegreenv1alpha1.SleepInfoStatus{OperationType: wakeUpOperation},
+		}
+		r := SleepInfoReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(sleepInfo).Build(),
+		}
+		require.NoError(t, r.updateFrozenBy(context.Background(), sleepInfo, freezeWindow))
+		require.Equal(t, "release-week", getFrozenBy(t, r, sleepInfo))
+	})
+
+	t.Run("freeze ended", func(t *testing.T) {
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "my-namespace"},
+			Status:     kubegreenv1alpha1.SleepInfoStatus{OperationType: wakeUpOperation, FrozenBy: "release-week"},
+		}
+		r := SleepInfoReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(sleepInfo).Build(),
+		}
+		require.NoError(t, r.updateFrozenBy(context.Background(), sleepInfo, nil))
+		require.Empty(t, getFrozenBy(t, r, sleepInfo))
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "my-namespace"},
+		}
+		// the status is not patched, so the missing SleepInfo is not an error.
+		r := SleepInfoReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).Build(),
+		}
+		require.NoError(t, r.updateFrozenBy(context.Background(), sleepInfo, nil))
+	})
+}
@@ -0,0 +1,53 @@
+package sleepinfo
+
+import (
+	"context"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// lastOperationLookBehind is how far the last scheduled operation is
+// searched. The schedules repeat every week, or every month with the L and #
+// weekdays, so a month is enough.
+const lastOperationLookBehind = 31 * 24 * time.Hour
+
+// isSpecChanged returns true if the spec of the SleepInfo changed since the
+// last reconcile which handled it.
+func isSpecChanged(sleepInfo *kubegreenv1alpha1.SleepInfo) bool {
+	return sleepInfo.Status.ObservedGeneration != sleepInfo.Generation
+}
+
+// isAwakeBySchedule returns true if the last operation scheduled by the
+// SleepInfo before now is a wake up, i.e. the namespace should be awake at now.
+func isAwakeBySchedule(sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time) (bool, error) {
+	operations, err := PreviewOperations(sleepInfo, now.Add(-lastOperationLookBehind), now)
+	if err != nil {
+		return false, err
+	}
+	if len(operations) == 0 {
+		return false, nil
+	}
+	return operations[len(operations)-1].Type == wakeUpOperation, nil
+}
+
+// isWakeUpBySpecChange returns true if the spec of the sleeping SleepInfo
+// changed, e.g. its schedule has been edited, and the new schedule says that
+// the namespace should be awake at now. The SleepInfo awake are never put to
+// sleep by a change of the spec, but only at their next sleep.
+func (r *SleepInfoReconciler) isWakeUpBySpecChange(sleepInfo *kubegreenv1alpha1.SleepInfo, data SleepInfoData, now time.Time) (bool, error) {
+	if !isSpecChanged(sleepInfo) || !data.IsWakeUpOperation() {
+		return false, nil
+	}
+	return isAwakeBySchedule(r.getSleepInfoWithDefaults(sleepInfo), now)
+}
+
+// updateObservedGeneration records in the status of the SleepInfo that its
+// current spec has been handled.
+func (r *SleepInfoReconciler) updateObservedGeneration(ctx context.Context, currentSleepInfo *kubegreenv1alpha1.SleepInfo) error {
+	sleepInfo := currentSleepInfo.DeepCopy()
+	sleepInfo.Status.ObservedGeneration = sleepInfo.Generation
+	return r.Status().Patch(ctx, sleepInfo, client.MergeFrom(currentSleepInfo))
+}
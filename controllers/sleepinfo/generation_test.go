@@ -0,0 +1,106 @@
+package sleepinfo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestIsWakeUpBySpecChange(t *testing.T) {
+	getSleepInfo := func(sleepTime string, observedGeneration int64) *kubegreenv1alpha1.SleepInfo {
+		return &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Generation: 2},
+			Spec: kubegreenv1alpha1.SleepInfoSpec{
+				Weekdays:   "*",
+				SleepTime:  sleepTime,
+				WakeUpTime: "08:00",
+			},
+			Status: kubegreenv1alpha1.SleepInfoStatus{ObservedGeneration: observedGeneration},
+		}
+	}
+	sleeping := SleepInfoData{CurrentOperationType: wakeUpOperation}
+	now := time.Date(2021, 3, 23, 22, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		sleepInfo       *kubegreenv1alpha1.SleepInfo
+		data            SleepInfoData
+		defaultTimeZone string
+		expected        bool
+	}{
+		{
+			name:      "spec not changed",
+			sleepInfo: getSleepInfo("23:00", 2),
+			data:      sleeping,
+		},
+		{
+			name:      "awake",
+			sleepInfo: getSleepInfo("23:00", 1),
+			data:      SleepInfoData{CurrentOperationType: sleepOperation},
+		},
+		{
+			name:      "new schedule says awake",
+			sleepInfo: getSleepInfo("23:00", 1),
+			data:      sleeping,
+			expected:  true,
+		},
+		{
+			name:      "new schedule says asleep",
+			sleepInfo: getSleepInfo("20:00", 1),
+			data:      sleeping,
+		},
+		{
+			name:            "new schedule in the default time zone says asleep",
+			sleepInfo:       getSleepInfo("23:00", 1),
+			data:            sleeping,
+			defaultTimeZone: "Europe/Rome",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			r := SleepInfoReconciler{DefaultTimeZone: test.defaultTimeZone}
+			isWakeUp, err := r.isWakeUpBySpecChange(test.sleepInfo, test.data, now)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, isWakeUp)
+		})
+	}
+}
+
+func TestUpdateObservedGeneration(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "sleepinfo",
+			Namespace:  "my-namespace",
+			Generation: 3,
+		},
+		Status: kubegreenv1alpha1.SleepInfoStatus{
+			ObservedGeneration: 2,
+			OperationType:      sleepOperation,
+		},
+	}
+	objects := []client.Object{sleepInfo}
+	r := SleepInfoReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+	}
+	require.True(t, isSpecChanged(sleepInfo))
+
+	require.NoError(t, r.updateObservedGeneration(context.Background(), sleepInfo))
+
+	updatedSleepInfo := &kubegreenv1alpha1.SleepInfo{}
+	require.NoError(t, r.Get(context.Background(), client.ObjectKeyFromObject(sleepInfo), updatedSleepInfo))
+	require.Equal(t, int64(3), updatedSleepInfo.Status.ObservedGeneration)
+	require.Equal(t, sleepOperation, updatedSleepInfo.Status.OperationType)
+	require.False(t, isSpecChanged(updatedSleepInfo))
+}
@@ -0,0 +1,195 @@
+package idle
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/promquery"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/internal/tracing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ReplicasAnnotation is set on the Deployments put to sleep because idle,
+	// with the replicas to restore on wake up.
+	ReplicasAnnotation = "kube-green.com/idle-replicas"
+	// WokenUpAtAnnotation is set on the Deployments woken up after an idle
+	// sleep: they are not put to sleep again before the idle period passes.
+	WokenUpAtAnnotation = "kube-green.com/idle-woken-up-at"
+)
+
+type idleDeployments struct {
+	resource.ResourceClient
+	querier promquery.Querier
+}
+
+// Sleep puts to sleep the Deployments of the namespace which have been idle
+// for the period set in the SleepInfo, and returns their names. If a Deployment
+// put to sleep has been scaled up manually, it is handled as woken up.
+func Sleep(ctx context.Context, res resource.ResourceClient, querier promquery.Querier, namespace string, now time.Time) (names []string, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "idle.sleep")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	idleSleep := res.SleepInfo.GetIdleSleep()
+	if idleSleep == nil {
+		return nil, nil
+	}
+	d := idleDeployments{ResourceClient: res, querier: querier}
+	deployments, err := d.getDeployments(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	names = []string{}
+	for _, deployment := range deployments {
+		deployment := deployment
+		if _, ok := deployment.Annotations[ReplicasAnnotation]; ok {
+			if *deployment.Spec.Replicas != 0 {
+				if err := d.setWokenUp(ctx, deployment, nil, now); err != nil {
+					return names, err
+				}
+			}
+			continue
+		}
+		if *deployment.Spec.Replicas == 0 || isWokenUpRecently(deployment, idleSleep.Period.Duration, now) || d.IsKeptAwake(&deployment) {
+			continue
+		}
+
+		isIdle, err := d.querier.IsTrue(ctx, getIdleQuery(deployment, *idleSleep), now)
+		if err != nil {
+			return names, fmt.Errorf("fails to check if deployment %s is idle: %s", deployment.Name, err)
+		}
+		if !isIdle {
+			continue
+		}
+
+		newDeploy := deployment.DeepCopy()
+		if newDeploy.Annotations == nil {
+			newDeploy.Annotations = map[string]string{}
+		}
+		newDeploy.Annotations[ReplicasAnnotation] = strconv.Itoa(int(*deployment.Spec.Replicas))
+		delete(newDeploy.Annotations, WokenUpAtAnnotation)
+		*newDeploy.Spec.Replicas = 0
+		if err := d.Patch(ctx, &deployment, newDeploy); err != nil {
+			return names, err
+		}
+		names = append(names, deployment.Name)
+	}
+	return names, nil
+}
+
+// WakeUp restores the replicas of the Deployments of the namespace put to
+// sleep because idle, and returns their names.
+func WakeUp(ctx context.Context, res resource.ResourceClient, namespace string, now time.Time) (names []string, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "idle.wakeUp")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	d := idleDeployments{ResourceClient: res}
+	deployments, err := d.getDeployments(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	names = []string{}
+	for _, deployment := range deployments {
+		value, ok := deployment.Annotations[ReplicasAnnotation]
+		if !ok {
+			continue
+		}
+		var replicas *int32
+		if *deployment.Spec.Replicas == 0 {
+			originalReplicas, err := strconv.ParseInt(value, 10, 32)
+			if err != nil {
+				d.Log.Info("invalid idle replicas annotation", "deployment", deployment.Name, "value", value)
+				continue
+			}
+			replicas = getPtr(int32(originalReplicas))
+		}
+		if err := d.setWokenUp(ctx, deployment, replicas, now); err != nil {
+			return names, err
+		}
+		names = append(names, deployment.Name)
+	}
+	return names, nil
+}
+
+func (d idleDeployments) getDeployments(ctx context.Context, namespace string) ([]appsv1.Deployment, error) {
+	deploymentList := appsv1.DeploymentList{}
+	if err := d.Client.List(ctx, &deploymentList, &client.ListOptions{
+		Namespace: namespace,
+		Limit:     500,
+	}); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	deployments := []appsv1.Deployment{}
+	for _, deployment := range deploymentList.Items {
+		deployment := deployment
+		if deployment.Spec.Replicas == nil || resource.IsExcluded("Deployment", &deployment, d.SleepInfo.GetExcludeRef()) || !d.SleepInfo.IsManagedByClass(deployment.Labels) {
+			continue
+		}
+		// the idle Deployments are scaled to zero, which is not allowed by the other strategies.
+		if d.SleepInfo.GetSleepStrategy(deployment.Annotations) != kubegreenv1alpha1.SleepStrategyScaleToZero {
+			continue
+		}
+		deployments = append(deployments, deployment)
+	}
+	return deployments, nil
+}
+
+// setWokenUp removes the idle replicas annotation from the Deployment, and
+// sets its replicas if not nil.
+func (d idleDeployments) setWokenUp(ctx context.Context, deployment appsv1.Deployment, replicas *int32, now time.Time) error {
+	newDeploy := deployment.DeepCopy()
+	delete(newDeploy.Annotations, ReplicasAnnotation)
+	newDeploy.Annotations[WokenUpAtAnnotation] = now.UTC().Format(time.RFC3339)
+	if replicas != nil {
+		newDeploy.Spec.Replicas = replicas
+	}
+	return d.Patch(ctx, &deployment, newDeploy)
+}
+
+func isWokenUpRecently(deployment appsv1.Deployment, period time.Duration, now time.Time) bool {
+	value, ok := deployment.Annotations[WokenUpAtAnnotation]
+	if !ok {
+		return false
+	}
+	wokenUpAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return false
+	}
+	return now.Before(wokenUpAt.Add(period))
+}
+
+// getIdleQuery returns the PromQL query which returns a sample if the pods
+// of the Deployment have been idle in the period.
+func getIdleQuery(deployment appsv1.Deployment, idleSleep kubegreenv1alpha1.IdleSleep) string {
+	podRegex := fmt.Sprintf("%s-[a-z0-9]+-[a-z0-9]+", regexp.QuoteMeta(deployment.Name))
+	period := fmt.Sprintf("%ds", int64(idleSleep.Period.Seconds()))
+
+	query := fmt.Sprintf(
+		`sum(rate(container_cpu_usage_seconds_total{namespace=%q,pod=~%q,container!=""}[%s])) < %s`,
+		deployment.Namespace, podRegex, period, formatFloat(idleSleep.GetCPUThreshold()),
+	)
+	if idleSleep.NetworkThreshold != nil {
+		query = fmt.Sprintf(
+			`(%s) and on() (sum(rate(container_network_receive_bytes_total{namespace=%q,pod=~%q}[%s])) < %s)`,
+			query, deployment.Namespace, podRegex, period, formatFloat(idleSleep.NetworkThreshold.AsApproximateFloat64()),
+		)
+	}
+	return query
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func getPtr[T any](v T) *T {
+	return &v
+}
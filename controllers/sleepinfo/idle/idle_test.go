@@ -0,0 +1,244 @@
+package idle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/deployments"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+type querierMock struct {
+	queries []string
+	isTrue  bool
+	err     error
+}
+
+func (q *querierMock) IsTrue(_ context.Context, query string, _ time.Time) (bool, error) {
+	q.queries = append(q.queries, query)
+	return q.isTrue, q.err
+}
+
+func TestSleep(t *testing.T) {
+	testLogger := zap.New(zap.UseDevMode(true))
+	now := time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)
+	namespace := "my-namespace"
+	sleepInfo := &v1alpha1.SleepInfo{
+		Spec: v1alpha1.SleepInfoSpec{
+			IdleSleep: &v1alpha1.IdleSleep{
+				Period: metav1.Duration{Duration: 2 * time.Hour},
+			},
+			ExcludeRef: []v1alpha1.ExcludeRef{
+				{Kind: "Deployment", Name: "excluded"},
+			},
+		},
+	}
+	getDeployment := func(name string, replicas int32, annotations map[string]string) *appsv1.Deployment {
+		deployment := deployments.GetMock(deployments.MockSpec{
+			Name:           name,
+			Namespace:      namespace,
+			Replicas:       &replicas,
+			PodAnnotations: annotations,
+		})
+		return &deployment
+	}
+
+	tests := []struct {
+		name             string
+		objects          []runtime.Object
+		querier          *querierMock
+		expectedNames    []string
+		expectedQueries  int
+		expectedReplicas map[string]int32
+		expectedError    string
+	}{
+		{
+			name: "puts to sleep the idle deployments",
+			objects: []runtime.Object{
+				getDeployment("api", 3, nil),
+				getDeployment("excluded", 1, nil),
+				getDeployment("zero", 0, nil),
+			},
+			querier:          &querierMock{isTrue: true},
+			expectedNames:    []string{"api"},
+			expectedQueries:  1,
+			expectedReplicas: map[string]int32{"api": 0, "excluded": 1, "zero": 0},
+		},
+		{
+			name: "does not put to sleep the deployments in use",
+			objects: []runtime.Object{
+				getDeployment("api", 3, nil),
+			},
+			querier:          &querierMock{isTrue: false},
+			expectedNames:    []string{},
+			expectedQueries:  1,
+			expectedReplicas: map[string]int32{"api": 3},
+		},
+		{
+			name: "does not put to sleep the deployments woken up recently",
+			objects: []runtime.Object{
+				getDeployment("api", 3, map[string]string{WokenUpAtAnnotation: now.Add(-time.Hour).Format(time.RFC3339)}),
+				getDeployment("worker", 1, map[string]string{WokenUpAtAnnotation: now.Add(-3 * time.Hour).Format(time.RFC3339)}),
+			},
+			querier:          &querierMock{isTrue: true},
+			expectedNames:    []string{"worker"},
+			expectedQueries:  1,
+			expectedReplicas: map[string]int32{"api": 3, "worker": 0},
+		},
+		{
+			name: "handles as woken up the sleeping deployments scaled up manually",
+			objects: []runtime.Object{
+				getDeployment("api", 2, map[string]string{ReplicasAnnotation: "3"}),
+				getDeployment("worker", 0, map[string]string{ReplicasAnnotation: "1"}),
+			},
+			querier:          &querierMock{isTrue: true},
+			expectedNames:    []string{},
+			expectedReplicas: map[string]int32{"api": 2, "worker": 0},
+		},
+		{
+			name: "fails to query prometheus",
+			objects: []runtime.Object{
+				getDeployment("api", 3, nil),
+			},
+			querier:          &querierMock{err: errors.New("prometheus error")},
+			expectedQueries:  1,
+			expectedReplicas: map[string]int32{"api": 3},
+			expectedError:    "fails to check if deployment api is idle: prometheus error",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithRuntimeObjects(test.objects...).Build()
+			res := resource.ResourceClient{
+				Client:    c,
+				Log:       testLogger,
+				SleepInfo: sleepInfo,
+			}
+
+			names, err := Sleep(context.Background(), res, test.querier, namespace, now)
+			if test.expectedError != "" {
+				require.EqualError(t, err, test.expectedError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expectedNames, names)
+			}
+			require.Len(t, test.querier.queries, test.expectedQueries)
+			requireReplicas(t, c, namespace, test.expectedReplicas)
+		})
+	}
+
+	t.Run("annotates the deployments put to sleep and woken up manually", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithRuntimeObjects(
+			getDeployment("api", 3, nil),
+			getDeployment("worker", 2, map[string]string{ReplicasAnnotation: "1"}),
+		).Build()
+		res := resource.ResourceClient{Client: c, Log: testLogger, SleepInfo: sleepInfo}
+
+		_, err := Sleep(context.Background(), res, &querierMock{isTrue: true}, namespace, now)
+		require.NoError(t, err)
+
+		api := appsv1.Deployment{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: "api"}, &api))
+		require.Equal(t, "3", api.Annotations[ReplicasAnnotation])
+
+		worker := appsv1.Deployment{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: "worker"}, &worker))
+		require.NotContains(t, worker.Annotations, ReplicasAnnotation)
+		require.Equal(t, "2021-03-23T20:00:00Z", worker.Annotations[WokenUpAtAnnotation])
+	})
+
+	t.Run("without idle sleep", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithRuntimeObjects(getDeployment("api", 3, nil)).Build()
+		res := resource.ResourceClient{Client: c, Log: testLogger, SleepInfo: &v1alpha1.SleepInfo{}}
+		querier := &querierMock{isTrue: true}
+
+		names, err := Sleep(context.Background(), res, querier, namespace, now)
+		require.NoError(t, err)
+		require.Empty(t, names)
+		require.Empty(t, querier.queries)
+	})
+}
+
+func TestWakeUp(t *testing.T) {
+	testLogger := zap.New(zap.UseDevMode(true))
+	now := time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)
+	namespace := "my-namespace"
+	getDeployment := func(name string, replicas int32, annotations map[string]string) *appsv1.Deployment {
+		deployment := deployments.GetMock(deployments.MockSpec{
+			Name:           name,
+			Namespace:      namespace,
+			Replicas:       &replicas,
+			PodAnnotations: annotations,
+		})
+		return &deployment
+	}
+
+	c := fake.NewClientBuilder().WithRuntimeObjects(
+		getDeployment("api", 0, map[string]string{ReplicasAnnotation: "3"}),
+		getDeployment("worker", 2, map[string]string{ReplicasAnnotation: "1"}),
+		getDeployment("invalid", 0, map[string]string{ReplicasAnnotation: "not-a-number"}),
+		getDeployment("other", 0, nil),
+	).Build()
+	res := resource.ResourceClient{
+		Client:    c,
+		Log:       testLogger,
+		SleepInfo: &v1alpha1.SleepInfo{},
+	}
+
+	names, err := WakeUp(context.Background(), res, namespace, now)
+	require.NoError(t, err)
+	require.Equal(t, []string{"api", "worker"}, names)
+	requireReplicas(t, c, namespace, map[string]int32{"api": 3, "worker": 2, "invalid": 0, "other": 0})
+
+	api := appsv1.Deployment{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: "api"}, &api))
+	require.NotContains(t, api.Annotations, ReplicasAnnotation)
+	require.Equal(t, "2021-03-23T20:00:00Z", api.Annotations[WokenUpAtAnnotation])
+}
+
+func TestGetIdleQuery(t *testing.T) {
+	deployment := deployments.GetMock(deployments.MockSpec{
+		Name:      "my.api",
+		Namespace: "my-namespace",
+	})
+
+	t.Run("with cpu threshold", func(t *testing.T) {
+		cpuThreshold := apiresource.MustParse("50m")
+		query := getIdleQuery(deployment, v1alpha1.IdleSleep{
+			Period:       metav1.Duration{Duration: 90 * time.Minute},
+			CPUThreshold: &cpuThreshold,
+		})
+		require.Equal(t, `sum(rate(container_cpu_usage_seconds_total{namespace="my-namespace",pod=~"my\\.api-[a-z0-9]+-[a-z0-9]+",container!=""}[5400s])) < 0.05`, query)
+	})
+
+	t.Run("with network threshold", func(t *testing.T) {
+		networkThreshold := apiresource.MustParse("1Ki")
+		query := getIdleQuery(deployment, v1alpha1.IdleSleep{
+			Period:           metav1.Duration{Duration: time.Hour},
+			NetworkThreshold: &networkThreshold,
+		})
+		require.Equal(t, `(sum(rate(container_cpu_usage_seconds_total{namespace="my-namespace",pod=~"my\\.api-[a-z0-9]+-[a-z0-9]+",container!=""}[3600s])) < 0.01) and on() (sum(rate(container_network_receive_bytes_total{namespace="my-namespace",pod=~"my\\.api-[a-z0-9]+-[a-z0-9]+"}[3600s])) < 1024)`, query)
+	})
+}
+
+func requireReplicas(t *testing.T, c client.Client, namespace string, expected map[string]int32) {
+	t.Helper()
+	for name, replicas := range expected {
+		deployment := appsv1.Deployment{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, &deployment))
+		require.Equal(t, replicas, *deployment.Spec.Replicas, name)
+	}
+}
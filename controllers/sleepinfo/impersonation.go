@@ -0,0 +1,88 @@
+package sleepinfo
+
+import (
+	"fmt"
+	"sync"
+
+	configv1alpha1 "github.com/kube-green/kube-green/api/config/v1alpha1"
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Impersonator returns the clients which impersonate the ServiceAccounts of
+// the namespaces, so that the resources of a SleepInfo are handled only with
+// the permissions delegated by the owner of the namespace.
+type Impersonator struct {
+	// ServiceAccountName is impersonated for the SleepInfo which do not set
+	// their own ServiceAccount. If empty, they are handled with the client of
+	// the controller.
+	ServiceAccountName string
+	// Required fails the operations of the SleepInfo without a ServiceAccount
+	// to impersonate.
+	Required bool
+	// NewClient returns a client which impersonates the user.
+	NewClient func(username string) (client.Client, error)
+
+	mu      sync.Mutex
+	clients map[string]client.Client
+}
+
+// NewImpersonator returns an Impersonator whose clients impersonate the
+// ServiceAccounts with the restConfig of the controller.
+func NewImpersonator(restConfig *rest.Config, scheme *runtime.Scheme, mapper meta.RESTMapper, impersonation *configv1alpha1.Impersonation) *Impersonator {
+	impersonator := &Impersonator{
+		NewClient: func(username string) (client.Client, error) {
+			config := rest.CopyConfig(restConfig)
+			config.Impersonate = rest.ImpersonationConfig{UserName: username}
+			return client.New(config, client.Options{Scheme: scheme, Mapper: mapper})
+		},
+	}
+	if impersonation != nil {
+		impersonator.ServiceAccountName = impersonation.ServiceAccountName
+		impersonator.Required = impersonation.Required
+	}
+	return impersonator
+}
+
+// GetServiceAccountName returns the ServiceAccount impersonated to handle the
+// resources of the SleepInfo, empty if none.
+func (i *Impersonator) GetServiceAccountName(sleepInfo *kubegreenv1alpha1.SleepInfo) string {
+	if sleepInfo.Spec.ServiceAccountName != "" {
+		return sleepInfo.Spec.ServiceAccountName
+	}
+	return i.ServiceAccountName
+}
+
+// GetClient returns the client which impersonates the ServiceAccount of the
+// SleepInfo, or defaultClient if the SleepInfo has no ServiceAccount and the
+// impersonation is not required. The clients are created once per
+// ServiceAccount.
+func (i *Impersonator) GetClient(sleepInfo *kubegreenv1alpha1.SleepInfo, defaultClient client.Client) (client.Client, error) {
+	serviceAccountName := i.GetServiceAccountName(sleepInfo)
+	if serviceAccountName == "" {
+		if i.Required {
+			return nil, fmt.Errorf("impersonation is required, but the SleepInfo has no serviceAccountName")
+		}
+		return defaultClient, nil
+	}
+	username := fmt.Sprintf("system:serviceaccount:%s:%s", sleepInfo.Namespace, serviceAccountName)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if c, ok := i.clients[username]; ok {
+		return c, nil
+	}
+	c, err := i.NewClient(username)
+	if err != nil {
+		return nil, fmt.Errorf("fails to create client impersonating %s: %s", username, err)
+	}
+	if i.clients == nil {
+		i.clients = map[string]client.Client{}
+	}
+	i.clients[username] = c
+	return c, nil
+}
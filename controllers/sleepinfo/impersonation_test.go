@@ -0,0 +1,78 @@
+package sleepinfo
+
+import (
+	"fmt"
+	"testing"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestImpersonator(t *testing.T) {
+	defaultClient := fake.NewClientBuilder().Build()
+	getSleepInfo := func(serviceAccountName string) *kubegreenv1alpha1.SleepInfo {
+		return &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "team-a"},
+			Spec:       kubegreenv1alpha1.SleepInfoSpec{ServiceAccountName: serviceAccountName},
+		}
+	}
+	getImpersonator := func() (*Impersonator, *[]string) {
+		usernames := []string{}
+		return &Impersonator{
+			NewClient: func(username string) (client.Client, error) {
+				usernames = append(usernames, username)
+				return fake.NewClientBuilder().Build(), nil
+			},
+		}, &usernames
+	}
+
+	t.Run("without service account", func(t *testing.T) {
+		impersonator, usernames := getImpersonator()
+		c, err := impersonator.GetClient(getSleepInfo(""), defaultClient)
+		require.NoError(t, err)
+		require.Same(t, defaultClient, c)
+		require.Empty(t, *usernames)
+	})
+
+	t.Run("service account of the sleepinfo", func(t *testing.T) {
+		impersonator, usernames := getImpersonator()
+		impersonator.ServiceAccountName = "kube-green"
+		c, err := impersonator.GetClient(getSleepInfo("team-a-sleeper"), defaultClient)
+		require.NoError(t, err)
+		require.NotSame(t, defaultClient, c)
+
+		cachedClient, err := impersonator.GetClient(getSleepInfo("team-a-sleeper"), defaultClient)
+		require.NoError(t, err)
+		require.Same(t, c, cachedClient)
+		require.Equal(t, []string{"system:serviceaccount:team-a:team-a-sleeper"}, *usernames)
+	})
+
+	t.Run("service account of the configuration", func(t *testing.T) {
+		impersonator, usernames := getImpersonator()
+		impersonator.ServiceAccountName = "kube-green"
+		_, err := impersonator.GetClient(getSleepInfo(""), defaultClient)
+		require.NoError(t, err)
+		require.Equal(t, []string{"system:serviceaccount:team-a:kube-green"}, *usernames)
+	})
+
+	t.Run("required impersonation", func(t *testing.T) {
+		impersonator, _ := getImpersonator()
+		impersonator.Required = true
+		_, err := impersonator.GetClient(getSleepInfo(""), defaultClient)
+		require.EqualError(t, err, "impersonation is required, but the SleepInfo has no serviceAccountName")
+	})
+
+	t.Run("fails to create client", func(t *testing.T) {
+		impersonator := &Impersonator{
+			NewClient: func(username string) (client.Client, error) {
+				return nil, fmt.Errorf("invalid config")
+			},
+		}
+		_, err := impersonator.GetClient(getSleepInfo("kube-green"), defaultClient)
+		require.EqualError(t, err, "fails to create client impersonating system:serviceaccount:team-a:kube-green: invalid config")
+	})
+}
@@ -0,0 +1,21 @@
+package sleepinfo
+
+import (
+	"hash/fnv"
+	"time"
+)
+
+// getScheduleJitter returns the delay of the operations of the namespace
+// after their schedule, up to MaxScheduleJitter. It is derived from the name
+// of the namespace, so that it is stable across the reconciles and the
+// restarts, while the SleepInfo with the same schedule in different
+// namespaces are spread over the window.
+func (r *SleepInfoReconciler) getScheduleJitter(namespace string) time.Duration {
+	if r.MaxScheduleJitter <= 0 {
+		return 0
+	}
+	hash := fnv.New64a()
+	// the write to a hash never fails.
+	_, _ = hash.Write([]byte(namespace))
+	return time.Duration(hash.Sum64() % uint64(r.MaxScheduleJitter))
+}
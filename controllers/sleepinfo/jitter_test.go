@@ -0,0 +1,30 @@
+package sleepinfo
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetScheduleJitter(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		r := SleepInfoReconciler{}
+		require.Zero(t, r.getScheduleJitter("my-namespace"))
+	})
+
+	t.Run("stable per namespace and within the max", func(t *testing.T) {
+		r := SleepInfoReconciler{MaxScheduleJitter: 5 * time.Minute}
+		jitters := map[time.Duration]bool{}
+		for i := 0; i < 100; i++ {
+			namespace := fmt.Sprintf("namespace-%d", i)
+			jitter := r.getScheduleJitter(namespace)
+			require.GreaterOrEqual(t, jitter, time.Duration(0))
+			require.Less(t, jitter, 5*time.Minute)
+			require.Equal(t, jitter, r.getScheduleJitter(namespace))
+			jitters[jitter] = true
+		}
+		require.Greater(t, len(jitters), 90)
+	})
+}
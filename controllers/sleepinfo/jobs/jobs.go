@@ -0,0 +1,204 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	ErrFetchingJobs = errors.New("error fetching jobs")
+)
+
+type OriginalSuspendStatus map[string]bool
+type jobs struct {
+	resource.ResourceClient
+	data                  []batchv1.Job
+	OriginalSuspendStatus OriginalSuspendStatus
+	areToSuspend          bool
+}
+
+func NewResource(ctx context.Context, res resource.ResourceClient, namespace string, originalSuspendStatus map[string]bool) (resource.Resource, error) {
+	j := jobs{
+		ResourceClient:        res,
+		OriginalSuspendStatus: originalSuspendStatus,
+		areToSuspend:          res.SleepInfo.IsJobsToSuspend() || (res.IsWakeUp && len(originalSuspendStatus) > 0),
+		data:                  []batchv1.Job{},
+	}
+	if !j.areToSuspend {
+		return j, nil
+	}
+	if err := j.fetch(ctx, namespace); err != nil {
+		return jobs{}, fmt.Errorf("%w: %s", ErrFetchingJobs, err)
+	}
+
+	return j, nil
+}
+
+func (j jobs) HasResource() bool {
+	return len(j.data) > 0
+}
+
+func (j jobs) GetResourceNames() []string {
+	names := []string{}
+	for _, job := range j.data {
+		names = append(names, job.Name)
+	}
+	return names
+}
+
+func isSuspended(job batchv1.Job) bool {
+	return job.Spec.Suspend != nil && *job.Spec.Suspend
+}
+
+func (j jobs) Sleep(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "jobs.sleep", trace.WithAttributes(attribute.Int("resources.count", len(j.data))))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	suspend := true
+	for _, job := range j.data {
+		job := job
+		if isSuspended(job) || j.IsKeptAwake(&job) {
+			continue
+		}
+		newJob := job.DeepCopy()
+		newJob.Spec.Suspend = &suspend
+
+		if err := j.Patch(ctx, &job, newJob); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j jobs) WakeUp(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "jobs.wakeUp", trace.WithAttributes(attribute.Int("resources.count", len(j.data))))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	suspend := false
+	for _, job := range j.data {
+		job := job
+
+		jobLogger := j.Log.WithValues("job", job.Name, "namespace", job.Namespace)
+		if !isSuspended(job) {
+			jobLogger.Info("job is not suspended during wake up")
+			continue
+		}
+
+		status, ok := j.OriginalSuspendStatus[job.Name]
+		if !ok || status {
+			jobLogger.Info("original job info not correctly set")
+			continue
+		}
+
+		newJob := job.DeepCopy()
+		newJob.Spec.Suspend = &suspend
+
+		if err := j.Patch(ctx, &job, newJob); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type OriginalJobStatus struct {
+	Name    string `json:"name"`
+	Suspend bool   `json:"suspend"`
+}
+
+func (j jobs) GetOriginalInfoToSave() ([]byte, error) {
+	if !j.areToSuspend {
+		return nil, nil
+	}
+	jobsStatus := []OriginalJobStatus{}
+	for _, job := range j.data {
+		// a job suspended by a previous sleep is still to wake up.
+		status, ok := j.OriginalSuspendStatus[job.Name]
+		if isSuspended(job) && (!ok || status) {
+			continue
+		}
+		jobsStatus = append(jobsStatus, OriginalJobStatus{
+			Name: job.Name,
+		})
+	}
+	return json.Marshal(jobsStatus)
+}
+
+func (j *jobs) fetch(ctx context.Context, namespace string) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "jobs.list")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	jobList, err := j.getListByNamespace(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	j.Log.V(1).WithValues("number of jobs", len(jobList), "namespace", namespace).Info("jobs in namespace")
+	j.data = j.filterJobs(jobList)
+	return nil
+}
+
+func (j jobs) getListByNamespace(ctx context.Context, namespace string) ([]batchv1.Job, error) {
+	listOptions := &client.ListOptions{
+		Namespace: namespace,
+		Limit:     500,
+	}
+	jobList := batchv1.JobList{}
+	if err := j.Client.List(ctx, &jobList, listOptions); err != nil {
+		return jobList.Items, client.IgnoreNotFound(err)
+	}
+	return jobList.Items, nil
+}
+
+// filterJobs returns the jobs not owned by other resources (e.g. the jobs
+// created by a CronJob), not yet finished and not excluded by the SleepInfo.
+func (j jobs) filterJobs(jobList []batchv1.Job) []batchv1.Job {
+	filteredList := []batchv1.Job{}
+	for _, job := range jobList {
+		job := job
+		if metav1.GetControllerOf(&job) != nil || isFinished(job) {
+			continue
+		}
+		if !j.IsWakeUp && (resource.IsExcluded("Job", &job, j.SleepInfo.GetExcludeRef()) || !j.SleepInfo.IsManagedByClass(job.Labels)) {
+			continue
+		}
+		filteredList = append(filteredList, job)
+	}
+	return filteredList
+}
+
+func isFinished(job batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) && condition.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func GetOriginalInfoToRestore(savedData []byte) (OriginalSuspendStatus, error) {
+	if savedData == nil {
+		return OriginalSuspendStatus{}, nil
+	}
+	originalSuspendedJobs := []OriginalJobStatus{}
+	if err := json.Unmarshal(savedData, &originalSuspendedJobs); err != nil {
+		return nil, err
+	}
+	originalSuspendedJobsData := map[string]bool{}
+	for _, job := range originalSuspendedJobs {
+		if job.Name != "" {
+			originalSuspendedJobsData[job.Name] = job.Suspend
+		}
+	}
+	return originalSuspendedJobsData, nil
+}
@@ -0,0 +1,372 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/internal/testutil"
+
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestJobs(t *testing.T) {
+	testLogger := zap.New(zap.UseDevMode(true))
+
+	namespace := "my-namespace"
+	suspendTrue := true
+	isController := true
+	job1 := GetMock(MockSpec{
+		Name:      "job1",
+		Namespace: namespace,
+	})
+	job2 := GetMock(MockSpec{
+		Name:      "job2",
+		Namespace: namespace,
+	})
+	jobWithLabels := GetMock(MockSpec{
+		Name:      "job-with-labels",
+		Namespace: namespace,
+		Labels: map[string]string{
+			"app": "foo",
+		},
+	})
+	jobOtherNamespace := GetMock(MockSpec{
+		Name:      "job-other-namespace",
+		Namespace: "other-namespace",
+	})
+	suspendedJob := GetMock(MockSpec{
+		Name:      "job-suspended",
+		Namespace: namespace,
+		Suspend:   &suspendTrue,
+	})
+	jobOwnedByCronJob := GetMock(MockSpec{
+		Name:      "job-owned-by-cronjob",
+		Namespace: namespace,
+		OwnerReferences: []metav1.OwnerReference{
+			{
+				APIVersion: "batch/v1",
+				Kind:       "CronJob",
+				Name:       "cronjob",
+				UID:        "cronjob-uid",
+				Controller: &isController,
+			},
+		},
+	})
+	completedJob := GetMock(MockSpec{
+		Name:      "job-completed",
+		Namespace: namespace,
+		Conditions: []batchv1.JobCondition{
+			{
+				Type:   batchv1.JobComplete,
+				Status: v1.ConditionTrue,
+			},
+		},
+	})
+	sleepInfo := &v1alpha1.SleepInfo{
+		Spec: v1alpha1.SleepInfoSpec{
+			SuspendJobs: true,
+		},
+	}
+
+	getNewResource := func(t *testing.T, client client.Client, originalSuspendStatus map[string]bool) jobs {
+		t.Helper()
+
+		resource, err := NewResource(context.Background(), resource.ResourceClient{
+			Client:    client,
+			Log:       testLogger,
+			SleepInfo: sleepInfo,
+		}, namespace, originalSuspendStatus)
+		require.NoError(t, err)
+
+		jobs, ok := resource.(jobs)
+		require.True(t, ok)
+		return jobs
+	}
+
+	t.Run("NewResource", func(t *testing.T) {
+		listJobsTests := []struct {
+			name          string
+			client        client.Client
+			expectedNames []string
+			sleepInfo     *v1alpha1.SleepInfo
+			throws        bool
+		}{
+			{
+				name: "get list of jobs",
+				client: fake.NewClientBuilder().
+					WithRuntimeObjects(&job1, &job2, &jobOtherNamespace).
+					Build(),
+				expectedNames: []string{"job1", "job2"},
+				sleepInfo:     sleepInfo,
+			},
+			{
+				name:      "fails to list jobs",
+				sleepInfo: sleepInfo,
+				client: &testutil.PossiblyErroringFakeCtrlRuntimeClient{
+					Client: fake.NewClientBuilder().Build(),
+					ShouldError: func(method testutil.Method, obj runtime.Object) bool {
+						return method == testutil.List
+					},
+				},
+				throws:        true,
+				expectedNames: []string{},
+			},
+			{
+				name: "skip jobs owned by other resources and finished jobs",
+				client: fake.NewClientBuilder().
+					WithRuntimeObjects(&job1, &jobOwnedByCronJob, &completedJob).
+					Build(),
+				expectedNames: []string{"job1"},
+				sleepInfo:     sleepInfo,
+			},
+			{
+				name: "exclude jobs by name and labels",
+				client: fake.NewClientBuilder().
+					WithRuntimeObjects(&job1, &job2, &jobWithLabels).
+					Build(),
+				expectedNames: []string{"job1"},
+				sleepInfo: &v1alpha1.SleepInfo{
+					Spec: v1alpha1.SleepInfoSpec{
+						SuspendJobs: true,
+						ExcludeRef: []v1alpha1.ExcludeRef{
+							{
+								APIVersion: "batch/v1",
+								Kind:       "Job",
+								Name:       "job2",
+							},
+							{
+								MatchLabels: map[string]string{
+									"app": "foo",
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				name: "disabled jobs suspend",
+				client: fake.NewClientBuilder().
+					WithRuntimeObjects(&job1, &job2).
+					Build(),
+				sleepInfo:     &v1alpha1.SleepInfo{},
+				expectedNames: []string{},
+			},
+		}
+
+		for _, test := range listJobsTests {
+			t.Run(test.name, func(t *testing.T) {
+				r := resource.ResourceClient{
+					Client:    test.client,
+					Log:       testLogger,
+					SleepInfo: test.sleepInfo,
+				}
+
+				res, err := NewResource(context.Background(), r, namespace, map[string]bool{})
+				if test.throws {
+					require.EqualError(t, err, fmt.Sprintf("%s: error during list", ErrFetchingJobs))
+				} else {
+					require.NoError(t, err)
+				}
+				require.Equal(t, test.expectedNames, res.GetResourceNames())
+			})
+		}
+	})
+
+	t.Run("HasResources", func(t *testing.T) {
+		t.Run("without resource", func(t *testing.T) {
+			j := getNewResource(t, fake.NewClientBuilder().Build(), nil)
+			require.False(t, j.HasResource())
+			require.Empty(t, j.GetResourceNames())
+		})
+
+		t.Run("with resource", func(t *testing.T) {
+			j := getNewResource(t, fake.NewClientBuilder().WithRuntimeObjects(&job1).Build(), nil)
+			require.True(t, j.HasResource())
+			require.Equal(t, []string{job1.Name}, j.GetResourceNames())
+		})
+	})
+
+	t.Run("Sleep", func(t *testing.T) {
+		t.Run("not throws if no data", func(t *testing.T) {
+			j := getNewResource(t, fake.NewClientBuilder().Build(), nil)
+			require.NoError(t, j.Sleep(context.Background()))
+		})
+
+		t.Run("suspend jobs", func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithRuntimeObjects(&job1, &job2, &suspendedJob).
+				Build()
+			j := getNewResource(t, fakeClient, nil)
+			require.NoError(t, j.Sleep(context.Background()))
+
+			jobList, err := j.getListByNamespace(context.Background(), namespace)
+			require.NoError(t, err)
+			require.Equal(t, map[string]bool{
+				"job1":          true,
+				"job2":          true,
+				"job-suspended": true,
+			}, getSuspendStatus(jobList))
+		})
+
+		t.Run("fails to suspend jobs", func(t *testing.T) {
+			fakeClient := testutil.PossiblyErroringFakeCtrlRuntimeClient{
+				Client: fake.NewClientBuilder().WithRuntimeObjects(&job1).Build(),
+				ShouldError: func(method testutil.Method, obj runtime.Object) bool {
+					return method == testutil.Patch
+				},
+			}
+			j := getNewResource(t, fakeClient, nil)
+			require.EqualError(t, j.Sleep(context.Background()), "fails to patch Job.batch job1: error during patch")
+		})
+	})
+
+	t.Run("WakeUp", func(t *testing.T) {
+		suspendedJob1 := GetMock(MockSpec{
+			Name:      "job1",
+			Namespace: namespace,
+			Suspend:   &suspendTrue,
+		})
+
+		t.Run("not throws if no data", func(t *testing.T) {
+			j := getNewResource(t, fake.NewClientBuilder().Build(), nil)
+			require.NoError(t, j.WakeUp(context.Background()))
+		})
+
+		t.Run("wake up only jobs suspended by controller", func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithRuntimeObjects(&suspendedJob1, &suspendedJob, &job2).
+				Build()
+			j := getNewResource(t, fakeClient, map[string]bool{
+				"job1": false,
+			})
+			require.NoError(t, j.WakeUp(context.Background()))
+
+			jobList, err := j.getListByNamespace(context.Background(), namespace)
+			require.NoError(t, err)
+			require.Equal(t, map[string]bool{
+				"job1":          false,
+				"job2":          false,
+				"job-suspended": true,
+			}, getSuspendStatus(jobList))
+		})
+
+		t.Run("wake up jobs no more to suspend", func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithRuntimeObjects(&suspendedJob1, &suspendedJob).
+				Build()
+			r, err := NewResource(context.Background(), resource.ResourceClient{
+				Client:    fakeClient,
+				Log:       testLogger,
+				SleepInfo: &v1alpha1.SleepInfo{},
+				IsWakeUp:  true,
+			}, namespace, map[string]bool{
+				"job1": false,
+			})
+			require.NoError(t, err)
+			require.NoError(t, r.WakeUp(context.Background()))
+
+			jobList, err := r.(jobs).getListByNamespace(context.Background(), namespace)
+			require.NoError(t, err)
+			require.Equal(t, map[string]bool{
+				"job1":          false,
+				"job-suspended": true,
+			}, getSuspendStatus(jobList))
+		})
+
+		t.Run("fails to wake up", func(t *testing.T) {
+			fakeClient := testutil.PossiblyErroringFakeCtrlRuntimeClient{
+				Client: fake.NewClientBuilder().WithRuntimeObjects(&suspendedJob1).Build(),
+				ShouldError: func(method testutil.Method, obj runtime.Object) bool {
+					return method == testutil.Patch
+				},
+			}
+			j := getNewResource(t, fakeClient, map[string]bool{
+				"job1": false,
+			})
+			require.EqualError(t, j.WakeUp(context.Background()), "fails to patch Job.batch job1: error during patch")
+		})
+	})
+
+	t.Run("GetOriginalInfoToSave", func(t *testing.T) {
+		t.Run("returns nil if not to suspend", func(t *testing.T) {
+			j := getNewResource(t, fake.NewClientBuilder().Build(), nil)
+			j.areToSuspend = false
+			res, err := j.GetOriginalInfoToSave()
+			require.NoError(t, err)
+			require.Nil(t, res)
+		})
+
+		t.Run("without jobs", func(t *testing.T) {
+			j := getNewResource(t, fake.NewClientBuilder().Build(), nil)
+			res, err := j.GetOriginalInfoToSave()
+			require.NoError(t, err)
+			require.JSONEq(t, `[]`, string(res))
+		})
+
+		t.Run("with jobs", func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithRuntimeObjects(&job1, &job2, &suspendedJob).
+				Build()
+			j := getNewResource(t, fakeClient, nil)
+			res, err := j.GetOriginalInfoToSave()
+			require.NoError(t, err)
+			require.JSONEq(t, `[{"name":"job1","suspend":false},{"name":"job2","suspend":false}]`, string(res))
+		})
+
+		t.Run("keeps jobs suspended by a previous sleep", func(t *testing.T) {
+			suspendedJob1 := GetMock(MockSpec{
+				Name:      "job1",
+				Namespace: namespace,
+				Suspend:   &suspendTrue,
+			})
+			fakeClient := fake.NewClientBuilder().
+				WithRuntimeObjects(&suspendedJob1, &suspendedJob).
+				Build()
+			j := getNewResource(t, fakeClient, map[string]bool{
+				"job1": false,
+			})
+			res, err := j.GetOriginalInfoToSave()
+			require.NoError(t, err)
+			require.JSONEq(t, `[{"name":"job1","suspend":false}]`, string(res))
+		})
+	})
+
+	t.Run("GetOriginalInfoToRestore", func(t *testing.T) {
+		t.Run("if empty saved data, returns empty status", func(t *testing.T) {
+			suspendedStatus, err := GetOriginalInfoToRestore(nil)
+			require.NoError(t, err)
+			require.Equal(t, OriginalSuspendStatus{}, suspendedStatus)
+		})
+
+		t.Run("throws if data is not a correct json", func(t *testing.T) {
+			_, err := GetOriginalInfoToRestore([]byte("{}"))
+			require.EqualError(t, err, "json: cannot unmarshal object into Go value of type []jobs.OriginalJobStatus")
+		})
+
+		t.Run("correctly returns data", func(t *testing.T) {
+			suspendedStatus, err := GetOriginalInfoToRestore([]byte(`[{"name":"job1","suspend":false},{"name":"","suspend":false}]`))
+			require.NoError(t, err)
+			require.Equal(t, OriginalSuspendStatus{
+				"job1": false,
+			}, suspendedStatus)
+		})
+	})
+}
+
+func getSuspendStatus(jobList []batchv1.Job) map[string]bool {
+	suspendStatus := map[string]bool{}
+	for _, job := range jobList {
+		suspendStatus[job.Name] = isSuspended(job)
+	}
+	return suspendStatus
+}
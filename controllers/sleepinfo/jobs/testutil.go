@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type MockSpec struct {
+	Namespace       string
+	Name            string
+	Labels          map[string]string
+	ResourceVersion string
+	Suspend         *bool
+	OwnerReferences []metav1.OwnerReference
+	Conditions      []batchv1.JobCondition
+}
+
+func GetMock(opts MockSpec) batchv1.Job {
+	return batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: "batch/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            opts.Name,
+			Namespace:       opts.Namespace,
+			ResourceVersion: opts.ResourceVersion,
+			Labels:          opts.Labels,
+			OwnerReferences: opts.OwnerReferences,
+		},
+		Spec: batchv1.JobSpec{
+			Suspend: opts.Suspend,
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Containers: []v1.Container{
+						{
+							Name:  "c1",
+							Image: "my-image",
+						},
+					},
+				},
+			},
+		},
+		Status: batchv1.JobStatus{
+			Conditions: opts.Conditions,
+		},
+	}
+}
@@ -7,6 +7,62 @@ import (
 
 type Metrics struct {
 	CurrentSleepInfo *prometheus.GaugeVec
+	// ScheduleDelay is the delay between the scheduled time of an operation
+	// and its execution, by operation.
+	ScheduleDelay *prometheus.HistogramVec
+	// RequeueAfter is the time after which the SleepInfo are reconciled again.
+	RequeueAfter prometheus.Histogram
+	// LateOperations counts the operations executed later than the schedule
+	// delta, by operation.
+	LateOperations *prometheus.CounterVec
+	// MissedOperations counts the operations skipped because their window
+	// passed before they were executed, by operation.
+	MissedOperations *prometheus.CounterVec
+	// OperationResources counts the resources handled by the operations, by
+	// operation, kind and result, succeeded or failed.
+	OperationResources *prometheus.CounterVec
+	// ThrottledOperations counts the operations failed because the API server
+	// is throttling the requests, by operation.
+	ThrottledOperations *prometheus.CounterVec
+	// WakeUpVerifications counts the verifications run after the wake ups,
+	// by result.
+	WakeUpVerifications *prometheus.CounterVec
+	// ReportSleptSeconds is the time slept in the window of the sleep
+	// reports, by namespace and report.
+	ReportSleptSeconds *prometheus.GaugeVec
+	// ReportAvoidedPodSeconds is the number of pod-seconds avoided in the
+	// window of the sleep reports, by namespace and report.
+	ReportAvoidedPodSeconds *prometheus.GaugeVec
+	// ReportAvoidedCPURequestSeconds is the CPU requested by the avoided
+	// pods, in cpu-seconds, by namespace and report.
+	ReportAvoidedCPURequestSeconds *prometheus.GaugeVec
+	// ReportAvoidedMemoryRequestByteSeconds is the memory requested by the
+	// avoided pods, in byte-seconds, by namespace and report.
+	ReportAvoidedMemoryRequestByteSeconds *prometheus.GaugeVec
+	// ReportEstimatedSavings is the estimated cost of the resources requested
+	// by the avoided pods, by namespace, report and currency.
+	ReportEstimatedSavings *prometheus.GaugeVec
+	// RemoteClusterReachable is 1 if the API server of the remote cluster is
+	// reachable, 0 otherwise, by cluster.
+	RemoteClusterReachable *prometheus.GaugeVec
+	// ResidualPods is the number of pods of the resources put to sleep still
+	// running after the sleep, by namespace.
+	ResidualPods *prometheus.GaugeVec
+	// OperationFailures counts the failed reconciles of the SleepInfo, by
+	// namespace and reason of the failure.
+	OperationFailures *prometheus.CounterVec
+	// FeatureEnabled is 1 if the feature is enabled by its feature gate, 0
+	// otherwise, by name and stage of the feature.
+	FeatureEnabled *prometheus.GaugeVec
+	// OrphanedStates counts the state secrets found without their SleepInfo,
+	// by namespace.
+	OrphanedStates *prometheus.CounterVec
+	// SleepInfoInfo is always 1, with the schedule of the SleepInfo as
+	// labels, by name and namespace.
+	SleepInfoInfo *prometheus.GaugeVec
+	// NamespaceAsleep is 1 if a SleepInfo of the namespace is asleep, 0
+	// otherwise, by namespace.
+	NamespaceAsleep *prometheus.GaugeVec
 }
 
 func SetupMetricsOrDie(prefix string) Metrics {
@@ -16,6 +72,103 @@ func SetupMetricsOrDie(prefix string) Metrics {
 			Name:      "current_sleepinfo",
 			Help:      "Info about SleepInfo resource",
 		}, []string{"name", "namespace"}),
+		ScheduleDelay: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: prefix,
+			Name:      "schedule_delay_seconds",
+			Help:      "Delay between the scheduled time of the operations and their execution",
+			Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800, 3600},
+		}, []string{"operation"}),
+		RequeueAfter: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: prefix,
+			Name:      "requeue_after_seconds",
+			Help:      "Time after which the SleepInfo are reconciled again",
+			Buckets:   []float64{1, 10, 60, 300, 900, 3600, 3 * 3600, 6 * 3600, 12 * 3600, 24 * 3600, 7 * 24 * 3600},
+		}),
+		LateOperations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "late_operations_total",
+			Help:      "Number of operations executed later than the schedule delta",
+		}, []string{"operation"}),
+		MissedOperations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "missed_operations_total",
+			Help:      "Number of operations skipped because their window passed before the execution",
+		}, []string{"operation"}),
+		OperationResources: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "operation_resources_total",
+			Help:      "Number of resources handled by the operations, by kind and result",
+		}, []string{"operation", "kind", "result"}),
+		ThrottledOperations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "throttled_operations_total",
+			Help:      "Number of operations throttled by the API server",
+		}, []string{"operation"}),
+		WakeUpVerifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "wake_up_verifications_total",
+			Help:      "Number of verifications run after the wake ups, by result",
+		}, []string{"result"}),
+		ReportSleptSeconds: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: prefix,
+			Name:      "report_slept_seconds",
+			Help:      "Time slept in the window of the sleep report",
+		}, []string{"namespace", "report"}),
+		ReportAvoidedPodSeconds: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: prefix,
+			Name:      "report_avoided_pod_seconds",
+			Help:      "Number of pod-seconds avoided in the window of the sleep report",
+		}, []string{"namespace", "report"}),
+		ReportAvoidedCPURequestSeconds: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: prefix,
+			Name:      "report_avoided_cpu_request_seconds",
+			Help:      "CPU requested by the pods avoided in the window of the sleep report, in cpu-seconds",
+		}, []string{"namespace", "report"}),
+		ReportAvoidedMemoryRequestByteSeconds: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: prefix,
+			Name:      "report_avoided_memory_request_byte_seconds",
+			Help:      "Memory requested by the pods avoided in the window of the sleep report, in byte-seconds",
+		}, []string{"namespace", "report"}),
+		ReportEstimatedSavings: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: prefix,
+			Name:      "report_estimated_savings",
+			Help:      "Estimated cost of the resources requested by the pods avoided in the window of the sleep report",
+		}, []string{"namespace", "report", "currency"}),
+		RemoteClusterReachable: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: prefix,
+			Name:      "remote_cluster_reachable",
+			Help:      "Whether the API server of the remote cluster is reachable",
+		}, []string{"cluster"}),
+		ResidualPods: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: prefix,
+			Name:      "residual_pods",
+			Help:      "Number of pods of the resources put to sleep still running after the sleep",
+		}, []string{"namespace"}),
+		OperationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "operation_failures_total",
+			Help:      "Number of failed reconciles of the SleepInfo, by reason of the failure",
+		}, []string{"namespace", "reason"}),
+		FeatureEnabled: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: prefix,
+			Name:      "feature_enabled",
+			Help:      "Whether the feature of the controller is enabled by its feature gate",
+		}, []string{"name", "stage"}),
+		OrphanedStates: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Name:      "orphaned_states_total",
+			Help:      "Number of state secrets found without their SleepInfo",
+		}, []string{"namespace"}),
+		SleepInfoInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: prefix,
+			Name:      "sleepinfo_info",
+			Help:      "Schedule of the SleepInfo",
+		}, []string{"name", "namespace", "timezone", "sleep_cron", "wake_cron", "suspend_cronjobs"}),
+		NamespaceAsleep: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: prefix,
+			Name:      "namespace_asleep",
+			Help:      "Whether a SleepInfo of the namespace is asleep",
+		}, []string{"namespace"}),
 	}
 	return sleepInfoMetrics
 }
@@ -23,6 +176,25 @@ func SetupMetricsOrDie(prefix string) Metrics {
 func (customMetrics Metrics) MustRegister(registry metrics.RegistererGatherer) Metrics {
 	registry.MustRegister(
 		customMetrics.CurrentSleepInfo,
+		customMetrics.ScheduleDelay,
+		customMetrics.RequeueAfter,
+		customMetrics.LateOperations,
+		customMetrics.MissedOperations,
+		customMetrics.OperationResources,
+		customMetrics.ThrottledOperations,
+		customMetrics.WakeUpVerifications,
+		customMetrics.ReportSleptSeconds,
+		customMetrics.ReportAvoidedPodSeconds,
+		customMetrics.ReportAvoidedCPURequestSeconds,
+		customMetrics.ReportAvoidedMemoryRequestByteSeconds,
+		customMetrics.ReportEstimatedSavings,
+		customMetrics.RemoteClusterReachable,
+		customMetrics.ResidualPods,
+		customMetrics.OperationFailures,
+		customMetrics.FeatureEnabled,
+		customMetrics.OrphanedStates,
+		customMetrics.SleepInfoInfo,
+		customMetrics.NamespaceAsleep,
 	)
 	return customMetrics
 }
@@ -20,6 +20,25 @@ func getAndUseMetrics() Metrics {
 		"name":      "test_name",
 		"namespace": "test_namespace",
 	}).Set(1)
+	m.ScheduleDelay.WithLabelValues("SLEEP").Observe(2)
+	m.RequeueAfter.Observe(3600)
+	m.LateOperations.WithLabelValues("SLEEP").Inc()
+	m.MissedOperations.WithLabelValues("WAKE_UP").Inc()
+	m.OperationResources.WithLabelValues("SLEEP", "Deployment", "succeeded").Add(3)
+	m.ThrottledOperations.WithLabelValues("WAKE_UP").Inc()
+	m.WakeUpVerifications.WithLabelValues("Failed").Inc()
+	m.ReportSleptSeconds.WithLabelValues("test_namespace", "daily").Set(12 * 3600)
+	m.ReportAvoidedPodSeconds.WithLabelValues("test_namespace", "daily").Set(24 * 3600)
+	m.ReportAvoidedCPURequestSeconds.WithLabelValues("test_namespace", "daily").Set(6 * 3600)
+	m.ReportAvoidedMemoryRequestByteSeconds.WithLabelValues("test_namespace", "daily").Set(1 << 30)
+	m.ReportEstimatedSavings.WithLabelValues("test_namespace", "daily", "USD").Set(0.25)
+	m.RemoteClusterReachable.WithLabelValues("dev-1").Set(1)
+	m.ResidualPods.WithLabelValues("test_namespace").Set(2)
+	m.OperationFailures.WithLabelValues("test_namespace", "InvalidSchedule").Inc()
+	m.FeatureEnabled.WithLabelValues("WakeOnRequest", "BETA").Set(1)
+	m.OrphanedStates.WithLabelValues("test_namespace").Inc()
+	m.SleepInfoInfo.WithLabelValues("test_name", "test_namespace", "Europe/Rome", "0 20 * * 1-5", "0 8 * * 1-5", "true").Set(1)
+	m.NamespaceAsleep.WithLabelValues("test_namespace").Set(1)
 
 	return m
 }
@@ -39,6 +58,192 @@ func TestMetrics(t *testing.T) {
 		`)
 		require.NoError(t, testutil.CollectAndCompare(m.CurrentSleepInfo, buf))
 	})
+
+	t.Run("LateOperations and MissedOperations", func(t *testing.T) {
+		m := getAndUseMetrics()
+
+		buf := bytes.NewBufferString(`
+		# HELP test_prefix_late_operations_total Number of operations executed later than the schedule delta
+		# TYPE test_prefix_late_operations_total counter
+		test_prefix_late_operations_total{operation="SLEEP"} 1
+		`)
+		require.NoError(t, testutil.CollectAndCompare(m.LateOperations, buf))
+		buf = bytes.NewBufferString(`
+		# HELP test_prefix_missed_operations_total Number of operations skipped because their window passed before the execution
+		# TYPE test_prefix_missed_operations_total counter
+		test_prefix_missed_operations_total{operation="WAKE_UP"} 1
+		`)
+		require.NoError(t, testutil.CollectAndCompare(m.MissedOperations, buf))
+	})
+
+	t.Run("OperationResources", func(t *testing.T) {
+		m := getAndUseMetrics()
+
+		prob, err := testutil.CollectAndLint(m.OperationResources)
+		require.NoError(t, err)
+		require.Nil(t, prob)
+
+		buf := bytes.NewBufferString(`
+		# HELP test_prefix_operation_resources_total Number of resources handled by the operations, by kind and result
+		# TYPE test_prefix_operation_resources_total counter
+		test_prefix_operation_resources_total{kind="Deployment",operation="SLEEP",result="succeeded"} 3
+		`)
+		require.NoError(t, testutil.CollectAndCompare(m.OperationResources, buf))
+	})
+
+	t.Run("ThrottledOperations", func(t *testing.T) {
+		m := getAndUseMetrics()
+
+		prob, err := testutil.CollectAndLint(m.ThrottledOperations)
+		require.NoError(t, err)
+		require.Nil(t, prob)
+
+		buf := bytes.NewBufferString(`
+		# HELP test_prefix_throttled_operations_total Number of operations throttled by the API server
+		# TYPE test_prefix_throttled_operations_total counter
+		test_prefix_throttled_operations_total{operation="WAKE_UP"} 1
+		`)
+		require.NoError(t, testutil.CollectAndCompare(m.ThrottledOperations, buf))
+	})
+
+	t.Run("WakeUpVerifications", func(t *testing.T) {
+		m := getAndUseMetrics()
+
+		prob, err := testutil.CollectAndLint(m.WakeUpVerifications)
+		require.NoError(t, err)
+		require.Nil(t, prob)
+
+		buf := bytes.NewBufferString(`
+		# HELP test_prefix_wake_up_verifications_total Number of verifications run after the wake ups, by result
+		# TYPE test_prefix_wake_up_verifications_total counter
+		test_prefix_wake_up_verifications_total{result="Failed"} 1
+		`)
+		require.NoError(t, testutil.CollectAndCompare(m.WakeUpVerifications, buf))
+	})
+
+	t.Run("sleep reports", func(t *testing.T) {
+		m := getAndUseMetrics()
+
+		buf := bytes.NewBufferString(`
+		# HELP test_prefix_report_slept_seconds Time slept in the window of the sleep report
+		# TYPE test_prefix_report_slept_seconds gauge
+		test_prefix_report_slept_seconds{namespace="test_namespace",report="daily"} 43200
+		`)
+		require.NoError(t, testutil.CollectAndCompare(m.ReportSleptSeconds, buf))
+		for _, collector := range []prometheus.Collector{m.ReportSleptSeconds, m.ReportAvoidedPodSeconds, m.ReportAvoidedCPURequestSeconds, m.ReportAvoidedMemoryRequestByteSeconds, m.ReportEstimatedSavings} {
+			prob, err := testutil.CollectAndLint(collector)
+			require.NoError(t, err)
+			require.Nil(t, prob)
+		}
+	})
+
+	t.Run("RemoteClusterReachable", func(t *testing.T) {
+		m := getAndUseMetrics()
+
+		prob, err := testutil.CollectAndLint(m.RemoteClusterReachable)
+		require.NoError(t, err)
+		require.Nil(t, prob)
+
+		buf := bytes.NewBufferString(`
+		# HELP test_prefix_remote_cluster_reachable Whether the API server of the remote cluster is reachable
+		# TYPE test_prefix_remote_cluster_reachable gauge
+		test_prefix_remote_cluster_reachable{cluster="dev-1"} 1
+		`)
+		require.NoError(t, testutil.CollectAndCompare(m.RemoteClusterReachable, buf))
+	})
+
+	t.Run("ResidualPods", func(t *testing.T) {
+		m := getAndUseMetrics()
+
+		prob, err := testutil.CollectAndLint(m.ResidualPods)
+		require.NoError(t, err)
+		require.Nil(t, prob)
+
+		buf := bytes.NewBufferString(`
+		# HELP test_prefix_residual_pods Number of pods of the resources put to sleep still running after the sleep
+		# TYPE test_prefix_residual_pods gauge
+		test_prefix_residual_pods{namespace="test_namespace"} 2
+		`)
+		require.NoError(t, testutil.CollectAndCompare(m.ResidualPods, buf))
+	})
+
+	t.Run("OperationFailures", func(t *testing.T) {
+		m := getAndUseMetrics()
+
+		prob, err := testutil.CollectAndLint(m.OperationFailures)
+		require.NoError(t, err)
+		require.Nil(t, prob)
+
+		buf := bytes.NewBufferString(`
+		# HELP test_prefix_operation_failures_total Number of failed reconciles of the SleepInfo, by reason of the failure
+		# TYPE test_prefix_operation_failures_total counter
+		test_prefix_operation_failures_total{namespace="test_namespace",reason="InvalidSchedule"} 1
+		`)
+		require.NoError(t, testutil.CollectAndCompare(m.OperationFailures, buf))
+	})
+
+	t.Run("FeatureEnabled", func(t *testing.T) {
+		m := getAndUseMetrics()
+
+		prob, err := testutil.CollectAndLint(m.FeatureEnabled)
+		require.NoError(t, err)
+		require.Nil(t, prob)
+
+		buf := bytes.NewBufferString(`
+		# HELP test_prefix_feature_enabled Whether the feature of the controller is enabled by its feature gate
+		# TYPE test_prefix_feature_enabled gauge
+		test_prefix_feature_enabled{name="WakeOnRequest",stage="BETA"} 1
+		`)
+		require.NoError(t, testutil.CollectAndCompare(m.FeatureEnabled, buf))
+	})
+
+	t.Run("OrphanedStates", func(t *testing.T) {
+		m := getAndUseMetrics()
+
+		prob, err := testutil.CollectAndLint(m.OrphanedStates)
+		require.NoError(t, err)
+		require.Nil(t, prob)
+
+		buf := bytes.NewBufferString(`
+		# HELP test_prefix_orphaned_states_total Number of state secrets found without their SleepInfo
+		# TYPE test_prefix_orphaned_states_total counter
+		test_prefix_orphaned_states_total{namespace="test_namespace"} 1
+		`)
+		require.NoError(t, testutil.CollectAndCompare(m.OrphanedStates, buf))
+	})
+
+	t.Run("SleepInfoInfo and NamespaceAsleep", func(t *testing.T) {
+		m := getAndUseMetrics()
+
+		for _, collector := range []prometheus.Collector{m.SleepInfoInfo, m.NamespaceAsleep} {
+			prob, err := testutil.CollectAndLint(collector)
+			require.NoError(t, err)
+			require.Nil(t, prob)
+		}
+		buf := bytes.NewBufferString(`
+		# HELP test_prefix_sleepinfo_info Schedule of the SleepInfo
+		# TYPE test_prefix_sleepinfo_info gauge
+		test_prefix_sleepinfo_info{name="test_name",namespace="test_namespace",sleep_cron="0 20 * * 1-5",suspend_cronjobs="true",timezone="Europe/Rome",wake_cron="0 8 * * 1-5"} 1
+		`)
+		require.NoError(t, testutil.CollectAndCompare(m.SleepInfoInfo, buf))
+		buf = bytes.NewBufferString(`
+		# HELP test_prefix_namespace_asleep Whether a SleepInfo of the namespace is asleep
+		# TYPE test_prefix_namespace_asleep gauge
+		test_prefix_namespace_asleep{namespace="test_namespace"} 1
+		`)
+		require.NoError(t, testutil.CollectAndCompare(m.NamespaceAsleep, buf))
+	})
+
+	t.Run("ScheduleDelay and RequeueAfter", func(t *testing.T) {
+		m := getAndUseMetrics()
+
+		for _, collector := range []prometheus.Collector{m.ScheduleDelay, m.RequeueAfter} {
+			prob, err := testutil.CollectAndLint(collector)
+			require.NoError(t, err)
+			require.Nil(t, prob)
+			require.Equal(t, 1, testutil.CollectAndCount(collector))
+		}
+	})
 }
 
 func TestSetupMetricsAndRegister(t *testing.T) {
@@ -47,5 +252,5 @@ func TestSetupMetricsAndRegister(t *testing.T) {
 
 	count, err := testutil.GatherAndCount(registry)
 	require.NoError(t, err)
-	require.Equal(t, 1, count)
+	require.Equal(t, 20, count)
 }
@@ -0,0 +1,63 @@
+package sleepinfo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/operationgate"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+)
+
+// operationGateRetryInterval is how frequently an operation denied by the
+// operation gate is reviewed again.
+const operationGateRetryInterval = 5 * time.Minute
+
+// reviewOperation asks the operation gate whether the operation can be
+// executed. It returns the resources of the operation, without the ones
+// excluded from the sleep by the gate, and false if the operation is denied.
+func (r *SleepInfoReconciler) reviewOperation(
+	ctx context.Context,
+	log logr.Logger,
+	sleepInfo *kubegreenv1alpha1.SleepInfo,
+	sleepInfoData SleepInfoData,
+	resourceClient resource.ResourceClient,
+	resources Resources,
+) (Resources, bool, error) {
+	response, err := r.OperationGate.Review(ctx, operationgate.Request{
+		Operation: sleepInfoData.CurrentOperationType,
+		Namespace: sleepInfo.Namespace,
+		SleepInfo: sleepInfo.Name,
+		Resources: resources.getResourceNames(),
+	})
+	if err != nil {
+		return Resources{}, false, fmt.Errorf("operation gate fails: %s", err)
+	}
+	if !response.Allowed {
+		log.Info("operation denied by the operation gate", "reason", response.Reason)
+		if r.Recorder != nil {
+			r.Recorder.Eventf(sleepInfo, v1.EventTypeWarning, "OperationDenied", "%s operation denied by the operation gate: %s", sleepInfoData.CurrentOperationType, response.Reason)
+		}
+		return Resources{}, false, nil
+	}
+	if response.Reason != "" {
+		log.Info("operation allowed by the operation gate", "reason", response.Reason)
+	}
+	// the wake up restores all the resources put to sleep.
+	if len(response.ExcludeRef) == 0 || !sleepInfoData.IsSleepOperation() {
+		return resources, true, nil
+	}
+
+	gatedSleepInfo := sleepInfo.DeepCopy()
+	gatedSleepInfo.Spec.ExcludeRef = append(gatedSleepInfo.Spec.ExcludeRef, response.ExcludeRef...)
+	resourceClient.SleepInfo = gatedSleepInfo
+	resources, err = NewResources(ctx, resourceClient, sleepInfo.Namespace, sleepInfoData)
+	if err != nil {
+		return Resources{}, false, err
+	}
+	return resources, true, nil
+}
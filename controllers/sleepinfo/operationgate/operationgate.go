@@ -0,0 +1,105 @@
+package operationgate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+)
+
+// Gate reviews the sleep and wake up operations before they are executed.
+type Gate interface {
+	// Review returns whether the operation is allowed, and the resources to
+	// exclude from it.
+	Review(ctx context.Context, request Request) (Response, error)
+}
+
+// Request is the planned operation, sent to the gate.
+type Request struct {
+	// Operation is the type of the operation, SLEEP or WAKE_UP.
+	Operation string `json:"operation"`
+	Namespace string `json:"namespace"`
+	SleepInfo string `json:"sleepInfo"`
+	// Resources are the names of the resources handled by the operation,
+	// grouped by kind.
+	Resources map[string][]string `json:"resources"`
+}
+
+// Response is the decision of the gate on the operation.
+type Response struct {
+	// Allowed is true if the operation can be executed.
+	Allowed bool `json:"allowed"`
+	// Reason explains the decision, e.g. the change freeze which denies the
+	// operation.
+	Reason string `json:"reason,omitempty"`
+	// ExcludeRef are the resources excluded from the sleep, besides the ones
+	// excluded by the SleepInfo. They are ignored by the wake up, which
+	// restores all the resources put to sleep.
+	ExcludeRef []kubegreenv1alpha1.ExcludeRef `json:"excludeRef,omitempty"`
+}
+
+type webhook struct {
+	client        *http.Client
+	url           string
+	timeout       time.Duration
+	ignoreFailure bool
+}
+
+// NewWebhook returns a Gate which sends a POST request with the planned
+// operation to the url, and decodes the Response from its body. If
+// ignoreFailure is true, the operations are allowed when the webhook fails.
+// If httpClient is nil, http.DefaultClient is used.
+func NewWebhook(httpClient *http.Client, url string, timeout time.Duration, ignoreFailure bool) Gate {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &webhook{
+		client:        httpClient,
+		url:           url,
+		timeout:       timeout,
+		ignoreFailure: ignoreFailure,
+	}
+}
+
+func (w *webhook) Review(ctx context.Context, request Request) (Response, error) {
+	response, err := w.do(ctx, request)
+	if err != nil {
+		if w.ignoreFailure {
+			return Response{Allowed: true, Reason: fmt.Sprintf("operation gate failure ignored: %s", err)}, nil
+		}
+		return Response{}, err
+	}
+	return response, nil
+}
+
+func (w *webhook) do(ctx context.Context, request Request) (Response, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return Response{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return Response{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return Response{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return Response{}, fmt.Errorf("operation gate responded with status %d", res.StatusCode)
+	}
+	response := Response{}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return Response{}, fmt.Errorf("invalid operation gate response: %s", err)
+	}
+	return response, nil
+}
@@ -0,0 +1,77 @@
+package operationgate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook(t *testing.T) {
+	request := Request{
+		Operation: "SLEEP",
+		Namespace: "my-namespace",
+		SleepInfo: "sleepinfo",
+		Resources: map[string][]string{"Deployment": {"api", "frontend"}},
+	}
+
+	t.Run("allowed with exclusions", func(t *testing.T) {
+		var received Request
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			require.Equal(t, http.MethodPost, req.Method)
+			require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&received))
+			_, _ = w.Write([]byte(`{"allowed":true,"excludeRef":[{"kind":"Deployment","name":"api"}]}`))
+		}))
+		defer server.Close()
+
+		response, err := NewWebhook(nil, server.URL, time.Second, false).Review(context.Background(), request)
+		require.NoError(t, err)
+		require.Equal(t, request, received)
+		require.Equal(t, Response{
+			Allowed:    true,
+			ExcludeRef: []kubegreenv1alpha1.ExcludeRef{{Kind: "Deployment", Name: "api"}},
+		}, response)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"allowed":false,"reason":"change freeze"}`))
+		}))
+		defer server.Close()
+
+		response, err := NewWebhook(nil, server.URL, time.Second, false).Review(context.Background(), request)
+		require.NoError(t, err)
+		require.Equal(t, Response{Allowed: false, Reason: "change freeze"}, response)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		_, err := NewWebhook(nil, server.URL, time.Second, false).Review(context.Background(), request)
+		require.EqualError(t, err, "operation gate responded with status 503")
+
+		response, err := NewWebhook(nil, server.URL, time.Second, true).Review(context.Background(), request)
+		require.NoError(t, err)
+		require.Equal(t, Response{Allowed: true, Reason: "operation gate failure ignored: operation gate responded with status 503"}, response)
+	})
+
+	t.Run("invalid response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`not json`))
+		}))
+		defer server.Close()
+
+		_, err := NewWebhook(nil, server.URL, time.Second, false).Review(context.Background(), request)
+		require.ErrorContains(t, err, "invalid operation gate response")
+	})
+}
@@ -0,0 +1,97 @@
+package sleepinfo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/deployments"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/operationgate"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+type fakeGate struct {
+	response operationgate.Response
+	err      error
+	requests []operationgate.Request
+}
+
+func (g *fakeGate) Review(_ context.Context, request operationgate.Request) (operationgate.Response, error) {
+	g.requests = append(g.requests, request)
+	return g.response, g.err
+}
+
+func TestReviewOperation(t *testing.T) {
+	namespace := "my-namespace"
+	replicas := int32(1)
+	log := zap.New(zap.UseDevMode(true))
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: namespace},
+	}
+	api := deployments.GetMock(deployments.MockSpec{Name: "api", Namespace: namespace, Replicas: &replicas})
+	frontend := deployments.GetMock(deployments.MockSpec{Name: "frontend", Namespace: namespace, Replicas: &replicas})
+
+	review := func(t *testing.T, gate *fakeGate, sleepInfoData SleepInfoData) (Resources, bool, *record.FakeRecorder, error) {
+		t.Helper()
+		recorder := record.NewFakeRecorder(10)
+		r := SleepInfoReconciler{OperationGate: gate, Recorder: recorder}
+		resourceClient := resource.ResourceClient{
+			Client:    getFakeClient().WithRuntimeObjects(&api, &frontend).Build(),
+			Log:       log,
+			SleepInfo: sleepInfo,
+		}
+		resources, err := NewResources(context.Background(), resourceClient, namespace, sleepInfoData)
+		require.NoError(t, err)
+		resources, isAllowed, err := r.reviewOperation(context.Background(), log, sleepInfo, sleepInfoData, resourceClient, resources)
+		return resources, isAllowed, recorder, err
+	}
+	sleepData := SleepInfoData{CurrentOperationType: sleepOperation}
+
+	t.Run("allowed", func(t *testing.T) {
+		gate := &fakeGate{response: operationgate.Response{Allowed: true}}
+		resources, isAllowed, _, err := review(t, gate, sleepData)
+		require.NoError(t, err)
+		require.True(t, isAllowed)
+		require.Equal(t, map[string][]string{"Deployment": {"api", "frontend"}}, resources.getResourceNames())
+		require.Equal(t, []operationgate.Request{{
+			Operation: sleepOperation,
+			Namespace: namespace,
+			SleepInfo: "sleepinfo",
+			Resources: map[string][]string{"Deployment": {"api", "frontend"}},
+		}}, gate.requests)
+	})
+
+	t.Run("allowed with exclusions", func(t *testing.T) {
+		gate := &fakeGate{response: operationgate.Response{
+			Allowed:    true,
+			ExcludeRef: []kubegreenv1alpha1.ExcludeRef{{APIVersion: "apps/v1", Kind: "Deployment", Name: "api"}},
+		}}
+		resources, isAllowed, _, err := review(t, gate, sleepData)
+		require.NoError(t, err)
+		require.True(t, isAllowed)
+		require.Equal(t, map[string][]string{"Deployment": {"frontend"}}, resources.getResourceNames())
+		require.Empty(t, sleepInfo.Spec.ExcludeRef)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		gate := &fakeGate{response: operationgate.Response{Reason: "change freeze"}}
+		resources, isAllowed, recorder, err := review(t, gate, sleepData)
+		require.NoError(t, err)
+		require.False(t, isAllowed)
+		require.False(t, resources.hasResources())
+		require.Equal(t, "Warning OperationDenied SLEEP operation denied by the operation gate: change freeze", <-recorder.Events)
+	})
+
+	t.Run("gate fails", func(t *testing.T) {
+		gate := &fakeGate{err: fmt.Errorf("connection refused")}
+		_, isAllowed, _, err := review(t, gate, sleepData)
+		require.EqualError(t, err, "operation gate fails: connection refused")
+		require.False(t, isAllowed)
+	})
+}
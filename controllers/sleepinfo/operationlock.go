@@ -0,0 +1,213 @@
+package sleepinfo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// operationLockRetryInterval is the interval after which a queued
+	// operation tries again to acquire the lock of the namespace.
+	operationLockRetryInterval = 5 * time.Second
+	// operationLockQueueTimeout is the time after which a queued operation
+	// which did not try again to acquire the lock, e.g. because its SleepInfo
+	// has been deleted, is removed from the queue.
+	operationLockQueueTimeout = time.Minute
+)
+
+// OperationLock serializes the operations of the SleepInfo of the same
+// namespace, e.g. a wake up requested by hand and the scheduled sleep of
+// another SleepInfo, so that they do not change the same resources at the
+// same time. The queued operations acquire the lock in the order they are
+// queued.
+type OperationLock struct {
+	mu      sync.Mutex
+	holders map[string]lockHolder
+	queues  map[string][]queuedOperation
+}
+
+type lockHolder struct {
+	sleepInfo string
+	operation string
+}
+
+type queuedOperation struct {
+	lockHolder
+	lastTry time.Time
+}
+
+// NewOperationLock returns an OperationLock with all the namespaces unlocked.
+func NewOperationLock() *OperationLock {
+	return &OperationLock{
+		holders: map[string]lockHolder{},
+		queues:  map[string][]queuedOperation{},
+	}
+}
+
+// TryLock acquires the lock of the namespace for the operation of the
+// SleepInfo, if it is not held and no other operation has been queued before.
+// Otherwise the operation is queued, and the operation which holds the lock,
+// or the first queued one, is returned.
+func (l *OperationLock) TryLock(namespace, sleepInfo, operation string, now time.Time) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	queue := []queuedOperation{}
+	position := -1
+	for _, queued := range l.queues[namespace] {
+		if queued.sleepInfo == sleepInfo {
+			queued.operation = operation
+			queued.lastTry = now
+			position = len(queue)
+		} else if now.Sub(queued.lastTry) > operationLockQueueTimeout {
+			continue
+		}
+		queue = append(queue, queued)
+	}
+
+	holder, isHeld := l.holders[namespace]
+	if isHeld && holder.sleepInfo == sleepInfo {
+		return "", true
+	}
+	if !isHeld && (len(queue) == 0 || position == 0) {
+		if position == 0 {
+			queue = queue[1:]
+		}
+		l.setQueue(namespace, queue)
+		l.holders[namespace] = lockHolder{sleepInfo: sleepInfo, operation: operation}
+		return "", true
+	}
+
+	if position == -1 {
+		queue = append(queue, queuedOperation{
+			lockHolder: lockHolder{sleepInfo: sleepInfo, operation: operation},
+			lastTry:    now,
+		})
+	}
+	l.setQueue(namespace, queue)
+	if !isHeld {
+		holder = queue[0].lockHolder
+	}
+	return fmt.Sprintf("the %s operation of SleepInfo %s", holder.operation, holder.sleepInfo), false
+}
+
+// Unlock releases the lock of the namespace, if it is held by the SleepInfo.
+func (l *OperationLock) Unlock(namespace, sleepInfo string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.holders[namespace].sleepInfo == sleepInfo {
+		delete(l.holders, namespace)
+	}
+}
+
+func (l *OperationLock) setQueue(namespace string, queue []queuedOperation) {
+	if len(queue) == 0 {
+		delete(l.queues, namespace)
+		return
+	}
+	l.queues[namespace] = queue
+}
+
+// acquireOperationLock acquires the lock of the namespace for the operation
+// of the SleepInfo. The operations interrupted before their end, recorded in
+// the state secrets of the other SleepInfo of the namespace, hold the lock
+// until they are resumed and completed, also after a restart of the
+// controller. If the lock is not acquired, the operation is queued, and it is
+// reported in the OperationQueued condition of the SleepInfo.
+func (r *SleepInfoReconciler) acquireOperationLock(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo, data SleepInfoData, secretName string, now time.Time) bool {
+	if r.OperationLock == nil {
+		return true
+	}
+	blockedBy := ""
+	if data.PendingOperationID == "" {
+		pendingOperation, err := r.getPendingOperationOfNamespace(ctx, sleepInfo.Namespace, secretName)
+		if err != nil {
+			// a failure in reading the state secrets does not block the
+			// operation, which is still serialized by the lock.
+			log.Error(err, "fails to read the pending operations of the namespace")
+		}
+		blockedBy = pendingOperation
+	}
+	if blockedBy == "" {
+		holder, isLocked := r.OperationLock.TryLock(sleepInfo.Namespace, sleepInfo.Name, data.CurrentOperationType, now)
+		if isLocked {
+			r.setOperationQueued(ctx, log, sleepInfo, metav1.Condition{
+				Type:    kubegreenv1alpha1.OperationQueuedCondition,
+				Status:  metav1.ConditionFalse,
+				Reason:  kubegreenv1alpha1.OperationQueuedReasonLockAcquired,
+				Message: fmt.Sprintf("the %s operation acquired the lock of the namespace", data.CurrentOperationType),
+			})
+			return true
+		}
+		blockedBy = holder
+	}
+
+	message := fmt.Sprintf("%s operation queued behind %s", data.CurrentOperationType, blockedBy)
+	log.Info("operation queued", "blockedBy", blockedBy)
+	if r.Recorder != nil && !meta.IsStatusConditionTrue(sleepInfo.Status.Conditions, kubegreenv1alpha1.OperationQueuedCondition) {
+		r.Recorder.Event(sleepInfo, v1.EventTypeNormal, "OperationQueued", message)
+	}
+	r.setOperationQueued(ctx, log, sleepInfo, metav1.Condition{
+		Type:    kubegreenv1alpha1.OperationQueuedCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  kubegreenv1alpha1.OperationQueuedReasonNamespaceLocked,
+		Message: message,
+	})
+	return false
+}
+
+// releaseOperationLock releases the lock of the namespace acquired by the
+// operation of the SleepInfo.
+func (r *SleepInfoReconciler) releaseOperationLock(sleepInfo *kubegreenv1alpha1.SleepInfo) {
+	if r.OperationLock == nil {
+		return
+	}
+	r.OperationLock.Unlock(sleepInfo.Namespace, sleepInfo.Name)
+}
+
+// setOperationQueued sets the OperationQueued condition of the SleepInfo. The
+// condition is only added when an operation is queued, so that the SleepInfo
+// whose operations were never queued are not updated. A failure is only
+// logged.
+func (r *SleepInfoReconciler) setOperationQueued(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo, condition metav1.Condition) {
+	if condition.Status == metav1.ConditionFalse && !meta.IsStatusConditionTrue(sleepInfo.Status.Conditions, condition.Type) {
+		return
+	}
+	if err := r.setLatestStatusCondition(ctx, client.ObjectKeyFromObject(sleepInfo), condition); err != nil {
+		log.Error(err, "fails to set the OperationQueued condition")
+	}
+}
+
+// getPendingOperationOfNamespace returns the first operation, by secret
+// name, interrupted before its end in the state secrets of the namespace
+// other than the one of the SleepInfo, empty if none.
+func (r *SleepInfoReconciler) getPendingOperationOfNamespace(ctx context.Context, namespace, secretName string) (string, error) {
+	secrets := v1.SecretList{}
+	if err := r.Client.List(ctx, &secrets, client.InNamespace(namespace), client.MatchingLabels{managedByLabel: fieldManagerName}); err != nil {
+		return "", err
+	}
+	sort.Slice(secrets.Items, func(i, j int) bool {
+		return secrets.Items[i].Name < secrets.Items[j].Name
+	})
+	for _, secret := range secrets.Items {
+		if secret.Name == secretName {
+			continue
+		}
+		if operationID := string(secret.Data[pendingOperationKey]); operationID != "" {
+			return fmt.Sprintf("the pending operation %s of the state secret %s", operationID, secret.Name), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,171 @@
+package sleepinfo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestOperationLock(t *testing.T) {
+	now := time.Date(2024, 3, 4, 20, 0, 0, 0, time.UTC)
+
+	t.Run("lock the namespace", func(t *testing.T) {
+		l := NewOperationLock()
+		_, isLocked := l.TryLock("ns1", "scheduled", sleepOperation, now)
+		require.True(t, isLocked)
+
+		holder, isLocked := l.TryLock("ns1", "manual", wakeUpOperation, now)
+		require.False(t, isLocked)
+		require.Equal(t, "the SLEEP operation of SleepInfo scheduled", holder)
+
+		_, isLocked = l.TryLock("ns2", "manual", wakeUpOperation, now)
+		require.True(t, isLocked, "the namespaces have their own lock")
+
+		l.Unlock("ns1", "scheduled")
+		_, isLocked = l.TryLock("ns1", "manual", wakeUpOperation, now)
+		require.True(t, isLocked)
+	})
+
+	t.Run("the queued operations acquire the lock in order", func(t *testing.T) {
+		l := NewOperationLock()
+		_, isLocked := l.TryLock("ns1", "a", sleepOperation, now)
+		require.True(t, isLocked)
+		_, isLocked = l.TryLock("ns1", "b", sleepOperation, now)
+		require.False(t, isLocked)
+		_, isLocked = l.TryLock("ns1", "c", wakeUpOperation, now.Add(time.Second))
+		require.False(t, isLocked)
+
+		l.Unlock("ns1", "a")
+		holder, isLocked := l.TryLock("ns1", "c", wakeUpOperation, now.Add(5*time.Second))
+		require.False(t, isLocked)
+		require.Equal(t, "the SLEEP operation of SleepInfo b", holder)
+
+		_, isLocked = l.TryLock("ns1", "b", sleepOperation, now.Add(5*time.Second))
+		require.True(t, isLocked)
+		l.Unlock("ns1", "b")
+		_, isLocked = l.TryLock("ns1", "c", wakeUpOperation, now.Add(10*time.Second))
+		require.True(t, isLocked)
+	})
+
+	t.Run("the queued operations which did not try again are removed", func(t *testing.T) {
+		l := NewOperationLock()
+		_, isLocked := l.TryLock("ns1", "a", sleepOperation, now)
+		require.True(t, isLocked)
+		_, isLocked = l.TryLock("ns1", "deleted", sleepOperation, now)
+		require.False(t, isLocked)
+		l.Unlock("ns1", "a")
+
+		_, isLocked = l.TryLock("ns1", "c", sleepOperation, now.Add(operationLockQueueTimeout+time.Second))
+		require.True(t, isLocked)
+	})
+
+	t.Run("unlock only by the holder", func(t *testing.T) {
+		l := NewOperationLock()
+		_, isLocked := l.TryLock("ns1", "a", sleepOperation, now)
+		require.True(t, isLocked)
+		l.Unlock("ns1", "b")
+
+		_, isLocked = l.TryLock("ns1", "b", sleepOperation, now)
+		require.False(t, isLocked)
+		_, isLocked = l.TryLock("ns1", "a", sleepOperation, now)
+		require.True(t, isLocked, "the lock is held by the SleepInfo")
+	})
+}
+
+func TestAcquireOperationLock(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+	require.NoError(t, v1.AddToScheme(scheme))
+
+	namespace := "my-namespace"
+	now := time.Date(2024, 3, 4, 20, 0, 0, 0, time.UTC)
+	getSleepInfo := func(name string) *kubegreenv1alpha1.SleepInfo {
+		return &kubegreenv1alpha1.SleepInfo{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	}
+	getSecret := func(name, pendingOperation string) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{managedByLabel: fieldManagerName},
+			},
+			Data: map[string][]byte{pendingOperationKey: []byte(pendingOperation)},
+		}
+	}
+	getReconciler := func(objects ...client.Object) (SleepInfoReconciler, *record.FakeRecorder) {
+		recorder := record.NewFakeRecorder(10)
+		return SleepInfoReconciler{
+			Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+			Recorder:      recorder,
+			OperationLock: NewOperationLock(),
+		}, recorder
+	}
+	getCondition := func(t *testing.T, r SleepInfoReconciler, sleepInfo *kubegreenv1alpha1.SleepInfo) *metav1.Condition {
+		t.Helper()
+		current := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, r.Get(context.Background(), client.ObjectKeyFromObject(sleepInfo), current))
+		return meta.FindStatusCondition(current.Status.Conditions, kubegreenv1alpha1.OperationQueuedCondition)
+	}
+	sleepData := SleepInfoData{CurrentOperationType: sleepOperation}
+	wakeUpData := SleepInfoData{CurrentOperationType: wakeUpOperation}
+
+	t.Run("queue the operation while another SleepInfo of the namespace holds the lock", func(t *testing.T) {
+		scheduled := getSleepInfo("scheduled")
+		manual := getSleepInfo("manual")
+		r, recorder := getReconciler(scheduled, manual)
+
+		require.True(t, r.acquireOperationLock(context.Background(), logr.Discard(), scheduled, sleepData, "sleepinfo-scheduled", now))
+		require.Nil(t, getCondition(t, r, scheduled), "the condition is not added if the operation is not queued")
+
+		require.False(t, r.acquireOperationLock(context.Background(), logr.Discard(), manual, wakeUpData, "sleepinfo-manual", now))
+		condition := getCondition(t, r, manual)
+		require.NotNil(t, condition)
+		require.Equal(t, metav1.ConditionTrue, condition.Status)
+		require.Equal(t, kubegreenv1alpha1.OperationQueuedReasonNamespaceLocked, condition.Reason)
+		require.Equal(t, "WAKE_UP operation queued behind the SLEEP operation of SleepInfo scheduled", condition.Message)
+		require.Equal(t, "Normal OperationQueued WAKE_UP operation queued behind the SLEEP operation of SleepInfo scheduled", <-recorder.Events)
+
+		r.releaseOperationLock(scheduled)
+		require.NoError(t, r.Get(context.Background(), client.ObjectKeyFromObject(manual), manual))
+		require.True(t, r.acquireOperationLock(context.Background(), logr.Discard(), manual, wakeUpData, "sleepinfo-manual", now.Add(operationLockRetryInterval)))
+		condition = getCondition(t, r, manual)
+		require.Equal(t, metav1.ConditionFalse, condition.Status)
+		require.Equal(t, kubegreenv1alpha1.OperationQueuedReasonLockAcquired, condition.Reason)
+	})
+
+	t.Run("queue the operation behind the pending operation of another SleepInfo", func(t *testing.T) {
+		sleepInfo := getSleepInfo("manual")
+		r, _ := getReconciler(sleepInfo, getSecret("sleepinfo-scheduled", "SLEEP-1709582400"), getSecret("sleepinfo-manual", ""))
+
+		require.False(t, r.acquireOperationLock(context.Background(), logr.Discard(), sleepInfo, wakeUpData, "sleepinfo-manual", now))
+		require.Equal(t, "WAKE_UP operation queued behind the pending operation SLEEP-1709582400 of the state secret sleepinfo-scheduled", getCondition(t, r, sleepInfo).Message)
+	})
+
+	t.Run("resume the pending operation of the SleepInfo", func(t *testing.T) {
+		sleepInfo := getSleepInfo("manual")
+		r, _ := getReconciler(sleepInfo, getSecret("sleepinfo-scheduled", "SLEEP-1709582400"), getSecret("sleepinfo-manual", "WAKE_UP-1709582400"))
+
+		data := SleepInfoData{CurrentOperationType: wakeUpOperation, PendingOperationID: "WAKE_UP-1709582400"}
+		require.True(t, r.acquireOperationLock(context.Background(), logr.Discard(), sleepInfo, data, "sleepinfo-manual", now))
+	})
+
+	t.Run("the lock is disabled", func(t *testing.T) {
+		sleepInfo := getSleepInfo("manual")
+		r := SleepInfoReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).Build()}
+
+		require.True(t, r.acquireOperationLock(context.Background(), logr.Discard(), sleepInfo, sleepData, "sleepinfo-manual", now))
+		require.True(t, r.acquireOperationLock(context.Background(), logr.Discard(), sleepInfo, sleepData, "sleepinfo-manual", now))
+	})
+}
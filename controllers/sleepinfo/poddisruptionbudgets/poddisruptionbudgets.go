@@ -0,0 +1,207 @@
+package poddisruptionbudgets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SleepingAnnotation is added to the PodDisruptionBudgets relaxed during the
+// sleep, e.g. to silence the alerts on them.
+const SleepingAnnotation = "kube-green.dev/sleeping"
+
+var (
+	ErrFetchingPodDisruptionBudgets = errors.New("error fetching poddisruptionbudgets")
+)
+
+type OriginalBudgets map[string]OriginalBudget
+type poddisruptionbudgets struct {
+	resource.ResourceClient
+	data            []policyv1.PodDisruptionBudget
+	OriginalBudgets OriginalBudgets
+	areToSuspend    bool
+}
+
+func NewResource(ctx context.Context, res resource.ResourceClient, namespace string, originalBudgets OriginalBudgets) (resource.Resource, error) {
+	p := poddisruptionbudgets{
+		ResourceClient:  res,
+		OriginalBudgets: originalBudgets,
+		areToSuspend:    res.SleepInfo.IsPodDisruptionBudgetsToSuspend() || (res.IsWakeUp && len(originalBudgets) > 0),
+		data:            []policyv1.PodDisruptionBudget{},
+	}
+	if !p.areToSuspend {
+		return p, nil
+	}
+	if err := p.fetch(ctx, namespace); err != nil {
+		return poddisruptionbudgets{}, fmt.Errorf("%w: %s", ErrFetchingPodDisruptionBudgets, err)
+	}
+
+	return p, nil
+}
+
+func (p poddisruptionbudgets) HasResource() bool {
+	return len(p.data) > 0
+}
+
+func (p poddisruptionbudgets) GetResourceNames() []string {
+	names := []string{}
+	for _, pdb := range p.data {
+		names = append(names, pdb.Name)
+	}
+	return names
+}
+
+func isSleeping(pdb policyv1.PodDisruptionBudget) bool {
+	_, ok := pdb.Annotations[SleepingAnnotation]
+	return ok
+}
+
+func (p poddisruptionbudgets) Sleep(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "poddisruptionbudgets.sleep", trace.WithAttributes(attribute.Int("resources.count", len(p.data))))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	for _, pdb := range p.data {
+		pdb := pdb
+		if isSleeping(pdb) || p.IsKeptAwake(&pdb) {
+			continue
+		}
+		newPDB := pdb.DeepCopy()
+		if newPDB.Annotations == nil {
+			newPDB.Annotations = map[string]string{}
+		}
+		newPDB.Annotations[SleepingAnnotation] = "true"
+		// minAvailable and maxUnavailable cannot be set together.
+		minAvailable := intstr.FromInt(0)
+		newPDB.Spec.MinAvailable = &minAvailable
+		newPDB.Spec.MaxUnavailable = nil
+
+		if err := p.Patch(ctx, &pdb, newPDB); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p poddisruptionbudgets) WakeUp(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "poddisruptionbudgets.wakeUp", trace.WithAttributes(attribute.Int("resources.count", len(p.data))))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	for _, pdb := range p.data {
+		pdb := pdb
+
+		pdbLogger := p.Log.WithValues("poddisruptionbudget", pdb.Name, "namespace", pdb.Namespace)
+		if !isSleeping(pdb) {
+			pdbLogger.Info("poddisruptionbudget is not sleeping during wake up")
+			continue
+		}
+
+		originalBudget, ok := p.OriginalBudgets[pdb.Name]
+		if !ok {
+			pdbLogger.Info("original poddisruptionbudget info not correctly set")
+			continue
+		}
+
+		newPDB := pdb.DeepCopy()
+		delete(newPDB.Annotations, SleepingAnnotation)
+		newPDB.Spec.MinAvailable = originalBudget.MinAvailable
+		newPDB.Spec.MaxUnavailable = originalBudget.MaxUnavailable
+
+		if err := p.Patch(ctx, &pdb, newPDB); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type OriginalBudget struct {
+	Name           string              `json:"name"`
+	MinAvailable   *intstr.IntOrString `json:"minAvailable,omitempty"`
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+func (p poddisruptionbudgets) GetOriginalInfoToSave() ([]byte, error) {
+	if !p.areToSuspend {
+		return nil, nil
+	}
+	originalBudgets := []OriginalBudget{}
+	for _, pdb := range p.data {
+		originalBudget := OriginalBudget{
+			Name:           pdb.Name,
+			MinAvailable:   pdb.Spec.MinAvailable,
+			MaxUnavailable: pdb.Spec.MaxUnavailable,
+		}
+		if isSleeping(pdb) {
+			// a poddisruptionbudget relaxed by a previous sleep is still to restore.
+			var ok bool
+			if originalBudget, ok = p.OriginalBudgets[pdb.Name]; !ok {
+				continue
+			}
+		}
+		originalBudgets = append(originalBudgets, originalBudget)
+	}
+	return json.Marshal(originalBudgets)
+}
+
+func (p *poddisruptionbudgets) fetch(ctx context.Context, namespace string) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "poddisruptionbudgets.list")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	pdbList, err := p.getListByNamespace(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	p.Log.V(1).WithValues("number of poddisruptionbudgets", len(pdbList), "namespace", namespace).Info("poddisruptionbudgets in namespace")
+	p.data = p.filterExcluded(pdbList)
+	return nil
+}
+
+func (p poddisruptionbudgets) getListByNamespace(ctx context.Context, namespace string) ([]policyv1.PodDisruptionBudget, error) {
+	listOptions := &client.ListOptions{
+		Namespace: namespace,
+		Limit:     500,
+	}
+	pdbList := policyv1.PodDisruptionBudgetList{}
+	if err := p.Client.List(ctx, &pdbList, listOptions); err != nil {
+		return pdbList.Items, client.IgnoreNotFound(err)
+	}
+	return pdbList.Items, nil
+}
+
+func (p poddisruptionbudgets) filterExcluded(pdbList []policyv1.PodDisruptionBudget) []policyv1.PodDisruptionBudget {
+	filteredList := []policyv1.PodDisruptionBudget{}
+	for _, pdb := range pdbList {
+		pdb := pdb
+		if !p.IsWakeUp && resource.IsExcluded("PodDisruptionBudget", &pdb, p.SleepInfo.GetExcludeRef()) {
+			continue
+		}
+		filteredList = append(filteredList, pdb)
+	}
+	return filteredList
+}
+
+func GetOriginalInfoToRestore(savedData []byte) (OriginalBudgets, error) {
+	if savedData == nil {
+		return OriginalBudgets{}, nil
+	}
+	originalBudgetsInfo := []OriginalBudget{}
+	if err := json.Unmarshal(savedData, &originalBudgetsInfo); err != nil {
+		return nil, err
+	}
+	originalBudgets := OriginalBudgets{}
+	for _, originalBudget := range originalBudgetsInfo {
+		if originalBudget.Name != "" {
+			originalBudgets[originalBudget.Name] = originalBudget
+		}
+	}
+	return originalBudgets, nil
+}
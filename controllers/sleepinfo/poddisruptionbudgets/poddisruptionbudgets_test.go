@@ -0,0 +1,331 @@
+package poddisruptionbudgets
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/internal/testutil"
+
+	"github.com/stretchr/testify/require"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestPodDisruptionBudgets(t *testing.T) {
+	testLogger := zap.New(zap.UseDevMode(true))
+
+	namespace := "my-namespace"
+	minAvailable := intstr.FromInt(2)
+	maxUnavailable := intstr.FromString("25%")
+	zero := intstr.FromInt(0)
+	sleeping := map[string]string{SleepingAnnotation: "true"}
+
+	pdbMinAvailable := GetMock(MockSpec{
+		Name:         "pdb-min-available",
+		Namespace:    namespace,
+		MinAvailable: &minAvailable,
+	})
+	pdbMaxUnavailable := GetMock(MockSpec{
+		Name:           "pdb-max-unavailable",
+		Namespace:      namespace,
+		MaxUnavailable: &maxUnavailable,
+	})
+	pdbWithLabels := GetMock(MockSpec{
+		Name:         "pdb-with-labels",
+		Namespace:    namespace,
+		MinAvailable: &minAvailable,
+		Labels: map[string]string{
+			"app": "foo",
+		},
+	})
+	pdbOtherNamespace := GetMock(MockSpec{
+		Name:         "pdb-other-namespace",
+		Namespace:    "other-namespace",
+		MinAvailable: &minAvailable,
+	})
+	sleepingPDBMinAvailable := GetMock(MockSpec{
+		Name:         "pdb-min-available",
+		Namespace:    namespace,
+		MinAvailable: &zero,
+		Annotations:  sleeping,
+	})
+	sleepingPDBMaxUnavailable := GetMock(MockSpec{
+		Name:         "pdb-max-unavailable",
+		Namespace:    namespace,
+		MinAvailable: &zero,
+		Annotations:  sleeping,
+	})
+	sleepInfo := &v1alpha1.SleepInfo{
+		Spec: v1alpha1.SleepInfoSpec{
+			SuspendPodDisruptionBudgets: true,
+		},
+	}
+
+	getNewResource := func(t *testing.T, client client.Client, originalBudgets OriginalBudgets) poddisruptionbudgets {
+		t.Helper()
+
+		resource, err := NewResource(context.Background(), resource.ResourceClient{
+			Client:    client,
+			Log:       testLogger,
+			SleepInfo: sleepInfo,
+		}, namespace, originalBudgets)
+		require.NoError(t, err)
+
+		pdbs, ok := resource.(poddisruptionbudgets)
+		require.True(t, ok)
+		return pdbs
+	}
+
+	t.Run("NewResource", func(t *testing.T) {
+		listTests := []struct {
+			name          string
+			client        client.Client
+			expectedNames []string
+			sleepInfo     *v1alpha1.SleepInfo
+			throws        bool
+		}{
+			{
+				name: "get list of poddisruptionbudgets",
+				client: fake.NewClientBuilder().
+					WithRuntimeObjects(&pdbMinAvailable, &pdbMaxUnavailable, &pdbOtherNamespace).
+					Build(),
+				expectedNames: []string{"pdb-max-unavailable", "pdb-min-available"},
+				sleepInfo:     sleepInfo,
+			},
+			{
+				name:      "fails to list poddisruptionbudgets",
+				sleepInfo: sleepInfo,
+				client: &testutil.PossiblyErroringFakeCtrlRuntimeClient{
+					Client: fake.NewClientBuilder().Build(),
+					ShouldError: func(method testutil.Method, obj runtime.Object) bool {
+						return method == testutil.List
+					},
+				},
+				throws:        true,
+				expectedNames: []string{},
+			},
+			{
+				name: "exclude poddisruptionbudgets by name and labels",
+				client: fake.NewClientBuilder().
+					WithRuntimeObjects(&pdbMinAvailable, &pdbMaxUnavailable, &pdbWithLabels).
+					Build(),
+				expectedNames: []string{"pdb-min-available"},
+				sleepInfo: &v1alpha1.SleepInfo{
+					Spec: v1alpha1.SleepInfoSpec{
+						SuspendPodDisruptionBudgets: true,
+						ExcludeRef: []v1alpha1.ExcludeRef{
+							{
+								APIVersion: "policy/v1",
+								Kind:       "PodDisruptionBudget",
+								Name:       "pdb-max-unavailable",
+							},
+							{
+								MatchLabels: map[string]string{
+									"app": "foo",
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				name: "disabled poddisruptionbudgets suspend",
+				client: fake.NewClientBuilder().
+					WithRuntimeObjects(&pdbMinAvailable).
+					Build(),
+				sleepInfo:     &v1alpha1.SleepInfo{},
+				expectedNames: []string{},
+			},
+		}
+
+		for _, test := range listTests {
+			t.Run(test.name, func(t *testing.T) {
+				r := resource.ResourceClient{
+					Client:    test.client,
+					Log:       testLogger,
+					SleepInfo: test.sleepInfo,
+				}
+
+				res, err := NewResource(context.Background(), r, namespace, OriginalBudgets{})
+				if test.throws {
+					require.EqualError(t, err, fmt.Sprintf("%s: error during list", ErrFetchingPodDisruptionBudgets))
+				} else {
+					require.NoError(t, err)
+				}
+				require.Equal(t, test.expectedNames, res.GetResourceNames())
+			})
+		}
+	})
+
+	t.Run("HasResources", func(t *testing.T) {
+		t.Run("without resource", func(t *testing.T) {
+			p := getNewResource(t, fake.NewClientBuilder().Build(), nil)
+			require.False(t, p.HasResource())
+			require.Empty(t, p.GetResourceNames())
+		})
+
+		t.Run("with resource", func(t *testing.T) {
+			p := getNewResource(t, fake.NewClientBuilder().WithRuntimeObjects(&pdbMinAvailable).Build(), nil)
+			require.True(t, p.HasResource())
+			require.Equal(t, []string{"pdb-min-available"}, p.GetResourceNames())
+		})
+	})
+
+	t.Run("Sleep", func(t *testing.T) {
+		t.Run("not throws if no data", func(t *testing.T) {
+			p := getNewResource(t, fake.NewClientBuilder().Build(), nil)
+			require.NoError(t, p.Sleep(context.Background()))
+		})
+
+		t.Run("set minAvailable to 0", func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithRuntimeObjects(&pdbMinAvailable, &pdbMaxUnavailable).
+				Build()
+			p := getNewResource(t, fakeClient, nil)
+			require.NoError(t, p.Sleep(context.Background()))
+
+			list, err := p.getListByNamespace(context.Background(), namespace)
+			require.NoError(t, err)
+			require.Len(t, list, 2)
+			for _, pdb := range list {
+				require.Equal(t, &zero, pdb.Spec.MinAvailable, pdb.Name)
+				require.Nil(t, pdb.Spec.MaxUnavailable, pdb.Name)
+				require.Equal(t, "true", pdb.Annotations[SleepingAnnotation], pdb.Name)
+			}
+		})
+
+		t.Run("fails to patch poddisruptionbudgets", func(t *testing.T) {
+			fakeClient := testutil.PossiblyErroringFakeCtrlRuntimeClient{
+				Client: fake.NewClientBuilder().WithRuntimeObjects(&pdbMinAvailable).Build(),
+				ShouldError: func(method testutil.Method, obj runtime.Object) bool {
+					return method == testutil.Patch
+				},
+			}
+			p := getNewResource(t, fakeClient, nil)
+			require.EqualError(t, p.Sleep(context.Background()), "fails to patch PodDisruptionBudget.policy pdb-min-available: error during patch")
+		})
+	})
+
+	t.Run("WakeUp", func(t *testing.T) {
+		t.Run("not throws if no data", func(t *testing.T) {
+			p := getNewResource(t, fake.NewClientBuilder().Build(), nil)
+			require.NoError(t, p.WakeUp(context.Background()))
+		})
+
+		t.Run("restore the original budgets", func(t *testing.T) {
+			sleepingNotSaved := GetMock(MockSpec{
+				Name:         "pdb-not-saved",
+				Namespace:    namespace,
+				MinAvailable: &zero,
+				Annotations:  sleeping,
+			})
+			fakeClient := fake.NewClientBuilder().
+				WithRuntimeObjects(&sleepingPDBMinAvailable, &sleepingPDBMaxUnavailable, &sleepingNotSaved).
+				Build()
+			p := getNewResource(t, fakeClient, OriginalBudgets{
+				"pdb-min-available":   {Name: "pdb-min-available", MinAvailable: &minAvailable},
+				"pdb-max-unavailable": {Name: "pdb-max-unavailable", MaxUnavailable: &maxUnavailable},
+			})
+			require.NoError(t, p.WakeUp(context.Background()))
+
+			list, err := p.getListByNamespace(context.Background(), namespace)
+			require.NoError(t, err)
+			budgets := getBudgets(list)
+			require.Equal(t, OriginalBudgets{
+				"pdb-min-available":   {Name: "pdb-min-available", MinAvailable: &minAvailable},
+				"pdb-max-unavailable": {Name: "pdb-max-unavailable", MaxUnavailable: &maxUnavailable},
+				"pdb-not-saved":       {Name: "pdb-not-saved", MinAvailable: &zero},
+			}, budgets)
+			for _, pdb := range list {
+				_, ok := pdb.Annotations[SleepingAnnotation]
+				require.Equal(t, pdb.Name == "pdb-not-saved", ok, pdb.Name)
+			}
+		})
+
+		t.Run("fails to wake up", func(t *testing.T) {
+			fakeClient := testutil.PossiblyErroringFakeCtrlRuntimeClient{
+				Client: fake.NewClientBuilder().WithRuntimeObjects(&sleepingPDBMinAvailable).Build(),
+				ShouldError: func(method testutil.Method, obj runtime.Object) bool {
+					return method == testutil.Patch
+				},
+			}
+			p := getNewResource(t, fakeClient, OriginalBudgets{
+				"pdb-min-available": {Name: "pdb-min-available", MinAvailable: &minAvailable},
+			})
+			require.EqualError(t, p.WakeUp(context.Background()), "fails to patch PodDisruptionBudget.policy pdb-min-available: error during patch")
+		})
+	})
+
+	t.Run("GetOriginalInfoToSave", func(t *testing.T) {
+		t.Run("returns nil if not to suspend", func(t *testing.T) {
+			p := getNewResource(t, fake.NewClientBuilder().Build(), nil)
+			p.areToSuspend = false
+			res, err := p.GetOriginalInfoToSave()
+			require.NoError(t, err)
+			require.Nil(t, res)
+		})
+
+		t.Run("with poddisruptionbudgets", func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithRuntimeObjects(&pdbMinAvailable, &pdbMaxUnavailable).
+				Build()
+			p := getNewResource(t, fakeClient, nil)
+			res, err := p.GetOriginalInfoToSave()
+			require.NoError(t, err)
+			require.JSONEq(t, `[{"name":"pdb-max-unavailable","maxUnavailable":"25%"},{"name":"pdb-min-available","minAvailable":2}]`, string(res))
+		})
+
+		t.Run("keeps poddisruptionbudgets relaxed by a previous sleep", func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithRuntimeObjects(&sleepingPDBMinAvailable, &sleepingPDBMaxUnavailable).
+				Build()
+			p := getNewResource(t, fakeClient, OriginalBudgets{
+				"pdb-min-available": {Name: "pdb-min-available", MinAvailable: &minAvailable},
+			})
+			res, err := p.GetOriginalInfoToSave()
+			require.NoError(t, err)
+			require.JSONEq(t, `[{"name":"pdb-min-available","minAvailable":2}]`, string(res))
+		})
+	})
+
+	t.Run("GetOriginalInfoToRestore", func(t *testing.T) {
+		t.Run("if empty saved data, returns empty info", func(t *testing.T) {
+			info, err := GetOriginalInfoToRestore(nil)
+			require.NoError(t, err)
+			require.Equal(t, OriginalBudgets{}, info)
+		})
+
+		t.Run("throws if data is not a correct json", func(t *testing.T) {
+			_, err := GetOriginalInfoToRestore([]byte("{}"))
+			require.EqualError(t, err, "json: cannot unmarshal object into Go value of type []poddisruptionbudgets.OriginalBudget")
+		})
+
+		t.Run("correctly returns data", func(t *testing.T) {
+			info, err := GetOriginalInfoToRestore([]byte(`[{"name":"pdb1","minAvailable":2},{"name":"pdb2","maxUnavailable":"25%"},{"name":""}]`))
+			require.NoError(t, err)
+			require.Equal(t, OriginalBudgets{
+				"pdb1": {Name: "pdb1", MinAvailable: &minAvailable},
+				"pdb2": {Name: "pdb2", MaxUnavailable: &maxUnavailable},
+			}, info)
+		})
+	})
+}
+
+func getBudgets(list []policyv1.PodDisruptionBudget) OriginalBudgets {
+	budgets := OriginalBudgets{}
+	for _, pdb := range list {
+		budgets[pdb.Name] = OriginalBudget{
+			Name:           pdb.Name,
+			MinAvailable:   pdb.Spec.MinAvailable,
+			MaxUnavailable: pdb.Spec.MaxUnavailable,
+		}
+	}
+	return budgets
+}
@@ -0,0 +1,42 @@
+package poddisruptionbudgets
+
+import (
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+type MockSpec struct {
+	Namespace       string
+	Name            string
+	Labels          map[string]string
+	Annotations     map[string]string
+	ResourceVersion string
+	MinAvailable    *intstr.IntOrString
+	MaxUnavailable  *intstr.IntOrString
+}
+
+func GetMock(opts MockSpec) policyv1.PodDisruptionBudget {
+	return policyv1.PodDisruptionBudget{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PodDisruptionBudget",
+			APIVersion: "policy/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            opts.Name,
+			Namespace:       opts.Namespace,
+			ResourceVersion: opts.ResourceVersion,
+			Labels:          opts.Labels,
+			Annotations:     opts.Annotations,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": opts.Name,
+				},
+			},
+			MinAvailable:   opts.MinAvailable,
+			MaxUnavailable: opts.MaxUnavailable,
+		},
+	}
+}
@@ -0,0 +1,122 @@
+package pods
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	ErrFetchingPods = errors.New("error fetching pods")
+)
+
+// pods are the standalone pods of the namespace, i.e. not owned by other
+// resources, e.g. the debug pods and the pods created with kubectl run. They
+// are deleted on sleep and, since they cannot be recreated, they are not
+// restored on wake up.
+type pods struct {
+	resource.ResourceClient
+	data        []v1.Pod
+	areToDelete bool
+}
+
+func NewResource(ctx context.Context, res resource.ResourceClient, namespace string) (resource.Resource, error) {
+	p := pods{
+		ResourceClient: res,
+		areToDelete:    !res.IsWakeUp && res.SleepInfo.IsStandalonePodsToDelete(),
+		data:           []v1.Pod{},
+	}
+	if !p.areToDelete {
+		return p, nil
+	}
+	if res.PodReader == nil {
+		res.Log.Info("pods not read by the controller, standalone pods not deleted")
+		return p, nil
+	}
+	if err := p.fetch(ctx, namespace); err != nil {
+		return pods{}, fmt.Errorf("%w: %s", ErrFetchingPods, err)
+	}
+
+	return p, nil
+}
+
+func (p pods) HasResource() bool {
+	return len(p.data) > 0
+}
+
+func (p pods) GetResourceNames() []string {
+	names := []string{}
+	for _, pod := range p.data {
+		names = append(names, pod.Name)
+	}
+	return names
+}
+
+func (p pods) Sleep(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "pods.sleep", trace.WithAttributes(attribute.Int("resources.count", len(p.data))))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	for _, pod := range p.data {
+		pod := pod
+		if err := p.Client.Delete(ctx, &pod); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("fails to delete pod %s: %s", pod.Name, err)
+		}
+		p.Log.V(1).Info("standalone pod deleted", "pod", pod.Name, "namespace", pod.Namespace)
+	}
+	return nil
+}
+
+// WakeUp does nothing, since the deleted pods are not restored.
+func (p pods) WakeUp(ctx context.Context) error {
+	return nil
+}
+
+// GetOriginalInfoToSave returns nil, since the deleted pods are not restored.
+func (p pods) GetOriginalInfoToSave() ([]byte, error) {
+	return nil, nil
+}
+
+func (p *pods) fetch(ctx context.Context, namespace string) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "pods.list")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	podList := v1.PodList{}
+	if err := p.PodReader.List(ctx, &podList, client.InNamespace(namespace)); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	p.Log.V(1).WithValues("number of pods", len(podList.Items), "namespace", namespace).Info("pods in namespace")
+	p.data = p.filterPods(podList.Items)
+	return nil
+}
+
+// filterPods returns the pods not owned by other resources, still running
+// and not excluded by the SleepInfo. The pods kept awake are filtered out
+// too, since the deleted pods are not restored.
+func (p pods) filterPods(podList []v1.Pod) []v1.Pod {
+	filteredList := []v1.Pod{}
+	for _, pod := range podList {
+		pod := pod
+		if len(pod.OwnerReferences) > 0 || isFinished(pod) {
+			continue
+		}
+		if resource.IsExcluded("Pod", &pod, p.SleepInfo.GetExcludeRef()) || !p.SleepInfo.IsManagedByClass(pod.Labels) || p.IsKeptAwake(&pod) {
+			continue
+		}
+		filteredList = append(filteredList, pod)
+	}
+	return filteredList
+}
+
+// isFinished returns true if the pod is already terminated or deleted, so
+// that it does not use the resources of its node anymore.
+func isFinished(pod v1.Pod) bool {
+	return pod.DeletionTimestamp != nil || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed
+}
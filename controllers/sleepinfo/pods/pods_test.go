@@ -0,0 +1,151 @@
+package pods
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestPods(t *testing.T) {
+	testLogger := zap.New(zap.UseDevMode(true))
+
+	namespace := "my-namespace"
+	isController := true
+	getPod := func(name string, mutate func(pod *v1.Pod)) *v1.Pod {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Status:     v1.PodStatus{Phase: v1.PodRunning},
+		}
+		if mutate != nil {
+			mutate(pod)
+		}
+		return pod
+	}
+	debugPod := getPod("debug", nil)
+	curlPod := getPod("curl", func(pod *v1.Pod) {
+		pod.Labels = map[string]string{"app": "curl"}
+	})
+	ownedPod := getPod("api-7d9f-x2x4z", func(pod *v1.Pod) {
+		pod.OwnerReferences = []metav1.OwnerReference{
+			{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "api-7d9f", UID: "replicaset-uid", Controller: &isController},
+		}
+	})
+	completedPod := getPod("completed", func(pod *v1.Pod) {
+		pod.Status.Phase = v1.PodSucceeded
+	})
+	keptAwakePod := getPod("kept-awake", func(pod *v1.Pod) {
+		pod.Annotations = map[string]string{resource.AwakeUntilAnnotation: time.Now().Add(time.Hour).Format(time.RFC3339)}
+	})
+	otherNamespacePod := getPod("other-namespace", func(pod *v1.Pod) {
+		pod.Namespace = "other-namespace"
+	})
+
+	getClient := func() client.Client {
+		return fake.NewClientBuilder().WithObjects(debugPod, curlPod, ownedPod, completedPod, keptAwakePod, otherNamespacePod).Build()
+	}
+	getNewResource := func(t *testing.T, c client.Client, sleepInfo *v1alpha1.SleepInfo, isWakeUp bool) pods {
+		t.Helper()
+		res, err := NewResource(context.Background(), resource.ResourceClient{
+			Client:    c,
+			PodReader: c,
+			Log:       testLogger,
+			SleepInfo: sleepInfo,
+			IsWakeUp:  isWakeUp,
+		}, namespace)
+		require.NoError(t, err)
+		p, ok := res.(pods)
+		require.True(t, ok)
+		return p
+	}
+	deleteStandalonePods := &v1alpha1.SleepInfo{
+		Spec: v1alpha1.SleepInfoSpec{DeleteStandalonePods: true},
+	}
+
+	t.Run("NewResource", func(t *testing.T) {
+		tests := []struct {
+			name          string
+			sleepInfo     *v1alpha1.SleepInfo
+			isWakeUp      bool
+			expectedNames []string
+		}{
+			{
+				name:          "standalone pods",
+				sleepInfo:     deleteStandalonePods,
+				expectedNames: []string{"curl", "debug"},
+			},
+			{
+				name: "excluded pods",
+				sleepInfo: &v1alpha1.SleepInfo{
+					Spec: v1alpha1.SleepInfoSpec{
+						DeleteStandalonePods: true,
+						ExcludeRef: []v1alpha1.ExcludeRef{
+							{Kind: "Pod", MatchLabels: map[string]string{"app": "curl"}},
+						},
+					},
+				},
+				expectedNames: []string{"debug"},
+			},
+			{
+				name:          "not enabled",
+				sleepInfo:     &v1alpha1.SleepInfo{},
+				expectedNames: []string{},
+			},
+			{
+				name:          "wake up",
+				sleepInfo:     deleteStandalonePods,
+				isWakeUp:      true,
+				expectedNames: []string{},
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				p := getNewResource(t, getClient(), test.sleepInfo, test.isWakeUp)
+				require.ElementsMatch(t, test.expectedNames, p.GetResourceNames())
+				require.Equal(t, len(test.expectedNames) > 0, p.HasResource())
+			})
+		}
+	})
+
+	t.Run("without the pod reader", func(t *testing.T) {
+		res, err := NewResource(context.Background(), resource.ResourceClient{
+			Client:    getClient(),
+			Log:       testLogger,
+			SleepInfo: deleteStandalonePods,
+		}, namespace)
+		require.NoError(t, err)
+		require.False(t, res.HasResource())
+	})
+
+	t.Run("Sleep", func(t *testing.T) {
+		c := getClient()
+		p := getNewResource(t, c, deleteStandalonePods, false)
+		require.NoError(t, p.Sleep(context.Background()))
+
+		for _, pod := range []*v1.Pod{debugPod, curlPod} {
+			err := c.Get(context.Background(), client.ObjectKeyFromObject(pod), &v1.Pod{})
+			require.True(t, apierrors.IsNotFound(err), pod.Name)
+		}
+		for _, pod := range []*v1.Pod{ownedPod, completedPod, keptAwakePod, otherNamespacePod} {
+			require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(pod), &v1.Pod{}), pod.Name)
+		}
+	})
+
+	t.Run("WakeUp and GetOriginalInfoToSave", func(t *testing.T) {
+		p := getNewResource(t, getClient(), deleteStandalonePods, false)
+		require.NoError(t, p.WakeUp(context.Background()))
+		info, err := p.GetOriginalInfoToSave()
+		require.NoError(t, err)
+		require.Nil(t, info)
+	})
+}
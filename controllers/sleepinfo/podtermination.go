@@ -0,0 +1,117 @@
+package sleepinfo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// checkPodTermination checks, once the timeout of the termination check
+// after the sleep is passed, the pods of the namespace still terminating.
+// The pods terminating after their grace period are stuck: an event is
+// recorded and, if the check force deletes them, they are deleted removing
+// their finalizers. It returns when the pods terminating within their grace
+// period must be checked again, and false if it is not needed.
+func (r *SleepInfoReconciler) checkPodTermination(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time) (time.Duration, bool) {
+	check := sleepInfo.GetPodTerminationCheck()
+	if check == nil || r.PodReader == nil || sleepInfo.Status.OperationType != sleepOperation {
+		return 0, false
+	}
+	checkAt := sleepInfo.Status.LastScheduleTime.Add(check.GetTimeout())
+	if now.Before(checkAt) {
+		return checkAt.Sub(now), true
+	}
+
+	pods := v1.PodList{}
+	if err := r.PodReader.List(ctx, &pods, client.InNamespace(sleepInfo.Namespace)); err != nil {
+		log.Error(err, "fails to list the pods terminating after the sleep")
+		return 0, false
+	}
+	stuckPods := []v1.Pod{}
+	var checkAfter time.Duration
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil {
+			continue
+		}
+		// the deletion timestamp is the end of the grace period of the pod.
+		if now.Before(pod.DeletionTimestamp.Time) {
+			if remaining := pod.DeletionTimestamp.Sub(now); checkAfter == 0 || remaining < checkAfter {
+				checkAfter = remaining
+			}
+			continue
+		}
+		stuckPods = append(stuckPods, pod)
+	}
+	if len(stuckPods) > 0 {
+		r.handleStuckPods(ctx, log, sleepInfo, *check, stuckPods)
+	}
+	return checkAfter, checkAfter > 0
+}
+
+// handleStuckPods records an event with the pods stuck terminating and, if
+// the check force deletes them, deletes them.
+func (r *SleepInfoReconciler) handleStuckPods(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo, check kubegreenv1alpha1.PodTerminationCheck, stuckPods []v1.Pod) {
+	names := make([]string, 0, len(stuckPods))
+	for _, pod := range stuckPods {
+		names = append(names, pod.Name)
+	}
+	sort.Strings(names)
+	log.Info("pods stuck terminating after the sleep", "pods", names)
+	if r.Recorder != nil {
+		r.Recorder.Eventf(sleepInfo, v1.EventTypeWarning, "PodsStuckTerminating", "%d pods stuck terminating after the sleep: %s", len(names), strings.Join(names, ", "))
+	}
+	if !check.ForceDelete {
+		return
+	}
+
+	c, err := r.getNamespaceClient(sleepInfo)
+	if err != nil {
+		log.Error(err, "fails to get the client to force delete the pods")
+		return
+	}
+	forceDeleted := []string{}
+	for i := range stuckPods {
+		pod := &stuckPods[i]
+		if err := forceDeletePod(ctx, c, pod); err != nil {
+			log.Error(err, "fails to force delete the pod", "pod", pod.Name)
+			continue
+		}
+		forceDeleted = append(forceDeleted, pod.Name)
+	}
+	if len(forceDeleted) == 0 {
+		return
+	}
+	sort.Strings(forceDeleted)
+	log.Info("pods force deleted", "pods", forceDeleted)
+	if r.Recorder != nil {
+		r.Recorder.Eventf(sleepInfo, v1.EventTypeNormal, "PodsForceDeleted", "%d pods force deleted: %s", len(forceDeleted), strings.Join(forceDeleted, ", "))
+	}
+}
+
+// forceDeletePod removes the finalizers of the pod and deletes it without
+// grace period.
+func forceDeletePod(ctx context.Context, c client.Client, pod *v1.Pod) error {
+	if len(pod.Finalizers) > 0 {
+		patch := client.MergeFrom(pod.DeepCopy())
+		pod.Finalizers = nil
+		if err := c.Patch(ctx, pod, patch); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("fails to remove the finalizers: %s", err)
+		}
+	}
+	if err := c.Delete(ctx, pod, client.GracePeriodSeconds(0)); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("fails to delete: %s", err)
+	}
+	return nil
+}
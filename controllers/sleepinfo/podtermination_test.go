@@ -0,0 +1,144 @@
+package sleepinfo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckPodTermination(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+	require.NoError(t, v1.AddToScheme(scheme))
+
+	namespace := "my-namespace"
+	sleepTime := time.Date(2024, 3, 4, 20, 0, 0, 0, time.UTC)
+	now := sleepTime.Add(10 * time.Minute)
+
+	getSleepInfo := func(check *kubegreenv1alpha1.PodTerminationCheck) *kubegreenv1alpha1.SleepInfo {
+		return &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: namespace},
+			Spec: kubegreenv1alpha1.SleepInfoSpec{
+				SleepPolicy: &kubegreenv1alpha1.SleepPolicy{TerminationCheck: check},
+			},
+			Status: kubegreenv1alpha1.SleepInfoStatus{
+				OperationType:    sleepOperation,
+				LastScheduleTime: metav1.NewTime(sleepTime),
+			},
+		}
+	}
+	getPod := func(name string, deletedAt *time.Time) *v1.Pod {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		}
+		if deletedAt != nil {
+			deletionTimestamp := metav1.NewTime(*deletedAt)
+			pod.DeletionTimestamp = &deletionTimestamp
+			// the fake client requires a finalizer on the deleted objects.
+			pod.Finalizers = []string{"example.com/volume"}
+		}
+		return pod
+	}
+	getReconciler := func(objects ...client.Object) (SleepInfoReconciler, *record.FakeRecorder) {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+		recorder := record.NewFakeRecorder(10)
+		return SleepInfoReconciler{
+			Client:    c,
+			PodReader: c,
+			Recorder:  recorder,
+		}, recorder
+	}
+	isPodFound := func(t *testing.T, r SleepInfoReconciler, name string) bool {
+		t.Helper()
+		err := r.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, &v1.Pod{})
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		require.NoError(t, err)
+		return true
+	}
+	stuckAt := sleepTime.Add(time.Minute)
+	terminatingUntil := now.Add(3 * time.Minute)
+
+	t.Run("not checked before the timeout", func(t *testing.T) {
+		r, _ := getReconciler()
+		sleepInfo := getSleepInfo(&kubegreenv1alpha1.PodTerminationCheck{})
+		checkAfter, isPending := r.checkPodTermination(context.Background(), logr.Discard(), sleepInfo, sleepTime.Add(time.Minute))
+		require.True(t, isPending)
+		require.Equal(t, 4*time.Minute, checkAfter)
+	})
+
+	t.Run("stuck pods reported", func(t *testing.T) {
+		r, recorder := getReconciler(
+			getPod("stuck-2", &stuckAt),
+			getPod("stuck-1", &stuckAt),
+			getPod("terminating", &terminatingUntil),
+			getPod("running", nil),
+		)
+		sleepInfo := getSleepInfo(&kubegreenv1alpha1.PodTerminationCheck{})
+
+		checkAfter, isPending := r.checkPodTermination(context.Background(), logr.Discard(), sleepInfo, now)
+		require.True(t, isPending)
+		require.Equal(t, 3*time.Minute, checkAfter)
+		require.Equal(t, "Warning PodsStuckTerminating 2 pods stuck terminating after the sleep: stuck-1, stuck-2", <-recorder.Events)
+		require.Empty(t, recorder.Events)
+		require.True(t, isPodFound(t, r, "stuck-1"))
+		require.True(t, isPodFound(t, r, "stuck-2"))
+	})
+
+	t.Run("stuck pods force deleted", func(t *testing.T) {
+		r, recorder := getReconciler(
+			getPod("stuck", &stuckAt),
+			getPod("terminating", &terminatingUntil),
+			getPod("running", nil),
+		)
+		sleepInfo := getSleepInfo(&kubegreenv1alpha1.PodTerminationCheck{ForceDelete: true})
+
+		_, isPending := r.checkPodTermination(context.Background(), logr.Discard(), sleepInfo, now)
+		require.True(t, isPending)
+		require.Equal(t, "Warning PodsStuckTerminating 1 pods stuck terminating after the sleep: stuck", <-recorder.Events)
+		require.Equal(t, "Normal PodsForceDeleted 1 pods force deleted: stuck", <-recorder.Events)
+		require.False(t, isPodFound(t, r, "stuck"))
+		require.True(t, isPodFound(t, r, "terminating"))
+		require.True(t, isPodFound(t, r, "running"))
+	})
+
+	t.Run("all the pods terminated", func(t *testing.T) {
+		r, recorder := getReconciler(getPod("running", nil))
+		sleepInfo := getSleepInfo(&kubegreenv1alpha1.PodTerminationCheck{ForceDelete: true})
+
+		_, isPending := r.checkPodTermination(context.Background(), logr.Discard(), sleepInfo, now)
+		require.False(t, isPending)
+		require.Empty(t, recorder.Events)
+	})
+
+	t.Run("not checked while awake", func(t *testing.T) {
+		r, recorder := getReconciler(getPod("stuck", &stuckAt))
+		sleepInfo := getSleepInfo(&kubegreenv1alpha1.PodTerminationCheck{})
+		sleepInfo.Status.OperationType = wakeUpOperation
+
+		_, isPending := r.checkPodTermination(context.Background(), logr.Discard(), sleepInfo, now)
+		require.False(t, isPending)
+		require.Empty(t, recorder.Events)
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		r, recorder := getReconciler(getPod("stuck", &stuckAt))
+
+		_, isPending := r.checkPodTermination(context.Background(), logr.Discard(), getSleepInfo(nil), now)
+		require.False(t, isPending)
+		require.Empty(t, recorder.Events)
+	})
+}
@@ -0,0 +1,54 @@
+package sleepinfo
+
+import (
+	"fmt"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/pkg/schedule"
+)
+
+// nextWakeUpLookAhead is how far the next wake up is searched. The schedules
+// repeat every week, so a week is enough.
+const nextWakeUpLookAhead = 7 * 24 * time.Hour
+
+// PreviewOperation is an operation of a SleepInfo computed by PreviewOperations.
+type PreviewOperation = schedule.Operation
+
+// PreviewOperations simulates the operations performed on the SleepInfo
+// between from and until, with its time zone applied. It lets users verify a
+// configuration before applying it.
+func PreviewOperations(sleepInfo *kubegreenv1alpha1.SleepInfo, from, until time.Time) ([]PreviewOperation, error) {
+	location := time.UTC
+	if sleepInfo.Spec.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(sleepInfo.Spec.TimeZone); err != nil {
+			return nil, fmt.Errorf("invalid time zone: %s", err)
+		}
+	}
+
+	sleepSchedule, err := sleepInfo.GetSleepSchedule()
+	if err != nil {
+		return nil, err
+	}
+	wakeUpSchedule, err := sleepInfo.GetWakeUpSchedule()
+	if err != nil {
+		return nil, err
+	}
+	return schedule.Preview(sleepSchedule, wakeUpSchedule, location, from, until)
+}
+
+// GetNextWakeUp returns the next scheduled wake up of the SleepInfo after
+// now, or the zero time if it never wakes up.
+func GetNextWakeUp(sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time) (time.Time, error) {
+	operations, err := PreviewOperations(sleepInfo, now, now.Add(nextWakeUpLookAhead))
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, operation := range operations {
+		if operation.Type == wakeUpOperation {
+			return operation.Time, nil
+		}
+	}
+	return time.Time{}, nil
+}
@@ -0,0 +1,142 @@
+package sleepinfo
+
+import (
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewOperations(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	require.NoError(t, err)
+	// Tuesday
+	from := time.Date(2021, 3, 23, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		spec          kubegreenv1alpha1.SleepInfoSpec
+		until         time.Time
+		expected      []PreviewOperation
+		expectedError string
+	}{
+		{
+			name: "sleep and wake up with time zone",
+			spec: kubegreenv1alpha1.SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				TimeZone:   "Europe/Rome",
+			},
+			until: from.Add(48 * time.Hour),
+			expected: []PreviewOperation{
+				{Type: sleepOperation, Time: time.Date(2021, 3, 23, 20, 0, 0, 0, rome)},
+				{Type: wakeUpOperation, Time: time.Date(2021, 3, 24, 8, 0, 0, 0, rome)},
+				{Type: sleepOperation, Time: time.Date(2021, 3, 24, 20, 0, 0, 0, rome)},
+				{Type: wakeUpOperation, Time: time.Date(2021, 3, 25, 8, 0, 0, 0, rome)},
+			},
+		},
+		{
+			name: "starts while sleeping",
+			spec: kubegreenv1alpha1.SleepInfoSpec{
+				Weekdays:   "1-5",
+				SleepTime:  "10:00",
+				WakeUpTime: "14:00",
+			},
+			until: from.Add(24 * time.Hour),
+			expected: []PreviewOperation{
+				{Type: wakeUpOperation, Time: time.Date(2021, 3, 23, 14, 0, 0, 0, time.UTC)},
+				{Type: sleepOperation, Time: time.Date(2021, 3, 24, 10, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name: "sleep over the week",
+			spec: kubegreenv1alpha1.SleepInfoSpec{
+				Weekdays:   "5",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+			},
+			until: from.Add(7 * 24 * time.Hour),
+			expected: []PreviewOperation{
+				{Type: wakeUpOperation, Time: time.Date(2021, 3, 26, 8, 0, 0, 0, time.UTC)},
+				{Type: sleepOperation, Time: time.Date(2021, 3, 26, 20, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name: "sleep without wake up",
+			spec: kubegreenv1alpha1.SleepInfoSpec{
+				Weekdays:  "*",
+				SleepTime: "20:00",
+			},
+			until: from.Add(48 * time.Hour),
+			expected: []PreviewOperation{
+				{Type: sleepOperation, Time: time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)},
+				{Type: sleepOperation, Time: time.Date(2021, 3, 24, 20, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name: "invalid time zone",
+			spec: kubegreenv1alpha1.SleepInfoSpec{
+				Weekdays:  "*",
+				SleepTime: "20:00",
+				TimeZone:  "Europe/Unknown",
+			},
+			until:         from.Add(48 * time.Hour),
+			expectedError: "invalid time zone: unknown time zone Europe/Unknown",
+		},
+		{
+			name: "invalid weekdays",
+			spec: kubegreenv1alpha1.SleepInfoSpec{
+				SleepTime: "20:00",
+			},
+			until:         from.Add(48 * time.Hour),
+			expectedError: "empty weekdays from SleepInfo configuration",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			operations, err := PreviewOperations(&kubegreenv1alpha1.SleepInfo{Spec: test.spec}, from, test.until)
+			if test.expectedError != "" {
+				require.EqualError(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, operations, len(test.expected))
+			for i, expected := range test.expected {
+				require.Equal(t, expected.Type, operations[i].Type)
+				require.True(t, expected.Time.Equal(operations[i].Time), "expected %s, got %s", expected.Time, operations[i].Time)
+			}
+		})
+	}
+}
+
+func TestGetNextWakeUp(t *testing.T) {
+	// Tuesday
+	now := time.Date(2021, 3, 23, 21, 0, 0, 0, time.UTC)
+
+	t.Run("next wake up", func(t *testing.T) {
+		nextWakeUp, err := GetNextWakeUp(&kubegreenv1alpha1.SleepInfo{
+			Spec: kubegreenv1alpha1.SleepInfoSpec{Weekdays: "1-5", SleepTime: "20:00", WakeUpTime: "08:00"},
+		}, now)
+		require.NoError(t, err)
+		require.True(t, time.Date(2021, 3, 24, 8, 0, 0, 0, time.UTC).Equal(nextWakeUp), nextWakeUp)
+	})
+
+	t.Run("without wake up", func(t *testing.T) {
+		nextWakeUp, err := GetNextWakeUp(&kubegreenv1alpha1.SleepInfo{
+			Spec: kubegreenv1alpha1.SleepInfoSpec{Weekdays: "1-5", SleepTime: "20:00"},
+		}, now)
+		require.NoError(t, err)
+		require.True(t, nextWakeUp.IsZero())
+	})
+
+	t.Run("invalid schedule", func(t *testing.T) {
+		_, err := GetNextWakeUp(&kubegreenv1alpha1.SleepInfo{
+			Spec: kubegreenv1alpha1.SleepInfoSpec{SleepTime: "20:00"},
+		}, now)
+		require.EqualError(t, err, "empty weekdays from SleepInfo configuration")
+	})
+}
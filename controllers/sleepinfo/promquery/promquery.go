@@ -0,0 +1,100 @@
+package promquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Querier evaluates PromQL expressions as conditions.
+type Querier interface {
+	// IsTrue evaluates the query at the given time. The query is true if it
+	// returns at least one sample, or a scalar different from 0.
+	IsTrue(ctx context.Context, query string, t time.Time) (bool, error)
+}
+
+type queryResponse struct {
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+	Data   queryData `json:"data"`
+}
+
+type queryData struct {
+	ResultType string          `json:"resultType"`
+	Result     json.RawMessage `json:"result"`
+}
+
+type querier struct {
+	client *http.Client
+	url    string
+}
+
+// NewQuerier returns a Querier which uses the instant query API of the
+// Prometheus at the url. If httpClient is nil, http.DefaultClient is used.
+func NewQuerier(httpClient *http.Client, prometheusURL string) Querier {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &querier{
+		client: httpClient,
+		url:    strings.TrimSuffix(prometheusURL, "/"),
+	}
+}
+
+func (q *querier) IsTrue(ctx context.Context, query string, t time.Time) (bool, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("time", t.UTC().Format(time.RFC3339))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, q.url+"/api/v1/query?"+params.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+	res, err := q.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	response := queryResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return false, fmt.Errorf("invalid prometheus response with status %d: %s", res.StatusCode, err)
+	}
+	if response.Status != "success" {
+		return false, fmt.Errorf("prometheus query failed: %s", response.Error)
+	}
+	return isTrue(response.Data)
+}
+
+func isTrue(data queryData) (bool, error) {
+	switch data.ResultType {
+	case "vector", "matrix":
+		samples := []json.RawMessage{}
+		if err := json.Unmarshal(data.Result, &samples); err != nil {
+			return false, fmt.Errorf("invalid %s result: %s", data.ResultType, err)
+		}
+		return len(samples) > 0, nil
+	case "scalar":
+		// a scalar is encoded as [<timestamp>, "<value>"]
+		scalar := []interface{}{}
+		if err := json.Unmarshal(data.Result, &scalar); err != nil || len(scalar) != 2 {
+			return false, fmt.Errorf("invalid scalar result")
+		}
+		value, ok := scalar[1].(string)
+		if !ok {
+			return false, fmt.Errorf("invalid scalar result")
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid scalar result: %s", err)
+		}
+		return f != 0, nil
+	default:
+		return false, fmt.Errorf("result type %s not supported", data.ResultType)
+	}
+}
@@ -0,0 +1,88 @@
+package promquery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuerier(t *testing.T) {
+	now := time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		status        int
+		response      string
+		expected      bool
+		expectedError string
+	}{
+		{
+			name:     "vector with samples",
+			status:   http.StatusOK,
+			response: `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1616529600,"0"]}]}}`,
+			expected: true,
+		},
+		{
+			name:     "empty vector",
+			status:   http.StatusOK,
+			response: `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+			expected: false,
+		},
+		{
+			name:     "scalar different from 0",
+			status:   http.StatusOK,
+			response: `{"status":"success","data":{"resultType":"scalar","result":[1616529600,"1"]}}`,
+			expected: true,
+		},
+		{
+			name:     "scalar 0",
+			status:   http.StatusOK,
+			response: `{"status":"success","data":{"resultType":"scalar","result":[1616529600,"0"]}}`,
+			expected: false,
+		},
+		{
+			name:          "string result",
+			status:        http.StatusOK,
+			response:      `{"status":"success","data":{"resultType":"string","result":[1616529600,"foo"]}}`,
+			expectedError: "result type string not supported",
+		},
+		{
+			name:          "invalid query",
+			status:        http.StatusBadRequest,
+			response:      `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+			expectedError: "prometheus query failed: parse error",
+		},
+		{
+			name:          "invalid response",
+			status:        http.StatusBadGateway,
+			response:      `bad gateway`,
+			expectedError: "invalid prometheus response with status 502: invalid character 'b' looking for beginning of value",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/api/v1/query", r.URL.Path)
+				require.Equal(t, "up == 0", r.URL.Query().Get("query"))
+				require.Equal(t, "2021-03-23T20:00:00Z", r.URL.Query().Get("time"))
+				w.WriteHeader(test.status)
+				_, _ = w.Write([]byte(test.response))
+			}))
+			defer server.Close()
+
+			q := NewQuerier(server.Client(), server.URL+"/")
+			isTrue, err := q.IsTrue(context.Background(), "up == 0", now)
+			if test.expectedError != "" {
+				require.EqualError(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, isTrue)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package sleepinfo
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// RateLimiterOptions configures the rate limiter of the SleepInfo work queue.
+type RateLimiterOptions struct {
+	// BaseDelay is the first delay applied to a failing request, doubled at each failure.
+	BaseDelay time.Duration
+	// MaxDelay is the upper bound of the per-request exponential backoff.
+	MaxDelay time.Duration
+	// QPS and Burst configure the overall token bucket shared by all the requests.
+	QPS   float64
+	Burst int
+	// NamespaceQPS and NamespaceBurst configure the token bucket of each
+	// namespace, shared by the requests of its SleepInfo, so that a namespace
+	// with many SleepInfo does not use all the overall rate. If NamespaceQPS
+	// is 0, the namespaces are not limited.
+	NamespaceQPS   float64
+	NamespaceBurst int
+}
+
+// NewRateLimiter returns a rate limiter which, as the controller-runtime default one,
+// is the max of a per-item exponential backoff and an overall token bucket, and
+// of the token bucket of the namespace of the item if NamespaceQPS is set.
+func NewRateLimiter(opts RateLimiterOptions) ratelimiter.RateLimiter {
+	limiters := []workqueue.RateLimiter{
+		workqueue.NewItemExponentialFailureRateLimiter(opts.BaseDelay, opts.MaxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(opts.QPS), opts.Burst)},
+	}
+	if opts.NamespaceQPS > 0 {
+		limiters = append(limiters, &namespaceRateLimiter{
+			qps:      opts.NamespaceQPS,
+			burst:    opts.NamespaceBurst,
+			limiters: map[string]*rate.Limiter{},
+		})
+	}
+	return workqueue.NewMaxOfRateLimiter(limiters...)
+}
+
+// namespaceRateLimiter is a token bucket for each namespace of the requests.
+// As the overall one, it has no backoff to forget.
+type namespaceRateLimiter struct {
+	qps   float64
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func (l *namespaceRateLimiter) When(item interface{}) time.Duration {
+	namespace := ""
+	if req, ok := item.(reconcile.Request); ok {
+		namespace = req.Namespace
+	}
+	l.mu.Lock()
+	limiter, ok := l.limiters[namespace]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(l.qps), l.burst)
+		l.limiters[namespace] = limiter
+	}
+	l.mu.Unlock()
+	return limiter.Reserve().Delay()
+}
+
+func (l *namespaceRateLimiter) NumRequeues(interface{}) int {
+	return 0
+}
+
+func (l *namespaceRateLimiter) Forget(interface{}) {}
@@ -0,0 +1,61 @@
+package sleepinfo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(RateLimiterOptions{
+		BaseDelay: 10 * time.Millisecond,
+		MaxDelay:  30 * time.Millisecond,
+		QPS:       1000,
+		Burst:     1000,
+	})
+
+	t.Run("exponential backoff per item", func(t *testing.T) {
+		require.Equal(t, 10*time.Millisecond, limiter.When("item"))
+		require.Equal(t, 20*time.Millisecond, limiter.When("item"))
+		require.Equal(t, 30*time.Millisecond, limiter.When("item"))
+		require.Equal(t, 30*time.Millisecond, limiter.When("item"))
+		require.Equal(t, 4, limiter.NumRequeues("item"))
+	})
+
+	t.Run("other items are not affected", func(t *testing.T) {
+		require.Equal(t, 10*time.Millisecond, limiter.When("other-item"))
+	})
+
+	t.Run("forget resets the backoff", func(t *testing.T) {
+		limiter.Forget("item")
+		require.Equal(t, 0, limiter.NumRequeues("item"))
+		require.Equal(t, 10*time.Millisecond, limiter.When("item"))
+	})
+}
+
+func TestNewRateLimiterByNamespace(t *testing.T) {
+	limiter := NewRateLimiter(RateLimiterOptions{
+		BaseDelay:      time.Millisecond,
+		MaxDelay:       time.Millisecond,
+		QPS:            1000,
+		Burst:          1000,
+		NamespaceQPS:   1,
+		NamespaceBurst: 2,
+	})
+	getRequest := func(namespace, name string) reconcile.Request {
+		return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+	}
+
+	t.Run("burst of the namespace", func(t *testing.T) {
+		require.Equal(t, time.Millisecond, limiter.When(getRequest("crowded", "first")))
+		require.Equal(t, time.Millisecond, limiter.When(getRequest("crowded", "second")))
+		require.Greater(t, limiter.When(getRequest("crowded", "third")), 900*time.Millisecond)
+	})
+
+	t.Run("other namespaces are not affected", func(t *testing.T) {
+		require.Equal(t, time.Millisecond, limiter.When(getRequest("other", "first")))
+	})
+}
@@ -0,0 +1,81 @@
+package sleepinfo
+
+import (
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/pkg/clock"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// ForRemoteCluster returns a copy of the reconciler which reconciles the
+// SleepInfo of a remote cluster with its client. The integrations bound to the
+// local cluster, i.e. the pod reader, the health tracker, the alerts silencer,
+// the Prometheus querier and the impersonator, are disabled. The remote
+// cluster is watched as a whole, so its Namespaces are read.
+func (r *SleepInfoReconciler) ForRemoteCluster(name string, remoteClient client.Client, recorder record.EventRecorder) *SleepInfoReconciler {
+	remote := *r
+	remote.Client = remoteClient
+	remote.Log = r.Log.WithValues("cluster", name)
+	remote.Recorder = recorder
+	remote.PodReader = nil
+	remote.HealthTracker = nil
+	remote.Silencer = nil
+	remote.PrometheusQuerier = nil
+	remote.Impersonator = nil
+	remote.WatchNamespaces = nil
+	if r.ThrottleBackoff != nil {
+		remote.ThrottleBackoff = NewThrottleBackoff(r.ThrottleBackoff.baseDelay, r.ThrottleBackoff.maxDelay)
+	}
+	if r.OperationLock != nil {
+		remote.OperationLock = NewOperationLock()
+	}
+	return &remote
+}
+
+// SetupWithRemoteCluster sets up the controller of the SleepInfo of a remote
+// cluster, which watches the SleepInfo, the CronJobs and the Namespaces in the
+// cache of the remote cluster.
+func (r *SleepInfoReconciler) SetupWithRemoteCluster(mgr ctrl.Manager, name string, remoteCache cache.Cache) error {
+	if r.Clock == nil {
+		r.Clock = clock.RealClock{}
+	}
+
+	maxConcurrentReconciles := r.MaxConcurrentReconciles
+	if maxConcurrentReconciles <= 0 {
+		maxConcurrentReconciles = defaultMaxConcurrentReconciles
+	}
+
+	pred := predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("sleepinfo-"+name).
+		Watches(
+			source.NewKindWithCache(&kubegreenv1alpha1.SleepInfo{}, remoteCache),
+			&handler.EnqueueRequestForObject{},
+		).
+		Watches(
+			source.NewKindWithCache(&batchv1.CronJob{}, remoteCache),
+			handler.EnqueueRequestsFromMapFunc(r.getSleepInfosToEnforce),
+			builder.WithPredicates(cronJobResumedPredicate),
+		).
+		Watches(
+			source.NewKindWithCache(&v1.Namespace{}, remoteCache),
+			handler.EnqueueRequestsFromMapFunc(r.getSleepInfosOfNamespace),
+			builder.WithPredicates(namespaceForcedAwakePredicate),
+		).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: maxConcurrentReconciles,
+			RateLimiter:             r.RateLimiter,
+		}).
+		WithEventFilter(pred).
+		Complete(r)
+}
@@ -0,0 +1,47 @@
+package sleepinfo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kube-green/kube-green/controllers/sleepinfo/alertmanager"
+	"github.com/kube-green/kube-green/internal/health"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestForRemoteCluster(t *testing.T) {
+	localClient := fake.NewClientBuilder().Build()
+	remoteClient := fake.NewClientBuilder().Build()
+	recorder := record.NewFakeRecorder(1)
+	r := &SleepInfoReconciler{
+		Client:          localClient,
+		Log:             logr.Discard(),
+		SleepDelta:      60,
+		DefaultTimeZone: "Europe/Rome",
+		HealthTracker:   health.NewTracker(3, time.Minute),
+		PodReader:       localClient,
+		Silencer:        alertmanager.NewSilencer(nil, "http://alertmanager:9093", "namespace"),
+		ThrottleBackoff: NewThrottleBackoff(time.Second, time.Minute),
+		OperationLock:   NewOperationLock(),
+		Impersonator:    &Impersonator{ServiceAccountName: "kube-green"},
+	}
+
+	remote := r.ForRemoteCluster("dev-1", remoteClient, recorder)
+
+	require.Equal(t, remoteClient, remote.Client)
+	require.Equal(t, recorder, remote.Recorder)
+	require.Equal(t, int64(60), remote.SleepDelta)
+	require.Equal(t, "Europe/Rome", remote.DefaultTimeZone)
+	require.Nil(t, remote.HealthTracker)
+	require.Nil(t, remote.PodReader)
+	require.Nil(t, remote.Silencer)
+	require.Nil(t, remote.Impersonator)
+	require.NotSame(t, r.ThrottleBackoff, remote.ThrottleBackoff)
+	require.Equal(t, time.Second, remote.ThrottleBackoff.Next("my-namespace", 0))
+	require.NotSame(t, r.OperationLock, remote.OperationLock)
+	require.Equal(t, localClient, r.Client)
+}
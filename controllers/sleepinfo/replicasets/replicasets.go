@@ -0,0 +1,246 @@
+package replicasets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	ErrFetchingReplicaSets = errors.New("error fetching replicasets")
+
+	// ReplicaSetGVK is the kind of the ReplicaSets.
+	ReplicaSetGVK = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}
+	// ReplicationControllerGVK is the kind of the ReplicationControllers.
+	ReplicationControllerGVK = schema.GroupVersionKind{Group: "", Version: "v1", Kind: "ReplicationController"}
+)
+
+// replicasets scales the ReplicaSets or the ReplicationControllers, which
+// share the same spec.replicas field, not owned by other resources.
+type replicasets struct {
+	resource.ResourceClient
+	gvk              schema.GroupVersionKind
+	data             []unstructured.Unstructured
+	OriginalReplicas map[string]int32
+	areToSuspend     bool
+}
+
+// NewReplicaSetResource returns the resource handling the orphan ReplicaSets.
+func NewReplicaSetResource(ctx context.Context, res resource.ResourceClient, namespace string, originalReplicas map[string]int32) (resource.Resource, error) {
+	return newResource(ctx, res, ReplicaSetGVK, namespace, originalReplicas)
+}
+
+// NewReplicationControllerResource returns the resource handling the orphan ReplicationControllers.
+func NewReplicationControllerResource(ctx context.Context, res resource.ResourceClient, namespace string, originalReplicas map[string]int32) (resource.Resource, error) {
+	return newResource(ctx, res, ReplicationControllerGVK, namespace, originalReplicas)
+}
+
+func newResource(ctx context.Context, res resource.ResourceClient, gvk schema.GroupVersionKind, namespace string, originalReplicas map[string]int32) (resource.Resource, error) {
+	r := replicasets{
+		ResourceClient:   res,
+		gvk:              gvk,
+		OriginalReplicas: originalReplicas,
+		areToSuspend:     res.SleepInfo.IsReplicaSetsToSuspend() || (res.IsWakeUp && len(originalReplicas) > 0),
+		data:             []unstructured.Unstructured{},
+	}
+	if !r.areToSuspend {
+		return r, nil
+	}
+	if err := r.fetch(ctx, namespace); err != nil {
+		return replicasets{}, fmt.Errorf("%w: %s", ErrFetchingReplicaSets, err)
+	}
+
+	return r, nil
+}
+
+func (r replicasets) HasResource() bool {
+	return len(r.data) > 0
+}
+
+func (r replicasets) GetResourceNames() []string {
+	names := []string{}
+	for _, replicaSet := range r.data {
+		names = append(names, replicaSet.GetName())
+	}
+	return names
+}
+
+func getReplicas(replicaSet unstructured.Unstructured) (int32, error) {
+	replicas, found, err := unstructured.NestedInt64(replicaSet.Object, "spec", "replicas")
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		// replicas default to 1 if not set.
+		return 1, nil
+	}
+	return int32(replicas), nil
+}
+
+func (r replicasets) Sleep(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "replicasets.sleep", trace.WithAttributes(
+		attribute.String("resources.kind", r.gvk.Kind),
+		attribute.Int("resources.count", len(r.data)),
+	))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	for _, replicaSet := range r.data {
+		replicaSet := replicaSet
+
+		replicas, err := getReplicas(replicaSet)
+		if err != nil {
+			return err
+		}
+		if replicas == 0 || r.IsKeptAwake(&replicaSet) {
+			continue
+		}
+		newReplicaSet := replicaSet.DeepCopy()
+		if err := unstructured.SetNestedField(newReplicaSet.Object, int64(0), "spec", "replicas"); err != nil {
+			return err
+		}
+
+		if err := r.Patch(ctx, &replicaSet, newReplicaSet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r replicasets) WakeUp(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "replicasets.wakeUp", trace.WithAttributes(
+		attribute.String("resources.kind", r.gvk.Kind),
+		attribute.Int("resources.count", len(r.data)),
+	))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	for _, replicaSet := range r.data {
+		replicaSet := replicaSet
+
+		rsLogger := r.Log.WithValues("kind", r.gvk.Kind, "name", replicaSet.GetName(), "namespace", replicaSet.GetNamespace())
+		replicas, err := getReplicas(replicaSet)
+		if err != nil {
+			rsLogger.Info("fails to read replicas")
+			return err
+		}
+		if replicas != 0 {
+			rsLogger.Info("replicas not 0 during wake up")
+			continue
+		}
+
+		originalReplicas, ok := r.OriginalReplicas[replicaSet.GetName()]
+		if !ok {
+			rsLogger.Info("original replicas info not correctly set")
+			continue
+		}
+
+		newReplicaSet := replicaSet.DeepCopy()
+		if err := unstructured.SetNestedField(newReplicaSet.Object, int64(originalReplicas), "spec", "replicas"); err != nil {
+			return err
+		}
+
+		if err := r.Patch(ctx, &replicaSet, newReplicaSet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type OriginalReplicas struct {
+	Name     string `json:"name"`
+	Replicas int32  `json:"replicas"`
+}
+
+func (r replicasets) GetOriginalInfoToSave() ([]byte, error) {
+	if !r.areToSuspend {
+		return nil, nil
+	}
+	originalReplicaSetsReplicas := []OriginalReplicas{}
+	for _, replicaSet := range r.data {
+		originalReplicas, err := getReplicas(replicaSet)
+		if err != nil {
+			return nil, err
+		}
+		if replicas, ok := r.OriginalReplicas[replicaSet.GetName()]; ok && replicas != 0 {
+			originalReplicas = replicas
+		}
+		if originalReplicas == 0 {
+			continue
+		}
+		originalReplicaSetsReplicas = append(originalReplicaSetsReplicas, OriginalReplicas{
+			Name:     replicaSet.GetName(),
+			Replicas: originalReplicas,
+		})
+	}
+	return json.Marshal(originalReplicaSetsReplicas)
+}
+
+func (r *replicasets) fetch(ctx context.Context, namespace string) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "replicasets.list", trace.WithAttributes(attribute.String("resources.kind", r.gvk.Kind)))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	list, err := r.getListByNamespace(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	r.Log.V(1).WithValues("kind", r.gvk.Kind, "number of resources", len(list), "namespace", namespace).Info("replicasets in namespace")
+	r.data = r.filterReplicaSets(list)
+	return nil
+}
+
+func (r replicasets) getListByNamespace(ctx context.Context, namespace string) ([]unstructured.Unstructured, error) {
+	listOptions := &client.ListOptions{
+		Namespace: namespace,
+		Limit:     500,
+	}
+	list := unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(r.gvk.GroupVersion().WithKind(r.gvk.Kind + "List"))
+	if err := r.Client.List(ctx, &list, listOptions); err != nil {
+		return list.Items, client.IgnoreNotFound(err)
+	}
+	return list.Items, nil
+}
+
+// filterReplicaSets returns the resources not owned by other resources (e.g.
+// the ReplicaSets created by a Deployment) and not excluded by the SleepInfo.
+func (r replicasets) filterReplicaSets(list []unstructured.Unstructured) []unstructured.Unstructured {
+	filteredList := []unstructured.Unstructured{}
+	for _, replicaSet := range list {
+		replicaSet := replicaSet
+		if metav1.GetControllerOf(&replicaSet) != nil {
+			continue
+		}
+		if !r.IsWakeUp && (resource.IsExcluded(r.gvk.Kind, &replicaSet, r.SleepInfo.GetExcludeRef()) || !r.SleepInfo.IsManagedByClass(replicaSet.GetLabels())) {
+			continue
+		}
+		filteredList = append(filteredList, replicaSet)
+	}
+	return filteredList
+}
+
+func GetOriginalInfoToRestore(data []byte) (map[string]int32, error) {
+	if data == nil {
+		return map[string]int32{}, nil
+	}
+	originalReplicaSetsReplicas := []OriginalReplicas{}
+	if err := json.Unmarshal(data, &originalReplicaSetsReplicas); err != nil {
+		return nil, err
+	}
+	originalReplicaSetsReplicasData := map[string]int32{}
+	for _, replicaInfo := range originalReplicaSetsReplicas {
+		if replicaInfo.Name != "" {
+			originalReplicaSetsReplicasData[replicaInfo.Name] = replicaInfo.Replicas
+		}
+	}
+	return originalReplicaSetsReplicasData, nil
+}
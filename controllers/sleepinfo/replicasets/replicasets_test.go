@@ -0,0 +1,372 @@
+package replicasets
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/internal/testutil"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestReplicaSets(t *testing.T) {
+	testLogger := zap.New(zap.UseDevMode(true))
+
+	namespace := "my-namespace"
+	var replicas0 int32 = 0
+	var replicas1 int32 = 1
+	var replicas3 int32 = 3
+	isController := true
+	replicaSet1 := GetMock(MockSpec{
+		Name:      "rs1",
+		Namespace: namespace,
+		Replicas:  &replicas1,
+	})
+	replicaSet2 := GetMock(MockSpec{
+		Name:      "rs2",
+		Namespace: namespace,
+		Replicas:  &replicas3,
+	})
+	replicaSetWithZeroReplicas := GetMock(MockSpec{
+		Name:      "rs-zero-replicas",
+		Namespace: namespace,
+		Replicas:  &replicas0,
+	})
+	replicaSetWithLabels := GetMock(MockSpec{
+		Name:      "rs-with-labels",
+		Namespace: namespace,
+		Replicas:  &replicas1,
+		Labels: map[string]string{
+			"app": "foo",
+		},
+	})
+	replicaSetOtherNamespace := GetMock(MockSpec{
+		Name:      "rs-other-namespace",
+		Namespace: "other-namespace",
+		Replicas:  &replicas1,
+	})
+	replicaSetOwnedByDeployment := GetMock(MockSpec{
+		Name:      "rs-owned-by-deployment",
+		Namespace: namespace,
+		Replicas:  &replicas1,
+		OwnerReferences: []metav1.OwnerReference{
+			{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       "deployment",
+				UID:        "deployment-uid",
+				Controller: &isController,
+			},
+		},
+	})
+	replicationController := GetMock(MockSpec{
+		Name:      "rc1",
+		Namespace: namespace,
+		Replicas:  &replicas3,
+		Kind:      ReplicationControllerGVK.Kind,
+	})
+	sleepInfo := &v1alpha1.SleepInfo{
+		Spec: v1alpha1.SleepInfoSpec{
+			SuspendReplicaSets: true,
+		},
+	}
+
+	getNewResource := func(t *testing.T, client client.Client, originalReplicas map[string]int32) replicasets {
+		t.Helper()
+
+		res, err := NewReplicaSetResource(context.Background(), resource.ResourceClient{
+			Client:    client,
+			Log:       testLogger,
+			SleepInfo: sleepInfo,
+		}, namespace, originalReplicas)
+		require.NoError(t, err)
+
+		r, ok := res.(replicasets)
+		require.True(t, ok)
+		return r
+	}
+
+	t.Run("NewResource", func(t *testing.T) {
+		listTests := []struct {
+			name          string
+			client        client.Client
+			newResource   func(context.Context, resource.ResourceClient, string, map[string]int32) (resource.Resource, error)
+			expectedNames []string
+			sleepInfo     *v1alpha1.SleepInfo
+			throws        bool
+		}{
+			{
+				name: "get list of replicasets",
+				client: fake.NewClientBuilder().
+					WithRuntimeObjects(&replicaSet1, &replicaSet2, &replicaSetOtherNamespace, &replicationController).
+					Build(),
+				newResource:   NewReplicaSetResource,
+				expectedNames: []string{"rs1", "rs2"},
+				sleepInfo:     sleepInfo,
+			},
+			{
+				name: "get list of replication controllers",
+				client: fake.NewClientBuilder().
+					WithRuntimeObjects(&replicaSet1, &replicationController).
+					Build(),
+				newResource:   NewReplicationControllerResource,
+				expectedNames: []string{"rc1"},
+				sleepInfo:     sleepInfo,
+			},
+			{
+				name: "fails to list replicasets",
+				client: &testutil.PossiblyErroringFakeCtrlRuntimeClient{
+					Client: fake.NewClientBuilder().Build(),
+					ShouldError: func(method testutil.Method, obj runtime.Object) bool {
+						return method == testutil.List
+					},
+				},
+				newResource:   NewReplicaSetResource,
+				expectedNames: []string{},
+				sleepInfo:     sleepInfo,
+				throws:        true,
+			},
+			{
+				name: "skip replicasets owned by other resources",
+				client: fake.NewClientBuilder().
+					WithRuntimeObjects(&replicaSet1, &replicaSetOwnedByDeployment).
+					Build(),
+				newResource:   NewReplicaSetResource,
+				expectedNames: []string{"rs1"},
+				sleepInfo:     sleepInfo,
+			},
+			{
+				name: "exclude replicasets by name and labels",
+				client: fake.NewClientBuilder().
+					WithRuntimeObjects(&replicaSet1, &replicaSet2, &replicaSetWithLabels).
+					Build(),
+				newResource:   NewReplicaSetResource,
+				expectedNames: []string{"rs1"},
+				sleepInfo: &v1alpha1.SleepInfo{
+					Spec: v1alpha1.SleepInfoSpec{
+						SuspendReplicaSets: true,
+						ExcludeRef: []v1alpha1.ExcludeRef{
+							{
+								APIVersion: "apps/v1",
+								Kind:       "ReplicaSet",
+								Name:       "rs2",
+							},
+							{
+								MatchLabels: map[string]string{
+									"app": "foo",
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				name: "disabled replicasets suspend",
+				client: fake.NewClientBuilder().
+					WithRuntimeObjects(&replicaSet1).
+					Build(),
+				newResource:   NewReplicaSetResource,
+				expectedNames: []string{},
+				sleepInfo:     &v1alpha1.SleepInfo{},
+			},
+		}
+
+		for _, test := range listTests {
+			t.Run(test.name, func(t *testing.T) {
+				r := resource.ResourceClient{
+					Client:    test.client,
+					Log:       testLogger,
+					SleepInfo: test.sleepInfo,
+				}
+
+				res, err := test.newResource(context.Background(), r, namespace, map[string]int32{})
+				if test.throws {
+					require.EqualError(t, err, fmt.Sprintf("%s: error during list", ErrFetchingReplicaSets))
+				} else {
+					require.NoError(t, err)
+				}
+				require.Equal(t, test.expectedNames, res.GetResourceNames())
+			})
+		}
+	})
+
+	t.Run("HasResources", func(t *testing.T) {
+		t.Run("without resource", func(t *testing.T) {
+			r := getNewResource(t, fake.NewClientBuilder().Build(), nil)
+			require.False(t, r.HasResource())
+			require.Empty(t, r.GetResourceNames())
+		})
+
+		t.Run("with resource", func(t *testing.T) {
+			r := getNewResource(t, fake.NewClientBuilder().WithRuntimeObjects(&replicaSet1).Build(), nil)
+			require.True(t, r.HasResource())
+			require.Equal(t, []string{"rs1"}, r.GetResourceNames())
+		})
+	})
+
+	t.Run("Sleep", func(t *testing.T) {
+		t.Run("not throws if no data", func(t *testing.T) {
+			r := getNewResource(t, fake.NewClientBuilder().Build(), nil)
+			require.NoError(t, r.Sleep(context.Background()))
+		})
+
+		t.Run("scale replicasets to zero", func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithRuntimeObjects(&replicaSet1, &replicaSet2, &replicaSetWithZeroReplicas).
+				Build()
+			r := getNewResource(t, fakeClient, nil)
+			require.NoError(t, r.Sleep(context.Background()))
+
+			list, err := r.getListByNamespace(context.Background(), namespace)
+			require.NoError(t, err)
+			require.Equal(t, map[string]int32{
+				"rs1":              0,
+				"rs2":              0,
+				"rs-zero-replicas": 0,
+			}, getReplicasByName(t, list))
+		})
+
+		t.Run("scale replication controllers to zero", func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithRuntimeObjects(&replicationController).
+				Build()
+			res, err := NewReplicationControllerResource(context.Background(), resource.ResourceClient{
+				Client:    fakeClient,
+				Log:       testLogger,
+				SleepInfo: sleepInfo,
+			}, namespace, nil)
+			require.NoError(t, err)
+			require.NoError(t, res.Sleep(context.Background()))
+
+			list, err := res.(replicasets).getListByNamespace(context.Background(), namespace)
+			require.NoError(t, err)
+			require.Equal(t, map[string]int32{
+				"rc1": 0,
+			}, getReplicasByName(t, list))
+		})
+
+		t.Run("fails to scale replicasets", func(t *testing.T) {
+			fakeClient := testutil.PossiblyErroringFakeCtrlRuntimeClient{
+				Client: fake.NewClientBuilder().WithRuntimeObjects(&replicaSet1).Build(),
+				ShouldError: func(method testutil.Method, obj runtime.Object) bool {
+					return method == testutil.Patch
+				},
+			}
+			r := getNewResource(t, fakeClient, nil)
+			require.EqualError(t, r.Sleep(context.Background()), "fails to patch ReplicaSet.apps rs1: error during patch")
+		})
+	})
+
+	t.Run("WakeUp", func(t *testing.T) {
+		t.Run("not throws if no data", func(t *testing.T) {
+			r := getNewResource(t, fake.NewClientBuilder().Build(), nil)
+			require.NoError(t, r.WakeUp(context.Background()))
+		})
+
+		t.Run("restore original replicas", func(t *testing.T) {
+			sleepingReplicaSet1 := GetMock(MockSpec{
+				Name:      "rs1",
+				Namespace: namespace,
+				Replicas:  &replicas0,
+			})
+			fakeClient := fake.NewClientBuilder().
+				WithRuntimeObjects(&sleepingReplicaSet1, &replicaSet2, &replicaSetWithZeroReplicas).
+				Build()
+			r := getNewResource(t, fakeClient, map[string]int32{
+				"rs1": 5,
+				"rs2": 1,
+			})
+			require.NoError(t, r.WakeUp(context.Background()))
+
+			list, err := r.getListByNamespace(context.Background(), namespace)
+			require.NoError(t, err)
+			require.Equal(t, map[string]int32{
+				"rs1":              5,
+				"rs2":              3,
+				"rs-zero-replicas": 0,
+			}, getReplicasByName(t, list))
+		})
+
+		t.Run("fails to wake up", func(t *testing.T) {
+			sleepingReplicaSet1 := GetMock(MockSpec{
+				Name:      "rs1",
+				Namespace: namespace,
+				Replicas:  &replicas0,
+			})
+			fakeClient := testutil.PossiblyErroringFakeCtrlRuntimeClient{
+				Client: fake.NewClientBuilder().WithRuntimeObjects(&sleepingReplicaSet1).Build(),
+				ShouldError: func(method testutil.Method, obj runtime.Object) bool {
+					return method == testutil.Patch
+				},
+			}
+			r := getNewResource(t, fakeClient, map[string]int32{
+				"rs1": 5,
+			})
+			require.EqualError(t, r.WakeUp(context.Background()), "fails to patch ReplicaSet.apps rs1: error during patch")
+		})
+	})
+
+	t.Run("GetOriginalInfoToSave", func(t *testing.T) {
+		t.Run("returns nil if not to suspend", func(t *testing.T) {
+			r := getNewResource(t, fake.NewClientBuilder().Build(), nil)
+			r.areToSuspend = false
+			res, err := r.GetOriginalInfoToSave()
+			require.NoError(t, err)
+			require.Nil(t, res)
+		})
+
+		t.Run("with replicasets", func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithRuntimeObjects(&replicaSet1, &replicaSet2, &replicaSetWithZeroReplicas).
+				Build()
+			r := getNewResource(t, fakeClient, map[string]int32{
+				"rs1": 4,
+			})
+			res, err := r.GetOriginalInfoToSave()
+			require.NoError(t, err)
+			require.JSONEq(t, `[{"name":"rs1","replicas":4},{"name":"rs2","replicas":3}]`, string(res))
+		})
+	})
+
+	t.Run("GetOriginalInfoToRestore", func(t *testing.T) {
+		t.Run("restore info with data nil", func(t *testing.T) {
+			info, err := GetOriginalInfoToRestore(nil)
+			require.NoError(t, err)
+			require.Equal(t, map[string]int32{}, info)
+		})
+
+		t.Run("fails if saved data are not valid json", func(t *testing.T) {
+			_, err := GetOriginalInfoToRestore([]byte(`{}`))
+			require.EqualError(t, err, "json: cannot unmarshal object into Go value of type []replicasets.OriginalReplicas")
+		})
+
+		t.Run("restore saved info", func(t *testing.T) {
+			info, err := GetOriginalInfoToRestore([]byte(`[{"name":"rs1","replicas":4},{"name":"","replicas":1}]`))
+			require.NoError(t, err)
+			require.Equal(t, map[string]int32{
+				"rs1": 4,
+			}, info)
+		})
+	})
+}
+
+func getReplicasByName(t *testing.T, list []unstructured.Unstructured) map[string]int32 {
+	t.Helper()
+
+	replicasByName := map[string]int32{}
+	for _, item := range list {
+		replicas, err := getReplicas(item)
+		require.NoError(t, err)
+		replicasByName[item.GetName()] = replicas
+	}
+	return replicasByName
+}
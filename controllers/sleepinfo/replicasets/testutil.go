@@ -0,0 +1,87 @@
+package replicasets
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type MockSpec struct {
+	Namespace       string
+	Name            string
+	Labels          map[string]string
+	Replicas        *int32
+	OwnerReferences []metav1.OwnerReference
+	// Kind is ReplicaSet or ReplicationController. Default to ReplicaSet.
+	Kind string
+}
+
+func GetMock(opts MockSpec) unstructured.Unstructured {
+	podTemplate := v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"app": opts.Name,
+			},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name:  "container",
+					Image: "my-image",
+				},
+			},
+		},
+	}
+	objectMeta := metav1.ObjectMeta{
+		Name:            opts.Name,
+		Namespace:       opts.Namespace,
+		Labels:          opts.Labels,
+		OwnerReferences: opts.OwnerReferences,
+	}
+
+	var obj interface{}
+	switch opts.Kind {
+	case ReplicationControllerGVK.Kind:
+		obj = v1.ReplicationController{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       ReplicationControllerGVK.Kind,
+				APIVersion: "v1",
+			},
+			ObjectMeta: objectMeta,
+			Spec: v1.ReplicationControllerSpec{
+				Replicas: opts.Replicas,
+				Selector: map[string]string{
+					"app": opts.Name,
+				},
+				Template: &podTemplate,
+			},
+		}
+	default:
+		obj = appsv1.ReplicaSet{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       ReplicaSetGVK.Kind,
+				APIVersion: "apps/v1",
+			},
+			ObjectMeta: objectMeta,
+			Spec: appsv1.ReplicaSetSpec{
+				Replicas: opts.Replicas,
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"app": opts.Name,
+					},
+				},
+				Template: podTemplate,
+			},
+		}
+	}
+
+	unstructuredObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&obj)
+	if err != nil {
+		panic(err)
+	}
+	return unstructured.Unstructured{
+		Object: unstructuredObj,
+	}
+}
@@ -0,0 +1,178 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CheckpointData is the progress of an operation, as stored in the secret of
+// the SleepInfo. The resources are identified by their group kind and name,
+// e.g. Deployment.apps/api.
+type CheckpointData struct {
+	// Planned are the resources the operation patches. If nil, all the
+	// resources are patched.
+	Planned []string `json:"planned,omitempty"`
+	// Patched are the resources already patched by the operation.
+	Patched []string `json:"patched,omitempty"`
+}
+
+// Checkpoint records the resources patched by an operation, so that the
+// operation interrupted before its end, e.g. by a crash or a leader change,
+// is resumed from where it stopped: the resources already patched, and the
+// ones not planned when it started, are not patched again. It is shared by
+// the copies of the ResourceClient.
+type Checkpoint struct {
+	mu      sync.Mutex
+	planned map[string]bool
+	patched map[string]bool
+	// save persists the progress every saveInterval patched resources.
+	save         func(ctx context.Context, data CheckpointData) error
+	saveInterval int
+	unsaved      int
+}
+
+// NewCheckpoint returns the Checkpoint of the operation with the given
+// progress.
+func NewCheckpoint(data CheckpointData) *Checkpoint {
+	c := &Checkpoint{patched: map[string]bool{}}
+	if data.Planned != nil {
+		c.Plan(data.Planned)
+	}
+	for _, key := range data.Patched {
+		c.patched[key] = true
+	}
+	return c
+}
+
+// GetCheckpointKey returns the key of the resource in the Checkpoint.
+func GetCheckpointKey(groupKind schema.GroupKind, name string) string {
+	return groupKind.String() + "/" + name
+}
+
+// Plan sets the resources the operation patches.
+func (c *Checkpoint) Plan(keys []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.planned = map[string]bool{}
+	for _, key := range keys {
+		c.planned[key] = true
+	}
+}
+
+// SetSave sets the function which persists the progress, called every
+// interval patched resources.
+func (c *Checkpoint) SetSave(interval int, save func(ctx context.Context, data CheckpointData) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.saveInterval = interval
+	c.save = save
+}
+
+// IsToPatch returns false if the resource is already patched, or if it was
+// not planned when the operation started.
+func (c *Checkpoint) IsToPatch(key string) bool {
+	if c == nil {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.patched[key] {
+		return false
+	}
+	return c.planned == nil || c.planned[key]
+}
+
+// Done records the patched resource, and persists the progress if
+// saveInterval resources have been patched since the last save.
+func (c *Checkpoint) Done(ctx context.Context, key string) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	c.patched[key] = true
+	c.unsaved++
+	if c.save == nil || c.saveInterval <= 0 || c.unsaved < c.saveInterval {
+		c.mu.Unlock()
+		return nil
+	}
+	c.unsaved = 0
+	data := c.getData()
+	save := c.save
+	c.mu.Unlock()
+	return save(ctx, data)
+}
+
+// Get returns the progress of the operation.
+func (c *Checkpoint) Get() CheckpointData {
+	if c == nil {
+		return CheckpointData{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.getData()
+}
+
+func (c *Checkpoint) getData() CheckpointData {
+	data := CheckpointData{Patched: getSortedKeys(c.patched)}
+	if c.planned != nil {
+		data.Planned = getSortedKeys(c.planned)
+	}
+	return data
+}
+
+func getSortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Marshal returns the progress of the operation as JSON.
+func (d CheckpointData) Marshal() ([]byte, error) {
+	return json.Marshal(d)
+}
+
+// UnmarshalCheckpointData parses the progress of an operation. If data is
+// empty, the operation has no progress.
+func UnmarshalCheckpointData(data []byte) (CheckpointData, error) {
+	checkpointData := CheckpointData{}
+	if len(data) == 0 {
+		return checkpointData, nil
+	}
+	if err := json.Unmarshal(data, &checkpointData); err != nil {
+		return CheckpointData{}, err
+	}
+	return checkpointData, nil
+}
+
+// isCheckpointed returns true if the resource is skipped since the
+// interrupted operation already patched it, or did not plan it.
+func (r ResourceClient) isCheckpointed(obj client.Object) bool {
+	if r.Checkpoint == nil || r.Checkpoint.IsToPatch(r.getCheckpointKey(obj)) {
+		return false
+	}
+	r.Log.V(1).Info("resource already handled by the interrupted operation, skip", "kind", r.getKind(obj), "name", obj.GetName())
+	return true
+}
+
+// checkpoint records the patched resource. A failure in persisting the
+// progress is logged, since the operation goes on anyway.
+func (r ResourceClient) checkpoint(ctx context.Context, obj client.Object) {
+	if r.Checkpoint == nil {
+		return
+	}
+	if err := r.Checkpoint.Done(ctx, r.getCheckpointKey(obj)); err != nil {
+		r.Log.Error(err, "fails to save the checkpoint of the operation")
+	}
+}
+
+func (r ResourceClient) getCheckpointKey(obj client.Object) string {
+	return GetCheckpointKey(r.getGVK(obj).GroupKind(), obj.GetName())
+}
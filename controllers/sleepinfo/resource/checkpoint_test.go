@@ -0,0 +1,147 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// countingClient is a client which counts the patches.
+type countingClient struct {
+	client.Client
+	patches *int
+}
+
+func (c countingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	*c.patches++
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestCheckpoint(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	replicas := int32(1)
+	getDeployment := func(name string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-namespace"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		}
+	}
+	newClient := func(checkpoint *Checkpoint, patches *int, err error) ResourceClient {
+		var c client.Client = countingClient{
+			Client:  fake.NewClientBuilder().WithScheme(scheme).WithObjects(getDeployment("api"), getDeployment("frontend"), getDeployment("new")).Build(),
+			patches: patches,
+		}
+		if err != nil {
+			c = rejectingClient{Client: c, err: err}
+		}
+		return ResourceClient{
+			Client:     c,
+			SleepInfo:  &kubegreenv1alpha1.SleepInfo{},
+			Log:        logr.Discard(),
+			Checkpoint: checkpoint,
+		}
+	}
+	patchAll := func(t *testing.T, r ResourceClient) {
+		t.Helper()
+		for _, name := range []string{"api", "frontend", "new"} {
+			deployment := getDeployment(name)
+			newDeployment := deployment.DeepCopy()
+			*newDeployment.Spec.Replicas = 0
+			require.NoError(t, r.Patch(context.Background(), deployment, newDeployment))
+		}
+	}
+
+	t.Run("without checkpoint all the resources are patched", func(t *testing.T) {
+		patches := 0
+		patchAll(t, newClient(nil, &patches, nil))
+		require.Equal(t, 3, patches)
+	})
+
+	t.Run("records the patched resources", func(t *testing.T) {
+		patches := 0
+		checkpoint := NewCheckpoint(CheckpointData{})
+		patchAll(t, newClient(checkpoint, &patches, nil))
+		require.Equal(t, 3, patches)
+		require.Equal(t, CheckpointData{
+			Patched: []string{"Deployment.apps/api", "Deployment.apps/frontend", "Deployment.apps/new"},
+		}, checkpoint.Get())
+	})
+
+	t.Run("resumes from the checkpoint", func(t *testing.T) {
+		patches := 0
+		checkpoint := NewCheckpoint(CheckpointData{
+			Planned: []string{"Deployment.apps/api", "Deployment.apps/frontend"},
+			Patched: []string{"Deployment.apps/api"},
+		})
+		patchAll(t, newClient(checkpoint, &patches, nil))
+		require.Equal(t, 1, patches)
+		require.Equal(t, CheckpointData{
+			Planned: []string{"Deployment.apps/api", "Deployment.apps/frontend"},
+			Patched: []string{"Deployment.apps/api", "Deployment.apps/frontend"},
+		}, checkpoint.Get())
+	})
+
+	t.Run("the failed patches are not recorded", func(t *testing.T) {
+		patches := 0
+		checkpoint := NewCheckpoint(CheckpointData{})
+		r := newClient(checkpoint, &patches, fmt.Errorf("some error"))
+		deployment := getDeployment("api")
+		require.Error(t, r.Patch(context.Background(), deployment, deployment.DeepCopy()))
+		require.Equal(t, CheckpointData{Patched: []string{}}, checkpoint.Get())
+	})
+
+	t.Run("saves the progress every interval", func(t *testing.T) {
+		patches := 0
+		saved := []CheckpointData{}
+		checkpoint := NewCheckpoint(CheckpointData{})
+		checkpoint.SetSave(2, func(ctx context.Context, data CheckpointData) error {
+			saved = append(saved, data)
+			return fmt.Errorf("save fails")
+		})
+		patchAll(t, newClient(checkpoint, &patches, nil))
+		require.Equal(t, 3, patches)
+		require.Equal(t, []CheckpointData{
+			{Patched: []string{"Deployment.apps/api", "Deployment.apps/frontend"}},
+		}, saved)
+	})
+}
+
+func TestCheckpointData(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		data := CheckpointData{
+			Planned: []string{"Deployment.apps/api", "Job.batch/migration"},
+			Patched: []string{"Deployment.apps/api"},
+		}
+		value, err := data.Marshal()
+		require.NoError(t, err)
+		require.JSONEq(t, `{"planned":["Deployment.apps/api","Job.batch/migration"],"patched":["Deployment.apps/api"]}`, string(value))
+
+		actual, err := UnmarshalCheckpointData(value)
+		require.NoError(t, err)
+		require.Equal(t, data, actual)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		actual, err := UnmarshalCheckpointData(nil)
+		require.NoError(t, err)
+		require.Equal(t, CheckpointData{}, actual)
+		require.True(t, NewCheckpoint(actual).IsToPatch("Deployment.apps/api"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := UnmarshalCheckpointData([]byte("{"))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,58 @@
+package resource
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type chunkedClient struct {
+	client.Client
+	size   int64
+	pause  time.Duration
+	writes *int64
+}
+
+// NewChunkedClient returns a client which splits the writes to the API server
+// in chunks of size requests, pausing between them, so that a very large
+// operation does not overload a shared control plane. The writes are counted
+// across the copies of the returned client. If the size is not positive, the
+// client is returned as is.
+func NewChunkedClient(c client.Client, size int, pause time.Duration) client.Client {
+	if size <= 0 {
+		return c
+	}
+	return chunkedClient{Client: c, size: int64(size), pause: pause, writes: new(int64)}
+}
+
+func (c chunkedClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.waitChunk(ctx); err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c chunkedClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if err := c.waitChunk(ctx); err != nil {
+		return err
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+// waitChunk pauses before the first write of each chunk, but the first one.
+func (c chunkedClient) waitChunk(ctx context.Context) error {
+	writes := atomic.AddInt64(c.writes, 1)
+	if writes == 1 || (writes-1)%c.size != 0 {
+		return nil
+	}
+	timer := time.NewTimer(c.pause)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
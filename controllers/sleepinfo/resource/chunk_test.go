@@ -0,0 +1,57 @@
+package resource
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestChunkedClient(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "test-namespace"},
+	}
+	patch := func(ctx context.Context, c client.Client) error {
+		newDeployment := deployment.DeepCopy()
+		newDeployment.Labels = map[string]string{"foo": "bar"}
+		return c.Patch(ctx, newDeployment, client.MergeFrom(deployment))
+	}
+	pause := 50 * time.Millisecond
+
+	t.Run("pause between the chunks", func(t *testing.T) {
+		c := NewChunkedClient(fake.NewClientBuilder().WithScheme(scheme).WithObjects(deployment).Build(), 2, pause)
+
+		start := time.Now()
+		require.NoError(t, patch(context.Background(), c))
+		require.NoError(t, patch(context.Background(), c))
+		require.Less(t, time.Since(start), pause)
+
+		// the copies of the client share the count of the writes.
+		copied := c
+		require.NoError(t, patch(context.Background(), copied))
+		require.GreaterOrEqual(t, time.Since(start), pause)
+	})
+
+	t.Run("stop the pause when the context is done", func(t *testing.T) {
+		c := NewChunkedClient(fake.NewClientBuilder().WithScheme(scheme).WithObjects(deployment).Build(), 1, time.Hour)
+		require.NoError(t, patch(context.Background(), c))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, patch(ctx, c), context.Canceled)
+	})
+
+	t.Run("not chunked without size", func(t *testing.T) {
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+		require.Equal(t, fakeClient, NewChunkedClient(fakeClient, 0, pause))
+	})
+}
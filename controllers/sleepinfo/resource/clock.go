@@ -0,0 +1,13 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/kube-green/kube-green/pkg/clock"
+)
+
+// Now returns the current time of the Clock of the ResourceClient, or the
+// real one if it is not set.
+func (r ResourceClient) Now() time.Time {
+	return clock.Now(r.Clock)
+}
@@ -0,0 +1,136 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// timeoutRetries is how many times a timed out patch is retried, before the
+// resource is skipped.
+const timeoutRetries = 2
+
+// FailedResources collects the resources skipped by an operation because
+// they fail to be handled. It is shared by the copies of the ResourceClient.
+type FailedResources struct {
+	resources []kubegreenv1alpha1.FailedResource
+}
+
+// Add records a skipped resource.
+func (f *FailedResources) Add(resource kubegreenv1alpha1.FailedResource) {
+	f.resources = append(f.resources, resource)
+}
+
+// Get returns the skipped resources, in the order in which they failed.
+func (f *FailedResources) Get() []kubegreenv1alpha1.FailedResource {
+	if f == nil {
+		return nil
+	}
+	return f.resources
+}
+
+// PatchError is the error of the patch of a resource, which identifies the
+// resource whose patch fails.
+type PatchError struct {
+	GVK       schema.GroupVersionKind
+	Namespace string
+	Name      string
+	Err       error
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("fails to patch %s %s: %s", e.GVK.GroupKind(), e.Name, e.Err)
+}
+
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+// isAdmissionRejection returns true if the request is denied by an admission
+// webhook.
+func isAdmissionRejection(err error) bool {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		return false
+	}
+	message := status.Status().Message
+	return strings.Contains(message, "admission webhook") && strings.Contains(message, "denied the request")
+}
+
+// patchWithRetry retries the patch if it times out. If FailedResources is
+// set, the resource is skipped, so that the operation goes on with the other
+// resources, if the patch still times out or if it is rejected by an
+// admission webhook and the RejectionPolicy of the SleepInfo is Skip.
+// The resources skipped by the Checkpoint are not patched, and the patched
+// ones are recorded in it.
+func (r ResourceClient) patchWithRetry(ctx context.Context, obj client.Object, patch func() error) error {
+	if r.isCheckpointed(obj) {
+		return nil
+	}
+	err := patch()
+	for i := 0; i < timeoutRetries && isTimeout(ctx, err); i++ {
+		r.Log.Info("patch timed out, retry", "kind", r.getKind(obj), "name", obj.GetName())
+		err = patch()
+	}
+	if err == nil {
+		r.checkpoint(ctx, obj)
+		return nil
+	}
+	if r.FailedResources == nil {
+		return err
+	}
+	switch {
+	case isTimeout(ctx, err):
+		r.skip(obj, err, "patch timed out, resource skipped")
+	case isAdmissionRejection(err) && r.SleepInfo.IsRejectedResourceSkipped():
+		r.skip(obj, err, "patch rejected by an admission webhook, resource skipped")
+		r.Eventf(obj, v1.EventTypeWarning, "PatchRejected", "Patch by SleepInfo %s rejected by an admission webhook, resource skipped: %s", r.SleepInfo.GetName(), err)
+	default:
+		return err
+	}
+	return nil
+}
+
+func (r ResourceClient) skip(obj client.Object, err error, msg string) {
+	kind := r.getKind(obj)
+	r.Log.Error(err, msg, "kind", kind, "name", obj.GetName())
+	r.FailedResources.Add(kubegreenv1alpha1.FailedResource{
+		Kind:   kind,
+		Name:   obj.GetName(),
+		Reason: err.Error(),
+	})
+}
+
+func (r ResourceClient) getKind(obj client.Object) string {
+	return r.getGVK(obj).Kind
+}
+
+func (r ResourceClient) getGVK(obj client.Object) schema.GroupVersionKind {
+	if gvk := obj.GetObjectKind().GroupVersionKind(); gvk.Kind != "" {
+		return gvk
+	}
+	gvk, err := apiutil.GVKForObject(obj, r.Client.Scheme())
+	if err != nil {
+		return schema.GroupVersionKind{}
+	}
+	return gvk
+}
+
+// newPatchError returns the PatchError of the patch of obj.
+func (r ResourceClient) newPatchError(obj client.Object, err error) error {
+	return &PatchError{
+		GVK:       r.getGVK(obj),
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		Err:       err,
+	}
+}
@@ -0,0 +1,116 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// rejectingClient is a client whose patches fail with err.
+type rejectingClient struct {
+	client.Client
+	err error
+}
+
+func (c rejectingClient) Patch(context.Context, client.Object, client.Patch, ...client.PatchOption) error {
+	return c.err
+}
+
+func TestPatchRejected(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "test-namespace"},
+	}
+	webhookErr := &apierrors.StatusError{ErrStatus: metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusForbidden,
+		Reason:  metav1.StatusReasonForbidden,
+		Message: `admission webhook "validate.example.com" denied the request: replicas cannot be 0`,
+	}}
+
+	tests := []struct {
+		name            string
+		rejectionPolicy string
+		err             error
+		expectedErr     bool
+		expectedFailed  int
+	}{
+		{
+			name:            "skip the rejected resource",
+			rejectionPolicy: kubegreenv1alpha1.RejectionPolicySkip,
+			err:             webhookErr,
+			expectedFailed:  1,
+		},
+		{
+			name:            "fail with the default policy",
+			rejectionPolicy: "",
+			err:             webhookErr,
+			expectedErr:     true,
+		},
+		{
+			name:            "fail with Fail policy",
+			rejectionPolicy: kubegreenv1alpha1.RejectionPolicyFail,
+			err:             webhookErr,
+			expectedErr:     true,
+		},
+		{
+			name:            "fail if not rejected by a webhook",
+			rejectionPolicy: kubegreenv1alpha1.RejectionPolicySkip,
+			err:             apierrors.NewForbidden(appsv1.Resource("deployments"), "api", fmt.Errorf("not allowed")),
+			expectedErr:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			failedResources := &FailedResources{}
+			recorder := record.NewFakeRecorder(1)
+			r := ResourceClient{
+				Client: rejectingClient{
+					Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(deployment).Build(),
+					err:    test.err,
+				},
+				SleepInfo: &kubegreenv1alpha1.SleepInfo{
+					ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo"},
+					Spec:       kubegreenv1alpha1.SleepInfoSpec{RejectionPolicy: test.rejectionPolicy},
+				},
+				Log:             logr.Discard(),
+				Recorder:        recorder,
+				FailedResources: failedResources,
+			}
+
+			err := r.Patch(context.Background(), deployment, deployment.DeepCopy())
+			if test.expectedErr {
+				require.ErrorIs(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			failed := failedResources.Get()
+			require.Len(t, failed, test.expectedFailed)
+			if test.expectedFailed > 0 {
+				require.Equal(t, kubegreenv1alpha1.FailedResource{
+					Kind:   "Deployment",
+					Name:   "api",
+					Reason: webhookErr.Error(),
+				}, failed[0])
+				require.Equal(t, fmt.Sprintf("Warning PatchRejected Patch by SleepInfo sleepinfo rejected by an admission webhook, resource skipped: %s", webhookErr), <-recorder.Events)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package resource
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// SleepAtAnnotation defers the sleep of a workload to the first HH:mm,
+	// set as its value in the time zone of the SleepInfo, after the sleep of
+	// the namespace, e.g. for a nightly worker which must stay up later than
+	// the rest of the namespace.
+	SleepAtAnnotation = "kube-green.dev/sleep-at"
+	// WakeUpAtAnnotation defers the wake up of a workload to the first HH:mm,
+	// set as its value in the time zone of the SleepInfo, after the wake up
+	// of the namespace.
+	WakeUpAtAnnotation = "kube-green.dev/wake-up-at"
+)
+
+// GetNextTimeOfDay returns the first time after the given time at the hour
+// and minute of the HH:mm value, in the location.
+func GetNextTimeOfDay(value string, after time.Time, location *time.Location) (time.Time, error) {
+	timeOfDay, err := time.Parse("15:04", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("time should be of format HH:mm, actual: %s", value)
+	}
+	after = after.In(location)
+	next := time.Date(after.Year(), after.Month(), after.Day(), timeOfDay.Hour(), timeOfDay.Minute(), 0, 0, location)
+	if !next.After(after) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next, nil
+}
@@ -0,0 +1,65 @@
+package resource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetNextTimeOfDay(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	require.NoError(t, err)
+	after := time.Date(2021, 3, 24, 20, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		value       string
+		location    *time.Location
+		expected    time.Time
+		expectedErr string
+	}{
+		{
+			name:     "later the same day",
+			value:    "23:00",
+			location: time.UTC,
+			expected: time.Date(2021, 3, 24, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "the next day",
+			value:    "02:30",
+			location: time.UTC,
+			expected: time.Date(2021, 3, 25, 2, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "the same time is the next day",
+			value:    "20:00",
+			location: time.UTC,
+			expected: time.Date(2021, 3, 25, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "in the location",
+			value:    "23:00",
+			location: rome,
+			expected: time.Date(2021, 3, 24, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "invalid time",
+			value:       "11pm",
+			location:    time.UTC,
+			expectedErr: "time should be of format HH:mm, actual: 11pm",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			next, err := GetNextTimeOfDay(test.value, after, test.location)
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, test.expected.Equal(next), next.String())
+		})
+	}
+}
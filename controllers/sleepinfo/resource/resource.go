@@ -1,24 +1,41 @@
 package resource
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"path"
+	"regexp"
 	"strings"
+	"time"
 
 	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/internal/tracing"
+	"github.com/kube-green/kube-green/pkg/clock"
 
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var ErrInvalidClient = errors.New("invalid client")
 
+// AwakeUntilAnnotation exempts a resource from the sleep until the RFC3339
+// timestamp set as its value, e.g. to keep a service up while debugging it.
+const AwakeUntilAnnotation = "kube-green.dev/awake-until"
+
 type Resource interface {
 	HasResource() bool
 	Sleep(ctx context.Context) error
 	WakeUp(ctx context.Context) error
 	GetOriginalInfoToSave() ([]byte, error)
+	GetResourceNames() []string
 }
 
 type ResourceClient struct {
@@ -26,17 +43,55 @@ type ResourceClient struct {
 	SleepInfo        *kubegreenv1alpha1.SleepInfo
 	Log              logr.Logger
 	FieldManagerName string
+	// Recorder records the events on the handled resources. If nil, the
+	// events are not recorded.
+	Recorder record.EventRecorder
+	// FailedResources collects the resources whose patch times out or is
+	// rejected by an admission webhook, if the RejectionPolicy of the
+	// SleepInfo is Skip, which are skipped so that they do not block the
+	// operation. If nil, the failure is returned as error.
+	FailedResources *FailedResources
+	// Clock is the clock of the time compared with the annotations of the
+	// resources. If nil, the real clock is used.
+	Clock clock.Clock
+	// PodReader reads the pods without the cache, so that the pods of the
+	// whole cluster are not watched. If nil, the pods are not handled.
+	PodReader client.Reader
+	// IsWakeUp is true if the resources are handled to wake them up. On wake
+	// up, the exclusions, the sleep classes and the kinds to suspend of the
+	// SleepInfo do not filter the resources: only the ones in the stored state
+	// are restored, so that the resources put to sleep are restored even if
+	// the SleepInfo has been changed during the sleep.
+	IsWakeUp bool
+	// Checkpoint records the progress of the operation, so that the
+	// operation interrupted before its end is resumed without patching again
+	// the resources already patched. If nil, all the resources are patched.
+	Checkpoint *Checkpoint
+}
+
+// Eventf records an event on the object, if the Recorder is set.
+func (r ResourceClient) Eventf(obj runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Eventf(obj, eventType, reason, messageFmt, args...)
 }
 
-func (r ResourceClient) Patch(ctx context.Context, oldObj, newObj client.Object) error {
+func (r ResourceClient) Patch(ctx context.Context, oldObj, newObj client.Object) (err error) {
 	if err := r.IsClientValid(); err != nil {
 		return err
 	}
-	if err := r.Client.Patch(ctx, newObj, client.MergeFrom(oldObj)); err != nil {
+	ctx, span := tracing.Tracer().Start(ctx, "patch", trace.WithAttributes(getSpanAttributes(newObj)...))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	patch := client.MergeFrom(oldObj)
+	if err := r.patchWithRetry(ctx, newObj, func() error {
+		return r.Client.Patch(ctx, newObj, patch)
+	}); err != nil {
 		if client.IgnoreNotFound(err) == nil {
 			return nil
 		}
-		return err
+		return r.newPatchError(newObj, err)
 	}
 	return nil
 }
@@ -44,23 +99,142 @@ func (r ResourceClient) Patch(ctx context.Context, oldObj, newObj client.Object)
 var forceTrue = true
 
 // Server Side Apply patch. Reference: https://kubernetes.io/docs/reference/using-api/server-side-apply/
-func (r ResourceClient) SSAPatch(ctx context.Context, newObj client.Object) error {
+func (r ResourceClient) SSAPatch(ctx context.Context, newObj client.Object) (err error) {
 	if err := r.IsClientValid(); err != nil {
 		return err
 	}
+	ctx, span := tracing.Tracer().Start(ctx, "patch", trace.WithAttributes(getSpanAttributes(newObj)...))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	newObj.SetManagedFields(nil)
-	if err := r.Client.Patch(ctx, newObj, client.Apply, &client.PatchOptions{
-		FieldManager: r.FieldManagerName,
-		Force:        &forceTrue,
+	if err := r.patchWithRetry(ctx, newObj, func() error {
+		return r.Client.Patch(ctx, newObj, client.Apply, &client.PatchOptions{
+			FieldManager: r.FieldManagerName,
+			Force:        &forceTrue,
+		})
 	}); err != nil {
 		if client.IgnoreNotFound(err) == nil {
 			return nil
 		}
-		return err
+		return r.newPatchError(newObj, err)
 	}
 	return nil
 }
 
+func getSpanAttributes(obj client.Object) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("resource.kind", obj.GetObjectKind().GroupVersionKind().Kind),
+		attribute.String("resource.name", obj.GetName()),
+	}
+}
+
+// IsExcluded returns true if the resource of the given kind is excluded by
+// name, by labels or by owner kind in the SleepInfo excludeRef.
+func IsExcluded(kind string, obj client.Object, excludeRef []kubegreenv1alpha1.ExcludeRef) bool {
+	for _, exclusion := range excludeRef {
+		if IsExcludedByOwner(kind, obj, exclusion) {
+			return true
+		}
+		if IsExcludedByNamePattern(kind, obj, exclusion) {
+			return true
+		}
+		if IsExcludedByField(kind, obj, exclusion) {
+			return true
+		}
+		if exclusion.Kind == kind && exclusion.Name != "" && exclusion.Name == obj.GetName() {
+			return true
+		}
+		if len(exclusion.MatchLabels) > 0 && labels.SelectorFromSet(exclusion.MatchLabels).Matches(labels.Set(obj.GetLabels())) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExcludedByOwner returns true if the resource of the given kind has an
+// owner of the ownerKind of the exclusion.
+func IsExcludedByOwner(kind string, obj client.Object, exclusion kubegreenv1alpha1.ExcludeRef) bool {
+	if exclusion.OwnerKind == "" || (exclusion.Kind != "" && exclusion.Kind != kind) {
+		return false
+	}
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.Kind == exclusion.OwnerKind {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExcludedByNamePattern returns true if the name of the resource of the
+// given kind matches the namePattern or the nameGlob of the exclusion.
+func IsExcludedByNamePattern(kind string, obj client.Object, exclusion kubegreenv1alpha1.ExcludeRef) bool {
+	if exclusion.Kind != kind {
+		return false
+	}
+	if exclusion.NamePattern != "" {
+		nameRegex, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", exclusion.NamePattern))
+		return err == nil && nameRegex.MatchString(obj.GetName())
+	}
+	if exclusion.NameGlob != "" {
+		matched, err := path.Match(exclusion.NameGlob, obj.GetName())
+		return err == nil && matched
+	}
+	return false
+}
+
+// IsExcludedByField returns true if the field of the resource of the given
+// kind, read with the JSONPath of the matchField of the exclusion, has one of
+// its values.
+func IsExcludedByField(kind string, obj client.Object, exclusion kubegreenv1alpha1.ExcludeRef) bool {
+	if exclusion.MatchField == nil || (exclusion.Kind != "" && exclusion.Kind != kind) {
+		return false
+	}
+	parser, err := exclusion.MatchField.ParseJSONPath()
+	if err != nil {
+		return false
+	}
+	var content map[string]interface{}
+	if u, ok := obj.(runtime.Unstructured); ok {
+		content = u.UnstructuredContent()
+	} else if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+		return false
+	}
+	value := &bytes.Buffer{}
+	if err := parser.Execute(value, content); err != nil {
+		return false
+	}
+	if len(exclusion.MatchField.Values) == 0 {
+		return value.Len() > 0
+	}
+	for _, expected := range exclusion.MatchField.Values {
+		if value.String() == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// IsKeptAwake returns true if the resource is exempted from the sleep by the
+// AwakeUntilAnnotation, recording an event which explains the skip.
+func (r ResourceClient) IsKeptAwake(obj client.Object) bool {
+	value, ok := obj.GetAnnotations()[AwakeUntilAnnotation]
+	if !ok {
+		return false
+	}
+	log := r.Log.WithValues("kind", obj.GetObjectKind().GroupVersionKind().Kind, "name", obj.GetName(), "namespace", obj.GetNamespace())
+	awakeUntil, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		log.Info("invalid awake until annotation, ignored", "value", value)
+		return false
+	}
+	if !r.Now().Before(awakeUntil) {
+		return false
+	}
+	log.Info("resource kept awake, skip sleep", "awakeUntil", value)
+	r.Eventf(obj, v1.EventTypeNormal, "SleepSkipped", "Sleep skipped: kept awake until %s by the %s annotation", value, AwakeUntilAnnotation)
+	return true
+}
+
 var errClientEmpty = "client is empty"
 var errSleepInfoEmpty = "sleepInfo is nil"
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
 	"github.com/kube-green/kube-green/internal/testutil"
@@ -16,6 +17,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -161,7 +163,7 @@ func TestResource(t *testing.T) {
 			newD1 := deployment.DeepCopy()
 			newD1.Spec.Template.Spec.Containers[0].Image = newImageName
 
-			require.EqualError(t, c.Patch(context.Background(), &deployment, newD1), "error during patch")
+			require.EqualError(t, c.Patch(context.Background(), &deployment, newD1), "fails to patch Deployment.apps my-name: error during patch")
 		})
 	})
 
@@ -188,7 +190,276 @@ func TestResource(t *testing.T) {
 			newD1 := deployment.DeepCopy()
 			newD1.Spec.Template.Spec.Containers[0].Image = newImageName
 
-			require.EqualError(t, c.SSAPatch(context.Background(), &deployment), "error during patch")
+			require.EqualError(t, c.SSAPatch(context.Background(), &deployment), "fails to patch Deployment.apps my-name: error during patch")
 		})
 	})
 }
+
+func TestIsExcluded(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "my-name",
+			Labels: map[string]string{
+				"app": "foo",
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "my-rollout"},
+			},
+		},
+		Spec: v1.PodSpec{
+			PriorityClassName: "critical",
+		},
+	}
+
+	tests := []struct {
+		name       string
+		kind       string
+		excludeRef []kubegreenv1alpha1.ExcludeRef
+		expected   bool
+	}{
+		{
+			name:     "without exclusions",
+			kind:     "Pod",
+			expected: false,
+		},
+		{
+			name: "excluded by name",
+			kind: "Pod",
+			excludeRef: []kubegreenv1alpha1.ExcludeRef{
+				{Kind: "Pod", Name: "my-name"},
+			},
+			expected: true,
+		},
+		{
+			name: "same name of another kind",
+			kind: "Pod",
+			excludeRef: []kubegreenv1alpha1.ExcludeRef{
+				{Kind: "Deployment", Name: "my-name"},
+			},
+			expected: false,
+		},
+		{
+			name: "excluded by labels",
+			kind: "Pod",
+			excludeRef: []kubegreenv1alpha1.ExcludeRef{
+				{MatchLabels: map[string]string{"app": "foo"}},
+			},
+			expected: true,
+		},
+		{
+			name: "labels not matching",
+			kind: "Pod",
+			excludeRef: []kubegreenv1alpha1.ExcludeRef{
+				{MatchLabels: map[string]string{"app": "foo", "other": "label"}},
+			},
+			expected: false,
+		},
+		{
+			name: "excluded by owner kind",
+			kind: "Pod",
+			excludeRef: []kubegreenv1alpha1.ExcludeRef{
+				{OwnerKind: "Rollout"},
+			},
+			expected: true,
+		},
+		{
+			name: "excluded by owner kind of the same kind",
+			kind: "Pod",
+			excludeRef: []kubegreenv1alpha1.ExcludeRef{
+				{Kind: "Pod", OwnerKind: "Rollout"},
+			},
+			expected: true,
+		},
+		{
+			name: "owner kind of another kind",
+			kind: "Pod",
+			excludeRef: []kubegreenv1alpha1.ExcludeRef{
+				{Kind: "Deployment", OwnerKind: "Rollout"},
+			},
+			expected: false,
+		},
+		{
+			name: "excluded by name pattern",
+			kind: "Pod",
+			excludeRef: []kubegreenv1alpha1.ExcludeRef{
+				{Kind: "Pod", NamePattern: "my-.*"},
+			},
+			expected: true,
+		},
+		{
+			name: "name pattern matching only part of the name",
+			kind: "Pod",
+			excludeRef: []kubegreenv1alpha1.ExcludeRef{
+				{Kind: "Pod", NamePattern: "my"},
+			},
+			expected: false,
+		},
+		{
+			name: "name pattern of another kind",
+			kind: "Pod",
+			excludeRef: []kubegreenv1alpha1.ExcludeRef{
+				{Kind: "Deployment", NamePattern: "my-.*"},
+			},
+			expected: false,
+		},
+		{
+			name: "excluded by name glob",
+			kind: "Pod",
+			excludeRef: []kubegreenv1alpha1.ExcludeRef{
+				{Kind: "Pod", NameGlob: "my-*"},
+			},
+			expected: true,
+		},
+		{
+			name: "name glob not matching",
+			kind: "Pod",
+			excludeRef: []kubegreenv1alpha1.ExcludeRef{
+				{Kind: "Pod", NameGlob: "preview-*"},
+			},
+			expected: false,
+		},
+		{
+			name: "excluded by field value",
+			kind: "Pod",
+			excludeRef: []kubegreenv1alpha1.ExcludeRef{
+				{MatchField: &kubegreenv1alpha1.FieldMatcher{JSONPath: "{.spec.priorityClassName}", Values: []string{"high", "critical"}}},
+			},
+			expected: true,
+		},
+		{
+			name: "excluded by field set, without braces",
+			kind: "Pod",
+			excludeRef: []kubegreenv1alpha1.ExcludeRef{
+				{Kind: "Pod", MatchField: &kubegreenv1alpha1.FieldMatcher{JSONPath: ".spec.priorityClassName"}},
+			},
+			expected: true,
+		},
+		{
+			name: "field value not matching",
+			kind: "Pod",
+			excludeRef: []kubegreenv1alpha1.ExcludeRef{
+				{MatchField: &kubegreenv1alpha1.FieldMatcher{JSONPath: "{.spec.priorityClassName}", Values: []string{"low"}}},
+			},
+			expected: false,
+		},
+		{
+			name: "missing field",
+			kind: "Pod",
+			excludeRef: []kubegreenv1alpha1.ExcludeRef{
+				{MatchField: &kubegreenv1alpha1.FieldMatcher{JSONPath: "{.spec.schedulerName}"}},
+			},
+			expected: false,
+		},
+		{
+			name: "field of another kind",
+			kind: "Pod",
+			excludeRef: []kubegreenv1alpha1.ExcludeRef{
+				{Kind: "Deployment", MatchField: &kubegreenv1alpha1.FieldMatcher{JSONPath: "{.spec.priorityClassName}"}},
+			},
+			expected: false,
+		},
+		{
+			name: "owner kind not matching",
+			kind: "Pod",
+			excludeRef: []kubegreenv1alpha1.ExcludeRef{
+				{OwnerKind: "Kafka"},
+			},
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, IsExcluded(test.kind, pod, test.excludeRef))
+		})
+	}
+}
+
+func TestIsKeptAwake(t *testing.T) {
+	tests := []struct {
+		name          string
+		annotations   map[string]string
+		expected      bool
+		expectedEvent bool
+	}{
+		{
+			name:     "without annotation",
+			expected: false,
+		},
+		{
+			name:          "awake until a future time",
+			annotations:   map[string]string{AwakeUntilAnnotation: time.Now().Add(time.Hour).Format(time.RFC3339)},
+			expected:      true,
+			expectedEvent: true,
+		},
+		{
+			name:        "awake until a past time",
+			annotations: map[string]string{AwakeUntilAnnotation: time.Now().Add(-time.Hour).Format(time.RFC3339)},
+			expected:    false,
+		},
+		{
+			name:        "invalid annotation",
+			annotations: map[string]string{AwakeUntilAnnotation: "tomorrow"},
+			expected:    false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r := ResourceClient{
+				Log:      logr.Discard(),
+				Recorder: recorder,
+			}
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "my-name",
+					Namespace:   "my-namespace",
+					Annotations: test.annotations,
+				},
+			}
+
+			require.Equal(t, test.expected, r.IsKeptAwake(pod))
+			if test.expectedEvent {
+				require.Len(t, recorder.Events, 1)
+				require.Contains(t, <-recorder.Events, "Normal SleepSkipped Sleep skipped: kept awake until")
+			} else {
+				require.Empty(t, recorder.Events)
+			}
+		})
+	}
+
+	t.Run("without recorder", func(t *testing.T) {
+		r := ResourceClient{Log: logr.Discard()}
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{AwakeUntilAnnotation: time.Now().Add(time.Hour).Format(time.RFC3339)},
+			},
+		}
+		require.True(t, r.IsKeptAwake(pod))
+	})
+
+	t.Run("with clock", func(t *testing.T) {
+		r := ResourceClient{
+			Log:   logr.Discard(),
+			Clock: fixedClock{now: time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)},
+		}
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{AwakeUntilAnnotation: "2021-03-23T21:00:00Z"},
+			},
+		}
+		require.True(t, r.IsKeptAwake(pod))
+
+		r.Clock = fixedClock{now: time.Date(2021, 3, 23, 21, 0, 0, 0, time.UTC)}
+		require.False(t, r.IsKeptAwake(pod))
+	})
+}
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
@@ -7,6 +7,7 @@ type Mock struct {
 	MockSleep               func(context.Context) error
 	MockWakeUp              func(context.Context) error
 	MockOriginalInfoToSave  func() ([]byte, error)
+	MockResourceNames       []string
 }
 
 func (r Mock) HasResource() bool {
@@ -34,6 +35,10 @@ func (r Mock) GetOriginalInfoToSave() ([]byte, error) {
 	return r.MockOriginalInfoToSave()
 }
 
+func (r Mock) GetResourceNames() []string {
+	return r.MockResourceNames
+}
+
 func GetResourceMock(mock Mock) Resource {
 	return mock
 }
@@ -0,0 +1,59 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type timeoutClient struct {
+	client.Client
+	timeout time.Duration
+}
+
+// NewTimeoutClient returns a client which cancels each request to the API
+// server after the timeout, so that a single hung request, e.g. to a resource
+// blocked by an admission webhook, cannot stall the whole operation. If the
+// timeout is not positive, the client is returned as is.
+func NewTimeoutClient(c client.Client, timeout time.Duration) client.Client {
+	if timeout <= 0 {
+		return c
+	}
+	return timeoutClient{Client: c, timeout: timeout}
+}
+
+func (c timeoutClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c timeoutClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c timeoutClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c timeoutClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+// isTimeout returns true if the request timed out, but not because the
+// operation context is done.
+func isTimeout(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return errors.Is(err, context.DeadlineExceeded) || apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err)
+}
@@ -0,0 +1,92 @@
+package resource
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// hungClient is a client whose patches hang until the context is done.
+type hungClient struct {
+	client.Client
+	patches int
+}
+
+func (c *hungClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.patches++
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestPatchTimeout(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "test-namespace"},
+	}
+	newResourceClient := func(failedResources *FailedResources) (ResourceClient, *hungClient) {
+		hung := &hungClient{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(deployment).Build()}
+		return ResourceClient{
+			Client:          NewTimeoutClient(hung, 10*time.Millisecond),
+			SleepInfo:       &kubegreenv1alpha1.SleepInfo{},
+			Log:             logr.Discard(),
+			FailedResources: failedResources,
+		}, hung
+	}
+
+	t.Run("skip the resource whose patch times out", func(t *testing.T) {
+		failedResources := &FailedResources{}
+		r, hung := newResourceClient(failedResources)
+
+		newDeployment := deployment.DeepCopy()
+		newDeployment.Labels = map[string]string{"foo": "bar"}
+		require.NoError(t, r.Patch(context.Background(), deployment, newDeployment))
+		require.NoError(t, r.SSAPatch(context.Background(), newDeployment))
+
+		require.Equal(t, 2*(timeoutRetries+1), hung.patches)
+		failed := failedResources.Get()
+		require.Len(t, failed, 2)
+		for _, failedResource := range failed {
+			require.Equal(t, "Deployment", failedResource.Kind)
+			require.Equal(t, "api", failedResource.Name)
+			require.Contains(t, failedResource.Reason, context.DeadlineExceeded.Error())
+		}
+	})
+
+	t.Run("return the timeout without FailedResources", func(t *testing.T) {
+		r, _ := newResourceClient(nil)
+
+		newDeployment := deployment.DeepCopy()
+		newDeployment.Labels = map[string]string{"foo": "bar"}
+		err := r.Patch(context.Background(), deployment, newDeployment)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("do not retry when the operation is canceled", func(t *testing.T) {
+		failedResources := &FailedResources{}
+		r, hung := newResourceClient(failedResources)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := r.Patch(ctx, deployment, deployment.DeepCopy())
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, 1, hung.patches)
+		require.Empty(t, failedResources.Get())
+	})
+
+	t.Run("without timeout the client is not wrapped", func(t *testing.T) {
+		c := fake.NewClientBuilder().Build()
+		require.Equal(t, c, NewTimeoutClient(c, 0))
+	})
+}
@@ -2,15 +2,39 @@ package sleepinfo
 
 import (
 	"context"
+	"strings"
 
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
 	"github.com/kube-green/kube-green/controllers/sleepinfo/cronjobs"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/customresources"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/daemonsets"
 	"github.com/kube-green/kube-green/controllers/sleepinfo/deployments"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/jobs"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/poddisruptionbudgets"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/pods"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/replicasets"
 	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/internal/tracing"
+)
+
+const (
+	resourceSucceeded = "succeeded"
+	resourceFailed    = "failed"
 )
 
 type Resources struct {
-	deployments resource.Resource
-	cronjobs    resource.Resource
+	deployments            resource.Resource
+	cronjobs               resource.Resource
+	jobs                   resource.Resource
+	replicasets            resource.Resource
+	replicationcontrollers resource.Resource
+	daemonsets             resource.Resource
+	poddisruptionbudgets   resource.Resource
+	customresources        resource.Resource
+	pods                   resource.Resource
+
+	failedResources *resource.FailedResources
+	checkpoint      *resource.Checkpoint
 }
 
 func NewResources(ctx context.Context, resourceClient resource.ResourceClient, namespace string, sleepInfoData SleepInfoData) (Resources, error) {
@@ -27,29 +51,186 @@ func NewResources(ctx context.Context, resourceClient resource.ResourceClient, n
 		resourceClient.Log.Error(err, "fails to init cronjobs")
 		return Resources{}, err
 	}
+	jobResource, err := jobs.NewResource(ctx, resourceClient, namespace, sleepInfoData.OriginalJobStatus)
+	if err != nil {
+		resourceClient.Log.Error(err, "fails to init jobs")
+		return Resources{}, err
+	}
+	replicaSetResource, err := replicasets.NewReplicaSetResource(ctx, resourceClient, namespace, sleepInfoData.OriginalReplicaSetsReplicas)
+	if err != nil {
+		resourceClient.Log.Error(err, "fails to init replicasets")
+		return Resources{}, err
+	}
+	replicationControllerResource, err := replicasets.NewReplicationControllerResource(ctx, resourceClient, namespace, sleepInfoData.OriginalReplicationControllersReplicas)
+	if err != nil {
+		resourceClient.Log.Error(err, "fails to init replicationcontrollers")
+		return Resources{}, err
+	}
+	daemonSetResource, err := daemonsets.NewResource(ctx, resourceClient, namespace, sleepInfoData.OriginalDaemonSetsNodeSelector)
+	if err != nil {
+		resourceClient.Log.Error(err, "fails to init daemonsets")
+		return Resources{}, err
+	}
+	podDisruptionBudgetResource, err := poddisruptionbudgets.NewResource(ctx, resourceClient, namespace, sleepInfoData.OriginalPodDisruptionBudgets)
+	if err != nil {
+		resourceClient.Log.Error(err, "fails to init poddisruptionbudgets")
+		return Resources{}, err
+	}
+	customResource, err := customresources.NewResource(ctx, resourceClient, namespace, customresources.DefaultRegistry, sleepInfoData.OriginalCustomResourcesInfo)
+	if err != nil {
+		resourceClient.Log.Error(err, "fails to init custom resources")
+		return Resources{}, err
+	}
+	podResource, err := pods.NewResource(ctx, resourceClient, namespace)
+	if err != nil {
+		resourceClient.Log.Error(err, "fails to init pods")
+		return Resources{}, err
+	}
 
 	return Resources{
-		deployments: deployResource,
-		cronjobs:    cronJobResource,
+		deployments:            deployResource,
+		cronjobs:               cronJobResource,
+		jobs:                   jobResource,
+		replicasets:            replicaSetResource,
+		replicationcontrollers: replicationControllerResource,
+		daemonsets:             daemonSetResource,
+		poddisruptionbudgets:   podDisruptionBudgetResource,
+		customresources:        customResource,
+		pods:                   podResource,
+		failedResources:        resourceClient.FailedResources,
+		checkpoint:             resourceClient.Checkpoint,
 	}, nil
 }
 
+type kindResource struct {
+	kind     string
+	resource resource.Resource
+}
+
+// getResourcesByKind returns the handled resources with their kind, in the
+// order in which they sleep and wake up. The resources not set are skipped,
+// so that the zero value of Resources has no resources.
+func (r Resources) getResourcesByKind() []kindResource {
+	resources := []kindResource{
+		{kind: "Deployment", resource: r.deployments},
+		{kind: "CronJob", resource: r.cronjobs},
+		{kind: "Job", resource: r.jobs},
+		{kind: "ReplicaSet", resource: r.replicasets},
+		{kind: "ReplicationController", resource: r.replicationcontrollers},
+		{kind: "DaemonSet", resource: r.daemonsets},
+		{kind: "PodDisruptionBudget", resource: r.poddisruptionbudgets},
+		{kind: "Pod", resource: r.pods},
+		{kind: "CustomResource", resource: r.customresources},
+	}
+	setResources := []kindResource{}
+	for _, res := range resources {
+		if res.resource != nil {
+			setResources = append(setResources, res)
+		}
+	}
+	return setResources
+}
+
 func (r Resources) hasResources() bool {
-	return r.deployments.HasResource() || r.cronjobs.HasResource()
+	for _, res := range r.getResourcesByKind() {
+		if res.resource.HasResource() {
+			return true
+		}
+	}
+	return false
 }
 
-func (r Resources) sleep(ctx context.Context) error {
-	if err := r.deployments.Sleep(ctx); err != nil {
-		return err
+// getResourceNames returns the names of the resources handled by the
+// SleepInfo, grouped by kind.
+func (r Resources) getResourceNames() map[string][]string {
+	resourceNames := map[string][]string{}
+	for _, res := range r.getResourcesByKind() {
+		if names := res.resource.GetResourceNames(); len(names) > 0 {
+			resourceNames[res.kind] = names
+		}
 	}
-	return r.cronjobs.Sleep(ctx)
+	return resourceNames
 }
 
-func (r Resources) wakeUp(ctx context.Context) error {
-	if err := r.deployments.WakeUp(ctx); err != nil {
-		return err
+// getResourceCounts returns the number of resources handled by the
+// SleepInfo, grouped by kind.
+func (r Resources) getResourceCounts() map[string]int {
+	resourceCounts := map[string]int{}
+	for kind, names := range r.getResourceNames() {
+		resourceCounts[kind] = len(names)
+	}
+	return resourceCounts
+}
+
+// getResourceResults returns the number of resources handled by the
+// operation, grouped by kind and by result. The custom resources are grouped
+// by their own kind. If the operation failed, only the skipped resources are
+// counted, since it is not known which of the others have been patched.
+func (r Resources) getResourceResults(operationErr error) map[string]map[string]int {
+	results := map[string]map[string]int{}
+	add := func(kind, result string, count int) {
+		if results[kind] == nil {
+			results[kind] = map[string]int{}
+		}
+		results[kind][result] += count
+	}
+	failedByKind := map[string]int{}
+	for _, failedResource := range r.getFailedResources() {
+		failedByKind[failedResource.Kind]++
+		add(failedResource.Kind, resourceFailed, 1)
+	}
+	if operationErr != nil {
+		return results
+	}
+	for kind, names := range r.getResourceNames() {
+		if kind != "CustomResource" {
+			add(kind, resourceSucceeded, len(names))
+			continue
+		}
+		for _, name := range names {
+			// the names of the custom resources are in the form Kind.group/name
+			kind, _, _ := strings.Cut(name, ".")
+			add(kind, resourceSucceeded, 1)
+		}
+	}
+	for kind, failed := range failedByKind {
+		if succeeded := results[kind][resourceSucceeded] - failed; succeeded > 0 {
+			results[kind][resourceSucceeded] = succeeded
+		} else {
+			delete(results[kind], resourceSucceeded)
+		}
 	}
-	return r.cronjobs.WakeUp(ctx)
+	return results
+}
+
+// getFailedResources returns the resources skipped by the operation because
+// their patch timed out or was rejected by an admission webhook.
+func (r Resources) getFailedResources() []kubegreenv1alpha1.FailedResource {
+	return r.failedResources.Get()
+}
+
+func (r Resources) sleep(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "sleep")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	for _, res := range r.getResourcesByKind() {
+		if err := res.resource.Sleep(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r Resources) wakeUp(ctx context.Context) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "wakeUp")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	for _, res := range r.getResourcesByKind() {
+		if err := res.resource.WakeUp(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (r Resources) getOriginalResourceInfoToSave() (map[string][]byte, error) {
@@ -71,6 +252,54 @@ func (r Resources) getOriginalResourceInfoToSave() (map[string][]byte, error) {
 		newData[originalCronjobStatusKey] = originalCronJobStatus
 	}
 
+	originalJobStatus, err := r.jobs.GetOriginalInfoToSave()
+	if err != nil {
+		return nil, err
+	}
+	if originalJobStatus != nil {
+		newData[originalJobStatusKey] = originalJobStatus
+	}
+
+	originalReplicaSetsReplicas, err := r.replicasets.GetOriginalInfoToSave()
+	if err != nil {
+		return nil, err
+	}
+	if originalReplicaSetsReplicas != nil {
+		newData[replicaSetsReplicasBeforeSleepKey] = originalReplicaSetsReplicas
+	}
+
+	originalReplicationControllersReplicas, err := r.replicationcontrollers.GetOriginalInfoToSave()
+	if err != nil {
+		return nil, err
+	}
+	if originalReplicationControllersReplicas != nil {
+		newData[replicationControllersReplicasBeforeSleepKey] = originalReplicationControllersReplicas
+	}
+
+	originalDaemonSetsInfo, err := r.daemonsets.GetOriginalInfoToSave()
+	if err != nil {
+		return nil, err
+	}
+	if originalDaemonSetsInfo != nil {
+		newData[originalDaemonSetsInfoKey] = originalDaemonSetsInfo
+	}
+
+	originalPodDisruptionBudgetsInfo, err := r.poddisruptionbudgets.GetOriginalInfoToSave()
+	if err != nil {
+		return nil, err
+	}
+	if originalPodDisruptionBudgetsInfo != nil {
+		newData[originalPodDisruptionBudgetsInfoKey] = originalPodDisruptionBudgetsInfo
+	}
+
+	originalCustomResourcesInfo, err := r.customresources.GetOriginalInfoToSave()
+	if err != nil {
+		return nil, err
+	}
+	if originalCustomResourcesInfo != nil {
+		newData[originalCustomResourcesInfoKey] = originalCustomResourcesInfo
+	}
+
 	return newData, nil
 }
 
@@ -87,5 +316,41 @@ func setOriginalResourceInfoToRestoreInSleepInfo(data map[string][]byte, sleepIn
 	}
 	sleepInfoData.OriginalCronJobStatus = originalCronJobStatusData
 
+	originalJobStatusData, err := jobs.GetOriginalInfoToRestore(data[originalJobStatusKey])
+	if err != nil {
+		return err
+	}
+	sleepInfoData.OriginalJobStatus = originalJobStatusData
+
+	originalReplicaSetsReplicasData, err := replicasets.GetOriginalInfoToRestore(data[replicaSetsReplicasBeforeSleepKey])
+	if err != nil {
+		return err
+	}
+	sleepInfoData.OriginalReplicaSetsReplicas = originalReplicaSetsReplicasData
+
+	originalReplicationControllersReplicasData, err := replicasets.GetOriginalInfoToRestore(data[replicationControllersReplicasBeforeSleepKey])
+	if err != nil {
+		return err
+	}
+	sleepInfoData.OriginalReplicationControllersReplicas = originalReplicationControllersReplicasData
+
+	originalDaemonSetsNodeSelectorData, err := daemonsets.GetOriginalInfoToRestore(data[originalDaemonSetsInfoKey])
+	if err != nil {
+		return err
+	}
+	sleepInfoData.OriginalDaemonSetsNodeSelector = originalDaemonSetsNodeSelectorData
+
+	originalPodDisruptionBudgetsData, err := poddisruptionbudgets.GetOriginalInfoToRestore(data[originalPodDisruptionBudgetsInfoKey])
+	if err != nil {
+		return err
+	}
+	sleepInfoData.OriginalPodDisruptionBudgets = originalPodDisruptionBudgetsData
+
+	originalCustomResourcesInfoData, err := customresources.GetOriginalInfoToRestore(data[originalCustomResourcesInfoKey])
+	if err != nil {
+		return err
+	}
+	sleepInfoData.OriginalCustomResourcesInfo = originalCustomResourcesInfoData
+
 	return nil
 }
@@ -2,13 +2,19 @@ package sleepinfo
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/kube-green/kube-green/api/v1alpha1"
 	"github.com/kube-green/kube-green/controllers/sleepinfo/cronjobs"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/customresources"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/daemonsets"
 	"github.com/kube-green/kube-green/controllers/sleepinfo/deployments"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/jobs"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/poddisruptionbudgets"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/replicasets"
 	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
 	"github.com/kube-green/kube-green/internal/testutil"
 
@@ -17,6 +23,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
@@ -70,6 +77,85 @@ func TestNewResources(t *testing.T) {
 		require.True(t, res.cronjobs.HasResource())
 	})
 
+	t.Run("retrieve jobs, replicasets and replication controllers data", func(t *testing.T) {
+		job := jobs.GetMock(jobs.MockSpec{
+			Name:      "job",
+			Namespace: namespace,
+		})
+		replicaSet := replicasets.GetMock(replicasets.MockSpec{
+			Name:      "replicaset",
+			Namespace: namespace,
+			Replicas:  &replica1,
+		})
+		replicationController := replicasets.GetMock(replicasets.MockSpec{
+			Name:      "replicationcontroller",
+			Namespace: namespace,
+			Replicas:  &replica1,
+			Kind:      "ReplicationController",
+		})
+		resClient := resource.ResourceClient{
+			Client: getFakeClient().WithRuntimeObjects(&job, &replicaSet, &replicationController).Build(),
+			Log:    zap.New(zap.UseDevMode(true)),
+			SleepInfo: &v1alpha1.SleepInfo{
+				Spec: v1alpha1.SleepInfoSpec{
+					SuspendJobs:        true,
+					SuspendReplicaSets: true,
+				},
+			},
+		}
+		res, err := NewResources(context.Background(), resClient, namespace, SleepInfoData{})
+		require.NoError(t, err)
+		require.False(t, res.deployments.HasResource())
+		require.False(t, res.cronjobs.HasResource())
+		require.Equal(t, map[string][]string{
+			"Job":                   {"job"},
+			"ReplicaSet":            {"replicaset"},
+			"ReplicationController": {"replicationcontroller"},
+		}, res.getResourceNames())
+	})
+
+	t.Run("retrieve daemonsets data", func(t *testing.T) {
+		daemonSet := daemonsets.GetMock(daemonsets.MockSpec{
+			Name:      "daemonset",
+			Namespace: namespace,
+		})
+		resClient := resource.ResourceClient{
+			Client: getFakeClient().WithRuntimeObjects(&daemonSet).Build(),
+			Log:    zap.New(zap.UseDevMode(true)),
+			SleepInfo: &v1alpha1.SleepInfo{
+				Spec: v1alpha1.SleepInfoSpec{
+					SuspendDaemonSets: true,
+				},
+			},
+		}
+		res, err := NewResources(context.Background(), resClient, namespace, SleepInfoData{})
+		require.NoError(t, err)
+		require.Equal(t, map[string][]string{
+			"DaemonSet": {"daemonset"},
+		}, res.getResourceNames())
+	})
+
+	t.Run("retrieve poddisruptionbudgets data", func(t *testing.T) {
+		pdb := poddisruptionbudgets.GetMock(poddisruptionbudgets.MockSpec{
+			Name:      "pdb",
+			Namespace: namespace,
+		})
+		resClient := resource.ResourceClient{
+			Client: getFakeClient().WithRuntimeObjects(&pdb).Build(),
+			Log:    zap.New(zap.UseDevMode(true)),
+			SleepInfo: &v1alpha1.SleepInfo{
+				Spec: v1alpha1.SleepInfoSpec{
+					SuspendPodDisruptionBudgets: true,
+				},
+			},
+		}
+		res, err := NewResources(context.Background(), resClient, namespace, SleepInfoData{})
+		require.NoError(t, err)
+		require.Equal(t, map[string][]string{
+			"PodDisruptionBudget": {"pdb"},
+		}, res.getResourceNames())
+	})
+
 	t.Run("throws if fetch deployments fails", func(t *testing.T) {
 		resClient := resource.ResourceClient{
 			Client: testutil.PossiblyErroringFakeCtrlRuntimeClient{
@@ -117,6 +203,11 @@ func TestHasResources(t *testing.T) {
 		name                     string
 		deploy                   bool
 		cronJob                  bool
+		job                      bool
+		replicaSet               bool
+		replicationController    bool
+		daemonSet                bool
+		customResource           bool
 		expectToPerformOperation bool
 	}{
 		{
@@ -139,6 +230,31 @@ func TestHasResources(t *testing.T) {
 			deploy:                   true,
 			expectToPerformOperation: true,
 		},
+		{
+			name:                     "some jobs",
+			job:                      true,
+			expectToPerformOperation: true,
+		},
+		{
+			name:                     "some replicasets",
+			replicaSet:               true,
+			expectToPerformOperation: true,
+		},
+		{
+			name:                     "some replication controllers",
+			replicationController:    true,
+			expectToPerformOperation: true,
+		},
+		{
+			name:                     "some daemonsets",
+			daemonSet:                true,
+			expectToPerformOperation: true,
+		},
+		{
+			name:                     "some custom resources",
+			customResource:           true,
+			expectToPerformOperation: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -158,11 +274,101 @@ func TestHasResources(t *testing.T) {
 				HasResourceResponseMock: test.cronJob,
 			})
 
+			resources.jobs = resource.GetResourceMock(resource.Mock{
+				HasResourceResponseMock: test.job,
+			})
+
+			resources.replicasets = resource.GetResourceMock(resource.Mock{
+				HasResourceResponseMock: test.replicaSet,
+			})
+
+			resources.replicationcontrollers = resource.GetResourceMock(resource.Mock{
+				HasResourceResponseMock: test.replicationController,
+			})
+
+			resources.daemonsets = resource.GetResourceMock(resource.Mock{
+				HasResourceResponseMock: test.daemonSet,
+			})
+
+			resources.customresources = resource.GetResourceMock(resource.Mock{
+				HasResourceResponseMock: test.customResource,
+			})
+
 			require.Equal(t, test.expectToPerformOperation, resources.hasResources())
 		})
 	}
 }
 
+func TestGetResourceNames(t *testing.T) {
+	t.Run("without resources", func(t *testing.T) {
+		r := newResourcesMock(t, resource.Mock{}, resource.Mock{})
+		require.Equal(t, map[string][]string{}, r.getResourceNames())
+		require.Equal(t, map[string]int{}, r.getResourceCounts())
+	})
+
+	t.Run("zero value", func(t *testing.T) {
+		r := Resources{}
+		require.False(t, r.hasResources())
+		require.Equal(t, map[string][]string{}, r.getResourceNames())
+		require.NoError(t, r.sleep(context.Background()))
+		require.NoError(t, r.wakeUp(context.Background()))
+	})
+
+	t.Run("with resources", func(t *testing.T) {
+		r := newResourcesMock(t, resource.Mock{
+			MockResourceNames: []string{"deploy1", "deploy2"},
+		}, resource.Mock{
+			MockResourceNames: []string{"cronjob1"},
+		})
+		require.Equal(t, map[string][]string{
+			"Deployment": {"deploy1", "deploy2"},
+			"CronJob":    {"cronjob1"},
+		}, r.getResourceNames())
+		require.Equal(t, map[string]int{
+			"Deployment": 2,
+			"CronJob":    1,
+		}, r.getResourceCounts())
+	})
+}
+
+func TestGetResourceResults(t *testing.T) {
+	getResources := func() Resources {
+		r := newResourcesMock(t, resource.Mock{
+			MockResourceNames: []string{"deploy1", "deploy2"},
+		}, resource.Mock{
+			MockResourceNames: []string{"cronjob1"},
+		})
+		r.customresources = resource.GetResourceMock(resource.Mock{
+			MockResourceNames: []string{"Kibana.kibana.k8s.elastic.co/kibana", "Elasticsearch.elasticsearch.k8s.elastic.co/es"},
+		})
+		r.failedResources = &resource.FailedResources{}
+		r.failedResources.Add(v1alpha1.FailedResource{Kind: "Deployment", Name: "deploy2", Reason: "context deadline exceeded"})
+		r.failedResources.Add(v1alpha1.FailedResource{Kind: "Kibana", Name: "kibana", Reason: "context deadline exceeded"})
+		return r
+	}
+
+	t.Run("without resources", func(t *testing.T) {
+		r := newResourcesMock(t, resource.Mock{}, resource.Mock{})
+		require.Equal(t, map[string]map[string]int{}, r.getResourceResults(nil))
+	})
+
+	t.Run("group the resources by kind and result", func(t *testing.T) {
+		require.Equal(t, map[string]map[string]int{
+			"Deployment":    {resourceSucceeded: 1, resourceFailed: 1},
+			"CronJob":       {resourceSucceeded: 1},
+			"Kibana":        {resourceFailed: 1},
+			"Elasticsearch": {resourceSucceeded: 1},
+		}, getResources().getResourceResults(nil))
+	})
+
+	t.Run("count only the failed resources if the operation fails", func(t *testing.T) {
+		require.Equal(t, map[string]map[string]int{
+			"Deployment": {resourceFailed: 1},
+			"Kibana":     {resourceFailed: 1},
+		}, getResources().getResourceResults(fmt.Errorf("some error")))
+	})
+}
+
 func TestResourcesSleep(t *testing.T) {
 	t.Run("correctly sleep all resources", func(t *testing.T) {
 		numberOfCalledDeploymentSleep := 0
@@ -188,6 +394,35 @@ func TestResourcesSleep(t *testing.T) {
 		require.Equal(t, 1, numberOfCalledCronJobSleep, "calls cron job sleep")
 	})
 
+	t.Run("sleep jobs, replicasets and replication controllers", func(t *testing.T) {
+		calledSleep := []string{}
+		getMock := func(kind string) resource.Resource {
+			return resource.GetResourceMock(resource.Mock{
+				MockSleep: func(ctx context.Context) error {
+					calledSleep = append(calledSleep, kind)
+					return nil
+				},
+			})
+		}
+
+		r := newResourcesMock(t, resource.Mock{}, resource.Mock{})
+		r.jobs = getMock("Job")
+		r.replicasets = getMock("ReplicaSet")
+		r.replicationcontrollers = getMock("ReplicationController")
+		require.NoError(t, r.sleep(context.Background()))
+		require.Equal(t, []string{"Job", "ReplicaSet", "ReplicationController"}, calledSleep)
+	})
+
+	t.Run("throws if job sleep fails", func(t *testing.T) {
+		r := newResourcesMock(t, resource.Mock{}, resource.Mock{})
+		r.jobs = resource.GetResourceMock(resource.Mock{
+			MockSleep: func(ctx context.Context) error {
+				return fmt.Errorf("some error")
+			},
+		})
+		require.EqualError(t, r.sleep(context.Background()), "some error")
+	})
+
 	t.Run("throws if deployment sleep fails", func(t *testing.T) {
 		deploymentMock := resource.Mock{
 			MockSleep: func(ctx context.Context) error {
@@ -240,6 +475,25 @@ func TestResourcesWakeUp(t *testing.T) {
 		require.Equal(t, 1, numberOfCalledCronJobWakeUp, "calls cron job wake up")
 	})
 
+	t.Run("wake up jobs, replicasets and replication controllers", func(t *testing.T) {
+		calledWakeUp := []string{}
+		getMock := func(kind string) resource.Resource {
+			return resource.GetResourceMock(resource.Mock{
+				MockWakeUp: func(ctx context.Context) error {
+					calledWakeUp = append(calledWakeUp, kind)
+					return nil
+				},
+			})
+		}
+
+		r := newResourcesMock(t, resource.Mock{}, resource.Mock{})
+		r.jobs = getMock("Job")
+		r.replicasets = getMock("ReplicaSet")
+		r.replicationcontrollers = getMock("ReplicationController")
+		require.NoError(t, r.wakeUp(context.Background()))
+		require.Equal(t, []string{"Job", "ReplicaSet", "ReplicationController"}, calledWakeUp)
+	})
+
 	t.Run("throws if deployment sleep fails", func(t *testing.T) {
 		deploymentMock := resource.Mock{
 			MockWakeUp: func(ctx context.Context) error {
@@ -322,6 +576,46 @@ func TestGetOriginalResourceInfoToSave(t *testing.T) {
 		require.Equal(t, 1, numberOfCalledCronJobInfoToSave, "calls cron job wake up")
 	})
 
+	t.Run("correctly get original resources for jobs, replicasets, replication controllers and daemonsets", func(t *testing.T) {
+		getMock := func(data string) resource.Resource {
+			return resource.GetResourceMock(resource.Mock{
+				MockOriginalInfoToSave: func() ([]byte, error) {
+					return []byte(data), nil
+				},
+			})
+		}
+
+		r := newResourcesMock(t, resource.Mock{}, resource.Mock{})
+		r.jobs = getMock(`[{"name":"job1"}]`)
+		r.replicasets = getMock(`[{"name":"rs1","replicas":2}]`)
+		r.replicationcontrollers = getMock(`[{"name":"rc1","replicas":3}]`)
+		r.daemonsets = getMock(`[{"name":"ds1"}]`)
+		r.poddisruptionbudgets = getMock(`[{"name":"pdb1","minAvailable":1}]`)
+		data, err := r.getOriginalResourceInfoToSave()
+		require.NoError(t, err)
+		require.Equal(t, map[string][]byte{
+			originalJobStatusKey:                         []byte(`[{"name":"job1"}]`),
+			replicaSetsReplicasBeforeSleepKey:            []byte(`[{"name":"rs1","replicas":2}]`),
+			replicationControllersReplicasBeforeSleepKey: []byte(`[{"name":"rc1","replicas":3}]`),
+			originalDaemonSetsInfoKey:                    []byte(`[{"name":"ds1"}]`),
+			originalPodDisruptionBudgetsInfoKey:          []byte(`[{"name":"pdb1","minAvailable":1}]`),
+		}, data)
+	})
+
+	t.Run("correctly get original resources for custom resources", func(t *testing.T) {
+		r := newResourcesMock(t, resource.Mock{}, resource.Mock{})
+		r.customresources = resource.GetResourceMock(resource.Mock{
+			MockOriginalInfoToSave: func() ([]byte, error) {
+				return []byte(`[{"key":"Kibana.kibana.k8s.elastic.co/kb1","original":{"found":true,"value":1}}]`), nil
+			},
+		})
+		data, err := r.getOriginalResourceInfoToSave()
+		require.NoError(t, err)
+		require.Equal(t, map[string][]byte{
+			originalCustomResourcesInfoKey: []byte(`[{"key":"Kibana.kibana.k8s.elastic.co/kb1","original":{"found":true,"value":1}}]`),
+		}, data)
+	})
+
 	t.Run("throws if deployment sleep fails", func(t *testing.T) {
 		deploymentMock := resource.Mock{
 			MockOriginalInfoToSave: func() ([]byte, error) {
@@ -391,8 +685,53 @@ func TestSetOriginalResourceInfoToRestoreInSleepInfo(t *testing.T) {
 		err := setOriginalResourceInfoToRestoreInSleepInfo(data, &sleepInfoData)
 		require.NoError(t, err)
 		require.Equal(t, SleepInfoData{
-			OriginalCronJobStatus:       map[string]bool{"cj1": true},
-			OriginalDeploymentsReplicas: map[string]int32{"deploy1": 5},
+			OriginalCronJobStatus:                  map[string]bool{"cj1": true},
+			OriginalDeploymentsReplicas:            map[string]int32{"deploy1": 5},
+			OriginalJobStatus:                      map[string]bool{},
+			OriginalReplicaSetsReplicas:            map[string]int32{},
+			OriginalReplicationControllersReplicas: map[string]int32{},
+			OriginalDaemonSetsNodeSelector:         map[string]map[string]string{},
+			OriginalPodDisruptionBudgets:           poddisruptionbudgets.OriginalBudgets{},
+			OriginalCustomResourcesInfo:            customresources.OriginalInfo{},
+		}, sleepInfoData)
+	})
+
+	t.Run("correctly set sleep info data for custom resources", func(t *testing.T) {
+		sleepInfoData := SleepInfoData{}
+		data := map[string][]byte{
+			originalCustomResourcesInfoKey: []byte(`[{"key":"Kibana.kibana.k8s.elastic.co/kb1","original":{"found":true,"value":1}}]`),
+		}
+		err := setOriginalResourceInfoToRestoreInSleepInfo(data, &sleepInfoData)
+		require.NoError(t, err)
+		require.Equal(t, customresources.OriginalInfo{
+			"Kibana.kibana.k8s.elastic.co/kb1": json.RawMessage(`{"found":true,"value":1}`),
+		}, sleepInfoData.OriginalCustomResourcesInfo)
+	})
+
+	t.Run("correctly set sleep info data for jobs, replicasets, replication controllers, daemonsets and poddisruptionbudgets", func(t *testing.T) {
+		sleepInfoData := SleepInfoData{}
+		data := map[string][]byte{
+			originalJobStatusKey:                         []byte(`[{"name":"job1","suspend":false}]`),
+			replicaSetsReplicasBeforeSleepKey:            []byte(`[{"name":"rs1","replicas":2}]`),
+			replicationControllersReplicasBeforeSleepKey: []byte(`[{"name":"rc1","replicas":3}]`),
+			originalDaemonSetsInfoKey:                    []byte(`[{"name":"ds1","nodeSelector":{"kubernetes.io/os":"linux"}}]`),
+			originalPodDisruptionBudgetsInfoKey:          []byte(`[{"name":"pdb1","minAvailable":1}]`),
+		}
+		err := setOriginalResourceInfoToRestoreInSleepInfo(data, &sleepInfoData)
+		require.NoError(t, err)
+		require.Equal(t, SleepInfoData{
+			OriginalCronJobStatus:                  map[string]bool{},
+			OriginalDeploymentsReplicas:            map[string]int32{},
+			OriginalJobStatus:                      map[string]bool{"job1": false},
+			OriginalReplicaSetsReplicas:            map[string]int32{"rs1": 2},
+			OriginalReplicationControllersReplicas: map[string]int32{"rc1": 3},
+			OriginalDaemonSetsNodeSelector: map[string]map[string]string{
+				"ds1": {"kubernetes.io/os": "linux"},
+			},
+			OriginalPodDisruptionBudgets: poddisruptionbudgets.OriginalBudgets{
+				"pdb1": {Name: "pdb1", MinAvailable: getPtr(intstr.FromInt(1))},
+			},
+			OriginalCustomResourcesInfo: customresources.OriginalInfo{},
 		}, sleepInfoData)
 	})
 }
@@ -400,8 +739,15 @@ func TestSetOriginalResourceInfoToRestoreInSleepInfo(t *testing.T) {
 func newResourcesMock(t *testing.T, deploymentsMock resource.Mock, cronjobsMock resource.Mock) Resources {
 	t.Helper()
 	return Resources{
-		deployments: resource.GetResourceMock(deploymentsMock),
-		cronjobs:    resource.GetResourceMock(cronjobsMock),
+		deployments:            resource.GetResourceMock(deploymentsMock),
+		cronjobs:               resource.GetResourceMock(cronjobsMock),
+		jobs:                   resource.GetResourceMock(resource.Mock{}),
+		replicasets:            resource.GetResourceMock(resource.Mock{}),
+		replicationcontrollers: resource.GetResourceMock(resource.Mock{}),
+		daemonsets:             resource.GetResourceMock(resource.Mock{}),
+		poddisruptionbudgets:   resource.GetResourceMock(resource.Mock{}),
+		customresources:        resource.GetResourceMock(resource.Mock{}),
+		pods:                   resource.GetResourceMock(resource.Mock{}),
 	}
 }
 
@@ -0,0 +1,56 @@
+package sleepinfo
+
+import (
+	"context"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PlaceholderLabel marks the placeholder pods created to warm up the nodes
+// before the wake up, which are not counted as running pods.
+const PlaceholderLabel = "kube-green.dev/placeholder"
+
+// getRunningPods returns the summary of the pods running in the namespace,
+// recorded in the operations history for the sleep reports. It returns nil
+// if the PodReader is not set. A failure is only logged.
+func (r *SleepInfoReconciler) getRunningPods(ctx context.Context, log logr.Logger, namespace string) *kubegreenv1alpha1.RunningPods {
+	if r.PodReader == nil {
+		return nil
+	}
+	pods := v1.PodList{}
+	if err := r.PodReader.List(ctx, &pods, client.InNamespace(namespace)); err != nil {
+		log.Error(err, "fails to list the running pods")
+		return nil
+	}
+	return GetRunningPods(pods.Items)
+}
+
+// GetRunningPods returns the summary of the pods which are running, or about
+// to run, i.e. not terminated nor deleted. The placeholder pods are skipped.
+func GetRunningPods(pods []v1.Pod) *kubegreenv1alpha1.RunningPods {
+	runningPods := &kubegreenv1alpha1.RunningPods{Requests: v1.ResourceList{}}
+	for _, pod := range pods {
+		if !isPodRunning(pod) {
+			continue
+		}
+		runningPods.Count++
+		for _, container := range pod.Spec.Containers {
+			for name, quantity := range container.Resources.Requests {
+				total := runningPods.Requests[name]
+				total.Add(quantity)
+				runningPods.Requests[name] = total
+			}
+		}
+	}
+	return runningPods
+}
+
+// isPodRunning returns true if the pod is running, or about to run, i.e. it
+// is not terminated nor deleted, and it is not a placeholder pod.
+func isPodRunning(pod v1.Pod) bool {
+	return pod.DeletionTimestamp == nil && pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed && pod.Labels[PlaceholderLabel] == ""
+}
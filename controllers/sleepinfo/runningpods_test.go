@@ -0,0 +1,62 @@
+package sleepinfo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestGetRunningPods(t *testing.T) {
+	getPod := func(name string, phase v1.PodPhase, requests ...v1.ResourceList) v1.Pod {
+		pod := v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "my-namespace"},
+			Status:     v1.PodStatus{Phase: phase},
+		}
+		for _, request := range requests {
+			pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{
+				Name:      "container",
+				Resources: v1.ResourceRequirements{Requests: request},
+			})
+		}
+		return pod
+	}
+	deleting := getPod("deleting", v1.PodRunning, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	deleting.DeletionTimestamp = &metav1.Time{}
+	deleting.Finalizers = []string{"kube-green.com/test"}
+	placeholder := getPod("placeholder", v1.PodRunning, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	placeholder.Labels = map[string]string{PlaceholderLabel: "true"}
+	pods := []v1.Pod{
+		getPod("running", v1.PodRunning,
+			v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m"), v1.ResourceMemory: resource.MustParse("128Mi")},
+			v1.ResourceList{v1.ResourceCPU: resource.MustParse("200m")},
+		),
+		getPod("pending", v1.PodPending, v1.ResourceList{v1.ResourceMemory: resource.MustParse("128Mi")}),
+		getPod("succeeded", v1.PodSucceeded, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}),
+		getPod("failed", v1.PodFailed, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}),
+		deleting,
+		placeholder,
+	}
+
+	runningPods := GetRunningPods(pods)
+	require.Equal(t, 2, runningPods.Count)
+	require.Equal(t, "300m", runningPods.Requests.Cpu().String())
+	require.Equal(t, "256Mi", runningPods.Requests.Memory().String())
+
+	t.Run("from the pod reader", func(t *testing.T) {
+		log := zap.New(zap.UseDevMode(true))
+		c := fake.NewClientBuilder().WithObjects(&pods[0], &pods[1]).Build()
+
+		r := SleepInfoReconciler{PodReader: c}
+		runningPods := r.getRunningPods(context.Background(), log, "my-namespace")
+		require.Equal(t, 2, runningPods.Count)
+
+		r = SleepInfoReconciler{}
+		require.Nil(t, r.getRunningPods(context.Background(), log, "my-namespace"))
+	})
+}
@@ -0,0 +1,139 @@
+package sleepinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/deployments"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+
+	"github.com/go-logr/logr"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ScaleWarningPath is the path of the webhook which warns who scales up a
+// workload put to sleep.
+const ScaleWarningPath = "/warn-apps-v1-scale"
+
+//+kubebuilder:webhook:path=/warn-apps-v1-scale,mutating=false,failurePolicy=ignore,sideEffects=None,groups=apps,resources=deployments;deployments/scale;statefulsets;statefulsets/scale,verbs=update,versions=v1,name=vscale.kube-green.com,admissionReviewVersions=v1
+
+// scaleWarningKinds are the kinds of the workloads warned, by resource.
+var scaleWarningKinds = map[string]string{
+	"deployments":  "Deployment",
+	"statefulsets": "StatefulSet",
+}
+
+// ScaleWarningHandler is the webhook which warns who scales up a Deployment
+// or a StatefulSet put to sleep by a SleepInfo still asleep, since it is
+// scaled down again at the next sleep. It never denies a request. The
+// requests of the system users, e.g. the controllers, are not warned.
+type ScaleWarningHandler struct {
+	// Client gets the workloads and lists the SleepInfo of their namespace.
+	Client client.Reader
+	Log    logr.Logger
+}
+
+var _ admission.Handler = &ScaleWarningHandler{}
+
+// Handle allows the request, with a warning if it scales up a workload put to
+// sleep.
+func (h *ScaleWarningHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	kind, ok := scaleWarningKinds[req.Resource.Resource]
+	if !ok || req.Operation != admissionv1.Update || strings.HasPrefix(req.UserInfo.Username, "system:") {
+		return admission.Allowed("")
+	}
+	log := h.Log.WithValues("kind", kind, "name", req.Name, "namespace", req.Namespace)
+	replicas, err := getSpecReplicas(req.Object.Raw)
+	if err != nil {
+		log.Error(err, "fails to decode the object")
+		return admission.Allowed("")
+	}
+	oldReplicas, err := getSpecReplicas(req.OldObject.Raw)
+	if err != nil {
+		log.Error(err, "fails to decode the old object")
+		return admission.Allowed("")
+	}
+	if replicas <= oldReplicas {
+		return admission.Allowed("")
+	}
+
+	warning, err := h.getWarning(ctx, kind, client.ObjectKey{Namespace: req.Namespace, Name: req.Name}, oldReplicas)
+	if err != nil {
+		log.Error(err, "fails to check the sleep of the workload")
+		return admission.Allowed("")
+	}
+	if warning == "" {
+		return admission.Allowed("")
+	}
+	return admission.Allowed("").WithWarnings(warning)
+}
+
+// getWarning returns the warning for the scale up of the workload, or an
+// empty string if it is not put to sleep by a SleepInfo asleep.
+func (h *ScaleWarningHandler) getWarning(ctx context.Context, kind string, key client.ObjectKey, oldReplicas int32) (string, error) {
+	var workload client.Object = &appsv1.Deployment{}
+	if kind == "StatefulSet" {
+		workload = &appsv1.StatefulSet{}
+	}
+	if err := h.Client.Get(ctx, key, workload); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	sleepInfos := kubegreenv1alpha1.SleepInfoList{}
+	if err := h.Client.List(ctx, &sleepInfos, client.InNamespace(key.Namespace)); err != nil {
+		return "", fmt.Errorf("fails to list sleepinfos: %s", err)
+	}
+	for _, sleepInfo := range sleepInfos.Items {
+		if sleepInfo.Status.OperationType != sleepOperation || !isWorkloadAsleep(&sleepInfo, kind, workload, oldReplicas) {
+			continue
+		}
+		warning := fmt.Sprintf("%s %s is asleep by SleepInfo %s of kube-green, which scales it down again at the next sleep.", kind, key.Name, sleepInfo.Name)
+		if sleepInfo.IsSleepEnforced() {
+			warning += " The sleepPolicy of the SleepInfo enforces the sleep of the namespace."
+		}
+		warning += fmt.Sprintf(" To keep the namespace awake, snooze the sleep: kubectl annotate sleepinfo %s -n %s %s=<RFC3339 time>", sleepInfo.Name, key.Namespace, SnoozeUntilAnnotation)
+		return warning, nil
+	}
+	return "", nil
+}
+
+// isWorkloadAsleep returns true if the workload, with the replicas before the
+// scale up, is put to sleep by the SleepInfo. The StatefulSets are put to
+// sleep only by the handlers of the custom resources.
+func isWorkloadAsleep(sleepInfo *kubegreenv1alpha1.SleepInfo, kind string, workload client.Object, replicas int32) bool {
+	if resource.IsExcluded(kind, workload, sleepInfo.GetExcludeRef()) {
+		return false
+	}
+	if kind == "StatefulSet" {
+		return sleepInfo.Spec.SuspendCustomResources && replicas == 0
+	}
+	if !sleepInfo.IsDeploymentsToSuspend() || !sleepInfo.IsManagedByClass(workload.GetLabels()) {
+		return false
+	}
+	if _, ok := workload.GetAnnotations()[deployments.SleepingAnnotation]; ok {
+		return true
+	}
+	return replicas <= sleepInfo.GetSleepReplicas(workload.GetLabels())
+}
+
+// getSpecReplicas returns the spec.replicas of the workload, or of its scale
+// subresource. The replicas not set are 0, as omitted by the Scale.
+func getSpecReplicas(raw []byte) (int32, error) {
+	obj := struct {
+		Spec struct {
+			Replicas *int32 `json:"replicas"`
+		} `json:"spec"`
+	}{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return 0, err
+	}
+	if obj.Spec.Replicas == nil {
+		return 0, nil
+	}
+	return *obj.Spec.Replicas, nil
+}
@@ -0,0 +1,174 @@
+package sleepinfo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/deployments"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestScaleWarningHandler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	namespace := "my-namespace"
+	asleepWarning := "Deployment api is asleep by SleepInfo working-hours of kube-green, which scales it down again at the next sleep. To keep the namespace awake, snooze the sleep: kubectl annotate sleepinfo working-hours -n my-namespace kube-green.dev/snooze-until=<RFC3339 time>"
+	getSleepInfo := func(operationType string, spec kubegreenv1alpha1.SleepInfoSpec) *kubegreenv1alpha1.SleepInfo {
+		return &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: "working-hours", Namespace: namespace},
+			Spec:       spec,
+			Status:     kubegreenv1alpha1.SleepInfoStatus{OperationType: operationType},
+		}
+	}
+	getDeployment := func(annotations map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: namespace, Annotations: annotations},
+		}
+	}
+	getRequest := func(resource, subResource string, oldReplicas, replicas int32) admission.Request {
+		return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Resource:    metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: resource},
+			SubResource: subResource,
+			Name:        "api",
+			Namespace:   namespace,
+			Operation:   admissionv1.Update,
+			UserInfo:    authenticationv1.UserInfo{Username: "jane@example.com"},
+			Object:      runtime.RawExtension{Raw: []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas))},
+			OldObject:   runtime.RawExtension{Raw: []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, oldReplicas))},
+		}}
+	}
+
+	tests := []struct {
+		name            string
+		objects         []client.Object
+		request         admission.Request
+		expectedWarning string
+	}{
+		{
+			name:            "warn the scale up of a deployment asleep",
+			objects:         []client.Object{getSleepInfo(sleepOperation, kubegreenv1alpha1.SleepInfoSpec{}), getDeployment(nil)},
+			request:         getRequest("deployments", "", 0, 3),
+			expectedWarning: asleepWarning,
+		},
+		{
+			name:            "warn the scale up by the scale subresource",
+			objects:         []client.Object{getSleepInfo(sleepOperation, kubegreenv1alpha1.SleepInfoSpec{}), getDeployment(nil)},
+			request:         getRequest("deployments", "scale", 0, 3),
+			expectedWarning: asleepWarning,
+		},
+		{
+			name: "warn the scale up of the canary kept by the sleep",
+			objects: []client.Object{
+				getSleepInfo(sleepOperation, kubegreenv1alpha1.SleepInfoSpec{}),
+				getDeployment(map[string]string{deployments.SleepingAnnotation: kubegreenv1alpha1.SleepStrategyKeepOne}),
+			},
+			request:         getRequest("deployments", "", 1, 3),
+			expectedWarning: asleepWarning,
+		},
+		{
+			name: "mention the enforced sleep",
+			objects: []client.Object{
+				getSleepInfo(sleepOperation, kubegreenv1alpha1.SleepInfoSpec{SleepPolicy: &kubegreenv1alpha1.SleepPolicy{Enforce: true}}),
+				getDeployment(nil),
+			},
+			request:         getRequest("deployments", "", 0, 3),
+			expectedWarning: "Deployment api is asleep by SleepInfo working-hours of kube-green, which scales it down again at the next sleep. The sleepPolicy of the SleepInfo enforces the sleep of the namespace. To keep the namespace awake, snooze the sleep: kubectl annotate sleepinfo working-hours -n my-namespace kube-green.dev/snooze-until=<RFC3339 time>",
+		},
+		{
+			name: "warn the scale up of a statefulset put to sleep by the custom resources",
+			objects: []client.Object{
+				getSleepInfo(sleepOperation, kubegreenv1alpha1.SleepInfoSpec{SuspendCustomResources: true}),
+				&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: namespace}},
+			},
+			request:         getRequest("statefulsets", "", 0, 1),
+			expectedWarning: "StatefulSet api is asleep by SleepInfo working-hours of kube-green, which scales it down again at the next sleep. To keep the namespace awake, snooze the sleep: kubectl annotate sleepinfo working-hours -n my-namespace kube-green.dev/snooze-until=<RFC3339 time>",
+		},
+		{
+			name: "not warn the statefulsets not put to sleep",
+			objects: []client.Object{
+				getSleepInfo(sleepOperation, kubegreenv1alpha1.SleepInfoSpec{}),
+				&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: namespace}},
+			},
+			request: getRequest("statefulsets", "", 0, 1),
+		},
+		{
+			name:    "not warn if the namespace is awake",
+			objects: []client.Object{getSleepInfo(wakeUpOperation, kubegreenv1alpha1.SleepInfoSpec{}), getDeployment(nil)},
+			request: getRequest("deployments", "", 0, 3),
+		},
+		{
+			name: "not warn the excluded deployments",
+			objects: []client.Object{
+				getSleepInfo(sleepOperation, kubegreenv1alpha1.SleepInfoSpec{ExcludeRef: []kubegreenv1alpha1.ExcludeRef{{APIVersion: "apps/v1", Kind: "Deployment", Name: "api"}}}),
+				getDeployment(nil),
+			},
+			request: getRequest("deployments", "", 0, 3),
+		},
+		{
+			name:    "not warn the scale down",
+			objects: []client.Object{getSleepInfo(sleepOperation, kubegreenv1alpha1.SleepInfoSpec{}), getDeployment(nil)},
+			request: getRequest("deployments", "", 3, 0),
+		},
+		{
+			name:    "not warn the deployments scaled up during the sleep",
+			objects: []client.Object{getSleepInfo(sleepOperation, kubegreenv1alpha1.SleepInfoSpec{}), getDeployment(nil)},
+			request: getRequest("deployments", "", 2, 3),
+		},
+		{
+			name:    "not warn the system users",
+			objects: []client.Object{getSleepInfo(sleepOperation, kubegreenv1alpha1.SleepInfoSpec{}), getDeployment(nil)},
+			request: func() admission.Request {
+				req := getRequest("deployments", "", 0, 3)
+				req.UserInfo.Username = "system:serviceaccount:kube-green:controller-manager"
+				return req
+			}(),
+		},
+		{
+			name:    "not warn if the deployment is not found",
+			objects: []client.Object{getSleepInfo(sleepOperation, kubegreenv1alpha1.SleepInfoSpec{})},
+			request: getRequest("deployments", "", 0, 3),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := &ScaleWarningHandler{
+				Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(test.objects...).Build(),
+				Log:    logr.Discard(),
+			}
+
+			response := h.Handle(context.Background(), test.request)
+			require.True(t, response.Allowed)
+			if test.expectedWarning == "" {
+				require.Empty(t, response.Warnings)
+				return
+			}
+			require.Equal(t, []string{test.expectedWarning}, response.Warnings)
+		})
+	}
+
+	t.Run("allow the invalid objects", func(t *testing.T) {
+		h := &ScaleWarningHandler{Client: fake.NewClientBuilder().WithScheme(scheme).Build(), Log: logr.Discard()}
+		req := getRequest("deployments", "", 0, 3)
+		req.Object.Raw = []byte("not json")
+
+		response := h.Handle(context.Background(), req)
+		require.True(t, response.Allowed)
+		require.Empty(t, response.Warnings)
+	})
+}
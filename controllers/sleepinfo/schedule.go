@@ -1,62 +1,48 @@
 package sleepinfo
 
 import (
-	"fmt"
 	"time"
 
-	"github.com/robfig/cron/v3"
+	"github.com/kube-green/kube-green/pkg/schedule"
 )
 
 func (r *SleepInfoReconciler) getNextSchedule(data SleepInfoData, now time.Time) (bool, time.Time, time.Duration, error) {
 	scheduleDelta := time.Duration(r.SleepDelta) * time.Second
-	sched, err := getCronParsed(data.CurrentOperationSchedule)
+	result, err := schedule.Next(schedule.State{
+		CurrentOperationSchedule: data.CurrentOperationSchedule,
+		NextOperationSchedule:    data.NextOperationSchedule,
+		LastSchedule:             data.LastSchedule,
+		ExecuteMissedOperations:  r.ExecuteMissedOperations,
+	}, now, scheduleDelta)
 	if err != nil {
-		return false, time.Time{}, 0, fmt.Errorf("current schedule not valid: %s", err)
+		return false, time.Time{}, 0, &ScheduleError{Err: err}
 	}
-
-	lastSchedule := data.LastSchedule
-
-	// subtract delta seconds because if now is after current schedule we skip
-	// the current schedule
-	var earliestTime = now.Add(-scheduleDelta)
-	if !lastSchedule.IsZero() {
-		earliestTime = lastSchedule
+	if result.IsMissed {
+		r.Metrics.MissedOperations.WithLabelValues(data.CurrentOperationType).Inc()
 	}
-	nextSchedule := sched.Next(earliestTime)
-
-	if earliestTime == lastSchedule && nextSchedule.Before(now) && !isTimeInDelta(nextSchedule, now, scheduleDelta) {
-		nextSchedule = sched.Next(now.Add(-scheduleDelta))
+	if result.IsToExecute {
+		r.observeScheduleDelay(data.CurrentOperationType, result.ScheduledAt, now, scheduleDelta)
 	}
-	isToExecute := isTimeInDelta(now, nextSchedule, scheduleDelta)
+	r.Metrics.RequeueAfter.Observe(result.RequeueAfter.Seconds())
+	r.Log.Info("is time to execute", "execute", result.IsToExecute, "next", result.NextSchedule, "last", data.LastSchedule, "now", now)
 
-	var requeueAfter time.Duration
-	if isToExecute {
-		nextOpSched, err := getCronParsed(data.NextOperationSchedule)
-		if err != nil {
-			return false, time.Time{}, 0, fmt.Errorf("next op schedule not valid: %s", err)
-		}
-		nextSchedule = nextOpSched.Next(now.Add(scheduleDelta))
-	}
-	requeueAfter = getRequeueAfter(nextSchedule, now)
-	r.Log.Info("is time to execute", "execute", isToExecute, "next", nextSchedule, "last", lastSchedule, "now", now)
+	return result.IsToExecute, result.NextSchedule, result.RequeueAfter, nil
+}
 
-	return isToExecute, nextSchedule, requeueAfter, nil
+// observeScheduleDelay observes the delay of the operation executed at now,
+// scheduled at scheduledAt. The operations executed in advance, within the
+// schedule delta, have no delay.
+func (r *SleepInfoReconciler) observeScheduleDelay(operationType string, scheduledAt, now time.Time, scheduleDelta time.Duration) {
+	delay := now.Sub(scheduledAt)
+	if delay < 0 {
+		delay = 0
+	}
+	r.Metrics.ScheduleDelay.WithLabelValues(operationType).Observe(delay.Seconds())
+	if delay > scheduleDelta {
+		r.Metrics.LateOperations.WithLabelValues(operationType).Inc()
+	}
 }
 
 func getRequeueAfter(schedule, now time.Time) time.Duration {
 	return schedule.Sub(now)
 }
-
-func getCronParsed(schedule string) (cron.Schedule, error) {
-	return cron.ParseStandard(schedule)
-}
-
-func isTimeInDelta(t1, t2 time.Time, delta time.Duration) bool {
-	var diffInMs int64
-	if t1.Before(t2) {
-		diffInMs = t2.Sub(t1).Milliseconds()
-	} else {
-		diffInMs = t1.Sub(t2).Milliseconds()
-	}
-	return diffInMs <= delta.Milliseconds()
-}
@@ -1,11 +1,15 @@
 package sleepinfo
 
 import (
-	"fmt"
 	"testing"
 	"time"
 
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
@@ -15,6 +19,7 @@ func TestSchedule(t *testing.T) {
 	sleepInfoReconciler := SleepInfoReconciler{
 		Log:        testLogger,
 		SleepDelta: 60,
+		Metrics:    metrics.SetupMetricsOrDie("kube_green"),
 	}
 
 	type expected struct {
@@ -25,10 +30,11 @@ func TestSchedule(t *testing.T) {
 	}
 
 	tests := []struct {
-		name     string
-		now      string
-		data     SleepInfoData
-		expected expected
+		name                    string
+		now                     string
+		data                    SleepInfoData
+		executeMissedOperations bool
+		expected                expected
 	}{
 		{
 			name: "fails if current schedule is invalid",
@@ -372,11 +378,102 @@ func TestSchedule(t *testing.T) {
 				requeueAfter: 4*time.Minute + 1*time.Millisecond,
 			},
 		},
+		{
+			name: "missed sleep across the midnight, not executed without executeMissedOperations",
+			now:  "2021-03-24T02:00:00.000Z",
+			data: SleepInfoData{
+				CurrentOperationSchedule: "00 22 * * *",
+				NextOperationSchedule:    "00 06 * * *",
+				LastSchedule:             getTime(t, "2021-03-23T06:00:00.000Z"),
+			},
+			expected: expected{
+				isToExecute:  false,
+				nextSchedule: "2021-03-24T22:00:00Z",
+				requeueAfter: 20 * time.Hour,
+			},
+		},
+		{
+			name: "missed sleep across the midnight, window still open",
+			now:  "2021-03-24T02:00:00.000Z",
+			data: SleepInfoData{
+				CurrentOperationSchedule: "00 22 * * *",
+				NextOperationSchedule:    "00 06 * * *",
+				LastSchedule:             getTime(t, "2021-03-23T06:00:00.000Z"),
+			},
+			executeMissedOperations: true,
+			expected: expected{
+				isToExecute:  true,
+				nextSchedule: "2021-03-24T06:00:00Z",
+				requeueAfter: 4 * time.Hour,
+			},
+		},
+		{
+			name: "missed sleep across the midnight, window closed",
+			now:  "2021-03-24T07:00:00.000Z",
+			data: SleepInfoData{
+				CurrentOperationSchedule: "00 22 * * *",
+				NextOperationSchedule:    "00 06 * * *",
+				LastSchedule:             getTime(t, "2021-03-23T06:00:00.000Z"),
+			},
+			executeMissedOperations: true,
+			expected: expected{
+				isToExecute:  false,
+				nextSchedule: "2021-03-24T22:00:00Z",
+				requeueAfter: 15 * time.Hour,
+			},
+		},
+		{
+			name: "missed sleep across the midnight on friday, window open during the weekend",
+			// Sunday
+			now: "2021-03-28T10:00:00.000Z",
+			data: SleepInfoData{
+				CurrentOperationSchedule: "00 20 * * 1-5",
+				NextOperationSchedule:    "00 08 * * 1-5",
+				LastSchedule:             getTime(t, "2021-03-26T08:00:00.000Z"),
+			},
+			executeMissedOperations: true,
+			expected: expected{
+				isToExecute:  true,
+				nextSchedule: "2021-03-29T08:00:00Z",
+				requeueAfter: 22 * time.Hour,
+			},
+		},
+		{
+			name: "missed wake up, window still open",
+			now:  "2021-03-24T10:00:00.000Z",
+			data: SleepInfoData{
+				CurrentOperationSchedule: "00 08 * * *",
+				NextOperationSchedule:    "00 20 * * *",
+				LastSchedule:             getTime(t, "2021-03-23T20:00:00.000Z"),
+			},
+			executeMissedOperations: true,
+			expected: expected{
+				isToExecute:  true,
+				nextSchedule: "2021-03-24T20:00:00Z",
+				requeueAfter: 10 * time.Hour,
+			},
+		},
+		{
+			name: "same next and current schedule - missed sleep is not executed",
+			now:  "2021-03-24T02:00:00.000Z",
+			data: SleepInfoData{
+				CurrentOperationSchedule: "00 22 * * *",
+				NextOperationSchedule:    "00 22 * * *",
+				LastSchedule:             getTime(t, "2021-03-22T22:00:00.000Z"),
+			},
+			executeMissedOperations: true,
+			expected: expected{
+				isToExecute:  false,
+				nextSchedule: "2021-03-24T22:00:00Z",
+				requeueAfter: 20 * time.Hour,
+			},
+		},
 	}
 
 	for _, test := range tests {
 		test := test // necessary to ensure the correct value is passed to the closure
 		t.Run(test.name, func(t *testing.T) {
+			sleepInfoReconciler.ExecuteMissedOperations = test.executeMissedOperations
 			isToExecute, nextSchedule, requeueAfter, err := sleepInfoReconciler.getNextSchedule(test.data, getTime(t, test.now))
 
 			expected := test.expected
@@ -394,65 +491,57 @@ func TestSchedule(t *testing.T) {
 	}
 }
 
-func TestTestIsTimeInDeltaMs(t *testing.T) {
-	now := time.Now()
-	tests := []struct {
-		name     string
-		t1       time.Time
-		t2       time.Time
-		expected bool
-		delta    time.Duration
-	}{
-		{
-			name:     "t1 > t2 30s - delta 60s",
-			t1:       now,
-			t2:       now.Add(60 * time.Second),
-			delta:    time.Second * 60,
-			expected: true,
-		},
-		{
-			name:     "t1 > t2 1ms - delta 1ms",
-			t1:       now,
-			t2:       now.Add(1 * time.Millisecond),
-			delta:    time.Millisecond * 1,
-			expected: true,
-		},
-		{
-			name:     "t1 > t2 31s - delta 30s",
-			t1:       now,
-			t2:       now.Add(31 * time.Second),
-			delta:    time.Second * 30,
-			expected: false,
-		},
-		{
-			name:     "t1 > t2 30s - delta 60s",
-			t1:       now.Add(60 * time.Second),
-			t2:       now,
-			delta:    time.Second * 60,
-			expected: true,
-		},
-		{
-			name:     "t1 < t2 31s - delta 30s",
-			t1:       now.Add(31 * time.Second),
-			t2:       now,
-			delta:    time.Second * 30,
-			expected: false,
-		},
-		{
-			name:     "t1 > t2 1s - delta 1s",
-			t1:       now.Add(1 * time.Second),
-			t2:       now,
-			delta:    time.Second * 1,
-			expected: true,
-		},
-	}
-	for _, test := range tests {
-		test := test // necessary to ensure the correct value is passed to the closure
-		t.Run(fmt.Sprintf("name, %s", test.name), func(t *testing.T) {
-			output := isTimeInDelta(test.t1, test.t2, test.delta)
-			require.Equal(t, test.expected, output)
-		})
+func TestScheduleMetrics(t *testing.T) {
+	sleepInfoReconciler := SleepInfoReconciler{
+		Log:        zap.New(zap.UseDevMode(true)),
+		SleepDelta: 60,
+		Metrics:    metrics.SetupMetricsOrDie("kube_green"),
+
+		ExecuteMissedOperations: true,
 	}
+	m := sleepInfoReconciler.Metrics
+
+	t.Run("operation executed in time", func(t *testing.T) {
+		_, _, requeueAfter, err := sleepInfoReconciler.getNextSchedule(SleepInfoData{
+			CurrentOperationType:     sleepOperation,
+			CurrentOperationSchedule: "00 20 * * *",
+			NextOperationSchedule:    "00 08 * * *",
+			LastSchedule:             getTime(t, "2021-03-23T08:00:00.000Z"),
+		}, getTime(t, "2021-03-23T20:00:30.000Z"))
+		require.NoError(t, err)
+		require.Equal(t, 11*time.Hour+59*time.Minute+30*time.Second, requeueAfter)
+
+		require.Equal(t, 1, testutil.CollectAndCount(m.ScheduleDelay))
+		require.Equal(t, 1, testutil.CollectAndCount(m.RequeueAfter))
+		require.Equal(t, 0, testutil.CollectAndCount(m.LateOperations))
+		require.Equal(t, 0, testutil.CollectAndCount(m.MissedOperations))
+	})
+
+	t.Run("operation executed late", func(t *testing.T) {
+		_, _, _, err := sleepInfoReconciler.getNextSchedule(SleepInfoData{
+			CurrentOperationType:     sleepOperation,
+			CurrentOperationSchedule: "00 20 * * *",
+			NextOperationSchedule:    "00 08 * * *",
+			LastSchedule:             getTime(t, "2021-03-23T08:00:00.000Z"),
+		}, getTime(t, "2021-03-23T22:00:00.000Z"))
+		require.NoError(t, err)
+
+		require.Equal(t, float64(1), testutil.ToFloat64(m.LateOperations.WithLabelValues(sleepOperation)))
+		require.Equal(t, 0, testutil.CollectAndCount(m.MissedOperations))
+	})
+
+	t.Run("operation missed", func(t *testing.T) {
+		_, _, _, err := sleepInfoReconciler.getNextSchedule(SleepInfoData{
+			CurrentOperationType:     wakeUpOperation,
+			CurrentOperationSchedule: "00 08 * * *",
+			NextOperationSchedule:    "00 20 * * *",
+			LastSchedule:             getTime(t, "2021-03-22T20:00:00.000Z"),
+		}, getTime(t, "2021-03-23T22:00:00.000Z"))
+		require.NoError(t, err)
+
+		require.Equal(t, float64(1), testutil.ToFloat64(m.MissedOperations.WithLabelValues(wakeUpOperation)))
+		require.Equal(t, float64(1), testutil.ToFloat64(m.LateOperations.WithLabelValues(sleepOperation)))
+	})
 }
 
 func getTime(t *testing.T, mockNowRaw string) time.Time {
@@ -461,3 +550,45 @@ func getTime(t *testing.T, mockNowRaw string) time.Time {
 	require.NoError(t, err)
 	return now
 }
+
+func TestRecordDSTAdjustments(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	sleepInfoReconciler := SleepInfoReconciler{
+		Log:        zap.New(zap.UseDevMode(true)),
+		SleepDelta: 60,
+		Recorder:   recorder,
+	}
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{}
+
+	t.Run("sleep moved by the DST change", func(t *testing.T) {
+		sleepInfoReconciler.recordDSTAdjustments(sleepInfoReconciler.Log, sleepInfo, SleepInfoData{
+			CurrentOperationType:     sleepOperation,
+			CurrentOperationSchedule: "CRON_TZ=Europe/Rome 30 2 * * *",
+			NextOperationSchedule:    "CRON_TZ=Europe/Rome 00 8 * * *",
+		}, time.Date(2021, 3, 28, 1, 0, 0, 0, time.UTC))
+
+		require.Len(t, recorder.Events, 1)
+		require.Equal(t, "Normal DSTAdjusted SLEEP operation: 2021-03-28 02:30 is skipped by the DST change, moved to 2021-03-28 03:00 CEST", <-recorder.Events)
+	})
+
+	t.Run("repeated sleep not executed again", func(t *testing.T) {
+		sleepInfoReconciler.recordDSTAdjustments(sleepInfoReconciler.Log, sleepInfo, SleepInfoData{
+			CurrentOperationType:     sleepOperation,
+			CurrentOperationSchedule: "CRON_TZ=Europe/Rome 30 2 * * *",
+			NextOperationSchedule:    "CRON_TZ=Europe/Rome 30 2 * * *",
+		}, time.Date(2021, 10, 31, 0, 30, 0, 0, time.UTC))
+
+		require.Len(t, recorder.Events, 1)
+		require.Equal(t, "Normal DSTAdjusted SLEEP operation: 2021-10-31 02:30 is repeated by the DST change, executed only at 2021-10-31 02:30 CEST", <-recorder.Events)
+	})
+
+	t.Run("no DST change", func(t *testing.T) {
+		sleepInfoReconciler.recordDSTAdjustments(sleepInfoReconciler.Log, sleepInfo, SleepInfoData{
+			CurrentOperationType:     sleepOperation,
+			CurrentOperationSchedule: "CRON_TZ=Europe/Rome 00 20 * * *",
+			NextOperationSchedule:    "CRON_TZ=Europe/Rome 00 8 * * *",
+		}, time.Date(2021, 3, 28, 18, 0, 0, 0, time.UTC))
+
+		require.Empty(t, recorder.Events)
+	})
+}
@@ -2,14 +2,17 @@ package sleepinfo
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/internal/tracing"
 
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -25,10 +28,6 @@ func (r *SleepInfoReconciler) getSecret(ctx context.Context, secretName, namespa
 	return secret, nil
 }
 
-func getSecretName(name string) string {
-	return fmt.Sprintf("sleepinfo-%s", name)
-}
-
 func (r SleepInfoReconciler) upsertSecret(
 	ctx context.Context,
 	logger logr.Logger,
@@ -38,23 +37,33 @@ func (r SleepInfoReconciler) upsertSecret(
 	secret *v1.Secret,
 	sleepInfoData SleepInfoData,
 	resources Resources,
-) error {
+) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "upsertSecret")
+	defer func() { tracing.EndSpan(span, err) }()
+
 	logger.Info("update secret", "name", secretName)
 
+	isController := true
+
 	var newSecret = &v1.Secret{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Secret",
 			APIVersion: "v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      secretName,
-			Namespace: namespace,
+			Name:        secretName,
+			Namespace:   namespace,
+			Labels:      r.getSecretLabels(),
+			Annotations: r.getSecretAnnotations(),
+			// the secret is deleted with the SleepInfo by the garbage collector.
 			OwnerReferences: []metav1.OwnerReference{
 				{
-					APIVersion: kubegreenv1alpha1.GroupVersion.String(),
-					Kind:       "SleepInfo",
-					Name:       sleepInfo.Name,
-					UID:        sleepInfo.UID,
+					APIVersion:         kubegreenv1alpha1.GroupVersion.String(),
+					Kind:               "SleepInfo",
+					Name:               sleepInfo.Name,
+					UID:                sleepInfo.UID,
+					Controller:         &isController,
+					BlockOwnerDeletion: &isController,
 				},
 			},
 		},
@@ -64,14 +73,48 @@ func (r SleepInfoReconciler) upsertSecret(
 	newSecret.StringData[lastScheduleKey] = now.Format(time.RFC3339)
 	if resources.hasResources() {
 		newSecret.StringData[lastOperationKey] = sleepInfoData.CurrentOperationType
+		// the operation is pending until its end, so that if it is
+		// interrupted, e.g. by a leader change, it is resumed by the next
+		// reconcile instead of being executed again from scratch.
+		newSecret.StringData[pendingOperationKey] = getOperationID(sleepInfoData, now)
+	}
+	if resources.hasResources() && resources.checkpoint != nil {
+		// the progress of the operation, so that the resumed operation
+		// skips the resources already patched.
+		checkpoint, err := resources.checkpoint.Get().Marshal()
+		if err != nil {
+			logger.Error(err, "failed to get the checkpoint of the operation")
+			return err
+		}
+		newSecret.StringData[operationCheckpointKey] = string(checkpoint)
 	}
 
-	if resources.hasResources() && sleepInfoData.IsSleepOperation() {
+	// the resumed sleep keeps the state saved before its first patch,
+	// instead of computing it again from the resources already put to sleep.
+	// The sleep interrupted by a previous version of kube-green has no
+	// checkpoint, so its state is computed again.
+	isSleepResumed := sleepInfoData.IsSleepOperation() && sleepInfoData.PendingOperationID != "" && secret != nil && len(secret.Data[operationCheckpointKey]) != 0
+	if resources.hasResources() && sleepInfoData.IsSleepOperation() && !isSleepResumed {
 		data, err := resources.getOriginalResourceInfoToSave()
 		if err != nil {
 			logger.Error(err, "failed to get original resource info to save")
 			return err
 		}
+		data, err = r.StateCodec.encode(data)
+		if err != nil {
+			logger.Error(err, "failed to encode original resource info to save")
+			return err
+		}
+		newSecret.Data = data
+	}
+	if resources.hasResources() && (sleepInfoData.IsWakeUpOperation() || isSleepResumed) {
+		// the state is kept until the end of the wake up, so that an
+		// interrupted wake up restores the resources not yet woken up.
+		data, err := r.StateCodec.encode(getStoredState(secret))
+		if err != nil {
+			logger.Error(err, "failed to encode original resource info to keep")
+			return err
+		}
 		newSecret.Data = data
 	}
 
@@ -81,6 +124,10 @@ func (r SleepInfoReconciler) upsertSecret(
 		}
 		logger.Info("secret created")
 	} else {
+		// Use the resource version of the read secret, so that if it has been
+		// changed in the meanwhile (e.g. by another replica of the controller
+		// during a leader change) the update fails instead of overwriting it.
+		newSecret.ResourceVersion = secret.ResourceVersion
 		if err := r.Client.Update(ctx, newSecret); err != nil {
 			return err
 		}
@@ -88,3 +135,81 @@ func (r SleepInfoReconciler) upsertSecret(
 	}
 	return nil
 }
+
+// getSecretLabels returns the labels of the secret: the configured labels and
+// the label of the manager of the secret.
+func (r SleepInfoReconciler) getSecretLabels() map[string]string {
+	labels := map[string]string{
+		managedByLabel: fieldManagerName,
+	}
+	if r.StateSecret != nil {
+		for key, value := range r.StateSecret.Labels {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+func (r SleepInfoReconciler) getSecretAnnotations() map[string]string {
+	if r.StateSecret == nil {
+		return nil
+	}
+	return r.StateSecret.Annotations
+}
+
+// getOperationID returns the id of the operation: the id of the pending one,
+// if it is resumed, otherwise a new one from the operation type and now.
+func getOperationID(sleepInfoData SleepInfoData, now time.Time) string {
+	if sleepInfoData.PendingOperationID != "" {
+		return sleepInfoData.PendingOperationID
+	}
+	return fmt.Sprintf("%s-%d", sleepInfoData.CurrentOperationType, now.Unix())
+}
+
+// getStoredState returns the original state of the resources, decoded, in the
+// data of the secret.
+func getStoredState(secret *v1.Secret) map[string][]byte {
+	state := map[string][]byte{}
+	if secret == nil {
+		return state
+	}
+	for key, value := range secret.Data {
+		if !isStateMetadataKey(key) && key != stateEncryptionKey {
+			state[key] = value
+		}
+	}
+	return state
+}
+
+// getStoredStateKeys returns the keys of the secret with the original state of
+// the resources, to remove at the end of the wake up.
+func getStoredStateKeys(secret *v1.Secret) []string {
+	keys := []string{stateChecksumKey, stateEncryptionKey}
+	for key := range getStoredState(secret) {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// completeOperation removes the pendingOperationKey and the checkpoint from
+// the secret at the end of the operation, together with the keys to remove. It
+// is called only if the operation succeeds: the failed one stays pending, with
+// its state, and is resumed by the retry. A failure is logged, since the
+// pending operation is resumed and completed by the next reconcile.
+func (r *SleepInfoReconciler) completeOperation(ctx context.Context, logger logr.Logger, secretName, namespace string, keysToRemove []string) {
+	data := map[string]interface{}{pendingOperationKey: nil, operationCheckpointKey: nil}
+	for _, key := range keysToRemove {
+		data[key] = nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		logger.Error(err, "fails to complete the operation")
+		return
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: namespace},
+	}
+	if err := r.Client.Patch(ctx, secret, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		logger.Error(err, "fails to complete the operation", "secret", secretName)
+	}
+}
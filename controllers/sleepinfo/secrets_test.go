@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	configv1alpha1 "github.com/kube-green/kube-green/api/config/v1alpha1"
 	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
 	"github.com/kube-green/kube-green/controllers/sleepinfo/deployments"
 	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -111,14 +113,20 @@ func TestUpsertSecrets(t *testing.T) {
 			UID:  "sleepinfo-uid",
 		},
 	}
+	isController := true
 	ownerRefs := []metav1.OwnerReference{
 		{
-			APIVersion: "kube-green.com/v1alpha1",
-			Kind:       "SleepInfo",
-			Name:       sleepInfo.Name,
-			UID:        sleepInfo.UID,
+			APIVersion:         "kube-green.com/v1alpha1",
+			Kind:               "SleepInfo",
+			Name:               sleepInfo.Name,
+			UID:                sleepInfo.UID,
+			Controller:         &isController,
+			BlockOwnerDeletion: &isController,
 		},
 	}
+	labels := map[string]string{
+		"app.kubernetes.io/managed-by": "kube-green",
+	}
 
 	t.Run("insert and update secret - sleep and wake up", func(t *testing.T) {
 		client := &testutil.PossiblyErroringFakeCtrlRuntimeClient{
@@ -158,12 +166,14 @@ func TestUpsertSecrets(t *testing.T) {
 				Namespace:       namespace,
 				ResourceVersion: "1",
 				OwnerReferences: ownerRefs,
+				Labels:          labels,
 			},
-			Data: map[string][]byte{
+			Data: withStateChecksum(map[string][]byte{
 				lastOperationKey:       []byte(sleepOperation),
 				lastScheduleKey:        []byte(now.Format(time.RFC3339)),
+				pendingOperationKey:    []byte(fmt.Sprintf("%s-%d", sleepOperation, now.Unix())),
 				replicasBeforeSleepKey: []byte(`[{"name":"deployment1","replicas":1},{"name":"deployment2","replicas":4}]`),
-			},
+			}),
 		}, secret)
 
 		t.Run("update existent secret - wake up", func(t *testing.T) {
@@ -187,11 +197,14 @@ func TestUpsertSecrets(t *testing.T) {
 					Namespace:       namespace,
 					ResourceVersion: "2",
 					OwnerReferences: ownerRefs,
+					Labels:          labels,
 				},
-				Data: map[string][]byte{
-					lastOperationKey: []byte(wakeUpOperation),
-					lastScheduleKey:  []byte(now.Format(time.RFC3339)),
-				},
+				Data: withStateChecksum(map[string][]byte{
+					lastOperationKey:       []byte(wakeUpOperation),
+					lastScheduleKey:        []byte(now.Format(time.RFC3339)),
+					pendingOperationKey:    []byte(fmt.Sprintf("%s-%d", wakeUpOperation, now.Unix())),
+					replicasBeforeSleepKey: []byte(`[{"name":"deployment1","replicas":1},{"name":"deployment2","replicas":4}]`),
+				}),
 			}, secret)
 		})
 	})
@@ -234,12 +247,14 @@ func TestUpsertSecrets(t *testing.T) {
 				Namespace:       namespace,
 				ResourceVersion: "1",
 				OwnerReferences: ownerRefs,
+				Labels:          labels,
 			},
-			Data: map[string][]byte{
+			Data: withStateChecksum(map[string][]byte{
 				lastOperationKey:       []byte(sleepOperation),
 				lastScheduleKey:        []byte(now.Format(time.RFC3339)),
+				pendingOperationKey:    []byte(fmt.Sprintf("%s-%d", sleepOperation, now.Unix())),
 				replicasBeforeSleepKey: []byte(`[{"name":"deployment1","replicas":1},{"name":"deployment2","replicas":4}]`),
-			},
+			}),
 		}, secret)
 
 		t.Run("update existent secret - new deploy to sleep", func(t *testing.T) {
@@ -285,12 +300,14 @@ func TestUpsertSecrets(t *testing.T) {
 					Namespace:       namespace,
 					ResourceVersion: "2",
 					OwnerReferences: ownerRefs,
+					Labels:          labels,
 				},
-				Data: map[string][]byte{
+				Data: withStateChecksum(map[string][]byte{
 					lastOperationKey:       []byte(sleepOperation),
 					lastScheduleKey:        []byte(now.Format(time.RFC3339)),
+					pendingOperationKey:    []byte(fmt.Sprintf("%s-%d", sleepOperation, now.Unix())),
 					replicasBeforeSleepKey: []byte(`[{"name":"deployment1","replicas":1},{"name":"deployment2","replicas":4},{"name":"new-deployment","replicas":1}]`),
-				},
+				}),
 			}, secret)
 		})
 	})
@@ -331,6 +348,7 @@ func TestUpsertSecrets(t *testing.T) {
 				Namespace:       namespace,
 				ResourceVersion: "1",
 				OwnerReferences: ownerRefs,
+				Labels:          labels,
 			},
 			Data: map[string][]byte{
 				lastScheduleKey: []byte(now.Format(time.RFC3339)),
@@ -385,6 +403,7 @@ func TestUpsertSecrets(t *testing.T) {
 				Namespace:       namespace,
 				ResourceVersion: "16",
 				OwnerReferences: ownerRefs,
+				Labels:          labels,
 			},
 			Data: map[string][]byte{
 				lastScheduleKey: []byte(now.Format(time.RFC3339)),
@@ -463,6 +482,74 @@ func TestUpsertSecrets(t *testing.T) {
 		err = r.upsertSecret(context.Background(), testLogger, now, secretName, namespace, sleepInfo, existentSecret, sleepInfoData, resources)
 		require.EqualError(t, err, "error during update")
 	})
+
+	t.Run("fails to update secret changed in the meanwhile", func(t *testing.T) {
+		existentSecret := getSecret(mockSecretSpec{
+			namespace:       namespace,
+			name:            secretName,
+			resourceVersion: "15",
+			data: map[string][]byte{
+				lastOperationKey: []byte(wakeUpOperation),
+				lastScheduleKey:  []byte(now.Add(1 * time.Hour).Format(time.RFC3339)),
+			},
+		})
+		client := &testutil.PossiblyErroringFakeCtrlRuntimeClient{
+			Client: fake.
+				NewClientBuilder().
+				WithRuntimeObjects(existentSecret).
+				Build(),
+		}
+		r := SleepInfoReconciler{
+			Client:     client,
+			Log:        testLogger,
+			SleepDelta: 60,
+		}
+		sleepInfoData := SleepInfoData{
+			CurrentOperationType: sleepOperation,
+		}
+		resources, err := NewResources(context.Background(), resource.ResourceClient{
+			Client: fake.
+				NewClientBuilder().
+				WithRuntimeObjects(&d1, &d2, &d3).
+				Build(),
+			Log:       testLogger,
+			SleepInfo: sleepInfo,
+		}, namespace, sleepInfoData)
+		require.NoError(t, err)
+
+		staleSecret := existentSecret.DeepCopy()
+		staleSecret.ResourceVersion = "14"
+		err = r.upsertSecret(context.Background(), testLogger, now, secretName, namespace, sleepInfo, staleSecret, sleepInfoData, resources)
+		require.True(t, apierrors.IsConflict(err))
+	})
+
+	t.Run("with configured labels and annotations", func(t *testing.T) {
+		client := fake.NewClientBuilder().Build()
+		r := SleepInfoReconciler{
+			Client:     client,
+			Log:        testLogger,
+			SleepDelta: 60,
+			StateSecret: &configv1alpha1.StateSecret{
+				Labels:      map[string]string{"team": "platform"},
+				Annotations: map[string]string{"example.com/backup": "skip"},
+			},
+		}
+		sleepInfoData := SleepInfoData{
+			CurrentOperationType: wakeUpOperation,
+		}
+
+		err := r.upsertSecret(context.Background(), testLogger, now, secretName, namespace, sleepInfo, nil, sleepInfoData, Resources{})
+		require.NoError(t, err)
+
+		secret, err := r.getSecret(context.Background(), secretName, namespace)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			"app.kubernetes.io/managed-by": "kube-green",
+			"team":                         "platform",
+		}, secret.Labels)
+		require.Equal(t, map[string]string{"example.com/backup": "skip"}, secret.Annotations)
+		require.Equal(t, ownerRefs, secret.OwnerReferences)
+	})
 }
 
 type mockSecretSpec struct {
@@ -489,3 +576,62 @@ func getSecret(opts mockSecretSpec) *v1.Secret {
 		Data: opts.data,
 	}
 }
+
+// withStateChecksum adds to data the checksum of the stored state.
+func withStateChecksum(data map[string][]byte) map[string][]byte {
+	data[stateChecksumKey] = []byte(getStateChecksum(data))
+	return data
+}
+
+func TestCompleteOperation(t *testing.T) {
+	testLogger := zap.New(zap.UseDevMode(true))
+	secretName := "secret-name"
+	namespace := "my-namespace"
+	now := time.Date(2023, 1, 9, 20, 0, 0, 0, time.UTC)
+	getStoredSecret := func() *v1.Secret {
+		return getSecret(mockSecretSpec{
+			namespace: namespace,
+			name:      secretName,
+			data: withStateChecksum(map[string][]byte{
+				lastOperationKey:       []byte(wakeUpOperation),
+				lastScheduleKey:        []byte(now.Format(time.RFC3339)),
+				pendingOperationKey:    []byte(fmt.Sprintf("%s-%d", wakeUpOperation, now.Unix())),
+				operationCheckpointKey: []byte(`{"patched":["Deployment.apps/deployment1"]}`),
+				replicasBeforeSleepKey: []byte(`[{"name":"deployment1","replicas":1}]`),
+			}),
+		})
+	}
+
+	t.Run("sleep keeps the state", func(t *testing.T) {
+		storedSecret := getStoredSecret()
+		r := SleepInfoReconciler{Client: fake.NewClientBuilder().WithRuntimeObjects(storedSecret).Build()}
+
+		r.completeOperation(context.Background(), testLogger, secretName, namespace, nil)
+
+		secret, err := r.getSecret(context.Background(), secretName, namespace)
+		require.NoError(t, err)
+		require.NotContains(t, secret.Data, pendingOperationKey)
+		require.NotContains(t, secret.Data, operationCheckpointKey)
+		require.Equal(t, storedSecret.Data[replicasBeforeSleepKey], secret.Data[replicasBeforeSleepKey])
+		require.Equal(t, storedSecret.Data[stateChecksumKey], secret.Data[stateChecksumKey])
+	})
+
+	t.Run("wake up removes the state", func(t *testing.T) {
+		storedSecret := getStoredSecret()
+		r := SleepInfoReconciler{Client: fake.NewClientBuilder().WithRuntimeObjects(storedSecret).Build()}
+
+		r.completeOperation(context.Background(), testLogger, secretName, namespace, getStoredStateKeys(storedSecret))
+
+		secret, err := r.getSecret(context.Background(), secretName, namespace)
+		require.NoError(t, err)
+		require.Equal(t, map[string][]byte{
+			lastOperationKey: []byte(wakeUpOperation),
+			lastScheduleKey:  []byte(now.Format(time.RFC3339)),
+		}, secret.Data)
+	})
+
+	t.Run("not fails if secret not found", func(t *testing.T) {
+		r := SleepInfoReconciler{Client: fake.NewClientBuilder().Build()}
+		r.completeOperation(context.Background(), testLogger, secretName, namespace, nil)
+	})
+}
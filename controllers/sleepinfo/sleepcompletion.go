@@ -0,0 +1,200 @@
+package sleepinfo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// sleepCompletionRetryInterval is how frequently an incomplete sleep is
+// checked again, until the namespace wakes up.
+const sleepCompletionRetryInterval = 10 * time.Minute
+
+// checkSleepCompletion checks, once the SleepCompletionCheckDelay after the
+// sleep is passed, whether the pods of the resources put to sleep are still
+// running, e.g. because a controller not handled by kube-green scaled them up
+// again. The residual pods are reported in the SleepIncomplete condition and
+// in the ResidualPods metric. It returns when the sleep must be checked
+// again, and false if it is not needed.
+func (r *SleepInfoReconciler) checkSleepCompletion(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo, data SleepInfoData, now time.Time) (time.Duration, bool) {
+	if r.PodReader == nil || r.SleepCompletionCheckDelay == 0 || sleepInfo.Status.OperationType != sleepOperation {
+		return 0, false
+	}
+	checkAt := sleepInfo.Status.LastScheduleTime.Add(r.SleepCompletionCheckDelay)
+	if now.Before(checkAt) {
+		return checkAt.Sub(now), true
+	}
+
+	residualPods, err := r.getResidualPods(ctx, sleepInfo, data)
+	if err != nil {
+		log.Error(err, "fails to get the pods running after the sleep")
+		return sleepCompletionRetryInterval, true
+	}
+	count := 0
+	for _, residual := range residualPods {
+		count += residual.count
+	}
+	r.Metrics.ResidualPods.WithLabelValues(sleepInfo.Namespace).Set(float64(count))
+
+	condition := metav1.Condition{
+		Type:               kubegreenv1alpha1.SleepIncompleteCondition,
+		Status:             metav1.ConditionFalse,
+		Reason:             kubegreenv1alpha1.SleepIncompleteReasonNoResidualPods,
+		Message:            "No pod of the resources put to sleep is running",
+		ObservedGeneration: sleepInfo.Generation,
+		LastTransitionTime: metav1.NewTime(now),
+	}
+	if count > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = kubegreenv1alpha1.SleepIncompleteReasonResidualPods
+		condition.Message = fmt.Sprintf("%d pods still running after the sleep: %s", count, formatResidualPods(residualPods))
+	}
+	isChanged, err := r.setSleepIncompleteCondition(ctx, sleepInfo, condition)
+	if err != nil {
+		log.Error(err, "fails to update the sleep incomplete condition")
+	}
+	if count == 0 {
+		return 0, false
+	}
+	if isChanged {
+		log.Info("sleep incomplete", "residualPods", count)
+		if r.Recorder != nil {
+			r.Recorder.Event(sleepInfo, v1.EventTypeWarning, kubegreenv1alpha1.SleepIncompleteCondition, condition.Message)
+		}
+	}
+	return sleepCompletionRetryInterval, true
+}
+
+// resetSleepCompletion removes the residual pods of the namespace woken up
+// from the ResidualPods metric.
+func (r *SleepInfoReconciler) resetSleepCompletion(namespace string) {
+	r.Metrics.ResidualPods.DeleteLabelValues(namespace)
+}
+
+type residualPods struct {
+	kind  string
+	name  string
+	count int
+}
+
+// getResidualPods returns the running pods selected by the Deployments,
+// ReplicaSets, ReplicationControllers and DaemonSets put to sleep, in the
+// state stored by the sleep. The resources deleted during the sleep are
+// skipped.
+func (r *SleepInfoReconciler) getResidualPods(ctx context.Context, sleepInfo *kubegreenv1alpha1.SleepInfo, data SleepInfoData) ([]residualPods, error) {
+	selectors := map[string]map[string]labels.Selector{}
+	addSelectors := func(kind string, names []string, newObject func() client.Object, getSelector func(client.Object) (labels.Selector, error)) error {
+		for _, name := range names {
+			obj := newObject()
+			if err := r.Client.Get(ctx, client.ObjectKey{Namespace: sleepInfo.Namespace, Name: name}, obj); err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return fmt.Errorf("fails to get %s %s: %s", kind, name, err)
+			}
+			selector, err := getSelector(obj)
+			if err != nil {
+				return fmt.Errorf("invalid selector of %s %s: %s", kind, name, err)
+			}
+			if selectors[kind] == nil {
+				selectors[kind] = map[string]labels.Selector{}
+			}
+			selectors[kind][name] = selector
+		}
+		return nil
+	}
+	if err := addSelectors("Deployment", getKeys(data.OriginalDeploymentsReplicas), func() client.Object { return &appsv1.Deployment{} }, func(obj client.Object) (labels.Selector, error) {
+		return metav1.LabelSelectorAsSelector(obj.(*appsv1.Deployment).Spec.Selector)
+	}); err != nil {
+		return nil, err
+	}
+	if err := addSelectors("ReplicaSet", getKeys(data.OriginalReplicaSetsReplicas), func() client.Object { return &appsv1.ReplicaSet{} }, func(obj client.Object) (labels.Selector, error) {
+		return metav1.LabelSelectorAsSelector(obj.(*appsv1.ReplicaSet).Spec.Selector)
+	}); err != nil {
+		return nil, err
+	}
+	if err := addSelectors("ReplicationController", getKeys(data.OriginalReplicationControllersReplicas), func() client.Object { return &v1.ReplicationController{} }, func(obj client.Object) (labels.Selector, error) {
+		return labels.SelectorFromSet(obj.(*v1.ReplicationController).Spec.Selector), nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := addSelectors("DaemonSet", getKeys(data.OriginalDaemonSetsNodeSelector), func() client.Object { return &appsv1.DaemonSet{} }, func(obj client.Object) (labels.Selector, error) {
+		return metav1.LabelSelectorAsSelector(obj.(*appsv1.DaemonSet).Spec.Selector)
+	}); err != nil {
+		return nil, err
+	}
+	if len(selectors) == 0 {
+		return nil, nil
+	}
+
+	pods := v1.PodList{}
+	if err := r.PodReader.List(ctx, &pods, client.InNamespace(sleepInfo.Namespace)); err != nil {
+		return nil, fmt.Errorf("fails to list the pods: %s", err)
+	}
+	residuals := []residualPods{}
+	for kind, selectorsByName := range selectors {
+		for name, selector := range selectorsByName {
+			count := 0
+			for _, pod := range pods.Items {
+				if isPodRunning(pod) && selector.Matches(labels.Set(pod.Labels)) {
+					count++
+				}
+			}
+			if count > 0 {
+				residuals = append(residuals, residualPods{kind: kind, name: name, count: count})
+			}
+		}
+	}
+	sort.Slice(residuals, func(i, j int) bool {
+		if residuals[i].kind != residuals[j].kind {
+			return residuals[i].kind < residuals[j].kind
+		}
+		return residuals[i].name < residuals[j].name
+	})
+	return residuals, nil
+}
+
+// formatResidualPods formats the residual pods by resource, e.g.
+// "Deployment api (2), DaemonSet agent (1)".
+func formatResidualPods(residuals []residualPods) string {
+	formatted := make([]string, 0, len(residuals))
+	for _, residual := range residuals {
+		formatted = append(formatted, fmt.Sprintf("%s %s (%d)", residual.kind, residual.name, residual.count))
+	}
+	return strings.Join(formatted, ", ")
+}
+
+func getKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// setSleepIncompleteCondition sets the SleepIncomplete condition in the
+// status of the SleepInfo. It returns true if the status of the condition
+// changed.
+func (r *SleepInfoReconciler) setSleepIncompleteCondition(ctx context.Context, currentSleepInfo *kubegreenv1alpha1.SleepInfo, condition metav1.Condition) (bool, error) {
+	current := meta.FindStatusCondition(currentSleepInfo.Status.Conditions, condition.Type)
+	if current != nil && current.Status == condition.Status && current.Reason == condition.Reason && current.Message == condition.Message {
+		return false, nil
+	}
+	sleepInfo := currentSleepInfo.DeepCopy()
+	meta.SetStatusCondition(&sleepInfo.Status.Conditions, condition)
+	isChanged := current == nil || current.Status != condition.Status
+	return isChanged, r.Status().Patch(ctx, sleepInfo, client.MergeFrom(currentSleepInfo))
+}
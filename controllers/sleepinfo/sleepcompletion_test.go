@@ -0,0 +1,164 @@
+package sleepinfo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/metrics"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckSleepCompletion(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+	require.NoError(t, appsv1.AddToScheme(scheme))
+	require.NoError(t, v1.AddToScheme(scheme))
+
+	namespace := "my-namespace"
+	sleepTime := time.Date(2024, 3, 4, 20, 0, 0, 0, time.UTC)
+	customMetrics := metrics.SetupMetricsOrDie("kube_green")
+	data := SleepInfoData{
+		OriginalDeploymentsReplicas: map[string]int32{"api": 2, "deleted": 1},
+		OriginalDaemonSetsNodeSelector: map[string]map[string]string{
+			"agent": nil,
+		},
+	}
+
+	getSleepInfo := func() *kubegreenv1alpha1.SleepInfo {
+		return &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: namespace},
+			Status: kubegreenv1alpha1.SleepInfoStatus{
+				OperationType:    sleepOperation,
+				LastScheduleTime: metav1.NewTime(sleepTime),
+			},
+		}
+	}
+	getDeployment := func(name string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+			},
+		}
+	}
+	getDaemonSet := func(name string) *appsv1.DaemonSet {
+		return &appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: appsv1.DaemonSetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+			},
+		}
+	}
+	getPod := func(name, app string, phase v1.PodPhase) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"app": app}},
+			Status:     v1.PodStatus{Phase: phase},
+		}
+	}
+	getReconciler := func(objects ...client.Object) (SleepInfoReconciler, *record.FakeRecorder) {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+		recorder := record.NewFakeRecorder(10)
+		return SleepInfoReconciler{
+			Client:                    c,
+			PodReader:                 c,
+			Metrics:                   customMetrics,
+			Recorder:                  recorder,
+			SleepCompletionCheckDelay: 2 * time.Minute,
+		}, recorder
+	}
+	getCondition := func(t *testing.T, r SleepInfoReconciler, sleepInfo *kubegreenv1alpha1.SleepInfo) *metav1.Condition {
+		t.Helper()
+		updatedSleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, r.Get(context.Background(), client.ObjectKeyFromObject(sleepInfo), updatedSleepInfo))
+		return meta.FindStatusCondition(updatedSleepInfo.Status.Conditions, kubegreenv1alpha1.SleepIncompleteCondition)
+	}
+
+	t.Run("not checked before the delay", func(t *testing.T) {
+		r, _ := getReconciler()
+		checkAfter, isPending := r.checkSleepCompletion(context.Background(), logr.Discard(), getSleepInfo(), data, sleepTime.Add(time.Minute))
+		require.True(t, isPending)
+		require.Equal(t, time.Minute, checkAfter)
+	})
+
+	t.Run("pods still running", func(t *testing.T) {
+		sleepInfo := getSleepInfo()
+		r, recorder := getReconciler(
+			sleepInfo,
+			getDeployment("api"),
+			getDaemonSet("agent"),
+			getPod("api-1", "api", v1.PodRunning),
+			getPod("api-2", "api", v1.PodPending),
+			getPod("api-3", "api", v1.PodSucceeded),
+			getPod("agent-1", "agent", v1.PodRunning),
+			getPod("other-1", "other", v1.PodRunning),
+		)
+
+		checkAfter, isPending := r.checkSleepCompletion(context.Background(), logr.Discard(), sleepInfo, data, sleepTime.Add(2*time.Minute))
+		require.True(t, isPending)
+		require.Equal(t, sleepCompletionRetryInterval, checkAfter)
+		require.Equal(t, float64(3), testutil.ToFloat64(customMetrics.ResidualPods.WithLabelValues(namespace)))
+
+		condition := getCondition(t, r, sleepInfo)
+		require.NotNil(t, condition)
+		require.Equal(t, metav1.ConditionTrue, condition.Status)
+		require.Equal(t, kubegreenv1alpha1.SleepIncompleteReasonResidualPods, condition.Reason)
+		require.Equal(t, "3 pods still running after the sleep: DaemonSet agent (1), Deployment api (2)", condition.Message)
+		require.Equal(t, "Warning SleepIncomplete 3 pods still running after the sleep: DaemonSet agent (1), Deployment api (2)", <-recorder.Events)
+
+		r.resetSleepCompletion(namespace)
+		require.Equal(t, 0, testutil.CollectAndCount(customMetrics.ResidualPods))
+	})
+
+	t.Run("all the pods stopped", func(t *testing.T) {
+		sleepInfo := getSleepInfo()
+		r, recorder := getReconciler(
+			sleepInfo,
+			getDeployment("api"),
+			getPod("api-1", "api", v1.PodSucceeded),
+			getPod("other-1", "other", v1.PodRunning),
+		)
+
+		_, isPending := r.checkSleepCompletion(context.Background(), logr.Discard(), sleepInfo, data, sleepTime.Add(time.Hour))
+		require.False(t, isPending)
+		require.Equal(t, float64(0), testutil.ToFloat64(customMetrics.ResidualPods.WithLabelValues(namespace)))
+
+		condition := getCondition(t, r, sleepInfo)
+		require.NotNil(t, condition)
+		require.Equal(t, metav1.ConditionFalse, condition.Status)
+		require.Equal(t, kubegreenv1alpha1.SleepIncompleteReasonNoResidualPods, condition.Reason)
+		require.Empty(t, recorder.Events)
+	})
+
+	t.Run("not checked while awake", func(t *testing.T) {
+		sleepInfo := getSleepInfo()
+		sleepInfo.Status.OperationType = wakeUpOperation
+		r, _ := getReconciler(sleepInfo)
+
+		_, isPending := r.checkSleepCompletion(context.Background(), logr.Discard(), sleepInfo, data, sleepTime.Add(time.Hour))
+		require.False(t, isPending)
+		require.Nil(t, getCondition(t, r, sleepInfo))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		sleepInfo := getSleepInfo()
+		r, _ := getReconciler(sleepInfo)
+		r.SleepCompletionCheckDelay = 0
+
+		_, isPending := r.checkSleepCompletion(context.Background(), logr.Discard(), sleepInfo, data, sleepTime.Add(time.Hour))
+		require.False(t, isPending)
+		require.Nil(t, getCondition(t, r, sleepInfo))
+	})
+}
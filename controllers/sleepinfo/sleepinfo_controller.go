@@ -7,35 +7,83 @@ package sleepinfo
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
+	configv1alpha1 "github.com/kube-green/kube-green/api/config/v1alpha1"
 	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/alertmanager"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/audit"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/cronjobs"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/customresources"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/idle"
 	"github.com/kube-green/kube-green/controllers/sleepinfo/metrics"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/operationgate"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/poddisruptionbudgets"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/promquery"
 	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/internal/health"
+	"github.com/kube-green/kube-green/internal/namespacefilter"
+	"github.com/kube-green/kube-green/internal/tracing"
+	"github.com/kube-green/kube-green/pkg/clock"
+	"github.com/kube-green/kube-green/pkg/schedule"
 
 	"github.com/go-logr/logr"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
-	lastScheduleKey               = "scheduled-at"
-	lastOperationKey              = "operation-type"
-	replicasBeforeSleepKey        = "deployment-replicas"
-	originalCronjobStatusKey      = "cronjobs-info"
-	replicasBeforeSleepAnnotation = "sleepinfo.kube-green.com/replicas-before-sleep"
+	lastScheduleKey                              = "scheduled-at"
+	lastOperationKey                             = "operation-type"
+	pendingOperationKey                          = "pending-operation"
+	operationCheckpointKey                       = "operation-checkpoint"
+	replicasBeforeSleepKey                       = "deployment-replicas"
+	originalCronjobStatusKey                     = "cronjobs-info"
+	originalJobStatusKey                         = "jobs-info"
+	replicaSetsReplicasBeforeSleepKey            = "replicaset-replicas"
+	replicationControllersReplicasBeforeSleepKey = "replicationcontroller-replicas"
+	originalDaemonSetsInfoKey                    = "daemonsets-info"
+	originalPodDisruptionBudgetsInfoKey          = "poddisruptionbudgets-info"
+	originalCustomResourcesInfoKey               = "customresources-info"
+	replicasBeforeSleepAnnotation                = "sleepinfo.kube-green.com/replicas-before-sleep"
 
-	sleepOperation  = "SLEEP"
-	wakeUpOperation = "WAKE_UP"
+	sleepOperation  = schedule.Sleep
+	wakeUpOperation = schedule.WakeUp
 
 	fieldManagerName = "kube-green"
+	managedByLabel   = "app.kubernetes.io/managed-by"
+
+	defaultMaxConcurrentReconciles = 20
+
+	maxOperationsHistory = 10
+	// maxSleepRecords covers two weeks of daily sleeps and wake ups, for the
+	// weekly sleep reports.
+	maxSleepRecords = 30
+
+	// idleCheckInterval is how frequently the idle Deployments are checked
+	// while the namespace is awake.
+	idleCheckInterval = 5 * time.Minute
 )
 
 // SleepInfoReconciler reconciles a SleepInfo object
@@ -46,26 +94,174 @@ type SleepInfoReconciler struct {
 	Clock
 	Metrics    metrics.Metrics
 	SleepDelta int64
+	// MaxScheduleJitter delays the operations of each namespace after their
+	// schedule by a stable jitter up to this value, so that the SleepInfo with
+	// the same schedule are not all executed at the same instant. If 0, the
+	// operations are executed at their schedule.
+	MaxScheduleJitter time.Duration
+	// ExecuteMissedOperations executes the operations missed, e.g. because
+	// the controller was not running, as soon as possible while their window
+	// is still open, i.e. before the following operation. Otherwise, they are
+	// skipped until their next schedule.
+	ExecuteMissedOperations bool
+	// WakeAll keeps all the namespaces awake, as forced by the
+	// ForceAwakeAnnotation: the sleeping namespaces are woken up, also the
+	// ones of the SleepInfo without wake up, and the sleeps are skipped, e.g.
+	// before a risky upgrade of the controller or to remove kube-green from
+	// the cluster.
+	WakeAll bool
+	// Teardown prepares the uninstall of kube-green: as with WakeAll, the
+	// sleeping namespaces are woken up. Then the state secrets are deleted and
+	// the SleepInfo are marked with the InertAnnotation.
+	Teardown bool
+	// DefaultTimeZone is the time zone used for the SleepInfo which do not set it.
+	DefaultTimeZone string
+	// MaxConcurrentReconciles is the number of SleepInfo reconciled in parallel. Default to 20.
+	MaxConcurrentReconciles int
+	// RateLimiter limits how frequently the SleepInfo are requeued. If nil, the
+	// controller-runtime default rate limiter is used.
+	RateLimiter ratelimiter.RateLimiter
+	// NamespaceFilter restricts the namespaces where the SleepInfo are reconciled.
+	// The zero value allows all the namespaces.
+	NamespaceFilter namespacefilter.Filter
+	// ProtectedNamespaces are the glob patterns of the namespaces whose
+	// SleepInfo are ignored, besides the kubegreenv1alpha1.DefaultProtectedNamespaces
+	// and the namespaces labeled with kubegreenv1alpha1.ProtectedNamespaceLabel.
+	ProtectedNamespaces []string
+	// WatchNamespaces are the namespaces watched by the manager. If set, the
+	// controller is deployed with namespace scoped permissions and cannot read
+	// the Namespaces: the protected namespaces are checked only by name, and
+	// the ForceAwakeAnnotation of the namespaces is not watched.
+	WatchNamespaces []string
+	// AuditSink receives an audit event for each sleep and wake up operation.
+	// If nil, the audit is disabled.
+	AuditSink audit.Sink
+	// Silencer silences the alerts of the namespaces while they are sleeping.
+	// If nil, the alerts are not silenced.
+	Silencer alertmanager.Silencer
+	// PrometheusQuerier evaluates the sleep conditions of the SleepInfo and
+	// detects the idle Deployments. If nil, the sleep conditions cannot be
+	// evaluated and their failure policy applies, and the idle sleep is disabled.
+	PrometheusQuerier promquery.Querier
+	// OperationGate reviews each operation before it is executed. If nil, the
+	// operations are not reviewed.
+	OperationGate operationgate.Gate
+	// Recorder records the events on the handled resources, e.g. when a
+	// resource is skipped by the sleep. If nil, the events are not recorded.
+	Recorder record.EventRecorder
+	// HealthTracker tracks the results of the reconciles, reported by the
+	// health and readiness checks. If nil, the reconciles are not tracked.
+	HealthTracker *health.Tracker
+	// PodReader reads the pods running in the namespaces before the sleep and
+	// the wake up, recorded in the operations history for the sleep reports
+	// and the warm up of the nodes. It should not be cached, to not watch all
+	// the pods of the cluster. If nil, the running pods are not recorded.
+	PodReader client.Reader
+	// ResourceTimeout is the timeout of each request to the API server made
+	// to sleep and wake up the resources. The resources whose patch still
+	// times out after the retries are skipped and reported in the operations
+	// history. If 0, the requests have no timeout.
+	ResourceTimeout time.Duration
+	// OperationChunkSize splits the patches of each operation in chunks of
+	// this size, with a pause of OperationChunkPause between them, so that
+	// very large operations do not overload the API server. If 0, the
+	// operations are not chunked.
+	OperationChunkSize  int
+	OperationChunkPause time.Duration
+	// StateSecret configures the name, the labels and the annotations of the
+	// Secret where the state of each SleepInfo is stored. If nil, the Secret
+	// has the default name.
+	StateSecret *configv1alpha1.StateSecret
+	// StateCodec adds a checksum to the state stored in the Secret, verified
+	// before the state is restored, and encrypts it if created with a key.
+	StateCodec StateCodec
+	// ThrottleBackoff delays the retry of the operations throttled by the API
+	// server, per namespace. If nil, they are retried with the rate limiter.
+	ThrottleBackoff *ThrottleBackoff
+	// OperationLock serializes the operations of the SleepInfo of the same
+	// namespace. If nil, they can run at the same time.
+	OperationLock *OperationLock
+	// VerificationHTTPClient checks the urls of the verifications run after
+	// the wake ups. If nil, http.DefaultClient is used.
+	VerificationHTTPClient *http.Client
+	// ExpiryHTTPClient sends the notifications of the expired namespaces to
+	// the webhooks. If nil, http.DefaultClient is used.
+	ExpiryHTTPClient *http.Client
+	// Impersonator returns the clients which impersonate the ServiceAccounts
+	// of the namespaces to handle their resources. If nil, the resources are
+	// handled with the client of the controller.
+	Impersonator *Impersonator
+	// FreezeWindows are the change freeze windows during which the scheduled
+	// operations are skipped.
+	FreezeWindows []configv1alpha1.FreezeWindow
+	// SleepCompletionCheckDelay is the time after the sleep when the pods of
+	// the resources put to sleep must not be running anymore. The residual
+	// pods are reported in the SleepIncomplete condition. If 0, or if the
+	// PodReader is not set, the completion of the sleeps is not checked.
+	SleepCompletionCheckDelay time.Duration
+	// DrainTimeout is how long the reconciles in flight when the controller is
+	// stopped, e.g. on SIGTERM, can still run to complete their operation:
+	// their requests to the API server are canceled only after this timeout.
+	// The reconciles not started yet are not run. If 0, the requests are
+	// canceled as soon as the controller is stopped.
+	DrainTimeout time.Duration
 }
 
-type realClock struct{}
-
-func (realClock) Now() time.Time {
-	return time.Now()
+// isNamespaceAllowed checks whether the namespace is allowed by the NamespaceFilter.
+// The namespace is fetched only if the filter contains label selectors.
+func (r *SleepInfoReconciler) isNamespaceAllowed(ctx context.Context, namespaceName string) (bool, error) {
+	if !r.NamespaceFilter.IsNameAllowed(namespaceName) {
+		return false, nil
+	}
+	if !r.NamespaceFilter.HasLabelSelectors() {
+		return true, nil
+	}
+	namespace := &v1.Namespace{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: namespaceName}, namespace); err != nil {
+		return false, err
+	}
+	return r.NamespaceFilter.IsAllowed(namespace), nil
 }
 
-// clock knows how to get the current time.
-// It can be used to fake out timing for testing.
-type Clock interface {
-	Now() time.Time
+// isNamespaceProtected checks whether the SleepInfo of the namespace are
+// ignored, because the namespace is protected.
+func (r *SleepInfoReconciler) isNamespaceProtected(ctx context.Context, namespaceName string) (bool, error) {
+	if kubegreenv1alpha1.IsNamespaceNameProtected(namespaceName, r.ProtectedNamespaces) {
+		return true, nil
+	}
+	if len(r.WatchNamespaces) > 0 {
+		return false, nil
+	}
+	namespace := &v1.Namespace{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: namespaceName}, namespace); err != nil {
+		return false, err
+	}
+	return kubegreenv1alpha1.IsNamespaceProtected(namespace, r.ProtectedNamespaces), nil
 }
 
+// Clock knows how to get the current time. It can be used to fake out timing
+// for testing and to simulate the schedules.
+type Clock = clock.Clock
+
 //+kubebuilder:rbac:groups=kube-green.com,resources=sleepinfos,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=kube-green.com,resources=sleepinfos/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=kube-green.com,resources=sleepinfos/finalizers,verbs=update
-//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete;deletecollection
 //+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete;deletecollection
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;deletecollection
+//+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;deletecollection
+//+kubebuilder:rbac:groups=core,resources=services,verbs=list;deletecollection
+//+kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=impersonate
+//+kubebuilder:rbac:groups=apps,resources=replicasets,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=replicationcontrollers,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;create;update
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=list;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -73,7 +269,51 @@ type Clock interface {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.7.2/pkg/reconcile
 func (r *SleepInfoReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	log := r.Log.WithValues("sleepinfo", req.NamespacedName)
+	if err := ctx.Err(); err != nil {
+		// the controller is stopping: the operation is executed on restart,
+		// or by the next leader.
+		r.Log.WithValues("sleepinfo", req.NamespacedName).Info("controller stopping, reconcile skipped")
+		return ctrl.Result{}, err
+	}
+	ctx, cancel := withDrainTimeout(ctx, r.DrainTimeout)
+	defer cancel()
+
+	if r.HealthTracker == nil {
+		return r.reconcile(ctx, req)
+	}
+	done := r.HealthTracker.Start()
+	result, err := r.reconcile(ctx, req)
+	done(err)
+	return result, err
+}
+
+func (r *SleepInfoReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// The reconcile ID correlates all the log lines of the same reconcile.
+	log := r.Log.WithValues("sleepinfo", req.NamespacedName, "reconcileID", controller.ReconcileIDFromContext(ctx))
+	ctx, span := tracing.Tracer().Start(ctx, "Reconcile", trace.WithAttributes(
+		attribute.String("sleepinfo.namespace", req.Namespace),
+		attribute.String("sleepinfo.name", req.Name),
+	))
+	defer span.End()
+
+	isNamespaceAllowed, err := r.isNamespaceAllowed(ctx, req.Namespace)
+	if err != nil {
+		log.Error(err, "unable to fetch namespace", "namespaceName", req.Namespace)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !isNamespaceAllowed {
+		log.Info("namespace not allowed by the controller configuration, skip")
+		return ctrl.Result{}, nil
+	}
+	isNamespaceProtected, err := r.isNamespaceProtected(ctx, req.Namespace)
+	if err != nil {
+		log.Error(err, "unable to fetch namespace", "namespaceName", req.Namespace)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if isNamespaceProtected {
+		log.Info("namespace protected, skip")
+		return ctrl.Result{}, nil
+	}
 
 	sleepInfo, err := r.getSleepInfo(ctx, req)
 	if err != nil {
@@ -86,79 +326,324 @@ func (r *SleepInfoReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 				"name":      req.Name,
 				"namespace": req.Namespace,
 			})
+			r.deleteState(ctx, log, req.NamespacedName)
 		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	if isInert(sleepInfo) {
+		log.Info("sleepinfo inert, skip", "annotation", InertAnnotation)
+		return ctrl.Result{}, nil
+	}
 	r.Metrics.CurrentSleepInfo.With(prometheus.Labels{
 		"name":      req.Name,
 		"namespace": req.Namespace,
 	}).Set(1)
+	r.reportState(ctx, log, sleepInfo, sleepInfo.Status.OperationType)
 
-	secretName := getSecretName(req.Name)
+	secretName := r.StateSecret.GetName(req.Name)
 	secret, err := r.getSecret(ctx, secretName, req.Namespace)
 	if client.IgnoreNotFound(err) != nil {
 		log.Error(err, "unable to fetch namespace", "namespaceName", req.Namespace)
+		err = &StateStoreError{Op: "read", Secret: secretName, Err: err}
+		r.setOperationFailed(ctx, log, sleepInfo, err)
 		return ctrl.Result{}, err
 	}
-	sleepInfoData, err := getSleepInfoData(secret, sleepInfo)
+	if secret != nil {
+		// the stored state is verified before it is restored.
+		data, err := r.StateCodec.decode(secret.Data)
+		if err != nil {
+			log.Error(err, "invalid stored state", "secret", secretName)
+			if r.Recorder != nil {
+				r.Recorder.Eventf(sleepInfo, v1.EventTypeWarning, "InvalidState", "The state stored in the secret %s is not valid: %s", secretName, err)
+			}
+			err = &StateStoreError{Op: "decode", Secret: secretName, Err: err}
+			r.setOperationFailed(ctx, log, sleepInfo, err)
+			return ctrl.Result{}, err
+		}
+		secret = secret.DeepCopy()
+		secret.Data = data
+	}
+	sleepInfoData, err := getSleepInfoData(secret, r.getSleepInfoWithDefaults(sleepInfo))
 	if err != nil {
 		log.Error(err, "unable to get secret data")
+		r.setOperationFailed(ctx, log, sleepInfo, err)
 		return ctrl.Result{}, err
 	}
+	if r.Teardown {
+		if !isSleeping(secret) {
+			if err := r.teardown(ctx, sleepInfo, secretName); err != nil {
+				log.Error(err, "fails to tear down the sleepinfo")
+				return ctrl.Result{}, err
+			}
+			log.Info("sleepinfo torn down")
+			return ctrl.Result{}, nil
+		}
+		// the SleepInfo without wake up, and the ones with a pending
+		// operation, are woken up too.
+		sleepInfoData.CurrentOperationType = wakeUpOperation
+		sleepInfoData.PendingOperationID = ""
+	}
+	if r.WakeAll && isSleeping(secret) && sleepInfoData.IsSleepOperation() && sleepInfoData.PendingOperationID == "" {
+		// the SleepInfo without wake up are woken up too, as by the
+		// teardown. The pending sleeps are replaced by the wake up anyway.
+		sleepInfoData.CurrentOperationType = wakeUpOperation
+	}
 	now := r.Clock.Now()
+	// the schedule is evaluated as if it was jitter earlier, so that both the
+	// execution and the requeue are delayed by the jitter of the namespace.
+	jitter := r.getScheduleJitter(req.Namespace)
+	scheduleNow := now.Add(-jitter)
 
-	isToExecute, nextSchedule, requeueAfter, err := r.getNextSchedule(sleepInfoData, now)
+	_, scheduleSpan := tracing.Tracer().Start(ctx, "getNextSchedule")
+	isToExecute, nextSchedule, requeueAfter, err := r.getNextSchedule(sleepInfoData, scheduleNow)
+	tracing.EndSpan(scheduleSpan, err)
+	// the actor of the operation in the audit log: the operations out of
+	// schedule are triggered on demand.
+	actor := audit.ActorSchedule
 	if err != nil {
 		log.Error(err, "unable to update deployment with 0 replicas")
+		r.setOperationFailed(ctx, log, sleepInfo, err)
 		return ctrl.Result{}, err
 	}
-	scheduleLog := log.WithValues("now", r.Now(), "next run", nextSchedule, "requeue", requeueAfter)
+	// the scheduled operations are skipped during the change freeze, and
+	// retried when it ends. The interrupted operations are resumed anyway.
+	var freezeWindow *configv1alpha1.FreezeWindow
+	if isToExecute && sleepInfoData.PendingOperationID == "" {
+		if freezeWindow = r.getFreezeWindow(sleepInfoData.CurrentOperationType, now); freezeWindow != nil {
+			isToExecute = false
+			requeueAfter = getRequeueAfterFreeze(requeueAfter, freezeWindow, now)
+			if sleepInfo.Status.FrozenBy != freezeWindow.Name && r.Recorder != nil {
+				r.Recorder.Eventf(sleepInfo, v1.EventTypeNormal, "OperationFrozen", "%s operation skipped during the change freeze %s, until %s", sleepInfoData.CurrentOperationType, freezeWindow.Name, freezeWindow.End.UTC().Format(time.RFC3339))
+			}
+		}
+	}
+	// a failure in reading the namespace does not block the operations.
+	isForcedAwake, err := r.isNamespaceForcedAwake(ctx, req.Namespace)
+	if err != nil {
+		log.Error(err, "unable to check if the namespace is forced awake", "namespaceName", req.Namespace)
+	}
+	wakeUpActor, isWakeUpAfterFailedSleep, err := getWakeUpAfterFailedSleep(sleepInfo, sleepInfoData, isForcedAwake, scheduleNow)
+	if err != nil {
+		log.Error(err, "unable to check the wake up after the failed sleep")
+	}
+	if isWakeUpAfterFailedSleep {
+		log.Info("wake up due, stop retrying the failed sleep", "operationID", sleepInfoData.PendingOperationID, "actor", wakeUpActor)
+		sleepInfoData = toWakeUpAfterFailedSleep(sleepInfoData)
+		if nextSchedule, requeueAfter, err = r.getNextScheduleAfterWakeUp(sleepInfoData, scheduleNow); err != nil {
+			log.Error(err, "unable to get the next schedule after the wake up of the failed sleep")
+			return ctrl.Result{}, err
+		}
+		isToExecute = true
+		actor = wakeUpActor
+	}
+	if !isToExecute && isWakeUpRequested(sleepInfo, sleepInfoData) {
+		if nextSchedule, requeueAfter, err = r.getNextScheduleAfterWakeUp(sleepInfoData, scheduleNow); err != nil {
+			log.Error(err, "unable to get the next schedule after the requested wake up")
+			return ctrl.Result{}, err
+		}
+		isToExecute = true
+		actor = getWakeUpRequestedBy(sleepInfo)
+		log.Info("wake up requested", "actor", actor)
+	}
+	if !isToExecute && isForcedAwake && sleepInfoData.IsWakeUpOperation() {
+		if nextSchedule, requeueAfter, err = r.getNextScheduleAfterWakeUp(sleepInfoData, scheduleNow); err != nil {
+			log.Error(err, "unable to get the next schedule after the forced wake up")
+			return ctrl.Result{}, err
+		}
+		isToExecute = true
+		actor = audit.ActorManual
+		log.Info("wake up forced", "annotation", ForceAwakeAnnotation, "wakeAll", r.WakeAll)
+	}
+	if !isToExecute && sleepInfoData.PendingOperationID != "" {
+		if nextSchedule, requeueAfter, err = r.getNextScheduleAfterWakeUp(sleepInfoData, scheduleNow); err != nil {
+			log.Error(err, "unable to get the next schedule after the pending operation")
+			return ctrl.Result{}, err
+		}
+		isToExecute = true
+		log.Info("resume the operation interrupted before its end", "operationID", sleepInfoData.PendingOperationID)
+	}
+	if !isToExecute {
+		wakeUpEnd, isTimeBoxed, err := getTimeBoxedWakeUpEnd(sleepInfo, sleepInfoData, nextSchedule)
+		if err != nil {
+			log.Error(err, "unable to check the end of the time-boxed wake up")
+		}
+		switch {
+		case isTimeBoxed && !scheduleNow.Before(wakeUpEnd):
+			if nextSchedule, requeueAfter, err = r.getNextScheduleAfterWakeUp(sleepInfoData, scheduleNow); err != nil {
+				log.Error(err, "unable to get the next schedule after the end of the time-boxed wake up")
+				return ctrl.Result{}, err
+			}
+			isToExecute = true
+			actor = audit.ActorManual
+			log.Info("time-boxed wake up ended, sleep again", "wakeUpUntil", wakeUpEnd)
+		case isTimeBoxed && wakeUpEnd.Sub(scheduleNow) < requeueAfter:
+			requeueAfter = wakeUpEnd.Sub(scheduleNow)
+		}
+	}
+	if r.Teardown {
+		// the SleepInfo is torn down by the reconcile after its wake up.
+		requeueAfter = teardownRequeueAfter
+	}
+	if !isToExecute {
+		isWakeUp, err := r.isWakeUpBySpecChange(sleepInfo, sleepInfoData, scheduleNow)
+		if err != nil {
+			log.Error(err, "unable to check the schedule of the changed spec")
+		}
+		if isWakeUp {
+			if nextSchedule, requeueAfter, err = r.getNextScheduleAfterWakeUp(sleepInfoData, scheduleNow); err != nil {
+				log.Error(err, "unable to get the next schedule after the wake up by the changed spec")
+				return ctrl.Result{}, err
+			}
+			isToExecute = true
+			log.Info("spec changed during the sleep and the namespace should be awake, wake up")
+		}
+	}
+	scheduleLog := log.WithValues("now", now, "next run", nextSchedule, "jitter", jitter, "requeue", requeueAfter)
 
 	if !isToExecute {
+		nextDeferred := r.handleDeferredOperations(ctx, log, sleepInfo, sleepInfoData.IsWakeUpOperation(), sleepInfoData.OriginalDeploymentsReplicas, sleepInfoData.LastSchedule, now)
+		requeueAfter = getRequeueAfterDeferred(requeueAfter, nextDeferred, now)
+		if sleepInfoData.IsSleepOperation() && sleepInfo.GetIdleSleep() != nil {
+			r.sleepIdleDeployments(ctx, log, sleepInfo, now)
+			if requeueAfter > idleCheckInterval {
+				requeueAfter = idleCheckInterval
+			}
+		}
+		if sleepInfoData.IsWakeUpOperation() && sleepInfo.IsSleepEnforced() {
+			r.enforceSleep(ctx, log, sleepInfo, sleepInfoData)
+		}
+		if sleepInfoData.IsSleepOperation() {
+			if verifyAfter, isPending := r.verifyWakeUp(ctx, log, sleepInfo, now); isPending {
+				requeueAfter = getRequeueAfterVerification(requeueAfter, verifyAfter)
+			}
+		}
+		// the namespace is asleep waiting for the wake up, or for the next
+		// sleep if the SleepInfo has no wake up.
+		if expireAfter, isPending := r.handleExpiry(ctx, log, sleepInfo, now); isPending {
+			requeueAfter = getRequeueAfterVerification(requeueAfter, expireAfter)
+		}
+		if checkAfter, isPending := r.checkSleepCompletion(ctx, log, sleepInfo, sleepInfoData, now); isPending {
+			requeueAfter = getRequeueAfterVerification(requeueAfter, checkAfter)
+		}
+		if checkAfter, isPending := r.checkPodTermination(ctx, log, sleepInfo, now); isPending {
+			requeueAfter = getRequeueAfterVerification(requeueAfter, checkAfter)
+		}
+		if isSpecChanged(sleepInfo) {
+			if err := r.updateObservedGeneration(ctx, sleepInfo); err != nil {
+				log.Error(err, "unable to update the observed generation")
+			}
+		}
+		if err := r.updateFrozenBy(ctx, sleepInfo, freezeWindow); err != nil {
+			log.Error(err, "unable to update the change freeze in the status")
+		}
+		r.resetOperationFailed(ctx, log, sleepInfo)
 		scheduleLog.Info("skip execution")
 		return ctrl.Result{
 			RequeueAfter: requeueAfter,
 		}, nil
 	}
 	scheduleLog.WithValues("last schedule", now, "status", sleepInfo.Status).Info("last schedule value")
+	span.SetAttributes(attribute.String("sleepinfo.operation", sleepInfoData.CurrentOperationType))
+	log = log.WithValues("namespace", req.Namespace, "operation", sleepInfoData.CurrentOperationType)
+	r.recordDSTAdjustments(log, sleepInfo, sleepInfoData, now)
+	if !r.acquireOperationLock(ctx, log, sleepInfo, sleepInfoData, secretName, now) {
+		return ctrl.Result{RequeueAfter: operationLockRetryInterval}, nil
+	}
+	defer r.releaseOperationLock(sleepInfo)
+	if sleepInfoData.IsWakeUpOperation() {
+		r.requestDependenciesWakeUp(ctx, log, sleepInfo, now)
+	}
 
-	resources, err := NewResources(ctx, resource.ResourceClient{
-		Client:           r.Client,
-		SleepInfo:        sleepInfo,
-		Log:              log,
-		FieldManagerName: fieldManagerName,
-	}, req.Namespace, sleepInfoData)
+	resourceClient, err := r.getResourceClient(log, sleepInfo)
+	if err != nil {
+		log.Error(err, "fails to get the client of the resources")
+		r.setOperationFailed(ctx, log, sleepInfo, err)
+		return ctrl.Result{}, err
+	}
+	resourceClient.FailedResources = &resource.FailedResources{}
+	resourceClient.IsWakeUp = sleepInfoData.IsWakeUpOperation()
+	checkpoint, err := getCheckpoint(secret, sleepInfoData)
+	if err != nil {
+		// the interrupted operation is resumed patching all its resources.
+		log.Error(err, "fails to get the checkpoint of the interrupted operation")
+	}
+	resourceClient.Checkpoint = checkpoint
+	resources, err := NewResources(ctx, resourceClient, req.Namespace, sleepInfoData)
 	if err != nil {
 		log.Error(err, "fails to get resources")
+		r.setOperationFailed(ctx, log, sleepInfo, err)
 		return ctrl.Result{}, err
 	}
 
+	sleepSkippedMsg := ""
+	// the sleep interrupted before its end is resumed, so that the resources
+	// already put to sleep are not left out of the stored state.
+	if sleepInfoData.IsSleepOperation() && resources.hasResources() && sleepInfoData.PendingOperationID == "" {
+		switch {
+		case isSleepGroupRolledBack(sleepInfo, sleepInfoData):
+			// the sleep group stays awake as a unit until the next sleep.
+			sleepSkippedMsg = "sleep of the sleep group rolled back, skip sleep"
+		case isSnoozed(sleepInfo, now):
+			sleepSkippedMsg = "sleep snoozed, skip sleep"
+		case isForcedAwake:
+			sleepSkippedMsg = "namespace forced awake, skip sleep"
+		case !r.isSleepConditionMet(ctx, log, sleepInfo, now):
+			sleepSkippedMsg = "sleep condition not met, skip sleep"
+		}
+		if sleepSkippedMsg != "" {
+			// the sleep is handled as if there were no resources to suspend.
+			resources = Resources{}
+		}
+	}
+
+	if resources.hasResources() && sleepInfoData.PendingOperationID == "" && r.OperationGate != nil {
+		var isAllowed bool
+		if resources, isAllowed, err = r.reviewOperation(ctx, log, sleepInfo, sleepInfoData, resourceClient, resources); err != nil {
+			log.Error(err, "fails to review the operation")
+			return ctrl.Result{}, err
+		}
+		if !isAllowed {
+			// the status is not updated, so the denied operation is reviewed
+			// again while its window is open.
+			return ctrl.Result{RequeueAfter: operationGateRetryInterval}, nil
+		}
+	}
+
 	if err := r.handleSleepInfoStatus(ctx, now, sleepInfo, sleepInfoData.CurrentOperationType, resources); err != nil {
 		log.Error(err, "unable to update sleepInfo status")
 		return ctrl.Result{}, err
 	}
 	log.V(1).Info("update status info")
+	// as in the status, the SleepInfo without resources has no operation.
+	operationType := sleepInfoData.CurrentOperationType
+	if !resources.hasResources() {
+		operationType = ""
+	}
+	r.reportState(ctx, log, sleepInfo, operationType)
 
 	logSecret := log.WithValues("secret", secretName)
 	if !resources.hasResources() {
 		if err = r.upsertSecret(ctx, log, now, secretName, req.Namespace, sleepInfo, secret, sleepInfoData, resources); err != nil {
 			logSecret.Error(err, "fails to update secret")
+			r.setOperationFailed(ctx, log, sleepInfo, &StateStoreError{Op: "write", Secret: secretName, Err: err})
 			return ctrl.Result{
 				Requeue: true,
 			}, nil
 		}
 
 		if sleepInfoData.IsSleepOperation() {
-			requeueAfter, err = skipWakeUpIfSleepNotPerformed(sleepInfoData.CurrentOperationSchedule, nextSchedule, now)
+			requeueAfter, err = skipWakeUpIfSleepNotPerformed(sleepInfoData.CurrentOperationSchedule, nextSchedule, scheduleNow)
 			if err != nil {
 				log.Error(err, "fails to parse cron - 0 deployment")
 				return ctrl.Result{}, nil
 			}
 		}
 
-		logMsg := "deployments and cronjobs not present in namespace"
-		if !sleepInfo.IsCronjobsToSuspend() && !sleepInfo.IsDeploymentsToSuspend() {
-			logMsg = "deployments and cronjobs are not to suspend"
+		logMsg := "resources to suspend not present in namespace"
+		if sleepSkippedMsg != "" {
+			logMsg = sleepSkippedMsg
+		} else if !sleepInfo.IsCronjobsToSuspend() && !sleepInfo.IsDeploymentsToSuspend() && !sleepInfo.IsJobsToSuspend() && !sleepInfo.IsReplicaSetsToSuspend() && !sleepInfo.IsDaemonSetsToSuspend() && !sleepInfo.IsCustomResourcesToSuspend() {
+			logMsg = "no resources are to suspend"
 		}
 		log.WithValues("requeueAfter", requeueAfter).Info(logMsg)
 
@@ -167,53 +652,206 @@ func (r *SleepInfoReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		}, nil
 	}
 
+	if sleepInfoData.IsSleepOperation() && sleepInfoData.PendingOperationID == "" {
+		// if the sleep is interrupted, it is resumed only on the resources
+		// listed now, whose state is stored.
+		checkpoint.Plan(resources.getCheckpointKeys())
+	}
 	if err = r.upsertSecret(ctx, log, now, secretName, req.Namespace, sleepInfo, secret, sleepInfoData, resources); err != nil {
 		logSecret.Error(err, "fails to update secret")
+		r.setOperationFailed(ctx, log, sleepInfo, &StateStoreError{Op: "write", Secret: secretName, Err: err})
 		return ctrl.Result{
 			Requeue: true,
 		}, nil
 	}
+	checkpoint.SetSave(checkpointSaveInterval, r.getCheckpointSave(secretName, req.Namespace))
+
+	opLog := log.WithValues("resourceCounts", resources.getResourceCounts())
+	opLog.Info("operation started")
+	runningPods := r.getRunningPods(ctx, log, req.Namespace)
+	states, err := getOperationStates(sleepInfoData.CurrentOperationType, resources, secret, sleepInfo)
+	if err != nil {
+		log.Error(err, "fails to get the state of the resources of the operation")
+	}
 
 	switch {
 	case sleepInfoData.IsSleepOperation():
-		if err := resources.sleep(ctx); err != nil {
+		err := resources.sleep(ctx)
+		if err == nil {
+			r.completeOperation(ctx, log, secretName, req.Namespace, nil)
+		}
+		r.recordOperation(ctx, log, now, sleepInfo, sleepInfoData.CurrentOperationType, actor, resources, runningPods, states, nil, err)
+		if err != nil {
 			log.Error(err, "fails to handle sleep")
-			return ctrl.Result{
-				Requeue: true,
-			}, err
+			r.rollbackSleepGroup(ctx, log, sleepInfo, now)
+			r.setOperationFailed(ctx, log, sleepInfo, err)
+			return r.getOperationErrorResult(log, req.Namespace, sleepInfoData.CurrentOperationType, err)
+		}
+		r.silenceAlerts(ctx, log, sleepInfo, now.Add(requeueAfter))
+		if expiry := sleepInfo.GetExpiry(); expiry != nil {
+			// the expiry is checked by the first reconcile after its delay.
+			requeueAfter = getRequeueAfterVerification(requeueAfter, expiry.After.Duration)
+		}
+		if r.PodReader != nil && r.SleepCompletionCheckDelay > 0 {
+			// the completion of the sleep is checked by the first reconcile
+			// after its delay.
+			requeueAfter = getRequeueAfterVerification(requeueAfter, r.SleepCompletionCheckDelay)
+		}
+		if check := sleepInfo.GetPodTerminationCheck(); check != nil && r.PodReader != nil {
+			// the termination of the pods is checked by the first reconcile
+			// after its timeout.
+			requeueAfter = getRequeueAfterVerification(requeueAfter, check.GetTimeout())
 		}
 	case sleepInfoData.IsWakeUpOperation():
-		if err := resources.wakeUp(ctx); err != nil {
+		// the live resources are compared with the stored state before they
+		// are changed by the wake up.
+		diffs, err := getWakeUpDiffs(ctx, r.Client, secret, sleepInfo)
+		if err != nil {
+			log.Error(err, "fails to get the changes during the sleep")
+		}
+		err = resources.wakeUp(ctx)
+		if err == nil {
+			r.completeOperation(ctx, log, secretName, req.Namespace, getStoredStateKeys(secret))
+		}
+		r.recordOperation(ctx, log, now, sleepInfo, sleepInfoData.CurrentOperationType, actor, resources, runningPods, states, diffs, err)
+		if err != nil {
 			log.Error(err, "fails to handle wake up")
-			return ctrl.Result{
-				Requeue: true,
-			}, err
+			r.setOperationFailed(ctx, log, sleepInfo, err)
+			return r.getOperationErrorResult(log, req.Namespace, sleepInfoData.CurrentOperationType, err)
+		}
+		r.expireAlertsSilence(ctx, log, sleepInfo)
+		r.resetSleepCompletion(req.Namespace)
+		r.wakeUpIdleDeployments(ctx, log, sleepInfo, now)
+		if verification := sleepInfo.GetWakeUpVerification(); verification != nil {
+			// the wake up is verified by the first reconcile after the delay.
+			requeueAfter = getRequeueAfterVerification(requeueAfter, verification.GetDelay())
 		}
 	default:
 		return ctrl.Result{}, fmt.Errorf("operation %s not supported", sleepInfoData.CurrentOperationType)
 	}
+	if r.ThrottleBackoff != nil {
+		r.ThrottleBackoff.Reset(req.Namespace)
+	}
+	// the deferred operations of the Deployments are checked before the next operation.
+	nextDeferred := r.handleDeferredOperations(ctx, log, sleepInfo, sleepInfoData.IsSleepOperation(), nil, now, now)
+	requeueAfter = getRequeueAfterDeferred(requeueAfter, nextDeferred, now)
+	r.resetOperationFailed(ctx, log, sleepInfo)
+	opLog.Info("operation completed", "duration", r.Clock.Now().Sub(now).String())
 
 	return ctrl.Result{
 		RequeueAfter: requeueAfter,
 	}, nil
 }
 
+// getOperationErrorResult returns the result of the reconcile of a failed
+// operation. If the API server is throttling the requests, the operation is
+// retried after the back-off of the namespace, instead of the rate limiter
+// one, so that the API server is not hammered.
+func (r *SleepInfoReconciler) getOperationErrorResult(log logr.Logger, namespace, operationType string, err error) (ctrl.Result, error) {
+	retryAfter, isThrottled := getThrottling(err)
+	if !isThrottled {
+		return ctrl.Result{Requeue: true}, err
+	}
+	r.Metrics.ThrottledOperations.WithLabelValues(operationType).Inc()
+	if r.ThrottleBackoff == nil {
+		return ctrl.Result{Requeue: true}, err
+	}
+	requeueAfter := r.ThrottleBackoff.Next(namespace, retryAfter)
+	log.Info("operation throttled by the API server, retry later", "retryAfter", retryAfter.String(), "requeueAfter", requeueAfter.String())
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *SleepInfoReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if r.Clock == nil {
-		r.Clock = realClock{}
+		r.Clock = clock.RealClock{}
+	}
+
+	maxConcurrentReconciles := r.MaxConcurrentReconciles
+	if maxConcurrentReconciles <= 0 {
+		maxConcurrentReconciles = defaultMaxConcurrentReconciles
 	}
 
-	pred := predicate.GenerationChangedPredicate{}
-	return ctrl.NewControllerManagedBy(mgr).
+	// the annotations are watched for the wake up requested by the dependent
+	// SleepInfo, and the resyncs so that all the SleepInfo are reconciled at
+	// least every sync period of the manager.
+	pred := predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}, resyncPredicate)
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&kubegreenv1alpha1.SleepInfo{}).
+		Watches(
+			&source.Kind{Type: &batchv1.CronJob{}},
+			handler.EnqueueRequestsFromMapFunc(r.getSleepInfosToEnforce),
+			builder.WithPredicates(cronJobResumedPredicate),
+		)
+	// the namespaces are cluster scoped, they are watched only if the manager
+	// watches the whole cluster.
+	if len(r.WatchNamespaces) == 0 {
+		b = b.Watches(
+			&source.Kind{Type: &v1.Namespace{}},
+			handler.EnqueueRequestsFromMapFunc(r.getSleepInfosOfNamespace),
+			builder.WithPredicates(namespaceForcedAwakePredicate),
+		)
+	}
+	return b.
 		WithOptions(controller.Options{
-			MaxConcurrentReconciles: 20,
+			MaxConcurrentReconciles: maxConcurrentReconciles,
+			RateLimiter:             r.RateLimiter,
 		}).
 		WithEventFilter(pred).
 		Complete(r)
 }
 
+// resyncPredicate filters the updates of the periodic resync of the cache,
+// whose object is unchanged.
+var resyncPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return e.ObjectOld.GetResourceVersion() == e.ObjectNew.GetResourceVersion()
+	},
+}
+
+// cronJobResumedPredicate filters the updates of the CronJobs resumed, i.e.
+// whose suspend field is changed from true.
+var cronJobResumedPredicate = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return false },
+	DeleteFunc:  func(event.DeleteEvent) bool { return false },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldCronJob, ok := e.ObjectOld.(*batchv1.CronJob)
+		if !ok {
+			return false
+		}
+		newCronJob, ok := e.ObjectNew.(*batchv1.CronJob)
+		if !ok {
+			return false
+		}
+		return isCronJobSuspended(oldCronJob) && !isCronJobSuspended(newCronJob)
+	},
+}
+
+func isCronJobSuspended(cronJob *batchv1.CronJob) bool {
+	return cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend
+}
+
+// getSleepInfosToEnforce returns the SleepInfo with the sleep enforced in the
+// namespace of the object.
+func (r *SleepInfoReconciler) getSleepInfosToEnforce(obj client.Object) []reconcile.Request {
+	sleepInfos := kubegreenv1alpha1.SleepInfoList{}
+	if err := r.Client.List(context.Background(), &sleepInfos, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "fails to list sleepinfos", "namespace", obj.GetNamespace())
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, sleepInfo := range sleepInfos.Items {
+		if !sleepInfo.IsSleepEnforced() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: sleepInfo.Namespace, Name: sleepInfo.Name},
+		})
+	}
+	return requests
+}
+
 func (r *SleepInfoReconciler) getSleepInfo(ctx context.Context, req ctrl.Request) (*kubegreenv1alpha1.SleepInfo, error) {
 	sleepInfo := &kubegreenv1alpha1.SleepInfo{}
 	if err := r.Client.Get(ctx, req.NamespacedName, sleepInfo); err != nil {
@@ -222,8 +860,299 @@ func (r *SleepInfoReconciler) getSleepInfo(ctx context.Context, req ctrl.Request
 	return sleepInfo, nil
 }
 
+// getSleepInfoWithDefaults returns a copy of the SleepInfo with the controller
+// defaults applied to the fields not set by the user.
+func (r *SleepInfoReconciler) getSleepInfoWithDefaults(sleepInfo *kubegreenv1alpha1.SleepInfo) *kubegreenv1alpha1.SleepInfo {
+	sleepInfoWithDefaults := sleepInfo.DeepCopy()
+	if sleepInfoWithDefaults.Spec.TimeZone == "" {
+		sleepInfoWithDefaults.Spec.TimeZone = r.DefaultTimeZone
+	}
+	return sleepInfoWithDefaults
+}
+
+// recordOperation keeps track of the performed operation in the SleepInfo
+// status history and in the audit log, and records its summary as event.
+func (r *SleepInfoReconciler) recordOperation(
+	ctx context.Context,
+	log logr.Logger,
+	now time.Time,
+	sleepInfo *kubegreenv1alpha1.SleepInfo,
+	operationType string,
+	actor string,
+	resources Resources,
+	runningPods *kubegreenv1alpha1.RunningPods,
+	states []ResourceState,
+	diffs []kubegreenv1alpha1.ResourceDiff,
+	operationErr error,
+) {
+	if err := r.appendOperationHistory(ctx, now, sleepInfo, operationType, resources, runningPods, diffs, operationErr); err != nil {
+		log.Error(err, "fails to update sleepInfo operations history")
+	}
+	if failedResources := resources.getFailedResources(); len(failedResources) > 0 && r.Recorder != nil {
+		names := make([]string, 0, len(failedResources))
+		for _, failedResource := range failedResources {
+			names = append(names, fmt.Sprintf("%s/%s", failedResource.Kind, failedResource.Name))
+		}
+		r.Recorder.Eventf(sleepInfo, v1.EventTypeWarning, "ResourcesSkipped", "%s operation: resources skipped because their patch failed: %s", operationType, strings.Join(names, ", "))
+	}
+	if len(diffs) > 0 && r.Recorder != nil {
+		changes := make([]string, 0, len(diffs))
+		for _, diff := range diffs {
+			changes = append(changes, fmt.Sprintf("%s/%s %s", diff.Kind, diff.Name, diff.Diff))
+		}
+		r.Recorder.Eventf(sleepInfo, v1.EventTypeWarning, "ChangedDuringSleep", "%s operation: resources changed during the sleep: %s", operationType, strings.Join(changes, "; "))
+	}
+	summary := getOperationSummary(operationType, states, resources, operationErr)
+	log.Info("operation summary", "summary", summary)
+	if r.Recorder != nil {
+		eventType := v1.EventTypeNormal
+		if operationErr != nil || len(resources.getFailedResources()) > 0 {
+			eventType = v1.EventTypeWarning
+		}
+		r.Recorder.Event(sleepInfo, eventType, "OperationSummary", summary)
+	}
+	for kind, results := range resources.getResourceResults(operationErr) {
+		for result, count := range results {
+			r.Metrics.OperationResources.WithLabelValues(operationType, kind, result).Add(float64(count))
+		}
+	}
+	r.writeAuditEvent(ctx, log, now, sleepInfo, operationType, actor, resources, operationErr)
+}
+
+// appendOperationHistory adds the operation to the SleepInfo status, keeping
+// only the last maxOperationsHistory operations. If the running pods are
+// recorded, the operation is added also to the sleep records, keeping the
+// last maxSleepRecords.
+func (r *SleepInfoReconciler) appendOperationHistory(
+	ctx context.Context,
+	now time.Time,
+	currentSleepInfo *kubegreenv1alpha1.SleepInfo,
+	operationType string,
+	resources Resources,
+	runningPods *kubegreenv1alpha1.RunningPods,
+	diffs []kubegreenv1alpha1.ResourceDiff,
+	operationErr error,
+) error {
+	operation := kubegreenv1alpha1.OperationHistory{
+		Type:            operationType,
+		Time:            metav1.NewTime(now),
+		ResourceCounts:  resources.getResourceCounts(),
+		RunningPods:     runningPods,
+		FailedResources: resources.getFailedResources(),
+		Diffs:           diffs,
+	}
+	if operationErr != nil {
+		operation.Error = operationErr.Error()
+	} else if operationType == wakeUpOperation && currentSleepInfo.GetWakeUpVerification() != nil {
+		operation.Verification = kubegreenv1alpha1.WakeUpVerificationPending
+	}
+
+	sleepInfo := currentSleepInfo.DeepCopy()
+	history := append(sleepInfo.Status.OperationsHistory, operation)
+	if len(history) > maxOperationsHistory {
+		history = history[len(history)-maxOperationsHistory:]
+	}
+	sleepInfo.Status.OperationsHistory = history
+	if r.PodReader != nil {
+		records := append(sleepInfo.Status.SleepRecords, kubegreenv1alpha1.SleepRecord{
+			Type:        operationType,
+			Time:        metav1.NewTime(now),
+			RunningPods: runningPods,
+		})
+		if len(records) > maxSleepRecords {
+			records = records[len(records)-maxSleepRecords:]
+		}
+		sleepInfo.Status.SleepRecords = records
+	}
+	return r.Status().Patch(ctx, sleepInfo, client.MergeFrom(currentSleepInfo))
+}
+
+// writeAuditEvent ships the audit event of the operation, triggered by the
+// actor, to the AuditSink. A failure in writing the event is only logged, so
+// it does not block the operation.
+func (r *SleepInfoReconciler) writeAuditEvent(
+	ctx context.Context,
+	log logr.Logger,
+	now time.Time,
+	sleepInfo *kubegreenv1alpha1.SleepInfo,
+	operationType string,
+	actor string,
+	resources Resources,
+	operationErr error,
+) {
+	if r.AuditSink == nil {
+		return
+	}
+	event := audit.Event{
+		Timestamp: now,
+		Namespace: sleepInfo.Namespace,
+		SleepInfo: sleepInfo.Name,
+		Operation: operationType,
+		Actor:     actor,
+		Resources: resources.getResourceNames(),
+		Outcome:   audit.OutcomeSuccess,
+	}
+	if operationErr != nil {
+		event.Outcome = audit.OutcomeFailure
+		event.Error = operationErr.Error()
+	}
+	if err := r.AuditSink.Write(ctx, event); err != nil {
+		log.Error(err, "fails to write audit event")
+	}
+}
+
+// isSleepConditionMet evaluates the sleep condition of the SleepInfo, if set.
+// If the condition cannot be evaluated, its failure policy applies.
+func (r *SleepInfoReconciler) isSleepConditionMet(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time) bool {
+	condition := sleepInfo.GetSleepCondition()
+	if condition == nil {
+		return true
+	}
+	if r.PrometheusQuerier == nil {
+		log.Error(fmt.Errorf("prometheus url not configured"), "fails to evaluate sleep condition", "failOpen", condition.IsFailOpen())
+		return condition.IsFailOpen()
+	}
+	isMet, err := r.PrometheusQuerier.IsTrue(ctx, condition.PrometheusQuery, now)
+	if err != nil {
+		log.Error(err, "fails to evaluate sleep condition", "failOpen", condition.IsFailOpen())
+		return condition.IsFailOpen()
+	}
+	return isMet
+}
+
+// silenceAlerts silences the alerts of the namespace until the next
+// operation. A failure is only logged, so it does not block the operation.
+func (r *SleepInfoReconciler) silenceAlerts(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo, endsAt time.Time) {
+	if r.Silencer == nil {
+		return
+	}
+	if err := r.Silencer.Silence(ctx, sleepInfo.Namespace, sleepInfo.Name, endsAt); err != nil {
+		log.Error(err, "fails to silence alerts")
+	}
+}
+
+// expireAlertsSilence expires the silence of the alerts of the namespace
+// created on sleep. A failure is only logged, so it does not block the operation.
+func (r *SleepInfoReconciler) expireAlertsSilence(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo) {
+	if r.Silencer == nil {
+		return
+	}
+	if err := r.Silencer.Expire(ctx, sleepInfo.Namespace, sleepInfo.Name); err != nil {
+		log.Error(err, "fails to expire alerts silence")
+	}
+}
+
+// recordDSTAdjustments records an event on the SleepInfo if the executed
+// operation or the next one is moved by a DST change of the time zone.
+func (r *SleepInfoReconciler) recordDSTAdjustments(log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo, data SleepInfoData, now time.Time) {
+	scheduleDelta := time.Duration(r.SleepDelta) * time.Second
+	nextOperationType := data.CurrentOperationType
+	if data.NextOperationSchedule != data.CurrentOperationSchedule {
+		nextOperationType = schedule.NextOperationType(data.CurrentOperationType)
+	}
+	operations := []struct {
+		operationType string
+		adjustment    string
+	}{
+		{operationType: data.CurrentOperationType, adjustment: schedule.DSTAdjustment(data.CurrentOperationSchedule, now.Add(-scheduleDelta))},
+		{operationType: nextOperationType, adjustment: schedule.DSTAdjustment(data.NextOperationSchedule, now.Add(scheduleDelta))},
+	}
+	for _, operation := range operations {
+		if operation.adjustment == "" {
+			continue
+		}
+		log.Info("operation moved by DST change", "operationType", operation.operationType, "adjustment", operation.adjustment)
+		if r.Recorder != nil {
+			r.Recorder.Eventf(sleepInfo, v1.EventTypeNormal, "DSTAdjusted", "%s operation: %s", operation.operationType, operation.adjustment)
+		}
+	}
+}
+
+// getNamespaceClient returns the client which handles the resources of the
+// namespace of the SleepInfo, impersonating its ServiceAccount if any.
+func (r *SleepInfoReconciler) getNamespaceClient(sleepInfo *kubegreenv1alpha1.SleepInfo) (client.Client, error) {
+	if r.Impersonator == nil {
+		return r.Client, nil
+	}
+	return r.Impersonator.GetClient(sleepInfo, r.Client)
+}
+
+func (r *SleepInfoReconciler) getResourceClient(log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo) (resource.ResourceClient, error) {
+	namespaceClient, err := r.getNamespaceClient(sleepInfo)
+	if err != nil {
+		return resource.ResourceClient{}, err
+	}
+	return resource.ResourceClient{
+		Client:           resource.NewChunkedClient(resource.NewTimeoutClient(namespaceClient, r.ResourceTimeout), r.OperationChunkSize, r.OperationChunkPause),
+		SleepInfo:        sleepInfo,
+		Log:              log,
+		FieldManagerName: fieldManagerName,
+		Recorder:         r.Recorder,
+		Clock:            r.Clock,
+		PodReader:        r.PodReader,
+	}, nil
+}
+
+// enforceSleep suspends again the CronJobs resumed while the namespace is
+// sleeping. A failure is only logged, so it is retried at the next reconcile.
+func (r *SleepInfoReconciler) enforceSleep(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo, data SleepInfoData) {
+	resourceClient, err := r.getResourceClient(log, sleepInfo)
+	if err != nil {
+		log.Error(err, "fails to get the client of the resources")
+		return
+	}
+	names, err := cronjobs.Resuspend(ctx, resourceClient, sleepInfo.Namespace, data.OriginalCronJobStatus)
+	if err != nil {
+		log.Error(err, "fails to suspend again resumed cronjobs")
+	}
+	if len(names) > 0 {
+		log.Info("resumed cronjobs suspended again", "cronjobs", names)
+	}
+}
+
+// sleepIdleDeployments puts to sleep the idle Deployments of the namespace.
+// A failure is only logged, so it is retried at the next idle check.
+func (r *SleepInfoReconciler) sleepIdleDeployments(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time) {
+	if r.PrometheusQuerier == nil {
+		log.Error(fmt.Errorf("prometheus url not configured"), "fails to check idle deployments")
+		return
+	}
+	resourceClient, err := r.getResourceClient(log, sleepInfo)
+	if err != nil {
+		log.Error(err, "fails to get the client of the resources")
+		return
+	}
+	names, err := idle.Sleep(ctx, resourceClient, r.PrometheusQuerier, sleepInfo.Namespace, now)
+	if err != nil {
+		log.Error(err, "fails to put to sleep idle deployments")
+	}
+	if len(names) > 0 {
+		log.Info("idle deployments put to sleep", "deployments", names)
+	}
+}
+
+// wakeUpIdleDeployments wakes up the Deployments put to sleep because idle.
+// A failure is only logged, so it does not block the operation.
+func (r *SleepInfoReconciler) wakeUpIdleDeployments(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time) {
+	if sleepInfo.GetIdleSleep() == nil {
+		return
+	}
+	resourceClient, err := r.getResourceClient(log, sleepInfo)
+	if err != nil {
+		log.Error(err, "fails to get the client of the resources")
+		return
+	}
+	names, err := idle.WakeUp(ctx, resourceClient, sleepInfo.Namespace, now)
+	if err != nil {
+		log.Error(err, "fails to wake up idle deployments")
+	}
+	if len(names) > 0 {
+		log.Info("idle deployments woken up", "deployments", names)
+	}
+}
+
 func skipWakeUpIfSleepNotPerformed(currentOperationCronSchedule string, nextSchedule, now time.Time) (time.Duration, error) {
-	nextOpSched, err := getCronParsed(currentOperationCronSchedule)
+	nextOpSched, err := schedule.Parse(currentOperationCronSchedule)
 	if err != nil {
 		return 0, fmt.Errorf("fails to parse cron current schedule: %s", err)
 	}
@@ -243,6 +1172,11 @@ func (r SleepInfoReconciler) handleSleepInfoStatus(
 	sleepInfo := currentSleepInfo.DeepCopy()
 	sleepInfo.Status.LastScheduleTime = metav1.NewTime(now)
 	sleepInfo.Status.OperationType = currentOperationType
+	sleepInfo.Status.ObservedGeneration = sleepInfo.Generation
+	sleepInfo.Status.FrozenBy = ""
+	if currentOperationType == wakeUpOperation {
+		meta.RemoveStatusCondition(&sleepInfo.Status.Conditions, kubegreenv1alpha1.SleepIncompleteCondition)
+	}
 	if !resources.hasResources() {
 		sleepInfo.Status.OperationType = ""
 	}
@@ -256,6 +1190,17 @@ type SleepInfoData struct {
 	CurrentOperationSchedule    string
 	NextOperationSchedule       string
 	OriginalCronJobStatus       map[string]bool
+	OriginalJobStatus           map[string]bool
+	// OriginalReplicaSetsReplicas and OriginalReplicationControllersReplicas
+	// are the replicas of the orphan ReplicaSets and ReplicationControllers.
+	OriginalReplicaSetsReplicas            map[string]int32
+	OriginalReplicationControllersReplicas map[string]int32
+	OriginalDaemonSetsNodeSelector         map[string]map[string]string
+	OriginalPodDisruptionBudgets           poddisruptionbudgets.OriginalBudgets
+	OriginalCustomResourcesInfo            customresources.OriginalInfo
+	// PendingOperationID is the id of the operation interrupted before its
+	// end, e.g. by a leader change, which is resumed as CurrentOperationType.
+	PendingOperationID string
 }
 
 func (s SleepInfoData) IsWakeUpOperation() bool {
@@ -269,11 +1214,11 @@ func (s SleepInfoData) IsSleepOperation() bool {
 func getSleepInfoData(secret *v1.Secret, sleepInfo *kubegreenv1alpha1.SleepInfo) (SleepInfoData, error) {
 	sleepSchedule, err := sleepInfo.GetSleepSchedule()
 	if err != nil {
-		return SleepInfoData{}, err
+		return SleepInfoData{}, &ScheduleError{Err: err}
 	}
 	wakeUpSchedule, err := sleepInfo.GetWakeUpSchedule()
 	if err != nil {
-		return SleepInfoData{}, err
+		return SleepInfoData{}, &ScheduleError{Err: err}
 	}
 
 	sleepInfoData := SleepInfoData{
@@ -292,21 +1237,28 @@ func getSleepInfoData(secret *v1.Secret, sleepInfo *kubegreenv1alpha1.SleepInfo)
 
 	err = setOriginalResourceInfoToRestoreInSleepInfo(data, &sleepInfoData)
 	if err != nil {
-		return SleepInfoData{}, fmt.Errorf("fails to set original resource info to restore in SleepInfo %s: %s", sleepInfo.Name, err)
+		return SleepInfoData{}, &StateStoreError{Op: "decode", Secret: secret.Name, Err: fmt.Errorf("fails to set original resource info to restore in SleepInfo %s: %s", sleepInfo.Name, err)}
 	}
 
 	lastSchedule, err := time.Parse(time.RFC3339, string(data[lastScheduleKey]))
 	if err != nil {
-		return SleepInfoData{}, fmt.Errorf("fails to parse %s: %s", lastScheduleKey, err)
+		return SleepInfoData{}, &StateStoreError{Op: "decode", Secret: secret.Name, Err: fmt.Errorf("fails to parse %s: %s", lastScheduleKey, err)}
 	}
 	sleepInfoData.LastSchedule = lastSchedule
 
 	lastOperation := string(data[lastOperationKey])
+	sleepInfoData.PendingOperationID = string(data[pendingOperationKey])
+	// the pending operation is resumed, instead of executing the next one.
+	isPending := sleepInfoData.PendingOperationID != ""
+	isWakeUp := (lastOperation == sleepOperation && !isPending) || (lastOperation == wakeUpOperation && isPending)
 
-	if lastOperation == sleepOperation && wakeUpSchedule != "" {
+	if isWakeUp && wakeUpSchedule != "" {
 		sleepInfoData.CurrentOperationSchedule = wakeUpSchedule
 		sleepInfoData.NextOperationSchedule = sleepSchedule
 		sleepInfoData.CurrentOperationType = wakeUpOperation
+	} else if isWakeUp && isPending {
+		// the wake up forced on a SleepInfo without wake up, e.g. by the teardown.
+		sleepInfoData.CurrentOperationType = wakeUpOperation
 	}
 
 	return sleepInfoData, nil
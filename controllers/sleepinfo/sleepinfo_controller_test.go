@@ -0,0 +1,1026 @@
+package sleepinfo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/audit"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/deployments"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/metrics"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/internal/namespacefilter"
+	"github.com/kube-green/kube-green/internal/testutil"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestGetSleepInfoWithDefaults(t *testing.T) {
+	t.Run("set default time zone if not set", func(t *testing.T) {
+		r := SleepInfoReconciler{
+			DefaultTimeZone: "Europe/Rome",
+		}
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{
+			Spec: kubegreenv1alpha1.SleepInfoSpec{
+				Weekdays:  "1-5",
+				SleepTime: "20:00",
+			},
+		}
+
+		sleepInfoWithDefaults := r.getSleepInfoWithDefaults(sleepInfo)
+		require.Equal(t, "Europe/Rome", sleepInfoWithDefaults.Spec.TimeZone)
+		require.Empty(t, sleepInfo.Spec.TimeZone, "original SleepInfo must not be changed")
+
+		schedule, err := sleepInfoWithDefaults.GetSleepSchedule()
+		require.NoError(t, err)
+		require.Equal(t, "CRON_TZ=Europe/Rome 00 20 * * 1-5", schedule)
+	})
+
+	t.Run("do not override time zone set in SleepInfo", func(t *testing.T) {
+		r := SleepInfoReconciler{
+			DefaultTimeZone: "Europe/Rome",
+		}
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{
+			Spec: kubegreenv1alpha1.SleepInfoSpec{
+				TimeZone: "America/New_York",
+			},
+		}
+
+		require.Equal(t, "America/New_York", r.getSleepInfoWithDefaults(sleepInfo).Spec.TimeZone)
+	})
+
+	t.Run("without default time zone", func(t *testing.T) {
+		r := SleepInfoReconciler{}
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{}
+
+		require.Equal(t, sleepInfo, r.getSleepInfoWithDefaults(sleepInfo))
+	})
+}
+
+func TestIsNamespaceAllowed(t *testing.T) {
+	namespace := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "team-a",
+			Labels: map[string]string{
+				"env": "dev",
+			},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithObjects(namespace).Build()
+
+	tests := []struct {
+		name            string
+		filter          func(t *testing.T) namespacefilter.Filter
+		namespaceName   string
+		expected        bool
+		expectedIsError bool
+	}{
+		{
+			name: "zero value filter allows all namespaces",
+			filter: func(t *testing.T) namespacefilter.Filter {
+				return namespacefilter.Filter{}
+			},
+			namespaceName: "not-existent",
+			expected:      true,
+		},
+		{
+			name: "denied by name",
+			filter: func(t *testing.T) namespacefilter.Filter {
+				f, err := namespacefilter.New(nil, []string{"team-*"}, nil, nil)
+				require.NoError(t, err)
+				return f
+			},
+			namespaceName: "team-a",
+			expected:      false,
+		},
+		{
+			name: "allowed by label selector",
+			filter: func(t *testing.T) namespacefilter.Filter {
+				selector, err := labels.Parse("env=dev")
+				require.NoError(t, err)
+				f, err := namespacefilter.New(nil, nil, selector, nil)
+				require.NoError(t, err)
+				return f
+			},
+			namespaceName: "team-a",
+			expected:      true,
+		},
+		{
+			name: "denied by label selector",
+			filter: func(t *testing.T) namespacefilter.Filter {
+				selector, err := labels.Parse("env=dev")
+				require.NoError(t, err)
+				f, err := namespacefilter.New(nil, nil, nil, selector)
+				require.NoError(t, err)
+				return f
+			},
+			namespaceName: "team-a",
+			expected:      false,
+		},
+		{
+			name: "namespace not found with label selector",
+			filter: func(t *testing.T) namespacefilter.Filter {
+				selector, err := labels.Parse("env=dev")
+				require.NoError(t, err)
+				f, err := namespacefilter.New(nil, nil, selector, nil)
+				require.NoError(t, err)
+				return f
+			},
+			namespaceName:   "not-existent",
+			expected:        false,
+			expectedIsError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := SleepInfoReconciler{
+				Client:          fakeClient,
+				NamespaceFilter: test.filter(t),
+			}
+
+			isAllowed, err := r.isNamespaceAllowed(context.Background(), test.namespaceName)
+			if test.expectedIsError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.expected, isAllowed)
+		})
+	}
+}
+
+func TestIsNamespaceProtected(t *testing.T) {
+	getNamespace := func(name string, labels map[string]string) *v1.Namespace {
+		return &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	fakeClient := fake.NewClientBuilder().WithObjects(
+		getNamespace("team-a", nil),
+		getNamespace("labeled", map[string]string{kubegreenv1alpha1.ProtectedNamespaceLabel: "true"}),
+	).Build()
+	r := SleepInfoReconciler{
+		Client:              fakeClient,
+		ProtectedNamespaces: []string{"platform-*"},
+	}
+
+	tests := []struct {
+		namespaceName   string
+		expected        bool
+		expectedIsError bool
+	}{
+		{namespaceName: "team-a", expected: false},
+		{namespaceName: "kube-system", expected: true},
+		{namespaceName: "platform-ingress", expected: true},
+		{namespaceName: "labeled", expected: true},
+		{namespaceName: "not-existent", expected: false, expectedIsError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.namespaceName, func(t *testing.T) {
+			isProtected, err := r.isNamespaceProtected(context.Background(), test.namespaceName)
+			if test.expectedIsError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.expected, isProtected)
+		})
+	}
+}
+
+func TestIsNamespaceProtectedWithWatchNamespaces(t *testing.T) {
+	// the controller with namespace scoped permissions cannot get the
+	// namespaces, so their labels are ignored.
+	fakeClient := fake.NewClientBuilder().WithObjects(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "labeled", Labels: map[string]string{kubegreenv1alpha1.ProtectedNamespaceLabel: "true"}}},
+	).Build()
+	r := SleepInfoReconciler{
+		Client:              fakeClient,
+		ProtectedNamespaces: []string{"platform-*"},
+		WatchNamespaces:     []string{"team-a"},
+	}
+
+	tests := []struct {
+		namespaceName string
+		expected      bool
+	}{
+		{namespaceName: "team-a", expected: false},
+		{namespaceName: "kube-system", expected: true},
+		{namespaceName: "platform-ingress", expected: true},
+		{namespaceName: "labeled", expected: false},
+	}
+	for _, test := range tests {
+		t.Run(test.namespaceName, func(t *testing.T) {
+			isProtected, err := r.isNamespaceProtected(context.Background(), test.namespaceName)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, isProtected)
+		})
+	}
+}
+
+type auditSinkMock struct {
+	events []audit.Event
+	err    error
+}
+
+func (s *auditSinkMock) Write(_ context.Context, event audit.Event) error {
+	s.events = append(s.events, event)
+	return s.err
+}
+
+func TestWriteAuditEvent(t *testing.T) {
+	now := time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sleepinfo",
+			Namespace: "my-namespace",
+		},
+	}
+	resources := newResourcesMock(t, resource.Mock{
+		MockResourceNames: []string{"deploy1"},
+	}, resource.Mock{})
+	log := zap.New(zap.UseDevMode(true))
+
+	t.Run("audit disabled", func(t *testing.T) {
+		r := SleepInfoReconciler{}
+		require.NotPanics(t, func() {
+			r.writeAuditEvent(context.Background(), log, now, sleepInfo, sleepOperation, audit.ActorSchedule, resources, nil)
+		})
+	})
+
+	t.Run("successful operation", func(t *testing.T) {
+		sink := &auditSinkMock{}
+		r := SleepInfoReconciler{AuditSink: sink}
+
+		r.writeAuditEvent(context.Background(), log, now, sleepInfo, sleepOperation, audit.ActorSchedule, resources, nil)
+
+		require.Equal(t, []audit.Event{
+			{
+				Timestamp: now,
+				Namespace: "my-namespace",
+				SleepInfo: "sleepinfo",
+				Operation: sleepOperation,
+				Actor:     audit.ActorSchedule,
+				Resources: map[string][]string{
+					"Deployment": {"deploy1"},
+				},
+				Outcome: audit.OutcomeSuccess,
+			},
+		}, sink.events)
+	})
+
+	t.Run("failed operation", func(t *testing.T) {
+		sink := &auditSinkMock{}
+		r := SleepInfoReconciler{AuditSink: sink}
+
+		r.writeAuditEvent(context.Background(), log, now, sleepInfo, wakeUpOperation, audit.ActorManual, resources, fmt.Errorf("some error"))
+
+		require.Len(t, sink.events, 1)
+		require.Equal(t, audit.ActorManual, sink.events[0].Actor)
+		require.Equal(t, audit.OutcomeFailure, sink.events[0].Outcome)
+		require.Equal(t, "some error", sink.events[0].Error)
+	})
+
+	t.Run("sink error does not panic", func(t *testing.T) {
+		sink := &auditSinkMock{err: fmt.Errorf("sink error")}
+		r := SleepInfoReconciler{AuditSink: sink}
+
+		r.writeAuditEvent(context.Background(), log, now, sleepInfo, sleepOperation, audit.ActorSchedule, resources, nil)
+		require.Len(t, sink.events, 1)
+	})
+}
+
+type silencerMock struct {
+	silenced []string
+	expired  []string
+	endsAt   time.Time
+	err      error
+}
+
+func (s *silencerMock) Silence(_ context.Context, namespace, sleepInfo string, endsAt time.Time) error {
+	s.silenced = append(s.silenced, namespace+"/"+sleepInfo)
+	s.endsAt = endsAt
+	return s.err
+}
+
+func (s *silencerMock) Expire(_ context.Context, namespace, sleepInfo string) error {
+	s.expired = append(s.expired, namespace+"/"+sleepInfo)
+	return s.err
+}
+
+func TestAlertsSilence(t *testing.T) {
+	endsAt := time.Date(2021, 3, 24, 8, 0, 0, 0, time.UTC)
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sleepinfo",
+			Namespace: "my-namespace",
+		},
+	}
+	log := zap.New(zap.UseDevMode(true))
+
+	t.Run("silencer disabled", func(t *testing.T) {
+		r := SleepInfoReconciler{}
+		require.NotPanics(t, func() {
+			r.silenceAlerts(context.Background(), log, sleepInfo, endsAt)
+			r.expireAlertsSilence(context.Background(), log, sleepInfo)
+		})
+	})
+
+	t.Run("silence and expire", func(t *testing.T) {
+		silencer := &silencerMock{}
+		r := SleepInfoReconciler{Silencer: silencer}
+
+		r.silenceAlerts(context.Background(), log, sleepInfo, endsAt)
+		require.Equal(t, []string{"my-namespace/sleepinfo"}, silencer.silenced)
+		require.Equal(t, endsAt, silencer.endsAt)
+
+		r.expireAlertsSilence(context.Background(), log, sleepInfo)
+		require.Equal(t, []string{"my-namespace/sleepinfo"}, silencer.expired)
+	})
+
+	t.Run("silencer error does not panic", func(t *testing.T) {
+		silencer := &silencerMock{err: fmt.Errorf("alertmanager error")}
+		r := SleepInfoReconciler{Silencer: silencer}
+
+		r.silenceAlerts(context.Background(), log, sleepInfo, endsAt)
+		r.expireAlertsSilence(context.Background(), log, sleepInfo)
+		require.Len(t, silencer.silenced, 1)
+		require.Len(t, silencer.expired, 1)
+	})
+}
+
+type querierMock struct {
+	queries []string
+	isTrue  bool
+	err     error
+}
+
+func (q *querierMock) IsTrue(_ context.Context, query string, _ time.Time) (bool, error) {
+	q.queries = append(q.queries, query)
+	return q.isTrue, q.err
+}
+
+func TestIsSleepConditionMet(t *testing.T) {
+	now := time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)
+	log := zap.New(zap.UseDevMode(true))
+	getSleepInfo := func(condition *kubegreenv1alpha1.SleepCondition) *kubegreenv1alpha1.SleepInfo {
+		return &kubegreenv1alpha1.SleepInfo{
+			Spec: kubegreenv1alpha1.SleepInfoSpec{
+				SleepCondition: condition,
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		querier   *querierMock
+		condition *kubegreenv1alpha1.SleepCondition
+		expected  bool
+	}{
+		{
+			name:     "without condition",
+			querier:  &querierMock{},
+			expected: true,
+		},
+		{
+			name:      "condition true",
+			querier:   &querierMock{isTrue: true},
+			condition: &kubegreenv1alpha1.SleepCondition{PrometheusQuery: "up == 0"},
+			expected:  true,
+		},
+		{
+			name:      "condition false",
+			querier:   &querierMock{isTrue: false},
+			condition: &kubegreenv1alpha1.SleepCondition{PrometheusQuery: "up == 0"},
+			expected:  false,
+		},
+		{
+			name:      "query fails with closed failure policy",
+			querier:   &querierMock{err: fmt.Errorf("prometheus error")},
+			condition: &kubegreenv1alpha1.SleepCondition{PrometheusQuery: "up == 0"},
+			expected:  false,
+		},
+		{
+			name:    "query fails with open failure policy",
+			querier: &querierMock{err: fmt.Errorf("prometheus error")},
+			condition: &kubegreenv1alpha1.SleepCondition{
+				PrometheusQuery: "up == 0",
+				FailurePolicy:   kubegreenv1alpha1.SleepConditionFailurePolicyOpen,
+			},
+			expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := SleepInfoReconciler{PrometheusQuerier: test.querier}
+			require.Equal(t, test.expected, r.isSleepConditionMet(context.Background(), log, getSleepInfo(test.condition), now))
+			if test.condition != nil {
+				require.Equal(t, []string{test.condition.PrometheusQuery}, test.querier.queries)
+			} else {
+				require.Empty(t, test.querier.queries)
+			}
+		})
+	}
+
+	t.Run("prometheus not configured", func(t *testing.T) {
+		r := SleepInfoReconciler{}
+		require.False(t, r.isSleepConditionMet(context.Background(), log, getSleepInfo(&kubegreenv1alpha1.SleepCondition{
+			PrometheusQuery: "up == 0",
+		}), now))
+		require.True(t, r.isSleepConditionMet(context.Background(), log, getSleepInfo(&kubegreenv1alpha1.SleepCondition{
+			PrometheusQuery: "up == 0",
+			FailurePolicy:   kubegreenv1alpha1.SleepConditionFailurePolicyOpen,
+		}), now))
+	})
+}
+
+func TestIdleDeployments(t *testing.T) {
+	now := time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)
+	log := zap.New(zap.UseDevMode(true))
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sleepinfo",
+			Namespace: "my-namespace",
+		},
+		Spec: kubegreenv1alpha1.SleepInfoSpec{
+			IdleSleep: &kubegreenv1alpha1.IdleSleep{
+				Period: metav1.Duration{Duration: time.Hour},
+			},
+		},
+	}
+	getReplicas := func(t *testing.T, c client.Client) int32 {
+		deployment := appsv1.Deployment{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "my-namespace", Name: "api"}, &deployment))
+		return *deployment.Spec.Replicas
+	}
+
+	t.Run("prometheus not configured", func(t *testing.T) {
+		deployment := deployments.GetMock(deployments.MockSpec{Name: "api", Namespace: "my-namespace", Replicas: getPtr(int32(2))})
+		c := fake.NewClientBuilder().WithObjects(&deployment).Build()
+		r := SleepInfoReconciler{Client: c}
+
+		r.sleepIdleDeployments(context.Background(), log, sleepInfo, now)
+		require.Equal(t, int32(2), getReplicas(t, c))
+	})
+
+	t.Run("put to sleep and wake up idle deployments", func(t *testing.T) {
+		deployment := deployments.GetMock(deployments.MockSpec{Name: "api", Namespace: "my-namespace", Replicas: getPtr(int32(2))})
+		c := fake.NewClientBuilder().WithObjects(&deployment).Build()
+		querier := &querierMock{isTrue: true}
+		r := SleepInfoReconciler{Client: c, PrometheusQuerier: querier}
+
+		r.sleepIdleDeployments(context.Background(), log, sleepInfo, now)
+		require.Len(t, querier.queries, 1)
+		require.Equal(t, int32(0), getReplicas(t, c))
+
+		r.wakeUpIdleDeployments(context.Background(), log, sleepInfo, now.Add(time.Hour))
+		require.Equal(t, int32(2), getReplicas(t, c))
+	})
+}
+
+func TestAppendOperationHistory(t *testing.T) {
+	now := time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	resources := newResourcesMock(t, resource.Mock{
+		MockResourceNames: []string{"deploy1", "deploy2"},
+	}, resource.Mock{
+		MockResourceNames: []string{"cronjob1"},
+	})
+
+	getSleepInfo := func(history []kubegreenv1alpha1.OperationHistory) *kubegreenv1alpha1.SleepInfo {
+		return &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sleepinfo",
+				Namespace: "my-namespace",
+			},
+			Status: kubegreenv1alpha1.SleepInfoStatus{
+				OperationsHistory: history,
+			},
+		}
+	}
+
+	t.Run("append operation", func(t *testing.T) {
+		sleepInfo := getSleepInfo(nil)
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sleepInfo).Build()
+		r := SleepInfoReconciler{Client: c}
+
+		runningPods := &kubegreenv1alpha1.RunningPods{Count: 3}
+		failedResource := kubegreenv1alpha1.FailedResource{Kind: "Deployment", Name: "deploy2", Reason: "context deadline exceeded"}
+		resources := resources
+		resources.failedResources = &resource.FailedResources{}
+		resources.failedResources.Add(failedResource)
+		err := r.appendOperationHistory(context.Background(), now, sleepInfo, sleepOperation, resources, runningPods, nil, fmt.Errorf("some error"))
+		require.NoError(t, err)
+
+		updatedSleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(sleepInfo), updatedSleepInfo))
+		require.Len(t, updatedSleepInfo.Status.OperationsHistory, 1)
+		operation := updatedSleepInfo.Status.OperationsHistory[0]
+		require.Equal(t, sleepOperation, operation.Type)
+		require.True(t, now.Equal(operation.Time.Time))
+		require.Equal(t, map[string]int{"Deployment": 2, "CronJob": 1}, operation.ResourceCounts)
+		require.Equal(t, "some error", operation.Error)
+		require.Equal(t, runningPods, operation.RunningPods)
+		require.Equal(t, []kubegreenv1alpha1.FailedResource{failedResource}, operation.FailedResources)
+		require.Empty(t, updatedSleepInfo.Status.SleepRecords, "the running pods are not recorded")
+	})
+
+	t.Run("keep only last operations", func(t *testing.T) {
+		history := []kubegreenv1alpha1.OperationHistory{}
+		for i := 0; i < maxOperationsHistory; i++ {
+			history = append(history, kubegreenv1alpha1.OperationHistory{
+				Type: sleepOperation,
+				Time: metav1.NewTime(now.Add(time.Duration(i-maxOperationsHistory) * time.Hour)),
+			})
+		}
+		sleepInfo := getSleepInfo(history)
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sleepInfo).Build()
+		r := SleepInfoReconciler{Client: c}
+
+		err := r.appendOperationHistory(context.Background(), now, sleepInfo, wakeUpOperation, resources, nil, nil, nil)
+		require.NoError(t, err)
+
+		updatedSleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(sleepInfo), updatedSleepInfo))
+		updatedHistory := updatedSleepInfo.Status.OperationsHistory
+		require.Len(t, updatedHistory, maxOperationsHistory)
+		require.True(t, history[1].Time.Equal(&updatedHistory[0].Time))
+		require.Equal(t, wakeUpOperation, updatedHistory[maxOperationsHistory-1].Type)
+	})
+
+	t.Run("keep the sleep records of more operations", func(t *testing.T) {
+		records := []kubegreenv1alpha1.SleepRecord{}
+		for i := 0; i < maxSleepRecords; i++ {
+			records = append(records, kubegreenv1alpha1.SleepRecord{
+				Type: sleepOperation,
+				Time: metav1.NewTime(now.Add(time.Duration(i-maxSleepRecords) * time.Hour)),
+			})
+		}
+		sleepInfo := getSleepInfo(nil)
+		sleepInfo.Status.SleepRecords = records
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sleepInfo).Build()
+		r := SleepInfoReconciler{Client: c, PodReader: c}
+
+		runningPods := &kubegreenv1alpha1.RunningPods{Count: 3}
+		err := r.appendOperationHistory(context.Background(), now, sleepInfo, wakeUpOperation, resources, runningPods, nil, nil)
+		require.NoError(t, err)
+
+		updatedSleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(sleepInfo), updatedSleepInfo))
+		require.Len(t, updatedSleepInfo.Status.OperationsHistory, 1)
+		updatedRecords := updatedSleepInfo.Status.SleepRecords
+		require.Len(t, updatedRecords, maxSleepRecords)
+		require.True(t, records[1].Time.Equal(&updatedRecords[0].Time))
+		require.Equal(t, wakeUpOperation, updatedRecords[maxSleepRecords-1].Type)
+		require.Equal(t, runningPods, updatedRecords[maxSleepRecords-1].RunningPods)
+	})
+
+	t.Run("set the wake up verification pending", func(t *testing.T) {
+		sleepInfo := getSleepInfo(nil)
+		sleepInfo.Spec.WakeUpPolicy = &kubegreenv1alpha1.WakeUpPolicy{
+			Verification: &kubegreenv1alpha1.WakeUpVerification{URL: "http://my-service"},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sleepInfo).Build()
+		r := SleepInfoReconciler{Client: c}
+
+		require.NoError(t, r.appendOperationHistory(context.Background(), now, sleepInfo, wakeUpOperation, resources, nil, nil, nil))
+
+		updatedSleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(sleepInfo), updatedSleepInfo))
+		require.Equal(t, kubegreenv1alpha1.WakeUpVerificationPending, updatedSleepInfo.Status.OperationsHistory[0].Verification)
+	})
+}
+
+func TestCronJobResumedPredicate(t *testing.T) {
+	getCronJob := func(suspend *bool) *batchv1.CronJob {
+		return &batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "cronjob", Namespace: "my-namespace"},
+			Spec:       batchv1.CronJobSpec{Suspend: suspend},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		old      *batchv1.CronJob
+		new      *batchv1.CronJob
+		expected bool
+	}{
+		{name: "suspend removed", old: getCronJob(getPtr(true)), new: getCronJob(nil), expected: true},
+		{name: "suspend set to false", old: getCronJob(getPtr(true)), new: getCronJob(getPtr(false)), expected: true},
+		{name: "still suspended", old: getCronJob(getPtr(true)), new: getCronJob(getPtr(true)), expected: false},
+		{name: "suspended", old: getCronJob(nil), new: getCronJob(getPtr(true)), expected: false},
+		{name: "never suspended", old: getCronJob(getPtr(false)), new: getCronJob(nil), expected: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, cronJobResumedPredicate.Update(event.UpdateEvent{ObjectOld: test.old, ObjectNew: test.new}))
+		})
+	}
+
+	require.False(t, cronJobResumedPredicate.Create(event.CreateEvent{Object: getCronJob(nil)}))
+	require.False(t, cronJobResumedPredicate.Delete(event.DeleteEvent{Object: getCronJob(nil)}))
+}
+
+func TestResyncPredicate(t *testing.T) {
+	getSleepInfo := func(resourceVersion string) *kubegreenv1alpha1.SleepInfo {
+		return &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "my-namespace", ResourceVersion: resourceVersion},
+		}
+	}
+
+	require.True(t, resyncPredicate.Update(event.UpdateEvent{ObjectOld: getSleepInfo("1"), ObjectNew: getSleepInfo("1")}), "resync")
+	require.False(t, resyncPredicate.Update(event.UpdateEvent{ObjectOld: getSleepInfo("1"), ObjectNew: getSleepInfo("2")}), "update")
+}
+
+func TestRequestDependenciesWakeUp(t *testing.T) {
+	now := time.Date(2021, 3, 23, 8, 0, 0, 0, time.UTC)
+	log := zap.New(zap.UseDevMode(true))
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	getSleepInfo := func(namespace, operationType string) *kubegreenv1alpha1.SleepInfo {
+		return &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: namespace},
+			Status:     kubegreenv1alpha1.SleepInfoStatus{OperationType: operationType},
+		}
+	}
+	frontend := getSleepInfo("frontend", wakeUpOperation)
+	frontend.Spec.DependsOn = []kubegreenv1alpha1.SleepInfoReference{
+		{Namespace: "backend"},
+		{Namespace: "database", Name: "sleepinfo"},
+	}
+	frontend.Spec.SleepGroup = "shop"
+	backend := getSleepInfo("backend", sleepOperation)
+	database := getSleepInfo("database", wakeUpOperation)
+	other := getSleepInfo("other", sleepOperation)
+	groupMember := getSleepInfo("payments", sleepOperation)
+	groupMember.Spec.SleepGroup = "shop"
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(frontend, backend, database, other, groupMember).Build()
+	r := SleepInfoReconciler{Client: c}
+	r.requestDependenciesWakeUp(context.Background(), log, frontend, now)
+
+	getAnnotations := func(sleepInfo *kubegreenv1alpha1.SleepInfo) map[string]string {
+		updatedSleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(sleepInfo), updatedSleepInfo))
+		return updatedSleepInfo.Annotations
+	}
+	require.Equal(t, map[string]string{WakeUpRequestedAtAnnotation: "2021-03-23T08:00:00Z", WakeUpRequestedByScheduleAtAnnotation: "2021-03-23T08:00:00Z"}, getAnnotations(backend))
+	require.Equal(t, map[string]string{WakeUpRequestedAtAnnotation: "2021-03-23T08:00:00Z", WakeUpRequestedByScheduleAtAnnotation: "2021-03-23T08:00:00Z"}, getAnnotations(groupMember))
+	// database is already awake and other is not a dependency.
+	require.Empty(t, getAnnotations(database))
+	require.Empty(t, getAnnotations(other))
+}
+
+func TestIsWakeUpRequested(t *testing.T) {
+	lastSchedule := time.Date(2021, 3, 22, 20, 0, 0, 0, time.UTC)
+	sleeping := SleepInfoData{CurrentOperationType: wakeUpOperation, LastSchedule: lastSchedule}
+	awake := SleepInfoData{CurrentOperationType: sleepOperation, LastSchedule: lastSchedule}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		data        SleepInfoData
+		expected    bool
+	}{
+		{name: "not requested", data: sleeping, expected: false},
+		{name: "requested while sleeping", annotations: map[string]string{WakeUpRequestedAtAnnotation: "2021-03-23T08:00:00Z"}, data: sleeping, expected: true},
+		{name: "requested at the sleep", annotations: map[string]string{WakeUpRequestedAtAnnotation: "2021-03-22T20:00:00Z"}, data: sleeping, expected: true},
+		{name: "requested before the sleep", annotations: map[string]string{WakeUpRequestedAtAnnotation: "2021-03-22T08:00:00Z"}, data: sleeping, expected: false},
+		{name: "requested while awake", annotations: map[string]string{WakeUpRequestedAtAnnotation: "2021-03-23T08:00:00Z"}, data: awake, expected: false},
+		{name: "invalid request", annotations: map[string]string{WakeUpRequestedAtAnnotation: "now"}, data: sleeping, expected: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sleepInfo := &kubegreenv1alpha1.SleepInfo{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}}
+			require.Equal(t, test.expected, isWakeUpRequested(sleepInfo, test.data))
+		})
+	}
+}
+
+func TestGetWakeUpRequestedBy(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    string
+	}{
+		{
+			name: "requested by kube-green",
+			annotations: map[string]string{
+				WakeUpRequestedAtAnnotation:           "2021-03-23T08:00:00Z",
+				WakeUpRequestedByScheduleAtAnnotation: "2021-03-23T08:00:00Z",
+			},
+			expected: audit.ActorSchedule,
+		},
+		{
+			name:        "requested with kubectl annotate",
+			annotations: map[string]string{WakeUpRequestedAtAnnotation: "2021-03-23T08:00:00Z"},
+			expected:    audit.ActorManual,
+		},
+		{
+			name: "request of kube-green overwritten with kubectl annotate",
+			annotations: map[string]string{
+				WakeUpRequestedAtAnnotation:           "2021-03-24T22:00:00Z",
+				WakeUpRequestedByScheduleAtAnnotation: "2021-03-23T08:00:00Z",
+			},
+			expected: audit.ActorManual,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sleepInfo := &kubegreenv1alpha1.SleepInfo{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}}
+			require.Equal(t, test.expected, getWakeUpRequestedBy(sleepInfo))
+		})
+	}
+}
+
+func TestIsSnoozed(t *testing.T) {
+	now := time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    bool
+	}{
+		{name: "not snoozed", expected: false},
+		{name: "snoozed", annotations: map[string]string{SnoozeUntilAnnotation: "2021-03-23T22:00:00Z"}, expected: true},
+		{name: "snooze expired", annotations: map[string]string{SnoozeUntilAnnotation: "2021-03-23T20:00:00Z"}, expected: false},
+		{name: "invalid snooze", annotations: map[string]string{SnoozeUntilAnnotation: "2h"}, expected: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sleepInfo := &kubegreenv1alpha1.SleepInfo{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}}
+			require.Equal(t, test.expected, isSnoozed(sleepInfo, now))
+		})
+	}
+}
+
+func TestRollbackSleepGroup(t *testing.T) {
+	now := time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)
+	log := zap.New(zap.UseDevMode(true))
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	getSleepInfo := func(namespace, sleepGroup string) *kubegreenv1alpha1.SleepInfo {
+		return &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: namespace},
+			Spec:       kubegreenv1alpha1.SleepInfoSpec{SleepGroup: sleepGroup},
+		}
+	}
+	failed := getSleepInfo("frontend", "shop")
+	member := getSleepInfo("backend", "shop")
+	other := getSleepInfo("other", "other-group")
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(failed, member, other).Build()
+	r := SleepInfoReconciler{Client: c}
+	r.rollbackSleepGroup(context.Background(), log, failed, now)
+
+	getAnnotations := func(sleepInfo *kubegreenv1alpha1.SleepInfo) map[string]string {
+		updatedSleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(sleepInfo), updatedSleepInfo))
+		return updatedSleepInfo.Annotations
+	}
+	require.Equal(t, map[string]string{WakeUpRequestedAtAnnotation: "2021-03-23T20:00:00Z", WakeUpRequestedByScheduleAtAnnotation: "2021-03-23T20:00:00Z"}, getAnnotations(failed))
+	require.Equal(t, map[string]string{WakeUpRequestedAtAnnotation: "2021-03-23T20:00:00Z", WakeUpRequestedByScheduleAtAnnotation: "2021-03-23T20:00:00Z"}, getAnnotations(member))
+	require.Empty(t, getAnnotations(other))
+
+	t.Run("members still awake skip the sleep", func(t *testing.T) {
+		updatedMember := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(member), updatedMember))
+
+		wokenUpBefore := SleepInfoData{CurrentOperationType: sleepOperation, LastSchedule: now.Add(-12 * time.Hour)}
+		require.True(t, isSleepGroupRolledBack(updatedMember, wokenUpBefore))
+		wokenUpAfter := SleepInfoData{CurrentOperationType: sleepOperation, LastSchedule: now.Add(time.Minute)}
+		require.False(t, isSleepGroupRolledBack(updatedMember, wokenUpAfter))
+		sleeping := SleepInfoData{CurrentOperationType: wakeUpOperation, LastSchedule: now.Add(-time.Minute)}
+		require.False(t, isSleepGroupRolledBack(updatedMember, sleeping))
+		require.True(t, isWakeUpRequested(updatedMember, sleeping))
+	})
+}
+
+func TestGetSleepInfoDataPendingOperation(t *testing.T) {
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{
+		Spec: kubegreenv1alpha1.SleepInfoSpec{
+			Weekdays:   "*",
+			SleepTime:  "20:00",
+			WakeUpTime: "08:00",
+		},
+	}
+	getSecretData := func(operationType, pendingOperationID string) *v1.Secret {
+		data := map[string][]byte{
+			lastScheduleKey:        []byte("2023-01-09T20:00:00Z"),
+			lastOperationKey:       []byte(operationType),
+			replicasBeforeSleepKey: []byte(`[{"name":"deployment1","replicas":1}]`),
+		}
+		if pendingOperationID != "" {
+			data[pendingOperationKey] = []byte(pendingOperationID)
+		}
+		return &v1.Secret{Data: data}
+	}
+
+	tests := []struct {
+		name              string
+		secret            *v1.Secret
+		expectedOperation string
+		expectedPendingID string
+		expectedOriginals map[string]int32
+	}{
+		{
+			name:              "completed sleep",
+			secret:            getSecretData(sleepOperation, ""),
+			expectedOperation: wakeUpOperation,
+			expectedOriginals: map[string]int32{"deployment1": 1},
+		},
+		{
+			name:              "pending sleep is resumed",
+			secret:            getSecretData(sleepOperation, "SLEEP-1673294400"),
+			expectedOperation: sleepOperation,
+			expectedPendingID: "SLEEP-1673294400",
+			expectedOriginals: map[string]int32{"deployment1": 1},
+		},
+		{
+			name:              "pending wake up is resumed",
+			secret:            getSecretData(wakeUpOperation, "WAKE_UP-1673294400"),
+			expectedOperation: wakeUpOperation,
+			expectedPendingID: "WAKE_UP-1673294400",
+			expectedOriginals: map[string]int32{"deployment1": 1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := getSleepInfoData(test.secret, sleepInfo)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedOperation, data.CurrentOperationType)
+			require.Equal(t, test.expectedPendingID, data.PendingOperationID)
+			require.Equal(t, test.expectedOriginals, data.OriginalDeploymentsReplicas)
+		})
+	}
+}
+
+func TestResumeFailedWakeUp(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	namespace := "my-namespace"
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "working-hours", Namespace: namespace},
+		Spec: kubegreenv1alpha1.SleepInfoSpec{
+			Weekdays:   "*",
+			SleepTime:  "20:00",
+			WakeUpTime: "08:00",
+			TimeZone:   "UTC",
+		},
+	}
+	storedReplicas := []byte(`[{"name":"api","replicas":3}]`)
+	storedSecret := getSecret(mockSecretSpec{
+		namespace: namespace,
+		name:      "sleepinfo-working-hours",
+		data: withStateChecksum(map[string][]byte{
+			lastOperationKey:       []byte(sleepOperation),
+			lastScheduleKey:        []byte("2023-01-09T20:00:00Z"),
+			replicasBeforeSleepKey: storedReplicas,
+		}),
+	})
+	var replicas0 int32 = 0
+	api := deployments.GetMock(deployments.MockSpec{Name: "api", Namespace: namespace, Replicas: &replicas0})
+
+	isPatchFailing := true
+	r := SleepInfoReconciler{
+		Client: testutil.PossiblyErroringFakeCtrlRuntimeClient{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}},
+				sleepInfo,
+				storedSecret,
+				&api,
+			).Build(),
+			ShouldError: func(method testutil.Method, obj runtime.Object) bool {
+				_, isDeployment := obj.(*appsv1.Deployment)
+				return isPatchFailing && method == testutil.Patch && isDeployment
+			},
+		},
+		Log:        zap.New(zap.UseDevMode(true)),
+		Metrics:    metrics.SetupMetricsOrDie("kube_green"),
+		Clock:      mockClock{now: "2023-01-10T08:00:30Z", t: t},
+		SleepDelta: 60,
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(sleepInfo)}
+	getSecret := func(t *testing.T) *v1.Secret {
+		t.Helper()
+		secret, err := r.getSecret(context.Background(), storedSecret.Name, namespace)
+		require.NoError(t, err)
+		return secret
+	}
+	getReplicas := func(t *testing.T) int32 {
+		t.Helper()
+		deployment := appsv1.Deployment{}
+		require.NoError(t, r.Client.Get(context.Background(), client.ObjectKeyFromObject(&api), &deployment))
+		return *deployment.Spec.Replicas
+	}
+
+	_, err := r.Reconcile(context.Background(), req)
+	require.Error(t, err)
+	require.Equal(t, int32(0), getReplicas(t))
+	secret := getSecret(t)
+	require.NotEmpty(t, secret.Data[pendingOperationKey], "the failed wake up is pending")
+	require.Equal(t, storedReplicas, secret.Data[replicasBeforeSleepKey], "the state to restore is kept")
+
+	isPatchFailing = false
+	_, err = r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), getReplicas(t))
+	secret = getSecret(t)
+	require.NotContains(t, secret.Data, pendingOperationKey)
+	require.NotContains(t, secret.Data, replicasBeforeSleepKey)
+}
+
+func TestAuditActorOfRequestedWakeUp(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	namespace := "my-namespace"
+	reconcile := func(t *testing.T, annotations map[string]string) audit.Event {
+		t.Helper()
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: "working-hours", Namespace: namespace, Annotations: annotations},
+			Spec: kubegreenv1alpha1.SleepInfoSpec{
+				Weekdays:   "*",
+				SleepTime:  "20:00",
+				WakeUpTime: "08:00",
+				TimeZone:   "UTC",
+			},
+			Status: kubegreenv1alpha1.SleepInfoStatus{OperationType: sleepOperation},
+		}
+		secret := getSecret(mockSecretSpec{
+			namespace: namespace,
+			name:      "sleepinfo-working-hours",
+			data: withStateChecksum(map[string][]byte{
+				lastOperationKey:       []byte(sleepOperation),
+				lastScheduleKey:        []byte("2023-01-09T20:00:00Z"),
+				replicasBeforeSleepKey: []byte(`[{"name":"api","replicas":3}]`),
+			}),
+		})
+		var replicas0 int32 = 0
+		api := deployments.GetMock(deployments.MockSpec{Name: "api", Namespace: namespace, Replicas: &replicas0})
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}},
+			sleepInfo,
+			secret,
+			&api,
+		).Build()
+		sink := &auditSinkMock{}
+		r := SleepInfoReconciler{
+			Client:    c,
+			Log:       zap.New(zap.UseDevMode(true)),
+			Metrics:   metrics.SetupMetricsOrDie("kube_green"),
+			Clock:     mockClock{now: "2023-01-09T22:00:00Z", t: t},
+			AuditSink: sink,
+		}
+
+		_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(sleepInfo)})
+		require.NoError(t, err)
+		require.Len(t, sink.events, 1)
+		require.Equal(t, wakeUpOperation, sink.events[0].Operation)
+		return sink.events[0]
+	}
+
+	t.Run("requested by a user", func(t *testing.T) {
+		event := reconcile(t, map[string]string{WakeUpRequestedAtAnnotation: "2023-01-09T21:00:00Z"})
+		require.Equal(t, audit.ActorManual, event.Actor)
+	})
+
+	t.Run("requested by kube-green", func(t *testing.T) {
+		event := reconcile(t, map[string]string{
+			WakeUpRequestedAtAnnotation:           "2023-01-09T21:00:00Z",
+			WakeUpRequestedByScheduleAtAnnotation: "2023-01-09T21:00:00Z",
+		})
+		require.Equal(t, audit.ActorSchedule, event.Actor)
+	})
+
+	t.Run("requested again by a user", func(t *testing.T) {
+		// e.g. with kubectl annotate --overwrite, after a wake up requested by
+		// kube-green the day before.
+		event := reconcile(t, map[string]string{
+			WakeUpRequestedAtAnnotation:           "2023-01-09T21:00:00Z",
+			WakeUpRequestedByScheduleAtAnnotation: "2023-01-08T07:00:00Z",
+		})
+		require.Equal(t, audit.ActorManual, event.Actor)
+	})
+}
@@ -83,7 +83,7 @@ func TestSleepInfoControllerReconciliation(t *testing.T) {
 				RequeueAfter: wakeUpRequeue(sleepScheduleTime) + sleepRequeue("2021-03-23T20:20:00.000Z"),
 			}, result)
 
-			secret, err := sleepInfoReconciler.getSecret(ctx, getSecretName(sleepInfoName), c.Namespace())
+			secret, err := sleepInfoReconciler.getSecret(ctx, sleepInfoReconciler.StateSecret.GetName(sleepInfoName), c.Namespace())
 			require.NoError(t, err)
 			require.NotNil(t, secret)
 			require.Equal(t, map[string][]byte{
@@ -121,7 +121,7 @@ func TestSleepInfoControllerReconciliation(t *testing.T) {
 				LastScheduleTime: metav1.NewTime(parseTime(t, lastSleepScheduleTime).Local()),
 			}, sleepInfo.Status)
 
-			secret, err := sleepInfoReconciler.getSecret(ctx, getSecretName(sleepInfoName), c.Namespace())
+			secret, err := sleepInfoReconciler.getSecret(ctx, sleepInfoReconciler.StateSecret.GetName(sleepInfoName), c.Namespace())
 			require.NoError(t, err)
 			require.NotNil(t, secret)
 			require.Equal(t, map[string][]byte{
@@ -904,7 +904,7 @@ func assertCorrectSleepOperation(t *testing.T, ctx context.Context, cfg *envconf
 	})
 
 	t.Run("secret is correctly set", func(t *testing.T) {
-		secret, err := sleepInfoReconciler.getSecret(ctx, getSecretName(assert.originalResources.sleepInfo.GetName()), cfg.Namespace())
+		secret, err := sleepInfoReconciler.getSecret(ctx, sleepInfoReconciler.StateSecret.GetName(assert.originalResources.sleepInfo.GetName()), cfg.Namespace())
 		require.NoError(t, err)
 		secretData := secret.Data
 
@@ -960,6 +960,7 @@ func assertCorrectSleepOperation(t *testing.T, ctx context.Context, cfg *envconf
 
 			expectedSecretData[originalCronjobStatusKey] = expectedStatus
 		}
+		expectedSecretData[stateChecksumKey] = []byte(getStateChecksum(expectedSecretData))
 
 		require.Equal(t, expectedSecretData, secretData)
 	})
@@ -974,10 +975,14 @@ func assertCorrectSleepOperation(t *testing.T, ctx context.Context, cfg *envconf
 			operationType = ""
 		}
 
-		require.Equal(t, kubegreenv1alpha1.SleepInfoStatus{
-			LastScheduleTime: metav1.NewTime(parseTime(t, assert.expectedScheduleTime).Local()),
-			OperationType:    operationType,
-		}, sleepInfo.Status)
+		require.Equal(t, metav1.NewTime(parseTime(t, assert.expectedScheduleTime).Local()), sleepInfo.Status.LastScheduleTime)
+		require.Equal(t, operationType, sleepInfo.Status.OperationType)
+		if operationType != "" {
+			history := sleepInfo.Status.OperationsHistory
+			require.NotEmpty(t, history)
+			require.Equal(t, sleepOperation, history[len(history)-1].Type)
+			require.Empty(t, history[len(history)-1].Error)
+		}
 	})
 
 	t.Run("is requeued after correct duration to wake up", func(t *testing.T) {
@@ -1037,7 +1042,7 @@ func assertCorrectWakeUpOperation(t *testing.T, ctx context.Context, cfg *envcon
 	})
 
 	t.Run("secret is correctly set", func(t *testing.T) {
-		secret, err := sleepInfoReconciler.getSecret(ctx, getSecretName(assert.originalResources.sleepInfo.GetName()), cfg.Namespace())
+		secret, err := sleepInfoReconciler.getSecret(ctx, sleepInfoReconciler.StateSecret.GetName(assert.originalResources.sleepInfo.GetName()), cfg.Namespace())
 		require.NoError(t, err)
 		secretData := secret.Data
 		require.Equal(t, map[string][]byte{
@@ -1049,10 +1054,12 @@ func assertCorrectWakeUpOperation(t *testing.T, ctx context.Context, cfg *envcon
 	t.Run("status correctly updated", func(t *testing.T) {
 		sleepInfo, err := sleepInfoReconciler.getSleepInfo(ctx, assert.req)
 		require.NoError(t, err)
-		require.Equal(t, kubegreenv1alpha1.SleepInfoStatus{
-			LastScheduleTime: metav1.NewTime(parseTime(t, assert.expectedScheduleTime).Round(time.Second).Local()),
-			OperationType:    wakeUpOperation,
-		}, sleepInfo.Status)
+		require.Equal(t, metav1.NewTime(parseTime(t, assert.expectedScheduleTime).Round(time.Second).Local()), sleepInfo.Status.LastScheduleTime)
+		require.Equal(t, wakeUpOperation, sleepInfo.Status.OperationType)
+		history := sleepInfo.Status.OperationsHistory
+		require.NotEmpty(t, history)
+		require.Equal(t, wakeUpOperation, history[len(history)-1].Type)
+		require.Empty(t, history[len(history)-1].Error)
 	})
 
 	t.Run("is requeued after correct duration to sleep", func(t *testing.T) {
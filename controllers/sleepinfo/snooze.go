@@ -0,0 +1,38 @@
+package sleepinfo
+
+import (
+	"context"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SnoozeUntilAnnotation postpones the sleep of a SleepInfo: its sleeps are
+// skipped until the RFC3339 time set as its value. It can be set e.g. with
+// kubectl annotate to keep a namespace awake for a late evening.
+const SnoozeUntilAnnotation = "kube-green.dev/snooze-until"
+
+// Snooze skips the sleeps of the SleepInfo until the given time, setting the
+// SnoozeUntilAnnotation. If the SleepInfo is sleeping, its wake up is
+// requested too.
+func Snooze(ctx context.Context, c client.Writer, sleepInfo *kubegreenv1alpha1.SleepInfo, now, until time.Time) error {
+	annotations := map[string]string{
+		SnoozeUntilAnnotation: until.Format(time.RFC3339),
+	}
+	if sleepInfo.Status.OperationType == sleepOperation {
+		annotations[WakeUpRequestedAtAnnotation] = now.Format(time.RFC3339)
+	}
+	return annotate(ctx, c, sleepInfo, annotations)
+}
+
+// isSnoozed returns true if the sleep of the SleepInfo is snoozed at now.
+func isSnoozed(sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time) bool {
+	value, ok := sleepInfo.Annotations[SnoozeUntilAnnotation]
+	if !ok {
+		return false
+	}
+	snoozeUntil, err := time.Parse(time.RFC3339, value)
+	return err == nil && now.Before(snoozeUntil)
+}
@@ -0,0 +1,189 @@
+package sleepinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	configv1alpha1 "github.com/kube-green/kube-green/api/config/v1alpha1"
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// stateKinds are the kinds of the resources whose original state is stored in
+// the secret of the SleepInfo, keyed by their key in the secret.
+var stateKinds = map[string]string{
+	replicasBeforeSleepKey:                       "Deployment",
+	originalCronjobStatusKey:                     "CronJob",
+	originalJobStatusKey:                         "Job",
+	replicaSetsReplicasBeforeSleepKey:            "ReplicaSet",
+	replicationControllersReplicasBeforeSleepKey: "ReplicationController",
+	originalDaemonSetsInfoKey:                    "DaemonSet",
+	originalPodDisruptionBudgetsInfoKey:          "PodDisruptionBudget",
+}
+
+// SleepState is the original state of the resources stored by the last sleep
+// of a SleepInfo, which is restored by the next wake up.
+type SleepState struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// LastOperation is the last operation performed, SLEEP or WAKE_UP. If
+	// empty, no operation has been performed yet.
+	LastOperation string `json:"lastOperation,omitempty"`
+	// CapturedAt is when the state has been stored, i.e. the time of the
+	// last operation.
+	CapturedAt *time.Time `json:"capturedAt,omitempty"`
+	// NextWakeUp is the next scheduled wake up, which restores the resources.
+	NextWakeUp *time.Time `json:"nextWakeUp,omitempty"`
+	// Resources are the resources restored by the next wake up, with their
+	// original state. It is empty if the namespace is not sleeping.
+	Resources []ResourceState `json:"resources"`
+}
+
+// ResourceState is the original state of a resource put to sleep.
+type ResourceState struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Original are the original values restored by the wake up, e.g. the
+	// replicas of a Deployment or the suspend flag of a CronJob.
+	Original map[string]interface{} `json:"original,omitempty"`
+}
+
+// GetSleepState returns the original state of the resources stored by the
+// last sleep of the SleepInfo, to audit what the next wake up will do. The
+// stateSecret and the codec are the configuration of the Secret of the
+// controller.
+func GetSleepState(ctx context.Context, c client.Reader, sleepInfo *kubegreenv1alpha1.SleepInfo, stateSecret *configv1alpha1.StateSecret, codec StateCodec, now time.Time) (SleepState, error) {
+	secret := &v1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: sleepInfo.Namespace, Name: stateSecret.GetName(sleepInfo.Name)}, secret); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return SleepState{}, fmt.Errorf("fails to get the secret of the SleepInfo: %s", err)
+		}
+		secret = nil
+	}
+	if secret != nil {
+		data, err := codec.decode(secret.Data)
+		if err != nil {
+			return SleepState{}, err
+		}
+		secret.Data = data
+	}
+	state, err := getSleepState(secret, sleepInfo)
+	if err != nil {
+		return SleepState{}, err
+	}
+	if state.LastOperation == sleepOperation {
+		nextWakeUp, err := GetNextWakeUp(sleepInfo, now)
+		if err != nil {
+			return SleepState{}, err
+		}
+		if !nextWakeUp.IsZero() {
+			state.NextWakeUp = &nextWakeUp
+		}
+	}
+	return state, nil
+}
+
+func getSleepState(secret *v1.Secret, sleepInfo *kubegreenv1alpha1.SleepInfo) (SleepState, error) {
+	state := SleepState{
+		Namespace: sleepInfo.Namespace,
+		Name:      sleepInfo.Name,
+		Resources: []ResourceState{},
+	}
+	if secret == nil || secret.Data == nil {
+		return state, nil
+	}
+	state.LastOperation = string(secret.Data[lastOperationKey])
+	if lastSchedule, ok := secret.Data[lastScheduleKey]; ok {
+		capturedAt, err := time.Parse(time.RFC3339, string(lastSchedule))
+		if err != nil {
+			return SleepState{}, fmt.Errorf("fails to parse %s: %s", lastScheduleKey, err)
+		}
+		state.CapturedAt = &capturedAt
+	}
+
+	resources, err := getStoredResourceStates(secret.Data)
+	if err != nil {
+		return SleepState{}, err
+	}
+	state.Resources = resources
+	return state, nil
+}
+
+// getStoredResourceStates decodes the original state of the resources stored
+// in the data of the secret, sorted by kind and name.
+func getStoredResourceStates(data map[string][]byte) ([]ResourceState, error) {
+	states := []ResourceState{}
+	for key, kind := range stateKinds {
+		resources, err := getResourceStates(kind, data[key])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", key, err)
+		}
+		states = append(states, resources...)
+	}
+	customResources, err := getCustomResourceStates(data[originalCustomResourcesInfoKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", originalCustomResourcesInfoKey, err)
+	}
+	states = append(states, customResources...)
+
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Kind != states[j].Kind {
+			return states[i].Kind < states[j].Kind
+		}
+		return states[i].Name < states[j].Name
+	})
+	return states, nil
+}
+
+// getResourceStates decodes the original state of the resources of a kind,
+// saved as a list of objects with the name and the original values.
+func getResourceStates(kind string, data []byte) ([]ResourceState, error) {
+	if data == nil {
+		return nil, nil
+	}
+	items := []map[string]interface{}{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+	resources := []ResourceState{}
+	for _, item := range items {
+		name, _ := item["name"].(string)
+		if name == "" {
+			continue
+		}
+		delete(item, "name")
+		resources = append(resources, ResourceState{Kind: kind, Name: name, Original: item})
+	}
+	return resources, nil
+}
+
+// getCustomResourceStates decodes the original state of the custom resources,
+// keyed by kind and name.
+func getCustomResourceStates(data []byte) ([]ResourceState, error) {
+	if data == nil {
+		return nil, nil
+	}
+	items := []struct {
+		Key      string                 `json:"key"`
+		Original map[string]interface{} `json:"original"`
+	}{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+	resources := []ResourceState{}
+	for _, item := range items {
+		kind, name, ok := strings.Cut(item.Key, "/")
+		if !ok {
+			continue
+		}
+		resources = append(resources, ResourceState{Kind: kind, Name: name, Original: item.Original})
+	}
+	return resources, nil
+}
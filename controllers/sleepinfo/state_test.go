@@ -0,0 +1,100 @@
+package sleepinfo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetSleepState(t *testing.T) {
+	// Tuesday
+	now := time.Date(2021, 3, 23, 22, 0, 0, 0, time.UTC)
+	lastSleep := time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "my-namespace"},
+		Spec: kubegreenv1alpha1.SleepInfoSpec{
+			Weekdays:   "1-5",
+			SleepTime:  "20:00",
+			WakeUpTime: "08:00",
+		},
+	}
+	getSecret := func(data map[string][]byte) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo-sleepinfo", Namespace: "my-namespace"},
+			Data:       data,
+		}
+	}
+
+	t.Run("sleeping namespace", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithObjects(getSecret(map[string][]byte{
+			lastScheduleKey:                []byte(lastSleep.Format(time.RFC3339)),
+			lastOperationKey:               []byte(sleepOperation),
+			replicasBeforeSleepKey:         []byte(`[{"name":"api","replicas":3},{"name":"worker","replicas":1}]`),
+			originalCronjobStatusKey:       []byte(`[{"name":"report","suspend":false}]`),
+			originalCustomResourcesInfoKey: []byte(`[{"key":"Kibana.kibana.k8s.elastic.co/kb1","original":{"found":true,"value":1}}]`),
+		})).Build()
+
+		state, err := GetSleepState(context.Background(), c, sleepInfo, nil, StateCodec{}, now)
+		require.NoError(t, err)
+		nextWakeUp := time.Date(2021, 3, 24, 8, 0, 0, 0, time.UTC)
+		require.Equal(t, SleepState{
+			Namespace:     "my-namespace",
+			Name:          "sleepinfo",
+			LastOperation: sleepOperation,
+			CapturedAt:    &lastSleep,
+			NextWakeUp:    &nextWakeUp,
+			Resources: []ResourceState{
+				{Kind: "CronJob", Name: "report", Original: map[string]interface{}{"suspend": false}},
+				{Kind: "Deployment", Name: "api", Original: map[string]interface{}{"replicas": float64(3)}},
+				{Kind: "Deployment", Name: "worker", Original: map[string]interface{}{"replicas": float64(1)}},
+				{Kind: "Kibana.kibana.k8s.elastic.co", Name: "kb1", Original: map[string]interface{}{"found": true, "value": float64(1)}},
+			},
+		}, state)
+	})
+
+	t.Run("awake namespace", func(t *testing.T) {
+		wakeUp := time.Date(2021, 3, 23, 8, 0, 0, 0, time.UTC)
+		c := fake.NewClientBuilder().WithObjects(getSecret(map[string][]byte{
+			lastScheduleKey:  []byte(wakeUp.Format(time.RFC3339)),
+			lastOperationKey: []byte(wakeUpOperation),
+		})).Build()
+
+		state, err := GetSleepState(context.Background(), c, sleepInfo, nil, StateCodec{}, now)
+		require.NoError(t, err)
+		require.Equal(t, SleepState{
+			Namespace:     "my-namespace",
+			Name:          "sleepinfo",
+			LastOperation: wakeUpOperation,
+			CapturedAt:    &wakeUp,
+			Resources:     []ResourceState{},
+		}, state)
+	})
+
+	t.Run("without secret", func(t *testing.T) {
+		state, err := GetSleepState(context.Background(), fake.NewClientBuilder().Build(), sleepInfo, nil, StateCodec{}, now)
+		require.NoError(t, err)
+		require.Equal(t, SleepState{
+			Namespace: "my-namespace",
+			Name:      "sleepinfo",
+			Resources: []ResourceState{},
+		}, state)
+	})
+
+	t.Run("throws if the stored state is invalid", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithObjects(getSecret(map[string][]byte{
+			lastScheduleKey:        []byte(lastSleep.Format(time.RFC3339)),
+			lastOperationKey:       []byte(sleepOperation),
+			replicasBeforeSleepKey: []byte(`{}`),
+		})).Build()
+
+		_, err := GetSleepState(context.Background(), c, sleepInfo, nil, StateCodec{}, now)
+		require.EqualError(t, err, "invalid deployment-replicas: json: cannot unmarshal object into Go value of type []map[string]interface {}")
+	})
+}
@@ -0,0 +1,138 @@
+package sleepinfo
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+const (
+	stateChecksumKey         = "checksum"
+	stateEncryptionKey       = "encryption"
+	stateEncryptionAlgorithm = "aes-256-gcm"
+)
+
+// StateCodec protects the original state of the resources stored in the
+// secret of the SleepInfo. It adds a checksum of the state, so that an edited
+// or truncated secret is detected before its values are restored, and it
+// encrypts the state if created with a key. The zero value only adds the
+// checksum.
+type StateCodec struct {
+	aead cipher.AEAD
+}
+
+// NewStateCodec returns a StateCodec which encrypts the state with AES-256-GCM,
+// with a key derived with SHA-256 from key. If key is empty, the state is not
+// encrypted.
+func NewStateCodec(key []byte) (StateCodec, error) {
+	key = bytes.TrimSpace(key)
+	if len(key) == 0 {
+		return StateCodec{}, nil
+	}
+	derivedKey := sha256.Sum256(key)
+	block, err := aes.NewCipher(derivedKey[:])
+	if err != nil {
+		return StateCodec{}, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return StateCodec{}, err
+	}
+	return StateCodec{aead: aead}, nil
+}
+
+// encode returns the original state of the resources to store in the secret,
+// encrypted if the codec has a key, with its checksum.
+func (c StateCodec) encode(data map[string][]byte) (map[string][]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	encoded := make(map[string][]byte, len(data)+2)
+	for key, value := range data {
+		if c.aead != nil {
+			nonce := make([]byte, c.aead.NonceSize())
+			if _, err := rand.Read(nonce); err != nil {
+				return nil, fmt.Errorf("fails to generate the nonce: %s", err)
+			}
+			// the key of the value is authenticated, so that the encrypted
+			// values cannot be swapped.
+			value = c.aead.Seal(nonce, nonce, value, []byte(key))
+		}
+		encoded[key] = value
+	}
+	if c.aead != nil {
+		encoded[stateEncryptionKey] = []byte(stateEncryptionAlgorithm)
+	}
+	encoded[stateChecksumKey] = []byte(getStateChecksum(encoded))
+	return encoded, nil
+}
+
+// decode verifies the checksum of the data of the secret, and returns them
+// decrypted. The secrets stored without the checksum, e.g. by a previous
+// version of kube-green, are not verified.
+func (c StateCodec) decode(data map[string][]byte) (map[string][]byte, error) {
+	if checksum, ok := data[stateChecksumKey]; ok && string(checksum) != getStateChecksum(data) {
+		return nil, fmt.Errorf("the checksum of the stored state does not match, the secret has been edited or truncated")
+	}
+	algorithm := string(data[stateEncryptionKey])
+	if algorithm != "" && algorithm != stateEncryptionAlgorithm {
+		return nil, fmt.Errorf("the stored state is encrypted with the unsupported algorithm %s", algorithm)
+	}
+	if algorithm != "" && c.aead == nil {
+		return nil, fmt.Errorf("the stored state is encrypted, but the encryption key is not set")
+	}
+
+	decoded := make(map[string][]byte, len(data))
+	for key, value := range data {
+		if key == stateChecksumKey || key == stateEncryptionKey {
+			continue
+		}
+		if algorithm != "" && !isStateMetadataKey(key) {
+			var err error
+			if value, err = c.decrypt(key, value); err != nil {
+				return nil, fmt.Errorf("fails to decrypt %s: %s", key, err)
+			}
+		}
+		decoded[key] = value
+	}
+	return decoded, nil
+}
+
+func (c StateCodec) decrypt(key string, value []byte) ([]byte, error) {
+	nonceSize := c.aead.NonceSize()
+	if len(value) < nonceSize {
+		return nil, fmt.Errorf("the value is too short")
+	}
+	return c.aead.Open(nil, value[:nonceSize], value[nonceSize:], []byte(key))
+}
+
+// isStateMetadataKey returns true for the keys of the secret which are not
+// part of the original state of the resources, so they are neither encrypted
+// nor in the checksum.
+func isStateMetadataKey(key string) bool {
+	return key == lastScheduleKey || key == lastOperationKey || key == pendingOperationKey || key == operationCheckpointKey || key == stateChecksumKey
+}
+
+// getStateChecksum returns the SHA-256 checksum of the original state of the
+// resources in the data of the secret.
+func getStateChecksum(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		if !isStateMetadataKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(hash, "%s\x00%d\x00", key, len(data[key]))
+		hash.Write(data[key])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
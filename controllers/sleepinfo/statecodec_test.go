@@ -0,0 +1,111 @@
+package sleepinfo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateCodec(t *testing.T) {
+	originalState := map[string][]byte{
+		replicasBeforeSleepKey:   []byte(`[{"name":"api","replicas":3}]`),
+		originalCronjobStatusKey: []byte(`[{"name":"report","suspend":false}]`),
+	}
+	// the secret contains the keys of the operation besides the stored state.
+	getSecretData := func(state map[string][]byte) map[string][]byte {
+		data := map[string][]byte{
+			lastScheduleKey:  []byte("2021-03-23T20:00:00Z"),
+			lastOperationKey: []byte(sleepOperation),
+		}
+		for key, value := range state {
+			data[key] = value
+		}
+		return data
+	}
+	expectedData := getSecretData(originalState)
+
+	codec, err := NewStateCodec([]byte("my-secret-key\n"))
+	require.NoError(t, err)
+
+	t.Run("without encryption", func(t *testing.T) {
+		encoded, err := StateCodec{}.encode(originalState)
+		require.NoError(t, err)
+		require.Equal(t, originalState[replicasBeforeSleepKey], encoded[replicasBeforeSleepKey])
+		require.NotEmpty(t, encoded[stateChecksumKey])
+		require.NotContains(t, encoded, stateEncryptionKey)
+
+		decoded, err := StateCodec{}.decode(getSecretData(encoded))
+		require.NoError(t, err)
+		require.Equal(t, expectedData, decoded)
+	})
+
+	t.Run("with encryption", func(t *testing.T) {
+		encoded, err := codec.encode(originalState)
+		require.NoError(t, err)
+		require.NotContains(t, string(encoded[replicasBeforeSleepKey]), "api")
+		require.Equal(t, stateEncryptionAlgorithm, string(encoded[stateEncryptionKey]))
+
+		decoded, err := codec.decode(getSecretData(encoded))
+		require.NoError(t, err)
+		require.Equal(t, expectedData, decoded)
+
+		t.Run("fails without the key", func(t *testing.T) {
+			_, err := StateCodec{}.decode(getSecretData(encoded))
+			require.EqualError(t, err, "the stored state is encrypted, but the encryption key is not set")
+		})
+
+		t.Run("fails with another key", func(t *testing.T) {
+			otherCodec, err := NewStateCodec([]byte("other-key"))
+			require.NoError(t, err)
+			_, err = otherCodec.decode(getSecretData(encoded))
+			require.ErrorContains(t, err, "fails to decrypt")
+		})
+	})
+
+	t.Run("decode the state stored without checksum", func(t *testing.T) {
+		decoded, err := codec.decode(expectedData)
+		require.NoError(t, err)
+		require.Equal(t, expectedData, decoded)
+	})
+
+	t.Run("the empty state is not encoded", func(t *testing.T) {
+		encoded, err := codec.encode(map[string][]byte{})
+		require.NoError(t, err)
+		require.Empty(t, encoded)
+	})
+
+	tests := []struct {
+		name   string
+		change func(data map[string][]byte)
+	}{
+		{
+			name: "edited state",
+			change: func(data map[string][]byte) {
+				data[replicasBeforeSleepKey] = []byte(`[{"name":"api","replicas":30}]`)
+			},
+		},
+		{
+			name: "truncated state",
+			change: func(data map[string][]byte) {
+				data[replicasBeforeSleepKey] = data[replicasBeforeSleepKey][:10]
+			},
+		},
+		{
+			name: "removed key",
+			change: func(data map[string][]byte) {
+				delete(data, originalCronjobStatusKey)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encoded, err := StateCodec{}.encode(originalState)
+			require.NoError(t, err)
+			data := getSecretData(encoded)
+			test.change(data)
+
+			_, err = StateCodec{}.decode(data)
+			require.EqualError(t, err, "the checksum of the stored state does not match, the secret has been edited or truncated")
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package sleepinfo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// StateCollector deletes the state secrets whose SleepInfo does not exist
+// anymore, e.g. because it was deleted orphaning its dependents, or because
+// its namespace has no SleepInfo at all. The orphaned state of a namespace
+// left asleep is restored before the secret is deleted, so that its resources
+// are not left asleep forever and its pending operation does not block the
+// operations of the other SleepInfo of the namespace.
+type StateCollector struct {
+	// Reconciler is the reconciler of the SleepInfo, whose configuration is
+	// used to find, decode and restore the state secrets.
+	Reconciler *SleepInfoReconciler
+	// Interval is how often the state secrets are checked.
+	Interval time.Duration
+	Log      logr.Logger
+}
+
+// Start checks the state secrets every Interval, until the context is done.
+func (c *StateCollector) Start(ctx context.Context) error {
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+	for {
+		if err := c.Run(ctx); err != nil {
+			c.Log.Error(err, "fails to collect the orphaned state secrets")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection returns true, so that only the leader collects the
+// state secrets.
+func (c *StateCollector) NeedLeaderElection() bool {
+	return true
+}
+
+// Run wakes up the resources of the orphaned state secrets, and deletes them.
+// The failure of a secret is logged, and it is retried at the next run.
+func (c *StateCollector) Run(ctx context.Context) error {
+	r := c.Reconciler
+	secrets := v1.SecretList{}
+	if err := r.Client.List(ctx, &secrets, client.MatchingLabels{managedByLabel: fieldManagerName}); err != nil {
+		return fmt.Errorf("fails to list secrets: %s", err)
+	}
+	sleepInfos := kubegreenv1alpha1.SleepInfoList{}
+	if err := r.Client.List(ctx, &sleepInfos); err != nil {
+		return fmt.Errorf("fails to list sleepinfos: %s", err)
+	}
+	stateSecrets := map[client.ObjectKey]bool{}
+	for _, sleepInfo := range sleepInfos.Items {
+		stateSecrets[client.ObjectKey{Namespace: sleepInfo.Namespace, Name: r.StateSecret.GetName(sleepInfo.Name)}] = true
+	}
+
+	for _, secret := range secrets.Items {
+		secret := secret
+		if stateSecrets[client.ObjectKeyFromObject(&secret)] || !isStateSecret(&secret) {
+			continue
+		}
+		log := c.Log.WithValues("secret", client.ObjectKeyFromObject(&secret))
+		if err := c.collect(ctx, log, &secret); err != nil {
+			log.Error(err, "fails to collect the orphaned state secret")
+		}
+	}
+	return nil
+}
+
+// collect wakes up the resources of the orphaned state secret, if they are
+// sleeping, then deletes it. The secrets of the namespaces not handled by the
+// controller are left untouched.
+func (c *StateCollector) collect(ctx context.Context, log logr.Logger, secret *v1.Secret) error {
+	r := c.Reconciler
+	isNamespaceAllowed, err := r.isNamespaceAllowed(ctx, secret.Namespace)
+	if err != nil || !isNamespaceAllowed {
+		return client.IgnoreNotFound(err)
+	}
+	isNamespaceProtected, err := r.isNamespaceProtected(ctx, secret.Namespace)
+	if err != nil || isNamespaceProtected {
+		return client.IgnoreNotFound(err)
+	}
+
+	r.Metrics.OrphanedStates.WithLabelValues(secret.Namespace).Inc()
+	log.Info("orphaned state secret found")
+	data, err := r.StateCodec.decode(secret.Data)
+	if err != nil {
+		return &StateStoreError{Op: "decode", Secret: secret.Name, Err: err}
+	}
+	state := secret.DeepCopy()
+	state.Data = data
+	if isSleeping(state) {
+		if err := c.wakeUp(ctx, log, state); err != nil {
+			return fmt.Errorf("fails to wake up the resources of the orphaned state: %s", err)
+		}
+		log.Info("resources of the orphaned state woken up")
+	}
+
+	if err := r.Client.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	log.Info("orphaned state secret deleted")
+	return nil
+}
+
+// wakeUp restores the resources in the state of the secret, on behalf of its
+// deleted SleepInfo.
+func (c *StateCollector) wakeUp(ctx context.Context, log logr.Logger, secret *v1.Secret) error {
+	r := c.Reconciler
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: getStateOwnerName(secret), Namespace: secret.Namespace},
+	}
+	sleepInfoData := SleepInfoData{CurrentOperationType: wakeUpOperation}
+	if err := setOriginalResourceInfoToRestoreInSleepInfo(secret.Data, &sleepInfoData); err != nil {
+		return &StateStoreError{Op: "decode", Secret: secret.Name, Err: err}
+	}
+	resourceClient, err := r.getResourceClient(log, sleepInfo)
+	if err != nil {
+		return err
+	}
+	resourceClient.IsWakeUp = true
+	resources, err := NewResources(ctx, resourceClient, secret.Namespace, sleepInfoData)
+	if err != nil {
+		return err
+	}
+	return resources.wakeUp(ctx)
+}
+
+// isStateSecret returns true if the secret stores the state of a SleepInfo,
+// which always has the time of its last schedule.
+func isStateSecret(secret *v1.Secret) bool {
+	_, ok := secret.Data[lastScheduleKey]
+	return ok
+}
+
+// getStateOwnerName returns the name of the SleepInfo which owns the state
+// secret or, if not owned, the name of the secret.
+func getStateOwnerName(secret *v1.Secret) string {
+	for _, owner := range secret.OwnerReferences {
+		if owner.Kind == "SleepInfo" && owner.APIVersion == kubegreenv1alpha1.GroupVersion.String() {
+			return owner.Name
+		}
+	}
+	return secret.Name
+}
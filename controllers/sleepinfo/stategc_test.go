@@ -0,0 +1,161 @@
+package sleepinfo
+
+import (
+	"context"
+	"testing"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/metrics"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestStateCollector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	getNamespace := func(name string) *v1.Namespace {
+		return &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+	getStateSecret := func(namespace, sleepInfoName string, data map[string]string) *v1.Secret {
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sleepinfo-" + sleepInfoName,
+				Namespace: namespace,
+				Labels:    map[string]string{managedByLabel: fieldManagerName},
+			},
+			Data: map[string][]byte{lastScheduleKey: []byte("2023-01-09T20:00:00Z")},
+		}
+		for key, value := range data {
+			secret.Data[key] = []byte(value)
+		}
+		return secret
+	}
+	getDeployment := func(namespace string, replicas int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: namespace},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		}
+	}
+	getCollector := func(objects ...client.Object) *StateCollector {
+		return &StateCollector{
+			Reconciler: &SleepInfoReconciler{
+				Client:  fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+				Log:     logr.Discard(),
+				Metrics: metrics.SetupMetricsOrDie("kube_green"),
+			},
+			Log: logr.Discard(),
+		}
+	}
+	isDeleted := func(t *testing.T, c *StateCollector, secret *v1.Secret) bool {
+		t.Helper()
+		err := c.Reconciler.Client.Get(context.Background(), client.ObjectKeyFromObject(secret), &v1.Secret{})
+		if apierrors.IsNotFound(err) {
+			return true
+		}
+		require.NoError(t, err)
+		return false
+	}
+
+	t.Run("wake up the resources of the orphaned state and delete it", func(t *testing.T) {
+		secret := getStateSecret("my-namespace", "deleted", map[string]string{
+			lastOperationKey:       sleepOperation,
+			replicasBeforeSleepKey: `[{"name":"frontend","replicas":3}]`,
+		})
+		c := getCollector(getNamespace("my-namespace"), secret, getDeployment("my-namespace", 0))
+
+		require.NoError(t, c.Run(context.Background()))
+
+		require.True(t, isDeleted(t, c, secret))
+		deployment := &appsv1.Deployment{}
+		require.NoError(t, c.Reconciler.Client.Get(context.Background(), client.ObjectKey{Namespace: "my-namespace", Name: "frontend"}, deployment))
+		require.Equal(t, int32(3), *deployment.Spec.Replicas)
+		require.Equal(t, float64(1), testutil.ToFloat64(c.Reconciler.Metrics.OrphanedStates.WithLabelValues("my-namespace")))
+	})
+
+	t.Run("delete the orphaned state of an awake namespace", func(t *testing.T) {
+		secret := getStateSecret("my-namespace", "deleted", map[string]string{
+			lastOperationKey: wakeUpOperation,
+		})
+		c := getCollector(getNamespace("my-namespace"), secret, getDeployment("my-namespace", 2))
+
+		require.NoError(t, c.Run(context.Background()))
+
+		require.True(t, isDeleted(t, c, secret))
+		deployment := &appsv1.Deployment{}
+		require.NoError(t, c.Reconciler.Client.Get(context.Background(), client.ObjectKey{Namespace: "my-namespace", Name: "frontend"}, deployment))
+		require.Equal(t, int32(2), *deployment.Spec.Replicas)
+	})
+
+	t.Run("keep the state of the existing SleepInfo", func(t *testing.T) {
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: "working-hours", Namespace: "my-namespace"},
+		}
+		secret := getStateSecret("my-namespace", "working-hours", map[string]string{
+			lastOperationKey: sleepOperation,
+		})
+		otherSecret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "not-a-state",
+				Namespace: "my-namespace",
+				Labels:    map[string]string{managedByLabel: fieldManagerName},
+			},
+		}
+		c := getCollector(getNamespace("my-namespace"), sleepInfo, secret, otherSecret)
+
+		require.NoError(t, c.Run(context.Background()))
+
+		require.False(t, isDeleted(t, c, secret))
+		require.False(t, isDeleted(t, c, otherSecret))
+		require.Equal(t, 0, testutil.CollectAndCount(c.Reconciler.Metrics.OrphanedStates))
+	})
+
+	t.Run("keep the state of the namespaces not handled", func(t *testing.T) {
+		protectedNamespace := getNamespace("kube-system")
+		secret := getStateSecret("kube-system", "deleted", map[string]string{
+			lastOperationKey: sleepOperation,
+		})
+		c := getCollector(protectedNamespace, secret)
+		c.Reconciler.ProtectedNamespaces = []string{"kube-system"}
+
+		require.NoError(t, c.Run(context.Background()))
+
+		require.False(t, isDeleted(t, c, secret))
+		require.Equal(t, 0, testutil.CollectAndCount(c.Reconciler.Metrics.OrphanedStates))
+	})
+
+	t.Run("keep the orphaned state which fails to be decoded", func(t *testing.T) {
+		secret := getStateSecret("my-namespace", "deleted", map[string]string{
+			lastOperationKey: sleepOperation,
+			stateChecksumKey: "invalid",
+		})
+		c := getCollector(getNamespace("my-namespace"), secret)
+
+		require.NoError(t, c.Run(context.Background()))
+
+		require.False(t, isDeleted(t, c, secret))
+		require.Equal(t, float64(1), testutil.ToFloat64(c.Reconciler.Metrics.OrphanedStates.WithLabelValues("my-namespace")))
+	})
+}
+
+func TestGetStateOwnerName(t *testing.T) {
+	secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo-working-hours"}}
+	require.Equal(t, "sleepinfo-working-hours", getStateOwnerName(secret))
+
+	secret.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: "apps/v1", Kind: "Deployment", Name: "frontend"},
+		{APIVersion: kubegreenv1alpha1.GroupVersion.String(), Kind: "SleepInfo", Name: "working-hours"},
+	}
+	require.Equal(t, "working-hours", getStateOwnerName(secret))
+}
@@ -0,0 +1,83 @@
+package sleepinfo
+
+import (
+	"context"
+	"strconv"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reportState sets the info series of the SleepInfo, with its schedule as
+// labels, and whether its namespace is asleep. The operationType is the
+// current operation of the SleepInfo, which may be newer than its status in
+// the cache.
+func (r *SleepInfoReconciler) reportState(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo, operationType string) {
+	// the labels of the SleepInfo may have changed, so its old series is
+	// deleted before the new one is set.
+	r.Metrics.SleepInfoInfo.DeletePartialMatch(prometheus.Labels{
+		"name":      sleepInfo.Name,
+		"namespace": sleepInfo.Namespace,
+	})
+	sleepInfoWithDefaults := r.getSleepInfoWithDefaults(sleepInfo)
+	// the time zone has its own label, so it is not repeated in the schedules.
+	withoutTimeZone := sleepInfoWithDefaults.DeepCopy()
+	withoutTimeZone.Spec.TimeZone = ""
+	sleepSchedule, _ := withoutTimeZone.GetSleepSchedule()
+	wakeUpSchedule, _ := withoutTimeZone.GetWakeUpSchedule()
+	r.Metrics.SleepInfoInfo.With(prometheus.Labels{
+		"name":             sleepInfo.Name,
+		"namespace":        sleepInfo.Namespace,
+		"timezone":         sleepInfoWithDefaults.Spec.TimeZone,
+		"sleep_cron":       sleepSchedule,
+		"wake_cron":        wakeUpSchedule,
+		"suspend_cronjobs": strconv.FormatBool(sleepInfo.IsCronjobsToSuspend()),
+	}).Set(1)
+
+	r.reportNamespaceAsleep(ctx, log, sleepInfo.Namespace, sleepInfo.Name, true, operationType == sleepOperation)
+}
+
+// deleteState deletes the series of the deleted SleepInfo, and updates
+// whether its namespace is asleep.
+func (r *SleepInfoReconciler) deleteState(ctx context.Context, log logr.Logger, key client.ObjectKey) {
+	r.Metrics.SleepInfoInfo.DeletePartialMatch(prometheus.Labels{
+		"name":      key.Name,
+		"namespace": key.Namespace,
+	})
+	r.reportNamespaceAsleep(ctx, log, key.Namespace, key.Name, false, false)
+}
+
+// reportNamespaceAsleep sets the namespace asleep if the SleepInfo with the
+// name is asleep, or if any other SleepInfo of the namespace is asleep. The
+// SleepInfo with the name is not read from the cache, which may be stale, but
+// from the arguments. The series of the namespace without SleepInfo is
+// deleted.
+func (r *SleepInfoReconciler) reportNamespaceAsleep(ctx context.Context, log logr.Logger, namespace, name string, exists, isAsleep bool) {
+	sleepInfos := kubegreenv1alpha1.SleepInfoList{}
+	if err := r.Client.List(ctx, &sleepInfos, client.InNamespace(namespace)); err != nil {
+		log.Error(err, "fails to list sleepinfos", "namespace", namespace)
+		return
+	}
+	hasSleepInfo := exists
+	for _, sleepInfo := range sleepInfos.Items {
+		if sleepInfo.Name == name {
+			continue
+		}
+		hasSleepInfo = true
+		if sleepInfo.Status.OperationType == sleepOperation {
+			isAsleep = true
+		}
+	}
+	if !hasSleepInfo {
+		r.Metrics.NamespaceAsleep.DeleteLabelValues(namespace)
+		return
+	}
+	value := 0.0
+	if isAsleep {
+		value = 1
+	}
+	r.Metrics.NamespaceAsleep.WithLabelValues(namespace).Set(value)
+}
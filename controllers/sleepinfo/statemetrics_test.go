@@ -0,0 +1,115 @@
+package sleepinfo
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/metrics"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReportState(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	getSleepInfo := func(name, operationType string) *kubegreenv1alpha1.SleepInfo {
+		return &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "my-namespace"},
+			Spec: kubegreenv1alpha1.SleepInfoSpec{
+				Weekdays:        "1-5",
+				SleepTime:       "20:00",
+				WakeUpTime:      "08:00",
+				SuspendCronjobs: true,
+			},
+			Status: kubegreenv1alpha1.SleepInfoStatus{OperationType: operationType},
+		}
+	}
+	getReconciler := func(objects ...client.Object) *SleepInfoReconciler {
+		return &SleepInfoReconciler{
+			Client:          fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+			Log:             logr.Discard(),
+			Metrics:         metrics.SetupMetricsOrDie("kube_green"),
+			DefaultTimeZone: "Europe/Rome",
+		}
+	}
+
+	t.Run("set the info and the namespace asleep", func(t *testing.T) {
+		sleepInfo := getSleepInfo("working-hours", "")
+		r := getReconciler(sleepInfo)
+
+		r.reportState(context.Background(), logr.Discard(), sleepInfo, sleepOperation)
+
+		require.NoError(t, testutil.CollectAndCompare(r.Metrics.SleepInfoInfo, bytes.NewBufferString(`
+		# HELP kube_green_sleepinfo_info Schedule of the SleepInfo
+		# TYPE kube_green_sleepinfo_info gauge
+		kube_green_sleepinfo_info{name="working-hours",namespace="my-namespace",sleep_cron="00 20 * * 1-5",suspend_cronjobs="true",timezone="Europe/Rome",wake_cron="00 08 * * 1-5"} 1
+		`)))
+		require.Equal(t, float64(1), testutil.ToFloat64(r.Metrics.NamespaceAsleep.WithLabelValues("my-namespace")))
+	})
+
+	t.Run("replace the info of the changed SleepInfo", func(t *testing.T) {
+		sleepInfo := getSleepInfo("working-hours", "")
+		r := getReconciler(sleepInfo)
+		r.reportState(context.Background(), logr.Discard(), sleepInfo, wakeUpOperation)
+
+		sleepInfo.Spec.TimeZone = "UTC"
+		sleepInfo.Spec.SuspendCronjobs = false
+		r.reportState(context.Background(), logr.Discard(), sleepInfo, wakeUpOperation)
+
+		require.NoError(t, testutil.CollectAndCompare(r.Metrics.SleepInfoInfo, bytes.NewBufferString(`
+		# HELP kube_green_sleepinfo_info Schedule of the SleepInfo
+		# TYPE kube_green_sleepinfo_info gauge
+		kube_green_sleepinfo_info{name="working-hours",namespace="my-namespace",sleep_cron="00 20 * * 1-5",suspend_cronjobs="false",timezone="UTC",wake_cron="00 08 * * 1-5"} 1
+		`)))
+		require.Equal(t, float64(0), testutil.ToFloat64(r.Metrics.NamespaceAsleep.WithLabelValues("my-namespace")))
+	})
+
+	t.Run("the namespace is asleep if any SleepInfo is asleep", func(t *testing.T) {
+		sleepInfo := getSleepInfo("working-hours", "")
+		r := getReconciler(sleepInfo, getSleepInfo("nights", sleepOperation))
+
+		r.reportState(context.Background(), logr.Discard(), sleepInfo, wakeUpOperation)
+
+		require.Equal(t, float64(1), testutil.ToFloat64(r.Metrics.NamespaceAsleep.WithLabelValues("my-namespace")))
+	})
+
+	t.Run("the operation overrides the stale status", func(t *testing.T) {
+		sleepInfo := getSleepInfo("working-hours", sleepOperation)
+		r := getReconciler(sleepInfo)
+
+		r.reportState(context.Background(), logr.Discard(), sleepInfo, wakeUpOperation)
+
+		require.Equal(t, float64(0), testutil.ToFloat64(r.Metrics.NamespaceAsleep.WithLabelValues("my-namespace")))
+	})
+
+	t.Run("delete the series of the deleted SleepInfo", func(t *testing.T) {
+		sleepInfo := getSleepInfo("working-hours", "")
+		r := getReconciler()
+		r.reportState(context.Background(), logr.Discard(), sleepInfo, sleepOperation)
+
+		r.deleteState(context.Background(), logr.Discard(), client.ObjectKeyFromObject(sleepInfo))
+
+		require.Equal(t, 0, testutil.CollectAndCount(r.Metrics.SleepInfoInfo))
+		require.Equal(t, 0, testutil.CollectAndCount(r.Metrics.NamespaceAsleep))
+	})
+
+	t.Run("keep the namespace of the other SleepInfo", func(t *testing.T) {
+		sleepInfo := getSleepInfo("working-hours", "")
+		r := getReconciler(getSleepInfo("nights", wakeUpOperation))
+		r.reportState(context.Background(), logr.Discard(), sleepInfo, sleepOperation)
+
+		r.deleteState(context.Background(), logr.Discard(), client.ObjectKeyFromObject(sleepInfo))
+
+		require.Equal(t, 0, testutil.CollectAndCount(r.Metrics.SleepInfoInfo))
+		require.Equal(t, float64(0), testutil.ToFloat64(r.Metrics.NamespaceAsleep.WithLabelValues("my-namespace")))
+	})
+}
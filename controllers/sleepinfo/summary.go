@@ -0,0 +1,178 @@
+package sleepinfo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// summaryKindOrder is the order of the kinds in the summary of the
+// operations, the same in which they sleep and wake up. The custom resources
+// follow, sorted by kind.
+var summaryKindOrder = []string{"Deployment", "CronJob", "Job", "ReplicaSet", "ReplicationController", "DaemonSet", "PodDisruptionBudget", "Pod"}
+
+// getOperationStates returns the resources changed by the operation, with
+// their original state: for a sleep, the state stored before the resources are
+// put to sleep, for a wake up, the state restored from the secret.
+func getOperationStates(operationType string, resources Resources, secret *v1.Secret, sleepInfo *kubegreenv1alpha1.SleepInfo) ([]ResourceState, error) {
+	if operationType == sleepOperation {
+		data, err := resources.getOriginalResourceInfoToSave()
+		if err != nil {
+			return nil, err
+		}
+		return getStoredResourceStates(data)
+	}
+	state, err := getSleepState(secret, sleepInfo)
+	if err != nil {
+		return nil, err
+	}
+	if state.LastOperation != sleepOperation {
+		return nil, nil
+	}
+	return state.Resources, nil
+}
+
+// kindSummary are the resources of a kind changed by the operation.
+type kindSummary struct {
+	count    int
+	replicas int64
+}
+
+// getOperationSummary returns a single sentence which summarizes the
+// operation, e.g. "Slept 14 Deployments (42 replicas), suspended 3 CronJobs,
+// skipped 2, 1 failed". The handled resources without a state to store or
+// restore, e.g. the Deployments already at 0 replicas, are skipped.
+func getOperationSummary(operationType string, states []ResourceState, resources Resources, operationErr error) string {
+	failedResources := resources.getFailedResources()
+	failed := map[string]bool{}
+	for _, failedResource := range failedResources {
+		failed[failedResource.Kind+"/"+failedResource.Name] = true
+	}
+
+	summaries := map[string]*kindSummary{}
+	alreadyZero := 0
+	for _, state := range states {
+		// the kind of the custom resources is in the form Kind.group
+		kind, _, _ := strings.Cut(state.Kind, ".")
+		if failed[kind+"/"+state.Name] {
+			continue
+		}
+		// the Deployments already at 0 replicas are stored, but skipped.
+		if isAlreadyZero, _ := state.Original["alreadyZero"].(bool); isAlreadyZero {
+			alreadyZero++
+			continue
+		}
+		if summaries[kind] == nil {
+			summaries[kind] = &kindSummary{}
+		}
+		summaries[kind].count++
+		if replicas, ok := state.Original["replicas"].(float64); ok {
+			summaries[kind].replicas += int64(replicas)
+		}
+	}
+	// the standalone pods deleted by the sleep have no state to store.
+	deletedPods := 0
+	if operationType == sleepOperation {
+		for _, name := range resources.getResourceNames()["Pod"] {
+			if !failed["Pod/"+name] {
+				deletedPods++
+			}
+		}
+	}
+	if deletedPods > 0 {
+		summaries["Pod"] = &kindSummary{count: deletedPods}
+	}
+
+	operation := "Sleep"
+	if operationType == wakeUpOperation {
+		operation = "Wake up"
+	}
+	parts := []string{}
+	switch {
+	case operationErr != nil:
+		// it is not known which resources have been changed before the error.
+		parts = append(parts, fmt.Sprintf("%s interrupted by error: %s", operation, operationErr))
+	case len(summaries) == 0:
+		parts = append(parts, fmt.Sprintf("%s: no resources changed", operation))
+	default:
+		for _, kind := range getSummaryKinds(summaries) {
+			summary := summaries[kind]
+			part := fmt.Sprintf("%s %d %s", getSummaryVerb(operationType, kind), summary.count, pluralize(kind, summary.count))
+			if summary.replicas > 0 {
+				part = fmt.Sprintf("%s (%d %s)", part, summary.replicas, pluralize("replica", int(summary.replicas)))
+			}
+			parts = append(parts, part)
+		}
+		parts[0] = strings.ToUpper(parts[0][:1]) + parts[0][1:]
+	}
+	if operationErr == nil {
+		skipped := -len(states) + alreadyZero - deletedPods
+		for _, count := range resources.getResourceCounts() {
+			skipped += count
+		}
+		if skipped > 0 {
+			parts = append(parts, fmt.Sprintf("skipped %d", skipped))
+		}
+	}
+	if len(failedResources) > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", len(failedResources)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// getSummaryKinds returns the kinds of the summary, in the order of
+// summaryKindOrder followed by the other kinds sorted by name.
+func getSummaryKinds(summaries map[string]*kindSummary) []string {
+	kinds := []string{}
+	known := map[string]bool{}
+	for _, kind := range summaryKindOrder {
+		known[kind] = true
+		if _, ok := summaries[kind]; ok {
+			kinds = append(kinds, kind)
+		}
+	}
+	others := []string{}
+	for kind := range summaries {
+		if !known[kind] {
+			others = append(others, kind)
+		}
+	}
+	sort.Strings(others)
+	return append(kinds, others...)
+}
+
+// getSummaryVerb returns what the operation does to the resources of the
+// kind.
+func getSummaryVerb(operationType, kind string) string {
+	isSleep := operationType == sleepOperation
+	switch kind {
+	case "Deployment", "ReplicaSet", "ReplicationController":
+		if isSleep {
+			return "slept"
+		}
+		return "woke up"
+	case "CronJob", "Job":
+		if isSleep {
+			return "suspended"
+		}
+		return "resumed"
+	case "Pod":
+		return "deleted"
+	default:
+		if isSleep {
+			return "patched"
+		}
+		return "restored"
+	}
+}
+
+func pluralize(word string, count int) string {
+	if count == 1 {
+		return word
+	}
+	return word + "s"
+}
@@ -0,0 +1,124 @@
+package sleepinfo
+
+import (
+	"fmt"
+	"testing"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetOperationSummary(t *testing.T) {
+	states := []ResourceState{
+		{Kind: "CronJob", Name: "report", Original: map[string]interface{}{"suspend": false}},
+		{Kind: "Deployment", Name: "api", Original: map[string]interface{}{"replicas": float64(3)}},
+		{Kind: "Deployment", Name: "worker", Original: map[string]interface{}{"replicas": float64(2)}},
+		{Kind: "Deployment", Name: "timeout", Original: map[string]interface{}{"replicas": float64(1)}},
+		{Kind: "Kibana.kibana.k8s.elastic.co", Name: "kibana"},
+	}
+	getResources := func() Resources {
+		r := newResourcesMock(t, resource.Mock{
+			MockResourceNames: []string{"api", "worker", "timeout", "scaled-to-zero"},
+		}, resource.Mock{
+			MockResourceNames: []string{"report"},
+		})
+		r.customresources = resource.GetResourceMock(resource.Mock{
+			MockResourceNames: []string{"Kibana.kibana.k8s.elastic.co/kibana"},
+		})
+		r.failedResources = &resource.FailedResources{}
+		r.failedResources.Add(kubegreenv1alpha1.FailedResource{Kind: "Deployment", Name: "timeout", Reason: "context deadline exceeded"})
+		return r
+	}
+
+	tests := []struct {
+		name          string
+		operationType string
+		states        []ResourceState
+		resources     Resources
+		operationErr  error
+		expected      string
+	}{
+		{
+			name:          "sleep",
+			operationType: sleepOperation,
+			states:        states,
+			resources:     getResources(),
+			expected:      "Slept 2 Deployments (5 replicas), suspended 1 CronJob, patched 1 Kibana, skipped 1, 1 failed",
+		},
+		{
+			name:          "wake up",
+			operationType: wakeUpOperation,
+			states:        states[:2],
+			resources:     newResourcesMock(t, resource.Mock{MockResourceNames: []string{"api"}}, resource.Mock{MockResourceNames: []string{"report"}}),
+			expected:      "Woke up 1 Deployment (3 replicas), resumed 1 CronJob",
+		},
+		{
+			name:          "standalone pods deleted",
+			operationType: sleepOperation,
+			states:        states[:2],
+			resources: func() Resources {
+				r := newResourcesMock(t, resource.Mock{MockResourceNames: []string{"api"}}, resource.Mock{MockResourceNames: []string{"report"}})
+				r.pods = resource.GetResourceMock(resource.Mock{MockResourceNames: []string{"debug", "curl"}})
+				return r
+			}(),
+			expected: "Slept 1 Deployment (3 replicas), suspended 1 CronJob, deleted 2 Pods",
+		},
+		{
+			name:          "no resources changed",
+			operationType: sleepOperation,
+			resources:     newResourcesMock(t, resource.Mock{MockResourceNames: []string{"scaled-to-zero"}}, resource.Mock{}),
+			expected:      "Sleep: no resources changed, skipped 1",
+		},
+		{
+			name:          "deployments already at zero replicas",
+			operationType: sleepOperation,
+			states:        []ResourceState{{Kind: "Deployment", Name: "scaled-to-zero", Original: map[string]interface{}{"replicas": float64(0), "alreadyZero": true}}},
+			resources:     newResourcesMock(t, resource.Mock{MockResourceNames: []string{"scaled-to-zero"}}, resource.Mock{}),
+			expected:      "Sleep: no resources changed, skipped 1",
+		},
+		{
+			name:          "operation failed",
+			operationType: wakeUpOperation,
+			states:        states,
+			resources:     getResources(),
+			operationErr:  fmt.Errorf("some error"),
+			expected:      "Wake up interrupted by error: some error, 1 failed",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, getOperationSummary(test.operationType, test.states, test.resources, test.operationErr))
+		})
+	}
+}
+
+func TestGetOperationStates(t *testing.T) {
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "my-namespace"},
+	}
+	secret := &v1.Secret{
+		Data: map[string][]byte{
+			lastOperationKey:       []byte(sleepOperation),
+			replicasBeforeSleepKey: []byte(`[{"name":"api","replicas":3}]`),
+		},
+	}
+	expected := []ResourceState{{Kind: "Deployment", Name: "api", Original: map[string]interface{}{"replicas": float64(3)}}}
+
+	t.Run("the state restored by the wake up", func(t *testing.T) {
+		states, err := getOperationStates(wakeUpOperation, Resources{}, secret, sleepInfo)
+		require.NoError(t, err)
+		require.Equal(t, expected, states)
+	})
+
+	t.Run("nothing to restore if the namespace is not sleeping", func(t *testing.T) {
+		secret := secret.DeepCopy()
+		secret.Data[lastOperationKey] = []byte(wakeUpOperation)
+		states, err := getOperationStates(wakeUpOperation, Resources{}, secret, sleepInfo)
+		require.NoError(t, err)
+		require.Empty(t, states)
+	})
+}
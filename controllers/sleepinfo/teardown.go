@@ -0,0 +1,63 @@
+package sleepinfo
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// InertAnnotation, set to "true" on a SleepInfo, makes the controller ignore
+// it. It is set by the teardown, so that the SleepInfo left in the cluster
+// after the uninstall of kube-green are not handled by a new installation
+// until it is removed.
+const InertAnnotation = "kube-green.dev/inert"
+
+// teardownRequeueAfter is the delay of the teardown of a SleepInfo after its
+// wake up.
+const teardownRequeueAfter = time.Second
+
+// isInert returns true if the SleepInfo is annotated with the InertAnnotation.
+func isInert(sleepInfo *kubegreenv1alpha1.SleepInfo) bool {
+	return sleepInfo.Annotations[InertAnnotation] == "true"
+}
+
+// isSleeping returns true if the last operation stored in the secret is a
+// sleep, whose resources are not yet woken up, or if an operation is pending.
+func isSleeping(secret *v1.Secret) bool {
+	if secret == nil {
+		return false
+	}
+	return string(secret.Data[lastOperationKey]) == sleepOperation || len(secret.Data[pendingOperationKey]) != 0
+}
+
+// teardown releases the SleepInfo, whose namespace is awake, so that kube-green
+// can be uninstalled: it deletes the state secret, removes the finalizers of
+// kube-green and marks the SleepInfo with the InertAnnotation.
+func (r *SleepInfoReconciler) teardown(ctx context.Context, currentSleepInfo *kubegreenv1alpha1.SleepInfo, secretName string) error {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: currentSleepInfo.Namespace},
+	}
+	if err := r.Client.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+
+	sleepInfo := currentSleepInfo.DeepCopy()
+	finalizers := []string{}
+	for _, finalizer := range sleepInfo.Finalizers {
+		if !strings.HasPrefix(finalizer, kubegreenv1alpha1.GroupVersion.Group+"/") {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+	sleepInfo.Finalizers = finalizers
+	if sleepInfo.Annotations == nil {
+		sleepInfo.Annotations = map[string]string{}
+	}
+	sleepInfo.Annotations[InertAnnotation] = "true"
+	return r.Client.Patch(ctx, sleepInfo, client.MergeFrom(currentSleepInfo))
+}
@@ -0,0 +1,71 @@
+package sleepinfo
+
+import (
+	"context"
+	"testing"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestTeardown(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	t.Run("isSleeping", func(t *testing.T) {
+		require.False(t, isSleeping(nil))
+		require.False(t, isSleeping(&v1.Secret{}))
+		require.False(t, isSleeping(&v1.Secret{Data: map[string][]byte{lastOperationKey: []byte(wakeUpOperation)}}))
+		require.True(t, isSleeping(&v1.Secret{Data: map[string][]byte{lastOperationKey: []byte(sleepOperation)}}))
+	})
+
+	t.Run("delete the secret and mark the sleepinfo inert", func(t *testing.T) {
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "sleepinfo",
+				Namespace:  "my-namespace",
+				Finalizers: []string{"kube-green.com/cleanup", "example.com/other"},
+			},
+		}
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo-sleepinfo", Namespace: "my-namespace"},
+		}
+		r := SleepInfoReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(sleepInfo, secret).Build(),
+		}
+		require.False(t, isInert(sleepInfo))
+
+		require.NoError(t, r.teardown(context.Background(), sleepInfo, secret.Name))
+
+		err := r.Get(context.Background(), client.ObjectKeyFromObject(secret), &v1.Secret{})
+		require.True(t, apierrors.IsNotFound(err))
+		updatedSleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, r.Get(context.Background(), client.ObjectKeyFromObject(sleepInfo), updatedSleepInfo))
+		require.True(t, isInert(updatedSleepInfo))
+		require.Equal(t, []string{"example.com/other"}, updatedSleepInfo.Finalizers)
+	})
+
+	t.Run("without secret", func(t *testing.T) {
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "my-namespace"},
+		}
+		r := SleepInfoReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(sleepInfo).Build(),
+		}
+
+		require.NoError(t, r.teardown(context.Background(), sleepInfo, "sleepinfo-sleepinfo"))
+
+		updatedSleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, r.Get(context.Background(), client.ObjectKeyFromObject(sleepInfo), updatedSleepInfo))
+		require.True(t, isInert(updatedSleepInfo))
+	})
+}
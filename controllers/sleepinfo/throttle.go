@@ -0,0 +1,71 @@
+package sleepinfo
+
+import (
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ThrottleBackoff is the back-off of the operations throttled by the API
+// server, per namespace. The delay is doubled at each throttled operation of
+// the namespace, and it is at least the Retry-After returned by the API
+// server.
+type ThrottleBackoff struct {
+	baseDelay time.Duration
+	maxDelay  time.Duration
+
+	mu       sync.Mutex
+	failures map[string]int
+}
+
+// NewThrottleBackoff returns a ThrottleBackoff which starts from baseDelay,
+// up to maxDelay.
+func NewThrottleBackoff(baseDelay, maxDelay time.Duration) *ThrottleBackoff {
+	return &ThrottleBackoff{
+		baseDelay: baseDelay,
+		maxDelay:  maxDelay,
+		failures:  map[string]int{},
+	}
+}
+
+// Next returns the delay before the throttled operation of the namespace is
+// retried.
+func (b *ThrottleBackoff) Next(namespace string, retryAfter time.Duration) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	failures := b.failures[namespace]
+	b.failures[namespace] = failures + 1
+
+	delay := b.baseDelay
+	for i := 0; i < failures && delay < b.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > b.maxDelay {
+		delay = b.maxDelay
+	}
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+	return delay
+}
+
+// Reset forgets the throttled operations of the namespace, after an operation
+// succeeds.
+func (b *ThrottleBackoff) Reset(namespace string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.failures, namespace)
+}
+
+// getThrottling returns true if the error is returned because the API server
+// is throttling the requests, with the delay it suggests before retrying.
+func getThrottling(err error) (time.Duration, bool) {
+	if !apierrors.IsTooManyRequests(err) {
+		return 0, false
+	}
+	retryAfter, _ := apierrors.SuggestsClientDelay(err)
+	return time.Duration(retryAfter) * time.Second, true
+}
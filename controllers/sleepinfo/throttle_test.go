@@ -0,0 +1,87 @@
+package sleepinfo
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kube-green/kube-green/controllers/sleepinfo/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestThrottleBackoff(t *testing.T) {
+	t.Run("double the delay up to the max", func(t *testing.T) {
+		b := NewThrottleBackoff(10*time.Second, time.Minute)
+		require.Equal(t, 10*time.Second, b.Next("ns1", 0))
+		require.Equal(t, 20*time.Second, b.Next("ns1", 0))
+		require.Equal(t, 40*time.Second, b.Next("ns1", 0))
+		require.Equal(t, time.Minute, b.Next("ns1", 0))
+		require.Equal(t, time.Minute, b.Next("ns1", 0))
+	})
+
+	t.Run("the namespaces have their own back-off", func(t *testing.T) {
+		b := NewThrottleBackoff(10*time.Second, time.Minute)
+		require.Equal(t, 10*time.Second, b.Next("ns1", 0))
+		require.Equal(t, 20*time.Second, b.Next("ns1", 0))
+		require.Equal(t, 10*time.Second, b.Next("ns2", 0))
+	})
+
+	t.Run("the delay is at least the retry after", func(t *testing.T) {
+		b := NewThrottleBackoff(10*time.Second, time.Minute)
+		require.Equal(t, 30*time.Second, b.Next("ns1", 30*time.Second))
+		require.Equal(t, 2*time.Minute, b.Next("ns1", 2*time.Minute))
+	})
+
+	t.Run("reset the back-off", func(t *testing.T) {
+		b := NewThrottleBackoff(10*time.Second, time.Minute)
+		require.Equal(t, 10*time.Second, b.Next("ns1", 0))
+		require.Equal(t, 20*time.Second, b.Next("ns1", 0))
+		b.Reset("ns1")
+		require.Equal(t, 10*time.Second, b.Next("ns1", 0))
+	})
+}
+
+func TestGetOperationErrorResult(t *testing.T) {
+	log := zap.New(zap.UseDevMode(true))
+	throttledErr := apierrors.NewTooManyRequests("the server has received too many requests", 15)
+
+	t.Run("retry the throttled operation after the back-off", func(t *testing.T) {
+		r := SleepInfoReconciler{
+			Metrics:         metrics.SetupMetricsOrDie("kube_green"),
+			ThrottleBackoff: NewThrottleBackoff(10*time.Second, time.Minute),
+		}
+		result, err := r.getOperationErrorResult(log, "my-namespace", sleepOperation, throttledErr)
+		require.NoError(t, err)
+		require.Equal(t, ctrl.Result{RequeueAfter: 15 * time.Second}, result)
+
+		result, err = r.getOperationErrorResult(log, "my-namespace", sleepOperation, throttledErr)
+		require.NoError(t, err)
+		require.Equal(t, ctrl.Result{RequeueAfter: 20 * time.Second}, result)
+		require.Equal(t, float64(2), testutil.ToFloat64(r.Metrics.ThrottledOperations.WithLabelValues(sleepOperation)))
+	})
+
+	t.Run("without back-off", func(t *testing.T) {
+		r := SleepInfoReconciler{Metrics: metrics.SetupMetricsOrDie("kube_green")}
+		result, err := r.getOperationErrorResult(log, "my-namespace", wakeUpOperation, throttledErr)
+		require.Equal(t, throttledErr, err)
+		require.Equal(t, ctrl.Result{Requeue: true}, result)
+		require.Equal(t, float64(1), testutil.ToFloat64(r.Metrics.ThrottledOperations.WithLabelValues(wakeUpOperation)))
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		r := SleepInfoReconciler{
+			Metrics:         metrics.SetupMetricsOrDie("kube_green"),
+			ThrottleBackoff: NewThrottleBackoff(10*time.Second, time.Minute),
+		}
+		someErr := fmt.Errorf("some error")
+		result, err := r.getOperationErrorResult(log, "my-namespace", wakeUpOperation, someErr)
+		require.Equal(t, someErr, err)
+		require.Equal(t, ctrl.Result{Requeue: true}, result)
+		require.Equal(t, 0, testutil.CollectAndCount(r.Metrics.ThrottledOperations))
+	})
+}
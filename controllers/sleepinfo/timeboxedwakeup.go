@@ -0,0 +1,59 @@
+package sleepinfo
+
+import (
+	"context"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/pkg/schedule"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WakeUpUntilAnnotation limits the wake up requested with the
+// WakeUpRequestedAtAnnotation: the SleepInfo goes back to sleep at the
+// RFC3339 time set as its value, instead of staying awake until its next
+// scheduled sleep. It is ignored if it is not after the request, or if the
+// SleepInfo is scheduled to wake up before it.
+const WakeUpUntilAnnotation = "kube-green.dev/wake-up-until"
+
+// RequestWakeUpFor requests the wake up of the SleepInfo for the duration,
+// after which it goes back to sleep.
+func RequestWakeUpFor(ctx context.Context, c client.Writer, sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time, duration time.Duration) error {
+	return annotate(ctx, c, sleepInfo, map[string]string{
+		WakeUpRequestedAtAnnotation: now.Format(time.RFC3339),
+		WakeUpUntilAnnotation:       now.Add(duration).Format(time.RFC3339),
+	})
+}
+
+// getTimeBoxedWakeUpEnd returns when the SleepInfo, woken up on request for a
+// limited time, goes back to sleep. It returns false if the SleepInfo stays
+// awake until its next scheduled sleep, at nextSchedule: the wake up is not
+// time-boxed, the SleepInfo has not been woken up by the request, or the end
+// of the wake up is not before the next scheduled sleep, or not before the
+// scheduled wake up following the requested one.
+func getTimeBoxedWakeUpEnd(sleepInfo *kubegreenv1alpha1.SleepInfo, data SleepInfoData, nextSchedule time.Time) (time.Time, bool, error) {
+	if !data.IsSleepOperation() || data.PendingOperationID != "" || data.NextOperationSchedule == data.CurrentOperationSchedule {
+		return time.Time{}, false, nil
+	}
+	requestedAt, ok := getWakeUpRequestedAt(sleepInfo)
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	until, err := time.Parse(time.RFC3339, sleepInfo.Annotations[WakeUpUntilAnnotation])
+	if err != nil || !until.After(requestedAt) {
+		return time.Time{}, false, nil
+	}
+	if data.LastSchedule.Before(requestedAt) || !data.LastSchedule.Before(until) || !until.Before(nextSchedule) {
+		return time.Time{}, false, nil
+	}
+	// the namespace is awake by schedule at the end of the wake up.
+	wakeUpSchedule, err := schedule.Parse(data.NextOperationSchedule)
+	if err != nil {
+		return time.Time{}, false, &ScheduleError{Err: err}
+	}
+	if !wakeUpSchedule.Next(data.LastSchedule).After(until) {
+		return time.Time{}, false, nil
+	}
+	return until, true, nil
+}
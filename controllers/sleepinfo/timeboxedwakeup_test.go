@@ -0,0 +1,152 @@
+package sleepinfo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetTimeBoxedWakeUpEnd(t *testing.T) {
+	// the namespace sleeps on Friday at 20:00, and it is woken up on Saturday
+	// at 10:00 for 3 hours.
+	requestedAt := time.Date(2021, 3, 27, 10, 0, 0, 0, time.UTC)
+	wokenUpAt := requestedAt.Add(30 * time.Second)
+	nextSleep := time.Date(2021, 3, 29, 20, 0, 0, 0, time.UTC)
+	getSleepInfo := func(requestedAt time.Time, until string) *kubegreenv1alpha1.SleepInfo {
+		return &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					WakeUpRequestedAtAnnotation: requestedAt.Format(time.RFC3339),
+					WakeUpUntilAnnotation:       until,
+				},
+			},
+		}
+	}
+	getData := func(operationType string, lastSchedule time.Time) SleepInfoData {
+		return SleepInfoData{
+			CurrentOperationType:     operationType,
+			CurrentOperationSchedule: "0 20 * * 1-5",
+			NextOperationSchedule:    "0 8 * * 1-5",
+			LastSchedule:             lastSchedule,
+		}
+	}
+
+	tests := []struct {
+		name          string
+		sleepInfo     *kubegreenv1alpha1.SleepInfo
+		data          SleepInfoData
+		nextSchedule  time.Time
+		expected      time.Time
+		expectedIsSet bool
+	}{
+		{
+			name:          "woken up for a duration",
+			sleepInfo:     getSleepInfo(requestedAt, "2021-03-27T13:00:00Z"),
+			data:          getData(sleepOperation, wokenUpAt),
+			nextSchedule:  nextSleep,
+			expected:      time.Date(2021, 3, 27, 13, 0, 0, 0, time.UTC),
+			expectedIsSet: true,
+		},
+		{
+			name: "woken up until the next scheduled sleep",
+			sleepInfo: &kubegreenv1alpha1.SleepInfo{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{WakeUpRequestedAtAnnotation: requestedAt.Format(time.RFC3339)},
+				},
+			},
+			data:         getData(sleepOperation, wokenUpAt),
+			nextSchedule: nextSleep,
+		},
+		{
+			name:         "invalid end",
+			sleepInfo:    getSleepInfo(requestedAt, "13:00"),
+			data:         getData(sleepOperation, wokenUpAt),
+			nextSchedule: nextSleep,
+		},
+		{
+			name:         "end of a previous request",
+			sleepInfo:    getSleepInfo(requestedAt, "2021-03-27T09:00:00Z"),
+			data:         getData(sleepOperation, wokenUpAt),
+			nextSchedule: nextSleep,
+		},
+		{
+			name:         "asleep",
+			sleepInfo:    getSleepInfo(requestedAt, "2021-03-27T13:00:00Z"),
+			data:         getData(wakeUpOperation, time.Date(2021, 3, 26, 20, 0, 0, 0, time.UTC)),
+			nextSchedule: time.Date(2021, 3, 29, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "asleep again after the end",
+			sleepInfo:    getSleepInfo(requestedAt, "2021-03-27T13:00:00Z"),
+			data:         getData(wakeUpOperation, time.Date(2021, 3, 27, 13, 0, 0, 0, time.UTC)),
+			nextSchedule: time.Date(2021, 3, 29, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "awake by schedule after the end",
+			sleepInfo:    getSleepInfo(requestedAt, "2021-03-27T13:00:00Z"),
+			data:         getData(sleepOperation, time.Date(2021, 3, 29, 8, 0, 0, 0, time.UTC)),
+			nextSchedule: nextSleep,
+		},
+		{
+			name:         "scheduled sleep before the end",
+			sleepInfo:    getSleepInfo(time.Date(2021, 3, 29, 18, 0, 0, 0, time.UTC), "2021-03-29T21:00:00Z"),
+			data:         getData(sleepOperation, time.Date(2021, 3, 29, 18, 0, 0, 0, time.UTC)),
+			nextSchedule: nextSleep,
+		},
+		{
+			name:         "scheduled wake up before the end",
+			sleepInfo:    getSleepInfo(time.Date(2021, 3, 29, 7, 0, 0, 0, time.UTC), "2021-03-29T10:00:00Z"),
+			data:         getData(sleepOperation, time.Date(2021, 3, 29, 7, 0, 0, 0, time.UTC)),
+			nextSchedule: nextSleep,
+		},
+		{
+			name:         "pending operation",
+			sleepInfo:    getSleepInfo(requestedAt, "2021-03-27T13:00:00Z"),
+			data:         SleepInfoData{CurrentOperationType: sleepOperation, PendingOperationID: "SLEEP-1616875200", LastSchedule: wokenUpAt},
+			nextSchedule: nextSleep,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			end, isSet, err := getTimeBoxedWakeUpEnd(test.sleepInfo, test.data, test.nextSchedule)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedIsSet, isSet)
+			require.True(t, test.expected.Equal(end), "end %s", end)
+		})
+	}
+
+	t.Run("invalid schedule", func(t *testing.T) {
+		data := getData(sleepOperation, wokenUpAt)
+		data.NextOperationSchedule = "0 25 * * *"
+		_, _, err := getTimeBoxedWakeUpEnd(getSleepInfo(requestedAt, "2021-03-27T13:00:00Z"), data, nextSleep)
+		var scheduleErr *ScheduleError
+		require.ErrorAs(t, err, &scheduleErr)
+	})
+}
+
+func TestRequestWakeUpFor(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "my-namespace"},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sleepInfo).Build()
+	now := time.Date(2021, 3, 27, 10, 0, 0, 0, time.UTC)
+
+	require.NoError(t, RequestWakeUpFor(context.Background(), c, sleepInfo, now, 3*time.Hour))
+	actual := &kubegreenv1alpha1.SleepInfo{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(sleepInfo), actual))
+	require.Equal(t, map[string]string{
+		WakeUpRequestedAtAnnotation: "2021-03-27T10:00:00Z",
+		WakeUpUntilAnnotation:       "2021-03-27T13:00:00Z",
+	}, actual.Annotations)
+}
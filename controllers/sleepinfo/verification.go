@@ -0,0 +1,300 @@
+package sleepinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// verificationRetryInterval is how frequently a verification not yet
+	// passed is checked again, until its timeout.
+	verificationRetryInterval = 30 * time.Second
+	// verificationRequestTimeout is the timeout of each request to the url
+	// of a verification.
+	verificationRequestTimeout = 10 * time.Second
+	// verificationJobTTL is how long the Jobs of the verifications are kept
+	// after they finish, to inspect their logs.
+	verificationJobTTL = int32(24 * 60 * 60)
+	// maxVerificationJobPrefixLength keeps the name of the Jobs of the
+	// verifications, usable as label value, within 63 characters.
+	maxVerificationJobPrefixLength = 45
+)
+
+// The environment variables set in the containers of the Job of the
+// verification, with the context of the wake up.
+const (
+	namespaceEnv    = "KUBE_GREEN_NAMESPACE"
+	sleepInfoEnv    = "KUBE_GREEN_SLEEPINFO"
+	operationEnv    = "KUBE_GREEN_OPERATION"
+	scheduleTimeEnv = "KUBE_GREEN_SCHEDULE_TIME"
+	resourcesEnv    = "KUBE_GREEN_RESOURCES"
+)
+
+// verifyWakeUp runs the verification of the last wake up of the SleepInfo, if
+// it is pending and its delay is passed. The verification not yet passed is
+// checked again until its timeout, when the wake up is marked as failed. It
+// returns when the verification must be checked again, and false if it is
+// not pending anymore.
+func (r *SleepInfoReconciler) verifyWakeUp(ctx context.Context, log logr.Logger, sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time) (time.Duration, bool) {
+	verification := sleepInfo.GetWakeUpVerification()
+	history := sleepInfo.Status.OperationsHistory
+	if verification == nil || len(history) == 0 {
+		return 0, false
+	}
+	wakeUp := history[len(history)-1]
+	if wakeUp.Type != wakeUpOperation || wakeUp.Verification != kubegreenv1alpha1.WakeUpVerificationPending {
+		return 0, false
+	}
+	startAt := wakeUp.Time.Add(verification.GetDelay())
+	if now.Before(startAt) {
+		return startAt.Sub(now), true
+	}
+
+	log = log.WithValues("wokenUpAt", wakeUp.Time.Time)
+	result, jobResult, reason := r.runWakeUpVerification(ctx, sleepInfo, *verification, wakeUp)
+	deadline := startAt.Add(verification.GetTimeout())
+	if result == kubegreenv1alpha1.WakeUpVerificationPending {
+		if now.Before(deadline) {
+			log.V(1).Info("wake up verification not passed yet", "reason", reason)
+			return getRequeueAfterVerification(deadline.Sub(now), verificationRetryInterval), true
+		}
+		result = kubegreenv1alpha1.WakeUpVerificationFailed
+		reason = fmt.Errorf("not passed within %s: %s", verification.GetTimeout(), reason)
+	}
+
+	if err := r.setWakeUpVerificationResult(ctx, sleepInfo, result, jobResult, reason); err != nil {
+		log.Error(err, "fails to update the result of the wake up verification")
+		return verificationRetryInterval, true
+	}
+	r.Metrics.WakeUpVerifications.WithLabelValues(result).Inc()
+	if result == kubegreenv1alpha1.WakeUpVerificationFailed {
+		log.Error(reason, "wake up verification failed")
+		if r.Recorder != nil {
+			r.Recorder.Eventf(sleepInfo, v1.EventTypeWarning, "WakeUpVerificationFailed", "Wake up verification failed: %s", reason)
+		}
+		return 0, false
+	}
+	log.Info("wake up verification passed")
+	if r.Recorder != nil {
+		r.Recorder.Event(sleepInfo, v1.EventTypeNormal, "WakeUpVerified", "Wake up verification passed")
+	}
+	return 0, false
+}
+
+// getRequeueAfterVerification returns the requeue of the SleepInfo,
+// anticipated to verifyAfter for the pending verification.
+func getRequeueAfterVerification(requeueAfter, verifyAfter time.Duration) time.Duration {
+	// a zero requeue does not requeue at all.
+	if verifyAfter < time.Second {
+		verifyAfter = time.Second
+	}
+	if verifyAfter < requeueAfter {
+		return verifyAfter
+	}
+	return requeueAfter
+}
+
+// runWakeUpVerification checks the verification of the wake up, returning its
+// result, the result of its Job if any and, if not passed, the reason.
+func (r *SleepInfoReconciler) runWakeUpVerification(ctx context.Context, sleepInfo *kubegreenv1alpha1.SleepInfo, verification kubegreenv1alpha1.WakeUpVerification, wakeUp kubegreenv1alpha1.OperationHistory) (string, *kubegreenv1alpha1.JobResult, error) {
+	if verification.URL != "" {
+		result, reason := r.checkVerificationURL(ctx, verification.URL)
+		return result, nil, reason
+	}
+	return r.checkVerificationJob(ctx, sleepInfo, verification.JobFromCronJob, wakeUp)
+}
+
+// checkVerificationURL passes if the url responds with a 2xx status code.
+// Otherwise the verification is still pending, so that the url is checked
+// again until the timeout.
+func (r *SleepInfoReconciler) checkVerificationURL(ctx context.Context, url string) (string, error) {
+	httpClient := r.VerificationHTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	ctx, cancel := context.WithTimeout(ctx, verificationRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return kubegreenv1alpha1.WakeUpVerificationFailed, err
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return kubegreenv1alpha1.WakeUpVerificationPending, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return kubegreenv1alpha1.WakeUpVerificationPending, fmt.Errorf("%s responded with status %d", url, res.StatusCode)
+	}
+	return kubegreenv1alpha1.WakeUpVerificationPassed, nil
+}
+
+// checkVerificationJob runs the job template of the CronJob as a Job, once
+// for each wake up, and passes when the Job completes. The result of the Job
+// is returned once it is finished.
+func (r *SleepInfoReconciler) checkVerificationJob(ctx context.Context, sleepInfo *kubegreenv1alpha1.SleepInfo, cronJobName string, wakeUp kubegreenv1alpha1.OperationHistory) (string, *kubegreenv1alpha1.JobResult, error) {
+	job := &batchv1.Job{}
+	jobName := getVerificationJobName(sleepInfo, wakeUp.Time.Time)
+	err := r.Client.Get(ctx, client.ObjectKey{Namespace: sleepInfo.Namespace, Name: jobName}, job)
+	if apierrors.IsNotFound(err) {
+		cronJob := &batchv1.CronJob{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Namespace: sleepInfo.Namespace, Name: cronJobName}, cronJob); err != nil {
+			if apierrors.IsNotFound(err) {
+				return kubegreenv1alpha1.WakeUpVerificationFailed, nil, fmt.Errorf("cronjob %s not found", cronJobName)
+			}
+			return kubegreenv1alpha1.WakeUpVerificationPending, nil, fmt.Errorf("fails to get cronjob %s: %s", cronJobName, err)
+		}
+		job, err = getVerificationJob(sleepInfo, cronJob, jobName, wakeUp)
+		if err != nil {
+			return kubegreenv1alpha1.WakeUpVerificationFailed, nil, err
+		}
+		if err := r.Client.Create(ctx, job); client.IgnoreAlreadyExists(err) != nil {
+			return kubegreenv1alpha1.WakeUpVerificationPending, nil, fmt.Errorf("fails to create job %s: %s", jobName, err)
+		}
+		return kubegreenv1alpha1.WakeUpVerificationPending, nil, fmt.Errorf("job %s created", jobName)
+	}
+	if err != nil {
+		return kubegreenv1alpha1.WakeUpVerificationPending, nil, fmt.Errorf("fails to get job %s: %s", jobName, err)
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != v1.ConditionTrue {
+			continue
+		}
+		jobResult := &kubegreenv1alpha1.JobResult{
+			Name:      jobName,
+			Succeeded: job.Status.Succeeded,
+			Failed:    job.Status.Failed,
+			Message:   condition.Message,
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return kubegreenv1alpha1.WakeUpVerificationPassed, jobResult, nil
+		case batchv1.JobFailed:
+			return kubegreenv1alpha1.WakeUpVerificationFailed, jobResult, fmt.Errorf("job %s failed: %s", jobName, condition.Message)
+		}
+	}
+	return kubegreenv1alpha1.WakeUpVerificationPending, nil, fmt.Errorf("job %s not completed", jobName)
+}
+
+// getVerificationJobName returns the name of the Job of the verification of
+// the wake up at wokenUpAt.
+func getVerificationJobName(sleepInfo *kubegreenv1alpha1.SleepInfo, wokenUpAt time.Time) string {
+	prefix := sleepInfo.Name
+	if len(prefix) > maxVerificationJobPrefixLength {
+		prefix = prefix[:maxVerificationJobPrefixLength]
+	}
+	return fmt.Sprintf("%s-verify-%d", prefix, wokenUpAt.Unix())
+}
+
+// getVerificationJob returns the Job created from the job template of the
+// CronJob, owned by the SleepInfo, so that it is deleted with it. The context
+// of the wake up is set in the environment of its containers.
+func getVerificationJob(sleepInfo *kubegreenv1alpha1.SleepInfo, cronJob *batchv1.CronJob, name string, wakeUp kubegreenv1alpha1.OperationHistory) (*batchv1.Job, error) {
+	isController := true
+	ttl := verificationJobTTL
+	labels := map[string]string{managedByLabel: fieldManagerName}
+	for key, value := range cronJob.Spec.JobTemplate.Labels {
+		labels[key] = value
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   sleepInfo.Namespace,
+			Labels:      labels,
+			Annotations: cronJob.Spec.JobTemplate.Annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         kubegreenv1alpha1.GroupVersion.String(),
+					Kind:               "SleepInfo",
+					Name:               sleepInfo.Name,
+					UID:                sleepInfo.UID,
+					Controller:         &isController,
+					BlockOwnerDeletion: &isController,
+				},
+			},
+		},
+		Spec: *cronJob.Spec.JobTemplate.Spec.DeepCopy(),
+	}
+	if job.Spec.TTLSecondsAfterFinished == nil {
+		job.Spec.TTLSecondsAfterFinished = &ttl
+	}
+
+	env, err := getVerificationJobEnv(sleepInfo, wakeUp)
+	if err != nil {
+		return nil, err
+	}
+	podSpec := &job.Spec.Template.Spec
+	for i := range podSpec.InitContainers {
+		podSpec.InitContainers[i].Env = setEnv(podSpec.InitContainers[i].Env, env)
+	}
+	for i := range podSpec.Containers {
+		podSpec.Containers[i].Env = setEnv(podSpec.Containers[i].Env, env)
+	}
+	return job, nil
+}
+
+// getVerificationJobEnv returns the environment variables with the context of
+// the wake up: the namespace, the SleepInfo, the operation, its time in
+// RFC3339 format and the number of resources it handled by kind, as JSON.
+func getVerificationJobEnv(sleepInfo *kubegreenv1alpha1.SleepInfo, wakeUp kubegreenv1alpha1.OperationHistory) ([]v1.EnvVar, error) {
+	resourceCounts := wakeUp.ResourceCounts
+	if resourceCounts == nil {
+		resourceCounts = map[string]int{}
+	}
+	resources, err := json.Marshal(resourceCounts)
+	if err != nil {
+		return nil, fmt.Errorf("fails to marshal the resources of the wake up: %s", err)
+	}
+	return []v1.EnvVar{
+		{Name: namespaceEnv, Value: sleepInfo.Namespace},
+		{Name: sleepInfoEnv, Value: sleepInfo.Name},
+		{Name: operationEnv, Value: wakeUp.Type},
+		{Name: scheduleTimeEnv, Value: wakeUp.Time.UTC().Format(time.RFC3339)},
+		{Name: resourcesEnv, Value: string(resources)},
+	}, nil
+}
+
+// setEnv sets the variables in the environment of a container, replacing the
+// ones with the same name.
+func setEnv(env []v1.EnvVar, vars []v1.EnvVar) []v1.EnvVar {
+	for _, envVar := range vars {
+		replaced := false
+		for i := range env {
+			if env[i].Name == envVar.Name {
+				env[i] = envVar
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			env = append(env, envVar)
+		}
+	}
+	return env
+}
+
+// setWakeUpVerificationResult sets the result of the verification, and of its
+// Job if any, on the last wake up of the operations history. If it failed,
+// its reason is set as the error of the wake up.
+func (r *SleepInfoReconciler) setWakeUpVerificationResult(ctx context.Context, currentSleepInfo *kubegreenv1alpha1.SleepInfo, result string, jobResult *kubegreenv1alpha1.JobResult, reason error) error {
+	sleepInfo := currentSleepInfo.DeepCopy()
+	wakeUp := &sleepInfo.Status.OperationsHistory[len(sleepInfo.Status.OperationsHistory)-1]
+	wakeUp.Verification = result
+	wakeUp.VerificationJob = jobResult
+	if result == kubegreenv1alpha1.WakeUpVerificationFailed {
+		wakeUp.Error = fmt.Sprintf("wake up verification failed: %s", reason)
+	}
+	return r.Status().Patch(ctx, sleepInfo, client.MergeFrom(currentSleepInfo))
+}
@@ -0,0 +1,252 @@
+package sleepinfo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestVerifyWakeUp(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+	log := zap.New(zap.UseDevMode(true))
+	wokenUpAt := time.Date(2023, 1, 9, 8, 0, 0, 0, time.UTC)
+
+	getSleepInfo := func(verification *kubegreenv1alpha1.WakeUpVerification) *kubegreenv1alpha1.SleepInfo {
+		return &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sleepinfo",
+				Namespace: "my-namespace",
+			},
+			Spec: kubegreenv1alpha1.SleepInfoSpec{
+				WakeUpPolicy: &kubegreenv1alpha1.WakeUpPolicy{Verification: verification},
+			},
+			Status: kubegreenv1alpha1.SleepInfoStatus{
+				OperationsHistory: []kubegreenv1alpha1.OperationHistory{
+					{Type: sleepOperation, Time: metav1.NewTime(wokenUpAt.Add(-12 * time.Hour))},
+					{
+						Type:           wakeUpOperation,
+						Time:           metav1.NewTime(wokenUpAt),
+						ResourceCounts: map[string]int{"Deployment": 2, "CronJob": 1},
+						Verification:   kubegreenv1alpha1.WakeUpVerificationPending,
+					},
+				},
+			},
+		}
+	}
+	getReconciler := func(objects ...client.Object) (SleepInfoReconciler, *record.FakeRecorder) {
+		recorder := record.NewFakeRecorder(10)
+		return SleepInfoReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+			Metrics:  metrics.SetupMetricsOrDie("kube_green"),
+			Recorder: recorder,
+		}, recorder
+	}
+	getVerification := func(r SleepInfoReconciler, sleepInfo *kubegreenv1alpha1.SleepInfo) kubegreenv1alpha1.OperationHistory {
+		updatedSleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, r.Get(context.Background(), client.ObjectKeyFromObject(sleepInfo), updatedSleepInfo))
+		history := updatedSleepInfo.Status.OperationsHistory
+		return history[len(history)-1]
+	}
+	getServer := func(statusCode int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(statusCode)
+		}))
+	}
+
+	t.Run("without pending verification", func(t *testing.T) {
+		sleepInfo := getSleepInfo(nil)
+		r, _ := getReconciler(sleepInfo)
+		_, isPending := r.verifyWakeUp(context.Background(), log, sleepInfo, wokenUpAt.Add(time.Hour))
+		require.False(t, isPending)
+
+		sleepInfo = getSleepInfo(&kubegreenv1alpha1.WakeUpVerification{URL: "http://localhost"})
+		sleepInfo.Status.OperationsHistory[1].Verification = kubegreenv1alpha1.WakeUpVerificationPassed
+		_, isPending = r.verifyWakeUp(context.Background(), log, sleepInfo, wokenUpAt.Add(time.Hour))
+		require.False(t, isPending)
+	})
+
+	t.Run("wait for the delay", func(t *testing.T) {
+		sleepInfo := getSleepInfo(&kubegreenv1alpha1.WakeUpVerification{URL: "http://localhost"})
+		r, _ := getReconciler(sleepInfo)
+		verifyAfter, isPending := r.verifyWakeUp(context.Background(), log, sleepInfo, wokenUpAt.Add(20*time.Second))
+		require.True(t, isPending)
+		require.Equal(t, 40*time.Second, verifyAfter)
+	})
+
+	t.Run("url passed", func(t *testing.T) {
+		server := getServer(http.StatusOK)
+		defer server.Close()
+		sleepInfo := getSleepInfo(&kubegreenv1alpha1.WakeUpVerification{URL: server.URL})
+		r, recorder := getReconciler(sleepInfo)
+
+		_, isPending := r.verifyWakeUp(context.Background(), log, sleepInfo, wokenUpAt.Add(time.Minute))
+		require.False(t, isPending)
+		require.Equal(t, kubegreenv1alpha1.WakeUpVerificationPassed, getVerification(r, sleepInfo).Verification)
+		require.Empty(t, getVerification(r, sleepInfo).Error)
+		require.Equal(t, "Normal WakeUpVerified Wake up verification passed", <-recorder.Events)
+		require.Equal(t, float64(1), testutil.ToFloat64(r.Metrics.WakeUpVerifications.WithLabelValues(kubegreenv1alpha1.WakeUpVerificationPassed)))
+	})
+
+	t.Run("url not passed yet", func(t *testing.T) {
+		server := getServer(http.StatusServiceUnavailable)
+		defer server.Close()
+		sleepInfo := getSleepInfo(&kubegreenv1alpha1.WakeUpVerification{URL: server.URL})
+		r, _ := getReconciler(sleepInfo)
+
+		verifyAfter, isPending := r.verifyWakeUp(context.Background(), log, sleepInfo, wokenUpAt.Add(time.Minute))
+		require.True(t, isPending)
+		require.Equal(t, verificationRetryInterval, verifyAfter)
+		require.Equal(t, kubegreenv1alpha1.WakeUpVerificationPending, getVerification(r, sleepInfo).Verification)
+	})
+
+	t.Run("url failed after the timeout", func(t *testing.T) {
+		server := getServer(http.StatusServiceUnavailable)
+		defer server.Close()
+		sleepInfo := getSleepInfo(&kubegreenv1alpha1.WakeUpVerification{
+			URL:     server.URL,
+			Timeout: &metav1.Duration{Duration: 5 * time.Minute},
+		})
+		r, recorder := getReconciler(sleepInfo)
+
+		_, isPending := r.verifyWakeUp(context.Background(), log, sleepInfo, wokenUpAt.Add(6*time.Minute))
+		require.False(t, isPending)
+		operation := getVerification(r, sleepInfo)
+		require.Equal(t, kubegreenv1alpha1.WakeUpVerificationFailed, operation.Verification)
+		require.Equal(t, "wake up verification failed: not passed within 5m0s: "+server.URL+" responded with status 503", operation.Error)
+		require.Equal(t, "Warning WakeUpVerificationFailed Wake up verification failed: not passed within 5m0s: "+server.URL+" responded with status 503", <-recorder.Events)
+		require.Equal(t, float64(1), testutil.ToFloat64(r.Metrics.WakeUpVerifications.WithLabelValues(kubegreenv1alpha1.WakeUpVerificationFailed)))
+	})
+
+	t.Run("job from cronjob", func(t *testing.T) {
+		cronJob := &batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "smoke-test", Namespace: "my-namespace"},
+			Spec: batchv1.CronJobSpec{
+				Schedule: "0 0 1 1 *",
+				JobTemplate: batchv1.JobTemplateSpec{
+					Spec: batchv1.JobSpec{
+						Template: v1.PodTemplateSpec{
+							Spec: v1.PodSpec{
+								RestartPolicy:  v1.RestartPolicyNever,
+								InitContainers: []v1.Container{{Name: "wait", Image: "busybox"}},
+								Containers: []v1.Container{{
+									Name:  "smoke-test",
+									Image: "curlimages/curl",
+									Env: []v1.EnvVar{
+										{Name: "TARGET", Value: "http://frontend"},
+										{Name: "KUBE_GREEN_OPERATION", Value: "overridden"},
+									},
+								}},
+							},
+						},
+					},
+				},
+			},
+		}
+		sleepInfo := getSleepInfo(&kubegreenv1alpha1.WakeUpVerification{JobFromCronJob: "smoke-test"})
+		r, recorder := getReconciler(sleepInfo, cronJob)
+		now := wokenUpAt.Add(2 * time.Minute)
+
+		_, isPending := r.verifyWakeUp(context.Background(), log, sleepInfo, now)
+		require.True(t, isPending)
+		job := &batchv1.Job{}
+		jobName := getVerificationJobName(sleepInfo, wokenUpAt)
+		require.NoError(t, r.Get(context.Background(), client.ObjectKey{Namespace: "my-namespace", Name: jobName}, job))
+		expectedEnv := []v1.EnvVar{
+			{Name: "KUBE_GREEN_NAMESPACE", Value: "my-namespace"},
+			{Name: "KUBE_GREEN_SLEEPINFO", Value: "sleepinfo"},
+			{Name: "KUBE_GREEN_OPERATION", Value: "WAKE_UP"},
+			{Name: "KUBE_GREEN_SCHEDULE_TIME", Value: "2023-01-09T08:00:00Z"},
+			{Name: "KUBE_GREEN_RESOURCES", Value: `{"CronJob":1,"Deployment":2}`},
+		}
+		require.Equal(t, expectedEnv, job.Spec.Template.Spec.InitContainers[0].Env)
+		require.Equal(t, append([]v1.EnvVar{
+			{Name: "TARGET", Value: "http://frontend"},
+			{Name: "KUBE_GREEN_OPERATION", Value: "WAKE_UP"},
+		}, expectedEnv[0], expectedEnv[1], expectedEnv[3], expectedEnv[4]), job.Spec.Template.Spec.Containers[0].Env)
+		require.Equal(t, "curlimages/curl", job.Spec.Template.Spec.Containers[0].Image)
+		require.Equal(t, "sleepinfo", job.OwnerReferences[0].Name)
+		require.Equal(t, fieldManagerName, job.Labels[managedByLabel])
+
+		_, isPending = r.verifyWakeUp(context.Background(), log, sleepInfo, now)
+		require.True(t, isPending)
+
+		job.Status.Succeeded = 1
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+		require.NoError(t, r.Status().Update(context.Background(), job))
+		_, isPending = r.verifyWakeUp(context.Background(), log, sleepInfo, now)
+		require.False(t, isPending)
+		operation := getVerification(r, sleepInfo)
+		require.Equal(t, kubegreenv1alpha1.WakeUpVerificationPassed, operation.Verification)
+		require.Equal(t, &kubegreenv1alpha1.JobResult{Name: jobName, Succeeded: 1}, operation.VerificationJob)
+		require.Equal(t, "Normal WakeUpVerified Wake up verification passed", <-recorder.Events)
+	})
+
+	t.Run("job failed", func(t *testing.T) {
+		sleepInfo := getSleepInfo(&kubegreenv1alpha1.WakeUpVerification{JobFromCronJob: "smoke-test"})
+		jobName := getVerificationJobName(sleepInfo, wokenUpAt)
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: "my-namespace"},
+			Status: batchv1.JobStatus{
+				Failed: 2,
+				Conditions: []batchv1.JobCondition{{
+					Type:    batchv1.JobFailed,
+					Status:  v1.ConditionTrue,
+					Message: "Job has reached the specified backoff limit",
+				}},
+			},
+		}
+		r, _ := getReconciler(sleepInfo, job)
+
+		_, isPending := r.verifyWakeUp(context.Background(), log, sleepInfo, wokenUpAt.Add(2*time.Minute))
+		require.False(t, isPending)
+		operation := getVerification(r, sleepInfo)
+		require.Equal(t, kubegreenv1alpha1.WakeUpVerificationFailed, operation.Verification)
+		require.Equal(t, "wake up verification failed: job "+jobName+" failed: Job has reached the specified backoff limit", operation.Error)
+		require.Equal(t, &kubegreenv1alpha1.JobResult{
+			Name:    jobName,
+			Failed:  2,
+			Message: "Job has reached the specified backoff limit",
+		}, operation.VerificationJob)
+	})
+
+	t.Run("job of missing cronjob", func(t *testing.T) {
+		sleepInfo := getSleepInfo(&kubegreenv1alpha1.WakeUpVerification{JobFromCronJob: "smoke-test"})
+		r, _ := getReconciler(sleepInfo)
+
+		_, isPending := r.verifyWakeUp(context.Background(), log, sleepInfo, wokenUpAt.Add(2*time.Minute))
+		require.False(t, isPending)
+		operation := getVerification(r, sleepInfo)
+		require.Equal(t, kubegreenv1alpha1.WakeUpVerificationFailed, operation.Verification)
+		require.Equal(t, "wake up verification failed: cronjob smoke-test not found", operation.Error)
+	})
+}
+
+func TestGetVerificationJobName(t *testing.T) {
+	wokenUpAt := time.Unix(1673251200, 0)
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{ObjectMeta: metav1.ObjectMeta{Name: "working-hours"}}
+	require.Equal(t, "working-hours-verify-1673251200", getVerificationJobName(sleepInfo, wokenUpAt))
+
+	sleepInfo.Name = "a-very-long-sleepinfo-name-which-does-not-fit-in-the-job-name"
+	jobName := getVerificationJobName(sleepInfo, wokenUpAt)
+	require.Equal(t, "a-very-long-sleepinfo-name-which-does-not-fit-verify-1673251200", jobName)
+	require.LessOrEqual(t, len(jobName), 63)
+}
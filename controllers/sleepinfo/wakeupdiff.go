@@ -0,0 +1,91 @@
+package sleepinfo
+
+import (
+	"context"
+	"fmt"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/deployments"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// diffKinds are the kinds of the resources compared with their stored state at
+// the wake up.
+var diffKinds = map[string]schema.GroupVersionKind{
+	"Deployment":            {Group: "apps", Version: "v1", Kind: "Deployment"},
+	"ReplicaSet":            {Group: "apps", Version: "v1", Kind: "ReplicaSet"},
+	"ReplicationController": {Group: "", Version: "v1", Kind: "ReplicationController"},
+	"DaemonSet":             {Group: "apps", Version: "v1", Kind: "DaemonSet"},
+	"CronJob":               {Group: "batch", Version: "v1", Kind: "CronJob"},
+	"Job":                   {Group: "batch", Version: "v1", Kind: "Job"},
+	"PodDisruptionBudget":   {Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"},
+}
+
+// getWakeUpDiffs compares the state of the resources stored by the sleep with
+// their live state before the wake up, and returns the resources changed
+// during the sleep, so that the surprises after the wake up are explainable.
+func getWakeUpDiffs(ctx context.Context, c client.Reader, secret *v1.Secret, sleepInfo *kubegreenv1alpha1.SleepInfo) ([]kubegreenv1alpha1.ResourceDiff, error) {
+	state, err := getSleepState(secret, sleepInfo)
+	if err != nil {
+		return nil, err
+	}
+	if state.LastOperation != sleepOperation {
+		return nil, nil
+	}
+
+	diffs := []kubegreenv1alpha1.ResourceDiff{}
+	for _, resource := range state.Resources {
+		gvk, ok := diffKinds[resource.Kind]
+		if !ok {
+			continue
+		}
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(gvk)
+		if err := c.Get(ctx, client.ObjectKey{Namespace: sleepInfo.Namespace, Name: resource.Name}, live); err != nil {
+			if apierrors.IsNotFound(err) {
+				diffs = append(diffs, kubegreenv1alpha1.ResourceDiff{Kind: resource.Kind, Name: resource.Name, Diff: "deleted during the sleep"})
+				continue
+			}
+			return nil, fmt.Errorf("fails to get %s %s: %s", resource.Kind, resource.Name, err)
+		}
+		if diff := getResourceDiff(resource, live); diff != "" {
+			diffs = append(diffs, kubegreenv1alpha1.ResourceDiff{Kind: resource.Kind, Name: resource.Name, Diff: diff})
+		}
+	}
+	return diffs, nil
+}
+
+// getResourceDiff returns what changed in the live resource during the sleep,
+// or an empty string if it is as left by the sleep.
+func getResourceDiff(resource ResourceState, live *unstructured.Unstructured) string {
+	switch resource.Kind {
+	case "Deployment", "ReplicaSet", "ReplicationController":
+		originalReplicas, _ := resource.Original["replicas"].(float64)
+		replicas, found, _ := unstructured.NestedInt64(live.Object, "spec", "replicas")
+		if found && replicas != 0 {
+			return fmt.Sprintf("scaled to %d during the sleep, replicas not restored", replicas)
+		}
+		// the revision of a Deployment changes only with its pod template.
+		originalRevision, _ := resource.Original["revision"].(string)
+		revision := live.GetAnnotations()[deployments.RevisionAnnotation]
+		if originalRevision != "" && revision != originalRevision {
+			originalRestartedAt, _ := resource.Original["restartedAt"].(string)
+			restartedAt, _, _ := unstructured.NestedString(live.Object, "spec", "template", "metadata", "annotations", deployments.RestartedAtAnnotation)
+			if restartedAt != "" && restartedAt != originalRestartedAt {
+				return fmt.Sprintf("restarted during the sleep at %s, replicas restored from 0 to %d with the new revision %s", restartedAt, int64(originalReplicas), revision)
+			}
+			return fmt.Sprintf("replicas restored from 0 to %d, but pod template changed during the sleep (revision %s to %s)", int64(originalReplicas), originalRevision, revision)
+		}
+	case "CronJob", "Job":
+		suspend, _, _ := unstructured.NestedBool(live.Object, "spec", "suspend")
+		if !suspend {
+			return "resumed during the sleep"
+		}
+	}
+	return ""
+}
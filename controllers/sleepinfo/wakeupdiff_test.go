@@ -0,0 +1,88 @@
+package sleepinfo
+
+import (
+	"context"
+	"testing"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/deployments"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetWakeUpDiffs(t *testing.T) {
+	namespace := "my-namespace"
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: namespace},
+	}
+	getDeployment := func(name string, replicas int32, revision string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				Annotations: map[string]string{deployments.RevisionAnnotation: revision},
+			},
+			Spec: appsv1.DeploymentSpec{Replicas: &replicas},
+		}
+	}
+	getCronJob := func(name string, suspend bool) *batchv1.CronJob {
+		return &batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       batchv1.CronJobSpec{Suspend: &suspend},
+		}
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo-sleepinfo", Namespace: namespace},
+		Data: map[string][]byte{
+			lastOperationKey:         []byte(sleepOperation),
+			replicasBeforeSleepKey:   []byte(`[{"name":"api","replicas":3,"revision":"2"},{"name":"edited","replicas":3,"revision":"2"},{"name":"restarted","replicas":3,"revision":"2","restartedAt":"2023-01-09T08:00:00Z"},{"name":"scaled","replicas":2},{"name":"deleted","replicas":1}]`),
+			originalCronjobStatusKey: []byte(`[{"name":"report","suspend":false},{"name":"resumed","suspend":false}]`),
+		},
+	}
+
+	t.Run("report the resources changed during the sleep", func(t *testing.T) {
+		restarted := getDeployment("restarted", 0, "3")
+		restarted.Spec.Template.Annotations = map[string]string{deployments.RestartedAtAnnotation: "2023-01-09T23:10:00Z"}
+		c := fake.NewClientBuilder().WithObjects(
+			restarted,
+			getDeployment("api", 0, "2"),
+			getDeployment("edited", 0, "3"),
+			getDeployment("scaled", 1, "1"),
+			getCronJob("report", true),
+			getCronJob("resumed", false),
+		).Build()
+
+		diffs, err := getWakeUpDiffs(context.Background(), c, secret, sleepInfo)
+		require.NoError(t, err)
+		require.Equal(t, []kubegreenv1alpha1.ResourceDiff{
+			{Kind: "CronJob", Name: "resumed", Diff: "resumed during the sleep"},
+			{Kind: "Deployment", Name: "deleted", Diff: "deleted during the sleep"},
+			{Kind: "Deployment", Name: "edited", Diff: "replicas restored from 0 to 3, but pod template changed during the sleep (revision 2 to 3)"},
+			{Kind: "Deployment", Name: "restarted", Diff: "restarted during the sleep at 2023-01-09T23:10:00Z, replicas restored from 0 to 3 with the new revision 3"},
+			{Kind: "Deployment", Name: "scaled", Diff: "scaled to 1 during the sleep, replicas not restored"},
+		}, diffs)
+	})
+
+	t.Run("no diff if the namespace is not sleeping", func(t *testing.T) {
+		secret := secret.DeepCopy()
+		secret.Data[lastOperationKey] = []byte(wakeUpOperation)
+		c := fake.NewClientBuilder().Build()
+
+		diffs, err := getWakeUpDiffs(context.Background(), c, secret, sleepInfo)
+		require.NoError(t, err)
+		require.Empty(t, diffs)
+	})
+
+	t.Run("no diff without secret", func(t *testing.T) {
+		c := fake.NewClientBuilder().Build()
+
+		diffs, err := getWakeUpDiffs(context.Background(), c, nil, sleepInfo)
+		require.NoError(t, err)
+		require.Empty(t, diffs)
+	})
+}
@@ -0,0 +1,298 @@
+package sleepreport
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	configv1alpha1 "github.com/kube-green/kube-green/api/config/v1alpha1"
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	sleepinfocontroller "github.com/kube-green/kube-green/controllers/sleepinfo"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/metrics"
+	"github.com/kube-green/kube-green/pkg/clock"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	sleepOperation = "SLEEP"
+
+	// secondsPerHour converts the savings of the reports, in hours, to the
+	// base unit of the metrics.
+	secondsPerHour = 3600
+)
+
+// defaultReports are the SleepReport created by the Reporter if missing.
+var defaultReports = map[string]time.Duration{
+	kubegreenv1alpha1.DailySleepReport:  24 * time.Hour,
+	kubegreenv1alpha1.WeeklySleepReport: 7 * 24 * time.Hour,
+}
+
+//+kubebuilder:rbac:groups=kube-green.com,resources=sleepreports,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups=kube-green.com,resources=sleepreports/status,verbs=get;update;patch
+
+// Reporter periodically computes the SleepReport, from the sleep records of
+// the SleepInfo, and exports them as metrics. The daily and the
+// weekly reports are created if missing, and other reports can be created
+// with a custom window.
+//
+// The pods avoided by a sleep are the pods running in the namespace before
+// the sleep, minus the pods still running before the wake up. If there are
+// more SleepInfo in a namespace, the namespace saves the most saved by one
+// of them. If the prices are set, the reports contain also the estimated
+// cost of the resources requested by the avoided pods.
+type Reporter struct {
+	// Client reads the SleepInfo and writes the SleepReport.
+	Client client.Client
+	// PodReader reads the pods still running in the sleeping namespaces. It
+	// should not be cached, to not watch all the pods of the cluster.
+	PodReader client.Reader
+	// Interval is how often the reports are computed.
+	Interval time.Duration
+	// Prices are the prices of the requested resources. If nil, the costs
+	// are not estimated.
+	Prices  *configv1alpha1.Prices
+	Metrics metrics.Metrics
+	Log     logr.Logger
+	// Clock gives the current time, to compute the report periods. If nil,
+	// the real clock is used.
+	Clock clock.Clock
+}
+
+// Start computes the reports every Interval, until the context is done.
+func (r *Reporter) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		if err := r.Report(ctx); err != nil {
+			r.Log.Error(err, "fails to compute the sleep reports")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection returns true, so that only the leader writes the reports.
+func (r *Reporter) NeedLeaderElection() bool {
+	return true
+}
+
+// Report computes and stores all the SleepReport.
+func (r *Reporter) Report(ctx context.Context) error {
+	if err := r.createDefaultReports(ctx); err != nil {
+		return err
+	}
+	sleepReports := kubegreenv1alpha1.SleepReportList{}
+	if err := r.Client.List(ctx, &sleepReports); err != nil {
+		return fmt.Errorf("fails to list sleepreports: %s", err)
+	}
+	sleepInfos := kubegreenv1alpha1.SleepInfoList{}
+	if err := r.Client.List(ctx, &sleepInfos); err != nil {
+		return fmt.Errorf("fails to list sleepinfos: %s", err)
+	}
+	now := clock.Now(r.Clock)
+	currentPods := r.getCurrentPods(ctx, sleepInfos.Items)
+
+	r.resetMetrics()
+	for _, sleepReport := range sleepReports.Items {
+		sleepReport := sleepReport
+		log := r.Log.WithValues("sleepreport", sleepReport.Name)
+		if err := sleepReport.Validate(); err != nil {
+			log.Error(err, "invalid sleepreport, skip")
+			continue
+		}
+		namespaces := getNamespacesSavings(sleepInfos.Items, currentPods, now.Add(-sleepReport.Spec.Window.Duration), now)
+		patch := client.MergeFrom(sleepReport.DeepCopy())
+		sleepReport.Status = getStatus(namespaces, r.Prices, now)
+		if err := r.Client.Status().Patch(ctx, &sleepReport, patch); err != nil {
+			log.Error(err, "fails to update sleepreport")
+			continue
+		}
+		r.setMetrics(sleepReport.Name, namespaces)
+	}
+	return nil
+}
+
+func (r *Reporter) createDefaultReports(ctx context.Context) error {
+	for name, window := range defaultReports {
+		sleepReport := &kubegreenv1alpha1.SleepReport{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       kubegreenv1alpha1.SleepReportSpec{Window: metav1.Duration{Duration: window}},
+		}
+		if err := r.Client.Create(ctx, sleepReport); err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("fails to create sleepreport %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// getCurrentPods returns the pods running now in the namespaces which are
+// sleeping, to compute the pods avoided by the sleep in progress. A failure
+// is only logged, and the namespace is skipped.
+func (r *Reporter) getCurrentPods(ctx context.Context, sleepInfos []kubegreenv1alpha1.SleepInfo) map[string]*kubegreenv1alpha1.RunningPods {
+	currentPods := map[string]*kubegreenv1alpha1.RunningPods{}
+	for _, sleepInfo := range sleepInfos {
+		records := sleepInfo.Status.SleepRecords
+		if len(records) == 0 || records[len(records)-1].Type != sleepOperation {
+			continue
+		}
+		if _, ok := currentPods[sleepInfo.Namespace]; ok {
+			continue
+		}
+		pods := v1.PodList{}
+		if err := r.PodReader.List(ctx, &pods, client.InNamespace(sleepInfo.Namespace)); err != nil {
+			r.Log.Error(err, "fails to list the running pods", "namespace", sleepInfo.Namespace)
+			continue
+		}
+		currentPods[sleepInfo.Namespace] = sleepinfocontroller.GetRunningPods(pods.Items)
+	}
+	return currentPods
+}
+
+func (r *Reporter) resetMetrics() {
+	r.Metrics.ReportSleptSeconds.Reset()
+	r.Metrics.ReportAvoidedPodSeconds.Reset()
+	r.Metrics.ReportAvoidedCPURequestSeconds.Reset()
+	r.Metrics.ReportAvoidedMemoryRequestByteSeconds.Reset()
+	r.Metrics.ReportEstimatedSavings.Reset()
+}
+
+func (r *Reporter) setMetrics(report string, namespaces map[string]savings) {
+	for namespace, namespaceSavings := range namespaces {
+		labels := prometheus.Labels{"namespace": namespace, "report": report}
+		r.Metrics.ReportSleptSeconds.With(labels).Set(namespaceSavings.sleptHours * secondsPerHour)
+		r.Metrics.ReportAvoidedPodSeconds.With(labels).Set(namespaceSavings.podHours * secondsPerHour)
+		r.Metrics.ReportAvoidedCPURequestSeconds.With(labels).Set(namespaceSavings.cpuRequestHours * secondsPerHour)
+		r.Metrics.ReportAvoidedMemoryRequestByteSeconds.With(labels).Set(namespaceSavings.memoryRequestByteHours * secondsPerHour)
+		if r.Prices != nil {
+			labels["currency"] = r.Prices.Currency
+			r.Metrics.ReportEstimatedSavings.With(labels).Set(namespaceSavings.estimateCost(r.Prices))
+		}
+	}
+}
+
+type savings struct {
+	sleptHours             float64
+	podHours               float64
+	cpuRequestHours        float64
+	memoryRequestByteHours float64
+}
+
+func (s savings) add(other savings) savings {
+	return savings{
+		sleptHours:             s.sleptHours + other.sleptHours,
+		podHours:               s.podHours + other.podHours,
+		cpuRequestHours:        s.cpuRequestHours + other.cpuRequestHours,
+		memoryRequestByteHours: s.memoryRequestByteHours + other.memoryRequestByteHours,
+	}
+}
+
+func (s savings) max(other savings) savings {
+	return savings{
+		sleptHours:             math.Max(s.sleptHours, other.sleptHours),
+		podHours:               math.Max(s.podHours, other.podHours),
+		cpuRequestHours:        math.Max(s.cpuRequestHours, other.cpuRequestHours),
+		memoryRequestByteHours: math.Max(s.memoryRequestByteHours, other.memoryRequestByteHours),
+	}
+}
+
+func (s savings) estimateCost(prices *configv1alpha1.Prices) float64 {
+	return prices.EstimateCost(s.cpuRequestHours, s.memoryRequestByteHours)
+}
+
+func (s savings) toAPI(prices *configv1alpha1.Prices) kubegreenv1alpha1.Savings {
+	apiSavings := kubegreenv1alpha1.Savings{
+		SleptHours:         *resource.NewMilliQuantity(int64(math.Round(s.sleptHours*1000)), resource.DecimalSI),
+		PodHours:           *resource.NewMilliQuantity(int64(math.Round(s.podHours*1000)), resource.DecimalSI),
+		CPURequestHours:    *resource.NewMilliQuantity(int64(math.Round(s.cpuRequestHours*1000)), resource.DecimalSI),
+		MemoryRequestHours: *resource.NewQuantity(int64(math.Round(s.memoryRequestByteHours)), resource.BinarySI),
+	}
+	if prices != nil {
+		apiSavings.EstimatedCost = strconv.FormatFloat(s.estimateCost(prices), 'f', 2, 64)
+	}
+	return apiSavings
+}
+
+// getNamespacesSavings returns the savings of each namespace between from
+// and now.
+func getNamespacesSavings(sleepInfos []kubegreenv1alpha1.SleepInfo, currentPods map[string]*kubegreenv1alpha1.RunningPods, from, now time.Time) map[string]savings {
+	namespaces := map[string]savings{}
+	for _, sleepInfo := range sleepInfos {
+		sleepInfoSavings := getSleepInfoSavings(sleepInfo.Status.SleepRecords, currentPods[sleepInfo.Namespace], from, now)
+		namespaces[sleepInfo.Namespace] = namespaces[sleepInfo.Namespace].max(sleepInfoSavings)
+	}
+	return namespaces
+}
+
+// getSleepInfoSavings returns the savings of the sleeps in the sleep records
+// between from and now. A sleep lasts until the next operation, or
+// until now if it is the last one.
+func getSleepInfoSavings(records []kubegreenv1alpha1.SleepRecord, currentPods *kubegreenv1alpha1.RunningPods, from, now time.Time) savings {
+	total := savings{}
+	for i, operation := range records {
+		if operation.Type != sleepOperation {
+			continue
+		}
+		start, end, podsAsleep := operation.Time.Time, now, currentPods
+		if i+1 < len(records) {
+			end, podsAsleep = records[i+1].Time.Time, records[i+1].RunningPods
+		}
+		if start.Before(from) {
+			start = from
+		}
+		if !end.After(start) {
+			continue
+		}
+		hours := end.Sub(start).Hours()
+		total.sleptHours += hours
+		if operation.RunningPods == nil || podsAsleep == nil {
+			continue
+		}
+		podsAwake := operation.RunningPods
+		total.podHours += hours * math.Max(0, float64(podsAwake.Count-podsAsleep.Count))
+		total.cpuRequestHours += hours * math.Max(0, podsAwake.Requests.Cpu().AsApproximateFloat64()-podsAsleep.Requests.Cpu().AsApproximateFloat64())
+		total.memoryRequestByteHours += hours * math.Max(0, podsAwake.Requests.Memory().AsApproximateFloat64()-podsAsleep.Requests.Memory().AsApproximateFloat64())
+	}
+	return total
+}
+
+func getStatus(namespaces map[string]savings, prices *configv1alpha1.Prices, now time.Time) kubegreenv1alpha1.SleepReportStatus {
+	status := kubegreenv1alpha1.SleepReportStatus{
+		LastUpdateTime: metav1.NewTime(now),
+		Namespaces:     []kubegreenv1alpha1.NamespaceSavings{},
+	}
+	if prices != nil {
+		status.Currency = prices.Currency
+	}
+	total := savings{}
+	for _, namespace := range getSortedKeys(namespaces) {
+		total = total.add(namespaces[namespace])
+		status.Namespaces = append(status.Namespaces, kubegreenv1alpha1.NamespaceSavings{
+			Namespace: namespace,
+			Savings:   namespaces[namespace].toAPI(prices),
+		})
+	}
+	status.Total = total.toAPI(prices)
+	return status
+}
+
+func getSortedKeys(namespaces map[string]savings) []string {
+	keys := make([]string, 0, len(namespaces))
+	for namespace := range namespaces {
+		keys = append(keys, namespace)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,238 @@
+package sleepreport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	configv1alpha1 "github.com/kube-green/kube-green/api/config/v1alpha1"
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/metrics"
+	kubegreentestutil "github.com/kube-green/kube-green/pkg/testutil"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var now = time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)
+
+func getRunningPods(count int, cpu, memory string) *kubegreenv1alpha1.RunningPods {
+	return &kubegreenv1alpha1.RunningPods{
+		Count: count,
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse(cpu),
+			v1.ResourceMemory: resource.MustParse(memory),
+		},
+	}
+}
+
+func getRecord(operationType string, ago time.Duration, runningPods *kubegreenv1alpha1.RunningPods) kubegreenv1alpha1.SleepRecord {
+	return kubegreenv1alpha1.SleepRecord{
+		Type:        operationType,
+		Time:        metav1.NewTime(now.Add(-ago)),
+		RunningPods: runningPods,
+	}
+}
+
+func TestGetSleepInfoSavings(t *testing.T) {
+	awake := getRunningPods(4, "2", "4Gi")
+	asleep := getRunningPods(1, "500m", "1Gi")
+
+	tests := []struct {
+		name        string
+		records     []kubegreenv1alpha1.SleepRecord
+		currentPods *kubegreenv1alpha1.RunningPods
+		expected    savings
+	}{
+		{
+			name:     "no operations",
+			expected: savings{},
+		},
+		{
+			name: "sleep and wake up",
+			records: []kubegreenv1alpha1.SleepRecord{
+				getRecord("SLEEP", 20*time.Hour, awake),
+				getRecord("WAKE_UP", 8*time.Hour, asleep),
+			},
+			expected: savings{sleptHours: 12, podHours: 36, cpuRequestHours: 18, memoryRequestByteHours: 36 * (1 << 30)},
+		},
+		{
+			name: "sleeping now",
+			records: []kubegreenv1alpha1.SleepRecord{
+				getRecord("SLEEP", 2*time.Hour, awake),
+			},
+			currentPods: asleep,
+			expected:    savings{sleptHours: 2, podHours: 6, cpuRequestHours: 3, memoryRequestByteHours: 6 * (1 << 30)},
+		},
+		{
+			name: "sleep started before the window",
+			records: []kubegreenv1alpha1.SleepRecord{
+				getRecord("SLEEP", 30*time.Hour, awake),
+				getRecord("WAKE_UP", 20*time.Hour, asleep),
+			},
+			expected: savings{sleptHours: 4, podHours: 12, cpuRequestHours: 6, memoryRequestByteHours: 12 * (1 << 30)},
+		},
+		{
+			name: "sleep ended before the window",
+			records: []kubegreenv1alpha1.SleepRecord{
+				getRecord("SLEEP", 40*time.Hour, awake),
+				getRecord("WAKE_UP", 30*time.Hour, asleep),
+			},
+			expected: savings{},
+		},
+		{
+			name: "running pods not recorded",
+			records: []kubegreenv1alpha1.SleepRecord{
+				getRecord("SLEEP", 20*time.Hour, nil),
+				getRecord("WAKE_UP", 8*time.Hour, nil),
+			},
+			expected: savings{sleptHours: 12},
+		},
+		{
+			name: "more pods while sleeping",
+			records: []kubegreenv1alpha1.SleepRecord{
+				getRecord("SLEEP", 20*time.Hour, asleep),
+				getRecord("WAKE_UP", 8*time.Hour, awake),
+			},
+			expected: savings{sleptHours: 12},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := getSleepInfoSavings(test.records, test.currentPods, now.Add(-24*time.Hour), now)
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestReporter(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	getSleepInfo := func(namespace, name string, records ...kubegreenv1alpha1.SleepRecord) *kubegreenv1alpha1.SleepInfo {
+		return &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Status:     kubegreenv1alpha1.SleepInfoStatus{SleepRecords: records},
+		}
+	}
+	awake := getRunningPods(4, "2", "4Gi")
+	asleep := getRunningPods(1, "500m", "1Gi")
+	sleepInfos := []client.Object{
+		getSleepInfo("team-a", "sleepinfo",
+			getRecord("SLEEP", 44*time.Hour, awake),
+			getRecord("WAKE_UP", 32*time.Hour, asleep),
+			getRecord("SLEEP", 20*time.Hour, awake),
+			getRecord("WAKE_UP", 8*time.Hour, asleep),
+			getRecord("SLEEP", 2*time.Hour, awake),
+		),
+		// another SleepInfo in the namespace, which saves less.
+		getSleepInfo("team-a", "other",
+			getRecord("SLEEP", 20*time.Hour, nil),
+			getRecord("WAKE_UP", 18*time.Hour, nil),
+		),
+		getSleepInfo("team-b", "sleepinfo"),
+		&kubegreenv1alpha1.SleepReport{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid"},
+		},
+	}
+	currentPod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "team-a"},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Name: "container",
+				Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("500m"),
+					v1.ResourceMemory: resource.MustParse("1Gi"),
+				}},
+			}},
+		},
+		Status: v1.PodStatus{Phase: v1.PodRunning},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sleepInfos...).Build()
+	reporter := &Reporter{
+		Client:    c,
+		PodReader: fake.NewClientBuilder().WithObjects(currentPod).Build(),
+		Interval:  time.Hour,
+		Prices:    &configv1alpha1.Prices{Currency: "USD", CPUHour: 0.5, MemoryGiBHour: 0.25},
+		Metrics:   metrics.SetupMetricsOrDie("test"),
+		Log:       logr.Discard(),
+		Clock:     kubegreentestutil.NewClock(now),
+	}
+	require.NoError(t, reporter.Report(context.Background()))
+
+	getSleepReport := func(name string) *kubegreenv1alpha1.SleepReport {
+		sleepReport := &kubegreenv1alpha1.SleepReport{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: name}, sleepReport))
+		return sleepReport
+	}
+
+	t.Run("daily report", func(t *testing.T) {
+		sleepReport := getSleepReport(kubegreenv1alpha1.DailySleepReport)
+		require.Equal(t, 24*time.Hour, sleepReport.Spec.Window.Duration)
+		require.True(t, now.Equal(sleepReport.Status.LastUpdateTime.Time))
+		require.Equal(t, "USD", sleepReport.Status.Currency)
+
+		teamA := kubegreenv1alpha1.Savings{
+			SleptHours:         resource.MustParse("14"),
+			PodHours:           resource.MustParse("42"),
+			CPURequestHours:    resource.MustParse("21"),
+			MemoryRequestHours: resource.MustParse("42Gi"),
+			EstimatedCost:      "21.00",
+		}
+		require.Len(t, sleepReport.Status.Namespaces, 2)
+		require.Equal(t, "team-a", sleepReport.Status.Namespaces[0].Namespace)
+		requireSavings(t, teamA, sleepReport.Status.Namespaces[0].Savings)
+		require.Equal(t, "team-b", sleepReport.Status.Namespaces[1].Namespace)
+		requireSavings(t, kubegreenv1alpha1.Savings{EstimatedCost: "0.00"}, sleepReport.Status.Namespaces[1].Savings)
+		requireSavings(t, teamA, sleepReport.Status.Total)
+	})
+
+	t.Run("weekly report", func(t *testing.T) {
+		sleepReport := getSleepReport(kubegreenv1alpha1.WeeklySleepReport)
+		require.Equal(t, 7*24*time.Hour, sleepReport.Spec.Window.Duration)
+		require.Equal(t, "26", sleepReport.Status.Total.SleptHours.String())
+		require.Equal(t, "78", sleepReport.Status.Total.PodHours.String())
+	})
+
+	t.Run("invalid report is skipped", func(t *testing.T) {
+		sleepReport := getSleepReport("invalid")
+		require.True(t, sleepReport.Status.LastUpdateTime.IsZero())
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		require.Equal(t, float64(14*3600), testutil.ToFloat64(reporter.Metrics.ReportSleptSeconds.WithLabelValues("team-a", "daily")))
+		require.Equal(t, float64(78*3600), testutil.ToFloat64(reporter.Metrics.ReportAvoidedPodSeconds.WithLabelValues("team-a", "weekly")))
+		require.Equal(t, float64(21*3600), testutil.ToFloat64(reporter.Metrics.ReportAvoidedCPURequestSeconds.WithLabelValues("team-a", "daily")))
+		require.Equal(t, float64(42*(1<<30)*3600), testutil.ToFloat64(reporter.Metrics.ReportAvoidedMemoryRequestByteSeconds.WithLabelValues("team-a", "daily")))
+		require.Equal(t, float64(0), testutil.ToFloat64(reporter.Metrics.ReportSleptSeconds.WithLabelValues("team-b", "daily")))
+		require.Equal(t, 4, testutil.CollectAndCount(reporter.Metrics.ReportSleptSeconds))
+		require.Equal(t, float64(21), testutil.ToFloat64(reporter.Metrics.ReportEstimatedSavings.WithLabelValues("team-a", "daily", "USD")))
+	})
+
+	t.Run("without prices", func(t *testing.T) {
+		reporter.Prices = nil
+		require.NoError(t, reporter.Report(context.Background()))
+
+		sleepReport := getSleepReport(kubegreenv1alpha1.DailySleepReport)
+		require.Empty(t, sleepReport.Status.Currency)
+		require.Empty(t, sleepReport.Status.Total.EstimatedCost)
+		require.Equal(t, 0, testutil.CollectAndCount(reporter.Metrics.ReportEstimatedSavings))
+	})
+}
+
+func requireSavings(t *testing.T, expected, actual kubegreenv1alpha1.Savings) {
+	t.Helper()
+	require.Zero(t, expected.SleptHours.Cmp(actual.SleptHours), "sleptHours %s", actual.SleptHours.String())
+	require.Zero(t, expected.PodHours.Cmp(actual.PodHours), "podHours %s", actual.PodHours.String())
+	require.Zero(t, expected.CPURequestHours.Cmp(actual.CPURequestHours), "cpuRequestHours %s", actual.CPURequestHours.String())
+	require.Zero(t, expected.MemoryRequestHours.Cmp(actual.MemoryRequestHours), "memoryRequestHours %s", actual.MemoryRequestHours.String())
+	require.Equal(t, expected.EstimatedCost, actual.EstimatedCost)
+}
@@ -0,0 +1,233 @@
+package wakeuprequest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	sleepinfocontroller "github.com/kube-green/kube-green/controllers/sleepinfo"
+	"github.com/kube-green/kube-green/pkg/clock"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	sleepOperation = "SLEEP"
+
+	// checkInterval is how often the wake up of the SleepInfo is checked.
+	checkInterval = 10 * time.Second
+	// defaultTimeout is how long the SleepInfo have to wake up, if the
+	// timeout is not set.
+	defaultTimeout = 10 * time.Minute
+)
+
+//+kubebuilder:rbac:groups=kube-green.com,resources=wakeuprequests,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=kube-green.com,resources=wakeuprequests/status,verbs=get;update;patch
+
+// WakeUpRequestReconciler wakes up the sleeping SleepInfo of the namespace of
+// a WakeUpRequest, regardless of their schedule, and deletes the request once
+// they are awake. The SleepInfo restore the resources from their stored
+// state, as in a scheduled wake up. The wake up is recorded in the events of
+// the request and of the SleepInfo, with the reason of the request.
+//
+// If the SleepInfo are not awake within the timeout, the request is marked
+// as failed and it is kept, so that the failure can be inspected.
+type WakeUpRequestReconciler struct {
+	Client   client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	// Timeout is how long the SleepInfo have to wake up. If 0, it is 10
+	// minutes.
+	Timeout time.Duration
+	// Clock gives the time at which the wake up is requested, and from
+	// which its Timeout is checked. If nil, the real clock is used.
+	Clock clock.Clock
+}
+
+// Reconcile requests the wake up of the SleepInfo of a new WakeUpRequest,
+// then checks until they are awake.
+func (r *WakeUpRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("wakeuprequest", req.NamespacedName)
+
+	wakeUpRequest := &kubegreenv1alpha1.WakeUpRequest{}
+	if err := r.Client.Get(ctx, req.NamespacedName, wakeUpRequest); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !wakeUpRequest.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	now := clock.Now(r.Clock)
+	switch wakeUpRequest.Status.Phase {
+	case "":
+		if err := r.requestWakeUp(ctx, log, wakeUpRequest, now); err != nil {
+			return ctrl.Result{}, err
+		}
+		if wakeUpRequest.Status.Phase == kubegreenv1alpha1.WakeUpRequestPhaseFailed {
+			return ctrl.Result{}, nil
+		}
+		return r.checkWakeUp(ctx, log, wakeUpRequest, now)
+	case kubegreenv1alpha1.WakeUpRequestPhaseWakingUp:
+		return r.checkWakeUp(ctx, log, wakeUpRequest, now)
+	default:
+		return ctrl.Result{}, nil
+	}
+}
+
+// requestWakeUp requests the wake up of the sleeping SleepInfo selected by
+// the WakeUpRequest, and saves them in its status.
+func (r *WakeUpRequestReconciler) requestWakeUp(ctx context.Context, log logr.Logger, wakeUpRequest *kubegreenv1alpha1.WakeUpRequest, now time.Time) error {
+	if err := wakeUpRequest.Validate(); err != nil {
+		return r.setFailed(ctx, log, wakeUpRequest, now, err.Error())
+	}
+	sleepInfos, err := r.getSleepInfos(ctx, wakeUpRequest)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return r.setFailed(ctx, log, wakeUpRequest, now, err.Error())
+		}
+		return err
+	}
+	if len(sleepInfos) == 0 {
+		return r.setFailed(ctx, log, wakeUpRequest, now, fmt.Sprintf("namespace %s has no SleepInfo", wakeUpRequest.Namespace))
+	}
+
+	sleeping := []string{}
+	for _, sleepInfo := range sleepInfos {
+		sleepInfo := sleepInfo
+		if sleepInfo.Status.OperationType != sleepOperation {
+			continue
+		}
+		if err := requestSleepInfoWakeUp(ctx, r.Client, &sleepInfo, now, wakeUpRequest.GetDuration()); err != nil {
+			return fmt.Errorf("fails to request the wake up of SleepInfo %s: %s", sleepInfo.Name, err)
+		}
+		sleeping = append(sleeping, sleepInfo.Name)
+		if r.Recorder != nil {
+			r.Recorder.Eventf(&sleepInfo, v1.EventTypeNormal, "WakeUpRequested", "Wake up requested by WakeUpRequest %s%s", wakeUpRequest.Name, formatReason(wakeUpRequest))
+		}
+	}
+	log.Info("wake up requested", "sleepInfos", sleeping, "reason", wakeUpRequest.Spec.Reason, "duration", wakeUpRequest.GetDuration())
+	if r.Recorder != nil && len(sleeping) > 0 {
+		r.Recorder.Eventf(wakeUpRequest, v1.EventTypeNormal, "WakeUpRequested", "Wake up of SleepInfo %s requested%s", strings.Join(sleeping, ", "), formatReason(wakeUpRequest))
+	}
+
+	patch := client.MergeFrom(wakeUpRequest.DeepCopy())
+	wakeUpRequest.Status = kubegreenv1alpha1.WakeUpRequestStatus{
+		Phase:       kubegreenv1alpha1.WakeUpRequestPhaseWakingUp,
+		RequestedAt: metav1.NewTime(now),
+		SleepInfos:  sleeping,
+	}
+	return r.Client.Status().Patch(ctx, wakeUpRequest, patch)
+}
+
+// getSleepInfos returns the SleepInfo of the namespace of the WakeUpRequest
+// listed in its spec, or all of them if the spec lists none. It returns a
+// not found error if a listed SleepInfo does not exist.
+func (r *WakeUpRequestReconciler) getSleepInfos(ctx context.Context, wakeUpRequest *kubegreenv1alpha1.WakeUpRequest) ([]kubegreenv1alpha1.SleepInfo, error) {
+	sleepInfos := kubegreenv1alpha1.SleepInfoList{}
+	if err := r.Client.List(ctx, &sleepInfos, client.InNamespace(wakeUpRequest.Namespace)); err != nil {
+		return nil, fmt.Errorf("fails to list sleepinfos: %s", err)
+	}
+	if len(wakeUpRequest.Spec.SleepInfos) == 0 {
+		return sleepInfos.Items, nil
+	}
+
+	selected := []kubegreenv1alpha1.SleepInfo{}
+	for _, name := range wakeUpRequest.Spec.SleepInfos {
+		found := false
+		for _, sleepInfo := range sleepInfos.Items {
+			if sleepInfo.Name == name {
+				selected = append(selected, sleepInfo)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, apierrors.NewNotFound(kubegreenv1alpha1.GroupVersion.WithResource("sleepinfos").GroupResource(), name)
+		}
+	}
+	return selected, nil
+}
+
+// checkWakeUp deletes the WakeUpRequest if its SleepInfo are awake, and marks
+// it as failed if they are still sleeping after the timeout.
+func (r *WakeUpRequestReconciler) checkWakeUp(ctx context.Context, log logr.Logger, wakeUpRequest *kubegreenv1alpha1.WakeUpRequest, now time.Time) (ctrl.Result, error) {
+	sleeping := []string{}
+	for _, name := range wakeUpRequest.Status.SleepInfos {
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Namespace: wakeUpRequest.Namespace, Name: name}, sleepInfo); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return ctrl.Result{}, err
+		}
+		if sleepInfo.Status.OperationType == sleepOperation {
+			sleeping = append(sleeping, name)
+		}
+	}
+
+	if len(sleeping) > 0 {
+		timeout := r.Timeout
+		if timeout == 0 {
+			timeout = defaultTimeout
+		}
+		if now.Sub(wakeUpRequest.Status.RequestedAt.Time) < timeout {
+			return ctrl.Result{RequeueAfter: checkInterval}, nil
+		}
+		sort.Strings(sleeping)
+		message := fmt.Sprintf("SleepInfo %s not woken up within %s", strings.Join(sleeping, ", "), timeout)
+		return ctrl.Result{}, r.setFailed(ctx, log, wakeUpRequest, wakeUpRequest.Status.RequestedAt.Time, message)
+	}
+
+	log.Info("wake up completed", "sleepInfos", wakeUpRequest.Status.SleepInfos, "reason", wakeUpRequest.Spec.Reason)
+	if r.Recorder != nil {
+		r.Recorder.Event(wakeUpRequest, v1.EventTypeNormal, "WakeUpCompleted", "Namespace woken up")
+	}
+	if err := r.Client.Delete(ctx, wakeUpRequest); err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *WakeUpRequestReconciler) setFailed(ctx context.Context, log logr.Logger, wakeUpRequest *kubegreenv1alpha1.WakeUpRequest, requestedAt time.Time, message string) error {
+	log.Info("wake up failed", "message", message)
+	if r.Recorder != nil {
+		r.Recorder.Event(wakeUpRequest, v1.EventTypeWarning, "WakeUpFailed", message)
+	}
+	patch := client.MergeFrom(wakeUpRequest.DeepCopy())
+	wakeUpRequest.Status.Phase = kubegreenv1alpha1.WakeUpRequestPhaseFailed
+	wakeUpRequest.Status.RequestedAt = metav1.NewTime(requestedAt)
+	wakeUpRequest.Status.Message = message
+	return r.Client.Status().Patch(ctx, wakeUpRequest, patch)
+}
+
+// requestSleepInfoWakeUp requests the wake up of the SleepInfo, for the
+// duration if it is not 0.
+func requestSleepInfoWakeUp(ctx context.Context, c client.Writer, sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time, duration time.Duration) error {
+	if duration > 0 {
+		return sleepinfocontroller.RequestWakeUpFor(ctx, c, sleepInfo, now, duration)
+	}
+	return sleepinfocontroller.RequestWakeUp(ctx, c, sleepInfo, now)
+}
+
+func formatReason(wakeUpRequest *kubegreenv1alpha1.WakeUpRequest) string {
+	if wakeUpRequest.Spec.Reason == "" {
+		return ""
+	}
+	return ": " + wakeUpRequest.Spec.Reason
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *WakeUpRequestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&kubegreenv1alpha1.WakeUpRequest{}).
+		Complete(r)
+}
@@ -0,0 +1,200 @@
+package wakeuprequest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	sleepinfocontroller "github.com/kube-green/kube-green/controllers/sleepinfo"
+	"github.com/kube-green/kube-green/pkg/testutil"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const namespace = "my-namespace"
+
+var now = time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)
+
+func getSleepInfo(name, operationType string) *kubegreenv1alpha1.SleepInfo {
+	return &kubegreenv1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Status:     kubegreenv1alpha1.SleepInfoStatus{OperationType: operationType},
+	}
+}
+
+func getWakeUpRequest(spec kubegreenv1alpha1.WakeUpRequestSpec) *kubegreenv1alpha1.WakeUpRequest {
+	return &kubegreenv1alpha1.WakeUpRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: namespace},
+		Spec:       spec,
+	}
+}
+
+func TestWakeUpRequest(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	getReconciler := func(objects ...client.Object) (*WakeUpRequestReconciler, *record.FakeRecorder) {
+		recorder := record.NewFakeRecorder(10)
+		return &WakeUpRequestReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+			Log:      logr.Discard(),
+			Recorder: recorder,
+			Clock:    testutil.NewClock(now),
+		}, recorder
+	}
+	reconcile := func(t *testing.T, r *WakeUpRequestReconciler) ctrl.Result {
+		t.Helper()
+		result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKey{Namespace: namespace, Name: "restore"}})
+		require.NoError(t, err)
+		return result
+	}
+	getRequest := func(t *testing.T, r *WakeUpRequestReconciler) *kubegreenv1alpha1.WakeUpRequest {
+		t.Helper()
+		wakeUpRequest := &kubegreenv1alpha1.WakeUpRequest{}
+		require.NoError(t, r.Client.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: "restore"}, wakeUpRequest))
+		return wakeUpRequest
+	}
+	setAwake := func(t *testing.T, r *WakeUpRequestReconciler, name string) {
+		t.Helper()
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, r.Client.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, sleepInfo))
+		sleepInfo.Status.OperationType = "WAKE_UP"
+		require.NoError(t, r.Client.Status().Update(context.Background(), sleepInfo))
+	}
+
+	t.Run("wake up the sleeping SleepInfo and delete the request", func(t *testing.T) {
+		r, recorder := getReconciler(
+			getSleepInfo("sleeping", "SLEEP"),
+			getSleepInfo("awake", "WAKE_UP"),
+			getWakeUpRequest(kubegreenv1alpha1.WakeUpRequestSpec{Reason: "disaster recovery"}),
+		)
+
+		require.Equal(t, ctrl.Result{RequeueAfter: checkInterval}, reconcile(t, r))
+		wakeUpRequest := getRequest(t, r)
+		require.Equal(t, kubegreenv1alpha1.WakeUpRequestPhaseWakingUp, wakeUpRequest.Status.Phase)
+		require.Equal(t, now, wakeUpRequest.Status.RequestedAt.UTC())
+		require.Equal(t, []string{"sleeping"}, wakeUpRequest.Status.SleepInfos)
+		require.Equal(t, "Normal WakeUpRequested Wake up requested by WakeUpRequest restore: disaster recovery", <-recorder.Events)
+		require.Equal(t, "Normal WakeUpRequested Wake up of SleepInfo sleeping requested: disaster recovery", <-recorder.Events)
+
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, r.Client.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: "sleeping"}, sleepInfo))
+		require.Equal(t, now.Format(time.RFC3339), sleepInfo.Annotations[sleepinfocontroller.WakeUpRequestedAtAnnotation])
+		require.NoError(t, r.Client.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: "awake"}, sleepInfo))
+		require.Empty(t, sleepInfo.Annotations, "the awake SleepInfo are not woken up")
+
+		require.Equal(t, ctrl.Result{RequeueAfter: checkInterval}, reconcile(t, r), "the SleepInfo is still sleeping")
+		setAwake(t, r, "sleeping")
+		require.Equal(t, ctrl.Result{}, reconcile(t, r))
+		require.Equal(t, "Normal WakeUpCompleted Namespace woken up", <-recorder.Events)
+		err := r.Client.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: "restore"}, wakeUpRequest)
+		require.True(t, apierrors.IsNotFound(err))
+	})
+
+	t.Run("wake up only the SleepInfo of the spec", func(t *testing.T) {
+		r, _ := getReconciler(
+			getSleepInfo("a", "SLEEP"),
+			getSleepInfo("b", "SLEEP"),
+			getWakeUpRequest(kubegreenv1alpha1.WakeUpRequestSpec{SleepInfos: []string{"b"}}),
+		)
+
+		reconcile(t, r)
+		require.Equal(t, []string{"b"}, getRequest(t, r).Status.SleepInfos)
+	})
+
+	t.Run("wake up for a duration", func(t *testing.T) {
+		r, _ := getReconciler(
+			getSleepInfo("sleeping", "SLEEP"),
+			getWakeUpRequest(kubegreenv1alpha1.WakeUpRequestSpec{Duration: &metav1.Duration{Duration: 3 * time.Hour}}),
+		)
+
+		reconcile(t, r)
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, r.Client.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: "sleeping"}, sleepInfo))
+		require.Equal(t, map[string]string{
+			sleepinfocontroller.WakeUpRequestedAtAnnotation: "2021-03-23T20:00:00Z",
+			sleepinfocontroller.WakeUpUntilAnnotation:       "2021-03-23T23:00:00Z",
+		}, sleepInfo.Annotations)
+	})
+
+	t.Run("invalid duration", func(t *testing.T) {
+		r, _ := getReconciler(
+			getSleepInfo("sleeping", "SLEEP"),
+			getWakeUpRequest(kubegreenv1alpha1.WakeUpRequestSpec{Duration: &metav1.Duration{Duration: -time.Hour}}),
+		)
+
+		require.Equal(t, ctrl.Result{}, reconcile(t, r))
+		wakeUpRequest := getRequest(t, r)
+		require.Equal(t, kubegreenv1alpha1.WakeUpRequestPhaseFailed, wakeUpRequest.Status.Phase)
+		require.Equal(t, "duration is invalid: must be positive", wakeUpRequest.Status.Message)
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, r.Client.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: "sleeping"}, sleepInfo))
+		require.Empty(t, sleepInfo.Annotations)
+	})
+
+	t.Run("the namespace is already awake", func(t *testing.T) {
+		r, _ := getReconciler(
+			getSleepInfo("awake", "WAKE_UP"),
+			getWakeUpRequest(kubegreenv1alpha1.WakeUpRequestSpec{}),
+		)
+
+		require.Equal(t, ctrl.Result{}, reconcile(t, r))
+		err := r.Client.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: "restore"}, &kubegreenv1alpha1.WakeUpRequest{})
+		require.True(t, apierrors.IsNotFound(err))
+	})
+
+	t.Run("the SleepInfo of the spec does not exist", func(t *testing.T) {
+		r, recorder := getReconciler(
+			getSleepInfo("a", "SLEEP"),
+			getWakeUpRequest(kubegreenv1alpha1.WakeUpRequestSpec{SleepInfos: []string{"not-exists"}}),
+		)
+
+		require.Equal(t, ctrl.Result{}, reconcile(t, r))
+		wakeUpRequest := getRequest(t, r)
+		require.Equal(t, kubegreenv1alpha1.WakeUpRequestPhaseFailed, wakeUpRequest.Status.Phase)
+		require.Equal(t, `sleepinfos.kube-green.com "not-exists" not found`, wakeUpRequest.Status.Message)
+		require.Equal(t, `Warning WakeUpFailed sleepinfos.kube-green.com "not-exists" not found`, <-recorder.Events)
+
+		require.Equal(t, ctrl.Result{}, reconcile(t, r), "the failed request is kept")
+		getRequest(t, r)
+	})
+
+	t.Run("the namespace has no SleepInfo", func(t *testing.T) {
+		r, _ := getReconciler(getWakeUpRequest(kubegreenv1alpha1.WakeUpRequestSpec{}))
+
+		reconcile(t, r)
+		require.Equal(t, "namespace my-namespace has no SleepInfo", getRequest(t, r).Status.Message)
+	})
+
+	t.Run("the SleepInfo do not wake up in time", func(t *testing.T) {
+		r, recorder := getReconciler(
+			getSleepInfo("a", "SLEEP"),
+			getWakeUpRequest(kubegreenv1alpha1.WakeUpRequestSpec{}),
+		)
+		reconcile(t, r)
+		<-recorder.Events
+		<-recorder.Events
+
+		r.Clock = testutil.NewClock(now.Add(defaultTimeout))
+		require.Equal(t, ctrl.Result{}, reconcile(t, r))
+		wakeUpRequest := getRequest(t, r)
+		require.Equal(t, kubegreenv1alpha1.WakeUpRequestPhaseFailed, wakeUpRequest.Status.Phase)
+		require.Equal(t, now, wakeUpRequest.Status.RequestedAt.UTC())
+		require.Equal(t, "SleepInfo a not woken up within 10m0s", wakeUpRequest.Status.Message)
+		require.Equal(t, "Warning WakeUpFailed SleepInfo a not woken up within 10m0s", <-recorder.Events)
+	})
+
+	t.Run("the request is not found", func(t *testing.T) {
+		r, _ := getReconciler()
+		require.Equal(t, ctrl.Result{}, reconcile(t, r))
+	})
+}
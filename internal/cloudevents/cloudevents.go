@@ -0,0 +1,238 @@
+package cloudevents
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	sleepinfocontroller "github.com/kube-green/kube-green/controllers/sleepinfo"
+	"github.com/kube-green/kube-green/pkg/clock"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// Path is where the CloudEvents are received, on the metrics endpoint.
+	Path = "/cloudevents"
+
+	sleepOperation = "SLEEP"
+
+	structuredContentType = "application/cloudevents+json"
+	specVersion           = "1.0"
+	maxBodySize           = 1 << 20
+	// maxAwakeFor is the max time a namespace can be kept awake by an event.
+	maxAwakeFor = 7 * 24 * time.Hour
+)
+
+// Event is a CloudEvent, with the attributes used by kube-green.
+type Event struct {
+	SpecVersion string `json:"specversion"`
+	ID          string `json:"id"`
+	Source      string `json:"source"`
+	Type        string `json:"type"`
+	// Namespace is the namespace to wake up, set with the namespace
+	// extension attribute or the namespace field of the JSON data.
+	Namespace string `json:"namespace,omitempty"`
+	// AwakeFor keeps the namespace awake for this duration, e.g. 4h, set with
+	// the awakefor extension attribute or the awakeFor field of the JSON data.
+	AwakeFor string          `json:"awakefor,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+}
+
+type eventData struct {
+	Namespace string `json:"namespace"`
+	AwakeFor  string `json:"awakeFor"`
+}
+
+// Handler receives the CloudEvents, in the binary or the structured content
+// mode of the HTTP binding, e.g. the "deployment started" events of the CI,
+// and wakes up the namespace of each event immediately. If the event sets an
+// awake duration, the sleeps of the namespace are snoozed too. The requests
+// must be authenticated with the bearer token.
+type Handler struct {
+	// Client reads and annotates the SleepInfo.
+	Client client.Client
+	// Token is the bearer token required to authenticate the requests.
+	Token string
+	// Types are the types of the events which wake up the namespaces. The
+	// events of other types are ignored. If empty, all the events wake up
+	// their namespace.
+	Types []string
+	Log   logr.Logger
+	// Clock gives the time of the wake ups requested by the events. If nil,
+	// the real clock is used.
+	Clock clock.Clock
+}
+
+// NewHandler returns a Handler authenticating the requests with the token
+// in tokenFile.
+func NewHandler(c client.Client, log logr.Logger, tokenFile string, types []string) (*Handler, error) {
+	data, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("fails to read token file: %s", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return nil, fmt.Errorf("token file %s is empty", tokenFile)
+	}
+	return &Handler{
+		Client: c,
+		Token:  token,
+		Types:  types,
+		Log:    log,
+	}, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !h.isAuthenticated(req) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxBodySize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "fails to read request")
+		return
+	}
+	event, err := parseEvent(req.Header, body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !h.isTypeHandled(event.Type) {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	awakeFor := time.Duration(0)
+	if event.AwakeFor != "" {
+		if awakeFor, err = time.ParseDuration(event.AwakeFor); err != nil || awakeFor <= 0 || awakeFor > maxAwakeFor {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid awakefor %s: must be positive and at most %s", event.AwakeFor, maxAwakeFor))
+			return
+		}
+	}
+	statusCode, err := h.wakeUp(req.Context(), event, awakeFor)
+	if err != nil {
+		writeError(w, statusCode, err.Error())
+		return
+	}
+	w.WriteHeader(statusCode)
+}
+
+func (h *Handler) isAuthenticated(req *http.Request) bool {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(h.Token)) == 1
+}
+
+func (h *Handler) isTypeHandled(eventType string) bool {
+	if len(h.Types) == 0 {
+		return true
+	}
+	for _, handledType := range h.Types {
+		if handledType == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// parseEvent parses the CloudEvent of the request, in the structured content
+// mode if the content type is application/cloudevents+json, in the binary
+// content mode otherwise.
+func parseEvent(header http.Header, body []byte) (Event, error) {
+	event := Event{}
+	contentType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if contentType == structuredContentType {
+		if err := json.Unmarshal(body, &event); err != nil {
+			return Event{}, fmt.Errorf("invalid event: %s", err)
+		}
+	} else {
+		event = Event{
+			SpecVersion: header.Get("ce-specversion"),
+			ID:          header.Get("ce-id"),
+			Source:      header.Get("ce-source"),
+			Type:        header.Get("ce-type"),
+			Namespace:   header.Get("ce-namespace"),
+			AwakeFor:    header.Get("ce-awakefor"),
+		}
+		if contentType == "application/json" {
+			event.Data = body
+		}
+	}
+
+	if event.SpecVersion != specVersion {
+		return Event{}, fmt.Errorf("unsupported specversion %q", event.SpecVersion)
+	}
+	if event.ID == "" || event.Source == "" || event.Type == "" {
+		return Event{}, fmt.Errorf("id, source and type are required")
+	}
+	if len(event.Data) > 0 {
+		data := eventData{}
+		// the data which is not a JSON object does not set the namespace.
+		if err := json.Unmarshal(event.Data, &data); err == nil {
+			if event.Namespace == "" {
+				event.Namespace = data.Namespace
+			}
+			if event.AwakeFor == "" {
+				event.AwakeFor = data.AwakeFor
+			}
+		}
+	}
+	if event.Namespace == "" {
+		return Event{}, fmt.Errorf("namespace is required")
+	}
+	return event, nil
+}
+
+// wakeUp wakes up the sleeping SleepInfo of the namespace of the event and,
+// if awakeFor is set, snoozes all its SleepInfo. It returns the status code
+// of the response.
+func (h *Handler) wakeUp(ctx context.Context, event Event, awakeFor time.Duration) (int, error) {
+	log := h.Log.WithValues("namespace", event.Namespace, "type", event.Type, "source", event.Source, "id", event.ID)
+	sleepInfos := kubegreenv1alpha1.SleepInfoList{}
+	if err := h.Client.List(ctx, &sleepInfos, client.InNamespace(event.Namespace)); err != nil {
+		log.Error(err, "fails to list sleepinfos")
+		return http.StatusInternalServerError, fmt.Errorf("fails to wake up namespace %s", event.Namespace)
+	}
+	if len(sleepInfos.Items) == 0 {
+		return http.StatusNotFound, fmt.Errorf("namespace %s has no SleepInfo", event.Namespace)
+	}
+
+	now := clock.Now(h.Clock)
+	for _, sleepInfo := range sleepInfos.Items {
+		sleepInfo := sleepInfo
+		var err error
+		switch {
+		case awakeFor > 0:
+			err = sleepinfocontroller.Snooze(ctx, h.Client, &sleepInfo, now, now.Add(awakeFor))
+		case sleepInfo.Status.OperationType == sleepOperation:
+			err = sleepinfocontroller.RequestWakeUp(ctx, h.Client, &sleepInfo, now)
+		}
+		if err != nil {
+			log.Error(err, "fails to request the wake up", "sleepinfo", sleepInfo.Name)
+			return http.StatusInternalServerError, fmt.Errorf("fails to wake up namespace %s", event.Namespace)
+		}
+	}
+	log.Info("wake up requested from cloudevent", "awakeFor", awakeFor)
+	return http.StatusAccepted, nil
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
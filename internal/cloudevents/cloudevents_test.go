@@ -0,0 +1,233 @@
+package cloudevents
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	sleepinfocontroller "github.com/kube-green/kube-green/controllers/sleepinfo"
+	"github.com/kube-green/kube-green/pkg/testutil"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHandler(t *testing.T) {
+	now := time.Date(2021, 3, 23, 21, 0, 0, 0, time.UTC)
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	newHandler := func() (*Handler, client.Client) {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&kubegreenv1alpha1.SleepInfo{
+				ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "preview-123"},
+				Status:     kubegreenv1alpha1.SleepInfoStatus{OperationType: "SLEEP"},
+			},
+			&kubegreenv1alpha1.SleepInfo{
+				ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "preview-456"},
+				Status:     kubegreenv1alpha1.SleepInfoStatus{OperationType: "WAKE_UP"},
+			},
+		).Build()
+		return &Handler{
+			Client: c,
+			Token:  "my-token",
+			Types:  []string{"dev.cdevents.deployment.started.0.1.0"},
+			Log:    logr.Discard(),
+			Clock:  testutil.NewClock(now),
+		}, c
+	}
+	getAnnotations := func(t *testing.T, c client.Client, namespace string) map[string]string {
+		t.Helper()
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: "sleepinfo"}, sleepInfo))
+		return sleepInfo.Annotations
+	}
+	binaryHeaders := func(namespace string) map[string]string {
+		return map[string]string{
+			"ce-specversion": "1.0",
+			"ce-id":          "event-1",
+			"ce-source":      "ci/pipelines/123",
+			"ce-type":        "dev.cdevents.deployment.started.0.1.0",
+			"ce-namespace":   namespace,
+		}
+	}
+	doRequest := func(handler *Handler, headers map[string]string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, Path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer my-token")
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("binary event", func(t *testing.T) {
+		handler, c := newHandler()
+		rec := doRequest(handler, binaryHeaders("preview-123"), "")
+
+		require.Equal(t, http.StatusAccepted, rec.Code)
+		require.Equal(t, map[string]string{
+			sleepinfocontroller.WakeUpRequestedAtAnnotation: "2021-03-23T21:00:00Z",
+		}, getAnnotations(t, c, "preview-123"))
+	})
+
+	t.Run("binary event with the namespace in the data", func(t *testing.T) {
+		handler, c := newHandler()
+		headers := binaryHeaders("")
+		headers["Content-Type"] = "application/json"
+		rec := doRequest(handler, headers, `{"namespace":"preview-123","awakeFor":"4h"}`)
+
+		require.Equal(t, http.StatusAccepted, rec.Code)
+		require.Equal(t, map[string]string{
+			sleepinfocontroller.SnoozeUntilAnnotation:       "2021-03-24T01:00:00Z",
+			sleepinfocontroller.WakeUpRequestedAtAnnotation: "2021-03-23T21:00:00Z",
+		}, getAnnotations(t, c, "preview-123"))
+	})
+
+	t.Run("structured event", func(t *testing.T) {
+		handler, c := newHandler()
+		rec := doRequest(handler, map[string]string{"Content-Type": "application/cloudevents+json; charset=utf-8"}, `{
+			"specversion": "1.0",
+			"id": "event-1",
+			"source": "ci/pipelines/123",
+			"type": "dev.cdevents.deployment.started.0.1.0",
+			"namespace": "preview-456",
+			"awakefor": "2h"
+		}`)
+
+		require.Equal(t, http.StatusAccepted, rec.Code)
+		require.Equal(t, map[string]string{
+			sleepinfocontroller.SnoozeUntilAnnotation: "2021-03-23T23:00:00Z",
+		}, getAnnotations(t, c, "preview-456"))
+	})
+
+	t.Run("awake namespace", func(t *testing.T) {
+		handler, c := newHandler()
+		rec := doRequest(handler, binaryHeaders("preview-456"), "")
+
+		require.Equal(t, http.StatusAccepted, rec.Code)
+		require.Empty(t, getAnnotations(t, c, "preview-456"))
+	})
+
+	t.Run("ignored type", func(t *testing.T) {
+		handler, c := newHandler()
+		headers := binaryHeaders("preview-123")
+		headers["ce-type"] = "dev.cdevents.build.started.0.1.0"
+		rec := doRequest(handler, headers, "")
+
+		require.Equal(t, http.StatusAccepted, rec.Code)
+		require.Empty(t, getAnnotations(t, c, "preview-123"))
+	})
+
+	t.Run("namespace without sleepinfo", func(t *testing.T) {
+		handler, _ := newHandler()
+		rec := doRequest(handler, binaryHeaders("preview-789"), "")
+
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		require.JSONEq(t, `{"error":"namespace preview-789 has no SleepInfo"}`, rec.Body.String())
+	})
+
+	t.Run("invalid events", func(t *testing.T) {
+		tests := []struct {
+			name          string
+			headers       map[string]string
+			body          string
+			expectedError string
+		}{
+			{
+				name:          "without namespace",
+				headers:       binaryHeaders(""),
+				expectedError: "namespace is required",
+			},
+			{
+				name:          "without specversion",
+				headers:       map[string]string{"ce-id": "event-1", "ce-source": "ci", "ce-type": "deploy", "ce-namespace": "preview-123"},
+				expectedError: "unsupported specversion \"\"",
+			},
+			{
+				name:          "without type",
+				headers:       map[string]string{"ce-specversion": "1.0", "ce-id": "event-1", "ce-source": "ci", "ce-namespace": "preview-123"},
+				expectedError: "id, source and type are required",
+			},
+			{
+				name:          "invalid structured event",
+				headers:       map[string]string{"Content-Type": "application/cloudevents+json"},
+				body:          "{",
+				expectedError: "invalid event: unexpected end of JSON input",
+			},
+			{
+				name: "invalid awake duration",
+				headers: map[string]string{
+					"ce-specversion": "1.0", "ce-id": "event-1", "ce-source": "ci", "ce-type": "dev.cdevents.deployment.started.0.1.0",
+					"ce-namespace": "preview-123", "ce-awakefor": "10d",
+				},
+				expectedError: "invalid awakefor 10d: must be positive and at most 168h0m0s",
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				handler, c := newHandler()
+				rec := doRequest(handler, test.headers, test.body)
+
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.JSONEq(t, `{"error":"`+strings.ReplaceAll(test.expectedError, `"`, `\"`)+`"}`, rec.Body.String())
+				require.Empty(t, getAnnotations(t, c, "preview-123"))
+			})
+		}
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		handler, c := newHandler()
+		for _, authorization := range []string{"", "Bearer other-token", "my-token"} {
+			req := httptest.NewRequest(http.MethodPost, Path, nil)
+			for key, value := range binaryHeaders("preview-123") {
+				req.Header.Set(key, value)
+			}
+			if authorization != "" {
+				req.Header.Set("Authorization", authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusUnauthorized, rec.Code, authorization)
+		}
+		require.Empty(t, getAnnotations(t, c, "preview-123"))
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		handler, _ := newHandler()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		require.Equal(t, http.MethodPost, rec.Header().Get("Allow"))
+	})
+}
+
+func TestNewHandler(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("my-token\n"), 0600))
+
+	handler, err := NewHandler(nil, logr.Discard(), tokenFile, []string{"deploy"})
+	require.NoError(t, err)
+	require.Equal(t, "my-token", handler.Token)
+	require.Equal(t, []string{"deploy"}, handler.Types)
+
+	emptyFile := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(emptyFile, []byte("\n"), 0600))
+	_, err = NewHandler(nil, logr.Discard(), emptyFile, nil)
+	require.EqualError(t, err, "token file "+emptyFile+" is empty")
+
+	_, err = NewHandler(nil, logr.Discard(), filepath.Join(dir, "missing"), nil)
+	require.ErrorContains(t, err, "fails to read token file")
+}
@@ -0,0 +1,266 @@
+package dashboard
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	sleepinfocontroller "github.com/kube-green/kube-green/controllers/sleepinfo"
+	"github.com/kube-green/kube-green/internal/statusapi"
+	"github.com/kube-green/kube-green/pkg/clock"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	sleepOperation = "SLEEP"
+
+	// savingsWindow is the window where the sleeping hours are estimated.
+	// The schedules repeat every week, so a week is enough.
+	savingsWindow = 7 * 24 * time.Hour
+	// maxSnooze is the longest snooze allowed from the dashboard.
+	maxSnooze = savingsWindow
+
+	shutdownTimeout = 5 * time.Second
+)
+
+//go:embed dashboard.html
+var dashboardHTML string
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(dashboardHTML))
+
+// Server serves a web dashboard with the state of the SleepInfo, and lets
+// users wake up or snooze a namespace. The actions set the same annotations
+// on the SleepInfo that can be set with kubectl annotate. The dashboard is
+// not authenticated: it must be bound to localhost, and reached with kubectl
+// port-forward, or exposed behind an authenticating proxy.
+type Server struct {
+	// Addr is the address the dashboard binds to.
+	Addr string
+	// Client reads and annotates the SleepInfo.
+	Client client.Client
+	Log    logr.Logger
+	// Clock gives the current time, to show the next operations and to
+	// request the wake ups. If nil, the real clock is used.
+	Clock clock.Clock
+}
+
+// Start serves the dashboard until the context is done.
+func (s *Server) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              s.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		s.Log.Info("serving dashboard", "addr", s.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- fmt.Errorf("fails to serve dashboard: %s", err)
+		}
+		close(errCh)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
+// NeedLeaderElection returns false, so that the dashboard is served by all
+// the replicas of the controller.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Handler returns the handler of the dashboard.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.serveDashboard)
+	mux.HandleFunc("/wake-up", s.serveAction(s.wakeUp))
+	mux.HandleFunc("/snooze", s.serveAction(s.snooze))
+	return mux
+}
+
+type dashboardData struct {
+	SleepInfos []sleepInfoView
+	Asleep     int
+	// SleepHours is the sum of the weekly sleeping hours of the SleepInfo.
+	SleepHours float64
+}
+
+type sleepInfoView struct {
+	statusapi.SleepInfoStatus
+	SnoozedUntil *time.Time
+	// WeeklySleepHours is the estimate of the hours the namespace sleeps in
+	// a week, from its schedule.
+	WeeklySleepHours float64
+	SleepPercentage  int
+}
+
+func (s *Server) serveDashboard(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/" {
+		http.NotFound(w, req)
+		return
+	}
+	if req.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sleepInfos := kubegreenv1alpha1.SleepInfoList{}
+	if err := s.Client.List(req.Context(), &sleepInfos); err != nil {
+		s.Log.Error(err, "fails to list sleepinfos")
+		http.Error(w, "fails to list sleepinfos", http.StatusInternalServerError)
+		return
+	}
+	sort.Slice(sleepInfos.Items, func(i, j int) bool {
+		a, b := sleepInfos.Items[i], sleepInfos.Items[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	data := dashboardData{SleepInfos: []sleepInfoView{}}
+	now := clock.Now(s.Clock)
+	for _, sleepInfo := range sleepInfos.Items {
+		sleepInfo := sleepInfo
+		view, err := getSleepInfoView(&sleepInfo, now)
+		if err != nil {
+			s.Log.Error(err, "fails to compute the next operations", "sleepinfo", client.ObjectKeyFromObject(&sleepInfo))
+		}
+		data.SleepInfos = append(data.SleepInfos, view)
+		data.SleepHours += view.WeeklySleepHours
+		if view.Asleep {
+			data.Asleep++
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTemplate.Execute(w, data); err != nil {
+		s.Log.Error(err, "fails to write dashboard")
+	}
+}
+
+func getSleepInfoView(sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time) (sleepInfoView, error) {
+	view := sleepInfoView{}
+	if value, ok := sleepInfo.Annotations[sleepinfocontroller.SnoozeUntilAnnotation]; ok {
+		if snoozeUntil, err := time.Parse(time.RFC3339, value); err == nil && now.Before(snoozeUntil) {
+			view.SnoozedUntil = &snoozeUntil
+		}
+	}
+	status, err := statusapi.GetSleepInfoStatus(sleepInfo, now)
+	view.SleepInfoStatus = status
+	if err != nil {
+		return view, err
+	}
+	sleepDuration, err := getSleepDuration(sleepInfo, now)
+	if err != nil {
+		return view, err
+	}
+	view.WeeklySleepHours = sleepDuration.Hours()
+	view.SleepPercentage = int(100 * sleepDuration / savingsWindow)
+	return view, nil
+}
+
+// getSleepDuration returns how long the namespace sleeps in the week after
+// now, following the schedule of the SleepInfo.
+func getSleepDuration(sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time) (time.Duration, error) {
+	until := now.Add(savingsWindow)
+	operations, err := sleepinfocontroller.PreviewOperations(sleepInfo, now, until)
+	if err != nil {
+		return 0, err
+	}
+	var sleepDuration time.Duration
+	var asleepSince *time.Time
+	if len(operations) > 0 && operations[0].Type != sleepOperation {
+		// the first operation is a wake up: the namespace sleeps since now.
+		asleepSince = &now
+	}
+	for _, operation := range operations {
+		operation := operation
+		switch {
+		case operation.Type == sleepOperation && asleepSince == nil:
+			asleepSince = &operation.Time
+		case operation.Type != sleepOperation && asleepSince != nil:
+			sleepDuration += operation.Time.Sub(*asleepSince)
+			asleepSince = nil
+		}
+	}
+	if asleepSince != nil {
+		sleepDuration += until.Sub(*asleepSince)
+	}
+	return sleepDuration, nil
+}
+
+// serveAction serves a POST of a form, with the namespace and the name of the
+// SleepInfo, that performs the action and redirects to the dashboard.
+func (s *Server) serveAction(action func(ctx context.Context, sleepInfo *kubegreenv1alpha1.SleepInfo, req *http.Request) (int, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		// the browsers set Sec-Fetch-Site: forms submitted by other sites
+		// must not act on the SleepInfo.
+		if site := req.Header.Get("Sec-Fetch-Site"); site != "" && site != "same-origin" && site != "none" {
+			http.Error(w, "cross-site request", http.StatusForbidden)
+			return
+		}
+
+		key := client.ObjectKey{Namespace: req.PostFormValue("namespace"), Name: req.PostFormValue("name")}
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		if err := s.Client.Get(req.Context(), key, sleepInfo); err != nil {
+			s.Log.Error(err, "fails to get sleepinfo", "sleepinfo", key)
+			http.Error(w, "fails to get sleepinfo", http.StatusNotFound)
+			return
+		}
+		if statusCode, err := action(req.Context(), sleepInfo, req); err != nil {
+			s.Log.Error(err, "fails to perform the action", "sleepinfo", key, "path", req.URL.Path)
+			http.Error(w, err.Error(), statusCode)
+			return
+		}
+		http.Redirect(w, req, "/", http.StatusSeeOther)
+	}
+}
+
+// wakeUp requests the wake up of the sleeping SleepInfo.
+func (s *Server) wakeUp(ctx context.Context, sleepInfo *kubegreenv1alpha1.SleepInfo, _ *http.Request) (int, error) {
+	if sleepInfo.Status.OperationType != sleepOperation {
+		return http.StatusConflict, fmt.Errorf("sleepinfo is not sleeping")
+	}
+	if err := sleepinfocontroller.RequestWakeUp(ctx, s.Client, sleepInfo, clock.Now(s.Clock)); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("fails to request the wake up: %s", err)
+	}
+	s.Log.Info("wake up requested", "sleepinfo", client.ObjectKeyFromObject(sleepInfo))
+	return 0, nil
+}
+
+// snooze skips the sleeps of the SleepInfo for the duration in the form, and
+// wakes it up if it is sleeping.
+func (s *Server) snooze(ctx context.Context, sleepInfo *kubegreenv1alpha1.SleepInfo, req *http.Request) (int, error) {
+	duration, err := time.ParseDuration(req.PostFormValue("duration"))
+	if err != nil || duration <= 0 || duration > maxSnooze {
+		return http.StatusBadRequest, fmt.Errorf("duration is invalid: must be positive and at most %s", maxSnooze)
+	}
+	now := clock.Now(s.Clock)
+	if err := sleepinfocontroller.Snooze(ctx, s.Client, sleepInfo, now, now.Add(duration)); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("fails to snooze: %s", err)
+	}
+	s.Log.Info("sleep snoozed", "sleepinfo", client.ObjectKeyFromObject(sleepInfo), "duration", duration)
+	return 0, nil
+}
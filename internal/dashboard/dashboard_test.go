@@ -0,0 +1,208 @@
+package dashboard
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	sleepinfocontroller "github.com/kube-green/kube-green/controllers/sleepinfo"
+	"github.com/kube-green/kube-green/pkg/testutil"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestServer(t *testing.T) {
+	// Tuesday
+	now := time.Date(2021, 3, 23, 12, 0, 0, 0, time.UTC)
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	getSleepInfos := func() []client.Object {
+		return []client.Object{
+			&kubegreenv1alpha1.SleepInfo{
+				ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "backend"},
+				Spec: kubegreenv1alpha1.SleepInfoSpec{
+					Weekdays:   "1-5",
+					SleepTime:  "10:00",
+					WakeUpTime: "14:00",
+				},
+				Status: kubegreenv1alpha1.SleepInfoStatus{
+					LastScheduleTime: metav1.NewTime(time.Date(2021, 3, 23, 10, 0, 0, 0, time.UTC)),
+					OperationType:    "SLEEP",
+				},
+			},
+			&kubegreenv1alpha1.SleepInfo{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "sleepinfo",
+					Namespace:   "frontend",
+					Annotations: map[string]string{sleepinfocontroller.SnoozeUntilAnnotation: "2021-03-23T20:00:00Z"},
+				},
+				Spec: kubegreenv1alpha1.SleepInfoSpec{
+					Weekdays:   "*",
+					SleepTime:  "20:00",
+					WakeUpTime: "08:00",
+				},
+			},
+		}
+	}
+
+	newServer := func() (*Server, client.Client) {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(getSleepInfos()...).Build()
+		return &Server{
+			Client: c,
+			Log:    logr.Discard(),
+			Clock:  testutil.NewClock(now),
+		}, c
+	}
+	getAnnotations := func(t *testing.T, c client.Client, namespace string) map[string]string {
+		t.Helper()
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: "sleepinfo"}, sleepInfo))
+		return sleepInfo.Annotations
+	}
+	postForm := func(server *Server, target string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("dashboard", func(t *testing.T) {
+		server, _ := newServer()
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+		require.Contains(t, body, "2 SleepInfo, 1 asleep now, 104 sleeping hours per week.")
+		require.Contains(t, body, "<td>backend</td>")
+		require.Contains(t, body, "WAKE_UP Tue Mar 23 14:00 UTC")
+		require.Contains(t, body, "Snoozed until Tue Mar 23 20:00 UTC")
+		require.Contains(t, body, "20.0 (11%)")
+		require.Contains(t, body, "84.0 (50%)")
+		require.Equal(t, 1, strings.Count(body, `action="/wake-up"`))
+	})
+
+	t.Run("wake up", func(t *testing.T) {
+		server, c := newServer()
+		rec := postForm(server, "/wake-up", url.Values{"namespace": {"backend"}, "name": {"sleepinfo"}})
+
+		require.Equal(t, http.StatusSeeOther, rec.Code)
+		require.Equal(t, map[string]string{
+			sleepinfocontroller.WakeUpRequestedAtAnnotation: "2021-03-23T12:00:00Z",
+		}, getAnnotations(t, c, "backend"))
+	})
+
+	t.Run("wake up of an awake sleepinfo", func(t *testing.T) {
+		server, _ := newServer()
+		rec := postForm(server, "/wake-up", url.Values{"namespace": {"frontend"}, "name": {"sleepinfo"}})
+
+		require.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("snooze a sleeping sleepinfo", func(t *testing.T) {
+		server, c := newServer()
+		rec := postForm(server, "/snooze", url.Values{"namespace": {"backend"}, "name": {"sleepinfo"}, "duration": {"2h"}})
+
+		require.Equal(t, http.StatusSeeOther, rec.Code)
+		require.Equal(t, map[string]string{
+			sleepinfocontroller.SnoozeUntilAnnotation:       "2021-03-23T14:00:00Z",
+			sleepinfocontroller.WakeUpRequestedAtAnnotation: "2021-03-23T12:00:00Z",
+		}, getAnnotations(t, c, "backend"))
+	})
+
+	t.Run("snooze an awake sleepinfo", func(t *testing.T) {
+		server, c := newServer()
+		rec := postForm(server, "/snooze", url.Values{"namespace": {"frontend"}, "name": {"sleepinfo"}, "duration": {"24h"}})
+
+		require.Equal(t, http.StatusSeeOther, rec.Code)
+		require.Equal(t, map[string]string{
+			sleepinfocontroller.SnoozeUntilAnnotation: "2021-03-24T12:00:00Z",
+		}, getAnnotations(t, c, "frontend"))
+	})
+
+	t.Run("invalid snooze duration", func(t *testing.T) {
+		for _, duration := range []string{"", "-1h", "8d", "169h"} {
+			server, _ := newServer()
+			rec := postForm(server, "/snooze", url.Values{"namespace": {"frontend"}, "name": {"sleepinfo"}, "duration": {duration}})
+			require.Equal(t, http.StatusBadRequest, rec.Code, duration)
+		}
+	})
+
+	t.Run("sleepinfo not found", func(t *testing.T) {
+		server, _ := newServer()
+		rec := postForm(server, "/wake-up", url.Values{"namespace": {"other"}, "name": {"sleepinfo"}})
+
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("cross-site request", func(t *testing.T) {
+		server, c := newServer()
+		form := url.Values{"namespace": {"backend"}, "name": {"sleepinfo"}}
+		req := httptest.NewRequest(http.MethodPost, "/wake-up", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Sec-Fetch-Site", "cross-site")
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusForbidden, rec.Code)
+		require.Empty(t, getAnnotations(t, c, "backend"))
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		server, _ := newServer()
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/wake-up", nil))
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+		rec = httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+func TestGetSleepDuration(t *testing.T) {
+	// Tuesday
+	now := time.Date(2021, 3, 23, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		spec     kubegreenv1alpha1.SleepInfoSpec
+		expected time.Duration
+	}{
+		{
+			name:     "sleep every night",
+			spec:     kubegreenv1alpha1.SleepInfoSpec{Weekdays: "*", SleepTime: "20:00", WakeUpTime: "08:00"},
+			expected: 7 * 12 * time.Hour,
+		},
+		{
+			name:     "sleeping now",
+			spec:     kubegreenv1alpha1.SleepInfoSpec{Weekdays: "1-5", SleepTime: "10:00", WakeUpTime: "14:00"},
+			expected: 5 * 4 * time.Hour,
+		},
+		{
+			name:     "without wake up",
+			spec:     kubegreenv1alpha1.SleepInfoSpec{Weekdays: "*", SleepTime: "20:00"},
+			expected: 7*24*time.Hour - 8*time.Hour,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sleepInfo := &kubegreenv1alpha1.SleepInfo{Spec: test.spec}
+			sleepDuration, err := getSleepDuration(sleepInfo, now)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, sleepDuration)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package featuregate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Feature is the name of a feature of the controller which can be enabled or
+// disabled with the --feature-gates flag.
+type Feature string
+
+const (
+	// WakeOnRequest enables the controller of the WakeUpRequest, which wakes
+	// up a namespace on request.
+	WakeOnRequest Feature = "WakeOnRequest"
+	// OperationLock serializes the operations of the SleepInfo of the same
+	// namespace.
+	OperationLock Feature = "OperationLock"
+	// ScaleWarning enables the webhook which warns who scales up a workload
+	// put to sleep.
+	ScaleWarning Feature = "ScaleWarning"
+)
+
+const (
+	// Alpha is the stage of the features which ship dark: they are disabled
+	// by default, and they can change or be removed.
+	Alpha = "ALPHA"
+	// Beta is the stage of the features enabled by default, which can still
+	// be disabled in case of issues.
+	Beta = "BETA"
+)
+
+// FeatureSpec is the default and the stage of a feature.
+type FeatureSpec struct {
+	Default bool
+	Stage   string
+}
+
+// features are the known features of the controller.
+var features = map[Feature]FeatureSpec{
+	WakeOnRequest: {Default: true, Stage: Beta},
+	OperationLock: {Default: true, Stage: Beta},
+	ScaleWarning:  {Default: false, Stage: Alpha},
+}
+
+// Gates tell which features are enabled.
+type Gates struct {
+	enabled map[Feature]bool
+}
+
+// Parse returns the gates set by the value of the --feature-gates flag, a
+// comma separated list of feature=bool pairs, e.g.
+// WakeOnRequest=false,OperationLock=true. The features not in the list keep
+// their default.
+func Parse(value string) (Gates, error) {
+	gates := Gates{enabled: map[Feature]bool{}}
+	for feature, spec := range features {
+		gates.enabled[feature] = spec.Default
+	}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, rawEnabled, ok := strings.Cut(pair, "=")
+		if !ok {
+			return Gates{}, fmt.Errorf("invalid feature gate %s: must be in the form feature=bool", pair)
+		}
+		feature := Feature(strings.TrimSpace(name))
+		if _, ok := features[feature]; !ok {
+			return Gates{}, fmt.Errorf("unknown feature gate %s: must be one of %s", feature, strings.Join(featureNames(), ", "))
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(rawEnabled))
+		if err != nil {
+			return Gates{}, fmt.Errorf("invalid value of feature gate %s: %s", feature, err)
+		}
+		gates.enabled[feature] = enabled
+	}
+	return gates, nil
+}
+
+// Enabled returns true if the feature is enabled. The unknown features are
+// disabled.
+func (g Gates) Enabled(feature Feature) bool {
+	return g.enabled[feature]
+}
+
+// Report sets the gauge, labeled by the name and the stage of the feature, to
+// 1 for the enabled features and to 0 for the disabled ones.
+func (g Gates) Report(gauge *prometheus.GaugeVec) {
+	for feature, spec := range features {
+		value := 0.0
+		if g.Enabled(feature) {
+			value = 1
+		}
+		gauge.WithLabelValues(string(feature), spec.Stage).Set(value)
+	}
+}
+
+// KnownFeatures returns the description of the known features, sorted by
+// name, e.g. "WakeOnRequest=true|false (BETA - default=true)".
+func KnownFeatures() []string {
+	known := []string{}
+	for _, name := range featureNames() {
+		spec := features[Feature(name)]
+		known = append(known, fmt.Sprintf("%s=true|false (%s - default=%t)", name, spec.Stage, spec.Default))
+	}
+	return known
+}
+
+func featureNames() []string {
+	names := []string{}
+	for feature := range features {
+		names = append(names, string(feature))
+	}
+	sort.Strings(names)
+	return names
+}
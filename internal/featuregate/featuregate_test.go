@@ -0,0 +1,102 @@
+package featuregate
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		expected      map[Feature]bool
+		expectedError string
+	}{
+		{
+			name:     "defaults",
+			value:    "",
+			expected: map[Feature]bool{WakeOnRequest: true, OperationLock: true, ScaleWarning: false},
+		},
+		{
+			name:     "disable a feature",
+			value:    "WakeOnRequest=false",
+			expected: map[Feature]bool{WakeOnRequest: false, OperationLock: true, ScaleWarning: false},
+		},
+		{
+			name:     "set more features",
+			value:    " WakeOnRequest=false, OperationLock=true ,",
+			expected: map[Feature]bool{WakeOnRequest: false, OperationLock: true},
+		},
+		{
+			name:     "enable an alpha feature",
+			value:    "ScaleWarning=true",
+			expected: map[Feature]bool{WakeOnRequest: true, OperationLock: true, ScaleWarning: true},
+		},
+		{
+			name:          "unknown feature",
+			value:         "StatefulSets=true,WakeOnRequest=false",
+			expectedError: "unknown feature gate StatefulSets: must be one of OperationLock, ScaleWarning, WakeOnRequest",
+		},
+		{
+			name:          "without value",
+			value:         "WakeOnRequest",
+			expectedError: "invalid feature gate WakeOnRequest: must be in the form feature=bool",
+		},
+		{
+			name:          "invalid value",
+			value:         "WakeOnRequest=maybe",
+			expectedError: `invalid value of feature gate WakeOnRequest: strconv.ParseBool: parsing "maybe": invalid syntax`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gates, err := Parse(test.value)
+			if test.expectedError != "" {
+				require.EqualError(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			for feature, enabled := range test.expected {
+				require.Equal(t, enabled, gates.Enabled(feature), feature)
+			}
+		})
+	}
+
+	t.Run("unknown features are disabled", func(t *testing.T) {
+		gates, err := Parse("")
+		require.NoError(t, err)
+		require.False(t, gates.Enabled(Feature("StatefulSets")))
+	})
+}
+
+func TestReport(t *testing.T) {
+	gates, err := Parse("WakeOnRequest=false")
+	require.NoError(t, err)
+
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "feature_enabled",
+		Help: "Whether the feature is enabled",
+	}, []string{"name", "stage"})
+	gates.Report(gauge)
+
+	require.NoError(t, testutil.CollectAndCompare(gauge, bytes.NewBufferString(`
+	# HELP feature_enabled Whether the feature is enabled
+	# TYPE feature_enabled gauge
+	feature_enabled{name="OperationLock",stage="BETA"} 1
+	feature_enabled{name="ScaleWarning",stage="ALPHA"} 0
+	feature_enabled{name="WakeOnRequest",stage="BETA"} 0
+	`)))
+}
+
+func TestKnownFeatures(t *testing.T) {
+	require.Equal(t, []string{
+		"OperationLock=true|false (BETA - default=true)",
+		"ScaleWarning=true|false (ALPHA - default=false)",
+		"WakeOnRequest=true|false (BETA - default=true)",
+	}, KnownFeatures())
+}
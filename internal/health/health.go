@@ -0,0 +1,106 @@
+package health
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kube-green/kube-green/pkg/clock"
+
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// Tracker tracks the reconciles of a controller, to report it as degraded
+// when the reconciles repeatedly fail or a reconcile is stuck.
+type Tracker struct {
+	// MaxConsecutiveFailures is the number of consecutive failed reconciles
+	// after which the controller is degraded. If 0, the failures are ignored.
+	MaxConsecutiveFailures int
+	// MaxReconcileDuration is the duration after which a running reconcile
+	// is stuck. If 0, the running reconciles are ignored.
+	MaxReconcileDuration time.Duration
+	// Clock gives the current time, to measure how long the reconciles are
+	// running. If nil, the real clock is used.
+	Clock clock.Clock
+
+	mu                  sync.Mutex
+	consecutiveFailures int
+	lastError           error
+	nextID              uint64
+	running             map[uint64]time.Time
+}
+
+// NewTracker returns a Tracker with the given thresholds.
+func NewTracker(maxConsecutiveFailures int, maxReconcileDuration time.Duration) *Tracker {
+	return &Tracker{
+		MaxConsecutiveFailures: maxConsecutiveFailures,
+		MaxReconcileDuration:   maxReconcileDuration,
+	}
+}
+
+// Start records the start of a reconcile. The returned function must be
+// called with the result of the reconcile when it ends.
+func (t *Tracker) Start() func(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.running == nil {
+		t.running = map[uint64]time.Time{}
+	}
+	id := t.nextID
+	t.nextID++
+	t.running[id] = clock.Now(t.Clock)
+
+	return func(err error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.running, id)
+		if err != nil {
+			t.consecutiveFailures++
+			t.lastError = err
+			return
+		}
+		t.consecutiveFailures = 0
+		t.lastError = nil
+	}
+}
+
+// ReconcileFailuresCheck fails if the last MaxConsecutiveFailures reconciles
+// have failed.
+func (t *Tracker) ReconcileFailuresCheck(_ *http.Request) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.MaxConsecutiveFailures > 0 && t.consecutiveFailures >= t.MaxConsecutiveFailures {
+		return fmt.Errorf("%d consecutive reconciles failed, last error: %s", t.consecutiveFailures, t.lastError)
+	}
+	return nil
+}
+
+// StuckReconcileCheck fails if a reconcile is running since more than
+// MaxReconcileDuration, i.e. a worker of the work queue is stuck.
+func (t *Tracker) StuckReconcileCheck(_ *http.Request) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.MaxReconcileDuration <= 0 {
+		return nil
+	}
+	now := clock.Now(t.Clock)
+	for _, startedAt := range t.running {
+		if running := now.Sub(startedAt); running > t.MaxReconcileDuration {
+			return fmt.Errorf("reconcile running since %s", running.Round(time.Second))
+		}
+	}
+	return nil
+}
+
+// APIServerCheck returns a checker which fails if the API server is not
+// reachable.
+func APIServerCheck(client discovery.ServerVersionInterface) healthz.Checker {
+	return func(_ *http.Request) error {
+		if _, err := client.ServerVersion(); err != nil {
+			return fmt.Errorf("api server not reachable: %s", err)
+		}
+		return nil
+	}
+}
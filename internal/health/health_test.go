@@ -0,0 +1,79 @@
+package health
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+func TestReconcileFailuresCheck(t *testing.T) {
+	tracker := NewTracker(2, 0)
+
+	tracker.Start()(fmt.Errorf("some error"))
+	require.NoError(t, tracker.ReconcileFailuresCheck(nil))
+
+	tracker.Start()(fmt.Errorf("other error"))
+	require.EqualError(t, tracker.ReconcileFailuresCheck(nil), "2 consecutive reconciles failed, last error: other error")
+
+	tracker.Start()(nil)
+	require.NoError(t, tracker.ReconcileFailuresCheck(nil))
+
+	t.Run("disabled", func(t *testing.T) {
+		tracker := NewTracker(0, 0)
+		for i := 0; i < 10; i++ {
+			tracker.Start()(fmt.Errorf("some error"))
+		}
+		require.NoError(t, tracker.ReconcileFailuresCheck(nil))
+	})
+}
+
+func TestStuckReconcileCheck(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)}
+	tracker := NewTracker(0, 10*time.Minute)
+	tracker.Clock = clock
+
+	done := tracker.Start()
+	require.NoError(t, tracker.StuckReconcileCheck(nil))
+
+	clock.now = clock.now.Add(11 * time.Minute)
+	tracker.Start()
+	require.EqualError(t, tracker.StuckReconcileCheck(nil), "reconcile running since 11m0s")
+
+	done(nil)
+	require.NoError(t, tracker.StuckReconcileCheck(nil))
+
+	t.Run("disabled", func(t *testing.T) {
+		tracker := NewTracker(0, 0)
+		tracker.Clock = clock
+		tracker.Start()
+		clock.now = clock.now.Add(24 * time.Hour)
+		require.NoError(t, tracker.StuckReconcileCheck(nil))
+	})
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+type fakeServerVersion struct {
+	err error
+}
+
+func (f fakeServerVersion) ServerVersion() (*version.Info, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &version.Info{GitVersion: "v1.26.4"}, nil
+}
+
+func TestAPIServerCheck(t *testing.T) {
+	require.NoError(t, APIServerCheck(fakeServerVersion{})(nil))
+	require.EqualError(t, APIServerCheck(fakeServerVersion{err: fmt.Errorf("connection refused")})(nil), "api server not reachable: connection refused")
+}
@@ -0,0 +1,132 @@
+package multicluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	configv1alpha1 "github.com/kube-green/kube-green/api/config/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/metrics"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+)
+
+const (
+	defaultStatusInterval = time.Minute
+	statusTimeout         = 5 * time.Second
+)
+
+// Cluster is a remote cluster, whose SleepInfo are reconciled by the controller.
+type Cluster struct {
+	Name string
+	cluster.Cluster
+}
+
+// NewCluster returns the remote cluster configured by the kubeconfig stored in
+// its Secret, read with the reader. The Secret is read only once, so the
+// controller must be restarted when the kubeconfig changes.
+func NewCluster(ctx context.Context, reader client.Reader, scheme *runtime.Scheme, remoteCluster configv1alpha1.RemoteCluster) (*Cluster, error) {
+	restConfig, err := GetRESTConfig(ctx, reader, remoteCluster.KubeconfigSecret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote cluster %s: %s", remoteCluster.Name, err)
+	}
+	remote, err := cluster.New(restConfig, func(o *cluster.Options) {
+		o.Scheme = scheme
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fails to create remote cluster %s: %s", remoteCluster.Name, err)
+	}
+	return &Cluster{Name: remoteCluster.Name, Cluster: remote}, nil
+}
+
+// GetRESTConfig returns the configuration of the client of a remote cluster,
+// from the kubeconfig stored in the Secret.
+func GetRESTConfig(ctx context.Context, reader client.Reader, secretRef configv1alpha1.KubeconfigSecretRef) (*rest.Config, error) {
+	secret := &v1.Secret{}
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: secretRef.Namespace, Name: secretRef.Name}, secret); err != nil {
+		return nil, fmt.Errorf("fails to get kubeconfig secret %s/%s: %s", secretRef.Namespace, secretRef.Name, err)
+	}
+	kubeconfig, ok := secret.Data[secretRef.GetKey()]
+	if !ok {
+		return nil, fmt.Errorf("kubeconfig secret %s/%s has no key %s", secretRef.Namespace, secretRef.Name, secretRef.GetKey())
+	}
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig in secret %s/%s: %s", secretRef.Namespace, secretRef.Name, err)
+	}
+	return restConfig, nil
+}
+
+// NewServerVersionClient returns the client which checks whether the API
+// server of the remote cluster is reachable.
+func (c *Cluster) NewServerVersionClient() (discovery.ServerVersionInterface, error) {
+	restConfig := rest.CopyConfig(c.GetConfig())
+	restConfig.Timeout = statusTimeout
+	return discovery.NewDiscoveryClientForConfig(restConfig)
+}
+
+// StatusReporter checks periodically whether the API servers of the remote
+// clusters are reachable. The status of each cluster is reported by the
+// RemoteClusterReachable metric and logged when it changes.
+type StatusReporter struct {
+	// Clusters are the clients which check the remote clusters, by name.
+	Clusters map[string]discovery.ServerVersionInterface
+	// Interval is the interval between the checks. Default to a minute.
+	Interval time.Duration
+	Metrics  metrics.Metrics
+	Log      logr.Logger
+
+	reachable map[string]bool
+}
+
+// Start checks the remote clusters until the context is done.
+func (s *StatusReporter) Start(ctx context.Context) error {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = defaultStatusInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.Check()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check checks whether the remote clusters are reachable and reports their
+// status.
+func (s *StatusReporter) Check() {
+	if s.reachable == nil {
+		s.reachable = map[string]bool{}
+	}
+	for name, serverVersionClient := range s.Clusters {
+		_, err := serverVersionClient.ServerVersion()
+		reachable := err == nil
+		if reachable {
+			s.Metrics.RemoteClusterReachable.WithLabelValues(name).Set(1)
+		} else {
+			s.Metrics.RemoteClusterReachable.WithLabelValues(name).Set(0)
+		}
+
+		if wasReachable, checked := s.reachable[name]; checked && wasReachable == reachable {
+			continue
+		}
+		s.reachable[name] = reachable
+		if reachable {
+			s.Log.Info("remote cluster reachable", "cluster", name)
+		} else {
+			s.Log.Error(err, "remote cluster not reachable", "cluster", name)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package multicluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	configv1alpha1 "github.com/kube-green/kube-green/api/config/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/metrics"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: dev-1
+  cluster:
+    server: https://dev-1.example.com:6443
+contexts:
+- name: dev-1
+  context:
+    cluster: dev-1
+    user: kube-green
+current-context: dev-1
+users:
+- name: kube-green
+  user:
+    token: my-token
+`
+
+func TestGetRESTConfig(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev-1", Namespace: "kube-green"},
+		Data: map[string][]byte{
+			"value":   []byte(kubeconfig),
+			"invalid": []byte("not a kubeconfig"),
+		},
+	}
+	reader := fake.NewClientBuilder().WithObjects(secret).Build()
+
+	t.Run("default key", func(t *testing.T) {
+		restConfig, err := GetRESTConfig(context.Background(), reader, configv1alpha1.KubeconfigSecretRef{Namespace: "kube-green", Name: "dev-1"})
+		require.NoError(t, err)
+		require.Equal(t, "https://dev-1.example.com:6443", restConfig.Host)
+		require.Equal(t, "my-token", restConfig.BearerToken)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		_, err := GetRESTConfig(context.Background(), reader, configv1alpha1.KubeconfigSecretRef{Namespace: "kube-green", Name: "dev-1", Key: "kubeconfig"})
+		require.EqualError(t, err, "kubeconfig secret kube-green/dev-1 has no key kubeconfig")
+	})
+
+	t.Run("invalid kubeconfig", func(t *testing.T) {
+		_, err := GetRESTConfig(context.Background(), reader, configv1alpha1.KubeconfigSecretRef{Namespace: "kube-green", Name: "dev-1", Key: "invalid"})
+		require.ErrorContains(t, err, "invalid kubeconfig in secret kube-green/dev-1")
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		_, err := GetRESTConfig(context.Background(), reader, configv1alpha1.KubeconfigSecretRef{Namespace: "kube-green", Name: "dev-2"})
+		require.ErrorContains(t, err, "fails to get kubeconfig secret kube-green/dev-2")
+	})
+}
+
+type fakeServerVersion struct {
+	err error
+}
+
+func (f *fakeServerVersion) ServerVersion() (*version.Info, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &version.Info{GitVersion: "v1.26.0"}, nil
+}
+
+func TestStatusReporter(t *testing.T) {
+	dev1 := &fakeServerVersion{}
+	dev2 := &fakeServerVersion{err: fmt.Errorf("connection refused")}
+	reporter := &StatusReporter{
+		Clusters: map[string]discovery.ServerVersionInterface{
+			"dev-1": dev1,
+			"dev-2": dev2,
+		},
+		Metrics: metrics.SetupMetricsOrDie("test"),
+		Log:     logr.Discard(),
+	}
+
+	reporter.Check()
+	require.Equal(t, float64(1), testutil.ToFloat64(reporter.Metrics.RemoteClusterReachable.WithLabelValues("dev-1")))
+	require.Equal(t, float64(0), testutil.ToFloat64(reporter.Metrics.RemoteClusterReachable.WithLabelValues("dev-2")))
+
+	dev1.err = fmt.Errorf("connection refused")
+	dev2.err = nil
+	reporter.Check()
+	require.Equal(t, float64(0), testutil.ToFloat64(reporter.Metrics.RemoteClusterReachable.WithLabelValues("dev-1")))
+	require.Equal(t, float64(1), testutil.ToFloat64(reporter.Metrics.RemoteClusterReachable.WithLabelValues("dev-2")))
+	require.Equal(t, map[string]bool{"dev-1": false, "dev-2": true}, reporter.reachable)
+}
@@ -0,0 +1,109 @@
+package namespacefilter
+
+import (
+	"fmt"
+	"path"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Filter selects the namespaces where kube-green is allowed to act.
+//
+// A namespace is allowed if its name matches at least one of the Allow
+// patterns (or Allow is empty) and its labels match the AllowSelector (if set).
+// Deny rules take precedence: a namespace whose name matches one of the Deny
+// patterns or whose labels match the DenySelector is never allowed.
+type Filter struct {
+	// Allow is the list of glob patterns of the allowed namespace names.
+	Allow []string
+	// Deny is the list of glob patterns of the denied namespace names.
+	Deny []string
+	// AllowSelector selects the allowed namespaces by labels.
+	AllowSelector labels.Selector
+	// DenySelector selects the denied namespaces by labels.
+	DenySelector labels.Selector
+}
+
+// New returns a Filter, validating the glob patterns.
+func New(allow, deny []string, allowSelector, denySelector labels.Selector) (Filter, error) {
+	for _, pattern := range append(append([]string{}, allow...), deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return Filter{}, fmt.Errorf("invalid namespace pattern %q: %s", pattern, err)
+		}
+	}
+	return Filter{
+		Allow:         allow,
+		Deny:          deny,
+		AllowSelector: allowSelector,
+		DenySelector:  denySelector,
+	}, nil
+}
+
+// NewFromLabelSelectors returns a Filter with the selectors of the API, as
+// set in the config file. A nil selector is not set: the rules of the filter
+// are only the ones configured.
+func NewFromLabelSelectors(allow, deny []string, allowSelector, denySelector *metav1.LabelSelector) (Filter, error) {
+	allowLabelSelector, err := labelSelectorAsSelector(allowSelector)
+	if err != nil {
+		return Filter{}, fmt.Errorf("invalid allow selector: %s", err)
+	}
+	denyLabelSelector, err := labelSelectorAsSelector(denySelector)
+	if err != nil {
+		return Filter{}, fmt.Errorf("invalid deny selector: %s", err)
+	}
+	return New(allow, deny, allowLabelSelector, denyLabelSelector)
+}
+
+// labelSelectorAsSelector converts the selector, mapping nil to nil:
+// metav1.LabelSelectorAsSelector returns labels.Nothing() for a nil selector,
+// which would deny all the namespaces.
+func labelSelectorAsSelector(selector *metav1.LabelSelector) (labels.Selector, error) {
+	if selector == nil {
+		return nil, nil
+	}
+	return metav1.LabelSelectorAsSelector(selector)
+}
+
+// HasLabelSelectors returns true if the namespace labels are needed to
+// evaluate the filter.
+func (f Filter) HasLabelSelectors() bool {
+	return !isEmptySelector(f.AllowSelector) || !isEmptySelector(f.DenySelector)
+}
+
+// IsNameAllowed evaluates only the name based rules of the filter.
+func (f Filter) IsNameAllowed(name string) bool {
+	if matchAny(f.Deny, name) {
+		return false
+	}
+	return len(f.Allow) == 0 || matchAny(f.Allow, name)
+}
+
+// IsAllowed evaluates all the rules of the filter on the namespace.
+func (f Filter) IsAllowed(namespace *v1.Namespace) bool {
+	if !f.IsNameAllowed(namespace.Name) {
+		return false
+	}
+	namespaceLabels := labels.Set(namespace.Labels)
+	if !isEmptySelector(f.DenySelector) && f.DenySelector.Matches(namespaceLabels) {
+		return false
+	}
+	if !isEmptySelector(f.AllowSelector) && !f.AllowSelector.Matches(namespaceLabels) {
+		return false
+	}
+	return true
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptySelector(selector labels.Selector) bool {
+	return selector == nil || selector.Empty()
+}
@@ -0,0 +1,165 @@
+package namespacefilter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("valid patterns", func(t *testing.T) {
+		filter, err := New([]string{"team-*"}, []string{"kube-*", "default"}, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, Filter{
+			Allow: []string{"team-*"},
+			Deny:  []string{"kube-*", "default"},
+		}, filter)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		_, err := New(nil, []string{"kube-["}, nil, nil)
+		require.EqualError(t, err, `invalid namespace pattern "kube-[": syntax error in pattern`)
+	})
+}
+
+func TestNewFromLabelSelectors(t *testing.T) {
+	t.Run("only name patterns", func(t *testing.T) {
+		filter, err := NewFromLabelSelectors([]string{"team-*"}, []string{"team-b"}, nil, nil)
+		require.NoError(t, err)
+		require.False(t, filter.HasLabelSelectors())
+
+		namespace := getNamespace("team-a", nil)
+		require.True(t, filter.IsAllowed(&namespace))
+		namespace = getNamespace("team-b", nil)
+		require.False(t, filter.IsAllowed(&namespace))
+	})
+
+	t.Run("label selectors", func(t *testing.T) {
+		filter, err := NewFromLabelSelectors(nil, nil, nil, &metav1.LabelSelector{MatchLabels: map[string]string{"kube-green.dev/deny": "true"}})
+		require.NoError(t, err)
+		require.True(t, filter.HasLabelSelectors())
+		require.Nil(t, filter.AllowSelector)
+
+		namespace := getNamespace("team-a", nil)
+		require.True(t, filter.IsAllowed(&namespace))
+		namespace = getNamespace("team-a", map[string]string{"kube-green.dev/deny": "true"})
+		require.False(t, filter.IsAllowed(&namespace))
+	})
+
+	t.Run("invalid selector", func(t *testing.T) {
+		_, err := NewFromLabelSelectors(nil, nil, &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: "Invalid"}}}, nil)
+		require.ErrorContains(t, err, "invalid allow selector:")
+	})
+}
+
+func TestIsAllowed(t *testing.T) {
+	mustParse := func(selector string) labels.Selector {
+		s, err := labels.Parse(selector)
+		require.NoError(t, err)
+		return s
+	}
+
+	tests := []struct {
+		name      string
+		filter    Filter
+		namespace v1.Namespace
+		expected  bool
+	}{
+		{
+			name:      "empty filter allows everything",
+			filter:    Filter{},
+			namespace: getNamespace("kube-system", nil),
+			expected:  true,
+		},
+		{
+			name: "denied by name",
+			filter: Filter{
+				Deny: []string{"kube-*"},
+			},
+			namespace: getNamespace("kube-system", nil),
+			expected:  false,
+		},
+		{
+			name: "not denied by name",
+			filter: Filter{
+				Deny: []string{"kube-*"},
+			},
+			namespace: getNamespace("team-a", nil),
+			expected:  true,
+		},
+		{
+			name: "allowed by name",
+			filter: Filter{
+				Allow: []string{"team-*", "preview"},
+			},
+			namespace: getNamespace("preview", nil),
+			expected:  true,
+		},
+		{
+			name: "not in allowed names",
+			filter: Filter{
+				Allow: []string{"team-*"},
+			},
+			namespace: getNamespace("production", nil),
+			expected:  false,
+		},
+		{
+			name: "deny wins over allow",
+			filter: Filter{
+				Allow: []string{"team-*"},
+				Deny:  []string{"team-prod"},
+			},
+			namespace: getNamespace("team-prod", nil),
+			expected:  false,
+		},
+		{
+			name: "denied by label",
+			filter: Filter{
+				DenySelector: mustParse("env=production"),
+			},
+			namespace: getNamespace("team-a", map[string]string{"env": "production"}),
+			expected:  false,
+		},
+		{
+			name: "allowed by label",
+			filter: Filter{
+				AllowSelector: mustParse("kube-green.dev/enabled"),
+			},
+			namespace: getNamespace("team-a", map[string]string{"kube-green.dev/enabled": "true"}),
+			expected:  true,
+		},
+		{
+			name: "not matching allowed label",
+			filter: Filter{
+				AllowSelector: mustParse("kube-green.dev/enabled"),
+			},
+			namespace: getNamespace("team-a", nil),
+			expected:  false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			namespace := test.namespace
+			require.Equal(t, test.expected, test.filter.IsAllowed(&namespace))
+		})
+	}
+}
+
+func TestHasLabelSelectors(t *testing.T) {
+	require.False(t, Filter{Allow: []string{"*"}}.HasLabelSelectors())
+	require.False(t, Filter{DenySelector: labels.Everything()}.HasLabelSelectors())
+	require.True(t, Filter{DenySelector: labels.SelectorFromSet(labels.Set{"foo": "bar"})}.HasLabelSelectors())
+}
+
+func getNamespace(name string, namespaceLabels map[string]string) v1.Namespace {
+	return v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: namespaceLabels,
+		},
+	}
+}
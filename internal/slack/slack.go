@@ -0,0 +1,313 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	configv1alpha1 "github.com/kube-green/kube-green/api/config/v1alpha1"
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	sleepinfocontroller "github.com/kube-green/kube-green/controllers/sleepinfo"
+	"github.com/kube-green/kube-green/internal/namespacefilter"
+	"github.com/kube-green/kube-green/pkg/clock"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// Path is where the Slack commands are served, on the metrics endpoint.
+	Path = "/slack"
+
+	sleepOperation = "SLEEP"
+
+	maxBodySize = 1 << 20
+	// maxRequestAge is the max difference between the timestamp of a request
+	// and now, to prevent replay attacks.
+	maxRequestAge   = 5 * time.Minute
+	defaultSnooze   = 2 * time.Hour
+	maxSnooze       = 7 * 24 * time.Hour
+	maxWakeUp       = 7 * 24 * time.Hour
+	responseTimeout = 3 * time.Second
+
+	usage = "Usage: `/kube-green wake <namespace> [duration]` or `/kube-green snooze <namespace> [duration]`"
+)
+
+// Handler serves the Slack slash commands and the interactive payloads,
+// which wake up or snooze a namespace:
+//
+//	/kube-green wake team-a-dev
+//	/kube-green wake team-a-dev 3h
+//	/kube-green snooze team-a-dev 4h
+//
+// With a duration, the namespace woken up goes back to sleep after it,
+// instead of staying awake until its next scheduled sleep.
+//
+// The value of the buttons of the interactive messages is a command with the
+// same syntax, e.g. "wake team-a-dev". The requests must be signed with the
+// signing secret of the Slack app, and the users can act only on the
+// namespaces they are mapped to.
+type Handler struct {
+	// Client reads and annotates the SleepInfo.
+	Client client.Client
+	// SigningSecret is the signing secret of the Slack app.
+	SigningSecret string
+	// Users maps the Slack user ids to the glob patterns of the namespaces
+	// they can wake up and snooze.
+	Users map[string][]string
+	// HTTPClient sends the responses of the interactive payloads. If nil,
+	// http.DefaultClient is used.
+	HTTPClient *http.Client
+	Log        logr.Logger
+	// Clock gives the current time, to check the age of the requests and to
+	// request the wake ups. If nil, the real clock is used.
+	Clock clock.Clock
+}
+
+// NewHandler returns a Handler verifying the requests with the signing
+// secret in signingSecretFile, and allowing the users to act on the
+// namespaces they are mapped to.
+func NewHandler(c client.Client, log logr.Logger, signingSecretFile string, users []configv1alpha1.SlackUser) (*Handler, error) {
+	data, err := os.ReadFile(signingSecretFile)
+	if err != nil {
+		return nil, fmt.Errorf("fails to read signing secret file: %s", err)
+	}
+	signingSecret := strings.TrimSpace(string(data))
+	if signingSecret == "" {
+		return nil, fmt.Errorf("signing secret file %s is empty", signingSecretFile)
+	}
+	handler := &Handler{
+		Client:        c,
+		SigningSecret: signingSecret,
+		Users:         map[string][]string{},
+		HTTPClient:    &http.Client{Timeout: responseTimeout},
+		Log:           log,
+	}
+	for _, user := range users {
+		handler.Users[user.ID] = append(handler.Users[user.ID], user.Namespaces...)
+	}
+	return handler, nil
+}
+
+type interactivePayload struct {
+	User struct {
+		ID string `json:"id"`
+	} `json:"user"`
+	Actions []struct {
+		Value string `json:"value"`
+	} `json:"actions"`
+	ResponseURL string `json:"response_url"`
+}
+
+type response struct {
+	ResponseType    string `json:"response_type"`
+	Text            string `json:"text"`
+	ReplaceOriginal *bool  `json:"replace_original,omitempty"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "fails to read request", http.StatusBadRequest)
+		return
+	}
+	if !h.isSignatureValid(req.Header, body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	payload := form.Get("payload")
+	if payload == "" {
+		// slash command
+		writeResponse(w, h.runCommand(req.Context(), form.Get("user_id"), form.Get("text")))
+		return
+	}
+
+	interactive := interactivePayload{}
+	if err := json.Unmarshal([]byte(payload), &interactive); err != nil || len(interactive.Actions) == 0 {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	text := h.runCommand(req.Context(), interactive.User.ID, interactive.Actions[0].Value)
+	// the interactive payloads are answered with a message to the response url.
+	w.WriteHeader(http.StatusOK)
+	if interactive.ResponseURL != "" {
+		if err := h.sendResponse(req.Context(), interactive.ResponseURL, text); err != nil {
+			h.Log.Error(err, "fails to send the response to slack")
+		}
+	}
+}
+
+// isSignatureValid verifies the signature of the request, computed by Slack
+// with the signing secret of the app.
+func (h *Handler) isSignatureValid(header http.Header, body []byte) bool {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := clock.Now(h.Clock).Sub(time.Unix(seconds, 0))
+	if age > maxRequestAge || age < -maxRequestAge {
+		return false
+	}
+	return hmac.Equal([]byte(header.Get("X-Slack-Signature")), []byte(computeSignature(h.SigningSecret, timestamp, body)))
+}
+
+func computeSignature(signingSecret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// runCommand runs the command of the user, and returns the message to answer.
+func (h *Handler) runCommand(ctx context.Context, userID, text string) string {
+	fields := strings.Fields(text)
+	if len(fields) < 2 {
+		return usage
+	}
+	command, namespace := fields[0], fields[1]
+	if !h.isAllowed(userID, namespace) {
+		return fmt.Sprintf("You are not allowed to wake up or snooze namespace %s.", namespace)
+	}
+
+	switch {
+	case command == "wake" && len(fields) <= 3:
+		var duration time.Duration
+		if len(fields) == 3 {
+			var err error
+			if duration, err = time.ParseDuration(fields[2]); err != nil || duration <= 0 || duration > maxWakeUp {
+				return fmt.Sprintf("Invalid duration %s: must be positive and at most %s.", fields[2], maxWakeUp)
+			}
+		}
+		return h.wakeUp(ctx, userID, namespace, duration)
+	case command == "snooze" && len(fields) <= 3:
+		duration := defaultSnooze
+		if len(fields) == 3 {
+			var err error
+			if duration, err = time.ParseDuration(fields[2]); err != nil || duration <= 0 || duration > maxSnooze {
+				return fmt.Sprintf("Invalid duration %s: must be positive and at most %s.", fields[2], maxSnooze)
+			}
+		}
+		return h.snooze(ctx, userID, namespace, duration)
+	default:
+		return usage
+	}
+}
+
+func (h *Handler) isAllowed(userID, namespace string) bool {
+	patterns := h.Users[userID]
+	return len(patterns) > 0 && namespacefilter.Filter{Allow: patterns}.IsNameAllowed(namespace)
+}
+
+func (h *Handler) wakeUp(ctx context.Context, userID, namespace string, duration time.Duration) string {
+	sleepInfos := kubegreenv1alpha1.SleepInfoList{}
+	if err := h.Client.List(ctx, &sleepInfos, client.InNamespace(namespace)); err != nil {
+		h.Log.Error(err, "fails to list sleepinfos", "namespace", namespace)
+		return fmt.Sprintf("Fails to wake up namespace %s.", namespace)
+	}
+	now := clock.Now(h.Clock)
+	requested := 0
+	for _, sleepInfo := range sleepInfos.Items {
+		sleepInfo := sleepInfo
+		if sleepInfo.Status.OperationType != sleepOperation {
+			continue
+		}
+		if err := requestWakeUp(ctx, h.Client, &sleepInfo, now, duration); err != nil {
+			h.Log.Error(err, "fails to request the wake up", "sleepinfo", client.ObjectKeyFromObject(&sleepInfo))
+			return fmt.Sprintf("Fails to wake up namespace %s.", namespace)
+		}
+		requested++
+	}
+	if requested == 0 {
+		return fmt.Sprintf("Namespace %s is not sleeping.", namespace)
+	}
+	h.Log.Info("wake up requested from slack", "namespace", namespace, "user", userID, "duration", duration)
+	if duration > 0 {
+		return fmt.Sprintf("Wake up of namespace %s requested, until %s.", namespace, now.Add(duration).Format(time.RFC3339))
+	}
+	return fmt.Sprintf("Wake up of namespace %s requested.", namespace)
+}
+
+// requestWakeUp requests the wake up of the SleepInfo, for the duration if it
+// is not 0.
+func requestWakeUp(ctx context.Context, c client.Writer, sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time, duration time.Duration) error {
+	if duration > 0 {
+		return sleepinfocontroller.RequestWakeUpFor(ctx, c, sleepInfo, now, duration)
+	}
+	return sleepinfocontroller.RequestWakeUp(ctx, c, sleepInfo, now)
+}
+
+func (h *Handler) snooze(ctx context.Context, userID, namespace string, duration time.Duration) string {
+	sleepInfos := kubegreenv1alpha1.SleepInfoList{}
+	if err := h.Client.List(ctx, &sleepInfos, client.InNamespace(namespace)); err != nil {
+		h.Log.Error(err, "fails to list sleepinfos", "namespace", namespace)
+		return fmt.Sprintf("Fails to snooze namespace %s.", namespace)
+	}
+	if len(sleepInfos.Items) == 0 {
+		return fmt.Sprintf("Namespace %s has no SleepInfo.", namespace)
+	}
+	now := clock.Now(h.Clock)
+	until := now.Add(duration)
+	for _, sleepInfo := range sleepInfos.Items {
+		sleepInfo := sleepInfo
+		if err := sleepinfocontroller.Snooze(ctx, h.Client, &sleepInfo, now, until); err != nil {
+			h.Log.Error(err, "fails to snooze", "sleepinfo", client.ObjectKeyFromObject(&sleepInfo))
+			return fmt.Sprintf("Fails to snooze namespace %s.", namespace)
+		}
+	}
+	h.Log.Info("sleep snoozed from slack", "namespace", namespace, "user", userID, "duration", duration)
+	return fmt.Sprintf("Sleep of namespace %s snoozed until %s.", namespace, until.Format(time.RFC3339))
+}
+
+func (h *Handler) sendResponse(ctx context.Context, responseURL, text string) error {
+	replaceOriginal := false
+	body, err := json.Marshal(response{ResponseType: "ephemeral", Text: text, ReplaceOriginal: &replaceOriginal})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	httpClient := h.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func writeResponse(w http.ResponseWriter, text string) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response{ResponseType: "ephemeral", Text: text})
+}
@@ -0,0 +1,254 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	configv1alpha1 "github.com/kube-green/kube-green/api/config/v1alpha1"
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	sleepinfocontroller "github.com/kube-green/kube-green/controllers/sleepinfo"
+	"github.com/kube-green/kube-green/pkg/testutil"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHandler(t *testing.T) {
+	now := time.Date(2021, 3, 23, 21, 0, 0, 0, time.UTC)
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	newHandler := func() (*Handler, client.Client) {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&kubegreenv1alpha1.SleepInfo{
+				ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "team-a-dev"},
+				Status:     kubegreenv1alpha1.SleepInfoStatus{OperationType: "SLEEP"},
+			},
+			&kubegreenv1alpha1.SleepInfo{
+				ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "team-a-staging"},
+				Status:     kubegreenv1alpha1.SleepInfoStatus{OperationType: "WAKE_UP"},
+			},
+			&kubegreenv1alpha1.SleepInfo{
+				ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "team-b-dev"},
+				Status:     kubegreenv1alpha1.SleepInfoStatus{OperationType: "SLEEP"},
+			},
+		).Build()
+		return &Handler{
+			Client:        c,
+			SigningSecret: "my-secret",
+			Users:         map[string][]string{"U012AB3CD": {"team-a-*"}},
+			Log:           logr.Discard(),
+			Clock:         testutil.NewClock(now),
+		}, c
+	}
+	getAnnotations := func(t *testing.T, c client.Client, namespace string) map[string]string {
+		t.Helper()
+		sleepInfo := &kubegreenv1alpha1.SleepInfo{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: "sleepinfo"}, sleepInfo))
+		return sleepInfo.Annotations
+	}
+	doRequest := func(handler *Handler, form url.Values) *httptest.ResponseRecorder {
+		body := form.Encode()
+		timestamp := strconv.FormatInt(now.Unix(), 10)
+		req := httptest.NewRequest(http.MethodPost, Path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+		req.Header.Set("X-Slack-Signature", computeSignature("my-secret", timestamp, []byte(body)))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	runCommand := func(t *testing.T, handler *Handler, userID, text string) string {
+		t.Helper()
+		rec := doRequest(handler, url.Values{"user_id": {userID}, "text": {text}})
+		require.Equal(t, http.StatusOK, rec.Code)
+		resp := response{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Equal(t, "ephemeral", resp.ResponseType)
+		return resp.Text
+	}
+
+	t.Run("wake", func(t *testing.T) {
+		handler, c := newHandler()
+		text := runCommand(t, handler, "U012AB3CD", "wake team-a-dev")
+
+		require.Equal(t, "Wake up of namespace team-a-dev requested.", text)
+		require.Equal(t, map[string]string{
+			sleepinfocontroller.WakeUpRequestedAtAnnotation: "2021-03-23T21:00:00Z",
+		}, getAnnotations(t, c, "team-a-dev"))
+	})
+
+	t.Run("wake for a duration", func(t *testing.T) {
+		handler, c := newHandler()
+		text := runCommand(t, handler, "U012AB3CD", "wake team-a-dev 3h")
+
+		require.Equal(t, "Wake up of namespace team-a-dev requested, until 2021-03-24T00:00:00Z.", text)
+		require.Equal(t, map[string]string{
+			sleepinfocontroller.WakeUpRequestedAtAnnotation: "2021-03-23T21:00:00Z",
+			sleepinfocontroller.WakeUpUntilAnnotation:       "2021-03-24T00:00:00Z",
+		}, getAnnotations(t, c, "team-a-dev"))
+	})
+
+	t.Run("wake with invalid duration", func(t *testing.T) {
+		handler, c := newHandler()
+		text := runCommand(t, handler, "U012AB3CD", "wake team-a-dev now")
+
+		require.Equal(t, "Invalid duration now: must be positive and at most 168h0m0s.", text)
+		require.Empty(t, getAnnotations(t, c, "team-a-dev"))
+	})
+
+	t.Run("wake an awake namespace", func(t *testing.T) {
+		handler, c := newHandler()
+		text := runCommand(t, handler, "U012AB3CD", "wake team-a-staging")
+
+		require.Equal(t, "Namespace team-a-staging is not sleeping.", text)
+		require.Empty(t, getAnnotations(t, c, "team-a-staging"))
+	})
+
+	t.Run("snooze", func(t *testing.T) {
+		handler, c := newHandler()
+		text := runCommand(t, handler, "U012AB3CD", "snooze team-a-dev 4h")
+
+		require.Equal(t, "Sleep of namespace team-a-dev snoozed until 2021-03-24T01:00:00Z.", text)
+		require.Equal(t, map[string]string{
+			sleepinfocontroller.SnoozeUntilAnnotation:       "2021-03-24T01:00:00Z",
+			sleepinfocontroller.WakeUpRequestedAtAnnotation: "2021-03-23T21:00:00Z",
+		}, getAnnotations(t, c, "team-a-dev"))
+	})
+
+	t.Run("snooze with the default duration", func(t *testing.T) {
+		handler, c := newHandler()
+		text := runCommand(t, handler, "U012AB3CD", "snooze team-a-staging")
+
+		require.Equal(t, "Sleep of namespace team-a-staging snoozed until 2021-03-23T23:00:00Z.", text)
+		require.Equal(t, map[string]string{
+			sleepinfocontroller.SnoozeUntilAnnotation: "2021-03-23T23:00:00Z",
+		}, getAnnotations(t, c, "team-a-staging"))
+	})
+
+	t.Run("snooze with invalid duration", func(t *testing.T) {
+		handler, _ := newHandler()
+		text := runCommand(t, handler, "U012AB3CD", "snooze team-a-dev 10d")
+
+		require.Equal(t, "Invalid duration 10d: must be positive and at most 168h0m0s.", text)
+	})
+
+	t.Run("namespace without sleepinfo", func(t *testing.T) {
+		handler, _ := newHandler()
+		text := runCommand(t, handler, "U012AB3CD", "snooze team-a-prod")
+
+		require.Equal(t, "Namespace team-a-prod has no SleepInfo.", text)
+	})
+
+	t.Run("namespace not allowed", func(t *testing.T) {
+		handler, c := newHandler()
+		for _, userID := range []string{"U012AB3CD", "U999"} {
+			text := runCommand(t, handler, userID, "wake team-b-dev")
+			require.Equal(t, "You are not allowed to wake up or snooze namespace team-b-dev.", text)
+		}
+		require.Empty(t, getAnnotations(t, c, "team-b-dev"))
+	})
+
+	t.Run("invalid command", func(t *testing.T) {
+		handler, _ := newHandler()
+		for _, command := range []string{"", "wake", "sleep team-a-dev", "wake team-a-dev 3h now"} {
+			require.Equal(t, usage, runCommand(t, handler, "U012AB3CD", command), command)
+		}
+	})
+
+	t.Run("interactive payload", func(t *testing.T) {
+		var responseBody []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var err error
+			responseBody, err = io.ReadAll(req.Body)
+			require.NoError(t, err)
+		}))
+		defer server.Close()
+
+		handler, c := newHandler()
+		payload := `{"type":"block_actions","user":{"id":"U012AB3CD"},"actions":[{"action_id":"wake","value":"wake team-a-dev"}],"response_url":"` + server.URL + `"}`
+		rec := doRequest(handler, url.Values{"payload": {payload}})
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{"response_type":"ephemeral","text":"Wake up of namespace team-a-dev requested.","replace_original":false}`, string(responseBody))
+		require.Equal(t, map[string]string{
+			sleepinfocontroller.WakeUpRequestedAtAnnotation: "2021-03-23T21:00:00Z",
+		}, getAnnotations(t, c, "team-a-dev"))
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		handler, c := newHandler()
+		body := url.Values{"user_id": {"U012AB3CD"}, "text": {"wake team-a-dev"}}.Encode()
+
+		tests := []struct {
+			name      string
+			timestamp string
+			signature string
+		}{
+			{name: "without signature", timestamp: strconv.FormatInt(now.Unix(), 10)},
+			{name: "wrong secret", timestamp: strconv.FormatInt(now.Unix(), 10), signature: computeSignature("other-secret", strconv.FormatInt(now.Unix(), 10), []byte(body))},
+			{name: "old request", timestamp: strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10), signature: computeSignature("my-secret", strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10), []byte(body))},
+			{name: "invalid timestamp", timestamp: "now", signature: computeSignature("my-secret", "now", []byte(body))},
+		}
+		for _, test := range tests {
+			req := httptest.NewRequest(http.MethodPost, Path, strings.NewReader(body))
+			req.Header.Set("X-Slack-Request-Timestamp", test.timestamp)
+			req.Header.Set("X-Slack-Signature", test.signature)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusUnauthorized, rec.Code, test.name)
+		}
+		require.Empty(t, getAnnotations(t, c, "team-a-dev"))
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		handler, _ := newHandler()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+func TestNewHandler(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("read the signing secret and map the users", func(t *testing.T) {
+		secretFile := filepath.Join(dir, "secret")
+		require.NoError(t, os.WriteFile(secretFile, []byte("my-secret\n"), 0o600))
+
+		handler, err := NewHandler(nil, logr.Discard(), secretFile, []configv1alpha1.SlackUser{
+			{ID: "U012AB3CD", Namespaces: []string{"team-a-*"}},
+			{ID: "U012AB3CD", Namespaces: []string{"shared"}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "my-secret", handler.SigningSecret)
+		require.Equal(t, map[string][]string{"U012AB3CD": {"team-a-*", "shared"}}, handler.Users)
+	})
+
+	t.Run("empty signing secret", func(t *testing.T) {
+		secretFile := filepath.Join(dir, "empty")
+		require.NoError(t, os.WriteFile(secretFile, []byte("\n"), 0o600))
+
+		_, err := NewHandler(nil, logr.Discard(), secretFile, nil)
+		require.EqualError(t, err, "signing secret file "+secretFile+" is empty")
+	})
+
+	t.Run("missing signing secret file", func(t *testing.T) {
+		_, err := NewHandler(nil, logr.Discard(), filepath.Join(dir, "missing"), nil)
+		require.ErrorContains(t, err, "fails to read signing secret file:")
+	})
+}
@@ -0,0 +1,187 @@
+package statusapi
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	configv1alpha1 "github.com/kube-green/kube-green/api/config/v1alpha1"
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	sleepinfocontroller "github.com/kube-green/kube-green/controllers/sleepinfo"
+	"github.com/kube-green/kube-green/pkg/clock"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// Path is where the status API is served, on the metrics endpoint.
+	Path = "/status"
+
+	sleepOperation = "SLEEP"
+
+	// nextOperationsWindow is how far ahead the next operations are computed.
+	// The schedules repeat every week, so a week is enough.
+	nextOperationsWindow = 7 * 24 * time.Hour
+	maxNextOperations    = 2
+)
+
+// Status is the response of the status API.
+type Status struct {
+	SleepInfos []SleepInfoStatus `json:"sleepInfos"`
+}
+
+// SleepInfoStatus is the state of a SleepInfo.
+type SleepInfoStatus struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Asleep is true if the namespace has been put to sleep by the SleepInfo.
+	Asleep bool `json:"asleep"`
+	// LastOperation is the last operation performed, SLEEP or WAKE_UP.
+	LastOperation    string     `json:"lastOperation,omitempty"`
+	LastScheduleTime *time.Time `json:"lastScheduleTime,omitempty"`
+	// LastError is the error of the last operation, if it failed.
+	LastError      string      `json:"lastError,omitempty"`
+	NextOperations []Operation `json:"nextOperations"`
+	// State is the original state of the resources stored by the last sleep,
+	// restored by the next wake up. It is set only if requested with the
+	// state query parameter.
+	State *sleepinfocontroller.SleepState `json:"state,omitempty"`
+}
+
+// Operation is a scheduled operation of a SleepInfo.
+type Operation struct {
+	// Type is SLEEP or WAKE_UP.
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+}
+
+// Handler serves the state of the SleepInfo as JSON, e.g. to show it in a
+// developer portal without access to the cluster. The requests must be
+// authenticated with the bearer token, and can be filtered by namespace with
+// the namespace query parameter. With the state=true query parameter, the
+// original state of the resources stored by the last sleep is returned too.
+type Handler struct {
+	// Client reads the SleepInfo.
+	Client client.Reader
+	// Token is the bearer token required to authenticate the requests.
+	Token string
+	Log   logr.Logger
+	// Clock gives the current time, to compute the next operations. If
+	// nil, the real clock is used.
+	Clock clock.Clock
+	// StateSecret is the configuration of the Secret where the state of each
+	// SleepInfo is stored. If nil, the Secret has the default name.
+	StateSecret *configv1alpha1.StateSecret
+	// StateCodec decodes the state stored in the Secret.
+	StateCodec sleepinfocontroller.StateCodec
+}
+
+// NewHandler returns a Handler authenticating the requests with the token
+// in tokenFile.
+func NewHandler(c client.Reader, log logr.Logger, tokenFile string) (*Handler, error) {
+	data, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("fails to read token file: %s", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return nil, fmt.Errorf("token file %s is empty", tokenFile)
+	}
+	return &Handler{
+		Client: c,
+		Token:  token,
+		Log:    log,
+	}, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !h.isAuthenticated(req) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	sleepInfos := kubegreenv1alpha1.SleepInfoList{}
+	if err := h.Client.List(req.Context(), &sleepInfos, client.InNamespace(req.URL.Query().Get("namespace"))); err != nil {
+		h.Log.Error(err, "fails to list sleepinfos")
+		writeError(w, http.StatusInternalServerError, "fails to list sleepinfos")
+		return
+	}
+
+	status := Status{SleepInfos: []SleepInfoStatus{}}
+	now := clock.Now(h.Clock)
+	withState := req.URL.Query().Get("state") == "true"
+	for _, sleepInfo := range sleepInfos.Items {
+		sleepInfo := sleepInfo
+		sleepInfoStatus, err := GetSleepInfoStatus(&sleepInfo, now)
+		if err != nil {
+			h.Log.Error(err, "fails to compute the next operations", "sleepinfo", client.ObjectKeyFromObject(&sleepInfo))
+		}
+		if withState {
+			state, err := sleepinfocontroller.GetSleepState(req.Context(), h.Client, &sleepInfo, h.StateSecret, h.StateCodec, now)
+			if err != nil {
+				h.Log.Error(err, "fails to get the sleep state", "sleepinfo", client.ObjectKeyFromObject(&sleepInfo))
+			} else {
+				sleepInfoStatus.State = &state
+			}
+		}
+		status.SleepInfos = append(status.SleepInfos, sleepInfoStatus)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		h.Log.Error(err, "fails to write status")
+	}
+}
+
+func (h *Handler) isAuthenticated(req *http.Request) bool {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(h.Token)) == 1
+}
+
+// GetSleepInfoStatus returns the state of the SleepInfo at now. If the next
+// operations cannot be computed, the state is returned without them together
+// with the error.
+func GetSleepInfoStatus(sleepInfo *kubegreenv1alpha1.SleepInfo, now time.Time) (SleepInfoStatus, error) {
+	status := SleepInfoStatus{
+		Namespace:      sleepInfo.Namespace,
+		Name:           sleepInfo.Name,
+		Asleep:         sleepInfo.Status.OperationType == sleepOperation,
+		LastOperation:  sleepInfo.Status.OperationType,
+		NextOperations: []Operation{},
+	}
+	if !sleepInfo.Status.LastScheduleTime.IsZero() {
+		lastScheduleTime := sleepInfo.Status.LastScheduleTime.Time
+		status.LastScheduleTime = &lastScheduleTime
+	}
+	if history := sleepInfo.Status.OperationsHistory; len(history) > 0 {
+		status.LastError = history[len(history)-1].Error
+	}
+
+	operations, err := sleepinfocontroller.PreviewOperations(sleepInfo, now, now.Add(nextOperationsWindow))
+	if err != nil {
+		return status, err
+	}
+	for i, operation := range operations {
+		if i == maxNextOperations {
+			break
+		}
+		status.NextOperations = append(status.NextOperations, Operation{Type: operation.Type, Time: operation.Time})
+	}
+	return status, nil
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
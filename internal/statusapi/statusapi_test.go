@@ -0,0 +1,179 @@
+package statusapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/pkg/testutil"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHandler(t *testing.T) {
+	// Tuesday
+	now := time.Date(2021, 3, 23, 12, 0, 0, 0, time.UTC)
+	lastSleep := time.Date(2021, 3, 22, 20, 0, 0, 0, time.UTC)
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+
+	sleeping := &kubegreenv1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "backend"},
+		Spec: kubegreenv1alpha1.SleepInfoSpec{
+			Weekdays:   "1-5",
+			SleepTime:  "10:00",
+			WakeUpTime: "14:00",
+		},
+		Status: kubegreenv1alpha1.SleepInfoStatus{
+			LastScheduleTime: metav1.NewTime(lastSleep),
+			OperationType:    "SLEEP",
+			OperationsHistory: []kubegreenv1alpha1.OperationHistory{
+				{Type: "SLEEP", Time: metav1.NewTime(lastSleep), Error: "error during patch"},
+			},
+		},
+	}
+	awake := &kubegreenv1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo", Namespace: "frontend"},
+		Spec: kubegreenv1alpha1.SleepInfoSpec{
+			Weekdays:  "*",
+			SleepTime: "20:00",
+		},
+	}
+	sleepingSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo-sleepinfo", Namespace: "backend"},
+		Data: map[string][]byte{
+			"scheduled-at":        []byte(lastSleep.Format(time.RFC3339)),
+			"operation-type":      []byte("SLEEP"),
+			"deployment-replicas": []byte(`[{"name":"api","replicas":3}]`),
+		},
+	}
+	handler := &Handler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(sleeping, awake, sleepingSecret).Build(),
+		Token:  "my-token",
+		Log:    logr.Discard(),
+		Clock:  testutil.NewClock(now),
+	}
+
+	doRequest := func(method, target, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("status of the sleepinfos", func(t *testing.T) {
+		rec := doRequest(http.MethodGet, Path, "my-token")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		status := Status{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+		require.Equal(t, Status{
+			SleepInfos: []SleepInfoStatus{
+				{
+					Namespace:        "backend",
+					Name:             "sleepinfo",
+					Asleep:           true,
+					LastOperation:    "SLEEP",
+					LastScheduleTime: &lastSleep,
+					LastError:        "error during patch",
+					NextOperations: []Operation{
+						{Type: "WAKE_UP", Time: time.Date(2021, 3, 23, 14, 0, 0, 0, time.UTC)},
+						{Type: "SLEEP", Time: time.Date(2021, 3, 24, 10, 0, 0, 0, time.UTC)},
+					},
+				},
+				{
+					Namespace: "frontend",
+					Name:      "sleepinfo",
+					NextOperations: []Operation{
+						{Type: "SLEEP", Time: time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)},
+						{Type: "SLEEP", Time: time.Date(2021, 3, 24, 20, 0, 0, 0, time.UTC)},
+					},
+				},
+			},
+		}, status)
+	})
+
+	t.Run("filter by namespace", func(t *testing.T) {
+		rec := doRequest(http.MethodGet, Path+"?namespace=frontend", "my-token")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		status := Status{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+		require.Len(t, status.SleepInfos, 1)
+		require.Equal(t, "frontend", status.SleepInfos[0].Namespace)
+	})
+
+	t.Run("with the sleep state", func(t *testing.T) {
+		rec := doRequest(http.MethodGet, Path+"?namespace=backend&state=true", "my-token")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		status := Status{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+		require.Len(t, status.SleepInfos, 1)
+		state := status.SleepInfos[0].State
+		require.NotNil(t, state)
+		require.Equal(t, "SLEEP", state.LastOperation)
+		require.True(t, lastSleep.Equal(*state.CapturedAt))
+		require.True(t, time.Date(2021, 3, 23, 14, 0, 0, 0, time.UTC).Equal(*state.NextWakeUp))
+		require.Len(t, state.Resources, 1)
+		require.Equal(t, "Deployment", state.Resources[0].Kind)
+		require.Equal(t, "api", state.Resources[0].Name)
+		require.Equal(t, map[string]interface{}{"replicas": float64(3)}, state.Resources[0].Original)
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		for _, token := range []string{"", "other-token"} {
+			rec := doRequest(http.MethodGet, Path, token)
+			require.Equal(t, http.StatusUnauthorized, rec.Code)
+			require.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+			require.JSONEq(t, `{"error":"unauthorized"}`, rec.Body.String())
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		rec := doRequest(http.MethodPost, Path, "my-token")
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+func TestNewHandler(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("read the token", func(t *testing.T) {
+		tokenFile := filepath.Join(dir, "token")
+		require.NoError(t, os.WriteFile(tokenFile, []byte("my-token\n"), 0o600))
+
+		handler, err := NewHandler(nil, logr.Discard(), tokenFile)
+		require.NoError(t, err)
+		require.Equal(t, "my-token", handler.Token)
+	})
+
+	t.Run("empty token", func(t *testing.T) {
+		tokenFile := filepath.Join(dir, "empty")
+		require.NoError(t, os.WriteFile(tokenFile, []byte("\n"), 0o600))
+
+		_, err := NewHandler(nil, logr.Discard(), tokenFile)
+		require.EqualError(t, err, "token file "+tokenFile+" is empty")
+	})
+
+	t.Run("missing token file", func(t *testing.T) {
+		_, err := NewHandler(nil, logr.Discard(), filepath.Join(dir, "missing"))
+		require.ErrorContains(t, err, "fails to read token file:")
+	})
+}
@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/kube-green/kube-green"
+	serviceName         = "kube-green"
+)
+
+// Options configures the export of the traces.
+type Options struct {
+	// Endpoint is the address of the OTLP gRPC collector. If empty, the
+	// traces are not exported.
+	Endpoint string
+	// Insecure disables the TLS on the connection to the collector.
+	Insecure bool
+	// SampleRatio is the ratio of the sampled traces, between 0 and 1.
+	SampleRatio float64
+}
+
+// Setup sets the global tracer provider, exporting the spans via OTLP.
+// It returns the function to flush the pending spans and stop the provider.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	if opts.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	clientOpts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(opts.Endpoint),
+	}
+	if opts.Insecure {
+		clientOpts = append(clientOpts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptrace.New(ctx, otlptracegrpc.NewClient(clientOpts...))
+	if err != nil {
+		return nil, fmt.Errorf("fails to create otlp exporter: %s", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("fails to create tracing resource: %s", err)
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(tracerProvider)
+
+	return tracerProvider.Shutdown, nil
+}
+
+// Tracer returns the kube-green tracer of the global tracer provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// EndSpan ends the span, recording the error if not nil.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetup(t *testing.T) {
+	t.Run("tracing disabled without endpoint", func(t *testing.T) {
+		shutdown, err := Setup(context.Background(), Options{})
+		require.NoError(t, err)
+		require.NoError(t, shutdown(context.Background()))
+	})
+}
+
+func TestEndSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	t.Run("without error", func(t *testing.T) {
+		_, span := tracer.Start(context.Background(), "success")
+		EndSpan(span, nil)
+
+		spans := recorder.Ended()
+		require.Equal(t, "success", spans[len(spans)-1].Name())
+		require.Equal(t, codes.Unset, spans[len(spans)-1].Status().Code)
+	})
+
+	t.Run("with error", func(t *testing.T) {
+		_, span := tracer.Start(context.Background(), "failure")
+		EndSpan(span, fmt.Errorf("some error"))
+
+		spans := recorder.Ended()
+		endedSpan := spans[len(spans)-1]
+		require.Equal(t, "failure", endedSpan.Name())
+		require.Equal(t, codes.Error, endedSpan.Status().Code)
+		require.Equal(t, "some error", endedSpan.Status().Description)
+		require.Len(t, endedSpan.Events(), 1)
+	})
+}
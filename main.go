@@ -5,23 +5,61 @@ Copyright 2021.
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
+	configv1alpha1 "github.com/kube-green/kube-green/api/config/v1alpha1"
 	kubegreencomv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/controllers/alertrules"
+	"github.com/kube-green/kube-green/controllers/kubegreenreport"
+	"github.com/kube-green/kube-green/controllers/nodehints"
+	"github.com/kube-green/kube-green/controllers/prewarm"
 	sleepinfocontroller "github.com/kube-green/kube-green/controllers/sleepinfo"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/alertmanager"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/audit"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/customresources"
 	"github.com/kube-green/kube-green/controllers/sleepinfo/metrics"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/operationgate"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/promquery"
+	"github.com/kube-green/kube-green/controllers/sleepreport"
+	"github.com/kube-green/kube-green/controllers/wakeuprequest"
+	"github.com/kube-green/kube-green/internal/cloudevents"
+	"github.com/kube-green/kube-green/internal/dashboard"
+	"github.com/kube-green/kube-green/internal/featuregate"
+	"github.com/kube-green/kube-green/internal/health"
+	"github.com/kube-green/kube-green/internal/multicluster"
+	"github.com/kube-green/kube-green/internal/namespacefilter"
+	"github.com/kube-green/kube-green/internal/slack"
+	"github.com/kube-green/kube-green/internal/statusapi"
+	"github.com/kube-green/kube-green/internal/tracing"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	ctrlMetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -30,77 +68,951 @@ var (
 	setupLog = ctrl.Log.WithName("setup")
 )
 
+// gracefulShutdownMargin is how long the manager waits, after the drain
+// timeout, for the reconciles whose operation has been interrupted to return.
+const gracefulShutdownMargin = 5 * time.Second
+
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(kubegreencomv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(configv1alpha1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "preview" {
+		if err := runPreview(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "inspect" {
+		if err := runInspect(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var configFile string
 	var webhookPort int
 	var metricsAddr string
 	var enableLeaderElection bool
+	var leaderElectionID string
+	var leaderElectionNamespace string
+	var leaderElectionReleaseOnCancel bool
+	var leaseDuration time.Duration
+	var renewDeadline time.Duration
+	var retryPeriod time.Duration
 	var probeAddr string
+	var healthMaxReconcileFailures int
+	var healthMaxReconcileDuration time.Duration
 	var sleepDelta int64
+	var maxScheduleJitter time.Duration
+	var executeMissedOperations bool
+	var wakeAllOnStart bool
+	var teardown bool
+	var maxConcurrentReconciles int
+	var resourceTimeout time.Duration
+	var kubeAPIQPS float64
+	var kubeAPIBurst int
+	var operationChunkSize int
+	var operationChunkPause time.Duration
+	var throttleBackoffBaseDelay time.Duration
+	var throttleBackoffMaxDelay time.Duration
+	var suspendExternalSecretsRefresh bool
+	var stateEncryptionKeyFile string
+	var rateLimiterOpts sleepinfocontroller.RateLimiterOptions
+	var syncPeriod time.Duration
+	var namespacesAllow string
+	var namespacesDeny string
+	var namespacesAllowSelector string
+	var namespacesDenySelector string
+	var watchNamespaces string
+	var auditSinkType string
+	var auditConfigMapNamespace string
+	var auditConfigMapName string
+	var auditConfigMapSize int
+	var auditHTTPURL string
+	var alertmanagerURL string
+	var alertmanagerNamespaceLabel string
+	var prometheusURL string
+	var statusAPITokenFile string
+	var dashboardAddr string
+	var slackSigningSecretFile string
+	var cloudEventsTokenFile string
+	var cloudEventsTypes string
+	var sleepReportInterval time.Duration
+	var kubeGreenReportInterval time.Duration
+	var alertRulesInterval time.Duration
+	var alertRulesThreshold time.Duration
+	var alertRulesLabels string
+	var sleepCompletionCheckDelay time.Duration
+	var drainTimeout time.Duration
+	var stateGCInterval time.Duration
+	var discoveryInterval time.Duration
+	var nodeHintsOpts nodeHintsOptions
+	var prewarmOpts prewarmOptions
+	var tracingOpts tracing.Options
+	var featureGatesValue string
+	flag.StringVar(&configFile, "config", "",
+		"The controller will load its configuration from this file. "+
+			"If set, the manager options (metrics, probes, webhook and leader election) are read only from the file, "+
+			"and the kube-green options set in the file override the corresponding flags.")
 	flag.IntVar(&webhookPort, "webhook-server-port", 9443, "The port where the server will listen.")
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.IntVar(&healthMaxReconcileFailures, "health-max-reconcile-failures", 10, "The number of consecutive failed reconciles after which the controller is not ready. If 0, the failures are ignored.")
+	flag.DurationVar(&healthMaxReconcileDuration, "health-max-reconcile-duration", 10*time.Minute, "The duration after which a running reconcile is stuck and the controller is not healthy. If 0, the running reconciles are ignored.")
 	flag.Int64Var(&sleepDelta, "sleep-delta", 60, "The delta in seconds between the cronjob schedule and when the job is being processed before skipping it")
+	flag.DurationVar(&maxScheduleJitter, "max-schedule-jitter", 0, "The maximum delay of the operations of each namespace after their schedule. The delay is stable per namespace, so that the SleepInfo with the same schedule in many namespaces are spread over this window instead of being executed at the same instant. It must be shorter than the time between the sleep and the wake up. If 0, the operations are executed at their schedule.")
+	flag.BoolVar(&executeMissedOperations, "execute-missed-operations", false, "Execute the operations missed, e.g. because the controller was not running, as soon as possible while their window is still open, i.e. before the following operation. Otherwise, the missed operations are skipped until their next schedule.")
+	flag.BoolVar(&wakeAllOnStart, "wake-all-on-start", false, "Wake up all the sleeping namespaces when the controller starts, and skip their sleeps while the controller runs with this flag, e.g. before a risky upgrade of the controller or to remove kube-green from the cluster.")
+	flag.BoolVar(&teardown, "teardown", false, "Prepare the uninstall of kube-green: wake up all the sleeping namespaces, also the ones of the SleepInfo without wake up, then delete the state secrets and mark the SleepInfo as inert with the kube-green.dev/inert annotation, so that they are ignored by the controller.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 20, "The maximum number of SleepInfo reconciled concurrently.")
+	flag.DurationVar(&resourceTimeout, "resource-timeout", 30*time.Second, "The timeout of each request to the API server made to sleep and wake up the resources. The resources whose patch still times out after the retries are skipped and reported in the SleepInfo status. If 0, the requests have no timeout.")
+	flag.DurationVar(&drainTimeout, "drain-timeout", 30*time.Second, "How long the operations in flight when the controller is stopped, e.g. on SIGTERM, can take to complete. The new operations are not started once the controller is stopping. The terminationGracePeriodSeconds of the pod should be longer. If 0, the operations in flight are interrupted.")
+	flag.Float64Var(&kubeAPIQPS, "kube-api-qps", 20, "The number of requests per second of the controller to the API server.")
+	flag.IntVar(&kubeAPIBurst, "kube-api-burst", 30, "The burst of requests of the controller to the API server.")
+	flag.IntVar(&operationChunkSize, "operation-chunk-size", 0, "The number of patches of an operation after which the controller pauses for --operation-chunk-pause, so that very large operations do not overload the API server. If 0, the operations are not chunked.")
+	flag.DurationVar(&operationChunkPause, "operation-chunk-pause", time.Second, "The pause between the chunks of patches of an operation.")
+	flag.DurationVar(&throttleBackoffBaseDelay, "throttle-backoff-base-delay", 10*time.Second, "The delay before an operation throttled by the API server is retried, doubled at each throttled operation of the namespace and at least the Retry-After of the API server. If 0, the throttled operations are retried with the rate limiter.")
+	flag.DurationVar(&throttleBackoffMaxDelay, "throttle-backoff-max-delay", 10*time.Minute, "The maximum delay before an operation throttled by the API server is retried.")
+	flag.StringVar(&stateEncryptionKeyFile, "state-encryption-key-file", "", "The file with the key used to encrypt the original state of the resources stored in the Secret of each SleepInfo. If empty, the state is not encrypted.")
+	flag.BoolVar(&suspendExternalSecretsRefresh, "suspend-external-secrets-refresh", false, "Disable the refresh of the ExternalSecrets, and postpone the renewal of the cert-manager Certificates, of the sleeping namespaces whose SleepInfo suspends the custom resources, restoring them on wake up.")
+	flag.DurationVar(&rateLimiterOpts.BaseDelay, "rate-limiter-base-delay", 5*time.Millisecond, "The base delay of the per-item exponential backoff applied to failing reconciles.")
+	flag.DurationVar(&rateLimiterOpts.MaxDelay, "rate-limiter-max-delay", 1000*time.Second, "The maximum delay of the per-item exponential backoff applied to failing reconciles.")
+	flag.Float64Var(&rateLimiterOpts.QPS, "rate-limiter-qps", 10, "The overall number of reconcile requests per second allowed in the work queue.")
+	flag.IntVar(&rateLimiterOpts.Burst, "rate-limiter-burst", 100, "The overall burst of reconcile requests allowed in the work queue.")
+	flag.Float64Var(&rateLimiterOpts.NamespaceQPS, "rate-limiter-namespace-qps", 0, "The number of reconcile requests per second allowed in the work queue for the SleepInfo of each namespace, so that a namespace with many SleepInfo does not use all the overall rate. If 0, the namespaces are not limited.")
+	flag.IntVar(&rateLimiterOpts.NamespaceBurst, "rate-limiter-namespace-burst", 10, "The burst of reconcile requests allowed in the work queue for the SleepInfo of each namespace.")
+	flag.DurationVar(&syncPeriod, "sync-period", 10*time.Hour, "The minimum frequency at which the cache of the watched resources is resynced. All the SleepInfo are reconciled at each resync.")
+	flag.StringVar(&namespacesAllow, "namespaces-allow", "", "Comma separated list of glob patterns of the namespaces where the controller acts. If empty, all the namespaces are allowed.")
+	flag.StringVar(&namespacesDeny, "namespaces-deny", "", "Comma separated list of glob patterns of the namespaces ignored by the controller. It takes precedence over --namespaces-allow.")
+	flag.StringVar(&namespacesAllowSelector, "namespaces-allow-selector", "", "Label selector of the namespaces where the controller acts.")
+	flag.StringVar(&namespacesDenySelector, "namespaces-deny-selector", "", "Label selector of the namespaces ignored by the controller. It takes precedence over --namespaces-allow-selector.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"Comma separated list of namespaces watched by the controller. If empty, the controller watches the whole cluster. "+
+			"Setting it allows to run the controller with namespace scoped permissions, without the namespaces label selectors.")
+	flag.StringVar(&auditSinkType, "audit-sink", "", "The sink of the audit events of sleep and wake up operations. One of: stdout, configmap, http. If empty, the audit is disabled.")
+	flag.StringVar(&auditConfigMapNamespace, "audit-configmap-namespace", "", "The namespace of the ConfigMap used by the configmap audit sink.")
+	flag.StringVar(&auditConfigMapName, "audit-configmap-name", "kube-green-audit", "The name of the ConfigMap used by the configmap audit sink.")
+	flag.IntVar(&auditConfigMapSize, "audit-configmap-size", 100, "The number of the last audit events kept by the configmap audit sink.")
+	flag.StringVar(&auditHTTPURL, "audit-http-url", "", "The endpoint where the http audit sink sends the audit events.")
+	flag.StringVar(&alertmanagerURL, "alertmanager-url", "", "The url of the Alertmanager where the alerts of the sleeping namespaces are silenced. If empty, the alerts are not silenced.")
+	flag.StringVar(&alertmanagerNamespaceLabel, "alertmanager-namespace-label", "namespace", "The label of the alerts matched by the silences of the sleeping namespaces.")
+	flag.StringVar(&prometheusURL, "prometheus-url", "", "The url of the Prometheus where the sleep conditions of the SleepInfo are evaluated.")
+	flag.StringVar(&statusAPITokenFile, "status-api-token-file", "", "The file with the bearer token of the status API, served at /status on the metrics endpoint. If empty, the status API is disabled.")
+	flag.StringVar(&dashboardAddr, "dashboard-bind-address", "", "The address the web dashboard binds to. The dashboard is not authenticated, so bind it to localhost and reach it with kubectl port-forward. If empty, the dashboard is disabled.")
+	flag.StringVar(&slackSigningSecretFile, "slack-signing-secret-file", "", "The file with the signing secret of the Slack app whose commands wake up and snooze the namespaces, served at /slack on the metrics endpoint. The users allowed are set in the slackUsers of the config file. If empty, the Slack commands are disabled.")
+	flag.StringVar(&cloudEventsTokenFile, "cloudevents-token-file", "", "The file with the bearer token of the CloudEvents endpoint, served at /cloudevents on the metrics endpoint, whose events wake up their namespace. If empty, the CloudEvents are disabled.")
+	flag.StringVar(&cloudEventsTypes, "cloudevents-types", "", "Comma separated list of the types of the CloudEvents which wake up their namespace. If empty, all the events wake up their namespace.")
+	flag.DurationVar(&sleepReportInterval, "sleep-report-interval", 0, "How often the SleepReport, with the capacity saved by the sleep, are computed. If 0, the sleep reports are disabled.")
+	flag.DurationVar(&kubeGreenReportInterval, "kube-green-report-interval", 0, "How often the KubeGreenReport, with the namespaces asleep and the operations run in the cluster, are computed. If 0, the kube-green reports are disabled.")
+	flag.DurationVar(&alertRulesInterval, "alert-rules-interval", 0, "How often the PrometheusRule with the alerts of the namespaces which fail to wake up on schedule are generated, from the schedules of the SleepInfo. It requires the Prometheus Operator. If 0, the alerting rules are disabled.")
+	flag.DurationVar(&alertRulesThreshold, "alert-rules-threshold", 15*time.Minute, "How long after the scheduled wake up a namespace still asleep is alerted.")
+	flag.StringVar(&alertRulesLabels, "alert-rules-labels", "", "Comma separated list of key=value labels set on the generated PrometheusRule, so that they are selected by the ruleSelector of the Prometheus.")
+	flag.DurationVar(&sleepCompletionCheckDelay, "sleep-completion-check-delay", 2*time.Minute, "How long after the sleep the pods of the resources put to sleep are checked, to report the pods still running in the SleepIncomplete condition and in the residual_pods metric. If 0, the completion of the sleeps is not checked.")
+	flag.DurationVar(&discoveryInterval, "discovery-interval", 10*time.Minute, "How often the custom resources handled by the controller are discovered, so that the ones not installed in the cluster, or that the controller is not allowed to list and patch, are skipped until their CRD is installed or their RBAC is granted. If 0, all the custom resources are handled.")
+	flag.DurationVar(&stateGCInterval, "state-gc-interval", time.Hour, "How often the state Secrets whose SleepInfo does not exist anymore are deleted, after waking up the resources still sleeping in their state. If 0, the orphaned state Secrets are not deleted.")
+	flag.DurationVar(&nodeHintsOpts.Interval, "node-hints-interval", 0, "How often the nodes which became empty while the namespaces sleep are marked, to help the cluster-autoscaler to remove them. If 0, the node hints are disabled.")
+	flag.StringVar(&nodeHintsOpts.NodeSelector, "node-hints-node-selector", "", "Label selector of the nodes which can be marked as empty, e.g. the nodes of the autoscaled node pools. If empty, all the nodes can be marked.")
+	flag.BoolVar(&nodeHintsOpts.Cordon, "node-hints-cordon", false, "Cordon the empty nodes, besides annotating them.")
+	flag.DurationVar(&nodeHintsOpts.WakeUpLeadTime, "node-hints-wake-up-lead-time", 15*time.Minute, "How long before the next wake up the node hints are removed.")
+	flag.StringVar(&nodeHintsOpts.HookURL, "node-hints-hook-url", "", "The endpoint notified with a POST request when the nodes are marked as empty and when the hints are removed. If empty, the hook is disabled.")
+	flag.DurationVar(&prewarmOpts.LeadTime, "prewarm-lead-time", 0, "How long before the wake up of a namespace the nodes are warmed up, with placeholder pods requesting the resources of the pods running before the sleep. If 0, the warm up is disabled.")
+	flag.StringVar(&prewarmOpts.PriorityClassName, "prewarm-priority-class", "", "The priority class, with a negative priority, of the placeholder pods created to warm up the nodes. If empty, the placeholder pods are not created.")
+	flag.StringVar(&prewarmOpts.Image, "prewarm-image", prewarm.DefaultImage, "The image of the placeholder pods created to warm up the nodes.")
+	flag.IntVar(&prewarmOpts.MaxPods, "prewarm-max-pods", 20, "The max number of placeholder pods created in a namespace to warm up the nodes. If 0, the placeholder pods are as many as the pods running before the sleep.")
+	flag.StringVar(&prewarmOpts.HookURL, "prewarm-hook-url", "", "The endpoint notified with a POST request when a namespace must be warmed up. If empty, the hook is disabled.")
+	flag.StringVar(&tracingOpts.Endpoint, "tracing-otlp-endpoint", "", "The address of the OpenTelemetry collector where the traces are exported via OTLP gRPC. If empty, the tracing is disabled.")
+	flag.BoolVar(&tracingOpts.Insecure, "tracing-otlp-insecure", false, "Disable the TLS on the connection to the OpenTelemetry collector.")
+	flag.Float64Var(&tracingOpts.SampleRatio, "tracing-sample-ratio", 1, "The ratio of the sampled traces, between 0 and 1.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
-	opts := zap.Options{
-		Development: true,
-	}
+	flag.StringVar(&leaderElectionID, "leader-election-id", "2bd226ed.kube-green.com", "The name of the resource used as lock for the leader election.")
+	flag.StringVar(&leaderElectionNamespace, "leader-election-namespace", "", "The namespace where the leader election resource is created. Default to the namespace where the controller is running.")
+	flag.DurationVar(&leaseDuration, "leader-election-lease-duration", 15*time.Second, "The duration that non-leader candidates will wait to force acquire leadership.")
+	flag.DurationVar(&renewDeadline, "leader-election-renew-deadline", 10*time.Second, "The duration that the acting leader will retry refreshing leadership before giving up.")
+	flag.DurationVar(&retryPeriod, "leader-election-retry-period", 2*time.Second, "The duration the leader election clients should wait between tries of actions.")
+	flag.BoolVar(&leaderElectionReleaseOnCancel, "leader-election-release-on-cancel", true,
+		"Release the leader election lock when the manager is stopped, so that a new leader is elected without waiting the lease duration. "+
+			"The manager must exit as soon as it is stopped.")
+	flag.StringVar(&featureGatesValue, "feature-gates", "", "Comma separated list of feature=bool pairs which enable or disable the features of the controller. Options are:\n"+strings.Join(featuregate.KnownFeatures(), "\n"))
+	opts := zap.Options{}
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	ctx := ctrl.SetupSignalHandler()
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	featureGates, err := featuregate.Parse(featureGatesValue)
+	if err != nil {
+		setupLog.Error(err, "invalid feature gates")
+		os.Exit(1)
+	}
+
+	options := ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
 		Port:                   webhookPort,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "2bd226ed.kube-green.com",
-	})
+		LeaderElectionID:       leaderElectionID,
+		SyncPeriod:             &syncPeriod,
+
+		LeaderElectionNamespace: leaderElectionNamespace,
+		LeaseDuration:           &leaseDuration,
+		RenewDeadline:           &renewDeadline,
+		RetryPeriod:             &retryPeriod,
+	}
+	// registered before the plugins, which can replace them.
+	if suspendExternalSecretsRefresh {
+		customresources.RegisterRefreshHandlers(customresources.DefaultRegistry)
+	}
+	kubeGreenConfig := configv1alpha1.KubeGreenConfig{}
+	if configFile != "" {
+		options, err = ctrl.Options{Scheme: scheme}.AndFrom(ctrl.ConfigFile().AtPath(configFile).OfKind(&kubeGreenConfig))
+		if err != nil {
+			setupLog.Error(err, "unable to load the config file")
+			os.Exit(1)
+		}
+		applyKubeGreenConfig(kubeGreenConfig, &sleepDelta, &maxConcurrentReconciles, &rateLimiterOpts)
+		if err := customresources.RegisterPresets(customresources.DefaultRegistry, kubeGreenConfig.Presets); err != nil {
+			setupLog.Error(err, "unable to register the presets")
+			os.Exit(1)
+		}
+		registerPlugins(customresources.DefaultRegistry, kubeGreenConfig.Plugins)
+	}
+	options.LeaderElectionReleaseOnCancel = leaderElectionReleaseOnCancel
+	// the manager waits for the reconciles in flight to complete their
+	// operation, and then for them to return.
+	gracefulShutdownTimeout := drainTimeout + gracefulShutdownMargin
+	options.GracefulShutdownTimeout = &gracefulShutdownTimeout
+
+	watchedNamespaces := splitList(watchNamespaces)
+	if len(kubeGreenConfig.WatchNamespaces) > 0 {
+		watchedNamespaces = kubeGreenConfig.WatchNamespaces
+	}
+	setWatchNamespaces(&options, watchedNamespaces)
+
+	namespaceFilter, err := newNamespaceFilter(namespacesAllow, namespacesDeny, namespacesAllowSelector, namespacesDenySelector, kubeGreenConfig.Namespaces)
+	if err != nil {
+		setupLog.Error(err, "invalid namespaces filter")
+		os.Exit(1)
+	}
+	if len(watchedNamespaces) > 0 && namespaceFilter.HasLabelSelectors() {
+		setupLog.Error(fmt.Errorf("namespaces label selectors require to watch the whole cluster"), "invalid namespaces filter")
+		os.Exit(1)
+	}
+
+	restConfig := ctrl.GetConfigOrDie()
+	restConfig.QPS = float32(kubeAPIQPS)
+	restConfig.Burst = kubeAPIBurst
+	if err := applyClientTransport(restConfig, kubeGreenConfig.ClientTransport); err != nil {
+		setupLog.Error(err, "unable to configure the client transport")
+		os.Exit(1)
+	}
+	mgr, err := ctrl.NewManager(restConfig, options)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	auditSink, err := newAuditSink(mgr, auditSinkType, auditConfigMapNamespace, auditConfigMapName, auditConfigMapSize, auditHTTPURL)
+	if err != nil {
+		setupLog.Error(err, "unable to create audit sink")
+		os.Exit(1)
+	}
+
+	var silencer alertmanager.Silencer
+	if alertmanagerURL != "" {
+		silencer = alertmanager.NewSilencer(nil, alertmanagerURL, alertmanagerNamespaceLabel)
+	}
+
+	var prometheusQuerier promquery.Querier
+	if prometheusURL != "" {
+		prometheusQuerier = promquery.NewQuerier(nil, prometheusURL)
+	}
+
+	var operationGate operationgate.Gate
+	if gate := kubeGreenConfig.OperationGate; gate != nil {
+		operationGate = operationgate.NewWebhook(nil, gate.URL, gate.GetTimeout(), gate.IsFailureIgnored())
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, tracingOpts)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
 	customMetrics := metrics.SetupMetricsOrDie("kube_green").MustRegister(ctrlMetrics.Registry)
+	featureGates.Report(customMetrics.FeatureEnabled)
+	healthTracker := health.NewTracker(healthMaxReconcileFailures, healthMaxReconcileDuration)
 
-	if err = (&sleepinfocontroller.SleepInfoReconciler{
+	// the pods are read without the cache, to not watch all the pods of the
+	// cluster.
+	var podReader client.Reader
+	if sleepReportInterval > 0 || prewarmOpts.LeadTime > 0 || sleepCompletionCheckDelay > 0 {
+		podReader = mgr.GetAPIReader()
+	}
+
+	stateCodec, err := newStateCodec(stateEncryptionKeyFile)
+	if err != nil {
+		setupLog.Error(err, "unable to create the state codec")
+		os.Exit(1)
+	}
+
+	var throttleBackoff *sleepinfocontroller.ThrottleBackoff
+	if throttleBackoffBaseDelay > 0 {
+		throttleBackoff = sleepinfocontroller.NewThrottleBackoff(throttleBackoffBaseDelay, throttleBackoffMaxDelay)
+	}
+
+	var operationLock *sleepinfocontroller.OperationLock
+	if featureGates.Enabled(featuregate.OperationLock) {
+		operationLock = sleepinfocontroller.NewOperationLock()
+	}
+
+	sleepInfoReconciler := &sleepinfocontroller.SleepInfoReconciler{
 		Client:     mgr.GetClient(),
 		Log:        ctrl.Log.WithName("controllers").WithName("SleepInfo"),
 		Scheme:     mgr.GetScheme(),
 		Metrics:    customMetrics,
 		SleepDelta: sleepDelta,
-	}).SetupWithManager(mgr); err != nil {
+
+		MaxScheduleJitter:         maxScheduleJitter,
+		ExecuteMissedOperations:   executeMissedOperations,
+		WakeAll:                   wakeAllOnStart,
+		Teardown:                  teardown,
+		DefaultTimeZone:           kubeGreenConfig.DefaultTimeZone,
+		MaxConcurrentReconciles:   maxConcurrentReconciles,
+		RateLimiter:               sleepinfocontroller.NewRateLimiter(rateLimiterOpts),
+		NamespaceFilter:           namespaceFilter,
+		ProtectedNamespaces:       kubeGreenConfig.ProtectedNamespaces,
+		WatchNamespaces:           watchedNamespaces,
+		AuditSink:                 auditSink,
+		Silencer:                  silencer,
+		PrometheusQuerier:         prometheusQuerier,
+		OperationGate:             operationGate,
+		Recorder:                  mgr.GetEventRecorderFor("kube-green"),
+		HealthTracker:             healthTracker,
+		PodReader:                 podReader,
+		ResourceTimeout:           resourceTimeout,
+		OperationChunkSize:        operationChunkSize,
+		OperationChunkPause:       operationChunkPause,
+		StateSecret:               kubeGreenConfig.StateSecret,
+		StateCodec:                stateCodec,
+		ThrottleBackoff:           throttleBackoff,
+		OperationLock:             operationLock,
+		Impersonator:              sleepinfocontroller.NewImpersonator(mgr.GetConfig(), mgr.GetScheme(), mgr.GetRESTMapper(), kubeGreenConfig.Impersonation),
+		FreezeWindows:             kubeGreenConfig.FreezeWindows,
+		SleepCompletionCheckDelay: sleepCompletionCheckDelay,
+		DrainTimeout:              drainTimeout,
+	}
+	if err = sleepInfoReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "SleepInfo")
 		os.Exit(1)
 	}
-	if err = (&kubegreencomv1alpha1.SleepInfo{}).SetupWebhookWithManager(mgr); err != nil {
+	if featureGates.Enabled(featuregate.WakeOnRequest) {
+		if err = (&wakeuprequest.WakeUpRequestReconciler{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("WakeUpRequest"),
+			Recorder: mgr.GetEventRecorderFor("kube-green"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "WakeUpRequest")
+			os.Exit(1)
+		}
+	}
+	if err = setupRemoteClusters(ctx, mgr, sleepInfoReconciler, kubeGreenConfig.RemoteClusters, kubeGreenConfig.Impersonation, rateLimiterOpts, customMetrics); err != nil {
+		setupLog.Error(err, "unable to set up remote clusters")
+		os.Exit(1)
+	}
+	if err = (&kubegreencomv1alpha1.SleepInfo{}).SetupWebhookWithManager(mgr, kubeGreenConfig.DefaultTimeZone, kubeGreenConfig.ProtectedNamespaces, len(watchedNamespaces) > 0); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "SleepInfo")
 		os.Exit(1)
 	}
+	if featureGates.Enabled(featuregate.ScaleWarning) {
+		mgr.GetWebhookServer().Register(sleepinfocontroller.ScaleWarningPath, &webhook.Admission{
+			Handler: &sleepinfocontroller.ScaleWarningHandler{
+				Client: mgr.GetAPIReader(),
+				Log:    ctrl.Log.WithName("webhooks").WithName("ScaleWarning"),
+			},
+		})
+	}
 	// +kubebuilder:scaffold:builder
 
+	if statusAPITokenFile != "" {
+		statusAPIHandler, err := statusapi.NewHandler(mgr.GetClient(), ctrl.Log.WithName("statusapi"), statusAPITokenFile)
+		if err != nil {
+			setupLog.Error(err, "unable to create status api")
+			os.Exit(1)
+		}
+		statusAPIHandler.StateSecret = kubeGreenConfig.StateSecret
+		statusAPIHandler.StateCodec = stateCodec
+		if err := mgr.AddMetricsExtraHandler(statusapi.Path, statusAPIHandler); err != nil {
+			setupLog.Error(err, "unable to set up status api")
+			os.Exit(1)
+		}
+	}
+	if slackSigningSecretFile != "" {
+		slackHandler, err := slack.NewHandler(mgr.GetClient(), ctrl.Log.WithName("slack"), slackSigningSecretFile, kubeGreenConfig.SlackUsers)
+		if err != nil {
+			setupLog.Error(err, "unable to create slack handler")
+			os.Exit(1)
+		}
+		if err := mgr.AddMetricsExtraHandler(slack.Path, slackHandler); err != nil {
+			setupLog.Error(err, "unable to set up slack handler")
+			os.Exit(1)
+		}
+	}
+	if cloudEventsTokenFile != "" {
+		cloudEventsHandler, err := cloudevents.NewHandler(mgr.GetClient(), ctrl.Log.WithName("cloudevents"), cloudEventsTokenFile, splitList(cloudEventsTypes))
+		if err != nil {
+			setupLog.Error(err, "unable to create cloudevents handler")
+			os.Exit(1)
+		}
+		if err := mgr.AddMetricsExtraHandler(cloudevents.Path, cloudEventsHandler); err != nil {
+			setupLog.Error(err, "unable to set up cloudevents handler")
+			os.Exit(1)
+		}
+	}
+	if dashboardAddr != "" {
+		if err := mgr.Add(&dashboard.Server{
+			Addr:   dashboardAddr,
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("dashboard"),
+		}); err != nil {
+			setupLog.Error(err, "unable to set up dashboard")
+			os.Exit(1)
+		}
+	}
+	if sleepReportInterval > 0 {
+		if err := mgr.Add(&sleepreport.Reporter{
+			Client:    mgr.GetClient(),
+			PodReader: podReader,
+			Interval:  sleepReportInterval,
+			Prices:    kubeGreenConfig.Prices,
+			Metrics:   customMetrics,
+			Log:       ctrl.Log.WithName("sleepreport"),
+		}); err != nil {
+			setupLog.Error(err, "unable to set up sleep reports")
+			os.Exit(1)
+		}
+	}
+	if kubeGreenReportInterval > 0 {
+		if err := mgr.Add(&kubegreenreport.Aggregator{
+			Client:   mgr.GetClient(),
+			Interval: kubeGreenReportInterval,
+			Log:      ctrl.Log.WithName("kubegreenreport"),
+		}); err != nil {
+			setupLog.Error(err, "unable to set up kube-green reports")
+			os.Exit(1)
+		}
+	}
+	if alertRulesInterval > 0 {
+		ruleLabels, err := labels.ConvertSelectorToLabelsMap(alertRulesLabels)
+		if err != nil {
+			setupLog.Error(err, "invalid alerting rules labels")
+			os.Exit(1)
+		}
+		if err := mgr.Add(&alertrules.Generator{
+			Client:     mgr.GetClient(),
+			Interval:   alertRulesInterval,
+			Threshold:  alertRulesThreshold,
+			RuleLabels: ruleLabels,
+			Log:        ctrl.Log.WithName("alertrules"),
+		}); err != nil {
+			setupLog.Error(err, "unable to set up the alerting rules")
+			os.Exit(1)
+		}
+	}
+
+	if stateGCInterval > 0 {
+		if err := mgr.Add(&sleepinfocontroller.StateCollector{
+			Reconciler: sleepInfoReconciler,
+			Interval:   stateGCInterval,
+			Log:        ctrl.Log.WithName("stategc"),
+		}); err != nil {
+			setupLog.Error(err, "unable to set up the collection of the orphaned state secrets")
+			os.Exit(1)
+		}
+	}
+
+	if prewarmOpts.LeadTime > 0 {
+		if prewarmOpts.PriorityClassName == "" && prewarmOpts.HookURL == "" {
+			setupLog.Error(fmt.Errorf("--prewarm-priority-class or --prewarm-hook-url is required"), "invalid warm up options")
+			os.Exit(1)
+		}
+		if err := mgr.Add(newWarmer(mgr, prewarmOpts)); err != nil {
+			setupLog.Error(err, "unable to set up the warm up")
+			os.Exit(1)
+		}
+	}
+	if nodeHintsOpts.Interval > 0 {
+		hinter, err := newNodeHinter(mgr, nodeHintsOpts)
+		if err != nil {
+			setupLog.Error(err, "invalid node hints options")
+			os.Exit(1)
+		}
+		if err := mgr.Add(hinter); err != nil {
+			setupLog.Error(err, "unable to set up node hints")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("health", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
+	if err := mgr.AddHealthzCheck("reconcile-stuck", healthTracker.StuckReconcileCheck); err != nil {
+		setupLog.Error(err, "unable to set up health check")
+		os.Exit(1)
+	}
 	if err := mgr.AddReadyzCheck("check", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if err := mgr.AddReadyzCheck("reconcile-failures", healthTracker.ReconcileFailuresCheck); err != nil {
+		setupLog.Error(err, "unable to set up ready check")
+		os.Exit(1)
+	}
+	apiServerConfig := rest.CopyConfig(mgr.GetConfig())
+	apiServerConfig.Timeout = 5 * time.Second
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(apiServerConfig)
+	if err != nil {
+		setupLog.Error(err, "unable to create discovery client")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("apiserver", health.APIServerCheck(discoveryClient)); err != nil {
+		setupLog.Error(err, "unable to set up ready check")
+		os.Exit(1)
+	}
+	// the custom resources of the remote clusters are not discovered, since
+	// the registry of the handlers is shared with the local cluster.
+	if discoveryInterval > 0 && len(kubeGreenConfig.RemoteClusters) == 0 {
+		if err := mgr.Add(&customresources.Discoverer{
+			Registry:  customresources.DefaultRegistry,
+			Discovery: discoveryClient,
+			Client:    mgr.GetClient(),
+			Interval:  discoveryInterval,
+			Log:       ctrl.Log.WithName("discovery"),
+		}); err != nil {
+			setupLog.Error(err, "unable to set up the discovery of the custom resources")
+			os.Exit(1)
+		}
+	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
+	if err := shutdownTracing(context.Background()); err != nil {
+		setupLog.Error(err, "problem flushing traces")
+	}
+}
+
+// runPreview prints the operations of the SleepInfo in a file for the next
+// days, so that a configuration can be verified before applying it.
+func runPreview(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("preview", flag.ContinueOnError)
+	file := fs.String("file", "", "The file with the SleepInfo to preview.")
+	days := fs.Int("days", 7, "The number of days to preview.")
+	defaultTimeZone := fs.String("default-time-zone", "", "The time zone used if the SleepInfo does not set it. Default to UTC.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("--file is required")
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return fmt.Errorf("fails to read file: %s", err)
+	}
+	sleepInfo := &kubegreencomv1alpha1.SleepInfo{}
+	if _, _, err := serializer.NewCodecFactory(scheme).UniversalDeserializer().Decode(data, nil, sleepInfo); err != nil {
+		return fmt.Errorf("invalid SleepInfo: %s", err)
+	}
+	if sleepInfo.Spec.TimeZone == "" {
+		sleepInfo.Spec.TimeZone = *defaultTimeZone
+	}
+	if err := sleepInfo.ValidateCreate(); err != nil {
+		return fmt.Errorf("invalid SleepInfo: %s", err)
+	}
+
+	now := time.Now()
+	operations, err := sleepinfocontroller.PreviewOperations(sleepInfo, now, now.Add(time.Duration(*days)*24*time.Hour))
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OPERATION\tTIME")
+	for _, operation := range operations {
+		fmt.Fprintf(w, "%s\t%s\n", operation.Type, operation.Time.Format("Mon 2006-01-02 15:04 MST"))
+	}
+	return w.Flush()
+}
+
+// runInspect prints the original state of the resources stored by the last
+// sleep of the SleepInfo of a namespace, i.e. what the next wake up restores.
+func runInspect(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	namespace := fs.String("namespace", "", "The namespace of the SleepInfo to inspect.")
+	name := fs.String("name", "", "The name of the SleepInfo to inspect. If empty, all the SleepInfo of the namespace are inspected.")
+	output := fs.String("output", "table", "The output format. One of: table, json.")
+	secretNamePattern := fs.String("secret-name-pattern", configv1alpha1.DefaultStateSecretNamePattern, "The name of the Secret where the state is stored, as set in the stateSecret of the config file of the controller.")
+	encryptionKeyFile := fs.String("encryption-key-file", "", "The file with the key used to encrypt the state, as set with --state-encryption-key-file in the controller.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *namespace == "" {
+		return fmt.Errorf("--namespace is required")
+	}
+	if *output != "table" && *output != "json" {
+		return fmt.Errorf("invalid output %s: must be table or json", *output)
+	}
+
+	stateCodec, err := newStateCodec(*encryptionKeyFile)
+	if err != nil {
+		return err
+	}
+
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		return fmt.Errorf("fails to get the kubeconfig: %s", err)
+	}
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return fmt.Errorf("fails to create the client: %s", err)
+	}
+	ctx := context.Background()
+	sleepInfos := []kubegreencomv1alpha1.SleepInfo{}
+	if *name != "" {
+		sleepInfo := kubegreencomv1alpha1.SleepInfo{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: *namespace, Name: *name}, &sleepInfo); err != nil {
+			return fmt.Errorf("fails to get the SleepInfo: %s", err)
+		}
+		sleepInfos = append(sleepInfos, sleepInfo)
+	} else {
+		sleepInfoList := kubegreencomv1alpha1.SleepInfoList{}
+		if err := c.List(ctx, &sleepInfoList, client.InNamespace(*namespace)); err != nil {
+			return fmt.Errorf("fails to list the SleepInfo: %s", err)
+		}
+		sleepInfos = sleepInfoList.Items
+	}
+
+	states := []sleepinfocontroller.SleepState{}
+	now := time.Now()
+	for _, sleepInfo := range sleepInfos {
+		sleepInfo := sleepInfo
+		state, err := sleepinfocontroller.GetSleepState(ctx, c, &sleepInfo, &configv1alpha1.StateSecret{NamePattern: *secretNamePattern}, stateCodec, now)
+		if err != nil {
+			return fmt.Errorf("fails to get the state of SleepInfo %s: %s", sleepInfo.Name, err)
+		}
+		states = append(states, state)
+	}
+	if *output == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(states)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for i, state := range states {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "SleepInfo %s/%s\n", state.Namespace, state.Name)
+		switch {
+		case state.LastOperation == "":
+			fmt.Fprintln(w, "No operation performed yet.")
+			continue
+		case state.CapturedAt != nil:
+			fmt.Fprintf(w, "Last operation %s at %s\n", state.LastOperation, state.CapturedAt.Format(time.RFC3339))
+		default:
+			fmt.Fprintf(w, "Last operation %s\n", state.LastOperation)
+		}
+		if state.NextWakeUp != nil {
+			fmt.Fprintf(w, "Next wake up at %s\n", state.NextWakeUp.Format(time.RFC3339))
+		}
+		if len(state.Resources) == 0 {
+			fmt.Fprintln(w, "No resource to restore.")
+			continue
+		}
+		fmt.Fprintln(w, "KIND\tNAME\tORIGINAL")
+		for _, resource := range state.Resources {
+			original, err := json.Marshal(resource.Original)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", resource.Kind, resource.Name, original)
+		}
+	}
+	return w.Flush()
+}
+
+// applyKubeGreenConfig overrides the kube-green options with the ones set in the config file.
+func applyKubeGreenConfig(
+	kubeGreenConfig configv1alpha1.KubeGreenConfig,
+	sleepDelta *int64,
+	maxConcurrentReconciles *int,
+	rateLimiterOpts *sleepinfocontroller.RateLimiterOptions,
+) {
+	if kubeGreenConfig.SleepDelta != nil {
+		*sleepDelta = *kubeGreenConfig.SleepDelta
+	}
+	if concurrency := kubeGreenConfig.GetSleepInfoConcurrency(); concurrency > 0 {
+		*maxConcurrentReconciles = concurrency
+	}
+	if rateLimiter := kubeGreenConfig.RateLimiter; rateLimiter != nil {
+		if rateLimiter.BaseDelay != nil {
+			rateLimiterOpts.BaseDelay = rateLimiter.BaseDelay.Duration
+		}
+		if rateLimiter.MaxDelay != nil {
+			rateLimiterOpts.MaxDelay = rateLimiter.MaxDelay.Duration
+		}
+		if rateLimiter.QPS != nil {
+			rateLimiterOpts.QPS = *rateLimiter.QPS
+		}
+		if rateLimiter.Burst != nil {
+			rateLimiterOpts.Burst = *rateLimiter.Burst
+		}
+		if rateLimiter.NamespaceQPS != nil {
+			rateLimiterOpts.NamespaceQPS = *rateLimiter.NamespaceQPS
+		}
+		if rateLimiter.NamespaceBurst != nil {
+			rateLimiterOpts.NamespaceBurst = *rateLimiter.NamespaceBurst
+		}
+	}
+}
+
+// applyClientTransport configures the transport of the client to the API server
+// with the clientTransport set in the config file.
+func applyClientTransport(restConfig *rest.Config, transport *configv1alpha1.ClientTransport) error {
+	if transport == nil {
+		return nil
+	}
+	if transport.ProxyURL != "" {
+		proxyURL, err := url.Parse(transport.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxyURL: %s", err)
+		}
+		restConfig.Proxy = http.ProxyURL(proxyURL)
+	}
+	if transport.CAFile != "" {
+		caData, err := os.ReadFile(transport.CAFile)
+		if err != nil {
+			return fmt.Errorf("fails to read caFile: %s", err)
+		}
+		// the CA bundle is added to the CA of the config, which is read from
+		// its file since the data take precedence over the file.
+		currentCAData := restConfig.CAData
+		if len(currentCAData) == 0 && restConfig.CAFile != "" {
+			if currentCAData, err = os.ReadFile(restConfig.CAFile); err != nil {
+				return fmt.Errorf("fails to read the CA file of the config: %s", err)
+			}
+		}
+		restConfig.CAData = append(append(append([]byte{}, currentCAData...), '\n'), caData...)
+	}
+	if transport.UserAgent != "" {
+		restConfig.UserAgent = transport.UserAgent
+	}
+	return nil
+}
+
+// newStateCodec returns the codec of the state stored in the Secret of the
+// SleepInfo, encrypting it with the key in keyFile, if set.
+func newStateCodec(keyFile string) (sleepinfocontroller.StateCodec, error) {
+	if keyFile == "" {
+		return sleepinfocontroller.StateCodec{}, nil
+	}
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return sleepinfocontroller.StateCodec{}, fmt.Errorf("fails to read the encryption key file: %s", err)
+	}
+	if len(bytes.TrimSpace(key)) == 0 {
+		return sleepinfocontroller.StateCodec{}, fmt.Errorf("the encryption key file is empty")
+	}
+	return sleepinfocontroller.NewStateCodec(key)
+}
+
+// registerPlugins adds to the registry the handlers of the plugins set in the
+// config file, replacing the built-in handlers of the same kind.
+func registerPlugins(registry *customresources.Registry, plugins []configv1alpha1.Plugin) {
+	for _, plugin := range plugins {
+		registry.Register(plugin.GetGroupVersionKind(), customresources.NewPluginHandler(nil, plugin.URL, plugin.GetTimeout()))
+		setupLog.Info("registered plugin", "kind", plugin.GetGroupVersionKind(), "url", plugin.URL)
+	}
+}
+
+type prewarmOptions struct {
+	LeadTime          time.Duration
+	PriorityClassName string
+	Image             string
+	MaxPods           int
+	HookURL           string
+}
+
+func newWarmer(mgr ctrl.Manager, opts prewarmOptions) *prewarm.Warmer {
+	var hook prewarm.Hook
+	if opts.HookURL != "" {
+		hook = prewarm.NewHTTPHook(&http.Client{Timeout: 10 * time.Second}, opts.HookURL)
+	}
+	return &prewarm.Warmer{
+		Client:            mgr.GetClient(),
+		PodReader:         mgr.GetAPIReader(),
+		Interval:          time.Minute,
+		LeadTime:          opts.LeadTime,
+		PriorityClassName: opts.PriorityClassName,
+		Image:             opts.Image,
+		MaxPods:           opts.MaxPods,
+		Hook:              hook,
+		Log:               ctrl.Log.WithName("prewarm"),
+	}
+}
+
+// newNamespaceFilter creates the namespace filter from the flags. If the
+// namespaces are configured in the config file, the flags are ignored.
+func newNamespaceFilter(allow, deny, allowSelector, denySelector string, namespaces *configv1alpha1.Namespaces) (namespacefilter.Filter, error) {
+	if namespaces != nil {
+		return namespacefilter.NewFromLabelSelectors(namespaces.Allow, namespaces.Deny, namespaces.AllowSelector, namespaces.DenySelector)
+	}
+
+	allowLabelSelector, err := labels.Parse(allowSelector)
+	if err != nil {
+		return namespacefilter.Filter{}, fmt.Errorf("invalid allow selector: %s", err)
+	}
+	denyLabelSelector, err := labels.Parse(denySelector)
+	if err != nil {
+		return namespacefilter.Filter{}, fmt.Errorf("invalid deny selector: %s", err)
+	}
+	return namespacefilter.New(splitList(allow), splitList(deny), allowLabelSelector, denyLabelSelector)
+}
+
+type nodeHintsOptions struct {
+	Interval       time.Duration
+	NodeSelector   string
+	Cordon         bool
+	WakeUpLeadTime time.Duration
+	HookURL        string
+}
+
+func newNodeHinter(mgr ctrl.Manager, opts nodeHintsOptions) (*nodehints.Hinter, error) {
+	nodeSelector, err := labels.Parse(opts.NodeSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node selector: %s", err)
+	}
+	var hook nodehints.Hook
+	if opts.HookURL != "" {
+		hook = nodehints.NewHTTPHook(&http.Client{Timeout: 10 * time.Second}, opts.HookURL)
+	}
+	return &nodehints.Hinter{
+		Client:         mgr.GetClient(),
+		PodReader:      mgr.GetAPIReader(),
+		Interval:       opts.Interval,
+		NodeSelector:   nodeSelector,
+		Cordon:         opts.Cordon,
+		WakeUpLeadTime: opts.WakeUpLeadTime,
+		Hook:           hook,
+		Log:            ctrl.Log.WithName("nodehints"),
+	}, nil
+}
+
+// setupRemoteClusters adds to the manager the remote clusters, the
+// controllers of their SleepInfo, configured as the local one, and the
+// reporter of their status.
+func setupRemoteClusters(
+	ctx context.Context,
+	mgr ctrl.Manager,
+	sleepInfoReconciler *sleepinfocontroller.SleepInfoReconciler,
+	remoteClusters []configv1alpha1.RemoteCluster,
+	impersonation *configv1alpha1.Impersonation,
+	rateLimiterOpts sleepinfocontroller.RateLimiterOptions,
+	customMetrics metrics.Metrics,
+) error {
+	if len(remoteClusters) == 0 {
+		return nil
+	}
+	statusReporter := &multicluster.StatusReporter{
+		Clusters: map[string]discovery.ServerVersionInterface{},
+		Metrics:  customMetrics,
+		Log:      ctrl.Log.WithName("multicluster"),
+	}
+	for _, remoteCluster := range remoteClusters {
+		remote, err := multicluster.NewCluster(ctx, mgr.GetAPIReader(), mgr.GetScheme(), remoteCluster)
+		if err != nil {
+			return err
+		}
+		if err := mgr.Add(remote); err != nil {
+			return fmt.Errorf("fails to add remote cluster %s: %s", remote.Name, err)
+		}
+		reconciler := sleepInfoReconciler.ForRemoteCluster(remote.Name, remote.GetClient(), remote.GetEventRecorderFor("kube-green"))
+		reconciler.RateLimiter = sleepinfocontroller.NewRateLimiter(rateLimiterOpts)
+		reconciler.Impersonator = sleepinfocontroller.NewImpersonator(remote.GetConfig(), remote.GetScheme(), remote.GetRESTMapper(), impersonation)
+		if err := reconciler.SetupWithRemoteCluster(mgr, remote.Name, remote.GetCache()); err != nil {
+			return fmt.Errorf("fails to create controller of remote cluster %s: %s", remote.Name, err)
+		}
+		serverVersionClient, err := remote.NewServerVersionClient()
+		if err != nil {
+			return fmt.Errorf("fails to create discovery client of remote cluster %s: %s", remote.Name, err)
+		}
+		statusReporter.Clusters[remote.Name] = serverVersionClient
+	}
+	return mgr.Add(statusReporter)
+}
+
+// newAuditSink creates the audit sink of the given type. It returns nil if
+// sinkType is empty, which disables the audit.
+func newAuditSink(mgr ctrl.Manager, sinkType, configMapNamespace, configMapName string, configMapSize int, httpURL string) (audit.Sink, error) {
+	switch sinkType {
+	case "":
+		return nil, nil
+	case "stdout":
+		return audit.NewJSONSink(os.Stdout), nil
+	case "configmap":
+		if configMapNamespace == "" || configMapName == "" {
+			return nil, fmt.Errorf("audit configmap namespace and name are required")
+		}
+		// the audit ConfigMap is read without the cache, to avoid to watch all
+		// the ConfigMaps of the cluster
+		c, err := client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper()})
+		if err != nil {
+			return nil, err
+		}
+		return audit.NewConfigMapSink(c, configMapNamespace, configMapName, configMapSize), nil
+	case "http":
+		if httpURL == "" {
+			return nil, fmt.Errorf("audit http url is required")
+		}
+		return audit.NewHTTPSink(&http.Client{Timeout: 10 * time.Second}, httpURL), nil
+	default:
+		return nil, fmt.Errorf("audit sink %s not supported", sinkType)
+	}
+}
+
+// setWatchNamespaces restricts the manager cache to the given namespaces.
+// If no namespace is set, the manager watches the whole cluster.
+func setWatchNamespaces(options *ctrl.Options, namespaces []string) {
+	switch len(namespaces) {
+	case 0:
+	case 1:
+		options.Namespace = namespaces[0]
+	default:
+		options.Namespace = ""
+		options.NewCache = cache.MultiNamespacedCacheBuilder(namespaces)
+	}
+}
+
+func splitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
@@ -0,0 +1,27 @@
+// Package clock gives the current time to the controllers, so that the tests
+// and the simulations of the schedules can fake it out.
+package clock
+
+import "time"
+
+// Clock knows how to get the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// RealClock is the Clock of the current time.
+type RealClock struct{}
+
+// Now returns the current time.
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// Now returns the current time of the clock, or the current time if the
+// clock is nil.
+func Now(clock Clock) time.Time {
+	if clock == nil {
+		return time.Now()
+	}
+	return clock.Now()
+}
@@ -0,0 +1,26 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+func TestNow(t *testing.T) {
+	now := time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)
+	require.Equal(t, now, Now(fixedClock{now: now}))
+
+	t.Run("nil clock", func(t *testing.T) {
+		before := time.Now()
+		require.False(t, Now(nil).Before(before))
+	})
+}
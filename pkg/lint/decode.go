@@ -0,0 +1,51 @@
+package lint
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kube-green/kube-green/api/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Decode reads the SleepInfo in the YAML documents, or in the JSON, of r.
+// The documents of the other kinds are skipped, so that the manifests of a
+// whole application can be linted. The unknown fields of the SleepInfo are
+// an error, since they are ignored by the API server.
+func Decode(r io.Reader) ([]v1alpha1.SleepInfo, error) {
+	reader := yaml.NewYAMLReader(bufio.NewReader(r))
+	sleepInfos := []v1alpha1.SleepInfo{}
+	for i := 1; ; i++ {
+		document, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return sleepInfos, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fails to read document %d: %s", i, err)
+		}
+		if len(bytes.TrimSpace(document)) == 0 {
+			continue
+		}
+
+		typeMeta := metav1.TypeMeta{}
+		if err := yaml.Unmarshal(document, &typeMeta); err != nil {
+			return nil, fmt.Errorf("invalid document %d: %s", i, err)
+		}
+		if typeMeta.Kind != "SleepInfo" || typeMeta.GroupVersionKind().Group != v1alpha1.GroupVersion.Group {
+			continue
+		}
+		if typeMeta.APIVersion != v1alpha1.GroupVersion.String() {
+			return nil, fmt.Errorf("invalid document %d: apiVersion %s not supported", i, typeMeta.APIVersion)
+		}
+		sleepInfo := v1alpha1.SleepInfo{}
+		if err := yaml.UnmarshalStrict(document, &sleepInfo); err != nil {
+			return nil, fmt.Errorf("invalid SleepInfo in document %d: %s", i, err)
+		}
+		sleepInfos = append(sleepInfos, sleepInfo)
+	}
+}
@@ -0,0 +1,97 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expectedNames []string
+		expectedErr   string
+	}{
+		{
+			name: "SleepInfo among other resources",
+			input: `apiVersion: v1
+kind: Namespace
+metadata:
+  name: my-namespace
+---
+apiVersion: kube-green.com/v1alpha1
+kind: SleepInfo
+metadata:
+  name: working-hours
+  namespace: my-namespace
+spec:
+  weekdays: "1-5"
+  sleepAt: "20:00"
+  wakeUpAt: "08:00"
+---
+---
+apiVersion: kube-green.com/v1alpha1
+kind: SleepInfo
+metadata:
+  name: nights
+  namespace: my-namespace
+spec:
+  preset: nights
+`,
+			expectedNames: []string{"working-hours", "nights"},
+		},
+		{
+			name:          "JSON",
+			input:         `{"apiVersion": "kube-green.com/v1alpha1", "kind": "SleepInfo", "metadata": {"name": "working-hours"}, "spec": {"sleepAt": "20:00"}}`,
+			expectedNames: []string{"working-hours"},
+		},
+		{
+			name:          "empty",
+			input:         "",
+			expectedNames: []string{},
+		},
+		{
+			name: "unknown field",
+			input: `apiVersion: kube-green.com/v1alpha1
+kind: SleepInfo
+metadata:
+  name: working-hours
+spec:
+  sleepTime: "20:00"
+`,
+			expectedErr: `invalid SleepInfo in document 1: error unmarshaling JSON: while decoding JSON: json: unknown field "sleepTime"`,
+		},
+		{
+			name: "unsupported apiVersion",
+			input: `apiVersion: kube-green.com/v1
+kind: SleepInfo
+metadata:
+  name: working-hours
+`,
+			expectedErr: "invalid document 1: apiVersion kube-green.com/v1 not supported",
+		},
+		{
+			name:        "invalid YAML",
+			input:       "kind: [SleepInfo",
+			expectedErr: "invalid document 1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sleepInfos, err := Decode(strings.NewReader(test.input))
+			if test.expectedErr != "" {
+				require.ErrorContains(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			names := []string{}
+			for _, sleepInfo := range sleepInfos {
+				names = append(names, sleepInfo.Name)
+			}
+			require.Equal(t, test.expectedNames, names)
+		})
+	}
+}
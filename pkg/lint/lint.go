@@ -0,0 +1,239 @@
+// Package lint checks the SleepInfo offline, without a cluster, e.g. in the
+// pre-merge checks of a GitOps repository. Besides the validation of the
+// webhook, it reports the configurations which are valid but probably not
+// the intended ones, e.g. two SleepInfo managing the same workloads.
+package lint
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/pkg/schedule"
+)
+
+// crossMidnightLookAhead is how far the sleep windows across the midnight
+// are simulated. The schedules repeat every week, or every month with the L
+// and # weekdays, so five weeks are enough.
+const crossMidnightLookAhead = 35 * 24 * time.Hour
+
+// maxCrossMidnightWindow is the longest sleep window across the midnight not
+// reported, i.e. a weekend from Friday night to Monday morning.
+const maxCrossMidnightWindow = 72 * time.Hour
+
+// Severity is the severity of a Finding.
+type Severity string
+
+const (
+	// SeverityError is the severity of the SleepInfo rejected by the webhook.
+	SeverityError Severity = "error"
+	// SeverityWarning is the severity of the SleepInfo accepted by the
+	// webhook, whose configuration is probably not the intended one.
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is an issue of a SleepInfo found by Lint.
+type Finding struct {
+	Severity Severity
+	// SleepInfo is the SleepInfo with the issue, as namespace/name, or only
+	// the name if the namespace is not set.
+	SleepInfo string
+	Message   string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s", f.Severity, f.SleepInfo, f.Message)
+}
+
+// Options configures Lint.
+type Options struct {
+	// DefaultTimeZone is the time zone of the SleepInfo which do not set it,
+	// as configured in the controller. If empty, the SleepInfo without time
+	// zone are reported, since their schedule depends on the cluster.
+	DefaultTimeZone string
+	// Now is when the schedules are simulated from. If zero, the current time
+	// is used.
+	Now time.Time
+}
+
+// Lint checks the SleepInfo and returns their findings, sorted by SleepInfo.
+// The SleepInfo in the same namespace are checked together, to find the
+// workloads managed by more than one of them.
+func Lint(sleepInfos []v1alpha1.SleepInfo, opts Options) []Finding {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	findings := []Finding{}
+	defaulted := make([]v1alpha1.SleepInfo, 0, len(sleepInfos))
+	for _, sleepInfo := range sleepInfos {
+		sleepInfo := *sleepInfo.DeepCopy()
+		sleepInfoFindings, ok := lintSleepInfo(&sleepInfo, opts.DefaultTimeZone, now)
+		findings = append(findings, sleepInfoFindings...)
+		if ok {
+			defaulted = append(defaulted, sleepInfo)
+		}
+	}
+	findings = append(findings, lintOverlaps(defaulted)...)
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].SleepInfo < findings[j].SleepInfo
+	})
+	return findings
+}
+
+// HasErrors returns true if one of the findings is an error.
+func HasErrors(findings []Finding) bool {
+	for _, finding := range findings {
+		if finding.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// lintSleepInfo defaults and checks the SleepInfo. It returns false if the
+// SleepInfo is not valid, so that it is not checked further.
+func lintSleepInfo(sleepInfo *v1alpha1.SleepInfo, defaultTimeZone string, now time.Time) ([]Finding, bool) {
+	name := getName(*sleepInfo)
+	findings := []Finding{}
+	if sleepInfo.Spec.TimeZone == "" && defaultTimeZone == "" {
+		findings = append(findings, Finding{
+			Severity:  SeverityWarning,
+			SleepInfo: name,
+			Message:   "timeZone is not set: the schedule depends on the default time zone of the controller, UTC if not configured",
+		})
+	}
+	if sleepInfo.Spec.TimeZone != "" {
+		if _, err := time.LoadLocation(sleepInfo.Spec.TimeZone); err != nil {
+			return append(findings, Finding{
+				Severity:  SeverityError,
+				SleepInfo: name,
+				Message:   fmt.Sprintf("timeZone %s is invalid: %s", sleepInfo.Spec.TimeZone, err),
+			}), false
+		}
+	}
+
+	defaulter := v1alpha1.SleepInfoDefaulter{DefaultTimeZone: defaultTimeZone}
+	if err := defaulter.Default(context.Background(), sleepInfo); err != nil {
+		return append(findings, Finding{Severity: SeverityError, SleepInfo: name, Message: err.Error()}), false
+	}
+	if err := sleepInfo.Validate(); err != nil {
+		return append(findings, Finding{Severity: SeverityError, SleepInfo: name, Message: err.Error()}), false
+	}
+
+	if message := getCrossMidnightIssue(*sleepInfo, now); message != "" {
+		findings = append(findings, Finding{Severity: SeverityWarning, SleepInfo: name, Message: message})
+	}
+	return findings, true
+}
+
+// getCrossMidnightIssue returns the description of the first sleep window
+// across the midnight longer than a weekend. Since the weekdays apply to both
+// the sleep and the wake up, the namespace going to sleep on a day whose next
+// day is not in the weekdays wakes up only on the next of the weekdays, e.g.
+// from Wednesday night to Monday morning with the weekdays 1,3. The SleepInfo
+// with different weekdays for the sleep and the wake up are not reported,
+// since their long windows are explicit. It returns an empty string if there
+// is no such window.
+func getCrossMidnightIssue(sleepInfo v1alpha1.SleepInfo, now time.Time) string {
+	if !sleepInfo.IsCrossMidnight() || sleepInfo.HasWeekdaysPerOperation() {
+		return ""
+	}
+	location, err := time.LoadLocation(sleepInfo.Spec.TimeZone)
+	if err != nil {
+		return ""
+	}
+	sleepSchedule, err := sleepInfo.GetSleepSchedule()
+	if err != nil {
+		return ""
+	}
+	wakeUpSchedule, err := sleepInfo.GetWakeUpSchedule()
+	if err != nil {
+		return ""
+	}
+	operations, err := schedule.Preview(sleepSchedule, wakeUpSchedule, location, now, now.Add(crossMidnightLookAhead))
+	if err != nil {
+		return ""
+	}
+
+	for i := 0; i+1 < len(operations); i++ {
+		sleep, wakeUp := operations[i], operations[i+1]
+		if sleep.Type != schedule.Sleep || wakeUp.Time.Sub(sleep.Time) <= maxCrossMidnightWindow {
+			continue
+		}
+		return fmt.Sprintf("the sleep window crosses the midnight and the weekdays apply to both the sleep and the wake up: the sleep of %s lasts until %s", sleep.Time.Format("Monday 15:04"), wakeUp.Time.Format("Monday 15:04"))
+	}
+	return ""
+}
+
+// lintOverlaps returns the findings of the SleepInfo of the same namespace
+// managing the same workloads: the second one to sleep finds them already
+// asleep, and the workloads could not be restored as expected on wake up.
+// The exclusions are not considered, since they cannot be evaluated without
+// the workloads.
+func lintOverlaps(sleepInfos []v1alpha1.SleepInfo) []Finding {
+	sort.SliceStable(sleepInfos, func(i, j int) bool {
+		return getName(sleepInfos[i]) < getName(sleepInfos[j])
+	})
+	findings := []Finding{}
+	for i, sleepInfo := range sleepInfos {
+		for _, other := range sleepInfos[i+1:] {
+			if other.Namespace != sleepInfo.Namespace {
+				continue
+			}
+			if overlap := getOverlap(sleepInfo, other); overlap != "" {
+				findings = append(findings, Finding{
+					Severity:  SeverityWarning,
+					SleepInfo: getName(sleepInfo),
+					Message:   fmt.Sprintf("SleepInfo %s manages the same workloads: %s", other.Name, overlap),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// getOverlap returns the description of the workloads managed by both the
+// SleepInfo, i.e. all the workloads of the namespace if both have no classes,
+// or the workloads of the classes of one managed by the other. It returns an
+// empty string if they manage different workloads.
+func getOverlap(sleepInfo, other v1alpha1.SleepInfo) string {
+	if len(sleepInfo.Spec.Classes) == 0 && len(other.Spec.Classes) == 0 {
+		return "all the workloads of the namespace"
+	}
+	classes := []string{}
+	allClasses := append(append([]v1alpha1.SleepClass{}, sleepInfo.Spec.Classes...), other.Spec.Classes...)
+	for _, class := range allClasses {
+		labels := map[string]string{v1alpha1.SleepClassLabel: class.Name}
+		if sleepInfo.IsManagedByClass(labels) && other.IsManagedByClass(labels) {
+			classes = append(classes, class.Name)
+		}
+	}
+	if len(classes) == 0 {
+		return ""
+	}
+	sort.Strings(classes)
+	return fmt.Sprintf("the workloads of the classes %s", strings.Join(compact(classes), ", "))
+}
+
+func compact(sorted []string) []string {
+	result := []string{}
+	for i, value := range sorted {
+		if i == 0 || value != sorted[i-1] {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func getName(sleepInfo v1alpha1.SleepInfo) string {
+	if sleepInfo.Namespace == "" {
+		return sleepInfo.Name
+	}
+	return fmt.Sprintf("%s/%s", sleepInfo.Namespace, sleepInfo.Name)
+}
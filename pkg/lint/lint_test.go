@@ -0,0 +1,190 @@
+package lint
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kube-green/kube-green/api/v1alpha1"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLint(t *testing.T) {
+	// Monday
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	getSleepInfo := func(namespace, name string, spec v1alpha1.SleepInfoSpec) v1alpha1.SleepInfo {
+		if spec.Weekdays == "" {
+			spec.Weekdays = "*"
+		}
+		if spec.SleepTime == "" {
+			spec.SleepTime = "20:00"
+		}
+		if spec.TimeZone == "" {
+			spec.TimeZone = "Europe/Rome"
+		}
+		return v1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       spec,
+		}
+	}
+
+	tests := []struct {
+		name             string
+		sleepInfos       []v1alpha1.SleepInfo
+		opts             Options
+		expectedFindings []Finding
+	}{
+		{
+			name: "valid",
+			sleepInfos: []v1alpha1.SleepInfo{
+				getSleepInfo("ns", "working-hours", v1alpha1.SleepInfoSpec{Weekdays: "mon-fri", WakeUpTime: "08:00"}),
+				getSleepInfo("other-ns", "working-hours", v1alpha1.SleepInfoSpec{Weekdays: "mon-fri", WakeUpTime: "08:00"}),
+			},
+			expectedFindings: []Finding{},
+		},
+		{
+			name: "invalid schedule",
+			sleepInfos: []v1alpha1.SleepInfo{
+				getSleepInfo("ns", "sleepinfo", v1alpha1.SleepInfoSpec{SleepTime: "25:00"}),
+			},
+			expectedFindings: []Finding{
+				{Severity: SeverityError, SleepInfo: "ns/sleepinfo", Message: "end of range (25) above maximum (23): 25"},
+			},
+		},
+		{
+			name: "same sleep and wake up time",
+			sleepInfos: []v1alpha1.SleepInfo{
+				getSleepInfo("ns", "sleepinfo", v1alpha1.SleepInfoSpec{WakeUpTime: "20:00"}),
+			},
+			expectedFindings: []Finding{
+				{Severity: SeverityError, SleepInfo: "ns/sleepinfo", Message: "sleepAt and wakeUpAt must be different: the sleep window is ambiguous, actual: 20:00"},
+			},
+		},
+		{
+			name: "invalid time zone",
+			sleepInfos: []v1alpha1.SleepInfo{
+				getSleepInfo("ns", "sleepinfo", v1alpha1.SleepInfoSpec{TimeZone: "Europe/Atlantis"}),
+			},
+			expectedFindings: []Finding{
+				{Severity: SeverityError, SleepInfo: "ns/sleepinfo", Message: "timeZone Europe/Atlantis is invalid: unknown time zone Europe/Atlantis"},
+			},
+		},
+		{
+			name: "time zone not set",
+			sleepInfos: []v1alpha1.SleepInfo{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo"},
+					Spec:       v1alpha1.SleepInfoSpec{Weekdays: "*", SleepTime: "20:00"},
+				},
+			},
+			expectedFindings: []Finding{
+				{Severity: SeverityWarning, SleepInfo: "sleepinfo", Message: "timeZone is not set: the schedule depends on the default time zone of the controller, UTC if not configured"},
+			},
+		},
+		{
+			name: "time zone not set with the default time zone",
+			sleepInfos: []v1alpha1.SleepInfo{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "sleepinfo"},
+					Spec:       v1alpha1.SleepInfoSpec{Weekdays: "*", SleepTime: "20:00"},
+				},
+			},
+			opts:             Options{DefaultTimeZone: "Europe/Rome"},
+			expectedFindings: []Finding{},
+		},
+		{
+			name: "sleep window across the midnight on some weekdays",
+			sleepInfos: []v1alpha1.SleepInfo{
+				getSleepInfo("ns", "nights", v1alpha1.SleepInfoSpec{Weekdays: "1,3", SleepTime: "22:00", WakeUpTime: "06:00"}),
+			},
+			expectedFindings: []Finding{
+				{Severity: SeverityWarning, SleepInfo: "ns/nights", Message: "the sleep window crosses the midnight and the weekdays apply to both the sleep and the wake up: the sleep of Wednesday 22:00 lasts until Monday 06:00"},
+			},
+		},
+		{
+			name: "sleep window across the midnight in the weekend",
+			sleepInfos: []v1alpha1.SleepInfo{
+				getSleepInfo("ns", "nights", v1alpha1.SleepInfoSpec{Weekdays: "1-5", SleepTime: "22:00", WakeUpTime: "06:00"}),
+			},
+			expectedFindings: []Finding{},
+		},
+		{
+			name: "sleep window across the midnight with weekdays per operation",
+			sleepInfos: []v1alpha1.SleepInfo{
+				getSleepInfo("ns", "weekend", v1alpha1.SleepInfoSpec{SleepWeekdays: "3", WakeUpWeekdays: "1", SleepTime: "22:00", WakeUpTime: "06:00"}),
+			},
+			expectedFindings: []Finding{},
+		},
+		{
+			name: "sleep window across the midnight every day",
+			sleepInfos: []v1alpha1.SleepInfo{
+				getSleepInfo("ns", "nights", v1alpha1.SleepInfoSpec{SleepTime: "22:00", WakeUpTime: "06:00"}),
+			},
+			expectedFindings: []Finding{},
+		},
+		{
+			name: "overlapping SleepInfo",
+			sleepInfos: []v1alpha1.SleepInfo{
+				getSleepInfo("ns", "b", v1alpha1.SleepInfoSpec{}),
+				getSleepInfo("ns", "a", v1alpha1.SleepInfoSpec{}),
+				getSleepInfo("other-ns", "c", v1alpha1.SleepInfoSpec{}),
+			},
+			expectedFindings: []Finding{
+				{Severity: SeverityWarning, SleepInfo: "ns/a", Message: "SleepInfo b manages the same workloads: all the workloads of the namespace"},
+			},
+		},
+		{
+			name: "overlapping classes",
+			sleepInfos: []v1alpha1.SleepInfo{
+				getSleepInfo("ns", "web", v1alpha1.SleepInfoSpec{Classes: []v1alpha1.SleepClass{{Name: "web"}, {Name: "batch", Skip: true}}}),
+				getSleepInfo("ns", "batch", v1alpha1.SleepInfoSpec{Classes: []v1alpha1.SleepClass{{Name: "batch"}}}),
+				getSleepInfo("ns", "all", v1alpha1.SleepInfoSpec{}),
+			},
+			expectedFindings: []Finding{
+				{Severity: SeverityWarning, SleepInfo: "ns/all", Message: "SleepInfo batch manages the same workloads: the workloads of the classes batch"},
+				{Severity: SeverityWarning, SleepInfo: "ns/all", Message: "SleepInfo web manages the same workloads: the workloads of the classes web"},
+			},
+		},
+		{
+			name: "different classes",
+			sleepInfos: []v1alpha1.SleepInfo{
+				getSleepInfo("ns", "web", v1alpha1.SleepInfoSpec{Classes: []v1alpha1.SleepClass{{Name: "web"}}}),
+				getSleepInfo("ns", "batch", v1alpha1.SleepInfoSpec{Classes: []v1alpha1.SleepClass{{Name: "batch"}}}),
+			},
+			expectedFindings: []Finding{},
+		},
+		{
+			name: "invalid SleepInfo not checked for overlaps",
+			sleepInfos: []v1alpha1.SleepInfo{
+				getSleepInfo("ns", "a", v1alpha1.SleepInfoSpec{}),
+				getSleepInfo("ns", "b", v1alpha1.SleepInfoSpec{Preset: "unknown"}),
+			},
+			expectedFindings: []Finding{
+				{Severity: SeverityError, SleepInfo: "ns/b", Message: "preset unknown not supported: must be one of officeHours, extendedOfficeHours or nights"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := test.opts
+			opts.Now = now
+			findings := Lint(test.sleepInfos, opts)
+			require.Equal(t, test.expectedFindings, findings)
+			require.Equal(t, len(findings) > 0 && findings[0].Severity == SeverityError, HasErrors(findings))
+		})
+	}
+
+	t.Run("SleepInfo not modified", func(t *testing.T) {
+		sleepInfos := []v1alpha1.SleepInfo{getSleepInfo("ns", "sleepinfo", v1alpha1.SleepInfoSpec{Weekdays: "mon-fri"})}
+		Lint(sleepInfos, Options{Now: now})
+		require.Equal(t, "mon-fri", sleepInfos[0].Spec.Weekdays)
+		require.Nil(t, sleepInfos[0].Spec.SuspendDeployments)
+	})
+}
+
+func TestFindingString(t *testing.T) {
+	finding := Finding{Severity: SeverityWarning, SleepInfo: "ns/sleepinfo", Message: "timeZone is not set"}
+	require.Equal(t, "warning: ns/sleepinfo: timeZone is not set", finding.String())
+}
@@ -0,0 +1,120 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+)
+
+// State is the state of the schedule of a SleepInfo, between two operations.
+type State struct {
+	// CurrentOperationSchedule is the cron schedule of the operation to
+	// execute.
+	CurrentOperationSchedule string
+	// NextOperationSchedule is the cron schedule of the operation following
+	// the current one. It is the same as the current one, or empty, if the
+	// SleepInfo never wakes up.
+	NextOperationSchedule string
+	// LastSchedule is when the last operation has been executed. It is the
+	// zero time if no operation has been executed yet.
+	LastSchedule time.Time
+	// ExecuteMissedOperations is true if a missed operation is executed late
+	// while its window is still open. Otherwise, the missed operations are
+	// skipped until their next schedule.
+	ExecuteMissedOperations bool
+}
+
+// Result is the result of Next.
+type Result struct {
+	// IsToExecute is true if the current operation is to execute now.
+	IsToExecute bool
+	// ScheduledAt is when the current operation is scheduled.
+	ScheduledAt time.Time
+	// IsMissed is true if an operation has been missed, e.g. because the
+	// controller was not running, and it is not executed anymore since the
+	// following operation is already passed.
+	IsMissed bool
+	// NextSchedule is when the next operation is scheduled: the following
+	// operation if the current one is executed, otherwise the current one.
+	NextSchedule time.Time
+	// RequeueAfter is the time from now to the NextSchedule.
+	RequeueAfter time.Duration
+}
+
+// Next returns whether the current operation of the state is to execute at
+// now, and when the next operation is scheduled. The operations are executed
+// if now is within delta of their schedule. With ExecuteMissedOperations, a
+// missed operation is executed late if its window is still open, i.e. the
+// following operation is not passed yet.
+func Next(state State, now time.Time, delta time.Duration) (Result, error) {
+	sched, err := Parse(state.CurrentOperationSchedule)
+	if err != nil {
+		return Result{}, fmt.Errorf("current schedule not valid: %s", err)
+	}
+
+	lastSchedule := state.LastSchedule
+
+	// subtract delta because if now is after current schedule we skip the
+	// current schedule
+	var earliestTime = now.Add(-delta)
+	if !lastSchedule.IsZero() {
+		earliestTime = lastSchedule
+	}
+	result := Result{ScheduledAt: sched.Next(earliestTime)}
+
+	if earliestTime == lastSchedule && result.ScheduledAt.Before(now) && !IsTimeInDelta(result.ScheduledAt, now, delta) {
+		// the operation has been missed (e.g. the controller was not running).
+		// With ExecuteMissedOperations, if its window is still open, i.e. the
+		// following operation is not passed yet, it is executed now: otherwise,
+		// e.g. with a sleep at 22:00 and a wake up at 06:00, a sleep missed at
+		// 22:00 leaves the namespace awake across the midnight until the next
+		// night.
+		isInWindow := false
+		if state.ExecuteMissedOperations {
+			if isInWindow, err = isMissedOperationInWindow(state, result.ScheduledAt, now); err != nil {
+				return Result{}, err
+			}
+		}
+		result.IsToExecute = isInWindow
+		if !isInWindow {
+			result.IsMissed = true
+			result.ScheduledAt = sched.Next(now.Add(-delta))
+		}
+	}
+	result.IsToExecute = result.IsToExecute || IsTimeInDelta(now, result.ScheduledAt, delta)
+
+	result.NextSchedule = result.ScheduledAt
+	if result.IsToExecute {
+		if result.NextSchedule, err = NextAfterExecution(state.NextOperationSchedule, now, delta); err != nil {
+			return Result{}, err
+		}
+	}
+	result.RequeueAfter = result.NextSchedule.Sub(now)
+	return result, nil
+}
+
+// NextAfterExecution returns when the operation following an operation
+// executed at now is scheduled. The runs of nextOperationSchedule within
+// delta from now are skipped, since they are covered by the executed one.
+func NextAfterExecution(nextOperationSchedule string, now time.Time, delta time.Duration) (time.Time, error) {
+	nextOpSched, err := Parse(nextOperationSchedule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("next op schedule not valid: %s", err)
+	}
+	return nextOpSched.Next(now.Add(delta)), nil
+}
+
+// isMissedOperationInWindow returns true if the operation missed at
+// missedSchedule is still to execute at now, because the following operation
+// is not passed yet. The following operation is computed from the missed one,
+// so the windows across the midnight are handled. Without a wake up schedule,
+// the missed operations are never executed.
+func isMissedOperationInWindow(state State, missedSchedule, now time.Time) (bool, error) {
+	if state.NextOperationSchedule == "" || state.NextOperationSchedule == state.CurrentOperationSchedule {
+		return false, nil
+	}
+	nextOpSched, err := Parse(state.NextOperationSchedule)
+	if err != nil {
+		return false, fmt.Errorf("next op schedule not valid: %s", err)
+	}
+	return now.Before(nextOpSched.Next(missedSchedule)), nil
+}
@@ -0,0 +1,149 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNext(t *testing.T) {
+	delta := time.Minute
+	workingHours := State{
+		CurrentOperationSchedule: "00 20 * * 1-5",
+		NextOperationSchedule:    "00 08 * * 1-5",
+		LastSchedule:             time.Date(2021, 3, 23, 8, 0, 0, 0, time.UTC),
+	}
+
+	executeMissedOperations := workingHours
+	executeMissedOperations.ExecuteMissedOperations = true
+
+	tests := []struct {
+		name          string
+		state         State
+		now           time.Time
+		expected      Result
+		expectedError string
+	}{
+		{
+			name:  "not yet to execute",
+			state: workingHours,
+			now:   time.Date(2021, 3, 23, 19, 0, 0, 0, time.UTC),
+			expected: Result{
+				ScheduledAt:  time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC),
+				NextSchedule: time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC),
+				RequeueAfter: time.Hour,
+			},
+		},
+		{
+			name:  "to execute within the delta",
+			state: workingHours,
+			now:   time.Date(2021, 3, 23, 19, 59, 30, 0, time.UTC),
+			expected: Result{
+				IsToExecute:  true,
+				ScheduledAt:  time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC),
+				NextSchedule: time.Date(2021, 3, 24, 8, 0, 0, 0, time.UTC),
+				RequeueAfter: 12*time.Hour + 30*time.Second,
+			},
+		},
+		{
+			name:  "missed operation",
+			state: workingHours,
+			now:   time.Date(2021, 3, 23, 22, 0, 0, 0, time.UTC),
+			expected: Result{
+				IsMissed:     true,
+				ScheduledAt:  time.Date(2021, 3, 24, 20, 0, 0, 0, time.UTC),
+				NextSchedule: time.Date(2021, 3, 24, 20, 0, 0, 0, time.UTC),
+				RequeueAfter: 22 * time.Hour,
+			},
+		},
+		{
+			name:  "missed operation executed in its window",
+			state: executeMissedOperations,
+			now:   time.Date(2021, 3, 23, 22, 0, 0, 0, time.UTC),
+			expected: Result{
+				IsToExecute:  true,
+				ScheduledAt:  time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC),
+				NextSchedule: time.Date(2021, 3, 24, 8, 0, 0, 0, time.UTC),
+				RequeueAfter: 10 * time.Hour,
+			},
+		},
+		{
+			name:  "missed operation after its window",
+			state: executeMissedOperations,
+			now:   time.Date(2021, 3, 24, 9, 0, 0, 0, time.UTC),
+			expected: Result{
+				IsMissed:     true,
+				ScheduledAt:  time.Date(2021, 3, 24, 20, 0, 0, 0, time.UTC),
+				NextSchedule: time.Date(2021, 3, 24, 20, 0, 0, 0, time.UTC),
+				RequeueAfter: 11 * time.Hour,
+			},
+		},
+		{
+			name: "missed operation without wake up",
+			state: State{
+				CurrentOperationSchedule: "00 20 * * *",
+				NextOperationSchedule:    "00 20 * * *",
+				LastSchedule:             time.Date(2021, 3, 22, 20, 0, 0, 0, time.UTC),
+				ExecuteMissedOperations:  true,
+			},
+			now: time.Date(2021, 3, 23, 22, 0, 0, 0, time.UTC),
+			expected: Result{
+				IsMissed:     true,
+				ScheduledAt:  time.Date(2021, 3, 24, 20, 0, 0, 0, time.UTC),
+				NextSchedule: time.Date(2021, 3, 24, 20, 0, 0, 0, time.UTC),
+				RequeueAfter: 22 * time.Hour,
+			},
+		},
+		{
+			name: "first operation",
+			state: State{
+				CurrentOperationSchedule: "CRON_TZ=Europe/Rome 00 20 * * *",
+				NextOperationSchedule:    "CRON_TZ=Europe/Rome 00 08 * * *",
+			},
+			now: time.Date(2021, 3, 23, 18, 0, 0, 0, time.UTC),
+			expected: Result{
+				ScheduledAt:  time.Date(2021, 3, 23, 19, 0, 0, 0, time.UTC),
+				NextSchedule: time.Date(2021, 3, 23, 19, 0, 0, 0, time.UTC),
+				RequeueAfter: time.Hour,
+			},
+		},
+		{
+			name:          "invalid current schedule",
+			state:         State{CurrentOperationSchedule: "* *"},
+			expectedError: "current schedule not valid: expected exactly 5 fields, found 2: [* *]",
+		},
+		{
+			name: "invalid next schedule",
+			state: State{
+				CurrentOperationSchedule: "00 20 * * *",
+				NextOperationSchedule:    "* *",
+			},
+			now:           time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC),
+			expectedError: "next op schedule not valid: expected exactly 5 fields, found 2: [* *]",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			result, err := Next(test.state, test.now, delta)
+			if test.expectedError != "" {
+				require.EqualError(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected.IsToExecute, result.IsToExecute)
+			require.Equal(t, test.expected.IsMissed, result.IsMissed)
+			require.True(t, test.expected.ScheduledAt.Equal(result.ScheduledAt), "expected %s, got %s", test.expected.ScheduledAt, result.ScheduledAt)
+			require.True(t, test.expected.NextSchedule.Equal(result.NextSchedule), "expected %s, got %s", test.expected.NextSchedule, result.NextSchedule)
+			require.Equal(t, test.expected.RequeueAfter, result.RequeueAfter)
+		})
+	}
+}
+
+func TestNextAfterExecution(t *testing.T) {
+	next, err := NextAfterExecution("00 08 * * *", time.Date(2021, 3, 23, 7, 59, 30, 0, time.UTC), time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2021, 3, 24, 8, 0, 0, 0, time.UTC), next)
+}
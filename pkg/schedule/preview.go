@@ -0,0 +1,96 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+)
+
+// previewLookBehind is how far before the start of the preview the operations
+// are simulated, to know if the namespace is sleeping when the preview starts.
+// The schedules repeat every week, or every month with the L and # weekdays,
+// so a month is enough.
+const previewLookBehind = 31 * 24 * time.Hour
+
+// Operation is an operation computed by Preview.
+type Operation struct {
+	// Type is Sleep or WakeUp.
+	Type string
+	// Time is when the operation is performed, in the location of the preview.
+	Time time.Time
+}
+
+// Preview simulates the operations of the sleep and wake up schedules between
+// from and until, in location. Without a wake up schedule, only the sleeps
+// are returned.
+func Preview(sleepSchedule, wakeUpSchedule string, location *time.Location, from, until time.Time) ([]Operation, error) {
+	sleepSched, err := Parse(sleepSchedule)
+	if err != nil {
+		return nil, fmt.Errorf("sleep schedule not valid: %s", err)
+	}
+	currentSched, nextSched := sleepSched, sleepSched
+	if wakeUpSchedule != "" {
+		if nextSched, err = Parse(wakeUpSchedule); err != nil {
+			return nil, fmt.Errorf("wake up schedule not valid: %s", err)
+		}
+	}
+
+	operations := []Operation{}
+	operationType := Sleep
+	t := from.In(location).Add(-previewLookBehind)
+	for {
+		t = currentSched.Next(t)
+		if t.IsZero() || !t.Before(until) {
+			return operations, nil
+		}
+		if !t.Before(from) {
+			operations = append(operations, Operation{Type: operationType, Time: t})
+		}
+		if wakeUpSchedule != "" {
+			currentSched, nextSched = nextSched, currentSched
+			operationType = NextOperationType(operationType)
+		}
+	}
+}
+
+// CheckAlternation returns an error if the operations of the sleep and the
+// wake up schedules between from and until do not alternate, i.e. if two
+// sleeps are not separated by a wake up, or two wake ups by a sleep, or if a
+// sleep and a wake up are at the same time. The schedules alternate by
+// construction if they share the weekdays, but not if their weekdays differ.
+func CheckAlternation(sleepSchedule, wakeUpSchedule string, from, until time.Time) error {
+	sleepSched, err := Parse(sleepSchedule)
+	if err != nil {
+		return fmt.Errorf("sleep schedule not valid: %s", err)
+	}
+	wakeUpSched, err := Parse(wakeUpSchedule)
+	if err != nil {
+		return fmt.Errorf("wake up schedule not valid: %s", err)
+	}
+
+	var last Operation
+	sleepAt, wakeUpAt := sleepSched.Next(from), wakeUpSched.Next(from)
+	for !sleepAt.IsZero() && !wakeUpAt.IsZero() && (sleepAt.Before(until) || wakeUpAt.Before(until)) {
+		if sleepAt.Equal(wakeUpAt) {
+			return fmt.Errorf("the sleep and the wake up are both at %s", sleepAt.Format(time.RFC3339))
+		}
+		current := Operation{Type: Sleep, Time: sleepAt}
+		if wakeUpAt.Before(sleepAt) {
+			current = Operation{Type: WakeUp, Time: wakeUpAt}
+			wakeUpAt = wakeUpSched.Next(wakeUpAt)
+		} else {
+			sleepAt = sleepSched.Next(sleepAt)
+		}
+		if last.Type == current.Type {
+			return fmt.Errorf("the %s at %s is followed by another %s at %s", getOperationName(current.Type), last.Time.Format(time.RFC3339), getOperationName(current.Type), current.Time.Format(time.RFC3339))
+		}
+		last = current
+	}
+	return nil
+}
+
+func getOperationName(operationType string) string {
+	if operationType == Sleep {
+		return "sleep"
+	}
+	return "wake up"
+}
@@ -0,0 +1,70 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreview(t *testing.T) {
+	from := time.Date(2021, 3, 23, 12, 0, 0, 0, time.UTC)
+
+	t.Run("sleep and wake up", func(t *testing.T) {
+		operations, err := Preview("00 20 * * *", "00 08 * * *", time.UTC, from, from.Add(24*time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, []Operation{
+			{Type: Sleep, Time: time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)},
+			{Type: WakeUp, Time: time.Date(2021, 3, 24, 8, 0, 0, 0, time.UTC)},
+		}, operations)
+	})
+
+	t.Run("only sleep", func(t *testing.T) {
+		operations, err := Preview("00 20 * * *", "", time.UTC, from, from.Add(48*time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, []Operation{
+			{Type: Sleep, Time: time.Date(2021, 3, 23, 20, 0, 0, 0, time.UTC)},
+			{Type: Sleep, Time: time.Date(2021, 3, 24, 20, 0, 0, 0, time.UTC)},
+		}, operations)
+	})
+
+	t.Run("invalid schedule", func(t *testing.T) {
+		_, err := Preview("00 20 * * *", "* *", time.UTC, from, from.Add(24*time.Hour))
+		require.EqualError(t, err, "wake up schedule not valid: expected exactly 5 fields, found 2: [* *]")
+	})
+}
+
+func TestNextOperationType(t *testing.T) {
+	require.Equal(t, WakeUp, NextOperationType(Sleep))
+	require.Equal(t, Sleep, NextOperationType(WakeUp))
+}
+
+func TestCheckAlternation(t *testing.T) {
+	from := time.Date(2021, 3, 22, 0, 0, 0, 0, time.UTC)
+	until := from.Add(35 * 24 * time.Hour)
+
+	t.Run("sleep on friday and wake up on monday", func(t *testing.T) {
+		require.NoError(t, CheckAlternation("00 20 * * 5", "00 08 * * 1", from, until))
+	})
+
+	t.Run("same weekdays", func(t *testing.T) {
+		require.NoError(t, CheckAlternation("CRON_TZ=Europe/Rome 00 20 * * 1-5", "CRON_TZ=Europe/Rome 00 08 * * 1-5", from, until))
+	})
+
+	t.Run("last friday of the month", func(t *testing.T) {
+		require.NoError(t, CheckAlternation("00 20 * * 5L", "00 08 * * 1#1", from, until))
+		require.EqualError(t, CheckAlternation("00 20 * * 5", "00 08 * * 1#1", from, until), "the sleep at 2021-03-26T20:00:00Z is followed by another sleep at 2021-04-02T20:00:00Z")
+	})
+
+	t.Run("two sleeps without a wake up", func(t *testing.T) {
+		require.EqualError(t, CheckAlternation("00 20 * * 1-5", "00 08 * * 1", from, until), "the sleep at 2021-03-22T20:00:00Z is followed by another sleep at 2021-03-23T20:00:00Z")
+	})
+
+	t.Run("sleep and wake up at the same time", func(t *testing.T) {
+		require.EqualError(t, CheckAlternation("00 20 * * 1-5", "00 20 * * 1", from, until), "the sleep and the wake up are both at 2021-03-22T20:00:00Z")
+	})
+
+	t.Run("invalid schedule", func(t *testing.T) {
+		require.EqualError(t, CheckAlternation("00 20 * * 5", "* *", from, until), "wake up schedule not valid: expected exactly 5 fields, found 2: [* *]")
+	})
+}
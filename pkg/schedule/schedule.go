@@ -0,0 +1,164 @@
+// Package schedule computes the operations of the SleepInfo from their cron
+// schedules. It is used by the controller to execute the operations, and it
+// can be used by other tools to compute them the same way.
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// Sleep is the type of the sleep operations.
+	Sleep = "SLEEP"
+	// WakeUp is the type of the wake up operations.
+	WakeUp = "WAKE_UP"
+)
+
+// Parse parses a standard cron schedule, optionally prefixed by CRON_TZ. The
+// returned schedule handles the DST changes of its time zone: a run whose
+// local time is skipped when the clock is moved forward is moved to the first
+// valid instant after the change, and a run whose local time is repeated when
+// the clock is moved back is executed only the first time.
+//
+// Besides the standard expressions, the day of the week accepts the L and #
+// expressions: 5L is the last Friday of the month, and 1#2 is the second
+// Monday of the month.
+func Parse(schedule string) (cron.Schedule, error) {
+	timeZone, fields := splitSchedule(schedule)
+	//nolint:gomnd
+	if len(fields) == 5 && isWeekdayExpression(fields[4]) {
+		return parseWeekdaySchedule(timeZone, fields)
+	}
+	sched, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return nil, err
+	}
+	if specSchedule, ok := sched.(*cron.SpecSchedule); ok {
+		return dstSafeSchedule{SpecSchedule: specSchedule}, nil
+	}
+	return sched, nil
+}
+
+// splitSchedule returns the CRON_TZ or TZ prefix of the schedule, if any, and
+// its fields.
+func splitSchedule(schedule string) (string, []string) {
+	fields := strings.Fields(schedule)
+	if len(fields) > 0 && (strings.HasPrefix(fields[0], "CRON_TZ=") || strings.HasPrefix(fields[0], "TZ=")) {
+		return fields[0], fields[1:]
+	}
+	return "", fields
+}
+
+// adjustedSchedule is a schedule which reports how the DST changes move its
+// runs.
+type adjustedSchedule interface {
+	nextWithAdjustment(t time.Time) (time.Time, string)
+}
+
+// DSTAdjustment returns the description of how the DST change moves the next
+// run of the schedule after t. It returns an empty string if the next run is
+// not moved.
+func DSTAdjustment(schedule string, t time.Time) string {
+	sched, err := Parse(schedule)
+	if err != nil {
+		return ""
+	}
+	adjustedSched, ok := sched.(adjustedSchedule)
+	if !ok {
+		return ""
+	}
+	_, adjustment := adjustedSched.nextWithAdjustment(t)
+	return adjustment
+}
+
+// NextOperationType returns the type of the operation following the one of
+// type operationType.
+func NextOperationType(operationType string) string {
+	if operationType == Sleep {
+		return WakeUp
+	}
+	return Sleep
+}
+
+// IsTimeInDelta returns true if t1 and t2 are within delta, with the
+// millisecond precision.
+func IsTimeInDelta(t1, t2 time.Time, delta time.Duration) bool {
+	var diffInMs int64
+	if t1.Before(t2) {
+		diffInMs = t2.Sub(t1).Milliseconds()
+	} else {
+		diffInMs = t1.Sub(t2).Milliseconds()
+	}
+	return diffInMs <= delta.Milliseconds()
+}
+
+// dstSafeSchedule handles the DST changes of the time zone of the schedule.
+// The cron library never runs a schedule whose local time is skipped when
+// the clock is moved forward, and runs twice a schedule whose local time is
+// repeated when the clock is moved back. Instead, a skipped run is moved to
+// the first valid instant after the change, and a repeated run is executed
+// only the first time.
+type dstSafeSchedule struct {
+	*cron.SpecSchedule
+}
+
+func (s dstSafeSchedule) Next(t time.Time) time.Time {
+	next, _ := s.nextWithAdjustment(t)
+	return next
+}
+
+// nextWithAdjustment returns the next run after t and, if it is moved by a
+// DST change, the description of the change.
+func (s dstSafeSchedule) nextWithAdjustment(t time.Time) (time.Time, string) {
+	next := s.SpecSchedule.Next(t)
+	if next.IsZero() {
+		return next, ""
+	}
+	// as the cron library, the returned time is in the location of t.
+	location := s.Location
+	if location == time.Local {
+		location = t.Location()
+	}
+
+	current := t.In(location)
+	for {
+		_, end := current.ZoneBounds()
+		if end.IsZero() || end.After(next) {
+			break
+		}
+		_, offsetBefore := current.Zone()
+		_, offsetAfter := end.Zone()
+		if offsetAfter > offsetBefore {
+			// the clock is moved forward: the local times between end and
+			// end+skipped, with the offset before the change, do not exist.
+			skipped := time.Duration(offsetAfter-offsetBefore) * time.Second
+			beforeChange := *s.SpecSchedule
+			beforeChange.Location = time.FixedZone("", offsetBefore)
+			scheduled := beforeChange.Next(end.Add(-time.Second))
+			if scheduled.Before(end.Add(skipped)) {
+				return end.In(t.Location()), fmt.Sprintf("%s is skipped by the DST change, moved to %s", scheduled.In(beforeChange.Location).Format("2006-01-02 15:04"), end.Format("2006-01-02 15:04 MST"))
+			}
+		}
+		current = end
+	}
+
+	localNext := next.In(location)
+	start, _ := localNext.ZoneBounds()
+	if !start.IsZero() {
+		_, offsetAfter := localNext.Zone()
+		_, offsetBefore := start.Add(-time.Second).Zone()
+		repeated := time.Duration(offsetBefore-offsetAfter) * time.Second
+		if repeated > 0 && localNext.Sub(start) < repeated {
+			// the clock is moved back and the local time of next has already
+			// been passed before the change, at first.
+			first := localNext.Add(-repeated)
+			following, _ := s.nextWithAdjustment(next)
+			return following, fmt.Sprintf("%s is repeated by the DST change, executed only at %s", localNext.Format("2006-01-02 15:04"), first.Format("2006-01-02 15:04 MST"))
+		}
+	}
+	return next, ""
+}
@@ -0,0 +1,150 @@
+package schedule
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name               string
+		schedule           string
+		from               time.Time
+		expected           []time.Time
+		expectedAdjustment string
+	}{
+		{
+			name:     "time skipped by the DST change runs at the first valid instant",
+			schedule: "CRON_TZ=Europe/Rome 30 2 * * *",
+			from:     time.Date(2021, 3, 27, 3, 0, 0, 0, rome),
+			expected: []time.Time{
+				time.Date(2021, 3, 28, 3, 0, 0, 0, rome),
+				time.Date(2021, 3, 29, 2, 30, 0, 0, rome),
+			},
+			expectedAdjustment: "2021-03-28 02:30 is skipped by the DST change, moved to 2021-03-28 03:00 CEST",
+		},
+		{
+			name:     "time repeated by the DST change runs only once",
+			schedule: "CRON_TZ=Europe/Rome 30 2 * * *",
+			from:     time.Date(2021, 10, 30, 3, 0, 0, 0, rome),
+			expected: []time.Time{
+				time.Date(2021, 10, 31, 0, 30, 0, 0, time.UTC),
+				time.Date(2021, 11, 1, 2, 30, 0, 0, rome),
+			},
+		},
+		{
+			name:     "hourly schedule does not run twice in the repeated hour",
+			schedule: "CRON_TZ=Europe/Rome 30 * * * *",
+			from:     time.Date(2021, 10, 31, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2021, 10, 31, 0, 30, 0, 0, time.UTC),
+				time.Date(2021, 10, 31, 2, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "schedule not affected by the DST change",
+			schedule: "CRON_TZ=Europe/Rome 00 20 * * *",
+			from:     time.Date(2021, 3, 27, 21, 0, 0, 0, rome),
+			expected: []time.Time{
+				time.Date(2021, 3, 28, 20, 0, 0, 0, rome),
+				time.Date(2021, 3, 29, 20, 0, 0, 0, rome),
+			},
+		},
+		{
+			name:     "schedule without time zone",
+			schedule: "30 2 * * *",
+			from:     time.Date(2021, 3, 28, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2021, 3, 28, 2, 30, 0, 0, time.UTC),
+				time.Date(2021, 3, 29, 2, 30, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			sched, err := Parse(test.schedule)
+			require.NoError(t, err)
+
+			require.Equal(t, test.expectedAdjustment, DSTAdjustment(test.schedule, test.from))
+			next := test.from
+			for _, expected := range test.expected {
+				next = sched.Next(next)
+				require.True(t, expected.Equal(next), "expected %s, got %s", expected, next)
+			}
+		})
+	}
+
+	t.Run("repeated time adjustment", func(t *testing.T) {
+		adjustment := DSTAdjustment("CRON_TZ=Europe/Rome 30 2 * * *", time.Date(2021, 10, 31, 0, 30, 0, 0, time.UTC))
+		require.Equal(t, "2021-10-31 02:30 is repeated by the DST change, executed only at 2021-10-31 02:30 CEST", adjustment)
+	})
+}
+
+func TestIsTimeInDelta(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		t1       time.Time
+		t2       time.Time
+		expected bool
+		delta    time.Duration
+	}{
+		{
+			name:     "t1 > t2 30s - delta 60s",
+			t1:       now,
+			t2:       now.Add(60 * time.Second),
+			delta:    time.Second * 60,
+			expected: true,
+		},
+		{
+			name:     "t1 > t2 1ms - delta 1ms",
+			t1:       now,
+			t2:       now.Add(1 * time.Millisecond),
+			delta:    time.Millisecond * 1,
+			expected: true,
+		},
+		{
+			name:     "t1 > t2 31s - delta 30s",
+			t1:       now,
+			t2:       now.Add(31 * time.Second),
+			delta:    time.Second * 30,
+			expected: false,
+		},
+		{
+			name:     "t1 > t2 30s - delta 60s",
+			t1:       now.Add(60 * time.Second),
+			t2:       now,
+			delta:    time.Second * 60,
+			expected: true,
+		},
+		{
+			name:     "t1 < t2 31s - delta 30s",
+			t1:       now.Add(31 * time.Second),
+			t2:       now,
+			delta:    time.Second * 30,
+			expected: false,
+		},
+		{
+			name:     "t1 > t2 1s - delta 1s",
+			t1:       now.Add(1 * time.Second),
+			t2:       now,
+			delta:    time.Second * 1,
+			expected: true,
+		},
+	}
+	for _, test := range tests {
+		test := test // necessary to ensure the correct value is passed to the closure
+		t.Run(fmt.Sprintf("name, %s", test.name), func(t *testing.T) {
+			output := IsTimeInDelta(test.t1, test.t2, test.delta)
+			require.Equal(t, test.expected, output)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package schedule
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// maxSkippedDays is how many days the weekday schedules search for a matching
+// day before giving up, as the cron library does for the schedules which
+// never run, e.g. the 5th Monday of February.
+const maxSkippedDays = 5 * 366
+
+// weekdaySchedule is a schedule whose day of the week has the L and #
+// expressions, not supported by the cron library: 5L is the last Friday of
+// the month, and 1#2 is the second Monday of the month. They can be listed
+// with the standard expressions, e.g. 1-4,5L.
+type weekdaySchedule struct {
+	// schedule is the schedule on every day of the week.
+	schedule dstSafeSchedule
+	// dow are the days of the week matched by the standard expressions, as
+	// the bits of the cron library.
+	dow uint64
+	// last are the days of the week matched only the last time in the month.
+	last []time.Weekday
+	// nth are the days of the week matched only the nth time in the month.
+	nth []nthWeekday
+}
+
+type nthWeekday struct {
+	weekday time.Weekday
+	n       int
+}
+
+// isWeekdayExpression returns true if the day of the week uses the L or #
+// expressions.
+func isWeekdayExpression(dow string) bool {
+	return strings.ContainsAny(dow, "L#")
+}
+
+// parseWeekdaySchedule parses a cron schedule, optionally prefixed by CRON_TZ,
+// whose day of the week uses the L or # expressions. The day of the month
+// must be *, since it would be ambiguous whether the day has to match both
+// or only one of them.
+func parseWeekdaySchedule(timeZone string, fields []string) (cron.Schedule, error) {
+	if fields[2] != "*" {
+		return nil, fmt.Errorf("day of month must be * with the L and # day of week expressions, actual: %s", fields[2])
+	}
+	everyDay := strings.Join(append(fields[:4:4], "*"), " ")
+	if timeZone != "" {
+		everyDay = fmt.Sprintf("%s %s", timeZone, everyDay)
+	}
+	sched, err := cron.ParseStandard(everyDay)
+	if err != nil {
+		return nil, err
+	}
+	specSchedule, ok := sched.(*cron.SpecSchedule)
+	if !ok {
+		return nil, fmt.Errorf("unsupported schedule: %s", everyDay)
+	}
+
+	weekdaySched := weekdaySchedule{schedule: dstSafeSchedule{SpecSchedule: specSchedule}}
+	for _, expression := range strings.Split(fields[4], ",") {
+		switch {
+		case strings.HasSuffix(expression, "L"):
+			weekday, err := parseWeekday(strings.TrimSuffix(expression, "L"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid day of week %s: %s", expression, err)
+			}
+			weekdaySched.last = append(weekdaySched.last, weekday)
+		case strings.Contains(expression, "#"):
+			weekdayAndN := strings.SplitN(expression, "#", 2)
+			weekday, err := parseWeekday(weekdayAndN[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid day of week %s: %s", expression, err)
+			}
+			n, err := strconv.Atoi(weekdayAndN[1])
+			//nolint:gomnd
+			if err != nil || n < 1 || n > 5 {
+				return nil, fmt.Errorf("invalid day of week %s: the occurrence in the month must be from 1 to 5", expression)
+			}
+			weekdaySched.nth = append(weekdaySched.nth, nthWeekday{weekday: weekday, n: n})
+		default:
+			dow, err := parseDow(expression)
+			if err != nil {
+				return nil, fmt.Errorf("invalid day of week %s: %s", expression, err)
+			}
+			weekdaySched.dow |= dow
+		}
+	}
+	return weekdaySched, nil
+}
+
+// parseDow returns the days of the week matched by a standard cron
+// expression, as the bits of the cron library.
+func parseDow(expression string) (uint64, error) {
+	sched, err := cron.ParseStandard(fmt.Sprintf("0 0 * * %s", expression))
+	if err != nil {
+		return 0, err
+	}
+	specSchedule, ok := sched.(*cron.SpecSchedule)
+	if !ok {
+		return 0, fmt.Errorf("unsupported expression")
+	}
+	// the bit of the star is set if the expression is *.
+	return specSchedule.Dow &^ (1 << 63), nil
+}
+
+// parseWeekday parses a single day of the week, as a number (0 or 7 is
+// Sunday) or a name.
+func parseWeekday(expression string) (time.Weekday, error) {
+	if expression == "" {
+		return 0, fmt.Errorf("L and # must follow a day of the week")
+	}
+	dow, err := parseDow(expression)
+	if err != nil {
+		return 0, err
+	}
+	if bits.OnesCount64(dow) != 1 {
+		return 0, fmt.Errorf("L and # must follow a single day of the week")
+	}
+	return time.Weekday(bits.TrailingZeros64(dow)), nil
+}
+
+func (s weekdaySchedule) Next(t time.Time) time.Time {
+	next, _ := s.nextWithAdjustment(t)
+	return next
+}
+
+// nextWithAdjustment returns the next run after t on a matching day and, if
+// it is moved by a DST change, the description of the change. The runs on
+// the days not matching are skipped a day at a time.
+func (s weekdaySchedule) nextWithAdjustment(t time.Time) (time.Time, string) {
+	location := s.schedule.Location
+	if location == time.Local {
+		location = t.Location()
+	}
+	for i := 0; i < maxSkippedDays; i++ {
+		next, adjustment := s.schedule.nextWithAdjustment(t)
+		if next.IsZero() {
+			return next, ""
+		}
+		localNext := next.In(location)
+		if s.isDayMatched(localNext) {
+			return next, adjustment
+		}
+		year, month, day := localNext.Date()
+		t = time.Date(year, month, day+1, 0, 0, 0, 0, location).Add(-time.Second)
+	}
+	return time.Time{}, ""
+}
+
+// isDayMatched returns true if the day of t matches the day of the week of
+// the schedule.
+func (s weekdaySchedule) isDayMatched(t time.Time) bool {
+	weekday := t.Weekday()
+	if s.dow&(1<<uint(weekday)) != 0 {
+		return true
+	}
+	for _, last := range s.last {
+		if weekday == last && t.AddDate(0, 0, 7).Month() != t.Month() {
+			return true
+		}
+	}
+	for _, nth := range s.nth {
+		if weekday == nth.weekday && (t.Day()-1)/7+1 == nth.n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWeekdayExpressions(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name               string
+		schedule           string
+		from               time.Time
+		expected           []time.Time
+		expectedAdjustment string
+	}{
+		{
+			name:     "last weekday of the month",
+			schedule: "00 20 * * 5L",
+			from:     time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2021, 10, 29, 20, 0, 0, 0, time.UTC),
+				time.Date(2021, 11, 26, 20, 0, 0, 0, time.UTC),
+				time.Date(2021, 12, 31, 20, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "nth weekday of the month",
+			schedule: "00 20 * * 1#2",
+			from:     time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2021, 10, 11, 20, 0, 0, 0, time.UTC),
+				time.Date(2021, 11, 8, 20, 0, 0, 0, time.UTC),
+				time.Date(2021, 12, 13, 20, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "listed with the standard expressions",
+			schedule: "00 20 * * 1-4,5L",
+			from:     time.Date(2021, 10, 27, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2021, 10, 27, 20, 0, 0, 0, time.UTC),
+				time.Date(2021, 10, 28, 20, 0, 0, 0, time.UTC),
+				time.Date(2021, 10, 29, 20, 0, 0, 0, time.UTC),
+				time.Date(2021, 11, 1, 20, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "months without the nth weekday are skipped",
+			schedule: "00 20 * * 1#5",
+			from:     time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2021, 3, 29, 20, 0, 0, 0, time.UTC),
+				time.Date(2021, 5, 31, 20, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "weekday name",
+			schedule: "00 20 * * FRI#1",
+			from:     time.Date(2021, 10, 2, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2021, 11, 5, 20, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "many runs in the matching day",
+			schedule: "00 */12 * * 5L",
+			from:     time.Date(2021, 10, 29, 1, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2021, 10, 29, 12, 0, 0, 0, time.UTC),
+				time.Date(2021, 11, 26, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "time skipped by the DST change runs at the first valid instant",
+			schedule: "CRON_TZ=Europe/Rome 30 2 * * 0L",
+			from:     time.Date(2021, 3, 1, 0, 0, 0, 0, rome),
+			expected: []time.Time{
+				time.Date(2021, 3, 28, 3, 0, 0, 0, rome),
+				time.Date(2021, 4, 25, 2, 30, 0, 0, rome),
+			},
+			expectedAdjustment: "2021-03-28 02:30 is skipped by the DST change, moved to 2021-03-28 03:00 CEST",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			sched, err := Parse(test.schedule)
+			require.NoError(t, err)
+
+			require.Equal(t, test.expectedAdjustment, DSTAdjustment(test.schedule, test.from))
+			next := test.from
+			for _, expected := range test.expected {
+				next = sched.Next(next)
+				require.True(t, expected.Equal(next), "expected %s, got %s", expected, next)
+			}
+		})
+	}
+
+	t.Run("invalid expressions", func(t *testing.T) {
+		for schedule, expectedError := range map[string]string{
+			"00 20 1 * 5L":    "day of month must be * with the L and # day of week expressions, actual: 1",
+			"00 20 * * L":     "invalid day of week L: L and # must follow a day of the week",
+			"00 20 * * 1-5L":  "invalid day of week 1-5L: L and # must follow a single day of the week",
+			"00 20 * * 1#0":   "invalid day of week 1#0: the occurrence in the month must be from 1 to 5",
+			"00 20 * * 1#one": "invalid day of week 1#one: the occurrence in the month must be from 1 to 5",
+		} {
+			_, err := Parse(schedule)
+			require.EqualError(t, err, expectedError, schedule)
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package testutil
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a fake clock, whose time is set and advanced by the tests, e.g.
+// to the time of the next sleep. It is safe for concurrent use.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock returns a Clock at the given time.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns the current time of the Clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set sets the current time of the Clock.
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Advance moves the current time of the Clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
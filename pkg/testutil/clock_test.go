@@ -0,0 +1,21 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClock(t *testing.T) {
+	now := time.Date(2024, 3, 4, 19, 59, 0, 0, time.UTC)
+	clock := NewClock(now)
+	require.Equal(t, now, clock.Now())
+
+	clock.Advance(2 * time.Minute)
+	require.Equal(t, now.Add(2*time.Minute), clock.Now())
+
+	wakeUp := time.Date(2024, 3, 5, 8, 0, 0, 0, time.UTC)
+	clock.Set(wakeUp)
+	require.Equal(t, wakeUp, clock.Now())
+}
@@ -0,0 +1,89 @@
+// Package testutil helps the platform teams to test their own handlers and
+// plugins against the semantics of kube-green: it starts a control plane with
+// envtest, reconciles the SleepInfo with a fake clock and checks whether the
+// namespaces are asleep or awake.
+package testutil
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+// Environment is a control plane started with envtest, with the CRDs of
+// kube-green installed.
+type Environment struct {
+	// Config is the config of the API server.
+	Config *rest.Config
+	// Client is a client of the API server, not cached, whose scheme includes
+	// the kube-green types.
+	Client client.Client
+
+	testEnv *envtest.Environment
+}
+
+// EnvironmentOptions configures the Environment.
+type EnvironmentOptions struct {
+	// CRDDirectoryPaths are the directories of other CRDs to install, e.g.
+	// the CRDs of the custom resources handled by the tested plugin.
+	CRDDirectoryPaths []string
+	// KubeGreenCRDPath is the directory of the CRDs of kube-green. If empty,
+	// it is the config/crd/bases directory of the kube-green module, found
+	// from the source of this package, e.g. in the module cache.
+	KubeGreenCRDPath string
+}
+
+// StartEnvironment starts a control plane with envtest and installs the
+// CRDs. The binaries of the control plane are found as configured for
+// envtest, e.g. in the KUBEBUILDER_ASSETS directory set with setup-envtest.
+func StartEnvironment(opts EnvironmentOptions) (*Environment, error) {
+	kubeGreenCRDPath := opts.KubeGreenCRDPath
+	if kubeGreenCRDPath == "" {
+		_, file, _, ok := runtime.Caller(0)
+		if !ok {
+			return nil, fmt.Errorf("fails to find the CRDs of kube-green: set the KubeGreenCRDPath")
+		}
+		kubeGreenCRDPath = filepath.Join(filepath.Dir(file), "..", "..", "config", "crd", "bases")
+	}
+
+	scheme := k8sruntime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := kubegreenv1alpha1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths:     append([]string{kubeGreenCRDPath}, opts.CRDDirectoryPaths...),
+		ErrorIfCRDPathMissing: true,
+		Scheme:                scheme,
+	}
+	cfg, err := testEnv.Start()
+	if err != nil {
+		return nil, fmt.Errorf("fails to start the environment: %s", err)
+	}
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		_ = testEnv.Stop()
+		return nil, fmt.Errorf("fails to create the client: %s", err)
+	}
+	return &Environment{
+		Config:  cfg,
+		Client:  c,
+		testEnv: testEnv,
+	}, nil
+}
+
+// Stop stops the control plane.
+func (e *Environment) Stop() error {
+	return e.testEnv.Stop()
+}
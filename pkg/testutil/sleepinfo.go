@@ -0,0 +1,147 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	sleepinfocontroller "github.com/kube-green/kube-green/controllers/sleepinfo"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/metrics"
+	"github.com/kube-green/kube-green/controllers/sleepinfo/resource"
+	"github.com/kube-green/kube-green/pkg/schedule"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewReconciler returns a SleepInfo reconciler with the given client, whose
+// current time is the time of the clock. The optional integrations of the
+// controller are disabled, and can be set on the returned reconciler.
+func NewReconciler(c client.Client, clock *Clock) *sleepinfocontroller.SleepInfoReconciler {
+	return &sleepinfocontroller.SleepInfoReconciler{
+		Client:     c,
+		Log:        logr.Discard(),
+		Scheme:     c.Scheme(),
+		Metrics:    metrics.SetupMetricsOrDie("kube_green"),
+		SleepDelta: 60,
+		Clock:      clock,
+	}
+}
+
+// ReconcileSleepInfo reconciles the SleepInfo once, as the controller does
+// when it changes and when its requeue expires.
+func ReconcileSleepInfo(ctx context.Context, r *sleepinfocontroller.SleepInfoReconciler, key client.ObjectKey) (ctrl.Result, error) {
+	return r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+}
+
+// CreateSleepInfo creates the SleepInfo, and its namespace if it does not
+// exist.
+func CreateSleepInfo(ctx context.Context, c client.Client, sleepInfo *kubegreenv1alpha1.SleepInfo) error {
+	namespace := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: sleepInfo.Namespace}}
+	if err := c.Create(ctx, namespace); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("fails to create namespace %s: %s", sleepInfo.Namespace, err)
+	}
+	if err := c.Create(ctx, sleepInfo); err != nil {
+		return fmt.Errorf("fails to create sleepinfo %s: %s", sleepInfo.Name, err)
+	}
+	return nil
+}
+
+// CheckAsleep returns nil if the namespace of the SleepInfo is asleep: the
+// last operation of the SleepInfo is a sleep, and the Deployments put to
+// sleep by it are scaled down. Otherwise, it returns an error which explains
+// why the namespace is not asleep.
+func CheckAsleep(ctx context.Context, c client.Client, key client.ObjectKey) error {
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{}
+	if err := c.Get(ctx, key, sleepInfo); err != nil {
+		return fmt.Errorf("fails to get sleepinfo %s: %s", key, err)
+	}
+	if sleepInfo.Status.OperationType != schedule.Sleep {
+		return fmt.Errorf("namespace %s not asleep: last operation of sleepinfo %s is %q", key.Namespace, key.Name, sleepInfo.Status.OperationType)
+	}
+	if !sleepInfo.IsDeploymentsToSuspend() {
+		return nil
+	}
+
+	deployments := appsv1.DeploymentList{}
+	if err := c.List(ctx, &deployments, client.InNamespace(key.Namespace)); err != nil {
+		return fmt.Errorf("fails to list the deployments: %s", err)
+	}
+	for _, deployment := range deployments.Items {
+		deployment := deployment
+		expectedReplicas, ok := getSleepReplicas(*sleepInfo, &deployment)
+		if !ok {
+			continue
+		}
+		if replicas := getReplicas(deployment); replicas > expectedReplicas {
+			return fmt.Errorf("namespace %s not asleep: deployment %s has %d replicas, expected %d", key.Namespace, deployment.Name, replicas, expectedReplicas)
+		}
+	}
+	return nil
+}
+
+// CheckAwake returns nil if the namespace of the SleepInfo is awake, i.e.
+// the last operation of the SleepInfo is not a sleep. Otherwise, it returns
+// an error.
+func CheckAwake(ctx context.Context, c client.Client, key client.ObjectKey) error {
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{}
+	if err := c.Get(ctx, key, sleepInfo); err != nil {
+		return fmt.Errorf("fails to get sleepinfo %s: %s", key, err)
+	}
+	if sleepInfo.Status.OperationType == schedule.Sleep {
+		return fmt.Errorf("namespace %s not awake: last operation of sleepinfo %s is %q", key.Namespace, key.Name, sleepInfo.Status.OperationType)
+	}
+	return nil
+}
+
+// AssertAsleep fails the test if the namespace of the SleepInfo is not
+// asleep, as checked by CheckAsleep.
+func AssertAsleep(t testing.TB, ctx context.Context, c client.Client, key client.ObjectKey) {
+	t.Helper()
+	if err := CheckAsleep(ctx, c, key); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertAwake fails the test if the namespace of the SleepInfo is not
+// awake, as checked by CheckAwake.
+func AssertAwake(t testing.TB, ctx context.Context, c client.Client, key client.ObjectKey) {
+	t.Helper()
+	if err := CheckAwake(ctx, c, key); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// getSleepReplicas returns the replicas the Deployment is scaled to by the
+// sleep of the SleepInfo. It returns false if the Deployment is not scaled
+// down by the sleep, e.g. because it is excluded or because of its sleep
+// strategy.
+func getSleepReplicas(sleepInfo kubegreenv1alpha1.SleepInfo, deployment *appsv1.Deployment) (int32, bool) {
+	if resource.IsExcluded("Deployment", deployment, sleepInfo.GetExcludeRef()) || !sleepInfo.IsManagedByClass(deployment.Labels) {
+		return 0, false
+	}
+	if _, ok := deployment.Annotations[resource.AwakeUntilAnnotation]; ok {
+		return 0, false
+	}
+	switch sleepInfo.GetSleepStrategy(deployment.Annotations) {
+	case kubegreenv1alpha1.SleepStrategyScaleToZero:
+		return sleepInfo.GetSleepReplicas(deployment.Labels), true
+	case kubegreenv1alpha1.SleepStrategyKeepOne:
+		return 1, true
+	default:
+		return 0, false
+	}
+}
+
+func getReplicas(deployment appsv1.Deployment) int32 {
+	if deployment.Spec.Replicas == nil {
+		return 1
+	}
+	return *deployment.Spec.Replicas
+}
@@ -0,0 +1,142 @@
+package testutil
+
+import (
+	"context"
+	"testing"
+
+	kubegreenv1alpha1 "github.com/kube-green/kube-green/api/v1alpha1"
+	"github.com/kube-green/kube-green/pkg/schedule"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCreateSleepInfo(t *testing.T) {
+	scheme := getScheme(t)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	sleepInfo := &kubegreenv1alpha1.SleepInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "working-hours", Namespace: "my-namespace"},
+	}
+
+	require.NoError(t, CreateSleepInfo(context.Background(), c, sleepInfo))
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: "my-namespace"}, &v1.Namespace{}))
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(sleepInfo), &kubegreenv1alpha1.SleepInfo{}))
+
+	t.Run("in an existing namespace", func(t *testing.T) {
+		other := &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: "nights", Namespace: "my-namespace"},
+		}
+		require.NoError(t, CreateSleepInfo(context.Background(), c, other))
+	})
+
+	t.Run("already exists", func(t *testing.T) {
+		require.ErrorContains(t, CreateSleepInfo(context.Background(), c, sleepInfo.DeepCopy()), "fails to create sleepinfo working-hours")
+	})
+}
+
+func TestCheckAsleepAndAwake(t *testing.T) {
+	scheme := getScheme(t)
+	namespace := "my-namespace"
+	key := client.ObjectKey{Name: "working-hours", Namespace: namespace}
+	getSleepInfo := func(operationType string, spec kubegreenv1alpha1.SleepInfoSpec) *kubegreenv1alpha1.SleepInfo {
+		return &kubegreenv1alpha1.SleepInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: namespace},
+			Spec:       spec,
+			Status:     kubegreenv1alpha1.SleepInfoStatus{OperationType: operationType},
+		}
+	}
+	getDeployment := func(name string, replicas int32, annotations map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Annotations: annotations},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		objects       []client.Object
+		expectedSleep string
+		expectedAwake string
+	}{
+		{
+			name: "asleep",
+			objects: []client.Object{
+				getSleepInfo(schedule.Sleep, kubegreenv1alpha1.SleepInfoSpec{}),
+				getDeployment("api", 0, nil),
+				getDeployment("canary", 1, map[string]string{kubegreenv1alpha1.SleepStrategyAnnotation: kubegreenv1alpha1.SleepStrategyKeepOne}),
+				getDeployment("paused", 3, map[string]string{kubegreenv1alpha1.SleepStrategyAnnotation: kubegreenv1alpha1.SleepStrategyPauseRollout}),
+			},
+			expectedAwake: `namespace my-namespace not awake: last operation of sleepinfo working-hours is "SLEEP"`,
+		},
+		{
+			name: "excluded deployments are not checked",
+			objects: []client.Object{
+				getSleepInfo(schedule.Sleep, kubegreenv1alpha1.SleepInfoSpec{
+					ExcludeRef: []kubegreenv1alpha1.ExcludeRef{{Kind: "Deployment", Name: "api"}},
+				}),
+				getDeployment("api", 2, nil),
+			},
+			expectedAwake: `namespace my-namespace not awake: last operation of sleepinfo working-hours is "SLEEP"`,
+		},
+		{
+			name: "deployment still running",
+			objects: []client.Object{
+				getSleepInfo(schedule.Sleep, kubegreenv1alpha1.SleepInfoSpec{}),
+				getDeployment("api", 2, nil),
+			},
+			expectedSleep: "namespace my-namespace not asleep: deployment api has 2 replicas, expected 0",
+			expectedAwake: `namespace my-namespace not awake: last operation of sleepinfo working-hours is "SLEEP"`,
+		},
+		{
+			name: "awake",
+			objects: []client.Object{
+				getSleepInfo(schedule.WakeUp, kubegreenv1alpha1.SleepInfoSpec{}),
+				getDeployment("api", 2, nil),
+			},
+			expectedSleep: `namespace my-namespace not asleep: last operation of sleepinfo working-hours is "WAKE_UP"`,
+		},
+		{
+			name: "never slept",
+			objects: []client.Object{
+				getSleepInfo("", kubegreenv1alpha1.SleepInfoSpec{}),
+			},
+			expectedSleep: `namespace my-namespace not asleep: last operation of sleepinfo working-hours is ""`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(test.objects...).Build()
+			requireError(t, test.expectedSleep, CheckAsleep(context.Background(), c, key))
+			requireError(t, test.expectedAwake, CheckAwake(context.Background(), c, key))
+		})
+	}
+
+	t.Run("sleepinfo not found", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		require.ErrorContains(t, CheckAsleep(context.Background(), c, key), "fails to get sleepinfo my-namespace/working-hours")
+		require.ErrorContains(t, CheckAwake(context.Background(), c, key), "fails to get sleepinfo my-namespace/working-hours")
+	})
+}
+
+func getScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1.AddToScheme(scheme))
+	require.NoError(t, appsv1.AddToScheme(scheme))
+	require.NoError(t, kubegreenv1alpha1.AddToScheme(scheme))
+	return scheme
+}
+
+func requireError(t *testing.T, expected string, err error) {
+	t.Helper()
+	if expected == "" {
+		require.NoError(t, err)
+		return
+	}
+	require.EqualError(t, err, expected)
+}
@@ -62,6 +62,18 @@ func TestValidationWebhook(t *testing.T) {
 				return ctx
 			},
 		},
+		{
+			Name: "mutate create - defaults and normalized fields",
+			Assessment: func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+				sleepInfo := getSleepInfo(t, ctx, sleepInfoName, c)
+
+				require.Equal(t, "08:00", sleepInfo.Spec.WakeUpTime)
+				require.NotEmpty(t, sleepInfo.Spec.TimeZone)
+				require.NotNil(t, sleepInfo.Spec.SuspendDeployments)
+				require.True(t, *sleepInfo.Spec.SuspendDeployments)
+				return ctx
+			},
+		},
 		{
 			Name: "validate create - ko",
 			Assessment: func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
@@ -78,7 +90,7 @@ func TestValidationWebhook(t *testing.T) {
 					Spec: kubegreenv1alpha1.SleepInfoSpec{},
 				}
 				err := k8sClient.Create(ctx, sleepInfo)
-				require.EqualError(t, err, "admission webhook \"vsleepinfo.kb.io\" denied the request: empty weekdays from SleepInfo configuration")
+				require.EqualError(t, err, "admission webhook \"vsleepinfo.kb.io\" denied the request: time should be of format HH:mm, actual: ")
 				return ctx
 			},
 		},
@@ -102,11 +114,11 @@ func TestValidationWebhook(t *testing.T) {
 				sleepInfo := getSleepInfo(t, ctx, sleepInfoName, c)
 
 				patch := client.MergeFrom(sleepInfo.DeepCopy())
-				sleepInfo.Spec.Weekdays = ""
+				sleepInfo.Spec.SleepTime = "19"
 
 				k8sClient := c.Client().Resources(c.Namespace()).GetControllerRuntimeClient()
 				err := k8sClient.Patch(ctx, sleepInfo, patch)
-				require.EqualError(t, err, "admission webhook \"vsleepinfo.kb.io\" denied the request: empty weekdays from SleepInfo configuration")
+				require.EqualError(t, err, "admission webhook \"vsleepinfo.kb.io\" denied the request: time should be of format HH:mm, actual: 19")
 				return ctx
 			},
 		},
@@ -130,9 +142,9 @@ func TestValidationWebhook(t *testing.T) {
 
 				sleepInfo := getSleepInfo(t, ctx, sleepInfoName, c)
 
-				sleepInfo.Spec.Weekdays = ""
+				sleepInfo.Spec.SleepTime = "19"
 				err := k8sClient.Update(ctx, sleepInfo)
-				require.EqualError(t, err, "admission webhook \"vsleepinfo.kb.io\" denied the request: empty weekdays from SleepInfo configuration")
+				require.EqualError(t, err, "admission webhook \"vsleepinfo.kb.io\" denied the request: time should be of format HH:mm, actual: 19")
 
 				return ctx
 			},